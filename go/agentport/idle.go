@@ -0,0 +1,61 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"time"
+)
+
+// touch records that a message was exchanged on the connection.
+func (ap *AgentPort) touch() {
+	ap.lastActive.Store(ap.clock.Now().UnixNano())
+}
+
+// LastActive returns the time at which the most recent message was exchanged
+// on the connection, or at which it was opened if none has been.
+func (ap *AgentPort) LastActive() time.Time {
+	return time.Unix(0, ap.lastActive.Load())
+}
+
+// Close disconnects the client and ends the connection. Any agent serving the
+// connection reads the end of its input, and stops.
+//
+// Close is used to end connections on the agent's initiative; Chrome does not
+// notify the agent of disconnection in this case.
+func (ap *AgentPort) Close() {
+	ap.p.Call("disconnect")
+	ap.OnDisconnect()
+}
+
+// CloseIdle closes and removes the connections on which no message has been
+// exchanged for at least timeout as of now; for example, those whose client
+// disappeared without disconnecting. The clients at the other end of the
+// closed connections are returned, ordered by the time at which they
+// connected.
+func (a AgentPorts) CloseIdle(now time.Time, timeout time.Duration) []Peer {
+	var closed []Peer
+	for p, ap := range a {
+		if now.Sub(ap.LastActive()) < timeout {
+			continue
+		}
+		ap.Close()
+		delete(a, p)
+		closed = append(closed, ap.peer)
+	}
+	sortPeers(closed)
+	return closed
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"errors"
+	"io"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCloseIdle(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	clk := fakes.NewClock(start)
+
+	// Fake chrome.runtime.Ports that record disconnection.
+	disconnected := map[string]bool{}
+	ports := AgentPorts{}
+	aps := map[string]*AgentPort{}
+	for _, id := range []string{"active", "idle"} {
+		id := id
+		disconnect := js.FuncOf(func(_ js.Value, _ []js.Value) any {
+			disconnected[id] = true
+			return nil
+		})
+		defer disconnect.Release()
+		port := js.Global().Get("Object").New()
+		port.Set("disconnect", disconnect)
+
+		ap := New(port, nil, clk)
+		defer ap.OnDisconnect()
+		ap.SetPeer(Peer{ID: id, Connected: start})
+		ports.Add(port, ap)
+		aps[id] = ap
+	}
+
+	// Only the active connection exchanges a message.
+	clk.Advance(30 * time.Minute)
+	go aps["active"].OnMessage(js.ValueOf(map[string]any{"type": messageType, "data": []any{11}}))
+	if _, err := io.ReadFull(aps["active"], make([]byte, 5)); err != nil {
+		t.Fatalf("failed to read request: %v", err)
+	}
+	if diff := cmp.Diff(aps["active"].LastActive(), clk.Now()); diff != "" {
+		t.Errorf("incorrect last activity; -got +want: %s", diff)
+	}
+
+	clk.Advance(40 * time.Minute)
+	closed := ports.CloseIdle(clk.Now(), time.Hour)
+	if diff := cmp.Diff(closed, []Peer{{ID: "idle", Connected: start}}); diff != "" {
+		t.Errorf("incorrect closed connections; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(disconnected, map[string]bool{"idle": true}); diff != "" {
+		t.Errorf("incorrect disconnected ports; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(ports.Peers(), []Peer{{ID: "active", Connected: start}}); diff != "" {
+		t.Errorf("incorrect remaining connections; -got +want: %s", diff)
+	}
+
+	// The agent serving the closed connection reads the end of its input.
+	if _, err := aps["idle"].Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("incorrect error reading closed connection: got %v, want %v", err, io.EOF)
+	}
+
+	// Nothing further is closed until the timeout elapses again.
+	if closed := ports.CloseIdle(clk.Now(), time.Hour); len(closed) > 0 {
+		t.Errorf("incorrect closed connections; got %v, want none", closed)
+	}
+	clk.Advance(20 * time.Minute)
+	closed = ports.CloseIdle(clk.Now(), time.Hour)
+	if diff := cmp.Diff(closed, []Peer{{ID: "active", Connected: start}}, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("incorrect closed connections; -got +want: %s", diff)
+	}
+}
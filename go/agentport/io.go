@@ -28,27 +28,41 @@ package agentport
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall/js"
+	"time"
 
+	"github.com/google/chrome-ssh-agent/go/clock"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
-	"github.com/norunners/vert"
+	"github.com/google/chrome-ssh-agent/go/metrics"
 )
 
 type AgentPort struct {
-	p         js.Value
-	inReader  *io.PipeReader // client -> agent pipe: agent read from incoming messages
-	inWriter  *io.PipeWriter // client -> agent pipe: write to agent
-	outReader *io.PipeReader // agent -> client pipe: read from agent
-	outWriter *io.PipeWriter // agent -> client pipe: agent write to outgoing messages
+	p          js.Value
+	inReader   *io.PipeReader    // client -> agent pipe: agent read from incoming messages
+	inWriter   *io.PipeWriter    // client -> agent pipe: write to agent
+	outReader  *io.PipeReader    // agent -> client pipe: read from agent
+	outWriter  *io.PipeWriter    // agent -> client pipe: agent write to outgoing messages
+	stats      *metrics.Registry // statistics for this connection
+	allStats   *metrics.Registry // statistics aggregated across connections; may be nil
+	peer       Peer              // client at the other end of the connection
+	binary     atomic.Bool       // client sends binary message data, and accepts it in return
+	clock      clock.Clock       // supplies the time of each message
+	lastActive atomic.Int64      // time of the most recent message, in Unix nanoseconds
 }
 
 // New returns a io.ReaderWriter that converts from the Chrome Secure Shell
 // Extension's SSH Agent protocol to the standard SSH Agent protocol.
 //
 // p is a Chrome Port object to which the Chrome Secure Shell Extension
-// has connected.
-func New(p js.Value) *AgentPort {
+// has connected. Statistics for the connection are also aggregated in
+// allStats, if non-nil. clk supplies the time of each message, such that
+// idle connections can be closed.
+func New(p js.Value, allStats *metrics.Registry, clk clock.Clock) *AgentPort {
 	jsutil.LogDebug("AgentPort.New")
 	ir, iw := io.Pipe()
 	or, ow := io.Pipe()
@@ -58,7 +72,11 @@ func New(p js.Value) *AgentPort {
 		inWriter:  iw,
 		outReader: or,
 		outWriter: ow,
+		stats:     metrics.NewRegistry(),
+		allStats:  allStats,
+		clock:     clk,
 	}
+	ap.touch()
 
 	jsutil.LogDebug("AgentPort.New: Initiating SendMessages loop")
 	go ap.SendMessages()
@@ -73,30 +91,123 @@ func (ap *AgentPort) OnDisconnect() {
 	ap.outWriter.Close()
 }
 
-type message struct {
-	Data []int  `js:"data"`
-	Type string `js:"type"`
+var (
+	// array refers to Javascript's Array class.
+	array = js.Global().Get("Array")
+	// arrayBuffer refers to Javascript's ArrayBuffer class.
+	arrayBuffer = js.Global().Get("ArrayBuffer")
+	// object refers to Javascript's Object class.
+	object = js.Global().Get("Object")
+	// uint8Array refers to Javascript's Uint8Array class.
+	uint8Array = js.Global().Get("Uint8Array")
+)
+
+// maxPooledBuffer is the capacity of the largest buffer retained in
+// bufferPool: OpenSSH's limit on the length of an agent message, plus its
+// length prefix. Larger buffers are not retained, so that an exceptionally
+// large message does not remain allocated indefinitely.
+const maxPooledBuffer = 4 + 256*1024
+
+// bufferPool holds buffers for framing messages, so that exchanging many
+// messages (e.g., when signing many requests) does not churn the heap.
+var bufferPool = sync.Pool{
+	New: func() any { return new([]byte) },
+}
+
+// getBuffer returns a buffer of length n from bufferPool. It must be returned
+// using putBuffer once it is no longer used.
+func getBuffer(n int) *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	*b = (*b)[:n]
+	return b
+}
+
+// putBuffer returns a buffer obtained from getBuffer to bufferPool.
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// dataArray returns a Uint8Array holding the data of a message from the
+// client, such that it can be copied in bulk rather than element by element.
+// Clients in the same context may send either an ArrayBuffer or Uint8Array,
+// in which case bin is true. Otherwise, clients send an array of integers as
+// sent by the Chrome Secure Shell Extension, or a Uint8Array that
+// chrome.runtime.Port serialized as JSON into an object keyed by index.
+func dataArray(val js.Value) (arr js.Value, bin bool, err error) {
+	switch {
+	case val.InstanceOf(uint8Array):
+		return val, true, nil
+	case val.InstanceOf(arrayBuffer):
+		return uint8Array.New(val), true, nil
+	case array.Call("isArray", val).Bool():
+		return uint8Array.New(val), false, nil
+	case val.Type() == js.TypeObject:
+		arr, err := serializedArray(val)
+		return arr, false, err
+	default:
+		return js.Undefined(), false, errors.New("message data is not an array")
+	}
+}
+
+// serializedArray returns a Uint8Array holding the data of a Uint8Array that
+// was serialized as JSON, which yields an object whose keys are the indices
+// of the elements.
+func serializedArray(val js.Value) (js.Value, error) {
+	keys := object.Call("keys", val)
+	n := keys.Length()
+	// Integer keys are enumerated first and in ascending order, so the
+	// keys are exactly the indices 0 to n-1 only if the last is n-1.
+	if n == 0 || keys.Index(n-1).String() != strconv.Itoa(n-1) {
+		return js.Undefined(), errors.New("message data is not an array")
+	}
+	return object.Call("assign", uint8Array.New(n), val), nil
+}
+
+// encodeData returns the data of a message to the client. If bin is true, the
+// data is a Uint8Array; otherwise, it is an array of integers as expected
+// by the Chrome Secure Shell Extension. A Uint8Array is only preserved when
+// posted within the same context; chrome.runtime.Port serializes it as an
+// object keyed by index.
+func encodeData(data []byte, bin bool) js.Value {
+	arr := jsutil.BytesToJS(data)
+	if bin {
+		return arr
+	}
+	return array.Call("from", arr)
 }
 
 func (ap *AgentPort) OnMessage(msg js.Value) {
 	jsutil.LogDebug("AgentPort.OnMessage: parsing message from client to agent")
-	var parsed message
-	if err := vert.ValueOf(msg).AssignTo(&parsed); err != nil {
+	arr, bin, err := dataArray(msg.Get("data"))
+	if err != nil {
 		jsutil.LogError("Failed to parse message to agent: %v; message=%s", err, msg)
 		ap.p.Call("disconnect")
 		return
 	}
+	// Reply in the same format the client used.
+	ap.binary.Store(bin)
+	ap.touch()
 
 	jsutil.LogDebug("AgentPort.OnMessage: converting to bytestream")
-	framed := make([]byte, 4+len(parsed.Data))
-	binary.BigEndian.PutUint32(framed, uint32(len(parsed.Data)))
-	for i, raw := range parsed.Data {
-		framed[i+4] = byte(raw)
-	}
+	length := arr.Length()
+	buf := getBuffer(4 + length)
+	defer putBuffer(buf)
+	framed := *buf
+	binary.BigEndian.PutUint32(framed, uint32(length))
+	js.CopyBytesToGo(framed[4:], arr)
 
+	ap.observe(messageMetric("in", framed[4:]), length, 0)
+
+	// The pipe returns only once the agent has read the entire message,
+	// so the buffer may then be reused.
 	jsutil.LogDebug("AgentPort.OnMessage: writing to agent")
-	_, err := ap.inWriter.Write(framed)
-	if err != nil {
+	if _, err := ap.inWriter.Write(framed); err != nil {
 		jsutil.LogError("Error writing to pipe: %v", err)
 		ap.p.Call("disconnect")
 	}
@@ -105,7 +216,10 @@ func (ap *AgentPort) OnMessage(msg js.Value) {
 func (ap *AgentPort) Read(p []byte) (n int, err error) {
 	jsutil.LogDebug("AgentPort.Read: agent reading from client")
 	defer jsutil.LogDebug("AgentPort.Read: read finished")
-	return ap.inReader.Read(p)
+	start := time.Now()
+	n, err = ap.inReader.Read(p)
+	ap.observe(readMetric, n, time.Since(start))
+	return n, err
 }
 
 const (
@@ -117,9 +231,9 @@ const (
 func (ap *AgentPort) SendMessages() {
 	jsutil.LogDebug("AgentPort.SendMessages: starting loop")
 	defer jsutil.LogDebug("AgentPort.SendMessages: finished loop")
+	l := make([]byte, 4)
 	for {
 		jsutil.LogDebug("AgentPort.SendMessages: reading message length from agent to client")
-		l := make([]byte, 4)
 		_, err := io.ReadFull(ap.outReader, l)
 		if err != nil {
 			jsutil.Log("AgentPort.SendMessages: Error reading from pipe: %v", err)
@@ -129,31 +243,37 @@ func (ap *AgentPort) SendMessages() {
 		length := binary.BigEndian.Uint32(l)
 
 		jsutil.LogDebug("AgentPort.SendMessages: reading message from agent to client")
-		data := make([]byte, length)
-		_, err = io.ReadFull(ap.outReader, data)
+		buf := getBuffer(int(length))
+		_, err = io.ReadFull(ap.outReader, *buf)
 		if err != nil {
+			putBuffer(buf)
 			jsutil.Log("AgentPort.SendMessages: Error reading from pipe: %v", err)
 			ap.outReader.Close()
 			return
 		}
 
+		// The data is copied when encoded, so the buffer may then be
+		// reused.
 		jsutil.LogDebug("AgentPort.SendMessages: encoding message from agent to client")
-		var encoded message
-		encoded.Type = messageType
-		encoded.Data = make([]int, len(data))
-		for i, b := range data {
-			encoded.Data[i] = int(b)
-		}
+		ap.observe(messageMetric("out", *buf), len(*buf), 0)
+		encoded := jsutil.NewObject()
+		encoded.Set("type", messageType)
+		encoded.Set("data", encodeData(*buf, ap.binary.Load()))
+		putBuffer(buf)
 
 		jsutil.LogDebug("AgentPort.SendMessages: sending message to client")
-		ap.p.Call("postMessage", vert.ValueOf(encoded).JSValue())
+		ap.p.Call("postMessage", encoded)
+		ap.touch()
 	}
 }
 
 func (ap *AgentPort) Write(p []byte) (n int, err error) {
 	jsutil.LogDebug("AgentPort.Write: agent writing to client")
 	defer jsutil.LogDebug("AgentPort.Write: write finished")
-	return ap.outWriter.Write(p)
+	start := time.Now()
+	n, err = ap.outWriter.Write(p)
+	ap.observe(writeMetric, n, time.Since(start))
+	return n, err
 }
 
 type portRef struct {
@@ -0,0 +1,233 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/go-cmp/cmp"
+	"github.com/norunners/vert"
+)
+
+// intMessage is a message whose data is an array of integers, decoded as the
+// agent did before transferring binary data.
+type intMessage struct {
+	Data []int  `js:"data"`
+	Type string `js:"type"`
+}
+
+func TestMessageFormats(t *testing.T) {
+	t.Parallel()
+
+	request := []byte{11, 0, 255}
+	response := []byte{12, 0, 0, 0, 0}
+
+	testcases := []struct {
+		description string
+		data        js.Value
+		wantBinary  bool
+	}{
+		{
+			description: "array of integers",
+			data:        js.ValueOf([]any{11, 0, 255}),
+		},
+		{
+			description: "Uint8Array",
+			data:        jsutil.BytesToJS(request),
+			wantBinary:  true,
+		},
+		{
+			description: "ArrayBuffer",
+			data:        jsutil.BytesToJS(request).Get("buffer"),
+			wantBinary:  true,
+		},
+		{
+			description: "serialized Uint8Array",
+			data:        js.Global().Get("JSON").Call("parse", js.Global().Get("JSON").Call("stringify", jsutil.BytesToJS(request))),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			// Fake chrome.runtime.Port that forwards posted messages.
+			posted := make(chan js.Value, 1)
+			post := js.FuncOf(func(_ js.Value, args []js.Value) any {
+				posted <- args[0]
+				return nil
+			})
+			defer post.Release()
+			port := js.Global().Get("Object").New()
+			port.Set("postMessage", post)
+
+			ap := New(port, nil, clock.Real)
+			defer ap.OnDisconnect()
+
+			// Agent reads the framed request.
+			msg := jsutil.NewObject()
+			msg.Set("type", messageType)
+			msg.Set("data", tc.data)
+			go ap.OnMessage(msg)
+			got := make([]byte, 4+len(request))
+			if _, err := io.ReadFull(ap, got); err != nil {
+				t.Fatalf("failed to read request: %v", err)
+			}
+			if diff := cmp.Diff(got, append([]byte{0, 0, 0, 3}, request...)); diff != "" {
+				t.Errorf("incorrect request; -got +want: %s", diff)
+			}
+
+			// Client receives the response in the same format as the
+			// request.
+			if _, err := ap.Write(append([]byte{0, 0, 0, byte(len(response))}, response...)); err != nil {
+				t.Fatalf("failed to write response: %v", err)
+			}
+			rsp := <-posted
+			if diff := cmp.Diff(rsp.Get("type").String(), messageType); diff != "" {
+				t.Errorf("incorrect response type; -got +want: %s", diff)
+			}
+			arr, bin, err := dataArray(rsp.Get("data"))
+			if err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(jsutil.BytesFromJS(arr), response); diff != "" {
+				t.Errorf("incorrect response; -got +want: %s", diff)
+			}
+			if bin != tc.wantBinary {
+				t.Errorf("incorrect response format: got binary=%v, want binary=%v", bin, tc.wantBinary)
+			}
+
+			// The response remains decodable as an array of
+			// integers by clients that expect one.
+			if !tc.wantBinary {
+				var legacy intMessage
+				if err := vert.ValueOf(rsp).AssignTo(&legacy); err != nil {
+					t.Fatalf("failed to parse response as integers: %v", err)
+				}
+				if diff := cmp.Diff(legacy.Data, []int{12, 0, 0, 0, 0}); diff != "" {
+					t.Errorf("incorrect response integers; -got +want: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestDataArrayInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, val := range []js.Value{
+		js.Undefined(),
+		js.ValueOf("abc"),
+		js.ValueOf(11),
+		js.ValueOf(map[string]any{}),
+		js.ValueOf(map[string]any{"1": 2}),
+		js.ValueOf(map[string]any{"0": 1, "foo": 2}),
+	} {
+		if _, _, err := dataArray(val); err == nil {
+			t.Errorf("dataArray(%v) succeeded; want error", val)
+		}
+	}
+}
+
+func TestGetBuffer(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 5, 1024, 4} {
+		buf := getBuffer(n)
+		if len(*buf) != n {
+			t.Errorf("getBuffer(%d) returned buffer of length %d", n, len(*buf))
+		}
+		putBuffer(buf)
+	}
+}
+
+// benchmarkSizes are the message sizes for which decoding and encoding are
+// benchmarked: a typical request, and a large identities answer.
+var benchmarkSizes = []int{64, 64 * 1024}
+
+// BenchmarkDecode compares decoding and framing messages from the client in
+// bulk against the former element-by-element decoding of an array of
+// integers.
+func BenchmarkDecode(b *testing.B) {
+	port := js.Global().Get("Object").New()
+	ap := New(port, nil, clock.Real)
+	defer ap.OnDisconnect()
+	go io.Copy(io.Discard, ap)
+
+	for _, size := range benchmarkSizes {
+		data := bytes.Repeat([]byte{0xab}, size)
+		ints := jsutil.NewObject()
+		ints.Set("data", encodeData(data, false))
+		bin := jsutil.NewObject()
+		bin.Set("data", encodeData(data, true))
+
+		b.Run(fmt.Sprintf("elementwise/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var parsed intMessage
+				if err := vert.ValueOf(ints).AssignTo(&parsed); err != nil {
+					b.Fatalf("failed to parse: %v", err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("array/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ap.OnMessage(ints)
+			}
+		})
+		b.Run(fmt.Sprintf("binary/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ap.OnMessage(bin)
+			}
+		})
+	}
+}
+
+// BenchmarkEncode compares encoding message data in bulk against the former
+// element-by-element encoding of an array of integers.
+func BenchmarkEncode(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		data := bytes.Repeat([]byte{0xab}, size)
+
+		b.Run(fmt.Sprintf("elementwise/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				msg := intMessage{Type: messageType, Data: make([]int, len(data))}
+				for j, d := range data {
+					msg.Data[j] = int(d)
+				}
+				vert.ValueOf(msg).JSValue()
+			}
+		})
+		b.Run(fmt.Sprintf("array/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				encodeData(data, false)
+			}
+		})
+		b.Run(fmt.Sprintf("binary/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				encodeData(data, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"encoding/binary"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Loopback connects a client within the extension to an agent served on an
+// AgentPort. Messages pass through the AgentPort exactly as they would from
+// a client connected using a chrome.runtime.Port, such that the path taken by
+// other extensions' requests can be tested.
+//
+// Loopback implements io.ReadWriter for the client, which exchanges messages
+// in the standard SSH Agent protocol (e.g., using agent.NewClient).
+type Loopback struct {
+	// Port is the agent's end of the connection; the agent must be served
+	// on it (e.g., using agent.ServeAgent).
+	Port *AgentPort
+
+	post       js.Func
+	disconnect js.Func
+	responses  chan []byte
+	request    []byte // framed request data written by the client, but not yet sent
+	response   []byte // framed response data not yet read by the client
+}
+
+// NewLoopback returns a new Loopback. clk supplies the time of each message.
+// Close must be invoked once the connection is no longer needed.
+func NewLoopback(clk clock.Clock) *Loopback {
+	// The agent responds to each request before the client sends the
+	// next, so at most one response is outstanding.
+	l := &Loopback{responses: make(chan []byte, 1)}
+	l.post = js.FuncOf(func(_ js.Value, args []js.Value) any {
+		arr, _, err := dataArray(args[0].Get("data"))
+		if err != nil {
+			jsutil.LogError("Loopback: failed to parse message from agent: %v", err)
+			return nil
+		}
+		framed := make([]byte, 4+arr.Length())
+		binary.BigEndian.PutUint32(framed, uint32(arr.Length()))
+		js.CopyBytesToGo(framed[4:], arr)
+		l.responses <- framed
+		return nil
+	})
+	// The connection is only closed by Close.
+	l.disconnect = js.FuncOf(func(js.Value, []js.Value) any { return nil })
+
+	port := jsutil.NewObject()
+	port.Set("postMessage", l.post)
+	port.Set("disconnect", l.disconnect)
+	l.Port = New(port, nil, clk)
+	return l
+}
+
+// Write sends framed requests from the client to the agent. Requests are
+// sent once complete; they may be written in several parts.
+func (l *Loopback) Write(p []byte) (int, error) {
+	l.request = append(l.request, p...)
+	for len(l.request) >= 4 {
+		length := int(binary.BigEndian.Uint32(l.request))
+		if len(l.request) < 4+length {
+			break
+		}
+		msg := jsutil.NewObject()
+		msg.Set("type", messageType)
+		msg.Set("data", jsutil.BytesToJS(l.request[4:4+length]))
+		l.request = l.request[4+length:]
+		l.Port.OnMessage(msg)
+	}
+	return len(p), nil
+}
+
+// Read receives framed responses from the agent to the client.
+func (l *Loopback) Read(p []byte) (int, error) {
+	if len(l.response) == 0 {
+		l.response = <-l.responses
+	}
+	n := copy(p, l.response)
+	l.response = l.response[n:]
+	return n, nil
+}
+
+// Close ends the connection. Any agent serving the connection reads the end
+// of its input, and stops. Close may be invoked more than once.
+func (l *Loopback) Close() error {
+	l.Port.OnDisconnect()
+	l.post.Release()
+	l.disconnect.Release()
+	return nil
+}
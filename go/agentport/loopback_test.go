@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestLoopback(t *testing.T) {
+	t.Parallel()
+
+	keyring := agent.NewKeyring()
+	l := NewLoopback(clock.Real)
+	defer l.Close()
+	served := make(chan error, 1)
+	go func() { served <- agent.ServeAgent(keyring, l.Port) }()
+
+	// The client's requests reach the agent, and the agent's responses
+	// reach the client.
+	client := agent.NewClient(l)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if err := client.Add(agent.AddedKey{PrivateKey: priv, Comment: "loopback"}); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+	listed, err := keyring.List()
+	if err != nil || len(listed) != 1 || listed[0].Comment != "loopback" {
+		t.Errorf("key not added to agent: got (%v, %v), want key with comment %q", listed, err, "loopback")
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to convert public key: %v", err)
+	}
+	data := []byte("data to sign")
+	sig, err := client.Sign(sshPub, data)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if err := sshPub.Verify(data, sig); err != nil {
+		t.Errorf("failed to verify signature: %v", err)
+	}
+
+	// Closing the connection stops the agent.
+	l.Close()
+	<-served
+}
@@ -0,0 +1,65 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"sort"
+	"time"
+)
+
+// Peer describes the client at the other end of a connection.
+type Peer struct {
+	// ID identifies the client: the ID of the extension, or the origin of
+	// the web application, that opened the connection.
+	ID string
+	// Origin is the origin of the page that opened the connection, or
+	// empty if it is not known.
+	Origin string
+	// Connected is the time at which the connection was opened.
+	Connected time.Time
+}
+
+// SetPeer records the client at the other end of the connection.
+func (ap *AgentPort) SetPeer(p Peer) {
+	ap.peer = p
+}
+
+// Peer returns the client at the other end of the connection.
+func (ap *AgentPort) Peer() Peer {
+	return ap.peer
+}
+
+// Peers returns the clients at the other end of each connection, ordered by
+// the time at which they connected.
+func (a AgentPorts) Peers() []Peer {
+	var result []Peer
+	for _, ap := range a {
+		result = append(result, ap.peer)
+	}
+	sortPeers(result)
+	return result
+}
+
+// sortPeers orders peers by the time at which they connected.
+func sortPeers(peers []Peer) {
+	sort.Slice(peers, func(i, j int) bool {
+		if !peers[i].Connected.Equal(peers[j].Connected) {
+			return peers[i].Connected.Before(peers[j].Connected)
+		}
+		return peers[i].ID < peers[j].ID
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPeers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	peers := []Peer{
+		{ID: "later", Origin: "https://later.example.com", Connected: now.Add(time.Minute)},
+		{ID: "earlier", Origin: "chrome-extension://earlier", Connected: now},
+		{ID: "same-time", Connected: now},
+	}
+
+	ports := AgentPorts{}
+	var values []js.Value
+	for _, p := range peers {
+		port := js.Global().Get("Object").New()
+		ap := New(port, nil, clock.Real)
+		defer ap.OnDisconnect()
+		ap.SetPeer(p)
+		ports.Add(port, ap)
+		values = append(values, port)
+	}
+
+	want := []Peer{peers[1], peers[2], peers[0]}
+	if diff := cmp.Diff(ports.Peers(), want); diff != "" {
+		t.Errorf("incorrect peers; -got +want: %s", diff)
+	}
+
+	ports.Delete(values[1])
+	want = []Peer{peers[2], peers[0]}
+	if diff := cmp.Diff(ports.Peers(), want); diff != "" {
+		t.Errorf("incorrect peers after disconnect; -got +want: %s", diff)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/metrics"
+)
+
+const (
+	// readMetric is the time spent by the agent reading from the client.
+	readMetric = "agentport.read"
+	// writeMetric is the time spent by the agent writing to the client.
+	writeMetric = "agentport.write"
+)
+
+// messageTypeNames are the names of SSH Agent protocol message types. See:
+//
+//	https://datatracker.ietf.org/doc/html/draft-miller-ssh-agent#section-5.1
+var messageTypeNames = map[byte]string{
+	5:  "failure",
+	6:  "success",
+	11: "request-identities",
+	12: "identities-answer",
+	13: "sign-request",
+	14: "sign-response",
+	17: "add-identity",
+	18: "remove-identity",
+	19: "remove-all-identities",
+	20: "add-smartcard-key",
+	21: "remove-smartcard-key",
+	22: "lock",
+	23: "unlock",
+	25: "add-id-constrained",
+	26: "add-smartcard-key-constrained",
+	27: "extension",
+	28: "extension-failure",
+}
+
+// messageMetric returns the name of the metric for a message in the specified
+// direction ("in" from the client, or "out" to the client). The message type
+// is the first byte of the message data.
+func messageMetric(direction string, data []byte) string {
+	name := "empty"
+	if len(data) > 0 {
+		t := data[0]
+		var ok bool
+		if name, ok = messageTypeNames[t]; !ok {
+			name = fmt.Sprintf("type-%d", t)
+		}
+	}
+	return fmt.Sprintf("agentport.%s.%s", direction, name)
+}
+
+// observe records an observation for the connection, and in the aggregate
+// statistics.
+func (ap *AgentPort) observe(name string, bytes int, d time.Duration) {
+	ap.stats.Observe(name, bytes, d)
+	if ap.allStats != nil {
+		ap.allStats.Observe(name, bytes, d)
+	}
+}
+
+// Stats returns the statistics for the connection.
+func (ap *AgentPort) Stats() map[string]metrics.Stat {
+	return ap.stats.Snapshot()
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentport
+
+import (
+	"io"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/metrics"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	// Fake chrome.runtime.Port that forwards posted messages.
+	posted := make(chan js.Value, 1)
+	post := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		posted <- args[0]
+		return nil
+	})
+	defer post.Release()
+	port := js.Global().Get("Object").New()
+	port.Set("postMessage", post)
+
+	all := metrics.NewRegistry()
+	ap := New(port, all, clock.Real)
+	defer ap.OnDisconnect()
+
+	// Client requests identities; agent reads the framed request.
+	go ap.OnMessage(js.ValueOf(map[string]any{"type": messageType, "data": []any{11}}))
+	req := make([]byte, 5)
+	if _, err := io.ReadFull(ap, req); err != nil {
+		t.Fatalf("failed to read request: %v", err)
+	}
+
+	// Agent responds; client receives the unframed response.
+	if _, err := ap.Write([]byte{0, 0, 0, 1, 12}); err != nil {
+		t.Fatalf("failed to write response: %v", err)
+	}
+	<-posted
+
+	want := map[string]metrics.Stat{
+		"agentport.in.request-identities": {Count: 1, Bytes: 1},
+		"agentport.out.identities-answer": {Count: 1, Bytes: 1},
+		"agentport.read":                  {Count: 1, Bytes: 5},
+		"agentport.write":                 {Count: 1, Bytes: 5},
+	}
+	ignoreDuration := cmpopts.IgnoreFields(metrics.Stat{}, "Duration")
+	if diff := cmp.Diff(ap.Stats(), want, ignoreDuration); diff != "" {
+		t.Errorf("incorrect connection stats; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(all.Snapshot(), want, ignoreDuration); diff != "" {
+		t.Errorf("incorrect aggregate stats; -got +want: %s", diff)
+	}
+}
+
+func TestMessageMetric(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		data        []byte
+		want        string
+	}{
+		{
+			description: "known type",
+			data:        []byte{13, 1, 2},
+			want:        "agentport.out.sign-request",
+		},
+		{
+			description: "unknown type",
+			data:        []byte{200},
+			want:        "agentport.out.type-200",
+		},
+		{
+			description: "empty",
+			want:        "agentport.out.empty",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(messageMetric("out", tc.data), tc.want); diff != "" {
+				t.Errorf("incorrect metric; -got +want: %s", diff)
+			}
+		})
+	}
+}
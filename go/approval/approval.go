@@ -0,0 +1,211 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package approval decides whether clients (e.g., other extensions) may
+// connect to the agent.
+//
+// When enabled in settings, the user is asked to approve each new client the
+// first time it connects. The user's decision is remembered in the client's
+// record (see package clients), and applied to subsequent connections from
+// the same client. A client whose access the user revoked may not connect.
+//
+// The user may also restrict the extensions that can connect to those they
+// explicitly allow.
+package approval
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/lock"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Prompter asks the user whether a client may connect to the agent.
+type Prompter interface {
+	// Prompt asks the user whether the specified client may connect.
+	// decided is false if the user did not make a decision (e.g., they
+	// dismissed the prompt).
+	Prompt(ctx jsutil.AsyncContext, client string) (allowed, decided bool, err error)
+}
+
+// legacyDecision is the user's decision for a single client, as persisted
+// by earlier versions of the extension. Decisions are now kept in the
+// client's record; legacy decisions are migrated when the client next
+// connects.
+type legacyDecision struct {
+	Client  string `js:"client"`
+	Allowed bool   `js:"allowed"`
+}
+
+var (
+	// legacyDecisionPrefixes are the prefixes used for keys when storing
+	// legacy decisions.
+	legacyDecisionPrefixes = []string{"approval"}
+)
+
+const (
+	// lockResourceID is the resource held while deciding whether a client
+	// may connect, so that concurrent connections from the same client
+	// result in a single prompt.
+	lockResourceID = "approval-lock"
+)
+
+// Gate decides whether clients may connect to the agent.
+type Gate struct {
+	settings *settings.Store
+	clients  *clients.Store
+	legacy   *storage.Typed[legacyDecision]
+	prompter Prompter
+	clock    clock.Clock
+}
+
+// NewGate returns a new Gate. Each client that connects is recorded, along
+// with the user's decision, in the supplied storage area (see
+// clients.Store). clk supplies the time at which clients connect.
+func NewGate(settings *settings.Store, store storage.Area, prompter Prompter, clk clock.Clock) *Gate {
+	return &Gate{
+		settings: settings,
+		clients:  clients.NewStore(store),
+		legacy:   storage.NewTyped[legacyDecision](store, legacyDecisionPrefixes),
+		prompter: prompter,
+		clock:    clk,
+	}
+}
+
+// Allow returns true if the client may connect to the agent. Clients whose
+// access the user revoked may not connect. Otherwise, if the user must
+// approve new clients and has not yet decided for this client, they are
+// prompted.
+func (g *Gate) Allow(ctx jsutil.AsyncContext, client string) (bool, error) {
+	return g.locked(ctx, func(ctx jsutil.AsyncContext) (bool, error) {
+		return g.allow(ctx, client)
+	})
+}
+
+// AllowExtension returns true if the extension with the specified ID may
+// connect to the agent. If the user configured the extensions that may
+// connect, others may not; otherwise, the extension is treated as for Allow.
+func (g *Gate) AllowExtension(ctx jsutil.AsyncContext, id string) (bool, error) {
+	return g.locked(ctx, func(ctx jsutil.AsyncContext) (bool, error) {
+		return g.allowExtension(ctx, id)
+	})
+}
+
+// locked invokes f while holding the lock used to serialize decisions.
+func (g *Gate) locked(ctx jsutil.AsyncContext, f func(ctx jsutil.AsyncContext) (bool, error)) (bool, error) {
+	var allowed bool
+	err := lock.WithLock(ctx, lockResourceID, func(ctx jsutil.AsyncContext) error {
+		var err error
+		allowed, err = f(ctx)
+		return err
+	})
+	return allowed, err
+}
+
+func (g *Gate) allow(ctx jsutil.AsyncContext, client string) (bool, error) {
+	s, err := g.settings.Get(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read settings: %w", err)
+	}
+	return g.decide(ctx, client, s.ApproveNewClients)
+}
+
+func (g *Gate) allowExtension(ctx jsutil.AsyncContext, id string) (bool, error) {
+	s, err := g.settings.Get(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read settings: %w", err)
+	}
+	if !s.ExtensionAllowed(id) {
+		jsutil.Log("Gate.allowExtension: extension %s not in allowed extensions", id)
+		return false, nil
+	}
+	return g.decide(ctx, id, s.ApproveNewClients)
+}
+
+// decide records that the client connected, and returns the user's decision
+// for it. If the user has not decided and approval is required, they are
+// prompted.
+func (g *Gate) decide(ctx jsutil.AsyncContext, client string, requireApproval bool) (bool, error) {
+	c, err := g.clients.Seen(ctx, client, g.clock.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to record client: %w", err)
+	}
+	if c.Decision == clients.Undecided {
+		if c.Decision, err = g.migrate(ctx, client); err != nil {
+			return false, err
+		}
+	}
+
+	switch c.Decision {
+	case clients.Allowed:
+		jsutil.LogDebug("Gate.decide: client %s previously allowed", client)
+		return true, nil
+	case clients.Denied:
+		jsutil.LogDebug("Gate.decide: client %s previously denied", client)
+		return false, nil
+	}
+	if !requireApproval {
+		return true, nil
+	}
+
+	jsutil.LogDebug("Gate.decide: prompting for client %s", client)
+	allowed, decided, err := g.prompter.Prompt(ctx, c.DisplayName())
+	if err != nil {
+		return false, fmt.Errorf("failed to prompt for approval: %w", err)
+	}
+	if !decided {
+		// Deny this connection, but ask again next time.
+		return false, nil
+	}
+
+	d := clients.Denied
+	if allowed {
+		d = clients.Allowed
+	}
+	if err := g.clients.Update(ctx, client, func(c *clients.Client) { c.Decision = d }); err != nil {
+		return false, fmt.Errorf("failed to write decision: %w", err)
+	}
+	return allowed, nil
+}
+
+// migrate moves the legacy decision for the client, if any, into the
+// client's record. The migrated decision is returned.
+func (g *Gate) migrate(ctx jsutil.AsyncContext, client string) (string, error) {
+	match := func(d *legacyDecision) bool { return d.Client == client }
+	d, err := g.legacy.Read(ctx, match)
+	if err != nil {
+		return clients.Undecided, fmt.Errorf("failed to read decision: %w", err)
+	}
+	if d == nil {
+		return clients.Undecided, nil
+	}
+
+	result := clients.Denied
+	if d.Allowed {
+		result = clients.Allowed
+	}
+	if err := g.clients.Update(ctx, client, func(c *clients.Client) { c.Decision = result }); err != nil {
+		return clients.Undecided, fmt.Errorf("failed to migrate decision: %w", err)
+	}
+	if err := g.legacy.Delete(ctx, match); err != nil {
+		jsutil.LogError("Gate.migrate: failed to remove legacy decision for client %s: %v", client, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,380 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approval
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	cfakes "github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// fakePrompter answers prompts with a fixed response, and records the clients
+// for which it was prompted.
+type fakePrompter struct {
+	allowed bool
+	decided bool
+	prompts []string
+}
+
+func (f *fakePrompter) Prompt(ctx jsutil.AsyncContext, client string) (bool, bool, error) {
+	f.prompts = append(f.prompts, client)
+	return f.allowed, f.decided, nil
+}
+
+func TestAllow(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		settings    *settings.Settings
+		managed     map[string]js.Value
+		prompter    *fakePrompter
+		clients     []string
+		wantAllowed []bool
+		wantPrompts []string
+	}{
+		{
+			description: "approval disabled",
+			settings:    &settings.Settings{ApproveNewClients: false},
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			clients:     []string{"client-1", "client-2"},
+			wantAllowed: []bool{true, true},
+		},
+		{
+			description: "allow remembered",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			clients:     []string{"client-1", "client-1"},
+			wantAllowed: []bool{true, true},
+			wantPrompts: []string{"client-1"},
+		},
+		{
+			description: "deny remembered",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			clients:     []string{"client-1", "client-1"},
+			wantAllowed: []bool{false, false},
+			wantPrompts: []string{"client-1"},
+		},
+		{
+			description: "decisions are per-client",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			clients:     []string{"client-1", "client-2", "client-1"},
+			wantAllowed: []bool{true, true, true},
+			wantPrompts: []string{"client-1", "client-2"},
+		},
+		{
+			description: "dismissed prompt denies and asks again",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			prompter:    &fakePrompter{decided: false},
+			clients:     []string{"client-1", "client-1"},
+			wantAllowed: []bool{false, false},
+			wantPrompts: []string{"client-1", "client-1"},
+		},
+		{
+			description: "approval enabled by policy",
+			settings:    &settings.Settings{ApproveNewClients: false},
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(true),
+			},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			clients:     []string{"client-1"},
+			wantAllowed: []bool{true},
+			wantPrompts: []string{"client-1"},
+		},
+		{
+			description: "approval disabled by policy",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(false),
+			},
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			clients:     []string{"client-1"},
+			wantAllowed: []bool{true},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				managed := fakes.NewManaged()
+				managed.SetPolicy(tc.managed)
+				ss := settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+				if err := ss.Set(ctx, tc.settings); err != nil {
+					t.Fatalf("failed to initialize settings: %v", err)
+				}
+
+				g := NewGate(ss, storage.NewRaw(st.NewMemArea()), tc.prompter, clock.Real)
+				var gotAllowed []bool
+				for _, c := range tc.clients {
+					allowed, err := g.Allow(ctx, c)
+					if err != nil {
+						t.Fatalf("Allow(%s) failed: %v", c, err)
+					}
+					gotAllowed = append(gotAllowed, allowed)
+				}
+
+				if diff := cmp.Diff(gotAllowed, tc.wantAllowed); diff != "" {
+					t.Errorf("incorrect allowed; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(tc.prompter.prompts, tc.wantPrompts); diff != "" {
+					t.Errorf("incorrect prompts; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestAllowExtension(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		settings    *settings.Settings
+		prompter    *fakePrompter
+		extensions  []string
+		wantAllowed []bool
+		wantPrompts []string
+		wantClients []string
+	}{
+		{
+			description: "any extension allowed by default",
+			settings:    &settings.Settings{ApproveNewClients: false},
+			prompter:    &fakePrompter{},
+			extensions:  []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+			wantAllowed: []bool{true, true},
+			wantClients: []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+		},
+		{
+			description: "extension not allowed",
+			settings: &settings.Settings{
+				ApproveNewClients: false,
+				AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop"},
+			},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			extensions:  []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+			wantAllowed: []bool{true, false},
+			wantClients: []string{"abcdefghijklmnopabcdefghijklmnop"},
+		},
+		{
+			description: "allowed extension still requires approval",
+			settings: &settings.Settings{
+				ApproveNewClients: true,
+				AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop"},
+			},
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			extensions:  []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+			wantAllowed: []bool{false, false},
+			wantPrompts: []string{"abcdefghijklmnopabcdefghijklmnop"},
+			wantClients: []string{"abcdefghijklmnopabcdefghijklmnop"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				ss := settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+				if err := ss.Set(ctx, tc.settings); err != nil {
+					t.Fatalf("failed to initialize settings: %v", err)
+				}
+
+				store := storage.NewRaw(st.NewMemArea())
+				g := NewGate(ss, store, tc.prompter, clock.Real)
+				var gotAllowed []bool
+				for _, e := range tc.extensions {
+					allowed, err := g.AllowExtension(ctx, e)
+					if err != nil {
+						t.Fatalf("AllowExtension(%s) failed: %v", e, err)
+					}
+					gotAllowed = append(gotAllowed, allowed)
+				}
+
+				if diff := cmp.Diff(gotAllowed, tc.wantAllowed); diff != "" {
+					t.Errorf("incorrect allowed; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(tc.prompter.prompts, tc.wantPrompts); diff != "" {
+					t.Errorf("incorrect prompts; -got +want: %s", diff)
+				}
+
+				// Extensions that are not allowed are not recorded.
+				cs, err := clients.NewStore(store).List(ctx)
+				if err != nil {
+					t.Fatalf("failed to list clients: %v", err)
+				}
+				var gotClients []string
+				for _, c := range cs {
+					gotClients = append(gotClients, c.ID)
+				}
+				if diff := cmp.Diff(gotClients, tc.wantClients); diff != "" {
+					t.Errorf("incorrect clients; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestClientRecords(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		settings    *settings.Settings
+		// initial are the client records initially stored.
+		initial []*clients.Client
+		// legacy are the decisions initially stored by earlier
+		// versions.
+		legacy      []*legacyDecision
+		prompter    *fakePrompter
+		wantAllowed bool
+		wantPrompts []string
+		want        []*clients.Client
+	}{
+		{
+			description: "new client recorded",
+			settings:    &settings.Settings{ApproveNewClients: false},
+			prompter:    &fakePrompter{},
+			wantAllowed: true,
+			want: []*clients.Client{
+				{ID: "client-1", FirstSeen: 2000, LastSeen: 2000},
+			},
+		},
+		{
+			description: "decision recorded",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			wantAllowed: true,
+			wantPrompts: []string{"client-1"},
+			want: []*clients.Client{
+				{ID: "client-1", Decision: clients.Allowed, FirstSeen: 2000, LastSeen: 2000},
+			},
+		},
+		{
+			description: "prompt uses friendly name",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			initial: []*clients.Client{
+				{ID: "client-1", Name: "My Terminal", FirstSeen: 1000, LastSeen: 1000},
+			},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			wantAllowed: true,
+			wantPrompts: []string{"My Terminal"},
+			want: []*clients.Client{
+				{ID: "client-1", Name: "My Terminal", Decision: clients.Allowed, FirstSeen: 1000, LastSeen: 2000},
+			},
+		},
+		{
+			description: "revoked client denied even if approval disabled",
+			settings:    &settings.Settings{ApproveNewClients: false},
+			initial: []*clients.Client{
+				{ID: "client-1", Decision: clients.Denied, FirstSeen: 1000, LastSeen: 1000},
+			},
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			wantAllowed: false,
+			want: []*clients.Client{
+				{ID: "client-1", Decision: clients.Denied, FirstSeen: 1000, LastSeen: 2000},
+			},
+		},
+		{
+			description: "legacy decision migrated",
+			settings:    &settings.Settings{ApproveNewClients: true},
+			legacy: []*legacyDecision{
+				{Client: "client-1", Allowed: true},
+			},
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			wantAllowed: true,
+			want: []*clients.Client{
+				{ID: "client-1", Decision: clients.Allowed, FirstSeen: 2000, LastSeen: 2000},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				ss := settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+				if err := ss.Set(ctx, tc.settings); err != nil {
+					t.Fatalf("failed to initialize settings: %v", err)
+				}
+
+				store := storage.NewRaw(st.NewMemArea())
+				cs := clients.NewStore(store)
+				for _, c := range tc.initial {
+					c := c
+					if _, err := cs.Seen(ctx, c.ID, time.Unix(c.FirstSeen, 0)); err != nil {
+						t.Fatalf("failed to initialize clients: %v", err)
+					}
+					if err := cs.Update(ctx, c.ID, func(u *clients.Client) { *u = *c }); err != nil {
+						t.Fatalf("failed to initialize clients: %v", err)
+					}
+				}
+				legacy := storage.NewTyped[legacyDecision](store, legacyDecisionPrefixes)
+				for _, d := range tc.legacy {
+					if err := legacy.Write(ctx, d); err != nil {
+						t.Fatalf("failed to initialize legacy decisions: %v", err)
+					}
+				}
+
+				clk := cfakes.NewClock(time.Unix(2000, 0))
+				g := NewGate(ss, store, tc.prompter, clk)
+				allowed, err := g.Allow(ctx, "client-1")
+				if err != nil {
+					t.Fatalf("Allow() failed: %v", err)
+				}
+				if diff := cmp.Diff(allowed, tc.wantAllowed); diff != "" {
+					t.Errorf("incorrect allowed; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(tc.prompter.prompts, tc.wantPrompts); diff != "" {
+					t.Errorf("incorrect prompts; -got +want: %s", diff)
+				}
+
+				got, err := cs.List(ctx)
+				if err != nil {
+					t.Fatalf("failed to list clients: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect clients; -got +want: %s", diff)
+				}
+				remaining, err := legacy.ReadAll(ctx)
+				if err != nil {
+					t.Fatalf("failed to read legacy decisions: %v", err)
+				}
+				if len(remaining) > 0 {
+					t.Errorf("legacy decisions not removed: %v", remaining)
+				}
+			})
+		})
+	}
+}
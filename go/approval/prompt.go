@@ -0,0 +1,65 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approval
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/chrome"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// NotificationPrompter prompts the user using a desktop notification.
+//
+// NotificationPrompter implements the Prompter interface.
+type NotificationPrompter struct {
+	notifications *chrome.Notifications
+}
+
+// NewNotificationPrompter returns a NotificationPrompter that displays
+// prompts using the supplied notifications.
+func NewNotificationPrompter(notifications *chrome.Notifications) *NotificationPrompter {
+	return &NotificationPrompter{
+		notifications: notifications,
+	}
+}
+
+const (
+	allowButton = iota
+	denyButton
+)
+
+// Prompt implements Prompter.Prompt().
+func (p *NotificationPrompter) Prompt(ctx jsutil.AsyncContext, client string) (allowed, decided bool, err error) {
+	button, err := p.notifications.Ask(
+		ctx,
+		"Allow connection to SSH Agent?",
+		fmt.Sprintf("%s is requesting access to the SSH keys loaded in the agent.", client),
+		[]string{"Allow", "Deny"})
+	if err != nil {
+		return false, false, err
+	}
+
+	switch button {
+	case allowButton:
+		return true, true, nil
+	case denyButton:
+		return false, true, nil
+	default:
+		return false, false, nil
+	}
+}
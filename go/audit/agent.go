@@ -0,0 +1,99 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Agent wraps the agent for a single connection, and records each signature
+// requested by the client in the log, whether or not it succeeded. Failure
+// to record a request does not prevent the signature.
+//
+// Agent implements the agent.ExtendedAgent interface.
+type Agent struct {
+	agent.Agent
+	log    *Log
+	client string
+	clock  clock.Clock
+}
+
+// NewAgent returns an Agent for a connection from the specified client. clk
+// supplies the time at which signatures are requested.
+func NewAgent(agt agent.Agent, log *Log, client string, clk clock.Clock) *Agent {
+	return &Agent{
+		Agent:  agt,
+		log:    log,
+		client: client,
+		clock:  clk,
+	}
+}
+
+// record records a signature requested with the key. Requests are served
+// outside of an AsyncContext, so the log is written using RunAsync.
+func (a *Agent) record(key ssh.PublicKey, err error) {
+	e := &keys.AuditEntry{
+		Time:        a.clock.Now().Unix(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Client:      a.client,
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		if rerr := a.log.Record(ctx, e); rerr != nil {
+			jsutil.LogError("failed to record signature request: %v", rerr)
+		}
+	})
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	sig, err := a.Agent.Sign(key, data)
+	a.record(key, err)
+	return sig, err
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			err := fmt.Errorf("signature flags %d not supported", flags)
+			a.record(key, err)
+			return nil, err
+		}
+		return a.Sign(key, data)
+	}
+	sig, err := ext.SignWithFlags(key, data, flags)
+	a.record(key, err)
+	return sig, err
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"testing"
+	"time"
+
+	cfakes "github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		keyring := agent.NewKeyring()
+		priv, err := ssh.ParseRawPrivateKey([]byte(testdata.WithoutPassphrase.Private))
+		if err != nil {
+			t.Errorf("failed to parse private key: %v", err)
+			return
+		}
+		if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+			t.Errorf("failed to load key: %v", err)
+			return
+		}
+		loaded, err := keyring.List()
+		if err != nil || len(loaded) != 1 {
+			t.Errorf("failed to list keys: %v", err)
+			return
+		}
+		unknown, err := ssh.ParsePrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+		if err != nil {
+			t.Errorf("failed to parse unloaded key: %v", err)
+			return
+		}
+
+		l := NewLog(storage.NewRaw(st.NewMemArea()), DefaultCapacity)
+		clk := cfakes.NewClock(time.Unix(1000, 0))
+		agt := NewAgent(keyring, l, "client-1", clk)
+
+		if _, err := agt.Sign(loaded[0], []byte("data")); err != nil {
+			t.Errorf("Sign failed: %v", err)
+		}
+		clk.Advance(time.Second)
+		_, signErr := agt.SignWithFlags(unknown.PublicKey(), []byte("data"), agent.SignatureFlagRsaSha256)
+		if signErr == nil {
+			t.Errorf("SignWithFlags unexpectedly succeeded with unloaded key")
+			return
+		}
+
+		got, err := l.Entries(ctx)
+		if err != nil {
+			t.Errorf("Entries failed: %v", err)
+			return
+		}
+		want := []*keys.AuditEntry{
+			{
+				Time:        1000,
+				Fingerprint: ssh.FingerprintSHA256(loaded[0]),
+				Client:      "client-1",
+			},
+			{
+				Time:        1001,
+				Fingerprint: ssh.FingerprintSHA256(unknown.PublicKey()),
+				Client:      "client-1",
+				Err:         signErr.Error(),
+			},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect entries; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the signatures requested from the agent, so the user
+// can review how their keys were used.
+//
+// The most recent requests are kept in a fixed-size log in session storage;
+// the log is therefore discarded when the browser exits.
+package audit
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/lock"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+const (
+	// logKey is the key under which the log is stored.
+	logKey = "auditLog"
+	// lockResourceID is the resource held while modifying the log, so
+	// that concurrent requests are not lost.
+	lockResourceID = "audit-lock"
+	// DefaultCapacity is the number of entries retained by default.
+	DefaultCapacity = 200
+)
+
+// storedLog is the raw object stored in session storage.
+type storedLog struct {
+	Entries []*keys.AuditEntry `js:"entries"`
+}
+
+// Log is a fixed-size log of signature requests. When full, the oldest
+// entries are discarded.
+//
+// Log implements the keys.AuditLog interface.
+type Log struct {
+	area     storage.Area
+	capacity int
+}
+
+// NewLog returns a Log that retains up to capacity entries in the supplied
+// storage area.
+func NewLog(area storage.Area, capacity int) *Log {
+	return &Log{
+		area:     area,
+		capacity: capacity,
+	}
+}
+
+// Entries implements keys.AuditLog.Entries.
+func (l *Log) Entries(ctx jsutil.AsyncContext) ([]*keys.AuditEntry, error) {
+	data, err := l.area.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	val, ok := data[logKey]
+	if !ok || val.Type() != js.TypeObject {
+		return nil, nil
+	}
+
+	var stored storedLog
+	if err := vert.ValueOf(val).AssignTo(&stored); err != nil {
+		return nil, fmt.Errorf("failed to parse audit log: %w", err)
+	}
+	return stored.Entries, nil
+}
+
+// write replaces the entries in the log.
+func (l *Log) write(ctx jsutil.AsyncContext, entries []*keys.AuditEntry) error {
+	data := map[string]js.Value{
+		logKey: vert.ValueOf(&storedLog{Entries: entries}).JSValue(),
+	}
+	if err := l.area.Set(ctx, data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// locked invokes f while holding the lock used to serialize changes to the
+// log.
+func (l *Log) locked(ctx jsutil.AsyncContext, f func(ctx jsutil.AsyncContext) error) error {
+	return lock.WithLock(ctx, lockResourceID, f)
+}
+
+// Record appends an entry to the log, discarding the oldest entries if the
+// log is full.
+func (l *Log) Record(ctx jsutil.AsyncContext, e *keys.AuditEntry) error {
+	return l.locked(ctx, func(ctx jsutil.AsyncContext) error {
+		entries, err := l.Entries(ctx)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		if len(entries) > l.capacity {
+			entries = entries[len(entries)-l.capacity:]
+		}
+		return l.write(ctx, entries)
+	})
+}
+
+// Clear implements keys.AuditLog.Clear.
+func (l *Log) Clear(ctx jsutil.AsyncContext) error {
+	return l.locked(ctx, func(ctx jsutil.AsyncContext) error {
+		return l.write(ctx, nil)
+	})
+}
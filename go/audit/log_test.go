@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	entry := func(i int64) *keys.AuditEntry {
+		return &keys.AuditEntry{Time: i, Fingerprint: "SHA256:key", Client: "client-1"}
+	}
+
+	testcases := []struct {
+		description string
+		capacity    int
+		record      []*keys.AuditEntry
+		clear       bool
+		want        []*keys.AuditEntry
+	}{
+		{
+			description: "empty",
+			capacity:    3,
+		},
+		{
+			description: "entries retained in order",
+			capacity:    3,
+			record:      []*keys.AuditEntry{entry(1), entry(2)},
+			want:        []*keys.AuditEntry{entry(1), entry(2)},
+		},
+		{
+			description: "oldest entries discarded when full",
+			capacity:    3,
+			record:      []*keys.AuditEntry{entry(1), entry(2), entry(3), entry(4), entry(5)},
+			want:        []*keys.AuditEntry{entry(3), entry(4), entry(5)},
+		},
+		{
+			description: "cleared",
+			capacity:    3,
+			record:      []*keys.AuditEntry{entry(1), entry(2)},
+			clear:       true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				area := storage.NewRaw(st.NewMemArea())
+				l := NewLog(area, tc.capacity)
+				for _, e := range tc.record {
+					if err := l.Record(ctx, e); err != nil {
+						t.Errorf("Record failed: %v", err)
+						return
+					}
+				}
+				if tc.clear {
+					if err := l.Clear(ctx); err != nil {
+						t.Errorf("Clear failed: %v", err)
+						return
+					}
+				}
+
+				// Read using a separate instance to verify the log
+				// is persisted.
+				got, err := NewLog(area, tc.capacity).Entries(ctx)
+				if err != nil {
+					t.Errorf("Entries failed: %v", err)
+					return
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect entries; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -18,36 +18,163 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/google/chrome-ssh-agent/go/agentport"
 	"github.com/google/chrome-ssh-agent/go/app"
+	"github.com/google/chrome-ssh-agent/go/approval"
+	"github.com/google/chrome-ssh-agent/go/audit"
+	"github.com/google/chrome-ssh-agent/go/chrome"
+	"github.com/google/chrome-ssh-agent/go/chrome/omnibox"
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/constrained"
+	"github.com/google/chrome-ssh-agent/go/debugreport"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
 	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/lock"
+	"github.com/google/chrome-ssh-agent/go/logbuf"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/google/chrome-ssh-agent/go/metrics"
+	"github.com/google/chrome-ssh-agent/go/nativeport"
+	"github.com/google/chrome-ssh-agent/go/relayport"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+	"github.com/google/chrome-ssh-agent/go/sessionbind"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/signguard"
 	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/google/chrome-ssh-agent/go/vault"
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 )
 
 type background struct {
-	// agent is keyring with the loaded keys.
-	agent agent.Agent
+	// agent is keyring with the loaded keys. It enforces constraints on
+	// keys added by clients.
+	agent *constrained.Agent
 	// ports manages opened ports for communicating with the agent.
 	ports agentport.AgentPorts
+	// native forwards connections from desktop applications through the
+	// native host; nil if not connected.
+	native *nativeport.Bridge
+	// relay forwards connections from a remote development environment
+	// through a relay; nil if not connected.
+	relay *nativeport.Bridge
+	// relayURL and relayToken are those with which relay was connected.
+	relayURL, relayToken string
 	// manager is a wrapper that can manage loaded keys.
 	manager *keys.DefaultManager
+	// broadcaster announces changes to keys and settings to the
+	// extension's pages.
+	broadcaster message.Sender
 	// server exposes an API for the manager.
 	server *keys.Server
+	// settingsServer exposes an API for the settings, such that the
+	// extension's pages share those used by the background worker.
+	settingsServer *settings.Server
+	// notifications displays notifications to the user.
+	notifications *chrome.Notifications
+	// omnibox performs commands entered in the browser's address bar.
+	omnibox *omnibox.Omnibox
+	// commands performs commands invoked using keyboard shortcuts.
+	commands *chrome.Commands
+	// vault protects saved passphrases with the master password.
+	vault *vault.Vault
+	// authenticator obtains signatures from security keys.
+	authenticator *securitykey.WindowAuthenticator
+	// gate decides whether new connections are permitted.
+	gate *approval.Gate
+	// settings are the user-configurable settings.
+	settings *settings.Store
+	// clients are the records of clients that have connected.
+	clients *clients.Store
+	// audit records the signatures requested by clients.
+	audit *audit.Log
+	// signPrompter asks the user whether to permit repeated signatures,
+	// and to confirm signatures with keys that require it.
+	signPrompter *signguard.NotificationPrompter
+	// selfTests are run after the extension is updated.
+	selfTests []selftest.Check
+	// diagnostics stores the results of the most recent self-test, and
+	// agent statistics, for inclusion in debug reports.
+	diagnostics storage.Area
+	// metrics aggregates statistics across all connections.
+	metrics *metrics.Registry
+	// clock supplies the current time.
+	clock clock.Clock
 }
 
 func newBackground() *background {
-	agt := agent.NewKeyring()
-	mgr := keys.NewManager(agt, storage.DefaultSync(), storage.DefaultSession())
-	return &background{
-		agent:   agt,
-		ports:   agentport.AgentPorts{},
-		manager: mgr,
-		server:  keys.NewServer(mgr),
+	// Entries are retained from the outset so that they can be viewed
+	// from the options page. Debug entries are retained only once
+	// settings are read, and only if the user has enabled them.
+	logs := logbuf.New(js.Global().Get("chrome").Get("storage").Get("session"), logbuf.DefaultCapacity)
+	jsutil.SetLogSink(logs.Add, false)
+
+	// WebAuthn is unavailable to the background worker, so security keys
+	// are asked to sign from a window showing the options page.
+	optionsURL := js.Global().Get("chrome").Get("runtime").Call("getURL", "html/options.html").String()
+	authn := securitykey.NewWindowAuthenticator(js.Undefined(), optionsURL, clock.Real)
+	agt := constrained.New(securitykey.NewAgent(agent.NewKeyring(), authn), clock.Real)
+	syncStorage, localStorage, sessionStorage := storage.DefaultSync(), storage.DefaultLocal(), storage.DefaultSession()
+	mgr := keys.NewManager(agt, syncStorage, localStorage, sessionStorage)
+	auditLog := audit.NewLog(sessionStorage, audit.DefaultCapacity)
+	mgr.SetAuditLog(auditLog)
+	mgr.SetLogBuffer(logs)
+	notifications := chrome.NewNotifications(js.Undefined())
+	// Settings and approvals fall back to their defaults if synced
+	// storage is unavailable.
+	prefStorage := storage.NewOptional(syncStorage)
+	// Settings that apply only to this device (e.g., the relay) are
+	// stored locally.
+	sts := settings.NewStore(prefStorage, localStorage, storage.DefaultManaged())
+	// Keys in synced storage may be encrypted using the master password
+	// that protects saved passphrases.
+	vlt := vault.New(prefStorage, sessionStorage)
+	mgr.SetKeySource(vlt)
+	mgr.SetPasswordVerifier(vlt)
+	// Pages displaying keys (e.g., in other windows) are told when keys
+	// are changed, so they can refresh.
+	broadcaster := message.NewLocalSender()
+	notifying := keys.Chain(mgr, keys.NotifyChanges(broadcaster))
+	a := &background{
+		agent:          agt,
+		ports:          agentport.AgentPorts{},
+		manager:        mgr,
+		broadcaster:    broadcaster,
+		server:         keys.NewServer(notifying, sts.Capabilities),
+		settingsServer: settings.NewServer(sts),
+		notifications:  notifications,
+		omnibox:        omnibox.New(js.Undefined(), notifying, notifications),
+		commands:       chrome.NewCommands(js.Undefined()),
+		vault:          vlt,
+		authenticator:  authn,
+		gate:           approval.NewGate(sts, prefStorage, approval.NewNotificationPrompter(notifications), clock.Real),
+		settings:       sts,
+		clients:        clients.NewStore(prefStorage),
+		audit:          auditLog,
+		signPrompter:   signguard.NewNotificationPrompter(notifications),
+		selfTests: []selftest.Check{
+			selftest.AgentRoundTrip(agt),
+			selftest.PortRoundTrip(agt),
+			selftest.StorageReadWrite("sync", syncStorage),
+			selftest.StorageReadWrite("local", localStorage),
+			selftest.StorageReadWrite("session", sessionStorage),
+			{Name: "session restore", Run: mgr.VerifySession},
+		},
+		diagnostics: localStorage,
+		metrics:     metrics.NewRegistry(),
+		clock:       clock.Real,
 	}
+	mgr.SetConnectionSource(a.connections)
+	mgr.SetReauthWindow(a.exportReauthWindow)
+	mgr.SetDiagnosticsRunner(a.runDiagnostics)
+	return a
 }
 
 func (a *background) Name() string {
@@ -55,44 +182,373 @@ func (a *background) Name() string {
 }
 
 func (a *background) Init(ctx jsutil.AsyncContext, cleanup *jsutil.CleanupFuncs) error {
+	if err := a.settings.Migrate(ctx); err != nil {
+		jsutil.LogError("failed to migrate settings: %v", err)
+	}
+	a.applySettings(ctx)
+	watchSettings, err := a.settings.Watch(ctx, a.settingsChanged)
+	if err != nil {
+		jsutil.LogError("failed to watch for changes to settings: %v", err)
+	} else {
+		cleanup.Add(watchSettings)
+	}
+
 	jsutil.Log("Cleaning up old data")
 	a.manager.CleanupOldData(ctx)
+	a.manager.RecoverTransactions(ctx)
+	a.separateConflicts(ctx)
 
 	jsutil.Log("Loading keys from session")
 	if err := a.manager.LoadFromSession(ctx); err != nil {
 		jsutil.LogError("failed to load keys into agent: %v", err)
 	}
+	// Only once the session keys are loaded can they be told apart from
+	// orphaned ones.
+	a.removeOrphanedSessionKeys(ctx)
+
+	a.autoLoad(ctx)
 
 	jsutil.LogDebug("Attaching event handlers")
 	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleOnMessage", a.onMessage))
 	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleConnectionMessage", a.onConnectionMessage))
 	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleConnectionDisconnect", a.onConnectionDisconnect))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleNotificationButtonClicked", a.onNotificationButtonClicked))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleNotificationClosed", a.onNotificationClosed))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleOmniboxInputChanged", a.onOmniboxInputChanged))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleOmniboxInputEntered", a.onOmniboxInputEntered))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleCommand", a.onCommand))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleInstalled", a.onInstalled))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleStartup", a.onStartup))
+	cleanup.Add(jsutil.DefineAsyncFunc(js.Global(), "handleAlarm", a.onAlarm))
+
+	a.omnibox.Init()
+	a.commands.Handle(lockCommand, a.lock)
+	a.commands.Handle(unlockCommand, a.unlock)
+
+	// Keys may also be changed in storage directly, notably when synced
+	// from another of the user's devices.
+	watch, err := a.manager.WatchStorage(ctx, func(ctx jsutil.AsyncContext) {
+		a.separateConflicts(ctx)
+		keys.BroadcastChange(ctx, a.broadcaster)
+	})
+	if err != nil {
+		jsutil.LogError("failed to watch for changes to keys: %v", err)
+	} else {
+		cleanup.Add(watch)
+	}
+
+	a.scheduleAlarm(ctx, idleAlarm, idlePeriodMinutes)
 	return nil
 }
 
+// applySettings applies the settings that take effect in the background
+// worker itself: debug log entries are retained, and the native host and
+// relay are connected, only if the user has enabled them.
+func (a *background) applySettings(ctx jsutil.AsyncContext) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read settings: %v", err)
+		return
+	}
+	jsutil.SetVerbose(s.VerboseLogging)
+	a.applyNativeHost(s.NativeHost)
+	a.applyRelay(s.RelayURL, s.RelayToken)
+}
+
+// settingsChanged applies the settings once they are changed (e.g., on the
+// options page, or on another of the user's devices), and announces the
+// change to the extension's pages.
+func (a *background) settingsChanged(ctx jsutil.AsyncContext) {
+	a.applySettings(ctx)
+	settings.BroadcastChange(ctx, a.broadcaster)
+}
+
+// separateConflicts ensures that conflicting copies of keys, such as those
+// synced from another device, are each configured with their own ID.
+func (a *background) separateConflicts(ctx jsutil.AsyncContext) {
+	if err := a.manager.SeparateConflicts(ctx); err != nil {
+		jsutil.LogError("failed to separate conflicting keys: %v", err)
+	}
+}
+
 func (a *background) onMessage(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
 	var message, sender, sendResponse js.Value
 	jsutil.ExpandArgs(args, &message, &sender, &sendResponse)
-	rsp := a.server.OnMessage(ctx, message, sender)
+	if a.authenticator.OnMessage(message) {
+		sendResponse.Invoke(js.Undefined())
+		return js.Undefined(), nil
+	}
+	rsp := a.settingsServer.OnMessage(ctx, message, sender)
+	if rsp.IsUndefined() {
+		rsp = a.server.OnMessage(ctx, message, sender)
+	}
 	sendResponse.Invoke(rsp)
 	return js.Undefined(), nil
 }
 
+// clientID returns an identifier for the client that opened a connection,
+// given the chrome.runtime.MessageSender for the connection.
+func clientID(sender js.Value) string {
+	for _, prop := range []string{"id", "origin", "url"} {
+		if v := sender.Get(prop); v.Type() == js.TypeString && v.String() != "" {
+			return v.String()
+		}
+	}
+	return "unknown"
+}
+
+// extensionID returns the ID of the extension that opened a connection,
+// given the chrome.runtime.MessageSender for the connection. false is
+// returned if the connection was not opened by an extension.
+func extensionID(sender js.Value) (string, bool) {
+	if id := sender.Get("id"); id.Type() == js.TypeString && id.String() != "" {
+		return id.String(), true
+	}
+	return "", false
+}
+
+// senderOrigin returns the origin of the page that opened a connection,
+// given the chrome.runtime.MessageSender for the connection. Empty is
+// returned if it is not known.
+func senderOrigin(sender js.Value) string {
+	if origin := sender.Get("origin"); origin.Type() == js.TypeString {
+		return origin.String()
+	}
+	return ""
+}
+
+// allow returns true if the sender may connect to the agent.
+func (a *background) allow(ctx jsutil.AsyncContext, sender js.Value) (string, bool, error) {
+	if id, ok := extensionID(sender); ok {
+		allowed, err := a.gate.AllowExtension(ctx, id)
+		return id, allowed, err
+	}
+	client := clientID(sender)
+	allowed, err := a.gate.Allow(ctx, client)
+	return client, allowed, err
+}
+
+// connections returns the clients currently connected to the agent.
+func (a *background) connections() []*keys.Connection {
+	var result []*keys.Connection
+	for _, p := range a.ports.Peers() {
+		result = append(result, &keys.Connection{
+			Client:    p.ID,
+			Origin:    p.Origin,
+			Connected: p.Connected.Unix(),
+		})
+	}
+	if a.native != nil {
+		for _, c := range a.native.Conns() {
+			result = append(result, &keys.Connection{
+				Client:    nativeport.HostName,
+				Connected: c.Opened().Unix(),
+			})
+		}
+	}
+	if a.relay != nil {
+		for _, c := range a.relay.Conns() {
+			result = append(result, &keys.Connection{
+				Client:    a.relayURL,
+				Connected: c.Opened().Unix(),
+			})
+		}
+	}
+	return result
+}
+
+// newAgent returns the agent served to the specified client.
+func (a *background) newAgent(client string) agent.Agent {
+	// Pages displaying keys are told when a client locks or unlocks the
+	// agent, since no keys are then listed.
+	locking := keys.NewLockAgent(a.agent, a.manager, func(ctx jsutil.AsyncContext) {
+		keys.BroadcastChange(ctx, a.broadcaster)
+	})
+	captured := keys.NewCaptureAgent(locking, a.manager, a.captureAddedKeys)
+	used := keys.NewUsageAgent(captured, a.manager, a.clock)
+	granted := clients.NewAgent(keys.NewKeyringAgent(used, a.manager, a.activeKeyring), a.clients, client)
+	// Hints only include keys listed to the client.
+	agt := keys.NewHintAgent(granted, a.manager)
+	confirmer := signguard.NewConfirmer(agt, client, a.lookupKey, a.signPrompter)
+	guard := signguard.NewGuard(confirmer, client, a.settings, a.signPrompter, a.clock)
+	notifier := signguard.NewNotifier(guard, client, a.lookupKey, a.signPrompter)
+	audited := audit.NewAgent(notifier, a.audit, client, a.clock)
+	return sessionbind.New(audited)
+}
+
+// captureAddedKeys returns true if keys added by clients are captured, such
+// that they can be saved as configured keys.
+func (a *background) captureAddedKeys(ctx jsutil.AsyncContext) (bool, error) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return s.CaptureAddedKeys, nil
+}
+
+// activeKeyring returns the keyring whose keys are offered to clients.
+func (a *background) activeKeyring(ctx jsutil.AsyncContext) (string, error) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return s.ActiveKeyring, nil
+}
+
+// exportReauthWindow returns the time after the master password is entered
+// during which private keys may be exported again without it.
+func (a *background) exportReauthWindow(ctx jsutil.AsyncContext) (time.Duration, error) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.ExportReauthWindow(), nil
+}
+
 func (a *background) addPort(port js.Value) *agentport.AgentPort {
-	ap := agentport.New(port)
+	sender := port.Get("sender")
+	client := clientID(sender)
+	ap := agentport.New(port, a.metrics, a.clock)
+	ap.SetPeer(agentport.Peer{
+		ID:        client,
+		Origin:    senderOrigin(sender),
+		Connected: a.clock.Now(),
+	})
 	a.ports.Add(port, ap)
 
-	go func() {
-		jsutil.LogDebug("ServeAgent: starting for new port")
-		defer jsutil.LogDebug("ServeAgent: finished")
-		if err := agent.ServeAgent(a.agent, ap); err != nil {
-			jsutil.LogDebug("ServeAgent: finished with error: %v", err)
+	// Serve the agent only once the connection is approved. Until then,
+	// messages from the client are buffered in the AgentPort.
+	jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+		client, allowed, err := a.allow(ctx, sender)
+		if err != nil {
+			jsutil.LogError("failed to check approval for client %s: %v", client, err)
+		}
+		if !allowed {
+			jsutil.Log("Connection from client %s denied", client)
+			ap.Close()
+			a.ports.Delete(port)
+			return js.Undefined(), nil
 		}
-	}()
+
+		bound := a.newAgent(client)
+		go func() {
+			jsutil.LogDebug("ServeAgent: starting for new port")
+			defer jsutil.LogDebug("ServeAgent: finished")
+			if err := agent.ServeAgent(bound, ap); err != nil {
+				jsutil.LogDebug("ServeAgent: finished with error: %v", err)
+			}
+		}()
+		return js.Undefined(), nil
+	})
 
 	return ap
 }
 
+// applyNativeHost connects to the native host if enabled, such that desktop
+// applications can use the agent, and disconnects from it otherwise.
+func (a *background) applyNativeHost(enabled bool) {
+	switch {
+	case enabled && a.native == nil:
+		jsutil.Log("Connecting to native host")
+		b, err := nativeport.Connect(js.Undefined(), func(c *nativeport.Conn) { a.serveForwarded(c, nativeport.HostName) }, a.onNativeDisconnect, a.clock)
+		if err != nil {
+			jsutil.LogError("failed to connect to native host: %v", err)
+			return
+		}
+		a.native = b
+	case !enabled && a.native != nil:
+		jsutil.Log("Disconnecting from native host")
+		a.native.Close()
+		a.native = nil
+	}
+}
+
+// onNativeDisconnect is invoked when the native host disconnects. It is
+// connected again when the settings are next applied.
+func (a *background) onNativeDisconnect(err error) {
+	a.native = nil
+	if err != nil {
+		jsutil.LogError("Native host disconnected: %v", err)
+		return
+	}
+	jsutil.Log("Native host disconnected")
+}
+
+// applyRelay connects to the relay at the specified URL, if any, such that a
+// remote development environment can use the agent, and disconnects from the
+// relay otherwise. The relay is connected only once its token is configured,
+// and again if its URL or token changed.
+func (a *background) applyRelay(relayURL, token string) {
+	if a.relay != nil && (relayURL != a.relayURL || token != a.relayToken) {
+		jsutil.Log("Disconnecting from relay %s", a.relayURL)
+		a.relay.Close()
+		a.relay = nil
+	}
+	if relayURL == "" || token == "" || a.relay != nil {
+		return
+	}
+
+	jsutil.Log("Connecting to relay %s", relayURL)
+	b, err := relayport.Connect(js.Undefined(), relayURL, token, func(c *nativeport.Conn) { a.serveForwarded(c, relayURL) }, a.onRelayDisconnect, a.clock)
+	if err != nil {
+		jsutil.LogError("failed to connect to relay: %v", err)
+		return
+	}
+	a.relay, a.relayURL, a.relayToken = b, relayURL, token
+}
+
+// onRelayDisconnect is invoked when the relay closes the connection to it.
+// It is connected again when the settings are next applied.
+func (a *background) onRelayDisconnect(err error) {
+	a.relay = nil
+	if err != nil {
+		jsutil.LogError("Relay disconnected: %v", err)
+		return
+	}
+	jsutil.Log("Relay disconnected")
+}
+
+// serveForwarded serves the agent over a connection forwarded by the native
+// host or a relay, once the specified client is approved.
+func (a *background) serveForwarded(c *nativeport.Conn, client string) {
+	jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+		allowed, err := a.gate.Allow(ctx, client)
+		if err != nil {
+			jsutil.LogError("failed to check approval for %s: %v", client, err)
+		}
+		if !allowed {
+			jsutil.Log("Connection from %s denied", client)
+			c.Close()
+			return js.Undefined(), nil
+		}
+
+		bound := a.newAgent(client)
+		go func() {
+			jsutil.LogDebug("ServeAgent: starting for connection from %s", client)
+			defer jsutil.LogDebug("ServeAgent: finished")
+			if err := agent.ServeAgent(bound, c); err != nil {
+				jsutil.LogDebug("ServeAgent: finished with error: %v", err)
+			}
+			c.Close()
+		}()
+		return js.Undefined(), nil
+	})
+}
+
+// lookupKey returns the key that is loaded with the specified public key, for
+// the purpose of deciding whether signatures with it must be confirmed. A
+// key added by a client with the confirmation constraint is described using
+// the comment it was added with, since it is not configured.
+func (a *background) lookupKey(ctx jsutil.AsyncContext, pub ssh.PublicKey) (*keys.ConfiguredKey, error) {
+	if comment, confirm := a.agent.NeedsConfirmation(pub); confirm {
+		if comment == "" {
+			comment = "added by a client"
+		}
+		return &keys.ConfiguredKey{Name: comment, Confirm: true}, nil
+	}
+	return a.manager.KeyFor(ctx, pub)
+}
+
 func (a *background) onConnectionMessage(_ jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
 	var port, msg js.Value
 	jsutil.ExpandArgs(args, &port, &msg)
@@ -114,7 +570,7 @@ func (a *background) onConnectionMessage(_ jsutil.AsyncContext, _ js.Value, args
 	return js.Undefined(), nil
 }
 
-func (a *background) onConnectionDisconnect(_ jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+func (a *background) onConnectionDisconnect(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
 	port := jsutil.SingleArg(args)
 
 	ap := a.ports.Lookup(port)
@@ -127,9 +583,270 @@ func (a *background) onConnectionDisconnect(_ jsutil.AsyncContext, _ js.Value, a
 	jsutil.LogDebug("onConnectionDisconnect: disconnecting")
 	ap.OnDisconnect()
 	a.ports.Delete(port)
+	jsutil.LogDebug("onConnectionDisconnect: connection statistics:\n%s", metrics.Format(ap.Stats()))
+	stats := a.metrics.Snapshot()
+	maps.Copy(stats, lock.Stats())
+	jsutil.LogDebug("onConnectionDisconnect: statistics across all connections:\n%s", metrics.Format(stats))
+	if err := debugreport.WriteStats(ctx, a.diagnostics, stats); err != nil {
+		jsutil.LogError("onConnectionDisconnect: failed to record statistics: %v", err)
+	}
 	return js.Undefined(), nil
 }
 
+func (a *background) onNotificationButtonClicked(_ jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	var id, index js.Value
+	jsutil.ExpandArgs(args, &id, &index)
+	a.notifications.OnButtonClicked(id.String(), index.Int())
+	return js.Undefined(), nil
+}
+
+func (a *background) onNotificationClosed(_ jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	id := jsutil.SingleArg(args)
+	a.notifications.OnClosed(id.String())
+	return js.Undefined(), nil
+}
+
+func (a *background) onOmniboxInputChanged(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	var text, suggest js.Value
+	jsutil.ExpandArgs(args, &text, &suggest)
+	a.omnibox.OnInputChanged(ctx, text.String(), suggest)
+	return js.Undefined(), nil
+}
+
+func (a *background) onOmniboxInputEntered(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	text := jsutil.SingleArg(args)
+	a.omnibox.OnInputEntered(ctx, text.String())
+	return js.Undefined(), nil
+}
+
+func (a *background) onCommand(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	name := jsutil.SingleArg(args)
+	a.commands.OnCommand(ctx, name.String())
+	return js.Undefined(), nil
+}
+
+func (a *background) onInstalled(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	details := jsutil.SingleArg(args)
+	if reason := details.Get("reason"); reason.Type() != js.TypeString || reason.String() != "update" {
+		return js.Undefined(), nil
+	}
+
+	jsutil.Log("Extension updated; running self-test")
+	a.runSelfTest(ctx)
+	return js.Undefined(), nil
+}
+
+// onStartup is invoked when the browser starts.  The worker may have been
+// spawned (and Init run) before the browser finished restoring the user's
+// profile, so keys configured to load at startup are loaded again.  Event
+// handlers only run after Init completes, so this always follows
+// LoadFromSession; keys that are already loaded are skipped.
+func (a *background) onStartup(ctx jsutil.AsyncContext, _ js.Value, _ []js.Value) (js.Value, error) {
+	jsutil.Log("Browser started")
+	a.autoLoad(ctx)
+	return js.Undefined(), nil
+}
+
+const (
+	// idleAlarm is the name of the alarm that triggers unloading of idle
+	// keys, closing of idle connections, reconnection to the native host,
+	// and removal of orphaned session keys and expired held keys. Go
+	// timers do not survive the worker being suspended, so an alarm is
+	// used instead.
+	idleAlarm = "idle"
+	// idlePeriodMinutes is the interval between checks for idle keys,
+	// and hence the precision with which idle timeouts are applied.
+	idlePeriodMinutes = 1
+)
+
+// scheduleAlarm creates a periodic alarm with the specified name, unless it
+// already exists. Alarms always fire; whether any action is taken depends on
+// the settings at the time.
+func (a *background) scheduleAlarm(ctx jsutil.AsyncContext, name string, periodMinutes int) {
+	alarms := js.Global().Get("chrome").Get("alarms")
+	existing, err := jsutil.AsPromise(alarms.Call("get", name)).Await(ctx)
+	if err != nil {
+		jsutil.LogError("failed to look up %s alarm: %v", name, err)
+		return
+	}
+	if existing.Truthy() {
+		return
+	}
+	info := js.ValueOf(map[string]interface{}{"periodInMinutes": periodMinutes})
+	if _, err := jsutil.AsPromise(alarms.Call("create", name, info)).Await(ctx); err != nil {
+		jsutil.LogError("failed to create %s alarm: %v", name, err)
+	}
+}
+
+// onAlarm is invoked when an alarm fires.
+func (a *background) onAlarm(ctx jsutil.AsyncContext, _ js.Value, args []js.Value) (js.Value, error) {
+	alarm := jsutil.SingleArg(args)
+	switch alarm.Get("name").String() {
+	case idleAlarm:
+		a.unloadIdle(ctx)
+		a.closeIdleConnections(ctx)
+		// The native host may have exited, or been installed since
+		// it was last connected, and the relay may have restarted.
+		a.applySettings(ctx)
+		a.removeOrphanedSessionKeys(ctx)
+		a.discardExpiredHeld(ctx)
+	}
+	return js.Undefined(), nil
+}
+
+// unloadIdle unloads keys that have not been used to sign for longer than
+// their idle timeout.
+func (a *background) unloadIdle(ctx jsutil.AsyncContext) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		// Keys may override the default timeout, so continue
+		// without one.
+		jsutil.LogError("failed to read settings; applying only per-key idle timeouts: %v", err)
+		s = &settings.Settings{}
+	}
+	unloaded, err := a.manager.UnloadIdle(ctx, s.IdleTimeout(), a.clock.Now())
+	if err != nil {
+		jsutil.LogError("failed to unload idle keys: %v", err)
+	}
+	if len(unloaded) > 0 {
+		jsutil.Log("Unloaded %d idle keys", len(unloaded))
+		keys.BroadcastChange(ctx, a.broadcaster)
+	}
+}
+
+// closeIdleConnections closes connections on which no message has been
+// exchanged for the connection idle timeout configured in the settings.
+// Clients may disappear without disconnecting, which would otherwise leave
+// the connection open indefinitely.
+func (a *background) closeIdleConnections(ctx jsutil.AsyncContext) {
+	s, err := a.settings.Get(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read settings; applying default connection idle timeout: %v", err)
+		s = &settings.Settings{}
+	}
+	for _, p := range a.ports.CloseIdle(a.clock.Now(), s.ConnectionIdleTimeout()) {
+		jsutil.Log("Closed idle connection from client %s", p.ID)
+	}
+}
+
+// removeOrphanedSessionKeys removes the session keys for keys that are
+// neither configured nor loaded.
+func (a *background) removeOrphanedSessionKeys(ctx jsutil.AsyncContext) {
+	removed, err := a.manager.RemoveOrphanedSessionKeys(ctx)
+	if err != nil {
+		jsutil.LogError("failed to remove orphaned session keys: %v", err)
+	}
+	if len(removed) > 0 {
+		jsutil.Log("Removed %d orphaned session keys", len(removed))
+	}
+}
+
+// discardExpiredHeld discards the keys held so that their removal or unload
+// could be undone, once they can no longer be.
+func (a *background) discardExpiredHeld(ctx jsutil.AsyncContext) {
+	discarded, err := a.manager.DiscardExpiredHeld(ctx, a.clock.Now())
+	if err != nil {
+		jsutil.LogError("failed to discard held keys: %v", err)
+	}
+	if len(discarded) > 0 {
+		jsutil.LogDebug("Discarded %d held keys", len(discarded))
+	}
+}
+
+// autoLoad loads keys that are configured to load at startup.
+func (a *background) autoLoad(ctx jsutil.AsyncContext) {
+	jsutil.Log("Loading keys configured to load at startup")
+	if err := a.manager.AutoLoad(ctx); err != nil {
+		jsutil.LogError("failed to auto-load keys into agent: %v", err)
+	}
+	keys.BroadcastChange(ctx, a.broadcaster)
+}
+
+const (
+	// lockCommand is the command, declared in the manifest, that locks
+	// the agent.
+	lockCommand = "lock"
+	// unlockCommand is the command, declared in the manifest, that
+	// unlocks the agent.
+	unlockCommand = "unlock"
+)
+
+// lock unloads all keys, and locks the saved passphrases, so that nothing can
+// sign until keys are loaded again.
+func (a *background) lock(ctx jsutil.AsyncContext) {
+	jsutil.Log("Locking agent")
+	unloaded, err := a.manager.UnloadAll(ctx)
+	if err != nil {
+		jsutil.LogError("failed to unload keys: %v", err)
+	}
+	if err := a.vault.Lock(ctx); err != nil {
+		jsutil.LogError("failed to lock saved passphrases: %v", err)
+	}
+	keys.BroadcastChange(ctx, a.broadcaster)
+
+	message := fmt.Sprintf("Unloaded %d keys.", len(unloaded))
+	if err != nil {
+		message = fmt.Sprintf("Failed to unload some keys: %v", err)
+	}
+	if shortcut, err := a.commands.Shortcut(ctx, unlockCommand); err != nil {
+		jsutil.LogError("failed to read unlock shortcut: %v", err)
+	} else if shortcut != "" {
+		message += fmt.Sprintf(" Press %s to load keys configured to load at startup.", shortcut)
+	}
+	a.notify(ctx, "SSH Agent locked", message)
+}
+
+// unlock loads the keys that are configured to load at startup. Keys that
+// require a passphrase must be loaded from the popup or options page.
+func (a *background) unlock(ctx jsutil.AsyncContext) {
+	jsutil.Log("Unlocking agent")
+	title, message := "SSH Agent unlocked", "Loaded keys configured to load at startup."
+	if err := a.manager.AutoLoad(ctx); err != nil {
+		jsutil.LogError("failed to auto-load keys into agent: %v", err)
+		title, message = "SSH Agent unlock failed", err.Error()
+	}
+	keys.BroadcastChange(ctx, a.broadcaster)
+	a.notify(ctx, title, message)
+}
+
+// notify displays a notification, logging any failure to do so.
+func (a *background) notify(ctx jsutil.AsyncContext, title, message string) {
+	if err := a.notifications.Notify(ctx, title, message); err != nil {
+		jsutil.LogError("failed to notify: %v", err)
+	}
+}
+
+// runDiagnostics runs the self-test and records the results, such that they
+// are included in debug reports.
+func (a *background) runDiagnostics(ctx jsutil.AsyncContext) *selftest.Report {
+	version := js.Global().Get("chrome").Get("runtime").Call("getManifest").Get("version").String()
+	r := selftest.Run(ctx, version, a.selfTests, a.clock.Now())
+	if err := selftest.WriteReport(ctx, a.diagnostics, r); err != nil {
+		jsutil.LogError("failed to record self-test results: %v", err)
+	}
+	return r
+}
+
+// runSelfTest runs the self-test, records the results, and notifies the user
+// if any check failed.
+func (a *background) runSelfTest(ctx jsutil.AsyncContext) {
+	r := a.runDiagnostics(ctx)
+	failed := r.Failed()
+	if len(failed) == 0 {
+		jsutil.Log("Self-test passed")
+		return
+	}
+
+	var names []string
+	for _, f := range failed {
+		names = append(names, f.Name)
+	}
+	msg := fmt.Sprintf("After updating to version %s, the following checks failed: %s.", r.Version, strings.Join(names, ", "))
+	if err := a.notifications.Notify(ctx, "SSH Agent self-test failed", msg); err != nil {
+		jsutil.LogError("failed to notify of self-test failure: %v", err)
+	}
+}
+
 func main() {
 	a := app.New(newBackground())
 	defer a.Release()
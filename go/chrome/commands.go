@@ -0,0 +1,94 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chrome
+
+import (
+	"fmt"
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Commands performs the commands that the user may invoke using keyboard
+// shortcuts. Commands, and their default shortcuts, are declared in the
+// manifest. See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/commands/
+//
+// Chrome delivers command events to the extension's background worker; the
+// worker must forward them to OnCommand().
+type Commands struct {
+	api js.Value
+
+	lock     sync.Mutex
+	handlers map[string]func(ctx jsutil.AsyncContext) // Protected by lock.
+}
+
+// NewCommands returns a Commands that reads assigned shortcuts using the
+// supplied object implementing the chrome.commands API.  If the object is null
+// or undefined, chrome.commands is used.
+func NewCommands(api js.Value) *Commands {
+	if api.IsUndefined() || api.IsNull() {
+		api = js.Global().Get("chrome").Get("commands")
+	}
+	return &Commands{
+		api:      api,
+		handlers: map[string]func(ctx jsutil.AsyncContext){},
+	}
+}
+
+// Handle configures the function invoked when the named command is invoked.
+func (c *Commands) Handle(name string, handler func(ctx jsutil.AsyncContext)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.handlers[name] = handler
+}
+
+// OnCommand must be invoked when chrome.commands.onCommand fires.
+func (c *Commands) OnCommand(ctx jsutil.AsyncContext, name string) {
+	c.lock.Lock()
+	handler := c.handlers[name]
+	c.lock.Unlock()
+
+	if handler == nil {
+		jsutil.LogError("Commands.OnCommand: unknown command %s", name)
+		return
+	}
+	jsutil.LogDebug("Commands.OnCommand: performing command %s", name)
+	handler(ctx)
+}
+
+// Shortcut returns the keyboard shortcut assigned to the named command (e.g.,
+// 'Alt+Shift+L'), or an empty string if the user has not assigned one.
+func (c *Commands) Shortcut(ctx jsutil.AsyncContext, name string) (string, error) {
+	all, err := jsutil.AsPromise(c.api.Call("getAll")).Await(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commands: %w", err)
+	}
+	for i := 0; i < all.Length(); i++ {
+		cmd := all.Index(i)
+		if cmd.Get("name").String() != name {
+			continue
+		}
+		if s := cmd.Get("shortcut"); s.Type() == js.TypeString {
+			return s.String(), nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown command %s", name)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chrome
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/norunners/vert"
+)
+
+type fakeCommand struct {
+	Name     string `js:"name"`
+	Shortcut string `js:"shortcut"`
+}
+
+// newFakeCommandsAPI returns an object implementing chrome.commands.getAll(),
+// which reports the supplied commands.
+func newFakeCommandsAPI(cmds []*fakeCommand) (js.Value, js.Func) {
+	api := js.Global().Get("Object").New()
+	getAll := js.FuncOf(func(this js.Value, args []js.Value) any {
+		arr := js.Global().Get("Array").New()
+		for _, c := range cmds {
+			arr.Call("push", vert.ValueOf(c).JSValue())
+		}
+		return js.Global().Get("Promise").Call("resolve", arr)
+	})
+	api.Set("getAll", getAll)
+	return api, getAll
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	api, getAll := newFakeCommandsAPI([]*fakeCommand{
+		{Name: "lock", Shortcut: "Alt+Shift+L"},
+		{Name: "unlock"},
+	})
+	defer getAll.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		c := NewCommands(api)
+		var invoked []string
+		c.Handle("lock", func(jsutil.AsyncContext) { invoked = append(invoked, "lock") })
+		c.Handle("unlock", func(jsutil.AsyncContext) { invoked = append(invoked, "unlock") })
+
+		c.OnCommand(ctx, "unlock")
+		c.OnCommand(ctx, "bogus")
+		c.OnCommand(ctx, "lock")
+		if len(invoked) != 2 || invoked[0] != "unlock" || invoked[1] != "lock" {
+			t.Errorf("incorrect commands invoked: got %v, want [unlock lock]", invoked)
+		}
+
+		for _, tc := range []struct {
+			name    string
+			want    string
+			wantErr bool
+		}{
+			{name: "lock", want: "Alt+Shift+L"},
+			{name: "unlock", want: ""},
+			{name: "bogus", wantErr: true},
+		} {
+			got, err := c.Shortcut(ctx, tc.name)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Shortcut(%s) returned error %v, want error %t", tc.name, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Shortcut(%s) incorrect: got %q, want %q", tc.name, got, tc.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,207 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n localizes user-visible strings using chrome.i18n. See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/api/i18n
+//
+// Messages are declared in _locales/<locale>/messages.json, and referred to
+// by name. A message may contain $1 through $9, which are replaced by the
+// substitutions supplied when it is looked up, and $$, which stands for a
+// literal dollar sign.
+//
+// Outside of the extension (e.g., in tests), chrome.i18n is unavailable, and
+// messages are looked up in the English locale embedded in the binary.
+package i18n
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// defaultMessages are the messages for the default locale, as packaged in
+// the extension.
+//
+//go:embed _locales/en/messages.json
+var defaultMessages []byte
+
+// entry is a message declared in a messages.json file.
+type entry struct {
+	// Message is the text of the message.
+	Message string `json:"message"`
+	// Placeholders are the named placeholders (e.g., $name$) that may
+	// appear in the message.
+	Placeholders map[string]struct {
+		// Content replaces the placeholder; it may itself refer to a
+		// substitution (e.g., $1).
+		Content string `json:"content"`
+	} `json:"placeholders"`
+}
+
+// parseMessages parses the contents of a messages.json file. Message names
+// are case-insensitive, and are therefore returned in lowercase.
+func parseMessages(data []byte) (map[string]string, error) {
+	var entries map[string]*entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse messages: %w", err)
+	}
+	result := map[string]string{}
+	for name, e := range entries {
+		msg := e.Message
+		for pname, p := range e.Placeholders {
+			msg = replaceFold(msg, "$"+pname+"$", p.Content)
+		}
+		result[strings.ToLower(name)] = msg
+	}
+	return result, nil
+}
+
+// replaceFold replaces occurrences of old in s, ignoring case, with new.
+func replaceFold(s, old, new string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(strings.ToLower(s), strings.ToLower(old))
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		b.WriteString(new)
+		s = s[i+len(old):]
+	}
+}
+
+// substitute replaces $1 through $9 in msg with the corresponding
+// substitutions (or nothing, if there are fewer), and $$ with $.
+func substitute(msg string, substitutions []string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if msg[i] == '$' && i+1 < len(msg) {
+			switch next := msg[i+1]; {
+			case next == '$':
+				b.WriteByte('$')
+				i++
+				continue
+			case next >= '1' && next <= '9':
+				if n := int(next - '1'); n < len(substitutions) {
+					b.WriteString(substitutions[n])
+				}
+				i++
+				continue
+			}
+		}
+		b.WriteByte(msg[i])
+	}
+	return b.String()
+}
+
+// Catalog looks up localized messages.
+type Catalog struct {
+	api      js.Value
+	messages map[string]string // Used if api is undefined.
+}
+
+// NewCatalog returns a Catalog that looks up messages using the supplied
+// object implementing the chrome.i18n API. If the object is null or
+// undefined, chrome.i18n is used if available; otherwise, messages are looked
+// up in the embedded English locale.
+func NewCatalog(api js.Value) *Catalog {
+	if api.IsUndefined() || api.IsNull() {
+		if chrome := js.Global().Get("chrome"); chrome.Truthy() {
+			api = chrome.Get("i18n")
+		}
+	}
+	if api.Truthy() && api.Get("getMessage").Type() == js.TypeFunction {
+		return &Catalog{api: api}
+	}
+
+	messages, err := parseMessages(defaultMessages)
+	if err != nil {
+		// The embedded messages are verified by tests.
+		panic(err)
+	}
+	return &Catalog{api: js.Undefined(), messages: messages}
+}
+
+// Message returns the named message, with the supplied substitutions. If
+// there is no such message, the name itself is returned, such that the
+// omission is evident.
+func (c *Catalog) Message(name string, substitutions ...string) string {
+	var msg string
+	if c.api.IsUndefined() {
+		if m, ok := c.messages[strings.ToLower(name)]; ok {
+			msg = substitute(m, substitutions)
+		}
+	} else {
+		subs := make([]any, len(substitutions))
+		for i, s := range substitutions {
+			subs[i] = s
+		}
+		msg = c.api.Call("getMessage", name, subs).String()
+	}
+	if msg == "" {
+		jsutil.LogError("i18n: no message named %s", name)
+		return name
+	}
+	return msg
+}
+
+var (
+	defaultCatalog     *Catalog
+	defaultCatalogOnce sync.Once
+)
+
+// Message returns the named message, with the supplied substitutions, as
+// looked up using chrome.i18n; see Catalog.Message.
+func Message(name string, substitutions ...string) string {
+	defaultCatalogOnce.Do(func() {
+		defaultCatalog = NewCatalog(js.Undefined())
+	})
+	return defaultCatalog.Message(name, substitutions...)
+}
+
+// Error is an error whose message is localized each time it is formatted.
+// Errors declared at package scope (e.g., those that callers check for using
+// errors.Is) may therefore be localized, even though they are created before
+// the locale is known.
+type Error struct {
+	name          string
+	substitutions []string
+}
+
+// NewError returns an error whose message is the named message, with the
+// supplied substitutions.
+func NewError(name string, substitutions ...string) error {
+	return &Error{name: name, substitutions: substitutions}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return Message(e.name, e.substitutions...)
+}
+
+// Wrap returns an error whose message is the named message, with the supplied
+// substitutions, followed by that of err, which it wraps. It is the localized
+// equivalent of fmt.Errorf("failed to ...: %w", err).
+func Wrap(err error, name string, substitutions ...string) error {
+	return fmt.Errorf("%s: %w", Message(name, substitutions...), err)
+}
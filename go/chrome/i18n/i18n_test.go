@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"errors"
+	"regexp"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefaultMessages(t *testing.T) {
+	t.Parallel()
+
+	messages, err := parseMessages(defaultMessages)
+	if err != nil {
+		t.Fatalf("failed to parse embedded messages: %v", err)
+	}
+	valid := regexp.MustCompile(`^[a-z0-9_@]+$`)
+	for name, msg := range messages {
+		if !valid.MatchString(name) {
+			t.Errorf("invalid message name %q", name)
+		}
+		if msg == "" {
+			t.Errorf("message %q is empty", name)
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description   string
+		data          string
+		substitutions []string
+		want          string
+	}{
+		{
+			description: "no substitutions",
+			data:        `{"greeting": {"message": "Hello"}}`,
+			want:        "Hello",
+		},
+		{
+			description:   "positional substitutions",
+			data:          `{"greeting": {"message": "Hello $2 and $1"}}`,
+			substitutions: []string{"Alice", "Bob"},
+			want:          "Hello Bob and Alice",
+		},
+		{
+			description:   "missing substitutions",
+			data:          `{"greeting": {"message": "Hello $1$2"}}`,
+			substitutions: []string{"Alice"},
+			want:          "Hello Alice",
+		},
+		{
+			description:   "escaped dollar",
+			data:          `{"greeting": {"message": "Costs $$$1"}}`,
+			substitutions: []string{"5"},
+			want:          "Costs $5",
+		},
+		{
+			description:   "named placeholders",
+			data:          `{"Greeting": {"message": "Hello $Name$", "placeholders": {"name": {"content": "$1"}}}}`,
+			substitutions: []string{"Alice"},
+			want:          "Hello Alice",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.description, func(t *testing.T) {
+			messages, err := parseMessages([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("parseMessages() failed: %v", err)
+			}
+			c := &Catalog{api: js.Undefined(), messages: messages}
+			if diff := cmp.Diff(c.Message("greeting", tc.substitutions...), tc.want); diff != "" {
+				t.Errorf("incorrect message; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	t.Parallel()
+
+	// Outside of the extension, the embedded messages are used.
+	c := NewCatalog(js.Undefined())
+	if diff := cmp.Diff(c.Message("errKeyNotFound"), "key not found"); diff != "" {
+		t.Errorf("incorrect message; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(c.Message("noSuchMessage"), "noSuchMessage"); diff != "" {
+		t.Errorf("incorrect message for missing name; -got +want: %s", diff)
+	}
+
+	// Otherwise, messages are looked up using chrome.i18n.
+	api := jsutil.NewObject()
+	getMessage := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if args[0].String() != "errKeyNotFound" {
+			return ""
+		}
+		return "clé introuvable: " + args[1].Index(0).String()
+	})
+	defer getMessage.Release()
+	api.Set("getMessage", getMessage)
+	c = NewCatalog(api)
+	if diff := cmp.Diff(c.Message("errKeyNotFound", "a"), "clé introuvable: a"); diff != "" {
+		t.Errorf("incorrect message; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(c.Message("noSuchMessage"), "noSuchMessage"); diff != "" {
+		t.Errorf("incorrect message for missing name; -got +want: %s", diff)
+	}
+}
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := NewError("errKeyNotFound")
+	if diff := cmp.Diff(errNotFound.Error(), "key not found"); diff != "" {
+		t.Errorf("incorrect error message; -got +want: %s", diff)
+	}
+
+	err := Wrap(errNotFound, "errKeyNotFound")
+	if diff := cmp.Diff(err.Error(), "key not found: key not found"); diff != "" {
+		t.Errorf("incorrect wrapped error message; -got +want: %s", diff)
+	}
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("errors.Is(%v, %v) = false; want true", err, errNotFound)
+	}
+}
@@ -0,0 +1,177 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chrome provides wrappers around Chrome extension APIs that are not
+// covered by more specific packages.
+package chrome
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/norunners/vert"
+)
+
+// Notifications displays desktop notifications and collects the user's
+// response to them. See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/notifications/
+//
+// Chrome delivers notification events to the extension's background worker;
+// the worker must forward them to OnButtonClicked() and OnClosed().
+type Notifications struct {
+	api js.Value
+
+	lock    sync.Mutex
+	pending map[string]chan int // Protected by lock.
+}
+
+// NewNotifications returns a Notifications that displays notifications using
+// the supplied object implementing the chrome.notifications API.  If the
+// object is null or undefined, chrome.notifications is used.
+func NewNotifications(api js.Value) *Notifications {
+	if api.IsUndefined() || api.IsNull() {
+		api = js.Global().Get("chrome").Get("notifications")
+	}
+	return &Notifications{
+		api:     api,
+		pending: map[string]chan int{},
+	}
+}
+
+const (
+	// Dismissed is returned by Ask when the notification was closed without
+	// any button being clicked.
+	Dismissed = -1
+)
+
+// notificationOptions mirrors chrome.notifications.NotificationOptions.
+type notificationOptions struct {
+	Type               string                `js:"type"`
+	IconURL            string                `js:"iconUrl"`
+	Title              string                `js:"title"`
+	Message            string                `js:"message"`
+	Buttons            []*notificationButton `js:"buttons"`
+	RequireInteraction bool                  `js:"requireInteraction"`
+}
+
+type notificationButton struct {
+	Title string `js:"title"`
+}
+
+const (
+	// iconURL is the icon displayed in notifications.
+	iconURL = "/img/icon128.png"
+)
+
+func newNotificationID() (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate notification ID: %w", err)
+	}
+	return "chrome-ssh-agent-" + i.String(), nil
+}
+
+// Ask displays a notification with the supplied buttons, and waits for the
+// user to respond. The index of the clicked button is returned, or Dismissed
+// if the notification was closed without clicking a button.
+func (n *Notifications) Ask(ctx jsutil.AsyncContext, title, message string, buttons []string) (int, error) {
+	id, err := newNotificationID()
+	if err != nil {
+		return Dismissed, err
+	}
+
+	opts := &notificationOptions{
+		Type:               "basic",
+		IconURL:            iconURL,
+		Title:              title,
+		Message:            message,
+		RequireInteraction: true,
+	}
+	for _, b := range buttons {
+		opts.Buttons = append(opts.Buttons, &notificationButton{Title: b})
+	}
+
+	c := make(chan int, 1)
+	n.lock.Lock()
+	n.pending[id] = c
+	n.lock.Unlock()
+
+	jsutil.LogDebug("Notifications.Ask: creating notification %s", id)
+	if _, err := jsutil.AsPromise(n.api.Call("create", id, vert.ValueOf(opts).JSValue())).Await(ctx); err != nil {
+		n.finish(id, Dismissed)
+		return Dismissed, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	result := <-c
+	jsutil.LogDebug("Notifications.Ask: notification %s finished with %d", id, result)
+	return result, nil
+}
+
+// Notify displays a notification that requires no response.
+func (n *Notifications) Notify(ctx jsutil.AsyncContext, title, message string) error {
+	id, err := newNotificationID()
+	if err != nil {
+		return err
+	}
+
+	opts := &notificationOptions{
+		Type:    "basic",
+		IconURL: iconURL,
+		Title:   title,
+		Message: message,
+	}
+	o := vert.ValueOf(opts).JSValue()
+	// Chrome rejects a null list of buttons.
+	o.Delete("buttons")
+	jsutil.LogDebug("Notifications.Notify: creating notification %s", id)
+	if _, err := jsutil.AsPromise(n.api.Call("create", id, o)).Await(ctx); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// finish completes the pending notification with the specified ID. It returns
+// true if the notification was pending.
+func (n *Notifications) finish(id string, result int) bool {
+	n.lock.Lock()
+	c, ok := n.pending[id]
+	delete(n.pending, id)
+	n.lock.Unlock()
+
+	if ok {
+		c <- result
+	}
+	return ok
+}
+
+// OnButtonClicked must be invoked when chrome.notifications.onButtonClicked
+// fires.
+func (n *Notifications) OnButtonClicked(id string, index int) {
+	if n.finish(id, index) {
+		n.api.Call("clear", id)
+	}
+}
+
+// OnClosed must be invoked when chrome.notifications.onClosed fires.
+func (n *Notifications) OnClosed(id string) {
+	n.finish(id, Dismissed)
+}
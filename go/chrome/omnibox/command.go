@@ -0,0 +1,149 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package omnibox
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// Verb is the operation requested by a command.
+type Verb string
+
+const (
+	// Load loads a configured key into the agent.
+	Load Verb = "load"
+	// Unload unloads a key from the agent.
+	Unload Verb = "unload"
+)
+
+// verbs are the supported verbs, in the order they are suggested.
+var verbs = []Verb{Load, Unload}
+
+var (
+	errEmpty      = errors.New("no command entered")
+	errUnknown    = errors.New("unknown command")
+	errMissingKey = errors.New("key name required")
+)
+
+// known indicates if the verb is supported.
+func (v Verb) known() bool {
+	for _, k := range verbs {
+		if k == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Command is a command entered by the user in the omnibox.
+type Command struct {
+	// Verb is the requested operation.
+	Verb Verb
+	// Name is the name of the key on which to operate.
+	Name string
+}
+
+// Parse parses a command of the form '<verb> <key name>'. The key name may
+// contain spaces.
+func Parse(text string) (*Command, error) {
+	verb, name, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if verb == "" {
+		return nil, errEmpty
+	}
+
+	v := Verb(strings.ToLower(verb))
+	if !v.known() {
+		return nil, fmt.Errorf("%w: %s", errUnknown, verb)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: %s", errMissingKey, v)
+	}
+	return &Command{Verb: v, Name: name}, nil
+}
+
+// Suggestion is a completion offered for partially-entered text.
+type Suggestion struct {
+	// Content is the text entered if the suggestion is selected.
+	Content string `js:"content"`
+	// Description is the text displayed for the suggestion. It uses the
+	// markup accepted by chrome.omnibox.
+	Description string `js:"description"`
+}
+
+// Suggest returns completions for partially-entered text. Verbs are suggested
+// until one is complete; then, the keys to which it applies are suggested:
+// keys that are not loaded for Load, and loaded keys for Unload.
+func Suggest(text string, configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*Suggestion {
+	text = strings.TrimLeft(text, " ")
+	verb, prefix, complete := strings.Cut(text, " ")
+	v := Verb(strings.ToLower(verb))
+
+	if !complete {
+		var result []*Suggestion
+		for _, k := range verbs {
+			if strings.HasPrefix(string(k), string(v)) {
+				result = append(result, &Suggestion{
+					Content:     string(k) + " ",
+					Description: fmt.Sprintf("<match>%s</match> <dim>&lt;key name&gt;</dim>", k),
+				})
+			}
+		}
+		return result
+	}
+	if !v.known() {
+		return nil
+	}
+
+	loadedIDs := make(map[keys.ID]bool)
+	for _, l := range loaded {
+		if id := l.ID(); id != keys.InvalidID {
+			loadedIDs[id] = true
+		}
+	}
+
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	var result []*Suggestion
+	for _, c := range configured {
+		// Only suggest keys whose state the command would change.
+		if loadedIDs[keys.ID(c.ID)] != (v == Unload) {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(c.Name), prefix) {
+			continue
+		}
+		desc := fmt.Sprintf("%s <match>%s</match>", v, html.EscapeString(c.Name))
+		if v == Load && c.Encrypted {
+			desc += " <dim>(requires a passphrase; use the popup)</dim>"
+		}
+		result = append(result, &Suggestion{
+			Content:     fmt.Sprintf("%s %s", v, c.Name),
+			Description: desc,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Content < result[j].Content
+	})
+	return result
+}
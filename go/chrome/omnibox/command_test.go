@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package omnibox
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		text        string
+		want        *Command
+		wantErr     error
+	}{
+		{
+			description: "load",
+			text:        "load work-key",
+			want:        &Command{Verb: Load, Name: "work-key"},
+		},
+		{
+			description: "unload",
+			text:        "unload work-key",
+			want:        &Command{Verb: Unload, Name: "work-key"},
+		},
+		{
+			description: "case-insensitive verb",
+			text:        "LOAD work-key",
+			want:        &Command{Verb: Load, Name: "work-key"},
+		},
+		{
+			description: "name with spaces",
+			text:        "  load   my work key  ",
+			want:        &Command{Verb: Load, Name: "my work key"},
+		},
+		{
+			description: "empty",
+			text:        "   ",
+			wantErr:     errEmpty,
+		},
+		{
+			description: "unknown verb",
+			text:        "remove work-key",
+			wantErr:     errUnknown,
+		},
+		{
+			description: "missing key name",
+			text:        "load ",
+			wantErr:     errMissingKey,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tc.text)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect command; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	configured := []*keys.ConfiguredKey{
+		{ID: "1", Name: "work-key"},
+		{ID: "2", Name: "web-key", Encrypted: true},
+		{ID: "3", Name: "<home>"},
+	}
+	loaded := []*keys.LoadedKey{
+		{Comment: "chrome-ssh-agent:3"},
+	}
+
+	testcases := []struct {
+		description string
+		text        string
+		want        []*Suggestion
+	}{
+		{
+			description: "all verbs",
+			text:        "",
+			want: []*Suggestion{
+				{Content: "load ", Description: "<match>load</match> <dim>&lt;key name&gt;</dim>"},
+				{Content: "unload ", Description: "<match>unload</match> <dim>&lt;key name&gt;</dim>"},
+			},
+		},
+		{
+			description: "partial verb",
+			text:        "un",
+			want: []*Suggestion{
+				{Content: "unload ", Description: "<match>unload</match> <dim>&lt;key name&gt;</dim>"},
+			},
+		},
+		{
+			description: "load suggests unloaded keys",
+			text:        "load ",
+			want: []*Suggestion{
+				{Content: "load web-key", Description: "load <match>web-key</match> <dim>(requires a passphrase; use the popup)</dim>"},
+				{Content: "load work-key", Description: "load <match>work-key</match>"},
+			},
+		},
+		{
+			description: "load filters by prefix",
+			text:        "load WO",
+			want: []*Suggestion{
+				{Content: "load work-key", Description: "load <match>work-key</match>"},
+			},
+		},
+		{
+			description: "unload suggests loaded keys",
+			text:        "unload ",
+			want: []*Suggestion{
+				{Content: "unload <home>", Description: "unload <match>&lt;home&gt;</match>"},
+			},
+		},
+		{
+			description: "unknown verb",
+			text:        "remove ",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got := Suggest(tc.text, configured, loaded)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect suggestions; -got +want: %s", diff)
+			}
+		})
+	}
+}
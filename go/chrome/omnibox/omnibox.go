@@ -0,0 +1,151 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package omnibox allows keys to be loaded and unloaded by typing commands in
+// the browser's address bar (e.g., 'ssha load work-key').
+package omnibox
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/norunners/vert"
+)
+
+var (
+	errNotFound           = errors.New("key not found")
+	errPassphraseRequired = errors.New("key requires a passphrase; load it from the extension's popup")
+)
+
+// Notifier displays the outcome of a command to the user.
+type Notifier interface {
+	// Notify displays a message that requires no response.
+	Notify(ctx jsutil.AsyncContext, title, message string) error
+}
+
+// Omnibox handles commands entered in the omnibox. See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/omnibox/
+//
+// Chrome delivers omnibox events to the extension's background worker; the
+// worker must forward them to OnInputChanged() and OnInputEntered().
+type Omnibox struct {
+	api      js.Value
+	mgr      keys.Manager
+	notifier Notifier
+}
+
+// New returns an Omnibox that performs commands using the supplied manager,
+// and reports their outcome using the supplied notifier. api is the object
+// implementing the chrome.omnibox API; if it is null or undefined,
+// chrome.omnibox is used.
+func New(api js.Value, mgr keys.Manager, notifier Notifier) *Omnibox {
+	if api.IsUndefined() || api.IsNull() {
+		api = js.Global().Get("chrome").Get("omnibox")
+	}
+	return &Omnibox{
+		api:      api,
+		mgr:      mgr,
+		notifier: notifier,
+	}
+}
+
+// defaultSuggestion describes the supported commands before the user has
+// entered any text.
+const defaultSuggestion = "load <dim>&lt;key name&gt;</dim> | unload <dim>&lt;key name&gt;</dim>"
+
+// Init configures the suggestion displayed before the user enters a command.
+func (o *Omnibox) Init() {
+	o.api.Call("setDefaultSuggestion", vert.ValueOf(&struct {
+		Description string `js:"description"`
+	}{Description: defaultSuggestion}).JSValue())
+}
+
+// OnInputChanged must be invoked when chrome.omnibox.onInputChanged fires.
+// suggest is the callback supplied with the event.
+func (o *Omnibox) OnInputChanged(ctx jsutil.AsyncContext, text string, suggest js.Value) {
+	var suggestions []*Suggestion
+	if configured, err := o.mgr.Configured(ctx); err != nil {
+		jsutil.LogError("Omnibox.OnInputChanged: failed to get configured keys: %v", err)
+	} else if loaded, err := o.mgr.Loaded(ctx); err != nil {
+		jsutil.LogError("Omnibox.OnInputChanged: failed to get loaded keys: %v", err)
+	} else {
+		suggestions = Suggest(text, configured, loaded)
+	}
+
+	arr := js.Global().Get("Array").New()
+	for _, s := range suggestions {
+		arr.Call("push", vert.ValueOf(s).JSValue())
+	}
+	suggest.Invoke(arr)
+}
+
+// OnInputEntered must be invoked when chrome.omnibox.onInputEntered fires.
+// The command is performed, and the user notified of the outcome.
+func (o *Omnibox) OnInputEntered(ctx jsutil.AsyncContext, text string) {
+	title := "SSH Agent"
+	message, err := o.run(ctx, text)
+	if err != nil {
+		title, message = "SSH Agent command failed", err.Error()
+	}
+	if err := o.notifier.Notify(ctx, title, message); err != nil {
+		jsutil.LogError("Omnibox.OnInputEntered: failed to notify: %v", err)
+	}
+}
+
+// run parses and performs the command, returning a message describing the
+// outcome.
+func (o *Omnibox) run(ctx jsutil.AsyncContext, text string) (string, error) {
+	cmd, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	configured, err := o.mgr.Configured(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get configured keys: %w", err)
+	}
+	var key *keys.ConfiguredKey
+	for _, c := range configured {
+		if c.Name == cmd.Name {
+			key = c
+			break
+		}
+	}
+	if key == nil {
+		return "", fmt.Errorf("%w: %s", errNotFound, cmd.Name)
+	}
+
+	switch cmd.Verb {
+	case Load:
+		if key.Encrypted {
+			return "", fmt.Errorf("%w: %s", errPassphraseRequired, cmd.Name)
+		}
+		if err := o.mgr.Load(ctx, keys.ID(key.ID), ""); err != nil {
+			return "", fmt.Errorf("failed to load key %s: %w", cmd.Name, err)
+		}
+		return fmt.Sprintf("Loaded key %s", cmd.Name), nil
+	case Unload:
+		if err := o.mgr.Unload(ctx, keys.ID(key.ID)); err != nil {
+			return "", fmt.Errorf("failed to unload key %s: %w", cmd.Name, err)
+		}
+		return fmt.Sprintf("Unloaded key %s", cmd.Name), nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknown, cmd.Verb)
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package omnibox
+
+import (
+	"syscall/js"
+	"testing"
+
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+type notification struct {
+	Title   string
+	Message string
+}
+
+type fakeNotifier struct {
+	notifications []notification
+}
+
+func (f *fakeNotifier) Notify(ctx jsutil.AsyncContext, title, message string) error {
+	f.notifications = append(f.notifications, notification{Title: title, Message: message})
+	return nil
+}
+
+func newManager() *keys.DefaultManager {
+	return keys.NewManager(agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+}
+
+func TestOnInputEntered(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		commands    []string
+		want        []notification
+		wantLoaded  int
+	}{
+		{
+			description: "load key",
+			commands:    []string{"load plain"},
+			want: []notification{
+				{Title: "SSH Agent", Message: "Loaded key plain"},
+			},
+			wantLoaded: 1,
+		},
+		{
+			description: "unload key",
+			commands:    []string{"load plain", "unload plain"},
+			want: []notification{
+				{Title: "SSH Agent", Message: "Loaded key plain"},
+				{Title: "SSH Agent", Message: "Unloaded key plain"},
+			},
+		},
+		{
+			description: "encrypted key",
+			commands:    []string{"load encrypted"},
+			want: []notification{
+				{Title: "SSH Agent command failed", Message: "key requires a passphrase; load it from the extension's popup: encrypted"},
+			},
+		},
+		{
+			description: "unknown key",
+			commands:    []string{"load bogus"},
+			want: []notification{
+				{Title: "SSH Agent command failed", Message: "key not found: bogus"},
+			},
+		},
+		{
+			description: "unknown command",
+			commands:    []string{"remove plain"},
+			want: []notification{
+				{Title: "SSH Agent command failed", Message: "unknown command: remove"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr := newManager()
+				if _, err := mgr.Add(ctx, "plain", testdata.WithoutPassphrase.Private); err != nil {
+					t.Errorf("failed to add key: %v", err)
+					return
+				}
+				if _, err := mgr.Add(ctx, "encrypted", testdata.WithPassphrase.Private); err != nil {
+					t.Errorf("failed to add key: %v", err)
+					return
+				}
+
+				notifier := &fakeNotifier{}
+				o := New(js.ValueOf(map[string]interface{}{}), mgr, notifier)
+				for _, c := range tc.commands {
+					o.OnInputEntered(ctx, c)
+				}
+				if diff := cmp.Diff(notifier.notifications, tc.want); diff != "" {
+					t.Errorf("incorrect notifications; -got +want: %s", diff)
+				}
+
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Errorf("failed to get loaded keys: %v", err)
+					return
+				}
+				if diff := cmp.Diff(len(loaded), tc.wantLoaded); diff != "" {
+					t.Errorf("incorrect number of loaded keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestOnInputChanged(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr := newManager()
+		if _, err := mgr.Add(ctx, "plain", testdata.WithoutPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+
+		var got []string
+		suggest := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			arr := jsutil.SingleArg(args)
+			for i := 0; i < arr.Length(); i++ {
+				got = append(got, arr.Index(i).Get("content").String())
+			}
+			return nil
+		})
+		defer suggest.Release()
+
+		o := New(js.ValueOf(map[string]interface{}{}), mgr, &fakeNotifier{})
+		o.OnInputChanged(ctx, "load p", suggest.Value)
+		if diff := cmp.Diff(got, []string{"load plain"}); diff != "" {
+			t.Errorf("incorrect suggestions; -got +want: %s", diff)
+		}
+	})
+}
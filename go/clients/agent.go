@@ -0,0 +1,141 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// ErrRevoked indicates that the client's access to the agent was
+	// revoked.
+	ErrRevoked = errors.New("client access revoked")
+	// ErrKeyNotGranted indicates that the client may not use the key.
+	ErrKeyNotGranted = errors.New("key not granted to client")
+)
+
+// Agent wraps the agent for a single connection, and applies the client's
+// record to each request: loaded keys the client may not use are hidden
+// and cannot be used for signing, and requests to list keys or sign fail if
+// the client's access is revoked. The record is read on every request, so changes take
+// effect without the client reconnecting.
+//
+// Agent implements the agent.ExtendedAgent interface.
+type Agent struct {
+	agent.Agent
+	store *Store
+	id    string
+}
+
+// NewAgent returns an Agent for a connection from the specified client.
+func NewAgent(agt agent.Agent, store *Store, id string) *Agent {
+	return &Agent{
+		Agent: agt,
+		store: store,
+		id:    id,
+	}
+}
+
+// client returns the client's record. Requests are served outside of an
+// AsyncContext, so the record is read asynchronously.
+func (a *Agent) client() (*Client, error) {
+	var c *Client
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		c, err = a.store.Get(ctx, a.id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		// The record was removed while connected; apply no
+		// restrictions until it is recreated on the next connection.
+		return &Client{ID: a.id}, nil
+	}
+	if c.Decision == Denied {
+		return nil, fmt.Errorf("%w: %s", ErrRevoked, a.id)
+	}
+	return c, nil
+}
+
+// List implements agent.Agent.List.
+func (a *Agent) List() ([]*agent.Key, error) {
+	c, err := a.client()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.Agent.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*agent.Key
+	for _, k := range keys {
+		if c.Permits(ssh.FingerprintSHA256(k)) {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+// check returns an error if the client may not sign using the key.
+func (a *Agent) check(key ssh.PublicKey) error {
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if fp := ssh.FingerprintSHA256(key); !c.Permits(fp) {
+		return fmt.Errorf("%w: key %s, client %s", ErrKeyNotGranted, fp, a.id)
+	}
+	return nil
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if err := a.check(key); err != nil {
+		return nil, err
+	}
+	return a.Agent.Sign(key, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	if err := a.check(key); err != nil {
+		return nil, err
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
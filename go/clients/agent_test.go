@@ -0,0 +1,130 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		// update modifies the client's record; the key fingerprints
+		// are supplied.
+		update   func(c *Client, rsa, ed25519 string)
+		wantList []string
+		wantErr  map[string]error
+	}{
+		{
+			description: "no restrictions",
+			update:      func(c *Client, rsa, ed25519 string) {},
+			wantList:    []string{"rsa", "ed25519"},
+		},
+		{
+			description: "restricted to key",
+			update: func(c *Client, rsa, ed25519 string) {
+				c.Keys = []string{ed25519}
+			},
+			wantList: []string{"ed25519"},
+			wantErr:  map[string]error{"rsa": ErrKeyNotGranted},
+		},
+		{
+			description: "revoked",
+			update: func(c *Client, rsa, ed25519 string) {
+				c.Decision = Denied
+			},
+			wantErr: map[string]error{"rsa": ErrRevoked, "ed25519": ErrRevoked},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				agt := agent.NewKeyring()
+				names := map[string]string{}
+				pubs := map[string]ssh.PublicKey{}
+				for name, pem := range map[string]string{
+					"rsa":     testdata.WithoutPassphrase.Private,
+					"ed25519": testdata.ED25519WithoutPassphrase.Private,
+				} {
+					priv, err := ssh.ParseRawPrivateKey([]byte(pem))
+					if err != nil {
+						t.Fatalf("failed to parse key: %v", err)
+					}
+					if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: name}); err != nil {
+						t.Fatalf("failed to add key: %v", err)
+					}
+					signer, err := ssh.NewSignerFromKey(priv)
+					if err != nil {
+						t.Fatalf("failed to create signer: %v", err)
+					}
+					pubs[name] = signer.PublicKey()
+					names[ssh.FingerprintSHA256(signer.PublicKey())] = name
+				}
+
+				s := NewStore(storage.NewRaw(st.NewMemArea()))
+				if _, err := s.Seen(ctx, "client-1", time.Unix(1000, 0)); err != nil {
+					t.Fatalf("failed to record client: %v", err)
+				}
+				if err := s.Update(ctx, "client-1", func(c *Client) {
+					tc.update(c, ssh.FingerprintSHA256(pubs["rsa"]), ssh.FingerprintSHA256(pubs["ed25519"]))
+				}); err != nil {
+					t.Fatalf("failed to update client: %v", err)
+				}
+
+				a := NewAgent(agt, s, "client-1")
+				listed, err := a.List()
+				if len(tc.wantList) == 0 {
+					if err == nil && len(listed) > 0 {
+						t.Errorf("List() unexpectedly returned keys: %v", listed)
+					}
+				} else if err != nil {
+					t.Errorf("List() failed: %v", err)
+				}
+				var gotList []string
+				for _, k := range listed {
+					gotList = append(gotList, names[ssh.FingerprintSHA256(k)])
+				}
+				if diff := cmp.Diff(gotList, tc.wantList, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect keys listed; -got +want: %s", diff)
+				}
+
+				for name, pub := range pubs {
+					_, err := a.Sign(pub, []byte("data"))
+					if want := tc.wantErr[name]; !errors.Is(err, want) {
+						t.Errorf("Sign(%s) returned incorrect error: got %v, want %v", name, err, want)
+					}
+				}
+			})
+		})
+	}
+}
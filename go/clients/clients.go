@@ -0,0 +1,164 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clients maintains a record of each client (e.g., another
+// extension, or a web application) that has connected to the agent. The
+// user may name each client, restrict the keys it may use, and revoke its
+// access.
+package clients
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Values for Client.Decision, the user's decision on whether a client may
+// connect.
+const (
+	// Undecided indicates the user has not decided. Whether the client
+	// may connect depends on settings.
+	Undecided = ""
+	// Allowed indicates the client may connect.
+	Allowed = "allowed"
+	// Denied indicates the client may not connect, including if access
+	// was revoked after it was previously allowed.
+	Denied = "denied"
+)
+
+// Client is the record for a single client.
+type Client struct {
+	// ID identifies the client; this is the extension ID for extensions,
+	// or the origin for web applications.
+	ID string `js:"id"`
+	// Name is a friendly name assigned by the user. Empty if none.
+	Name string `js:"name"`
+	// Decision is the user's decision on whether the client may connect;
+	// one of Undecided, Allowed or Denied.
+	Decision string `js:"decision"`
+	// Keys are the SHA256 fingerprints of the keys the client may use. If
+	// empty, the client may use all loaded keys.
+	Keys []string `js:"keys"`
+	// FirstSeen is the time at which the client first connected, in
+	// seconds since the Unix epoch.
+	FirstSeen int64 `js:"firstSeen"`
+	// LastSeen is the time at which the client most recently connected,
+	// in seconds since the Unix epoch.
+	LastSeen int64 `js:"lastSeen"`
+}
+
+// DisplayName returns the name by which the client is displayed to the user.
+func (c *Client) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.ID
+}
+
+// Permits returns true if the client may use the key with the specified
+// SHA256 fingerprint.
+func (c *Client) Permits(fingerprint string) bool {
+	return len(c.Keys) == 0 || slices.Contains(c.Keys, fingerprint)
+}
+
+var (
+	// clientPrefixes are the prefixes used for keys when storing client
+	// records.
+	clientPrefixes = []string{"client"}
+)
+
+// Store reads and writes client records.
+type Store struct {
+	clients *storage.Typed[Client]
+}
+
+// NewStore returns a Store that persists client records in the supplied
+// storage area.
+func NewStore(store storage.Area) *Store {
+	return &Store{
+		clients: storage.NewTyped[Client](store, clientPrefixes),
+	}
+}
+
+// Get returns the record for the specified client. Nil is returned if there
+// is no record for the client.
+func (s *Store) Get(ctx jsutil.AsyncContext, id string) (*Client, error) {
+	c, err := s.clients.Read(ctx, func(c *Client) bool { return c.ID == id })
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client: %w", err)
+	}
+	return c, nil
+}
+
+// List returns the records for all clients, ordered by the time they most
+// recently connected (most recent first).
+func (s *Store) List(ctx jsutil.AsyncContext) ([]*Client, error) {
+	clients, err := s.clients.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clients: %w", err)
+	}
+	sort.SliceStable(clients, func(i, j int) bool {
+		if clients[i].LastSeen != clients[j].LastSeen {
+			return clients[i].LastSeen > clients[j].LastSeen
+		}
+		return clients[i].ID < clients[j].ID
+	})
+	return clients, nil
+}
+
+// Seen records that the specified client connected at the specified time,
+// creating a record for it if necessary. The updated record is returned.
+func (s *Store) Seen(ctx jsutil.AsyncContext, id string, now time.Time) (*Client, error) {
+	c, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = &Client{ID: id, FirstSeen: now.Unix(), LastSeen: now.Unix()}
+		if err := s.clients.Write(ctx, c); err != nil {
+			return nil, fmt.Errorf("failed to write client: %w", err)
+		}
+		return c, nil
+	}
+
+	c.LastSeen = now.Unix()
+	if err := s.Update(ctx, id, func(u *Client) { u.LastSeen = c.LastSeen }); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update modifies the record for the specified client. It is not an error
+// if there is no record for the client.
+func (s *Store) Update(ctx jsutil.AsyncContext, id string, update func(c *Client)) error {
+	if err := s.clients.Update(ctx, func(c *Client) bool { return c.ID == id }, update); err != nil {
+		return fmt.Errorf("failed to update client: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the record for the specified client. The client is treated
+// as new the next time it connects.
+func (s *Store) Delete(ctx jsutil.AsyncContext, id string) error {
+	if err := s.clients.Delete(ctx, func(c *Client) bool { return c.ID == id }); err != nil {
+		return fmt.Errorf("failed to delete client: %w", err)
+	}
+	return nil
+}
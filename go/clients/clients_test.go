@@ -0,0 +1,182 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		sequence    func(ctx jsutil.AsyncContext, s *Store) error
+		want        []*Client
+	}{
+		{
+			description: "no clients",
+			sequence:    func(ctx jsutil.AsyncContext, s *Store) error { return nil },
+		},
+		{
+			description: "first connection recorded",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				_, err := s.Seen(ctx, "client-1", time.Unix(1000, 0))
+				return err
+			},
+			want: []*Client{
+				{ID: "client-1", FirstSeen: 1000, LastSeen: 1000},
+			},
+		},
+		{
+			description: "later connection updates last seen",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				if _, err := s.Seen(ctx, "client-1", time.Unix(1000, 0)); err != nil {
+					return err
+				}
+				_, err := s.Seen(ctx, "client-1", time.Unix(2000, 0))
+				return err
+			},
+			want: []*Client{
+				{ID: "client-1", FirstSeen: 1000, LastSeen: 2000},
+			},
+		},
+		{
+			description: "most recent first",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				if _, err := s.Seen(ctx, "client-1", time.Unix(1000, 0)); err != nil {
+					return err
+				}
+				if _, err := s.Seen(ctx, "client-2", time.Unix(2000, 0)); err != nil {
+					return err
+				}
+				_, err := s.Seen(ctx, "client-3", time.Unix(1500, 0))
+				return err
+			},
+			want: []*Client{
+				{ID: "client-2", FirstSeen: 2000, LastSeen: 2000},
+				{ID: "client-3", FirstSeen: 1500, LastSeen: 1500},
+				{ID: "client-1", FirstSeen: 1000, LastSeen: 1000},
+			},
+		},
+		{
+			description: "update client",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				if _, err := s.Seen(ctx, "client-1", time.Unix(1000, 0)); err != nil {
+					return err
+				}
+				return s.Update(ctx, "client-1", func(c *Client) {
+					c.Name = "My Terminal"
+					c.Decision = Denied
+					c.Keys = []string{"SHA256:abc"}
+				})
+			},
+			want: []*Client{
+				{ID: "client-1", Name: "My Terminal", Decision: Denied, Keys: []string{"SHA256:abc"}, FirstSeen: 1000, LastSeen: 1000},
+			},
+		},
+		{
+			description: "update missing client",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				return s.Update(ctx, "client-1", func(c *Client) { c.Name = "My Terminal" })
+			},
+		},
+		{
+			description: "delete client",
+			sequence: func(ctx jsutil.AsyncContext, s *Store) error {
+				if _, err := s.Seen(ctx, "client-1", time.Unix(1000, 0)); err != nil {
+					return err
+				}
+				if _, err := s.Seen(ctx, "client-2", time.Unix(2000, 0)); err != nil {
+					return err
+				}
+				return s.Delete(ctx, "client-1")
+			},
+			want: []*Client{
+				{ID: "client-2", FirstSeen: 2000, LastSeen: 2000},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				s := NewStore(storage.NewRaw(st.NewMemArea()))
+				if err := tc.sequence(ctx, s); err != nil {
+					t.Fatalf("sequence failed: %v", err)
+				}
+				got, err := s.List(ctx)
+				if err != nil {
+					t.Fatalf("List() failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect clients; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestPermits(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		keys        []string
+		fingerprint string
+		want        bool
+	}{
+		{
+			description: "no restriction",
+			fingerprint: "SHA256:abc",
+			want:        true,
+		},
+		{
+			description: "granted",
+			keys:        []string{"SHA256:abc", "SHA256:def"},
+			fingerprint: "SHA256:def",
+			want:        true,
+		},
+		{
+			description: "not granted",
+			keys:        []string{"SHA256:abc"},
+			fingerprint: "SHA256:def",
+			want:        false,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Client{ID: "client-1", Keys: tc.keys}
+			if diff := cmp.Diff(c.Permits(tc.fingerprint), tc.want); diff != "" {
+				t.Errorf("incorrect result; -got +want: %s", diff)
+			}
+		})
+	}
+}
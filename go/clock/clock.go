@@ -0,0 +1,82 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clock abstracts the passage of time, such that code that depends on
+// it can be tested deterministically.
+package clock
+
+import (
+	"time"
+)
+
+// Clock provides the current time, and notifies when time has elapsed.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer creates a Timer that sends the current time on its channel
+	// after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event, analogous to time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It returns true if the call
+	// stops the timer, and false if the timer has already fired or been
+	// stopped.
+	Stop() bool
+}
+
+// Real is the Clock backed by the system time.
+//
+// In Javascript, Go's timers are implemented by the runtime using
+// setTimeout(). They do not survive the extension's service worker being
+// suspended; anything that must fire across suspension requires an alarm
+// instead.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+// Now implements Clock.Now.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Clock.After.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTimer implements Clock.NewTimer.
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+// C implements Timer.C.
+func (r realTimer) C() <-chan time.Time {
+	return r.t.C
+}
+
+// Stop implements Timer.Stop.
+func (r realTimer) Stop() bool {
+	return r.t.Stop()
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealAfter(t *testing.T) {
+	t.Parallel()
+
+	start := Real.Now()
+	got := <-Real.After(time.Millisecond)
+	if got.Before(start.Add(time.Millisecond)) {
+		t.Errorf("After fired early: started %v, fired %v", start, got)
+	}
+}
+
+func TestRealTimerStop(t *testing.T) {
+	t.Parallel()
+
+	timer := Real.NewTimer(time.Hour)
+	if !timer.Stop() {
+		t.Errorf("Stop() returned false for pending timer")
+	}
+	if timer.Stop() {
+		t.Errorf("Stop() returned true for stopped timer")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakes provides a fake implementation of clock.Clock for use in tests.
+package fakes
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+)
+
+// Clock is a clock.Clock for use in tests. Time only advances when explicitly
+// requested, at which point any timers that are due fire.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewClock returns a Clock whose current time is now.
+func NewClock(now time.Time) *Clock {
+	f := &Clock{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now implements clock.Clock.Now.
+func (f *Clock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After implements clock.Clock.After.
+func (f *Clock) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer implements clock.Clock.NewTimer.
+func (f *Clock) NewTimer(d time.Duration) clock.Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{
+		clock:    f,
+		ch:       make(chan time.Time, 1),
+		deadline: f.now.Add(d),
+	}
+	if d <= 0 {
+		t.ch <- f.now
+		return t
+	}
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+	return t
+}
+
+// Advance moves the current time forward by d, firing any timers that become
+// due in order of their deadlines.
+func (f *Clock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.timers, func(i, j int) bool { return f.timers[i].deadline.Before(f.timers[j].deadline) })
+	var pending []*fakeTimer
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- f.now
+	}
+	f.timers = pending
+}
+
+// Pending returns the number of timers that have not yet fired or been
+// stopped.
+func (f *Clock) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil blocks until at least n timers are pending. It allows a test to
+// wait for the code under test to start waiting before advancing time.
+func (f *Clock) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// stop removes the timer, returning true if it was pending.
+func (f *Clock) stop(t *fakeTimer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, p := range f.timers {
+		if p == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *Clock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+// C implements clock.Timer.C.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop implements clock.Timer.Stop.
+func (t *fakeTimer) Stop() bool {
+	return t.clock.stop(t)
+}
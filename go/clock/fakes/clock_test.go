@@ -0,0 +1,127 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fired returns the time sent on ch, or the zero time if none has been sent.
+func fired(ch <-chan time.Time) time.Time {
+	select {
+	case t := <-ch:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func TestFakeTimers(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		delay       time.Duration
+		advance     []time.Duration
+		stop        bool
+		want        time.Time
+		wantPending int
+	}{
+		{
+			description: "not yet due",
+			delay:       time.Minute,
+			advance:     []time.Duration{59 * time.Second},
+			wantPending: 1,
+		},
+		{
+			description: "due exactly",
+			delay:       time.Minute,
+			advance:     []time.Duration{time.Minute},
+			want:        epoch.Add(time.Minute),
+		},
+		{
+			description: "due after multiple advances",
+			delay:       time.Minute,
+			advance:     []time.Duration{30 * time.Second, 45 * time.Second},
+			want:        epoch.Add(75 * time.Second),
+		},
+		{
+			description: "zero delay fires immediately",
+			want:        epoch,
+		},
+		{
+			description: "stopped",
+			delay:       time.Minute,
+			advance:     []time.Duration{time.Minute},
+			stop:        true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			f := NewClock(epoch)
+			timer := f.NewTimer(tc.delay)
+			if tc.stop {
+				if !timer.Stop() {
+					t.Errorf("Stop() returned false for pending timer")
+				}
+			}
+			for _, d := range tc.advance {
+				f.Advance(d)
+			}
+
+			if diff := cmp.Diff(fired(timer.C()), tc.want); diff != "" {
+				t.Errorf("incorrect fired time; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(f.Pending(), tc.wantPending); diff != "" {
+				t.Errorf("incorrect pending timers; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFakeNow(t *testing.T) {
+	t.Parallel()
+
+	f := NewClock(epoch)
+	f.Advance(time.Hour)
+	if diff := cmp.Diff(f.Now(), epoch.Add(time.Hour)); diff != "" {
+		t.Errorf("incorrect time; -got +want: %s", diff)
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	t.Parallel()
+
+	f := NewClock(epoch)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-f.After(time.Second)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	if diff := cmp.Diff(<-done, epoch.Add(time.Second)); diff != "" {
+		t.Errorf("incorrect fired time; -got +want: %s", diff)
+	}
+}
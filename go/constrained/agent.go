@@ -0,0 +1,246 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package constrained enforces the constraints that clients may place on keys
+// they add to the agent (e.g., using 'ssh-add -t' or 'ssh-add -c').
+package constrained
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// ErrUnsupportedConstraint indicates that a key was added with a
+	// constraint extension that the agent does not support. Such keys are
+	// rejected rather than added without the constraint.
+	ErrUnsupportedConstraint = errors.New("unsupported key constraint")
+)
+
+// constraints are the constraints applied to a key.
+type constraints struct {
+	// expire is when the key is removed, or the zero time if it does not
+	// expire.
+	expire time.Time
+	// confirm indicates that each signature must be confirmed.
+	confirm bool
+	// comment is the comment with which the key was added.
+	comment string
+}
+
+// Agent wraps an agent, and enforces constraints on the keys added to it:
+// keys added with a lifetime are removed once it elapses, and keys added with
+// the confirmation constraint are reported by NeedsConfirmation. Keys added
+// with any other constraint are rejected.
+//
+// Unlike the wrappers for individual connections, a single Agent must wrap
+// the keyring and be shared by all connections and the key manager, such
+// that it observes every key that is added.
+//
+// Agent implements the agent.ExtendedAgent interface.
+type Agent struct {
+	agent.Agent
+	clock clock.Clock
+
+	mu sync.Mutex
+	// keys are the constraints for keys, indexed by the key's public
+	// key blob. Keys without constraints are not present.
+	keys map[string]*constraints
+}
+
+// New returns an Agent that enforces constraints on keys added to agt.
+func New(agt agent.Agent, clk clock.Clock) *Agent {
+	return &Agent{
+		Agent: agt,
+		clock: clk,
+		keys:  map[string]*constraints{},
+	}
+}
+
+// blobOf returns the public key blob for the key being added.
+func blobOf(key agent.AddedKey) (string, error) {
+	if key.Certificate != nil {
+		return string(key.Certificate.Marshal()), nil
+	}
+	// Keys unknown to the SSH library (e.g., those backed by security keys)
+	// supply their own public key.
+	if k, ok := key.PrivateKey.(interface{ PublicKey() ssh.PublicKey }); ok {
+		return string(k.PublicKey().Marshal()), nil
+	}
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse key: %w", err)
+	}
+	return string(signer.PublicKey().Marshal()), nil
+}
+
+// Add implements agent.Agent.Add.
+func (a *Agent) Add(key agent.AddedKey) error {
+	if len(key.ConstraintExtensions) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupportedConstraint, key.ConstraintExtensions[0].ExtensionName)
+	}
+	blob, err := blobOf(key)
+	if err != nil {
+		return err
+	}
+
+	var c *constraints
+	if key.LifetimeSecs > 0 || key.ConfirmBeforeUse {
+		c = &constraints{
+			confirm: key.ConfirmBeforeUse,
+			comment: key.Comment,
+		}
+		if key.LifetimeSecs > 0 {
+			c.expire = a.clock.Now().Add(time.Duration(key.LifetimeSecs) * time.Second)
+		}
+	}
+
+	// Constraints are enforced here, so the wrapped agent need not
+	// (and, for lifetimes, would use a different clock).
+	key.LifetimeSecs = 0
+	key.ConfirmBeforeUse = false
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	if err := a.Agent.Add(key); err != nil {
+		return err
+	}
+	// Adding a key again replaces any constraints it had.
+	delete(a.keys, blob)
+	if c == nil {
+		return nil
+	}
+	a.keys[blob] = c
+	if !c.expire.IsZero() {
+		go a.expireAfter(c.expire.Sub(a.clock.Now()))
+	}
+	return nil
+}
+
+// expireAfter removes expired keys once the duration elapses. Keys are also
+// removed lazily on access, so nothing is lost if the timer does not fire
+// (e.g., the worker is suspended, in which case the keyring is lost anyway).
+func (a *Agent) expireAfter(d time.Duration) {
+	<-a.clock.After(d)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+}
+
+// expireLocked removes keys whose lifetime has elapsed. a.mu must be held.
+func (a *Agent) expireLocked() {
+	now := a.clock.Now()
+	for blob, c := range a.keys {
+		if c.expire.IsZero() || now.Before(c.expire) {
+			continue
+		}
+		delete(a.keys, blob)
+		pub, err := ssh.ParsePublicKey([]byte(blob))
+		if err != nil {
+			jsutil.LogError("constrained.Agent: failed to parse expired key: %v", err)
+			continue
+		}
+		jsutil.LogDebug("constrained.Agent: removing expired key %s", ssh.FingerprintSHA256(pub))
+		if err := a.Agent.Remove(pub); err != nil {
+			jsutil.LogError("constrained.Agent: failed to remove expired key %s: %v", ssh.FingerprintSHA256(pub), err)
+		}
+	}
+}
+
+// NeedsConfirmation returns true if each signature with the key must be
+// confirmed by the user, along with the comment with which the key was
+// added.
+func (a *Agent) NeedsConfirmation(key ssh.PublicKey) (comment string, confirm bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	c, ok := a.keys[string(key.Marshal())]
+	if !ok || !c.confirm {
+		return "", false
+	}
+	return c.comment, true
+}
+
+// List implements agent.Agent.List.
+func (a *Agent) List() ([]*agent.Key, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	return a.Agent.List()
+}
+
+// Signers implements agent.Agent.Signers.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked()
+	return a.Agent.Signers()
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	a.mu.Lock()
+	a.expireLocked()
+	a.mu.Unlock()
+	return a.Agent.Sign(key, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	a.mu.Lock()
+	a.expireLocked()
+	a.mu.Unlock()
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// Remove implements agent.Agent.Remove.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.keys, string(key.Marshal()))
+	return a.Agent.Remove(key)
+}
+
+// RemoveAll implements agent.Agent.RemoveAll.
+func (a *Agent) RemoveAll() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = map[string]*constraints{}
+	return a.Agent.RemoveAll()
+}
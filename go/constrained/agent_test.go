@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constrained
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func mustParseKey(t *testing.T, key testdata.TestKey) interface{} {
+	t.Helper()
+	priv, err := ssh.ParseRawPrivateKey([]byte(key.Private))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	return priv
+}
+
+func mustParseCert(t *testing.T, key testdata.TestKey) *ssh.Certificate {
+	t.Helper()
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.Certificate))
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		t.Fatalf("unexpected certificate type %T", pub)
+	}
+	return cert
+}
+
+func loadedComments(t *testing.T, agt agent.Agent) []string {
+	t.Helper()
+	loaded, err := agt.List()
+	if err != nil {
+		t.Fatalf("failed to list keys: %v", err)
+	}
+	var result []string
+	for _, l := range loaded {
+		result = append(result, l.Comment)
+	}
+	return result
+}
+
+func TestAddLifetime(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		lifetime    uint32
+		elapsed     time.Duration
+		want        []string
+	}{
+		{
+			description: "no lifetime",
+			elapsed:     24 * time.Hour,
+			want:        []string{"key"},
+		},
+		{
+			description: "lifetime not yet elapsed",
+			lifetime:    60,
+			elapsed:     59 * time.Second,
+			want:        []string{"key"},
+		},
+		{
+			description: "lifetime elapsed",
+			lifetime:    60,
+			elapsed:     60 * time.Second,
+			want:        nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			clk := fakes.NewClock(time.Unix(10000, 0))
+			agt := New(agent.NewKeyring(), clk)
+			err := agt.Add(agent.AddedKey{
+				PrivateKey:   mustParseKey(t, testdata.ED25519WithoutPassphrase),
+				Comment:      "key",
+				LifetimeSecs: tc.lifetime,
+			})
+			if err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+			clk.Advance(tc.elapsed)
+			if diff := cmp.Diff(loadedComments(t, agt), tc.want); diff != "" {
+				t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		cert        bool
+		confirm     bool
+		readd       bool
+		remove      bool
+		wantComment string
+		wantConfirm bool
+	}{
+		{
+			description: "confirmation not required",
+		},
+		{
+			description: "confirmation required",
+			confirm:     true,
+			wantComment: "key",
+			wantConfirm: true,
+		},
+		{
+			description: "confirmation required for certificate",
+			cert:        true,
+			confirm:     true,
+			wantComment: "key",
+			wantConfirm: true,
+		},
+		{
+			description: "added again without constraint",
+			confirm:     true,
+			readd:       true,
+		},
+		{
+			description: "key removed",
+			confirm:     true,
+			remove:      true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			agt := New(agent.NewKeyring(), fakes.NewClock(time.Unix(10000, 0)))
+			key := agent.AddedKey{
+				PrivateKey:       mustParseKey(t, testdata.ED25519WithCertificate),
+				Comment:          "key",
+				ConfirmBeforeUse: tc.confirm,
+			}
+			if tc.cert {
+				key.Certificate = mustParseCert(t, testdata.ED25519WithCertificate)
+			}
+			if err := agt.Add(key); err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+			if tc.readd {
+				key.ConfirmBeforeUse = false
+				if err := agt.Add(key); err != nil {
+					t.Fatalf("failed to add key again: %v", err)
+				}
+			}
+
+			loaded, err := agt.List()
+			if err != nil {
+				t.Fatalf("failed to list keys: %v", err)
+			}
+			if len(loaded) != 1 {
+				t.Fatalf("unexpected number of loaded keys: %d", len(loaded))
+			}
+			if tc.remove {
+				if err := agt.Remove(loaded[0]); err != nil {
+					t.Fatalf("failed to remove key: %v", err)
+				}
+			}
+
+			comment, confirm := agt.NeedsConfirmation(loaded[0])
+			if diff := cmp.Diff(comment, tc.wantComment); diff != "" {
+				t.Errorf("incorrect comment; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(confirm, tc.wantConfirm); diff != "" {
+				t.Errorf("incorrect confirmation; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAddConstraintExtension(t *testing.T) {
+	t.Parallel()
+
+	agt := New(agent.NewKeyring(), fakes.NewClock(time.Unix(10000, 0)))
+	err := agt.Add(agent.AddedKey{
+		PrivateKey: mustParseKey(t, testdata.ED25519WithoutPassphrase),
+		Comment:    "key",
+		ConstraintExtensions: []agent.ConstraintExtension{
+			{ExtensionName: "restrict-destination-v00@openssh.com"},
+		},
+	})
+	if diff := cmp.Diff(err, ErrUnsupportedConstraint, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("incorrect error; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(loadedComments(t, agt), []string(nil)); diff != "" {
+		t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+	}
+}
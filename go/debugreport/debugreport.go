@@ -0,0 +1,322 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debugreport assembles a summary of the extension's state for
+// inclusion in bug reports. The summary omits sensitive data such as private
+// keys, passphrases, key comments and the relay token.
+package debugreport
+
+import (
+	"fmt"
+	"sort"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/metrics"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+	"golang.org/x/crypto/ssh"
+)
+
+// Key describes a configured or loaded key, without any sensitive data.
+type Key struct {
+	// Name is the name of the configured key, or empty if the key was
+	// loaded into the agent by some other means.
+	Name string `js:"name"`
+	// Configured indicates if the key is configured in the extension.
+	Configured bool `js:"configured"`
+	// Loaded indicates if the key is loaded into the agent.
+	Loaded bool `js:"loaded"`
+	// Encrypted indicates if the configured key requires a passphrase.
+	Encrypted bool `js:"encrypted"`
+	// Local indicates if the configured key is stored only on the local
+	// device.
+	Local bool `js:"local"`
+	// AutoLoad indicates if the configured key is loaded at startup.
+	AutoLoad bool `js:"autoLoad"`
+	// Certificate indicates if the configured key has a certificate.
+	Certificate bool `js:"certificate"`
+	// Type is the type of the loaded key (e.g., 'ssh-ed25519').
+	Type string `js:"type"`
+	// Fingerprint is the SHA256 fingerprint of the loaded key.
+	Fingerprint string `js:"fingerprint"`
+}
+
+// Stat is a single metric recorded by the background worker.
+type Stat struct {
+	Count          int64 `js:"count"`
+	Bytes          int64 `js:"bytes"`
+	DurationMillis int64 `js:"durationMillis"`
+}
+
+// Report is a summary of the extension's state.
+type Report struct {
+	// Version is the version of the extension.
+	Version string `js:"version"`
+	// UserAgent is the browser's user agent string.
+	UserAgent string `js:"userAgent"`
+	// Time is the time at which the report was generated, in seconds
+	// since the Unix epoch.
+	Time int64 `js:"time"`
+	// Settings are the current settings, including any set by policy.
+	Settings *settings.Settings `js:"settings"`
+	// Restrictions are the restrictions set by policy.
+	Restrictions *settings.Restrictions `js:"restrictions"`
+	// Managed are the names of the settings overridden by policy.
+	Managed []string `js:"managed"`
+	// Keys describes the configured and loaded keys.
+	Keys []*Key `js:"keys"`
+	// Stats are the agent statistics across all connections, as last
+	// recorded by the background worker.
+	Stats map[string]*Stat `js:"stats"`
+	// SelfTest is the result of the most recent self-test, if any.
+	SelfTest *selftest.Report `js:"selfTest"`
+	// StorageUsage is the number of bytes used in each storage area.
+	StorageUsage map[string]int `js:"storageUsage"`
+	// Logs are recent log entries from the page generating the report.
+	Logs []string `js:"logs"`
+	// Errors describes any information that could not be collected.
+	Errors []string `js:"errors"`
+}
+
+// JSON returns the report as a JSON string.
+func (r *Report) JSON() string {
+	return jsutil.ToJSON(vert.ValueOf(r).JSValue())
+}
+
+// SettingsSource provides the current settings. It is implemented by
+// settings.Store and settings.Client.
+type SettingsSource interface {
+	Get(ctx jsutil.AsyncContext) (*settings.Settings, error)
+	Restrictions(ctx jsutil.AsyncContext) (*settings.Restrictions, error)
+	Managed(ctx jsutil.AsyncContext) (map[string]bool, error)
+}
+
+// Sources are the sources from which a report is assembled.
+type Sources struct {
+	// Manager provides the configured and loaded keys.
+	Manager keys.Manager
+	// Settings provides the current settings.
+	Settings SettingsSource
+	// Diagnostics is the storage area in which the background worker
+	// records statistics and self-test results.
+	Diagnostics storage.Area
+	// Usage returns the number of bytes used in each storage area. If
+	// nil, usage is not reported.
+	Usage func(ctx jsutil.AsyncContext) (map[string]int, error)
+}
+
+// redacted replaces sensitive settings in the report, such that it still
+// shows whether they are set.
+const redacted = "(redacted)"
+
+// Collect assembles a report from the supplied sources. Information that
+// cannot be collected is described in the report's Errors, rather than
+// causing the entire report to fail.
+func Collect(ctx jsutil.AsyncContext, src Sources, now time.Time) *Report {
+	r := &Report{
+		Version:   extensionVersion(),
+		UserAgent: userAgent(),
+		Time:      now.Unix(),
+		Logs:      jsutil.RecentLogs(),
+	}
+	addErr := func(what string, err error) {
+		r.Errors = append(r.Errors, fmt.Sprintf("failed to read %s: %v", what, err))
+	}
+
+	var err error
+	if r.Settings, err = src.Settings.Get(ctx); err != nil {
+		addErr("settings", err)
+	} else if r.Settings.RelayToken != "" {
+		r.Settings.RelayToken = redacted
+	}
+	if r.Restrictions, err = src.Settings.Restrictions(ctx); err != nil {
+		addErr("restrictions", err)
+	}
+	if managed, err := src.Settings.Managed(ctx); err != nil {
+		addErr("managed settings", err)
+	} else {
+		for n, m := range managed {
+			if m {
+				r.Managed = append(r.Managed, n)
+			}
+		}
+		sort.Strings(r.Managed)
+	}
+
+	if r.Keys, err = collectKeys(ctx, src.Manager); err != nil {
+		addErr("keys", err)
+	}
+
+	if r.Stats, err = ReadStats(ctx, src.Diagnostics); err != nil {
+		addErr("statistics", err)
+	}
+	if r.SelfTest, err = selftest.ReadReport(ctx, src.Diagnostics); err != nil {
+		addErr("self-test report", err)
+	}
+
+	if src.Usage != nil {
+		if r.StorageUsage, err = src.Usage(ctx); err != nil {
+			addErr("storage usage", err)
+		}
+	}
+
+	return r
+}
+
+// collectKeys returns a description of the configured and loaded keys.
+func collectKeys(ctx jsutil.AsyncContext, mgr keys.Manager) ([]*Key, error) {
+	configured, err := mgr.Configured(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configured keys: %w", err)
+	}
+	loaded, err := mgr.Loaded(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loaded keys: %w", err)
+	}
+
+	var result []*Key
+	byID := map[keys.ID]*Key{}
+	for _, c := range configured {
+		k := &Key{
+			Name:        c.Name,
+			Configured:  true,
+			Encrypted:   c.Encrypted,
+			Local:       c.Local,
+			AutoLoad:    c.AutoLoad,
+			Certificate: c.Certificate != nil,
+		}
+		byID[keys.ID(c.ID)] = k
+		result = append(result, k)
+	}
+
+	for _, l := range loaded {
+		k := byID[l.ID()]
+		if k != nil && l.IsCertificate() {
+			// Already described by the configured key.
+			continue
+		}
+		if k == nil {
+			k = &Key{}
+			result = append(result, k)
+		}
+		k.Loaded = true
+		k.Type = l.Type
+		if pub, err := ssh.ParsePublicKey(l.Blob()); err == nil {
+			k.Fingerprint = ssh.FingerprintSHA256(pub)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// extensionVersion returns the version of the extension, or an empty string if
+// it cannot be determined.
+func extensionVersion() string {
+	runtime := js.Global().Get("chrome")
+	if runtime.IsUndefined() {
+		return ""
+	}
+	runtime = runtime.Get("runtime")
+	if runtime.IsUndefined() || runtime.Get("getManifest").IsUndefined() {
+		return ""
+	}
+	return runtime.Call("getManifest").Get("version").String()
+}
+
+// userAgent returns the browser's user agent string, or an empty string if it
+// cannot be determined.
+func userAgent() string {
+	nav := js.Global().Get("navigator")
+	if nav.IsUndefined() || nav.Get("userAgent").IsUndefined() {
+		return ""
+	}
+	return nav.Get("userAgent").String()
+}
+
+// ChromeStorageUsage returns the number of bytes used in each of Chrome's
+// storage areas.
+func ChromeStorageUsage(ctx jsutil.AsyncContext) (map[string]int, error) {
+	chrome := js.Global().Get("chrome")
+	if chrome.IsUndefined() || chrome.Get("storage").IsUndefined() {
+		return nil, fmt.Errorf("%w: chrome.storage is not defined", storage.ErrUnavailable)
+	}
+
+	usage := map[string]int{}
+	for _, name := range []string{"sync", "local", "session"} {
+		area := chrome.Get("storage").Get(name)
+		if area.IsUndefined() || area.Get("getBytesInUse").IsUndefined() {
+			continue
+		}
+		n, err := jsutil.AsPromise(area.Call("getBytesInUse", js.Null())).Await(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read usage for %s storage: %w", name, err)
+		}
+		usage[name] = n.Int()
+	}
+	return usage, nil
+}
+
+const (
+	// statsKey is the key under which statistics are stored.
+	statsKey = "agentStats"
+)
+
+// WriteStats records the supplied statistics in the storage area, for later
+// inclusion in a report.
+func WriteStats(ctx jsutil.AsyncContext, area storage.Area, stats map[string]metrics.Stat) error {
+	stored := map[string]*Stat{}
+	for n, s := range stats {
+		stored[n] = &Stat{
+			Count:          s.Count,
+			Bytes:          s.Bytes,
+			DurationMillis: s.Duration.Milliseconds(),
+		}
+	}
+	data := map[string]js.Value{
+		statsKey: vert.ValueOf(stored).JSValue(),
+	}
+	if err := area.Set(ctx, data); err != nil {
+		return fmt.Errorf("failed to write statistics: %w", err)
+	}
+	return nil
+}
+
+// ReadStats reads the statistics from the storage area. nil is returned if no
+// statistics have been recorded.
+func ReadStats(ctx jsutil.AsyncContext, area storage.Area) (map[string]*Stat, error) {
+	data, err := area.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statistics: %w", err)
+	}
+	val, ok := data[statsKey]
+	if !ok || val.Type() != js.TypeObject {
+		return nil, nil
+	}
+
+	var stats map[string]*Stat
+	if err := vert.ValueOf(val).AssignTo(&stats); err != nil {
+		return nil, fmt.Errorf("failed to parse statistics: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugreport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/metrics"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func mustFingerprint(t *testing.T, private string) string {
+	t.Helper()
+	signer, err := ssh.ParsePrivateKey([]byte(private))
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		mgr := keys.NewManager(agt, storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		sts := settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		diagnostics := storage.NewRaw(st.NewMemArea())
+		if err := sts.Set(ctx, &settings.Settings{RelayURL: "wss://relay.example.com/agent"}); err != nil {
+			t.Fatalf("failed to write settings: %v", err)
+		}
+		if err := sts.SetRelayToken(ctx, "relay-secret-token"); err != nil {
+			t.Fatalf("failed to write relay token: %v", err)
+		}
+
+		// Configure one key and load it, configure another without
+		// loading it, and load a third directly into the agent.
+		if _, err := mgr.Add(ctx, "loaded-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		if _, err := mgr.Add(ctx, "unloaded-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		configured, err := mgr.Configured(ctx)
+		if err != nil {
+			t.Fatalf("failed to read configured keys: %v", err)
+		}
+		for _, c := range configured {
+			if c.Name == "loaded-key" {
+				if err := mgr.Load(ctx, keys.ID(c.ID), ""); err != nil {
+					t.Fatalf("failed to load key: %v", err)
+				}
+			}
+		}
+		priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+		if err != nil {
+			t.Fatalf("failed to parse private key: %v", err)
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "user@secret-host"}); err != nil {
+			t.Fatalf("failed to load key: %v", err)
+		}
+
+		// Record diagnostics from the background worker.
+		stats := metrics.NewRegistry()
+		stats.Observe("in:SSH_AGENTC_REQUEST_IDENTITIES", 5, 2*time.Millisecond)
+		if err := WriteStats(ctx, diagnostics, stats.Snapshot()); err != nil {
+			t.Fatalf("failed to write statistics: %v", err)
+		}
+		if err := selftest.WriteReport(ctx, diagnostics, &selftest.Report{Version: "1.2.3"}); err != nil {
+			t.Fatalf("failed to write self-test report: %v", err)
+		}
+
+		r := Collect(ctx, Sources{
+			Manager:     mgr,
+			Settings:    sts,
+			Diagnostics: diagnostics,
+		}, time.Unix(1234, 0))
+
+		if diff := cmp.Diff(r.Keys, []*Key{
+			{
+				Loaded:      true,
+				Type:        ssh.KeyAlgoED25519,
+				Fingerprint: mustFingerprint(t, testdata.ED25519WithoutPassphrase.Private),
+			},
+			{
+				Name:        "loaded-key",
+				Configured:  true,
+				Loaded:      true,
+				Type:        ssh.KeyAlgoRSA,
+				Fingerprint: mustFingerprint(t, testdata.WithoutPassphrase.Private),
+			},
+			{
+				Name:       "unloaded-key",
+				Configured: true,
+				Encrypted:  true,
+			},
+		}); diff != "" {
+			t.Errorf("incorrect keys; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(r.Stats, map[string]*Stat{
+			"in:SSH_AGENTC_REQUEST_IDENTITIES": {Count: 1, Bytes: 5, DurationMillis: 2},
+		}); diff != "" {
+			t.Errorf("incorrect statistics; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(r.SelfTest, &selftest.Report{Version: "1.2.3"}); diff != "" {
+			t.Errorf("incorrect self-test report; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(r.Settings, &settings.Settings{
+			RelayURL:   "wss://relay.example.com/agent",
+			RelayToken: redacted,
+		}); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(r.Time, int64(1234)); diff != "" {
+			t.Errorf("incorrect time; -got +want: %s", diff)
+		}
+		if len(r.Errors) > 0 {
+			t.Errorf("unexpected errors: %v", r.Errors)
+		}
+
+		// Sensitive data must not be included.
+		j := r.JSON()
+		for _, s := range []string{"PRIVATE KEY", "user@secret-host", "relay-secret-token", testdata.WithoutPassphrase.Blob} {
+			if strings.Contains(j, s) {
+				t.Errorf("report unexpectedly contains %q", s)
+			}
+		}
+	})
+}
+
+func TestCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		// The manager cannot be reached, and storage usage is
+		// unavailable. The remainder of the report is still collected.
+		r := Collect(ctx, Sources{
+			Manager:     keys.NewClient(mfakes.NewHub()),
+			Settings:    settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea())),
+			Diagnostics: storage.NewRaw(st.NewMemArea()),
+			Usage: func(ctx jsutil.AsyncContext) (map[string]int, error) {
+				return nil, errors.New("usage failed")
+			},
+		}, time.Unix(1234, 0))
+
+		if diff := cmp.Diff(len(r.Errors), 2); diff != "" {
+			t.Errorf("incorrect number of errors %v; -got +want: %s", r.Errors, diff)
+		}
+		if diff := cmp.Diff(r.Settings, &settings.Settings{}); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+		if r.Keys != nil {
+			t.Errorf("unexpected keys: %v", r.Keys)
+		}
+	})
+}
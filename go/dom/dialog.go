@@ -0,0 +1,265 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// dialogHandler is a callback registered with a Dialog.
+type dialogHandler struct {
+	callback func(ctx jsutil.AsyncContext, evt Event)
+}
+
+// Dialog represents an HTML dialog.
+//
+// A dialog may be closed by the program (see Close), or cancelled by the
+// user by pressing Escape or clicking outside the dialog (the backdrop).
+// Cancelling invokes callbacks registered by OnCancel, and then closes the
+// dialog. Callbacks registered by OnClose are invoked however the dialog is
+// closed, and those registered by OnShow whenever it is shown, so that callers
+// can await either transition rather than polling the dialog's state.
+//
+// While shown, keyboard focus is kept within the dialog: Tab and Shift+Tab
+// wrap around its controls. When it closes, focus returns to the element that
+// had it before the dialog was shown, if that element is still present.
+type Dialog struct {
+	dialog js.Value
+
+	showHandlers   []*dialogHandler
+	closeHandlers  []*dialogHandler
+	cancelHandlers []*dialogHandler
+	// shown releases the event listeners that are active while the dialog
+	// is shown.
+	shown *jsutil.CleanupFuncs
+	// previousFocus is the element that had keyboard focus before the
+	// dialog was shown.
+	previousFocus js.Value
+}
+
+// NewDialog returns a dialog wrapping the specified element.
+func NewDialog(dialog js.Value) *Dialog {
+	return &Dialog{
+		dialog: dialog,
+	}
+}
+
+// simulated returns true if the dialog API is not supported and must be
+// simulated. jsdom (which is used in tests) does not support it.
+func (d *Dialog) simulated() bool {
+	return d.dialog.Get("showModal").IsUndefined() || d.dialog.Get("close").IsUndefined()
+}
+
+// ShowModal shows the dialog as a modal dialog.
+func (d *Dialog) ShowModal() {
+	d.shown = &jsutil.CleanupFuncs{}
+	d.previousFocus = d.activeElement()
+	// Handlers are invoked once the dialog is shown and has focus.
+	defer d.invoke(d.showHandlers, js.Undefined())
+	defer d.focusFirst()
+
+	d.shown.Add(addEventListener(d.dialog, "keydown", func(this js.Value, args []js.Value) interface{} {
+		if evt := jsutil.SingleArg(args); evt.Get("key").String() == "Tab" {
+			d.trapFocus(evt)
+		}
+		return nil
+	}))
+
+	// Clicks on the backdrop are delivered to the dialog element itself,
+	// rather than to any of its content.
+	d.shown.Add(addEventListener(d.dialog, "click", func(this js.Value, args []js.Value) interface{} {
+		if evt := jsutil.SingleArg(args); evt.Get("target").Equal(d.dialog) {
+			d.Cancel()
+		}
+		return nil
+	}))
+
+	if d.simulated() {
+		jsutil.Log("showModal() not found")
+		// The browser cancels the dialog when Escape is pressed;
+		// simulate this.
+		d.shown.Add(addEventListener(d.dialog, "keydown", func(this js.Value, args []js.Value) interface{} {
+			if evt := jsutil.SingleArg(args); evt.Get("key").String() == "Escape" {
+				d.Cancel()
+			}
+			return nil
+		}))
+		// Simulate 'open' property.
+		d.dialog.Set("open", true)
+		return
+	}
+
+	// The browser raises 'cancel' when Escape is pressed, and then closes
+	// the dialog.
+	d.shown.Add(addEventListener(d.dialog, "cancel", func(this js.Value, args []js.Value) interface{} {
+		d.invoke(d.cancelHandlers, jsutil.SingleArg(args))
+		return nil
+	}))
+	d.shown.Add(addEventListener(d.dialog, "close", func(this js.Value, args []js.Value) interface{} {
+		d.closed(jsutil.SingleArg(args))
+		return nil
+	}))
+	d.dialog.Call("showModal")
+}
+
+// Open returns true if the dialog is shown.
+func (d *Dialog) Open() bool {
+	return d.dialog.Get("open").Truthy()
+}
+
+// Close closes the dialog.
+func (d *Dialog) Close() {
+	if !d.Open() {
+		return
+	}
+
+	if d.simulated() {
+		jsutil.Log("close() not found")
+		// Simulate 'open' property.
+		d.dialog.Set("open", false)
+		// Simulate 'close' event; we need to ensure OnClose is triggered.
+		// Using Javascript's dispatchEvent(new Event('close')) doesn't
+		// work; it appears to send node.js into an infinite loop.
+		d.closed(js.Undefined())
+		return
+	}
+
+	d.dialog.Call("close")
+}
+
+// Cancel cancels the dialog, as if the user pressed Escape.
+func (d *Dialog) Cancel() {
+	if !d.Open() {
+		return
+	}
+	d.invoke(d.cancelHandlers, js.Undefined())
+	d.Close()
+}
+
+// closed is invoked when the dialog has been closed.
+func (d *Dialog) closed(evt js.Value) {
+	if d.shown != nil {
+		d.shown.Do()
+		d.shown = nil
+	}
+	if prev := d.previousFocus; prev.Truthy() && prev.Get("isConnected").Truthy() {
+		Focus(prev)
+	}
+	d.previousFocus = js.Undefined()
+	d.invoke(d.closeHandlers, evt)
+}
+
+// activeElement returns the element in the dialog's document that has
+// keyboard focus.
+func (d *Dialog) activeElement() js.Value {
+	return d.dialog.Get("ownerDocument").Get("activeElement")
+}
+
+// tabbable returns the controls in the dialog that are reached using Tab.
+func (d *Dialog) tabbable() []js.Value {
+	var result []js.Value
+	for _, elt := range Focusable(d.dialog) {
+		if elt.Get("tabIndex").Int() >= 0 {
+			result = append(result, elt)
+		}
+	}
+	return result
+}
+
+// focusFirst moves keyboard focus to the dialog's first control, unless focus
+// is already within the dialog (e.g., on an element with autofocus).
+func (d *Dialog) focusFirst() {
+	if Contains(d.dialog, d.activeElement()) {
+		return
+	}
+	if elts := d.tabbable(); len(elts) > 0 {
+		Focus(elts[0])
+	}
+}
+
+// trapFocus handles a Tab key press, wrapping focus from the dialog's last
+// control to its first (or, with Shift, from its first to its last) rather
+// than letting it leave the dialog.
+func (d *Dialog) trapFocus(evt js.Value) {
+	elts := d.tabbable()
+	if len(elts) == 0 {
+		evt.Call("preventDefault")
+		return
+	}
+	first, last := elts[0], elts[len(elts)-1]
+	active := d.activeElement()
+	switch {
+	case evt.Get("shiftKey").Bool() && (active.Equal(first) || !Contains(d.dialog, active)):
+		evt.Call("preventDefault")
+		Focus(last)
+	case !evt.Get("shiftKey").Bool() && (active.Equal(last) || !Contains(d.dialog, active)):
+		evt.Call("preventDefault")
+		Focus(first)
+	}
+}
+
+// invoke asynchronously invokes each of the handlers.
+func (d *Dialog) invoke(handlers []*dialogHandler, evt js.Value) {
+	// Handlers may be removed while running; iterate over a copy.
+	for _, h := range append([]*dialogHandler(nil), handlers...) {
+		h := h
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			h.callback(ctx, Event{Value: evt})
+			return js.Undefined(), nil
+		})
+	}
+}
+
+// addHandler registers the callback in handlers. The returned function
+// removes it.
+func addHandler(handlers *[]*dialogHandler, callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	h := &dialogHandler{callback: callback}
+	*handlers = append(*handlers, h)
+	return func() {
+		for i, o := range *handlers {
+			if o == h {
+				*handlers = append((*handlers)[:i], (*handlers)[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// OnShow registers the specified callback to be invoked when the dialog is
+// shown. Multiple callbacks may be registered. The returned function must be
+// invoked to cleanup when it is no longer needed.
+func (d *Dialog) OnShow(callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addHandler(&d.showHandlers, callback)
+}
+
+// OnClose registers the specified callback to be invoked when the dialog is
+// closed, including when it is cancelled. Multiple callbacks may be
+// registered. The returned function must be invoked to cleanup when it is no
+// longer needed.
+func (d *Dialog) OnClose(callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addHandler(&d.closeHandlers, callback)
+}
+
+// OnCancel registers the specified callback to be invoked when the user
+// cancels the dialog, before it is closed. Multiple callbacks may be
+// registered. The returned function must be invoked to cleanup when it is no
+// longer needed.
+func (d *Dialog) OnCancel(callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addHandler(&d.cancelHandlers, callback)
+}
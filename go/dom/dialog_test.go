@@ -0,0 +1,198 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"testing"
+	"time"
+
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/go-cmp/cmp"
+)
+
+const dialogHTML = `
+	<dialog id="dialog">
+		<button id="inside" type="button">Inside</button>
+	</dialog>
+`
+
+// waitEvent waits for an event to be signalled on c.
+func waitEvent(t *testing.T, c chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-c:
+		if got != want {
+			t.Errorf("incorrect event: got %s, want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("event %s not received", want)
+	}
+}
+
+// noEvent checks that no event is signalled on c.
+func noEvent(t *testing.T, c chan string) {
+	t.Helper()
+	select {
+	case got := <-c:
+		t.Errorf("unexpected event: %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDialog(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		dismiss     func(d *Doc, dialog *Dialog)
+		wantCancel  bool
+	}{
+		{
+			description: "closed",
+			dismiss:     func(d *Doc, dialog *Dialog) { dialog.Close() },
+		},
+		{
+			description: "cancelled",
+			dismiss:     func(d *Doc, dialog *Dialog) { dialog.Cancel() },
+			wantCancel:  true,
+		},
+		{
+			description: "escape pressed",
+			dismiss:     func(d *Doc, dialog *Dialog) { dt.PressEscape(d.GetElement("dialog")) },
+			wantCancel:  true,
+		},
+		{
+			description: "backdrop clicked",
+			dismiss:     func(d *Doc, dialog *Dialog) { dt.ClickBackdrop(d.GetElement("dialog")) },
+			wantCancel:  true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			d := New(dt.NewDocForTesting(dialogHTML))
+			dialog := NewDialog(d.GetElement("dialog"))
+
+			// Register multiple close handlers.
+			shows := make(chan string, 10)
+			closes := make(chan string, 10)
+			cancels := make(chan string, 10)
+			defer dialog.OnShow(func(ctx jsutil.AsyncContext, evt Event) { shows <- "show" })()
+			defer dialog.OnClose(func(ctx jsutil.AsyncContext, evt Event) { closes <- "close-1" })()
+			defer dialog.OnClose(func(ctx jsutil.AsyncContext, evt Event) { closes <- "close-2" })()
+			defer dialog.OnCancel(func(ctx jsutil.AsyncContext, evt Event) { cancels <- "cancel" })()
+
+			dialog.ShowModal()
+			waitEvent(t, shows, "show")
+			if !dialog.Open() {
+				t.Errorf("dialog not open")
+			}
+			// Clicking the content of the dialog does not cancel it.
+			DoClick(d.GetElement("inside"))
+			noEvent(t, cancels)
+
+			tc.dismiss(d, dialog)
+			if tc.wantCancel {
+				waitEvent(t, cancels, "cancel")
+			}
+			got := map[string]bool{}
+			for i := 0; i < 2; i++ {
+				select {
+				case e := <-closes:
+					got[e] = true
+				case <-time.After(5 * time.Second):
+					t.Fatalf("close handlers not invoked")
+				}
+			}
+			if diff := cmp.Diff(got, map[string]bool{"close-1": true, "close-2": true}); diff != "" {
+				t.Errorf("incorrect close handlers invoked; -got +want: %s", diff)
+			}
+			noEvent(t, shows)
+			noEvent(t, cancels)
+			noEvent(t, closes)
+			if dialog.Open() {
+				t.Errorf("dialog still open")
+			}
+		})
+	}
+}
+
+func TestDialogRemoveHandler(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(dialogHTML))
+	dialog := NewDialog(d.GetElement("dialog"))
+
+	events := make(chan string, 10)
+	remove := dialog.OnClose(func(ctx jsutil.AsyncContext, evt Event) { events <- "removed" })
+	defer dialog.OnClose(func(ctx jsutil.AsyncContext, evt Event) { events <- "close" })()
+	remove()
+
+	dialog.ShowModal()
+	dialog.Close()
+	waitEvent(t, events, "close")
+	noEvent(t, events)
+}
+
+func TestDialogFocus(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<button id="opener" type="button">Open</button>
+		<dialog id="dialog">
+			<input id="first"/>
+			<input id="hidden" hidden/>
+			<button id="disabled" type="button" disabled>Disabled</button>
+			<button id="last" type="button">Last</button>
+		</dialog>
+	`))
+	dialog := NewDialog(d.GetElement("dialog"))
+
+	Focus(d.GetElement("opener"))
+	dialog.ShowModal()
+	if got := ID(d.ActiveElement()); got != "first" {
+		t.Errorf("incorrect focus when shown: got %q, want %q", got, "first")
+	}
+
+	// Tab from the last control wraps to the first, and Shift+Tab from
+	// the first wraps to the last.
+	Focus(d.GetElement("last"))
+	if dt.PressKey(d.GetElement("last"), "Tab", false) {
+		t.Errorf("Tab from last control not prevented")
+	}
+	if got := ID(d.ActiveElement()); got != "first" {
+		t.Errorf("incorrect focus after Tab: got %q, want %q", got, "first")
+	}
+	if dt.PressKey(d.GetElement("first"), "Tab", true) {
+		t.Errorf("Shift+Tab from first control not prevented")
+	}
+	if got := ID(d.ActiveElement()); got != "last" {
+		t.Errorf("incorrect focus after Shift+Tab: got %q, want %q", got, "last")
+	}
+	// Tab between other controls is left to the browser.
+	Focus(d.GetElement("first"))
+	if !dt.PressKey(d.GetElement("first"), "Tab", false) {
+		t.Errorf("Tab between controls prevented")
+	}
+
+	dialog.Close()
+	if got := ID(d.ActiveElement()); got != "opener" {
+		t.Errorf("incorrect focus when closed: got %q, want %q", got, "opener")
+	}
+}
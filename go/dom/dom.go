@@ -18,7 +18,6 @@
 package dom
 
 import (
-	"fmt"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -37,6 +36,9 @@ type Event struct {
 // Doc provides an API for interacting with the DOM for a Document.
 type Doc struct {
 	doc js.Value
+	// stopTheme stops following the browser's preferred color scheme;
+	// see SetTheme.
+	stopTheme jsutil.CleanupFunc
 }
 
 // New returns a Doc instance for interacting with the specified
@@ -103,6 +105,22 @@ func RemoveChildren(p js.Value) {
 	}
 }
 
+// Download saves data to a file with the specified name, using the browser's
+// support for downloads. mimeType is the type of the data (e.g.,
+// 'application/json').
+func (d *Doc) Download(filename, mimeType, data string) {
+	window := d.doc.Get("defaultView")
+	url := window.Get("URL")
+	blob := window.Get("Blob").New([]any{data}, map[string]any{"type": mimeType})
+	href := url.Call("createObjectURL", blob)
+	defer url.Call("revokeObjectURL", href)
+
+	a := d.NewElement("a")
+	a.Set("href", href)
+	a.Set("download", filename)
+	a.Call("click")
+}
+
 // DoClick simulates a click. Any callback registered by OnClick() will be
 // invoked.
 func DoClick(o js.Value) {
@@ -149,6 +167,77 @@ func OnSubmit(o js.Value, callback func(ctx jsutil.AsyncContext, evt Event)) jsu
 		})
 }
 
+// OnChange registers a callback to be invoked when the value of the specified
+// object is changed by the user.
+func OnChange(o js.Value, callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addEventListener(
+		o, "change",
+		func(this js.Value, args []js.Value) interface{} {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				callback(ctx, Event{Value: jsutil.SingleArg(args)})
+				return js.Undefined(), nil
+			})
+			return nil
+		})
+}
+
+// OnInput registers a callback to be invoked whenever the user edits the
+// value of the specified object. Unlike OnChange, the callback is invoked on
+// each edit, rather than once the user has finished editing.
+func OnInput(o js.Value, callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addEventListener(
+		o, "input",
+		func(this js.Value, args []js.Value) interface{} {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				callback(ctx, Event{Value: jsutil.SingleArg(args)})
+				return js.Undefined(), nil
+			})
+			return nil
+		})
+}
+
+// OnKeyUp registers a callback to be invoked whenever a key is released while
+// the specified object has keyboard focus.
+func OnKeyUp(o js.Value, callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addEventListener(
+		o, "keyup",
+		func(this js.Value, args []js.Value) interface{} {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				callback(ctx, Event{Value: jsutil.SingleArg(args)})
+				return js.Undefined(), nil
+			})
+			return nil
+		})
+}
+
+// OnKeyDown registers a callback to be invoked whenever a key is pressed while
+// the specified object (or one of its descendants) has keyboard focus. Unlike
+// other callbacks, it is invoked synchronously, so that it can prevent the
+// key's default action (e.g., scrolling) using the event's preventDefault();
+// it must not block.
+func OnKeyDown(o js.Value, callback func(evt Event)) jsutil.CleanupFunc {
+	return addEventListener(
+		o, "keydown",
+		func(this js.Value, args []js.Value) interface{} {
+			callback(Event{Value: jsutil.SingleArg(args)})
+			return nil
+		})
+}
+
+// OnFocusIn registers a callback to be invoked whenever the specified object,
+// or one of its descendants, receives keyboard focus.
+func OnFocusIn(o js.Value, callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
+	return addEventListener(
+		o, "focusin",
+		func(this js.Value, args []js.Value) interface{} {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				callback(ctx, Event{Value: jsutil.SingleArg(args)})
+				return js.Undefined(), nil
+			})
+			return nil
+		})
+}
+
 // ID returns the element ID of an object as a string.
 func ID(o js.Value) string {
 	return o.Get("id").String()
@@ -164,93 +253,106 @@ func SetValue(o js.Value, value string) {
 	o.Set("value", value)
 }
 
-// TextContent returns the text content of the specified object (and its
-// children).
-func TextContent(o js.Value) string {
-	return o.Get("textContent").String()
+// Checked returns true if the object (e.g., a checkbox) is checked.
+func Checked(o js.Value) bool {
+	return o.Get("checked").Bool()
 }
 
-// AppendChild adds the child object.  If non-nil, the populate() function is
-// invoked on the child to initialize it.
-func AppendChild(parent, child js.Value, populate func(child js.Value)) {
-	if populate != nil {
-		populate(child)
-	}
-	parent.Call("appendChild", child)
+// SetChecked sets whether the object (e.g., a checkbox) is checked.
+func SetChecked(o js.Value, checked bool) {
+	o.Set("checked", checked)
+}
+
+// SetAttribute sets the attribute of the specified element.
+func SetAttribute(o js.Value, name, value string) {
+	o.Call("setAttribute", name, value)
 }
 
-// Dialog represents an HTML dialog.
-type Dialog struct {
-	dialog js.Value
+// RemoveAttribute removes the attribute from the specified element.
+func RemoveAttribute(o js.Value, name string) {
+	o.Call("removeAttribute", name)
+}
 
-	simOnClose js.Func
+// SetRole sets the ARIA role of the specified element (e.g., 'grid', 'row'),
+// which describes it to assistive technologies such as screen readers.
+func SetRole(o js.Value, role string) {
+	SetAttribute(o, "role", role)
 }
 
-// NewDialog returns a dialog wrapping the specified element.
-func NewDialog(dialog js.Value) *Dialog {
-	return &Dialog{
-		dialog: dialog,
-	}
+// SetLabel sets the accessible name of the specified element, which is
+// announced by assistive technologies in place of its content.
+func SetLabel(o js.Value, label string) {
+	SetAttribute(o, "aria-label", label)
 }
 
-// ShowModal shows the dialog as a modal dialog.
-func (d *Dialog) ShowModal() {
-	if d.dialog.Get("showModal").IsUndefined() {
-		// jsdom (which is used in tests) does not support showModal.
-		jsutil.Log("showModal() not found")
-		// Simulate 'open' property.
-		d.dialog.Set("open", true)
-		return
-	}
-	d.dialog.Call("showModal")
-}
-
-// Close closes the dialog.
-func (d *Dialog) Close() {
-	if d.dialog.Get("close").IsUndefined() {
-		// jsdom (which is used in tests) does not support close.
-		jsutil.Log("close() not found")
-		// Simulate 'open' property.
-		d.dialog.Set("open", false)
-		// Simulate 'close' event; we need to ensure OnClose is triggered.
-		// Using Javascript's dispatchEvent(new Event('close')) doesn't
-		// work; it appears to send node.js into an infinite loop.
-		if !d.simOnClose.IsUndefined() {
-			d.simOnClose.Invoke()
+// focusableSelector matches the elements that can receive keyboard focus,
+// unless disabled or hidden.
+const focusableSelector = `a[href], button, input:not([type="hidden"]), select, textarea, summary, [tabindex]`
+
+// Focusable returns the descendants of the specified element that can receive
+// keyboard focus, in document order. Disabled and hidden elements are
+// excluded, but those removed from the tab order (i.e., with a negative
+// tabindex) are not.
+func Focusable(o js.Value) []js.Value {
+	var result []js.Value
+	elts := o.Call("querySelectorAll", focusableSelector)
+	for i := 0; i < elts.Length(); i++ {
+		elt := elts.Index(i)
+		if elt.Get("disabled").Truthy() || elt.Call("closest", "[hidden]").Truthy() {
+			continue
 		}
-		return
+		result = append(result, elt)
 	}
+	return result
+}
 
-	d.dialog.Call("close")
+// Focus moves keyboard focus to the specified element.
+func Focus(o js.Value) {
+	o.Call("focus")
 }
 
-// OnClose registers the specified callback to be invoked when the dialog is
-// closed. The returned function must be invoked to cleanup when it is no longer
-// needed.
-func (d *Dialog) OnClose(callback func(ctx jsutil.AsyncContext, evt Event)) jsutil.CleanupFunc {
-	if d.dialog.Get("close").IsUndefined() {
-		// jsdom (which is used in tests) does not support close. Store
-		// the OnClose event for a subsequent invocation of Close().
-		if !d.simOnClose.IsUndefined() {
-			panic(fmt.Errorf("Multiple simulated OnClose handlers not supported"))
-		}
-		d.simOnClose = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
-				callback(ctx, Event{Value: jsutil.SingleArg(args)})
-				return js.Undefined(), nil
-			})
-			return nil
-		})
-		return d.simOnClose.Release
+// ActiveElement returns the element that has keyboard focus, or null if there
+// is none.
+func (d *Doc) ActiveElement() js.Value {
+	return d.doc.Get("activeElement")
+}
+
+// Contains returns true if o is the specified element, or one of its
+// descendants.
+func Contains(parent, o js.Value) bool {
+	return o.Truthy() && parent.Call("contains", o).Bool()
+}
+
+// AddClass adds the CSS classes to the specified element.
+func AddClass(o js.Value, names ...string) {
+	list := o.Get("classList")
+	for _, n := range names {
+		list.Call("add", n)
 	}
+}
 
-	return addEventListener(
-		d.dialog, "close",
-		func(this js.Value, args []js.Value) interface{} {
-			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
-				callback(ctx, Event{Value: jsutil.SingleArg(args)})
-				return js.Undefined(), nil
-			})
-			return nil
-		})
+// SetClass adds the CSS class to the specified element if on is true, and
+// removes it otherwise.
+func SetClass(o js.Value, name string, on bool) {
+	o.Get("classList").Call("toggle", name, on)
+}
+
+// HasClass returns true if the specified element has the CSS class.
+func HasClass(o js.Value, name string) bool {
+	return o.Get("classList").Call("contains", name).Bool()
+}
+
+// TextContent returns the text content of the specified object (and its
+// children).
+func TextContent(o js.Value) string {
+	return o.Get("textContent").String()
+}
+
+// AppendChild adds the child object.  If non-nil, the populate() function is
+// invoked on the child to initialize it.
+func AppendChild(parent, child js.Value, populate func(child js.Value)) {
+	if populate != nil {
+		populate(child)
+	}
+	parent.Call("appendChild", child)
 }
@@ -104,6 +104,31 @@ func TestClick(t *testing.T) {
 	}
 }
 
+func TestKeyUp(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<input id="field"/>
+	`))
+
+	field := d.GetElement("field")
+	keys := make(chan string, 1)
+	cleanup := OnKeyUp(field, func(ctx jsutil.AsyncContext, evt Event) { keys <- evt.Get("key").String() })
+	defer cleanup()
+
+	init := js.Global().Get("Object").New()
+	init.Set("key", "a")
+	field.Call("dispatchEvent", field.Get("ownerDocument").Get("defaultView").Get("KeyboardEvent").New("keyup", init))
+	select {
+	case key := <-keys:
+		if key != "a" {
+			t.Errorf("incorrect key: got %q, want %q", key, "a")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("key up callback not invoked")
+	}
+}
+
 func TestDOMContentLoaded(t *testing.T) {
 	t.Parallel()
 
@@ -140,6 +165,23 @@ func TestValue(t *testing.T) {
 	}
 }
 
+func TestChecked(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<input id="ipt" type="checkbox" checked>
+	`))
+
+	if diff := cmp.Diff(Checked(d.GetElement("ipt")), true); diff != "" {
+		t.Errorf("incorrect checked; -got +want: %s", diff)
+	}
+
+	SetChecked(d.GetElement("ipt"), false)
+	if diff := cmp.Diff(Checked(d.GetElement("ipt")), false); diff != "" {
+		t.Errorf("incorrect checked; -got +want: %s", diff)
+	}
+}
+
 func joinTextContent(objs []js.Value) string {
 	var result string
 	for _, o := range objs {
@@ -162,3 +204,148 @@ func TestGetElementsByTag(t *testing.T) {
 		t.Errorf("incorrect text content; -got +want: %s", diff)
 	}
 }
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	doc := dt.NewDocForTesting(`<div></div>`)
+	d := New(doc)
+
+	// jsdom does not support object URLs; record their use instead.
+	url := doc.Get("defaultView").Get("URL")
+	var blob js.Value
+	createObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		blob = args[0]
+		return "blob:download"
+	})
+	defer createObjectURL.Release()
+	var revoked string
+	revokeObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		revoked = args[0].String()
+		return nil
+	})
+	defer revokeObjectURL.Release()
+	url.Set("createObjectURL", createObjectURL)
+	url.Set("revokeObjectURL", revokeObjectURL)
+
+	d.Download("keys.json", "application/json", `["data"]`)
+
+	if blob.IsUndefined() {
+		t.Fatalf("no object URL created")
+	}
+	if diff := cmp.Diff(blob.Get("type").String(), "application/json"); diff != "" {
+		t.Errorf("incorrect type; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(blob.Get("size").Int(), len(`["data"]`)); diff != "" {
+		t.Errorf("incorrect size; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(revoked, "blob:download"); diff != "" {
+		t.Errorf("incorrect revoked URL; -got +want: %s", diff)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<div id="elt" class="first"></div>
+	`))
+	elt := d.GetElement("elt")
+	AddClass(elt, "second", "third")
+	SetClass(elt, "first", false)
+	SetClass(elt, "fourth", true)
+	if diff := cmp.Diff(elt.Get("className").String(), "second third fourth"); diff != "" {
+		t.Errorf("incorrect classes; -got +want: %s", diff)
+	}
+	if !HasClass(elt, "third") {
+		t.Errorf("class third missing")
+	}
+	if HasClass(elt, "first") {
+		t.Errorf("class first present after removal")
+	}
+}
+
+func TestFocusable(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<div id="parent">
+			<button id="button">Button</button>
+			<button id="disabled" disabled>Disabled</button>
+			<div hidden><input id="hidden"/></div>
+			<input id="input"/>
+			<input type="hidden" id="hiddenInput"/>
+			<div id="untabbable" tabindex="-1">Untabbable</div>
+			<a id="link" href="#">Link</a>
+			<a id="anchor">Anchor</a>
+		</div>
+	`))
+	var got []string
+	for _, elt := range Focusable(d.GetElement("parent")) {
+		got = append(got, ID(elt))
+	}
+	if diff := cmp.Diff(got, []string{"button", "input", "untabbable", "link"}); diff != "" {
+		t.Errorf("incorrect focusable elements; -got +want: %s", diff)
+	}
+
+	Focus(d.GetElement("input"))
+	if got := ID(d.ActiveElement()); got != "input" {
+		t.Errorf("incorrect focus: got %q, want %q", got, "input")
+	}
+	if !Contains(d.GetElement("parent"), d.ActiveElement()) {
+		t.Errorf("focused element not contained by parent")
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<table id="table"></table>
+	`))
+	table := d.GetElement("table")
+	SetRole(table, "grid")
+	SetLabel(table, "Keys")
+	SetAttribute(table, "aria-rowcount", "3")
+	RemoveAttribute(table, "aria-rowcount")
+	for name, want := range map[string]any{
+		"role":          "grid",
+		"aria-label":    "Keys",
+		"aria-rowcount": nil,
+	} {
+		got := table.Call("getAttribute", name)
+		if want == nil {
+			if !got.IsNull() {
+				t.Errorf("attribute %s not removed: got %q", name, got.String())
+			}
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("incorrect attribute %s: got %q, want %q", name, got.String(), want)
+		}
+	}
+}
+
+func TestKeyDown(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<div id="parent"><input id="field"/></div>
+	`))
+
+	var keys []string
+	cleanup := OnKeyDown(d.GetElement("parent"), func(evt Event) {
+		keys = append(keys, evt.Get("key").String())
+		evt.Call("preventDefault")
+	})
+	defer cleanup()
+
+	// The callback is invoked synchronously, so the default action can be
+	// prevented.
+	if dt.PressKey(d.GetElement("field"), "ArrowDown", false) {
+		t.Errorf("default action not prevented")
+	}
+	if diff := cmp.Diff(keys, []string{"ArrowDown"}); diff != "" {
+		t.Errorf("incorrect keys; -got +want: %s", diff)
+	}
+}
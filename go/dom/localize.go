@@ -0,0 +1,104 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// localizedAttrs are the attributes that may be localized; see Localize.
+var localizedAttrs = []string{"title", "placeholder", "value", "aria-label"}
+
+// Localize replaces the text of the elements marked for localization with
+// messages looked up by name using message (e.g., i18n.Message):
+//
+//   - The content of an element with a data-i18n attribute is replaced by the
+//     message it names. The element's child elements, if any, replace $1
+//     through $9 in the message, in order, such that a translation can
+//     position them (e.g., an element whose content is set later).
+//   - The title, placeholder, value or aria-label attribute of an element
+//     with a data-i18n-title, data-i18n-placeholder, data-i18n-value or
+//     data-i18n-aria-label attribute, respectively, is replaced by the
+//     message it names.
+func (d *Doc) Localize(message func(name string, substitutions ...string) string) {
+	for _, elt := range d.querySelectorAll("[data-i18n]") {
+		var children []js.Value
+		var markers []string
+		kids := elt.Get("children")
+		for i := 0; i < kids.Length(); i++ {
+			children = append(children, kids.Index(i))
+			markers = append(markers, fmt.Sprintf("$%d", i+1))
+		}
+		msg := message(elt.Call("getAttribute", "data-i18n").String(), markers...)
+
+		RemoveChildren(elt)
+		for _, p := range splitMarkers(msg, len(children)) {
+			if p.child > 0 {
+				elt.Call("appendChild", children[p.child-1])
+				continue
+			}
+			elt.Call("appendChild", d.NewText(p.text))
+		}
+	}
+
+	for _, attr := range localizedAttrs {
+		marker := "data-i18n-" + attr
+		for _, elt := range d.querySelectorAll("[" + marker + "]") {
+			elt.Call("setAttribute", attr, message(elt.Call("getAttribute", marker).String()))
+		}
+	}
+}
+
+// querySelectorAll returns the elements matching the specified selector.
+func (d *Doc) querySelectorAll(selector string) []js.Value {
+	var result []js.Value
+	elts := d.doc.Call("querySelectorAll", selector)
+	for i := 0; i < elts.Length(); i++ {
+		result = append(result, elts.Index(i))
+	}
+	return result
+}
+
+// messagePart is part of a localized message: either text, or a reference
+// to a child element.
+type messagePart struct {
+	text  string
+	child int // One-based index of the child element; zero for text.
+}
+
+// splitMarkers splits msg into text and references to child elements, which
+// appear as $1 through $n.
+func splitMarkers(msg string, n int) []messagePart {
+	var parts []messagePart
+	start := 0
+	for i := 0; i+1 < len(msg); i++ {
+		if msg[i] != '$' || msg[i+1] < '1' || int(msg[i+1]-'0') > n {
+			continue
+		}
+		if i > start {
+			parts = append(parts, messagePart{text: msg[start:i]})
+		}
+		parts = append(parts, messagePart{child: int(msg[i+1] - '0')})
+		i++
+		start = i + 1
+	}
+	if start < len(msg) {
+		parts = append(parts, messagePart{text: msg[start:]})
+	}
+	return parts
+}
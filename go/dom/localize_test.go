@@ -0,0 +1,104 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeMessage returns the message name in uppercase, followed by the
+// substitutions.
+func fakeMessage(name string, substitutions ...string) string {
+	return strings.ToUpper(name) + strings.Join(substitutions, "")
+}
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<div id="plain" data-i18n="plain">Plain</div>
+		<label id="nested" data-i18n="nested">Remove <span id="name">key</span>?</label>
+		<input id="filter" data-i18n-placeholder="filter" placeholder="Filter"/>
+		<input id="ok" type="submit" data-i18n-value="okButton" value="OK"/>
+		<td id="sort" data-i18n="sort" data-i18n-title="sortTitle" title="Sort">Name</td>
+		<table id="table" data-i18n-aria-label="tableLabel" aria-label="Keys"></table>
+		<div id="untouched">Untouched</div>
+	`))
+	d.Localize(fakeMessage)
+
+	for id, want := range map[string]string{
+		"plain":     "PLAIN",
+		"nested":    "NESTEDkey",
+		"name":      "key",
+		"untouched": "Untouched",
+	} {
+		if diff := cmp.Diff(TextContent(d.GetElement(id)), want); diff != "" {
+			t.Errorf("incorrect text content for %s; -got +want: %s", id, diff)
+		}
+	}
+	for _, tc := range []struct {
+		id, attr, want string
+	}{
+		{"filter", "placeholder", "FILTER"},
+		{"ok", "value", "OKBUTTON"},
+		{"sort", "title", "SORTTITLE"},
+		{"table", "aria-label", "TABLELABEL"},
+	} {
+		if diff := cmp.Diff(d.GetElement(tc.id).Call("getAttribute", tc.attr).String(), tc.want); diff != "" {
+			t.Errorf("incorrect %s for %s; -got +want: %s", tc.attr, tc.id, diff)
+		}
+	}
+}
+
+func TestLocalizeReordersChildren(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(`
+		<div id="msg" data-i18n="msg"><span id="first">A</span><span id="second">B</span></div>
+	`))
+	d.Localize(func(name string, substitutions ...string) string {
+		return fmt.Sprintf("%s then %s", substitutions[1], substitutions[0])
+	})
+	if diff := cmp.Diff(TextContent(d.GetElement("msg")), "B then A"); diff != "" {
+		t.Errorf("incorrect text content; -got +want: %s", diff)
+	}
+}
+
+func TestSplitMarkers(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		msg  string
+		n    int
+		want []messagePart
+	}{
+		{msg: "", n: 0, want: nil},
+		{msg: "plain", n: 0, want: []messagePart{{text: "plain"}}},
+		{msg: "Remove $1?", n: 1, want: []messagePart{{text: "Remove "}, {child: 1}, {text: "?"}}},
+		{msg: "$2$1", n: 2, want: []messagePart{{child: 2}, {child: 1}}},
+		{msg: "Costs $3 or $", n: 2, want: []messagePart{{text: "Costs $3 or $"}}},
+	}
+	for _, tc := range testcases {
+		got := splitMarkers(tc.msg, tc.n)
+		if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(messagePart{})); diff != "" {
+			t.Errorf("splitMarkers(%q, %d) returned incorrect parts; -got +want: %s", tc.msg, tc.n, diff)
+		}
+	}
+}
@@ -52,3 +52,29 @@ func NewDocForTesting(html string) js.Value {
 		}))
 	return <-c
 }
+
+// PressEscape simulates the user pressing the Escape key while the element
+// (e.g., a dialog) has focus.
+func PressEscape(elt js.Value) {
+	PressKey(elt, "Escape", false)
+}
+
+// PressKey simulates the user pressing the specified key (e.g., 'Tab',
+// 'ArrowDown'), optionally with Shift held, while the element has focus. It
+// returns false if a handler prevented the key's default action. The default
+// action itself (e.g., moving focus) is not simulated.
+func PressKey(elt js.Value, key string, shift bool) bool {
+	keyboardEvent := elt.Get("ownerDocument").Get("defaultView").Get("KeyboardEvent")
+	return elt.Call("dispatchEvent", keyboardEvent.New("keydown", map[string]interface{}{
+		"key":        key,
+		"shiftKey":   shift,
+		"bubbles":    true,
+		"cancelable": true,
+	})).Bool()
+}
+
+// ClickBackdrop simulates the user clicking outside of a modal dialog. The
+// browser delivers such clicks to the dialog element itself.
+func ClickBackdrop(dialog js.Value) {
+	dialog.Call("click")
+}
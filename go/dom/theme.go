@@ -0,0 +1,130 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Theme is the color theme in which a document is displayed.
+type Theme string
+
+const (
+	// ThemeSystem follows the browser's preferred color scheme.
+	ThemeSystem Theme = "system"
+	// ThemeLight displays dark text on a light background.
+	ThemeLight Theme = "light"
+	// ThemeDark displays light text on a dark background.
+	ThemeDark Theme = "dark"
+)
+
+const (
+	// themeAttr is the attribute of the root element that holds the
+	// theme in effect, for use in style sheets.
+	themeAttr = "data-theme"
+	// prefersDarkQuery is the media query that matches if the browser
+	// prefers a dark color scheme.
+	prefersDarkQuery = "(prefers-color-scheme: dark)"
+)
+
+// prefersDark returns the media query list for prefersDarkQuery, or undefined
+// if media queries are not supported (e.g., in jsdom).
+func (d *Doc) prefersDark() js.Value {
+	window := d.doc.Get("defaultView")
+	if !window.Truthy() || !window.Get("matchMedia").Truthy() {
+		return js.Undefined()
+	}
+	return window.Call("matchMedia", prefersDarkQuery)
+}
+
+// PrefersDark returns true if the browser prefers a dark color scheme. False
+// is returned if the preference cannot be determined.
+func (d *Doc) PrefersDark() bool {
+	mq := d.prefersDark()
+	return mq.Truthy() && mq.Get("matches").Bool()
+}
+
+// OnPrefersDarkChange registers a callback to be invoked when the browser's
+// preferred color scheme changes. dark is true if a dark color scheme is now
+// preferred.
+func (d *Doc) OnPrefersDarkChange(callback func(ctx jsutil.AsyncContext, dark bool)) jsutil.CleanupFunc {
+	mq := d.prefersDark()
+	if !mq.Truthy() {
+		return func() {}
+	}
+	return addEventListener(
+		mq, "change",
+		func(this js.Value, args []js.Value) interface{} {
+			dark := mq.Get("matches").Bool()
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				callback(ctx, dark)
+				return js.Undefined(), nil
+			})
+			return nil
+		})
+}
+
+// SetTheme displays the document in the specified theme. The root element's
+// data-theme attribute is set to the theme in effect (ThemeLight or
+// ThemeDark), which style sheets use to select colors. For ThemeSystem (or an
+// unrecognized theme), the attribute follows the browser's preferred color
+// scheme, including later changes to it, until SetTheme is next invoked.
+func (d *Doc) SetTheme(t Theme) {
+	if d.stopTheme != nil {
+		d.stopTheme()
+		d.stopTheme = nil
+	}
+
+	switch t {
+	case ThemeLight, ThemeDark:
+		d.applyTheme(t)
+	default:
+		d.applyTheme(themeFor(d.PrefersDark()))
+		d.stopTheme = d.OnPrefersDarkChange(func(ctx jsutil.AsyncContext, dark bool) {
+			d.applyTheme(themeFor(dark))
+		})
+	}
+}
+
+// Theme returns the theme in effect: ThemeLight or ThemeDark.
+func (d *Doc) Theme() Theme {
+	if d.root().Call("getAttribute", themeAttr).String() == string(ThemeDark) {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+// applyTheme records the theme in effect on the root element.
+func (d *Doc) applyTheme(t Theme) {
+	d.root().Call("setAttribute", themeAttr, string(t))
+}
+
+// root returns the root element of the document.
+func (d *Doc) root() js.Value {
+	return d.doc.Get("documentElement")
+}
+
+// themeFor returns the theme corresponding to a preference for a dark color
+// scheme.
+func themeFor(dark bool) Theme {
+	if dark {
+		return ThemeDark
+	}
+	return ThemeLight
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+)
+
+// fakePrefersDark replaces the document's matchMedia() with one returning a
+// media query list whose matches property is controlled by the test. jsdom
+// does not support media queries.
+func fakePrefersDark(t *testing.T, doc js.Value, dark bool) (mq js.Value) {
+	t.Helper()
+
+	mq = js.Global().Get("EventTarget").New()
+	mq.Set("matches", dark)
+	matchMedia := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].String() != prefersDarkQuery {
+			t.Errorf("incorrect media query: got %q, want %q", args[0].String(), prefersDarkQuery)
+		}
+		return mq
+	})
+	t.Cleanup(matchMedia.Release)
+	doc.Get("defaultView").Set("matchMedia", matchMedia)
+	return mq
+}
+
+// changePrefersDark simulates a change to the browser's preferred color
+// scheme.
+func changePrefersDark(mq js.Value, dark bool) {
+	mq.Set("matches", dark)
+	mq.Call("dispatchEvent", js.Global().Get("Event").New("change"))
+}
+
+// waitTheme waits for the theme in effect to become want.
+func waitTheme(t *testing.T, d *Doc, want Theme) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Theme() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("incorrect theme: got %q, want %q", d.Theme(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	t.Parallel()
+
+	doc := dt.NewDocForTesting(`<div></div>`)
+	d := New(doc)
+	fakePrefersDark(t, doc, true)
+
+	for _, tc := range []struct {
+		theme Theme
+		want  Theme
+	}{
+		{theme: ThemeLight, want: ThemeLight},
+		{theme: ThemeDark, want: ThemeDark},
+		{theme: ThemeLight, want: ThemeLight},
+		{theme: ThemeSystem, want: ThemeDark},
+		{theme: Theme("bogus"), want: ThemeDark},
+	} {
+		d.SetTheme(tc.theme)
+		if got := d.Theme(); got != tc.want {
+			t.Errorf("incorrect theme after SetTheme(%q): got %q, want %q", tc.theme, got, tc.want)
+		}
+	}
+}
+
+func TestSetThemeFollowsSystem(t *testing.T) {
+	t.Parallel()
+
+	doc := dt.NewDocForTesting(`<div></div>`)
+	d := New(doc)
+	mq := fakePrefersDark(t, doc, false)
+
+	d.SetTheme(ThemeSystem)
+	waitTheme(t, d, ThemeLight)
+	changePrefersDark(mq, true)
+	waitTheme(t, d, ThemeDark)
+	changePrefersDark(mq, false)
+	waitTheme(t, d, ThemeLight)
+
+	// An explicit theme stops following the system.
+	d.SetTheme(ThemeDark)
+	changePrefersDark(mq, false)
+	time.Sleep(50 * time.Millisecond)
+	if got := d.Theme(); got != ThemeDark {
+		t.Errorf("incorrect theme after system change: got %q, want %q", got, ThemeDark)
+	}
+}
+
+func TestSetThemeUnsupported(t *testing.T) {
+	t.Parallel()
+
+	// jsdom does not support media queries, so no preference is known.
+	d := New(dt.NewDocForTesting(`<div></div>`))
+	if d.PrefersDark() {
+		t.Errorf("dark color scheme preferred without media query support")
+	}
+	d.SetTheme(ThemeSystem)
+	if got := d.Theme(); got != ThemeLight {
+		t.Errorf("incorrect theme: got %q, want %q", got, ThemeLight)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Toaster displays brief notifications (toasts) in a container element. A
+// toast may offer an action (e.g., 'Undo'). It is dismissed once its timeout
+// elapses, its action is taken, or a newer toast replaces it.
+//
+// The container should be a live region (e.g., role="status"), so that
+// screen readers announce toasts as they are shown.
+type Toaster struct {
+	doc       *Doc
+	container js.Value
+	clock     clock.Clock
+	// current is the toast being displayed, or nil if there is none.
+	current *Toast
+}
+
+// NewToaster returns a Toaster that displays toasts in the container, and
+// measures their timeouts with clk.
+func NewToaster(doc *Doc, container js.Value, clk clock.Clock) *Toaster {
+	return &Toaster{
+		doc:       doc,
+		container: container,
+		clock:     clk,
+	}
+}
+
+// Toast is a notification displayed by a Toaster.
+type Toast struct {
+	elt     js.Value
+	cleanup *jsutil.CleanupFuncs
+	// done is closed when the toast is dismissed.
+	done chan struct{}
+}
+
+// Show displays a toast containing text, replacing any toast already
+// displayed. If action is not empty, the toast includes a button labelled
+// with it; clicking the button dismisses the toast and invokes onAction. The
+// toast is dismissed once timeout elapses.
+func (t *Toaster) Show(text, action string, timeout time.Duration, onAction func(ctx jsutil.AsyncContext)) *Toast {
+	if t.current != nil {
+		t.current.Dismiss()
+	}
+
+	toast := &Toast{
+		elt:     t.doc.NewElement("div"),
+		cleanup: &jsutil.CleanupFuncs{},
+		done:    make(chan struct{}),
+	}
+	AddClass(toast.elt, "toast")
+	AppendChild(toast.elt, t.doc.NewElement("span"), func(span js.Value) {
+		AppendChild(span, t.doc.NewText(text), nil)
+	})
+	if action != "" {
+		AppendChild(toast.elt, t.doc.NewElement("button"), func(btn js.Value) {
+			btn.Set("type", "button")
+			AppendChild(btn, t.doc.NewText(action), nil)
+			toast.cleanup.Add(OnClick(btn, func(ctx jsutil.AsyncContext, evt Event) {
+				if toast.Dismiss() && onAction != nil {
+					onAction(ctx)
+				}
+			}))
+		})
+	}
+	AppendChild(t.container, toast.elt, nil)
+	t.current = toast
+
+	timer := t.clock.NewTimer(timeout)
+	go func() {
+		select {
+		case <-timer.C():
+			toast.Dismiss()
+		case <-toast.done:
+			timer.Stop()
+		}
+	}()
+	return toast
+}
+
+// Dismiss removes the toast. It returns false if the toast was already
+// dismissed.
+func (t *Toast) Dismiss() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+	}
+	close(t.done)
+	t.elt.Call("remove")
+	t.cleanup.Do()
+	return true
+}
+
+// Dismissed returns true if the toast was dismissed.
+func (t *Toast) Dismissed() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
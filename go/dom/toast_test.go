@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+const toastHTML = `
+	<div id="toasts" role="status"></div>
+`
+
+// waitDismissed waits for the toast to be dismissed.
+func waitDismissed(t *testing.T, toast *Toast) {
+	t.Helper()
+	select {
+	case <-toast.done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("toast not dismissed")
+	}
+}
+
+func TestToast(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		dismiss     func(clk *fakes.Clock, toast *Toast)
+		wantAction  bool
+	}{
+		{
+			description: "timeout elapses",
+			dismiss: func(clk *fakes.Clock, toast *Toast) {
+				clk.BlockUntil(1)
+				clk.Advance(time.Minute)
+			},
+		},
+		{
+			description: "action taken",
+			dismiss: func(clk *fakes.Clock, toast *Toast) {
+				DoClick(toast.elt.Call("querySelector", "button"))
+			},
+			wantAction: true,
+		},
+		{
+			description: "dismissed",
+			dismiss: func(clk *fakes.Clock, toast *Toast) {
+				toast.Dismiss()
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			d := New(dt.NewDocForTesting(toastHTML))
+			container := d.GetElement("toasts")
+			clk := fakes.NewClock(time.Unix(0, 0))
+			toaster := NewToaster(d, container, clk)
+
+			events := make(chan string, 10)
+			toast := toaster.Show("Key removed", "Undo", time.Minute, func(ctx jsutil.AsyncContext) { events <- "action" })
+			if got := TextContent(container); got != "Key removedUndo" {
+				t.Errorf("incorrect toast: got %q", got)
+			}
+
+			tc.dismiss(clk, toast)
+			if tc.wantAction {
+				waitEvent(t, events, "action")
+			}
+			waitDismissed(t, toast)
+			noEvent(t, events)
+			if got := container.Get("children").Length(); got != 0 {
+				t.Errorf("toast still displayed")
+			}
+		})
+	}
+}
+
+func TestToastReplaced(t *testing.T) {
+	t.Parallel()
+
+	d := New(dt.NewDocForTesting(toastHTML))
+	container := d.GetElement("toasts")
+	toaster := NewToaster(d, container, fakes.NewClock(time.Unix(0, 0)))
+
+	first := toaster.Show("first", "", time.Minute, nil)
+	second := toaster.Show("second", "", time.Minute, nil)
+	if !first.Dismissed() {
+		t.Errorf("first toast not dismissed")
+	}
+	if second.Dismissed() {
+		t.Errorf("second toast dismissed")
+	}
+	if got := TextContent(container); got != "second" {
+		t.Errorf("incorrect toast: got %q, want %q", got, "second")
+	}
+}
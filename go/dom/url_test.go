@@ -71,3 +71,45 @@ func TestHas(t *testing.T) {
 		})
 	}
 }
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		queryString string
+		param       string
+		want        string
+	}{
+		{
+			description: "param with value",
+			queryString: "?key=value",
+			param:       "key",
+			want:        "value",
+		},
+		{
+			description: "param without value",
+			queryString: "?key",
+			param:       "key",
+			want:        "",
+		},
+		{
+			description: "no param found",
+			queryString: "?other-key=value",
+			param:       "key",
+			want:        "",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			qs := NewURLSearchParams(tc.queryString)
+			if diff := cmp.Diff(qs.Get(tc.param), tc.want); diff != "" {
+				t.Errorf("incorrect result; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"syscall/js"
+)
+
+// uint8Array refers to Javascript's Uint8Array class.
+var uint8Array = js.Global().Get("Uint8Array")
+
+// BytesToJS returns a new Uint8Array holding a copy of b.
+func BytesToJS(b []byte) js.Value {
+	arr := uint8Array.New(len(b))
+	js.CopyBytesToJS(arr, b)
+	return arr
+}
+
+// BytesFromJS returns a copy of the bytes held by an ArrayBuffer or
+// Uint8Array.
+func BytesFromJS(val js.Value) []byte {
+	arr := val
+	if !val.InstanceOf(uint8Array) {
+		arr = uint8Array.New(val)
+	}
+	b := make([]byte, arr.Length())
+	js.CopyBytesToGo(b, arr)
+	return b
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBytesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := []byte{0, 1, 2, 254, 255}
+	arr := BytesToJS(want)
+	if got := arr.Length(); got != len(want) {
+		t.Errorf("incorrect length; got %d, want %d", got, len(want))
+	}
+	if diff := cmp.Diff(BytesFromJS(arr), want); diff != "" {
+		t.Errorf("incorrect bytes from Uint8Array; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(BytesFromJS(arr.Get("buffer")), want); diff != "" {
+		t.Errorf("incorrect bytes from ArrayBuffer; -got +want: %s", diff)
+	}
+}
+
+func TestBytesToJSEmpty(t *testing.T) {
+	t.Parallel()
+
+	arr := BytesToJS(nil)
+	if !arr.InstanceOf(js.Global().Get("Uint8Array")) {
+		t.Errorf("expecting Uint8Array; got %v", arr)
+	}
+	if diff := cmp.Diff(BytesFromJS(arr), []byte{}); diff != "" {
+		t.Errorf("incorrect bytes; -got +want: %s", diff)
+	}
+}
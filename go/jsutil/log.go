@@ -18,6 +18,7 @@ package jsutil
 
 import (
 	"fmt"
+	"sync"
 	"syscall/js"
 	"time"
 )
@@ -25,17 +26,116 @@ import (
 // console is the default 'console' object for the browser.
 var console = js.Global().Get("console")
 
+const (
+	// maxRecentLogs is the number of log entries retained for
+	// RecentLogs.
+	maxRecentLogs = 200
+)
+
+// recent holds the most recent general and error log entries. Debug entries
+// are too numerous to be useful, and are not retained.
+var recent struct {
+	sync.Mutex
+	entries []string
+	// lastError is the most recent error entry, retained even once it
+	// is discarded from entries.
+	lastError string
+}
+
+// record retains a log entry, discarding the oldest if necessary.
+func record(level, ts, msg string) {
+	recent.Lock()
+	defer recent.Unlock()
+	if len(recent.entries) >= maxRecentLogs {
+		recent.entries = recent.entries[1:]
+	}
+	entry := fmt.Sprintf("%s %s %s", ts, level, msg)
+	recent.entries = append(recent.entries, entry)
+	if level == "ERROR" {
+		recent.lastError = entry
+	}
+}
+
+// RecentLogs returns the most recent general and error log entries from the
+// current context, oldest first.
+func RecentLogs() []string {
+	recent.Lock()
+	defer recent.Unlock()
+	return append([]string(nil), recent.entries...)
+}
+
+// LogSink receives log entries as they are logged, such that they can be
+// retained beyond the current context. level is one of 'INFO', 'ERROR' or
+// 'DEBUG'.
+type LogSink func(level string, t time.Time, msg string)
+
+// sink holds the configured LogSink, if any.
+var sink struct {
+	sync.Mutex
+	f LogSink
+	// verbose indicates that debug entries are also supplied.
+	verbose bool
+}
+
+// SetLogSink configures a function that receives each general and error log
+// entry logged in the current context. Debug entries are also supplied if
+// verbose is true. A nil sink disables it.
+//
+// The sink is invoked synchronously, and must not itself log.
+func SetLogSink(f LogSink, verbose bool) {
+	sink.Lock()
+	defer sink.Unlock()
+	sink.f = f
+	sink.verbose = verbose
+}
+
+// SetVerbose configures whether debug entries are supplied to the LogSink.
+func SetVerbose(verbose bool) {
+	sink.Lock()
+	defer sink.Unlock()
+	sink.verbose = verbose
+}
+
+// emit supplies a log entry to the LogSink, if any.
+func emit(level string, t time.Time, msg string) {
+	sink.Lock()
+	f, verbose := sink.f, sink.verbose
+	sink.Unlock()
+	if f == nil || (level == "DEBUG" && !verbose) {
+		return
+	}
+	f(level, t, msg)
+}
+
+// LastError returns the most recent error log entry from the current context,
+// or an empty string if no error has been logged.
+func LastError() string {
+	recent.Lock()
+	defer recent.Unlock()
+	return recent.lastError
+}
+
 // Log logs general information to the Javascript Console.
 func Log(format string, objs ...interface{}) {
-	console.Call("log", time.Now().Format(time.StampMilli), fmt.Sprintf(format, objs...))
+	now, msg := time.Now(), fmt.Sprintf(format, objs...)
+	ts := now.Format(time.StampMilli)
+	record("INFO", ts, msg)
+	emit("INFO", now, msg)
+	console.Call("log", ts, msg)
 }
 
 // LogError logs an error to the Javascript Console.
 func LogError(format string, objs ...interface{}) {
-	console.Call("error", time.Now().Format(time.StampMilli), fmt.Sprintf(format, objs...))
+	now, msg := time.Now(), fmt.Sprintf(format, objs...)
+	ts := now.Format(time.StampMilli)
+	record("ERROR", ts, msg)
+	emit("ERROR", now, msg)
+	console.Call("error", ts, msg)
 }
 
 // LogDebug logs a debug message to the Javascript Console.
 func LogDebug(format string, objs ...interface{}) {
-	console.Call("debug", time.Now().Format(time.StampMilli), fmt.Sprintf(format, objs...))
+	now, msg := time.Now(), fmt.Sprintf(format, objs...)
+	emit("DEBUG", now, msg)
+	console.Call("debug", now.Format(time.StampMilli), msg)
 }
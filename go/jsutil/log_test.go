@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecentLogs(t *testing.T) {
+	// Not parallel; recent logs are shared across the package.
+
+	Log("some info %d", 1)
+	LogError("some error %d", 2)
+	LogDebug("some debug %d", 3)
+
+	// Entries are matched by suffix, since they are prefixed by a
+	// timestamp.
+	var got []string
+	for _, e := range RecentLogs() {
+		for _, want := range []string{" INFO some info 1", " ERROR some error 2", "some debug 3"} {
+			if strings.HasSuffix(e, want) {
+				got = append(got, want)
+			}
+		}
+	}
+	if diff := cmp.Diff(got, []string{" INFO some info 1", " ERROR some error 2"}); diff != "" {
+		t.Errorf("incorrect entries; -got +want: %s", diff)
+	}
+
+	// Only the most recent entries are retained.
+	for i := 0; i < 2*maxRecentLogs; i++ {
+		Log("entry %d", i)
+	}
+	logs := RecentLogs()
+	if diff := cmp.Diff(len(logs), maxRecentLogs); diff != "" {
+		t.Errorf("incorrect number of entries; -got +want: %s", diff)
+	}
+	if want := fmt.Sprintf(" INFO entry %d", 2*maxRecentLogs-1); !strings.HasSuffix(logs[len(logs)-1], want) {
+		t.Errorf("incorrect last entry: got %q, want suffix %q", logs[len(logs)-1], want)
+	}
+
+	// The last error is retained, even once it is no longer recent.
+	if want := " ERROR some error 2"; !strings.HasSuffix(LastError(), want) {
+		t.Errorf("incorrect last error: got %q, want suffix %q", LastError(), want)
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	// Not parallel; the sink is shared across the package.
+
+	var got []string
+	SetLogSink(func(level string, _ time.Time, msg string) {
+		got = append(got, level+" "+msg)
+	}, false)
+	defer SetLogSink(nil, false)
+
+	Log("some info")
+	LogError("some error")
+	LogDebug("hidden debug")
+	SetVerbose(true)
+	LogDebug("verbose debug")
+
+	want := []string{"INFO some info", "ERROR some error", "DEBUG verbose debug"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect entries; -got +want: %s", diff)
+	}
+}
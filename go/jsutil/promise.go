@@ -134,6 +134,20 @@ func Async(f func(ctx AsyncContext) (js.Value, error)) *Promise {
 	return p
 }
 
+// RunAsync executes a function asynchronously, and blocks until it
+// completes. Unlike Await, it may be invoked from a goroutine that is not
+// executing within an AsyncContext (e.g., one serving the SSH agent
+// protocol). As with Await, it must not be invoked from the main thread.
+func RunAsync(f func(ctx AsyncContext)) {
+	done := make(chan struct{})
+	Async(func(ctx AsyncContext) (js.Value, error) {
+		defer close(done)
+		f(ctx)
+		return js.Undefined(), nil
+	})
+	<-done
+}
+
 // Await blocks until a Promise is either resolved or rejected. It must only be
 // invoked from within an AsyncContext.
 func (p *Promise) Await(ctx AsyncContext) (js.Value, error) {
@@ -207,3 +207,21 @@ func TestAwait(t *testing.T) {
 	)
 	<-done
 }
+
+func TestRunAsync(t *testing.T) {
+	t.Parallel()
+
+	var got int
+	RunAsync(func(ctx AsyncContext) {
+		val, err := Async(func(ctx AsyncContext) (js.Value, error) {
+			return js.ValueOf(2), nil
+		}).Await(ctx)
+		if err != nil {
+			t.Errorf("incorrect error; got %v", err)
+		}
+		got = val.Int()
+	})
+	if diff := cmp.Diff(got, 2); diff != "" {
+		t.Errorf("incorrect result: -got +want: %s", diff)
+	}
+}
@@ -0,0 +1,147 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// FakeWebSocket is a fake Javascript WebSocket class. Each WebSocket
+// constructed from it is recorded, such that tests can act as its remote end.
+type FakeWebSocket struct {
+	// Class is the constructor to use in place of the global WebSocket
+	// class.
+	Class js.Value
+
+	ctor    js.Func
+	mu      sync.Mutex
+	sockets []*FakeSocket
+}
+
+// NewFakeWebSocket returns a new fake WebSocket class. Release must be
+// invoked once it is no longer needed.
+func NewFakeWebSocket() *FakeWebSocket {
+	f := &FakeWebSocket{}
+	f.ctor = js.FuncOf(func(_ js.Value, args []js.Value) any {
+		s := newFakeSocket(args[0].String())
+		f.mu.Lock()
+		f.sockets = append(f.sockets, s)
+		f.mu.Unlock()
+		// A constructor returning an object evaluates to that object.
+		return s.obj
+	})
+	f.Class = f.ctor.Value
+	return f
+}
+
+// Release releases the resources held by the fake class and the WebSockets
+// constructed from it.
+func (f *FakeWebSocket) Release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sockets {
+		s.send.Release()
+		s.close.Release()
+	}
+	f.ctor.Release()
+}
+
+// Sockets returns the WebSockets constructed so far, in the order they were
+// constructed.
+func (f *FakeWebSocket) Sockets() []*FakeSocket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*FakeSocket(nil), f.sockets...)
+}
+
+// FakeSocket is a WebSocket constructed from a FakeWebSocket.
+type FakeSocket struct {
+	// URL is the URL with which the WebSocket was constructed.
+	URL string
+	// Sent receives the messages sent over the WebSocket.
+	Sent chan string
+
+	obj   js.Value
+	send  js.Func
+	close js.Func
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newFakeSocket(url string) *FakeSocket {
+	s := &FakeSocket{
+		URL:  url,
+		Sent: make(chan string, 10),
+		obj:  jsutil.NewObject(),
+	}
+	s.send = js.FuncOf(func(_ js.Value, args []js.Value) any {
+		s.Sent <- args[0].String()
+		return nil
+	})
+	s.close = js.FuncOf(func(_ js.Value, _ []js.Value) any {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		s.obj.Set("readyState", 3)
+		return nil
+	})
+	s.obj.Set("readyState", 0)
+	s.obj.Set("send", s.send)
+	s.obj.Set("close", s.close)
+	return s
+}
+
+// dispatch invokes the WebSocket's handler for the specified event, if any.
+func (s *FakeSocket) dispatch(handler string, event js.Value) {
+	if h := s.obj.Get(handler); h.Type() == js.TypeFunction {
+		h.Invoke(event)
+	}
+}
+
+// Open simulates the WebSocket opening.
+func (s *FakeSocket) Open() {
+	s.obj.Set("readyState", 1)
+	s.dispatch("onopen", jsutil.NewObject())
+}
+
+// Receive simulates a text message received over the WebSocket.
+func (s *FakeSocket) Receive(data string) {
+	event := jsutil.NewObject()
+	event.Set("data", data)
+	s.dispatch("onmessage", event)
+}
+
+// CloseRemote simulates the remote end closing the WebSocket with the
+// specified code and reason.
+func (s *FakeSocket) CloseRemote(code int, reason string) {
+	s.obj.Set("readyState", 3)
+	event := jsutil.NewObject()
+	event.Set("code", code)
+	event.Set("reason", reason)
+	s.dispatch("onclose", event)
+}
+
+// Closed returns true if the WebSocket was closed using its close method.
+func (s *FakeSocket) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
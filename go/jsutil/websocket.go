@@ -0,0 +1,135 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall/js"
+)
+
+const (
+	// wsOpen is the readyState of a WebSocket that is open.
+	wsOpen = 1
+	// wsNormalClosure is the close code for a WebSocket closed normally.
+	wsNormalClosure = 1000
+)
+
+// WebSocket is a Javascript WebSocket exchanging text messages.
+type WebSocket struct {
+	ws js.Value
+
+	mu    sync.Mutex
+	funcs []js.Func
+}
+
+// NewWebSocket opens a WebSocket to url using ctor (the global WebSocket
+// class if undefined). onOpen is invoked once the WebSocket is open, and
+// onMessage with each text message subsequently received. onClose is invoked
+// once the WebSocket is closed by the remote end or fails to open, with an
+// error describing why if it was not closed normally; it is not invoked if
+// the WebSocket is closed using Close.
+func NewWebSocket(ctor js.Value, url string, onOpen func(), onMessage func(data string), onClose func(err error)) (w *WebSocket, err error) {
+	if ctor.IsUndefined() || ctor.IsNull() {
+		ctor = js.Global().Get("WebSocket")
+	}
+	if ctor.Type() != js.TypeFunction {
+		return nil, errors.New("WebSocket is unavailable")
+	}
+
+	func() {
+		// The constructor throws if the URL is invalid.
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("failed to open WebSocket: %v", r)
+			}
+		}()
+		w = &WebSocket{ws: ctor.New(url)}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	w.handle("onopen", func(js.Value) {
+		onOpen()
+	})
+	w.handle("onmessage", func(event js.Value) {
+		data := event.Get("data")
+		if data.Type() != js.TypeString {
+			LogError("WebSocket: ignoring binary message")
+			return
+		}
+		onMessage(data.String())
+	})
+	w.handle("onclose", func(event js.Value) {
+		w.detach()
+		var err error
+		if code := event.Get("code").Int(); code != wsNormalClosure {
+			err = fmt.Errorf("WebSocket closed with code %d: %s", code, event.Get("reason").String())
+		}
+		onClose(err)
+	})
+	return w, nil
+}
+
+// handle sets the WebSocket's event handler with the specified name.
+func (w *WebSocket) handle(name string, f func(event js.Value)) {
+	fo := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		f(SingleArg(args))
+		return nil
+	})
+	w.mu.Lock()
+	w.funcs = append(w.funcs, fo)
+	w.mu.Unlock()
+	w.ws.Set(name, fo)
+}
+
+// detach removes the event handlers set by NewWebSocket.
+func (w *WebSocket) detach() {
+	w.mu.Lock()
+	funcs := w.funcs
+	w.funcs = nil
+	w.mu.Unlock()
+
+	for _, name := range []string{"onopen", "onmessage", "onclose"} {
+		w.ws.Set(name, js.Null())
+	}
+	for _, fo := range funcs {
+		fo.Release()
+	}
+}
+
+// Send sends a text message over the WebSocket, which must be open.
+func (w *WebSocket) Send(data string) (err error) {
+	if w.ws.Get("readyState").Int() != wsOpen {
+		return errors.New("WebSocket is not open")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to send over WebSocket: %v", r)
+		}
+	}()
+	w.ws.Call("send", data)
+	return nil
+}
+
+// Close closes the WebSocket.
+func (w *WebSocket) Close() {
+	w.detach()
+	w.ws.Call("close", wsNormalClosure)
+}
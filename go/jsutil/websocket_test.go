@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsutil
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeWebSocketClass returns a constructor for WebSockets that record the
+// messages sent over them. The returned function releases it.
+func fakeWebSocketClass(sockets *[]js.Value, sent *[]string) (js.Value, func()) {
+	send := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		*sent = append(*sent, args[0].String())
+		return nil
+	})
+	closeFunc := js.FuncOf(func(this js.Value, _ []js.Value) any {
+		this.Set("readyState", 3)
+		return nil
+	})
+	ctor := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		ws := NewObject()
+		ws.Set("url", args[0])
+		ws.Set("readyState", 0)
+		ws.Set("send", send)
+		ws.Set("close", closeFunc)
+		*sockets = append(*sockets, ws)
+		return ws
+	})
+	return ctor.Value, func() {
+		send.Release()
+		closeFunc.Release()
+		ctor.Release()
+	}
+}
+
+// dispatchWebSocket invokes the WebSocket's handler for the specified event.
+func dispatchWebSocket(ws js.Value, handler string, event map[string]any) {
+	if h := ws.Get(handler); h.Type() == js.TypeFunction {
+		h.Invoke(js.ValueOf(event))
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	var sockets []js.Value
+	var sent []string
+	ctor, release := fakeWebSocketClass(&sockets, &sent)
+	defer release()
+
+	var opened bool
+	var received []string
+	var closeErr error
+	var closed bool
+	w, err := NewWebSocket(ctor, "wss://relay.example.com/agent",
+		func() { opened = true },
+		func(data string) { received = append(received, data) },
+		func(err error) {
+			closed = true
+			closeErr = err
+		})
+	if err != nil {
+		t.Fatalf("NewWebSocket failed: %v", err)
+	}
+	if len(sockets) != 1 {
+		t.Fatalf("unexpected number of WebSockets: got %d, want 1", len(sockets))
+	}
+	ws := sockets[0]
+	if diff := cmp.Diff(ws.Get("url").String(), "wss://relay.example.com/agent"); diff != "" {
+		t.Errorf("incorrect URL; -got +want: %s", diff)
+	}
+
+	if err := w.Send("too soon"); err == nil {
+		t.Errorf("Send before open succeeded; want error")
+	}
+
+	ws.Set("readyState", 1)
+	dispatchWebSocket(ws, "onopen", map[string]any{})
+	if !opened {
+		t.Errorf("onOpen not invoked")
+	}
+
+	if err := w.Send("hello"); err != nil {
+		t.Errorf("Send failed: %v", err)
+	}
+	if diff := cmp.Diff(sent, []string{"hello"}); diff != "" {
+		t.Errorf("incorrect messages sent; -got +want: %s", diff)
+	}
+
+	dispatchWebSocket(ws, "onmessage", map[string]any{"data": "world"})
+	if diff := cmp.Diff(received, []string{"world"}); diff != "" {
+		t.Errorf("incorrect messages received; -got +want: %s", diff)
+	}
+
+	dispatchWebSocket(ws, "onclose", map[string]any{"code": 4001, "reason": "bad token"})
+	if !closed {
+		t.Fatalf("onClose not invoked")
+	}
+	if closeErr == nil {
+		t.Errorf("onClose invoked without error for code 4001")
+	}
+	if !ws.Get("onmessage").IsNull() {
+		t.Errorf("handlers not removed once closed")
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	var sockets []js.Value
+	var sent []string
+	ctor, release := fakeWebSocketClass(&sockets, &sent)
+	defer release()
+
+	var closed bool
+	w, err := NewWebSocket(ctor, "wss://relay.example.com/agent", func() {}, func(string) {}, func(error) { closed = true })
+	if err != nil {
+		t.Fatalf("NewWebSocket failed: %v", err)
+	}
+	w.Close()
+	ws := sockets[0]
+	if diff := cmp.Diff(ws.Get("readyState").Int(), 3); diff != "" {
+		t.Errorf("WebSocket not closed; -got +want: %s", diff)
+	}
+	dispatchWebSocket(ws, "onclose", map[string]any{"code": 1000, "reason": ""})
+	if closed {
+		t.Errorf("onClose invoked after Close")
+	}
+}
+
+func TestWebSocketUnavailable(t *testing.T) {
+	_, err := NewWebSocket(js.ValueOf(1), "wss://relay.example.com/agent", func() {}, func(string) {}, func(error) {})
+	if err == nil {
+		t.Errorf("NewWebSocket succeeded; want error")
+	}
+}
@@ -0,0 +1,221 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// capturedKey is the raw object stored in session storage for a key that a
+// client added to the agent, such that it can be adopted as a configured key.
+type capturedKey struct {
+	// Blob is the public key of the added key, encoded as for
+	// LoadedKey.EncodedBlob.
+	Blob          string `js:"blob"`
+	PEMPrivateKey string `js:"pemPrivateKey"`
+}
+
+var (
+	// capturedKeyPrefixes is the prefix for captured keys in session
+	// storage.
+	capturedKeyPrefixes = []string{"captured"}
+)
+
+// CaptureAgent wraps the agent for a single connection, and retains the
+// private keys that the client adds (e.g., using 'ssh-add'), such that they
+// can later be adopted as configured keys using Manager.AdoptLoaded. Like
+// loaded keys, captured keys are retained only for the browser session.
+// Certificates and keys backed by security keys are not captured.
+//
+// CaptureAgent implements the agent.ExtendedAgent interface.
+type CaptureAgent struct {
+	agent.Agent
+	mgr     *DefaultManager
+	enabled func(ctx jsutil.AsyncContext) (bool, error)
+}
+
+// NewCaptureAgent returns a CaptureAgent that captures the keys added to agt
+// with mgr whenever enabled returns true.
+func NewCaptureAgent(agt agent.Agent, mgr *DefaultManager, enabled func(ctx jsutil.AsyncContext) (bool, error)) *CaptureAgent {
+	return &CaptureAgent{
+		Agent:   agt,
+		mgr:     mgr,
+		enabled: enabled,
+	}
+}
+
+// Add implements agent.Agent.Add. Requests are served outside of an
+// AsyncContext, so the key is captured using RunAsync. Failures are logged;
+// they must not prevent the key from being added.
+func (a *CaptureAgent) Add(key agent.AddedKey) error {
+	if err := a.Agent.Add(key); err != nil {
+		return err
+	}
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		if err := a.capture(ctx, key); err != nil {
+			jsutil.LogError("CaptureAgent: %v", err)
+		}
+	})
+	return nil
+}
+
+// capture retains the added key, if enabled.
+func (a *CaptureAgent) capture(ctx jsutil.AsyncContext, key agent.AddedKey) error {
+	if key.Certificate != nil {
+		return nil
+	}
+	if _, ok := key.PrivateKey.(*securitykey.Key); ok {
+		return nil
+	}
+	enabled, err := a.enabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to determine whether to capture added key: %w", err)
+	}
+	if !enabled {
+		return nil
+	}
+
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse added key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key.PrivateKey, key.Comment)
+	if err != nil {
+		return fmt.Errorf("failed to encode added key: %w", err)
+	}
+	ck := &capturedKey{
+		Blob:          base64.StdEncoding.EncodeToString(signer.PublicKey().Marshal()),
+		PEMPrivateKey: string(pem.EncodeToMemory(block)),
+	}
+
+	// A key added again replaces the one captured previously.
+	if err := a.mgr.capturedKeys.Delete(ctx, func(c *capturedKey) bool { return c.Blob == ck.Blob }); err != nil {
+		return fmt.Errorf("failed to replace captured key: %w", err)
+	}
+	if err := a.mgr.capturedKeys.Write(ctx, ck); err != nil {
+		return fmt.Errorf("failed to capture added key: %w", err)
+	}
+	return nil
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *CaptureAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *CaptureAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// capturedBlobs returns the public keys of the captured keys, encoded as for
+// LoadedKey.EncodedBlob.
+func (m *DefaultManager) capturedBlobs(ctx jsutil.AsyncContext) (map[string]bool, error) {
+	captured, err := m.capturedKeys.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read captured keys: %w", err)
+	}
+	result := map[string]bool{}
+	for _, c := range captured {
+		result[c.Blob] = true
+	}
+	return result, nil
+}
+
+// AdoptLoaded implements Manager.AdoptLoaded.
+func (m *DefaultManager) AdoptLoaded(ctx jsutil.AsyncContext, blob, name string) error {
+	byBlob := func(c *capturedKey) bool { return c.Blob == blob }
+	ck, err := m.capturedKeys.Read(ctx, byBlob)
+	if err != nil {
+		return fmt.Errorf("failed to read captured key: %w", err)
+	}
+	if ck == nil {
+		return fmt.Errorf("%w: key was not captured when it was added to the agent", ErrKeyNotFound)
+	}
+
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+	found := false
+	for _, l := range loaded {
+		if l.EncodedBlob() == blob && l.ID() == InvalidID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: key is no longer loaded", ErrKeyNotFound)
+	}
+
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+	names := map[string][]ID{}
+	for _, k := range configured {
+		names[k.Name] = append(names[k.Name], ID(k.ID))
+	}
+	if len(names[name]) > 0 {
+		name = unusedName(name, names)
+	}
+
+	id, _, err := m.add(ctx, name, ck.PEMPrivateKey, false)
+	if err != nil {
+		return fmt.Errorf("failed to configure key: %w", err)
+	}
+	// Loading the configured key replaces the copy added by the client,
+	// along with any constraints the client applied to it.
+	if err := m.Load(ctx, id, ""); err != nil {
+		return fmt.Errorf("failed to load configured key: %w", err)
+	}
+	if err := m.capturedKeys.Delete(ctx, byBlob); err != nil {
+		jsutil.LogError("DefaultManager.AdoptLoaded: failed to remove captured key: %v", err)
+	}
+	return nil
+}
+
+// removeStaleCaptured removes the captured keys that are no longer loaded
+// into the agent, and so can no longer be adopted.
+func (m *DefaultManager) removeStaleCaptured(ctx jsutil.AsyncContext, loaded []*LoadedKey) error {
+	current := map[string]bool{}
+	for _, l := range loaded {
+		if l.ID() == InvalidID {
+			current[l.EncodedBlob()] = true
+		}
+	}
+	if err := m.capturedKeys.Delete(ctx, func(c *capturedKey) bool { return !current[c.Blob] }); err != nil {
+		return fmt.Errorf("failed to delete captured keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAdoptLoaded(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description  string
+		initial      []*initialKey
+		capture      bool
+		name         string
+		wantCaptured bool
+		wantNames    []string
+		wantErr      error
+	}{
+		{
+			description:  "adopt captured key",
+			capture:      true,
+			name:         "client-key",
+			wantCaptured: true,
+			wantNames:    []string{"client-key"},
+		},
+		{
+			description: "adopt captured key with name in use",
+			initial: []*initialKey{
+				{
+					Name:          "client-key",
+					PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+				},
+			},
+			capture:      true,
+			name:         "client-key",
+			wantCaptured: true,
+			wantNames:    []string{"client-key", "client-key (2)"},
+		},
+		{
+			description: "fail if not captured",
+			capture:     false,
+			name:        "client-key",
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, tc.initial)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+
+				// A client adds a key to the agent.
+				agt := NewCaptureAgent(mgr.agent, mgr, func(ctx jsutil.AsyncContext) (bool, error) { return tc.capture, nil })
+				priv, err := ssh.ParseRawPrivateKey([]byte(testdata.WithoutPassphrase.Private))
+				if err != nil {
+					t.Fatalf("failed to parse key: %v", err)
+				}
+				if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "client-key"}); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if len(loaded) != 1 {
+					t.Fatalf("incorrect number of loaded keys: got %d, want 1", len(loaded))
+				}
+				if diff := cmp.Diff(loaded[0].Captured, tc.wantCaptured); diff != "" {
+					t.Errorf("incorrect captured; -got +want: %s", diff)
+				}
+
+				err = mgr.AdoptLoaded(ctx, loaded[0].EncodedBlob(), tc.name)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if tc.wantErr != nil {
+					return
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				var names []string
+				ids := map[string]bool{}
+				for _, k := range configured {
+					names = append(names, k.Name)
+					ids[k.ID] = true
+				}
+				if diff := cmp.Diff(names, tc.wantNames, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+
+				// The configured key replaces the client's copy.
+				loaded, err = mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if len(loaded) != 1 {
+					t.Fatalf("incorrect number of loaded keys after adopting: got %d, want 1", len(loaded))
+				}
+				if id := loaded[0].ID(); !ids[string(id)] {
+					t.Errorf("loaded key has ID %s; want a configured key", id)
+				}
+				if loaded[0].Captured {
+					t.Errorf("adopted key still captured")
+				}
+			})
+		})
+	}
+}
+
+func TestRemoveStaleCaptured(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		agt := NewCaptureAgent(mgr.agent, mgr, func(ctx jsutil.AsyncContext) (bool, error) { return true, nil })
+		priv, err := ssh.ParseRawPrivateKey([]byte(testdata.WithoutPassphrase.Private))
+		if err != nil {
+			t.Fatalf("failed to parse key: %v", err)
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "client-key"}); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		captured := func() int {
+			t.Helper()
+			keys, err := mgr.capturedKeys.ReadAll(ctx)
+			if err != nil {
+				t.Fatalf("failed to read captured keys: %v", err)
+			}
+			return len(keys)
+		}
+
+		// Captured keys are retained while loaded.
+		if _, err := mgr.RemoveOrphanedSessionKeys(ctx); err != nil {
+			t.Fatalf("failed to remove orphaned keys: %v", err)
+		}
+		if diff := cmp.Diff(captured(), 1); diff != "" {
+			t.Errorf("incorrect captured keys while loaded; -got +want: %s", diff)
+		}
+
+		if err := agt.RemoveAll(); err != nil {
+			t.Fatalf("failed to remove keys: %v", err)
+		}
+		if _, err := mgr.RemoveOrphanedSessionKeys(ctx); err != nil {
+			t.Fatalf("failed to remove orphaned keys: %v", err)
+		}
+		if diff := cmp.Diff(captured(), 0); diff != "" {
+			t.Errorf("incorrect captured keys after removal; -got +want: %s", diff)
+		}
+	})
+}
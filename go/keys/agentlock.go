@@ -0,0 +1,210 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// agentLockPrefixes is the prefix for the agent lock in session
+	// storage.
+	agentLockPrefixes = []string{"agentLock"}
+)
+
+// agentLock is the raw object stored in session storage while a client has
+// locked the agent, such that the agent is locked again if the background
+// worker restarts.
+type agentLock struct {
+	// Salt is the base64-encoded salt with which the lock passphrase is
+	// hashed; see lockSecret.
+	Salt string `js:"salt"`
+	// Secret is the base64-encoded secret with which the agent is locked.
+	Secret string `js:"secret"`
+}
+
+// lockSaltBytes is the size of the salt with which lock passphrases are
+// hashed.
+const lockSaltBytes = 16
+
+// lockSecret derives the secret with which the agent is locked from the
+// passphrase supplied by the client. The agent is locked with the secret
+// rather than the passphrase, such that the passphrase itself is never stored.
+func lockSecret(salt, passphrase []byte) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write(passphrase)
+	return h.Sum(nil)
+}
+
+// restoreAgentLock locks the agent again if a client had locked it before the
+// background worker restarted. It must be invoked after keys are loaded into
+// the agent, since keys cannot be added while it is locked.
+func (m *DefaultManager) restoreAgentLock(ctx jsutil.AsyncContext) error {
+	lk, err := m.agentLock.Read(ctx, func(*agentLock) bool { return true })
+	if err != nil {
+		return fmt.Errorf("failed to read agent lock: %w", err)
+	}
+	if lk == nil {
+		return nil
+	}
+	secret, err := base64.StdEncoding.DecodeString(lk.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to parse agent lock: %w", err)
+	}
+	if err := m.agent.Lock(secret); err != nil {
+		return fmt.Errorf("failed to lock agent: %w", err)
+	}
+	m.agentLocked.Store(true)
+	return nil
+}
+
+// LockAgent wraps the agent for a single connection, and records with the
+// manager when a client locks or unlocks the agent using the agent protocol
+// (e.g., 'ssh-add -x' and 'ssh-add -X'). While locked, the agent lists no
+// keys and refuses to sign; the manager reports the lock in its Snapshot,
+// and refuses to load keys. The lock is recorded in session storage, such that
+// LoadFromSession locks the agent again if the background worker restarts.
+//
+// LockAgent implements the agent.ExtendedAgent interface.
+type LockAgent struct {
+	agent.Agent
+	mgr *DefaultManager
+	// changed is invoked after the agent is locked or unlocked, or is nil.
+	changed func(ctx jsutil.AsyncContext)
+}
+
+// NewLockAgent returns a LockAgent that records the lock state of agt with
+// mgr, and invokes changed (if non-nil) whenever it changes.
+func NewLockAgent(agt agent.Agent, mgr *DefaultManager, changed func(ctx jsutil.AsyncContext)) *LockAgent {
+	return &LockAgent{
+		Agent:   agt,
+		mgr:     mgr,
+		changed: changed,
+	}
+}
+
+// setLocked records the lock state, persisting lk in session storage if the
+// agent is locked. Requests are served outside of an AsyncContext, so storage
+// is accessed and changed is invoked using RunAsync.
+func (a *LockAgent) setLocked(locked bool, lk *agentLock) error {
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		if err = a.mgr.agentLock.Delete(ctx, func(*agentLock) bool { return true }); err != nil {
+			return
+		}
+		if locked {
+			if err = a.mgr.agentLock.Write(ctx, lk); err != nil {
+				return
+			}
+		}
+		a.mgr.agentLocked.Store(locked)
+		if a.changed != nil {
+			a.changed(ctx)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record agent lock: %w", err)
+	}
+	return nil
+}
+
+// storedLock returns the lock recorded in session storage, or nil if there is
+// none.
+func (a *LockAgent) storedLock() (*agentLock, error) {
+	var lk *agentLock
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		lk, err = a.mgr.agentLock.Read(ctx, func(*agentLock) bool { return true })
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent lock: %w", err)
+	}
+	return lk, nil
+}
+
+// Lock implements agent.Agent.Lock.
+func (a *LockAgent) Lock(passphrase []byte) error {
+	salt := make([]byte, lockSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	secret := lockSecret(salt, passphrase)
+	if err := a.Agent.Lock(secret); err != nil {
+		return err
+	}
+	lk := &agentLock{
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+		Secret: base64.StdEncoding.EncodeToString(secret),
+	}
+	if err := a.setLocked(true, lk); err != nil {
+		// Unless recorded, the lock would be lifted if the worker
+		// restarts; fail rather than give a false sense of security.
+		if uerr := a.Agent.Unlock(secret); uerr != nil {
+			jsutil.LogError("failed to unlock agent after failing to record lock: %v", uerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unlock implements agent.Agent.Unlock.
+func (a *LockAgent) Unlock(passphrase []byte) error {
+	lk, err := a.storedLock()
+	if err != nil {
+		return err
+	}
+	if lk == nil {
+		return errors.New("agent: not locked")
+	}
+	salt, err := base64.StdEncoding.DecodeString(lk.Salt)
+	if err != nil {
+		return fmt.Errorf("failed to parse agent lock: %w", err)
+	}
+	if err := a.Agent.Unlock(lockSecret(salt, passphrase)); err != nil {
+		return err
+	}
+	return a.setLocked(false, nil)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *LockAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *LockAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestLockAgent(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "loaded-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "unloaded-key",
+				PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		unloadedID, err := findKey(ctx, mgr, InvalidID, "unloaded-key")
+		if err != nil {
+			t.Fatalf("failed to find key: %v", err)
+		}
+
+		changes := 0
+		agt := NewLockAgent(mgr.agent, mgr, func(ctx jsutil.AsyncContext) { changes++ })
+
+		snapshot := func() *Snapshot {
+			t.Helper()
+			s, err := mgr.Snapshot(ctx)
+			if err != nil {
+				t.Fatalf("failed to get snapshot: %v", err)
+			}
+			return s
+		}
+
+		if err := agt.Lock([]byte("secret")); err != nil {
+			t.Fatalf("failed to lock agent: %v", err)
+		}
+		if s := snapshot(); !s.Locked || len(s.Loaded) != 0 {
+			t.Errorf("incorrect snapshot while locked: locked=%t, loaded=%d; want locked with none loaded", s.Locked, len(s.Loaded))
+		}
+		err = mgr.Load(ctx, unloadedID, "")
+		if diff := cmp.Diff(err, ErrAgentLocked, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error loading key while locked; -got +want: %s", diff)
+		}
+
+		// The lock passphrase must match.
+		if err := agt.Unlock([]byte("wrong")); err == nil {
+			t.Errorf("unlock with incorrect passphrase succeeded; want error")
+		}
+		if s := snapshot(); !s.Locked {
+			t.Errorf("agent unlocked by incorrect passphrase")
+		}
+
+		if err := agt.Unlock([]byte("secret")); err != nil {
+			t.Fatalf("failed to unlock agent: %v", err)
+		}
+		if s := snapshot(); s.Locked || len(s.Loaded) != 1 {
+			t.Errorf("incorrect snapshot after unlock: locked=%t, loaded=%d; want unlocked with 1 loaded", s.Locked, len(s.Loaded))
+		}
+		if err := mgr.Load(ctx, unloadedID, ""); err != nil {
+			t.Errorf("failed to load key after unlock: %v", err)
+		}
+
+		// Each change is announced.
+		if diff := cmp.Diff(changes, 2); diff != "" {
+			t.Errorf("incorrect number of changes announced; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestLockAgentRestart(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		localStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+		if _, err := mgr.Add(ctx, "loaded-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		id, err := findKey(ctx, mgr, InvalidID, "loaded-key")
+		if err != nil {
+			t.Errorf("failed to find key: %v", err)
+			return
+		}
+		if err := mgr.Load(ctx, id, ""); err != nil {
+			t.Errorf("failed to load key: %v", err)
+			return
+		}
+		if err := NewLockAgent(mgr.agent, mgr, nil).Lock([]byte("secret")); err != nil {
+			t.Errorf("failed to lock agent: %v", err)
+			return
+		}
+
+		// Simulate the worker restarting: a new manager, with a new
+		// keyring, restores the keys from the same session storage.
+		restarted := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+		if err := restarted.LoadFromSession(ctx); err != nil {
+			t.Errorf("LoadFromSession failed: %v", err)
+			return
+		}
+		s, err := restarted.Snapshot(ctx)
+		if err != nil {
+			t.Errorf("failed to get snapshot: %v", err)
+			return
+		}
+		if !s.Locked || len(s.Loaded) != 0 {
+			t.Errorf("incorrect snapshot after restart: locked=%t, loaded=%d; want locked with none loaded", s.Locked, len(s.Loaded))
+		}
+
+		// The original passphrase is still required to unlock it.
+		agt := NewLockAgent(restarted.agent, restarted, nil)
+		if err := agt.Unlock([]byte("wrong")); err == nil {
+			t.Errorf("unlock with incorrect passphrase succeeded; want error")
+		}
+		if err := agt.Unlock([]byte("secret")); err != nil {
+			t.Errorf("failed to unlock agent: %v", err)
+			return
+		}
+		s, err = restarted.Snapshot(ctx)
+		if err != nil {
+			t.Errorf("failed to get snapshot: %v", err)
+			return
+		}
+		if s.Locked || len(s.Loaded) != 1 {
+			t.Errorf("incorrect snapshot after unlock: locked=%t, loaded=%d; want unlocked with 1 loaded", s.Locked, len(s.Loaded))
+		}
+
+		// Once unlocked, the agent is no longer locked after a restart.
+		again := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+		if err := again.LoadFromSession(ctx); err != nil {
+			t.Errorf("LoadFromSession failed: %v", err)
+			return
+		}
+		if again.agentLocked.Load() {
+			t.Errorf("agent locked after restart following unlock")
+		}
+	})
+}
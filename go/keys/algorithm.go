@@ -0,0 +1,106 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/ppk"
+	"golang.org/x/crypto/ssh"
+)
+
+// oidDSA identifies DSA keys in PKCS#8 structures.
+var oidDSA = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+
+// unsupportedAlgorithms are the legacy key algorithms that the agent cannot
+// serve, and a description of each for the user.
+var unsupportedAlgorithms = map[string]string{
+	ssh.KeyAlgoDSA: "DSA (ssh-dss) keys are insecure, and are disabled by OpenSSH and most SSH servers",
+}
+
+// opensshWrapper is the outer structure of an OpenSSH private key, whose
+// public key is never encrypted. See:
+//
+//	https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+type opensshWrapper struct {
+	CipherName string
+	KdfName    string
+	KdfOpts    string
+	NumKeys    uint32
+	PubKey     []byte
+	Rest       []byte `ssh:"rest"`
+}
+
+// pkcs8Wrapper is the outer structure of an unencrypted PKCS#8 private key.
+type pkcs8Wrapper struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// keyAlgorithm returns the SSH algorithm of a private key, determined without
+// decrypting or fully parsing it. An empty string is returned if it cannot be
+// determined this way.
+func keyAlgorithm(pemPrivateKey string) string {
+	if ppk.IsPPK([]byte(pemPrivateKey)) {
+		if k, err := ppk.Parse([]byte(pemPrivateKey)); err == nil {
+			return k.PublicKey().Type()
+		}
+		return ""
+	}
+
+	block, _ := pem.Decode([]byte(pemPrivateKey))
+	if block == nil {
+		return ""
+	}
+	switch block.Type {
+	case "DSA PRIVATE KEY":
+		return ssh.KeyAlgoDSA
+	case "PRIVATE KEY":
+		var w pkcs8Wrapper
+		if _, err := asn1.Unmarshal(block.Bytes, &w); err == nil && w.Algorithm.Algorithm.Equal(oidDSA) {
+			return ssh.KeyAlgoDSA
+		}
+	case "OPENSSH PRIVATE KEY":
+		const magic = "openssh-key-v1\x00"
+		if !bytes.HasPrefix(block.Bytes, []byte(magic)) {
+			return ""
+		}
+		var w opensshWrapper
+		if err := ssh.Unmarshal(block.Bytes[len(magic):], &w); err != nil || w.NumKeys != 1 {
+			return ""
+		}
+		if pub, err := ssh.ParsePublicKey(w.PubKey); err == nil {
+			return pub.Type()
+		}
+	}
+	return ""
+}
+
+// checkAlgorithm returns an error wrapping ErrUnsupportedAlgorithm if the
+// private key uses a legacy algorithm that the agent cannot serve.
+func checkAlgorithm(pemPrivateKey string) error {
+	alg := keyAlgorithm(pemPrivateKey)
+	if reason, ok := unsupportedAlgorithms[alg]; ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, reason)
+	}
+	return nil
+}
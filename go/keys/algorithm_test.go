@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCheckAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		wantErr     error
+	}{
+		{
+			description: "DSA key in OpenSSH format",
+			key:         testdata.DSAOpenSSHFormat.Private,
+			wantErr:     ErrUnsupportedAlgorithm,
+		},
+		{
+			description: "DSA key in legacy format",
+			key:         testdata.DSALegacyFormat.Private,
+			wantErr:     ErrUnsupportedAlgorithm,
+		},
+		{
+			description: "DSA key in PKCS#8 format",
+			key:         testdata.DSAPKCS8Format.Private,
+			wantErr:     ErrUnsupportedAlgorithm,
+		},
+		{
+			description: "RSA key",
+			key:         testdata.WithoutPassphrase.Private,
+		},
+		{
+			description: "encrypted OpenSSH key",
+			key:         testdata.ED25519WithPassphrase.Private,
+		},
+		{
+			description: "unencrypted PKCS#8 key",
+			key:         testdata.PKCS8FormatWithoutPassphrase.Private,
+		},
+		{
+			description: "PuTTY key",
+			key:         testdata.PPKv3ECDSAWithoutPassphrase.Private,
+		},
+		{
+			description: "not a key",
+			key:         "bogus-key-data",
+		},
+	}
+
+	for _, tc := range testcases {
+		err := checkAlgorithm(tc.key)
+		if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("%s: incorrect error; -got +want: %s", tc.description, diff)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// AuditEntry records a single signature requested from the agent.
+type AuditEntry struct {
+	// Time is the time at which the signature was requested, in seconds
+	// since the Unix epoch.
+	Time int64 `js:"time"`
+	// Fingerprint is the SHA256 fingerprint of the key with which a
+	// signature was requested.
+	Fingerprint string `js:"fingerprint"`
+	// Client identifies the client that requested the signature.
+	Client string `js:"client"`
+	// Err describes why the signature was not made, or is empty if it
+	// succeeded.
+	Err string `js:"err"`
+}
+
+// AuditLog is a log of the signatures requested from the agent, kept so the
+// user can review how their keys were used.
+type AuditLog interface {
+	// Entries returns the entries in the log, oldest first.
+	Entries(ctx jsutil.AsyncContext) ([]*AuditEntry, error)
+	// Clear removes all entries from the log.
+	Clear(ctx jsutil.AsyncContext) error
+}
+
+// SetAuditLog configures the log in which signatures requested from the agent
+// are recorded.
+func (m *DefaultManager) SetAuditLog(l AuditLog) {
+	m.auditLog = l
+}
+
+// AuditEntries implements Manager.AuditEntries.
+func (m *DefaultManager) AuditEntries(ctx jsutil.AsyncContext) ([]*AuditEntry, error) {
+	if m.auditLog == nil {
+		return nil, nil
+	}
+	return m.auditLog.Entries(ctx)
+}
+
+// ClearAuditLog implements Manager.ClearAuditLog.
+func (m *DefaultManager) ClearAuditLog(ctx jsutil.AsyncContext) error {
+	if m.auditLog == nil {
+		return nil
+	}
+	return m.auditLog.Clear(ctx)
+}
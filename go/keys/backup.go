@@ -0,0 +1,204 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+var (
+	// ErrPasswordRequired indicates that the master password cannot be
+	// verified because none is set.
+	ErrPasswordRequired = i18n.NewError("errExportPasswordRequired")
+
+	errInvalidBackup = i18n.NewError("errInvalidBackup")
+)
+
+// PasswordVerifier verifies the master password, which the user must enter
+// before private keys are exported if one is set.
+type PasswordVerifier interface {
+	// Configured returns true if a master password is set.
+	Configured(ctx jsutil.AsyncContext) (bool, error)
+	// VerifyPassword returns nil if password is the master password. An
+	// error is returned if it is incorrect, or no master password is set.
+	VerifyPassword(ctx jsutil.AsyncContext, password string) error
+}
+
+// SetPasswordVerifier configures the verifier for the master password
+// required to export private keys. Until it is called, or while no master
+// password is set, private keys are exported without one; callers should
+// confirm with the user instead.
+func (m *DefaultManager) SetPasswordVerifier(v PasswordVerifier) {
+	m.passwordVerifier = v
+}
+
+// ReauthWindowSource returns the time after the master password is entered
+// to export private keys during which they may be exported again without it.
+// Zero requires the master password for every export.
+type ReauthWindowSource func(ctx jsutil.AsyncContext) (time.Duration, error)
+
+// SetReauthWindow configures the source of the time during which private keys
+// may be exported again without the master password. Until it is called, the
+// master password is required for every export.
+func (m *DefaultManager) SetReauthWindow(s ReauthWindowSource) {
+	m.reauthWindow = s
+}
+
+// verifyExport checks that private keys may be exported. If a master password
+// is set, password must be it. An empty password is instead accepted if the
+// master password was entered within the re-authentication window; otherwise,
+// ErrReauthRequired is returned.
+func (m *DefaultManager) verifyExport(ctx jsutil.AsyncContext, password string) error {
+	if m.passwordVerifier == nil {
+		return nil
+	}
+	configured, err := m.passwordVerifier.Configured(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check for master password: %w", err)
+	}
+	if !configured {
+		return nil
+	}
+
+	now := time.Now()
+	if password == "" {
+		if m.reauthWindow == nil {
+			return ErrReauthRequired
+		}
+		window, err := m.reauthWindow(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read re-authentication window: %w", err)
+		}
+		if last := m.lastVerified.Load(); last == 0 || now.Sub(time.Unix(0, last)) >= window {
+			return ErrReauthRequired
+		}
+		return nil
+	}
+
+	if err := m.passwordVerifier.VerifyPassword(ctx, password); err != nil {
+		return fmt.Errorf("failed to verify master password: %w", err)
+	}
+	m.lastVerified.Store(now.UnixNano())
+	return nil
+}
+
+const (
+	// backupVersion is the version of the backup format.
+	backupVersion = 1
+)
+
+// BackupBundle is the content of keys exported by the user.
+type BackupBundle struct {
+	// Version is the version of the backup format.
+	Version int `json:"version"`
+	// Created is the time at which the backup was created, in seconds
+	// since the Unix epoch.
+	Created int64 `json:"created"`
+	// Keys are the configured keys.
+	Keys []*BackupKey `json:"keys"`
+}
+
+// BackupKey is a single configured key in a backup.
+type BackupKey struct {
+	// Name is the name of the configured key.
+	Name string `json:"name"`
+	// Fingerprint is the SHA256 fingerprint of the key. Empty if it
+	// cannot be determined without the passphrase.
+	Fingerprint string `json:"fingerprint"`
+	// PublicKey is the public key in authorized_keys format. Empty if it
+	// cannot be determined without the passphrase.
+	PublicKey string `json:"publicKey"`
+	// PrivateKey is the PEM-encoded private key, as configured. Exports
+	// only include private keys if requested; otherwise this is empty.
+	PrivateKey string `json:"privateKey"`
+	// Certificate is the OpenSSH certificate for the key, or empty if
+	// there is none.
+	Certificate string `json:"certificate"`
+}
+
+// newBackupBundle returns a bundle of the configured keys. Private keys are
+// included only if includePrivate is true.
+func (m *DefaultManager) newBackupBundle(ctx jsutil.AsyncContext, now time.Time, includePrivate bool) (*BackupBundle, error) {
+	stored, err := m.readAllStoredKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BackupBundle{
+		Version: backupVersion,
+		Created: now.Unix(),
+		Keys:    []*BackupKey{},
+	}
+	for _, sk := range stored {
+		bk := &BackupKey{
+			Name:        sk.Name,
+			Fingerprint: sk.Fingerprint(),
+			PublicKey:   sk.AuthorizedKey(),
+			Certificate: sk.Certificate,
+		}
+		if includePrivate {
+			bk.PrivateKey = sk.PEMPrivateKey
+		}
+		b.Keys = append(b.Keys, bk)
+	}
+	return b, nil
+}
+
+// readAllStoredKeys returns the stored keys from both locations. A key
+// present in both locations is returned once.
+func (m *DefaultManager) readAllStoredKeys(ctx jsutil.AsyncContext) ([]*storedKey, error) {
+	var result []*storedKey
+	seen := map[string]bool{}
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		stored, err := keys.ReadAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keys: %w", err)
+		}
+		for _, sk := range stored {
+			if !seen[sk.ID] {
+				seen[sk.ID] = true
+				result = append(result, sk)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Export implements Manager.Export.
+func (m *DefaultManager) Export(ctx jsutil.AsyncContext, includePrivate bool, password string) ([]byte, error) {
+	if includePrivate {
+		if err := m.verifyExport(ctx, password); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := m.newBackupBundle(ctx, time.Now(), includePrivate)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode keys: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// fakeVerifier is a PasswordVerifier that accepts a single password. An empty
+// password indicates that no master password is set.
+type fakeVerifier struct {
+	password string
+}
+
+var errFakeWrongPassword = errors.New("wrong password")
+
+func (v *fakeVerifier) Configured(_ jsutil.AsyncContext) (bool, error) {
+	return v.password != "", nil
+}
+
+func (v *fakeVerifier) VerifyPassword(_ jsutil.AsyncContext, password string) error {
+	if v.password == "" {
+		return ErrPasswordRequired
+	}
+	if password != v.password {
+		return errFakeWrongPassword
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	publicOnly := []*BackupKey{
+		{Name: "encrypted"},
+		{
+			Name:        "unencrypted",
+			Fingerprint: blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob),
+			PublicKey:   authorizedKey(t, testdata.ED25519WithoutPassphrase.Blob),
+		},
+	}
+
+	withPrivate := []*BackupKey{
+		{Name: "encrypted", PrivateKey: testdata.WithPassphrase.Private},
+		{
+			Name:        "unencrypted",
+			Fingerprint: blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob),
+			PublicKey:   authorizedKey(t, testdata.ED25519WithoutPassphrase.Blob),
+			PrivateKey:  testdata.ED25519WithoutPassphrase.Private,
+		},
+	}
+
+	testcases := []struct {
+		description    string
+		verifier       PasswordVerifier
+		includePrivate bool
+		password       string
+		wantKeys       []*BackupKey
+		wantErr        error
+	}{
+		{
+			description: "exclude private keys",
+			wantKeys:    publicOnly,
+		},
+		{
+			description: "exclude private keys without password",
+			verifier:    &fakeVerifier{password: "master"},
+			wantKeys:    publicOnly,
+		},
+		{
+			description:    "include private keys",
+			verifier:       &fakeVerifier{password: "master"},
+			includePrivate: true,
+			password:       "master",
+			wantKeys:       withPrivate,
+		},
+		{
+			description:    "include private keys with wrong password",
+			verifier:       &fakeVerifier{password: "master"},
+			includePrivate: true,
+			password:       "guess",
+			wantErr:        errFakeWrongPassword,
+		},
+		{
+			description:    "include private keys without entering password",
+			verifier:       &fakeVerifier{password: "master"},
+			includePrivate: true,
+			wantErr:        ErrReauthRequired,
+		},
+		{
+			description:    "include private keys without master password",
+			verifier:       &fakeVerifier{},
+			includePrivate: true,
+			wantKeys:       withPrivate,
+		},
+		{
+			description:    "include private keys without verifier",
+			includePrivate: true,
+			wantKeys:       withPrivate,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), []*initialKey{
+					{Name: "encrypted", PEMPrivateKey: testdata.WithPassphrase.Private},
+					{Name: "unencrypted", PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private},
+				})
+				if err != nil {
+					t.Errorf("failed to initialize manager: %v", err)
+					return
+				}
+				if tc.verifier != nil {
+					mgr.SetPasswordVerifier(tc.verifier)
+				}
+
+				data, err := mgr.Export(ctx, tc.includePrivate, tc.password)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if err != nil {
+					if data != nil {
+						t.Errorf("data returned with error: %s", data)
+					}
+					return
+				}
+				var b BackupBundle
+				if err := json.Unmarshal(data, &b); err != nil {
+					t.Errorf("failed to parse exported keys: %v", err)
+					return
+				}
+				sorted := cmpopts.SortSlices(func(a, b *BackupKey) bool { return a.Name < b.Name })
+				if diff := cmp.Diff(b.Keys, tc.wantKeys, sorted); diff != "" {
+					t.Errorf("incorrect exported keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestExportReauthWindow(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		window      ReauthWindowSource
+		wantErr     error
+	}{
+		{
+			description: "no window",
+			wantErr:     ErrReauthRequired,
+		},
+		{
+			description: "zero window",
+			window:      func(jsutil.AsyncContext) (time.Duration, error) { return 0, nil },
+			wantErr:     ErrReauthRequired,
+		},
+		{
+			description: "within window",
+			window:      func(jsutil.AsyncContext) (time.Duration, error) { return 5 * time.Minute, nil },
+		},
+		{
+			description: "window elapsed",
+			window:      func(jsutil.AsyncContext) (time.Duration, error) { return time.Nanosecond, nil },
+			wantErr:     ErrReauthRequired,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), []*initialKey{
+					{Name: "unencrypted", PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private},
+				})
+				if err != nil {
+					t.Errorf("failed to initialize manager: %v", err)
+					return
+				}
+				mgr.SetPasswordVerifier(&fakeVerifier{password: "master"})
+				if tc.window != nil {
+					mgr.SetReauthWindow(tc.window)
+				}
+
+				// The master password is required before it has been
+				// entered.
+				if _, err := mgr.Export(ctx, true, ""); !errors.Is(err, ErrReauthRequired) {
+					t.Errorf("incorrect error before entering password; got %v, want %v", err, ErrReauthRequired)
+				}
+				if _, err := mgr.Export(ctx, true, "master"); err != nil {
+					t.Errorf("failed to export with password: %v", err)
+					return
+				}
+				time.Sleep(time.Millisecond)
+				_, err = mgr.Export(ctx, true, "")
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error after entering password; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
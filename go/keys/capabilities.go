@@ -0,0 +1,45 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Capabilities indicates the operations on configured keys that the user is
+// permitted to perform. Loading and unloading keys is always permitted.
+type Capabilities struct {
+	// Add indicates that new keys may be configured.
+	Add bool `js:"add"`
+	// Remove indicates that configured keys may be removed.
+	Remove bool `js:"remove"`
+	// SetLocal indicates that keys may be moved between synced and
+	// local-only storage.
+	SetLocal bool `js:"setLocal"`
+}
+
+// AllCapabilities returns Capabilities that permit all operations.
+func AllCapabilities() *Capabilities {
+	return &Capabilities{
+		Add:      true,
+		Remove:   true,
+		SetLocal: true,
+	}
+}
+
+// CapabilitiesFunc returns the Capabilities currently in effect.
+type CapabilitiesFunc func(ctx jsutil.AsyncContext) (*Capabilities, error)
@@ -0,0 +1,221 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// CertificateInfo describes an OpenSSH certificate associated with a key.
+type CertificateInfo struct {
+	// Type is the type of certificate; either 'user' or 'host'.
+	Type string `js:"type"`
+	// KeyID is the identifier assigned to the certificate by the CA.
+	KeyID string `js:"keyId"`
+	// Principals are the principals for which the certificate is valid.
+	// Empty if the certificate is valid for any principal.
+	Principals []string `js:"principals"`
+	// ValidAfter is the time (in seconds since the Unix epoch) from which
+	// the certificate is valid. Zero if the certificate is valid from the
+	// beginning of time.
+	ValidAfter int64 `js:"validAfter"`
+	// ValidBefore is the time (in seconds since the Unix epoch) at which
+	// the certificate expires. Zero if the certificate does not expire.
+	ValidBefore int64 `js:"validBefore"`
+	// CAFingerprint is the SHA256 fingerprint of the CA that signed the
+	// certificate.
+	CAFingerprint string `js:"caFingerprint"`
+}
+
+// Expired returns true if the certificate has expired at the specified time.
+func (c *CertificateInfo) Expired(now time.Time) bool {
+	return c.ValidBefore != 0 && now.Unix() >= c.ValidBefore
+}
+
+// ExpiresWithin returns true if the certificate has not yet expired at the
+// specified time, but expires within the specified duration.
+func (c *CertificateInfo) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return c.ValidBefore != 0 && !c.Expired(now) && now.Add(d).Unix() >= c.ValidBefore
+}
+
+var (
+	errInvalidCertificate  = i18n.NewError("errInvalidCertificate")
+	errCertificateMismatch = i18n.NewError("errCertificateMismatch")
+)
+
+// splitCertificate separates a PEM-encoded private key from a certificate
+// that follows it. The certificate is in the format used for OpenSSH
+// certificate files (e.g., id_ed25519-cert.pub). If no certificate is present,
+// an empty certificate is returned.
+func splitCertificate(s string) (pemPrivateKey string, cert string, err error) {
+	block, rest := pem.Decode([]byte(s))
+	if block == nil {
+		// Not PEM-encoded; leave it for the parser to complain about
+		// when the key is loaded.
+		return s, "", nil
+	}
+
+	rest = bytes.TrimSpace(rest)
+	if len(rest) == 0 {
+		return s, "", nil
+	}
+	if _, err := parseCertificate(string(rest)); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(s, string(rest))), string(rest), nil
+}
+
+// parseCertificate parses a certificate in the format used for OpenSSH
+// certificate files.
+func parseCertificate(s string) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidCertificate, err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key of type %s is not a certificate", errInvalidCertificate, pub.Type())
+	}
+	return cert, nil
+}
+
+// describeCertificate returns a description of the supplied certificate.
+func describeCertificate(cert *ssh.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		KeyID:         cert.KeyId,
+		Principals:    cert.ValidPrincipals,
+		CAFingerprint: ssh.FingerprintSHA256(cert.SignatureKey),
+	}
+	switch cert.CertType {
+	case ssh.UserCert:
+		info.Type = "user"
+	case ssh.HostCert:
+		info.Type = "host"
+	}
+	// Times are unsigned in the certificate, with the maximum value
+	// indicating that it does not expire.
+	if cert.ValidAfter != 0 && cert.ValidAfter != ssh.CertTimeInfinity {
+		info.ValidAfter = int64(cert.ValidAfter)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		info.ValidBefore = int64(cert.ValidBefore)
+	}
+	return info
+}
+
+// SetCertificate implements Manager.SetCertificate.
+func (m *DefaultManager) SetCertificate(ctx jsutil.AsyncContext, id ID, certificate string) error {
+	certificate = strings.TrimSpace(certificate)
+
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	// The private key is pinned again along with the certificate, so it
+	// must not have changed since it was last pinned.
+	checksumKey, err := m.checksumKey(ctx)
+	if err := key.verifyChecksum(checksumKey, err); err != nil {
+		return err
+	}
+	checksum, err := m.checksumFunc(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated := *key
+	updated.Certificate = certificate
+	if certificate != "" {
+		cert, err := parseCertificate(certificate)
+		if err != nil {
+			return err
+		}
+		// The public key of some encrypted keys cannot be determined;
+		// the certificate is then checked when the key is loaded.
+		plain := *key
+		plain.Certificate = ""
+		if pub := plain.PublicKey(); pub != nil && !bytes.Equal(pub.Marshal(), cert.Key.Marshal()) {
+			return errCertificateMismatch
+		}
+	}
+	updated.Checksum = checksum(&updated)
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		err := keys.Update(ctx, byID, func(sk *storedKey) {
+			sk.Certificate = updated.Certificate
+			sk.Checksum = updated.Checksum
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+
+	return m.reloadCertificate(ctx, id, certificate)
+}
+
+// reloadCertificate replaces the certificate loaded into the agent for the
+// key with the specified ID, if the key is loaded. This allows a renewed
+// certificate to be used without the passphrase being entered again.
+func (m *DefaultManager) reloadCertificate(ctx jsutil.AsyncContext, id ID, certificate string) error {
+	sk, err := m.sessionKeys.Read(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id })
+	if err != nil {
+		return fmt.Errorf("failed to read session key: %w", err)
+	}
+	if sk == nil {
+		return nil // Not loaded.
+	}
+
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+	for _, l := range loaded {
+		if l.ID() != id || !l.IsCertificate() {
+			continue
+		}
+		jsutil.LogDebug("DefaultManager.SetCertificate: unloading previous certificate for %s", id)
+		if err := m.agent.Remove(&agent.Key{Format: l.Type, Blob: l.Blob()}); err != nil {
+			return fmt.Errorf("failed to unload previous certificate: %w", err)
+		}
+	}
+
+	// Adding the key again replaces the copy without the certificate.
+	if err := m.addToAgent(id, decryptedKey(sk.PrivateKey), certificate); err != nil {
+		return err
+	}
+	err = m.sessionKeys.Update(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id }, func(sk *sessionKey) {
+		sk.Certificate = certificate
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store certificate to session: %w", err)
+	}
+	return nil
+}
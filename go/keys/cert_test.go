@@ -0,0 +1,336 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// testCertificateInfo describes the certificate for
+	// testdata.ED25519WithCertificate.
+	testCertificateInfo = &CertificateInfo{
+		Type:          "user",
+		KeyID:         "test-cert",
+		Principals:    []string{"alice", "bob"},
+		ValidAfter:    1577836800, // 2020-01-01
+		ValidBefore:   4102358400, // 2099-12-31
+		CAFingerprint: "SHA256:fQjt437JzDyDzK2iGXRN2zdV2PRbZrzkMeIS+1RJBSE",
+	}
+)
+
+func TestCertificateExpiry(t *testing.T) {
+	t.Parallel()
+
+	validBefore := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		description     string
+		info            *CertificateInfo
+		now             time.Time
+		wantExpired     bool
+		wantExpiresSoon bool
+	}{
+		{
+			description: "valid",
+			info:        &CertificateInfo{ValidBefore: validBefore.Unix()},
+			now:         validBefore.Add(-30 * 24 * time.Hour),
+		},
+		{
+			description:     "expires soon",
+			info:            &CertificateInfo{ValidBefore: validBefore.Unix()},
+			now:             validBefore.Add(-24 * time.Hour),
+			wantExpiresSoon: true,
+		},
+		{
+			description: "expired",
+			info:        &CertificateInfo{ValidBefore: validBefore.Unix()},
+			now:         validBefore,
+			wantExpired: true,
+		},
+		{
+			description: "does not expire",
+			info:        &CertificateInfo{},
+			now:         validBefore,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.info.Expired(tc.now), tc.wantExpired); diff != "" {
+				t.Errorf("incorrect expired; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(tc.info.ExpiresWithin(tc.now, 7*24*time.Hour), tc.wantExpiresSoon); diff != "" {
+				t.Errorf("incorrect expires soon; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCertificates(t *testing.T) {
+	t.Parallel()
+
+	certKey := testdata.ED25519WithCertificate
+
+	testcases := []struct {
+		description     string
+		pemPrivateKey   string
+		wantAddErr      error
+		wantCertificate *CertificateInfo
+		wantLoadErr     error
+		wantLoaded      []string
+	}{
+		{
+			description:   "key without certificate",
+			pemPrivateKey: certKey.Private,
+			wantLoaded:    []string{certKey.Type},
+		},
+		{
+			description:     "key with certificate",
+			pemPrivateKey:   certKey.Private + "\n" + certKey.Certificate + "\n",
+			wantCertificate: testCertificateInfo,
+			wantLoaded:      []string{certKey.Type, "ssh-ed25519-cert-v01@openssh.com"},
+		},
+		{
+			description:   "invalid certificate",
+			pemPrivateKey: certKey.Private + "\n" + "bogus-certificate",
+			wantAddErr:    errInvalidCertificate,
+		},
+		{
+			description:   "public key is not a certificate",
+			pemPrivateKey: certKey.Private + "\n" + "ssh-ed25519 " + certKey.Blob,
+			wantAddErr:    errInvalidCertificate,
+		},
+		{
+			description:     "certificate for a different key",
+			pemPrivateKey:   testdata.ED25519WithoutPassphrase.Private + "\n" + certKey.Certificate,
+			wantCertificate: testCertificateInfo,
+			wantLoadErr:     errCertificateMismatch,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+
+				_, err = mgr.Add(ctx, "cert-key", tc.pemPrivateKey)
+				if diff := cmp.Diff(err, tc.wantAddErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect add error; -got +want: %s", diff)
+				}
+				if err != nil {
+					return
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if len(configured) != 1 {
+					t.Fatalf("incorrect number of configured keys: got %d, want 1", len(configured))
+				}
+				if diff := cmp.Diff(configured[0].Certificate, tc.wantCertificate); diff != "" {
+					t.Errorf("incorrect certificate; -got +want: %s", diff)
+				}
+
+				id := ID(configured[0].ID)
+				err = mgr.Load(ctx, id, "")
+				if diff := cmp.Diff(err, tc.wantLoadErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect load error; -got +want: %s", diff)
+				}
+				if err != nil {
+					return
+				}
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(loadedKeyTypes(loaded), tc.wantLoaded, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+				}
+
+				// Certificates are restored along with the key
+				// when loaded from the session.
+				restoredAgent := agent.NewKeyring()
+				restored, err := newTestManager(ctx, restoredAgent, syncStorage, sessionStorage, nil)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				if err := restored.LoadFromSession(ctx); err != nil {
+					t.Fatalf("failed to load from session: %v", err)
+				}
+				loaded, err = restored.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(loadedKeyTypes(loaded), tc.wantLoaded, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect restored keys; -got +want: %s", diff)
+				}
+
+				// Unloading removes both the key and certificate.
+				if err := mgr.Unload(ctx, id); err != nil {
+					t.Fatalf("failed to unload key: %v", err)
+				}
+				loaded, err = mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(loadedKeyTypes(loaded), []string(nil)); diff != "" {
+					t.Errorf("incorrect loaded keys after unload; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestSetCertificate(t *testing.T) {
+	t.Parallel()
+
+	certKey := testdata.ED25519WithCertificate
+
+	testcases := []struct {
+		description     string
+		pemPrivateKey   string
+		load            bool
+		id              ID
+		certificate     string
+		wantErr         error
+		wantCertificate *CertificateInfo
+		wantLoaded      []string
+	}{
+		{
+			description:     "attach certificate",
+			pemPrivateKey:   certKey.Private,
+			certificate:     certKey.Certificate,
+			wantCertificate: testCertificateInfo,
+		},
+		{
+			description:     "attach certificate to loaded key",
+			pemPrivateKey:   certKey.Private,
+			load:            true,
+			certificate:     certKey.Certificate + "\n",
+			wantCertificate: testCertificateInfo,
+			wantLoaded:      []string{certKey.Type, "ssh-ed25519-cert-v01@openssh.com"},
+		},
+		{
+			description:   "remove certificate from loaded key",
+			pemPrivateKey: certKey.Private + "\n" + certKey.Certificate,
+			load:          true,
+			certificate:   "",
+			wantLoaded:    []string{certKey.Type},
+		},
+		{
+			description:     "reject invalid certificate",
+			pemPrivateKey:   certKey.Private + "\n" + certKey.Certificate,
+			certificate:     "bogus-certificate",
+			wantErr:         errInvalidCertificate,
+			wantCertificate: testCertificateInfo,
+		},
+		{
+			description:   "reject certificate for a different key",
+			pemPrivateKey: testdata.ED25519WithoutPassphrase.Private,
+			certificate:   certKey.Certificate,
+			wantErr:       errCertificateMismatch,
+		},
+		{
+			description:   "fail on invalid ID",
+			pemPrivateKey: certKey.Private,
+			id:            ID("bogus-id"),
+			certificate:   certKey.Certificate,
+			wantErr:       ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "cert-key",
+						PEMPrivateKey: tc.pemPrivateKey,
+						Load:          tc.load,
+					},
+				})
+				if err != nil {
+					t.Errorf("failed to initialize manager: %v", err)
+					return
+				}
+
+				id := tc.id
+				if id == "" {
+					if id, err = findKey(ctx, mgr, InvalidID, "cert-key"); err != nil {
+						t.Errorf("failed to find key: %v", err)
+						return
+					}
+				}
+
+				err = mgr.SetCertificate(ctx, id, tc.certificate)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Errorf("failed to get configured keys: %v", err)
+					return
+				}
+				if diff := cmp.Diff(configured[0].Certificate, tc.wantCertificate); diff != "" {
+					t.Errorf("incorrect certificate; -got +want: %s", diff)
+				}
+
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Errorf("failed to get loaded keys: %v", err)
+					return
+				}
+				if diff := cmp.Diff(loadedKeyTypes(loaded), tc.wantLoaded, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+				}
+
+				// The key can still be loaded; that is, the
+				// checksum was updated along with the certificate.
+				if err := mgr.Load(ctx, ID(configured[0].ID), ""); err != nil {
+					t.Errorf("failed to load key: %v", err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,131 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+// msgKeysChanged is broadcast to the extension's pages when the configured or
+// loaded keys may have changed. It is not a request; no response is expected.
+type msgKeysChanged struct {
+	Type int `js:"type"`
+}
+
+// changingOps are the operations that may change the configured or loaded
+// keys, or how they are displayed.
+var changingOps = map[OpName]bool{
+	OpAdd:              true,
+	OpAddLocal:         true,
+	OpRemove:           true,
+	OpLoad:             true,
+	OpUnload:           true,
+	OpUndoRemove:       true,
+	OpUndoUnload:       true,
+	OpSetLocal:         true,
+	OpSetAutoLoad:      true,
+	OpRemoveMalformed:  true,
+	OpRepin:            true,
+	OpSetKeysEncrypted: true,
+	OpImport:           true,
+	OpGenerate:         true,
+	OpSetIdleTimeout:   true,
+	OpSetConfirm:       true,
+	OpSetNotify:        true,
+	OpUpdate:           true,
+	OpSetCertificate:   true,
+	OpSetKeyring:       true,
+	OpSetTags:          true,
+	OpUnloadTagged:     true,
+	OpSetHosts:         true,
+	OpSetPersist:       true,
+	OpAdoptLoaded:      true,
+}
+
+// NotifyChanges returns a Middleware that announces each successful operation
+// that may change the configured or loaded keys, using BroadcastChange.
+func NotifyChanges(sender message.Sender) Middleware {
+	return Middleware{
+		After: func(ctx jsutil.AsyncContext, op *Op, err error) error {
+			if err == nil && changingOps[op.Name] {
+				BroadcastChange(ctx, sender)
+			}
+			return err
+		},
+	}
+}
+
+// BroadcastChange announces to the extension's pages, using sender, that the
+// configured or loaded keys may have changed. Pages can use WatchChanges to
+// refresh the keys they display.
+func BroadcastChange(ctx jsutil.AsyncContext, sender message.Sender) {
+	msg := msgKeysChanged{Type: msgTypeKeysChanged}
+	// Chrome fails if no page is listening (e.g., none is open), so the
+	// announcement is best-effort.
+	if _, err := sender.Send(ctx, vert.ValueOf(msg).JSValue()); err != nil {
+		jsutil.LogDebug("BroadcastChange: announcement not delivered: %v", err)
+	}
+}
+
+// WatchStorage invokes callback whenever the configured keys are changed in
+// storage, including by another of the user's devices for synced keys, until
+// the returned cleanup function is invoked. Changes made through m are also
+// reported.
+func (m *DefaultManager) WatchStorage(ctx jsutil.AsyncContext, callback func(ctx jsutil.AsyncContext)) (jsutil.CleanupFunc, error) {
+	cleanup := &jsutil.CleanupFuncs{}
+	for _, area := range []storage.Area{m.syncStorage, m.localStorage} {
+		c, err := storage.NewView(storedKeyPrefixes, area).Watch(ctx, func(ctx jsutil.AsyncContext, _ []string) {
+			callback(ctx)
+		})
+		if err != nil {
+			cleanup.Do()
+			return nil, fmt.Errorf("failed to watch storage: %w", err)
+		}
+		cleanup.Add(c)
+	}
+	return cleanup.Do, nil
+}
+
+// WatchChanges invokes callback whenever a change to the configured or loaded
+// keys is announced by BroadcastChange, until the returned cleanup function is
+// invoked.
+func WatchChanges(listener message.Listener, callback func(ctx jsutil.AsyncContext)) jsutil.CleanupFunc {
+	return listener.Listen(func(ctx jsutil.AsyncContext, msg js.Value) {
+		if !isKeysChanged(msg) {
+			return
+		}
+		callback(ctx)
+	})
+}
+
+// isKeysChanged returns true if msg is a msgKeysChanged announcement.
+func isKeysChanged(msg js.Value) bool {
+	if msg.Type() != js.TypeObject {
+		return false
+	}
+	var header msgHeader
+	if err := vert.ValueOf(msg).AssignTo(&header); err != nil {
+		return false
+	}
+	return header.Type == msgTypeKeysChanged
+}
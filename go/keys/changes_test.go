@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/message"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/norunners/vert"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// recordingSender records the messages sent. As with Chrome when no page is
+// listening, sending fails.
+type recordingSender struct {
+	msgs []js.Value
+}
+
+func (r *recordingSender) Send(_ jsutil.AsyncContext, msg js.Value) (js.Value, error) {
+	r.msgs = append(r.msgs, msg)
+	return js.Undefined(), message.ErrUnavailable
+}
+
+func TestNotifyChanges(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description   string
+		err           error
+		op            func(ctx jsutil.AsyncContext, m Manager) error
+		wantAnnounced bool
+	}{
+		{
+			description: "load",
+			op: func(ctx jsutil.AsyncContext, m Manager) error {
+				return m.Load(ctx, ID("id-0"), "")
+			},
+			wantAnnounced: true,
+		},
+		{
+			description: "add",
+			op: func(ctx jsutil.AsyncContext, m Manager) error {
+				_, err := m.Add(ctx, "new-key", "private-key")
+				return err
+			},
+			wantAnnounced: true,
+		},
+		{
+			description: "failed load",
+			err:         errors.New("failed"),
+			op: func(ctx jsutil.AsyncContext, m Manager) error {
+				return m.Load(ctx, ID("id-0"), "")
+			},
+		},
+		{
+			description: "read",
+			op: func(ctx jsutil.AsyncContext, m Manager) error {
+				_, err := m.Snapshot(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				sender := &recordingSender{}
+				mgr := &dummyManager{Err: tc.err}
+				m := Chain(mgr, NotifyChanges(sender))
+
+				if diff := cmp.Diff(tc.op(ctx, m), tc.err, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				var announced bool
+				for _, msg := range sender.msgs {
+					announced = announced || isKeysChanged(msg)
+				}
+				if diff := cmp.Diff(announced, tc.wantAnnounced); diff != "" {
+					t.Errorf("incorrect announcement; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestWatchStorage(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		syncStorage func() storage.Area
+		local       bool
+	}{
+		{
+			description: "synced key",
+			syncStorage: func() storage.Area { return storage.NewRaw(st.NewMemArea()) },
+		},
+		{
+			description: "local key",
+			syncStorage: func() storage.Area { return storage.NewRaw(st.NewMemArea()) },
+			local:       true,
+		},
+		{
+			description: "sync unavailable",
+			syncStorage: func() storage.Area { return storage.NewUnavailable("sync") },
+			local:       true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := tc.syncStorage()
+				localStorage := storage.NewRaw(st.NewMemArea())
+				mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, storage.NewRaw(st.NewMemArea()))
+
+				changed := make(chan bool, 10)
+				cleanup, err := mgr.WatchStorage(ctx, func(_ jsutil.AsyncContext) {
+					changed <- true
+				})
+				if err != nil {
+					t.Fatalf("WatchStorage failed: %v", err)
+				}
+				defer cleanup()
+
+				// Keys added elsewhere (e.g., another device
+				// sharing synced storage) are reported.
+				other := NewManager(agent.NewKeyring(), syncStorage, localStorage, storage.NewRaw(st.NewMemArea()))
+				add := other.Add
+				if tc.local {
+					add = other.AddLocal
+				}
+				if _, err := add(ctx, "new-key", testdata.WithoutPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				if !<-changed {
+					t.Errorf("change not reported")
+				}
+			})
+		})
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(&dummyManager{}, nil))
+
+		changed := make(chan bool, 1)
+		cleanup := WatchChanges(hub, func(_ jsutil.AsyncContext) {
+			changed <- true
+		})
+		defer cleanup()
+
+		BroadcastChange(ctx, hub)
+		if !<-changed {
+			t.Errorf("change not announced")
+		}
+	})
+}
+
+func TestIsKeysChanged(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		msg         js.Value
+		want        bool
+	}{
+		{
+			description: "announcement",
+			msg:         vert.ValueOf(msgKeysChanged{Type: msgTypeKeysChanged}).JSValue(),
+			want:        true,
+		},
+		{
+			description: "request",
+			msg:         vert.ValueOf(msgLoaded{Type: msgTypeLoaded}).JSValue(),
+		},
+		{
+			description: "other message",
+			msg:         js.ValueOf(map[string]interface{}{"securityKeyResponse": "id"}),
+		},
+		{
+			description: "not an object",
+			msg:         js.ValueOf("keys changed"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(isKeysChanged(tc.msg), tc.want); diff != "" {
+				t.Errorf("incorrect result; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Key material is pinned with a checksum when the key is added, or when the
+// user changes it or trusts the changes. The checksum is stored (and synced)
+// alongside the key, so that key material corrupted or tampered with in
+// storage or in transit between devices fails verification when the key is
+// loaded, rather than producing a confusing parse error.
+//
+// If a master password is set (see SetKeySource), the checksum is an
+// HMAC-SHA256 keyed with a key derived from the vault's data key, so it
+// cannot be updated to match changed key material without the master
+// password; keys can then only be pinned and loaded once it is entered.
+// Otherwise, it is an unkeyed SHA-256 digest, which detects accidental
+// corruption only. Unkeyed checksums recorded before the master password was
+// set are replaced by keyed ones once the key is next loaded.
+//
+// Keys added by earlier versions are not pinned, and are loaded without
+// verification until the user changes their key material.
+
+var (
+	errChecksumMismatch = i18n.NewError("errChecksumMismatch")
+	errChecksumLocked   = i18n.NewError("errChecksumLocked")
+)
+
+const (
+	// checksumPrefix identifies an unkeyed checksum.
+	checksumPrefix = "sha256:"
+	// macPrefix identifies a checksum keyed with the vault's data key.
+	macPrefix = "hmac-sha256:"
+	// macKeyLabel distinguishes the key used for checksums from the data
+	// key from which it is derived, which also encrypts synced keys.
+	macKeyLabel = "chrome-ssh-agent key checksum"
+)
+
+// writeMaterial writes the key material covered by the checksum to h.
+func (s *storedKey) writeMaterial(h interface{ Write([]byte) (int, error) }) {
+	h.Write([]byte(s.PEMPrivateKey))
+	// Separate the fields, such that material cannot move between them
+	// without changing the checksum.
+	h.Write([]byte{0})
+	h.Write([]byte(s.Certificate))
+}
+
+// computeChecksum returns the unkeyed checksum over the key material.
+func (s *storedKey) computeChecksum() string {
+	h := sha256.New()
+	s.writeMaterial(h)
+	return checksumPrefix + base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// computeMAC returns the checksum over the key material, keyed with the
+// specified key.
+func (s *storedKey) computeMAC(key []byte) string {
+	h := hmac.New(sha256.New, key)
+	s.writeMaterial(h)
+	return macPrefix + base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Pinned indicates if the key material is pinned with a checksum.
+func (s *storedKey) Pinned() bool {
+	return s.Checksum != ""
+}
+
+// keyedChecksum indicates if the key material is pinned with a keyed
+// checksum.
+func (s *storedKey) keyedChecksum() bool {
+	return strings.HasPrefix(s.Checksum, macPrefix)
+}
+
+// verifyChecksum returns an error if the key material is pinned, but does not
+// match the checksum. key and keyErr are as returned by checksumKey.
+func (s *storedKey) verifyChecksum(key []byte, keyErr error) error {
+	var want string
+	switch {
+	case !s.Pinned():
+		return nil
+	case s.keyedChecksum() && keyErr != nil:
+		return fmt.Errorf("failed to verify key ID %s: %w", s.ID, keyErr)
+	case s.keyedChecksum() && key == nil:
+		// The master password was removed, so the checksum can no
+		// longer be verified; the user must trust the key again.
+		return fmt.Errorf("%w: key ID %s", errChecksumMismatch, s.ID)
+	case s.keyedChecksum():
+		want = s.computeMAC(key)
+	default:
+		want = s.computeChecksum()
+	}
+	if !hmac.Equal([]byte(s.Checksum), []byte(want)) {
+		return fmt.Errorf("%w: key ID %s", errChecksumMismatch, s.ID)
+	}
+	return nil
+}
+
+// checksumKey returns the key with which key material is pinned, derived from
+// the vault's data key. Nil is returned if no master password is set, such
+// that unkeyed checksums are used. An error wrapping errChecksumLocked is
+// returned if a master password is set, but has not been entered.
+func (m *DefaultManager) checksumKey(ctx jsutil.AsyncContext) ([]byte, error) {
+	if m.keySource == nil {
+		return nil, nil
+	}
+	dataKey, err := m.keySource.EncryptionKey(ctx)
+	if err != nil {
+		if m.passwordVerifier != nil {
+			configured, cerr := m.passwordVerifier.Configured(ctx)
+			if cerr != nil {
+				return nil, fmt.Errorf("failed to check for master password: %w", cerr)
+			}
+			if !configured {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", errChecksumLocked, err)
+	}
+	h := hmac.New(sha256.New, dataKey)
+	h.Write([]byte(macKeyLabel))
+	return h.Sum(nil), nil
+}
+
+// checksumFunc returns a function computing the checksum that pins key
+// material; it is keyed if a master password is set. An error wrapping
+// errChecksumLocked is returned if the master password has not been entered.
+func (m *DefaultManager) checksumFunc(ctx jsutil.AsyncContext) (func(s *storedKey) string, error) {
+	key, err := m.checksumKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return (*storedKey).computeChecksum, nil
+	}
+	return func(s *storedKey) string { return s.computeMAC(key) }, nil
+}
+
+// pin updates the checksum for the key with the specified ID to match its
+// current key material.
+func (m *DefaultManager) pin(ctx jsutil.AsyncContext, id ID) error {
+	checksum, err := m.checksumFunc(ctx)
+	if err != nil {
+		return err
+	}
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Checksum = checksum(sk) }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// Repin implements Manager.Repin.
+func (m *DefaultManager) Repin(ctx jsutil.AsyncContext, id ID) error {
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+	return m.pin(ctx, id)
+}
@@ -0,0 +1,266 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	certKey := testdata.ED25519WithCertificate
+
+	testcases := []struct {
+		description  string
+		modify       func(sk *storedKey)
+		wantMismatch bool
+		wantErr      error
+	}{
+		{
+			description: "unmodified key",
+			modify:      func(sk *storedKey) {},
+		},
+		{
+			description: "modified private key",
+			modify: func(sk *storedKey) {
+				sk.PEMPrivateKey = testdata.WithoutPassphrase.Private
+				sk.Certificate = ""
+			},
+			wantMismatch: true,
+			wantErr:      errChecksumMismatch,
+		},
+		{
+			description: "removed certificate",
+			modify: func(sk *storedKey) {
+				sk.Certificate = ""
+			},
+			wantMismatch: true,
+			wantErr:      errChecksumMismatch,
+		},
+		{
+			description: "key not pinned",
+			modify: func(sk *storedKey) {
+				sk.Checksum = ""
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: certKey.Private + "\n" + certKey.Certificate,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				// Simulate the stored key being changed, e.g., by
+				// sync.
+				if err := mgr.storedKeys.Update(ctx, func(sk *storedKey) bool { return ID(sk.ID) == id }, tc.modify); err != nil {
+					t.Fatalf("failed to modify key: %v", err)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configured[0].ChecksumMismatch, tc.wantMismatch); diff != "" {
+					t.Errorf("incorrect checksum mismatch; -got +want: %s", diff)
+				}
+
+				err = mgr.Load(ctx, id, "")
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				// After re-pinning, the key can be loaded.
+				if err := mgr.Repin(ctx, id); err != nil {
+					t.Fatalf("failed to repin key: %v", err)
+				}
+				if tc.wantErr != nil {
+					if err := mgr.Load(ctx, id, ""); err != nil {
+						t.Errorf("failed to load key after repin: %v", err)
+					}
+				}
+
+				// The key is always pinned after re-pinning.
+				key, err := mgr.readStoredKey(ctx, id)
+				if err != nil {
+					t.Fatalf("failed to read key: %v", err)
+				}
+				if !key.Pinned() {
+					t.Errorf("key not pinned after repin")
+				}
+			})
+		})
+	}
+}
+
+func TestChecksumKeyed(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		// modify simulates the stored key being changed, e.g., by
+		// sync.
+		modify func(sk *storedKey)
+		// locked indicates that the master password has not been
+		// entered when the key is loaded.
+		locked bool
+		// removed indicates that the master password was removed
+		// before the key is loaded.
+		removed      bool
+		wantMismatch bool
+		wantErr      error
+		wantKeyed    bool
+	}{
+		{
+			description: "unmodified key",
+			modify:      func(sk *storedKey) {},
+			wantKeyed:   true,
+		},
+		{
+			description: "modified key with recomputed keyed checksum",
+			modify: func(sk *storedKey) {
+				sk.PEMPrivateKey = testdata.ED25519WithoutPassphrase.Private
+				sk.Checksum = sk.computeMAC([]byte("guessed key"))
+			},
+			wantMismatch: true,
+			wantErr:      errChecksumMismatch,
+			wantKeyed:    true,
+		},
+		{
+			description: "master password not entered",
+			modify:      func(sk *storedKey) {},
+			locked:      true,
+			wantErr:     errChecksumLocked,
+			wantKeyed:   true,
+		},
+		{
+			description:  "master password removed",
+			modify:       func(sk *storedKey) {},
+			removed:      true,
+			wantMismatch: true,
+			wantErr:      errChecksumMismatch,
+			wantKeyed:    true,
+		},
+		{
+			description: "unkeyed checksum replaced once loaded",
+			modify: func(sk *storedKey) {
+				sk.Checksum = sk.computeChecksum()
+			},
+			wantKeyed: true,
+		},
+		{
+			description: "unpinned key not pinned when loaded",
+			modify: func(sk *storedKey) {
+				sk.Checksum = ""
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				keySource := &fakeKeySource{}
+				verifier := &fakeVerifier{password: "master"}
+				mgr.SetKeySource(keySource)
+				mgr.SetPasswordVerifier(verifier)
+
+				id, _, err := mgr.add(ctx, "good-key", testdata.WithoutPassphrase.Private, true)
+				if err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				if err := mgr.localKeys.Update(ctx, func(sk *storedKey) bool { return ID(sk.ID) == id }, tc.modify); err != nil {
+					t.Fatalf("failed to modify key: %v", err)
+				}
+				keySource.locked = tc.locked || tc.removed
+				if tc.removed {
+					verifier.password = ""
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configured[0].ChecksumMismatch, tc.wantMismatch); diff != "" {
+					t.Errorf("incorrect checksum mismatch; -got +want: %s", diff)
+				}
+
+				err = mgr.Load(ctx, id, "")
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				key, err := mgr.readStoredKey(ctx, id)
+				if err != nil {
+					t.Fatalf("failed to read key: %v", err)
+				}
+				if diff := cmp.Diff(key.keyedChecksum(), tc.wantKeyed); diff != "" {
+					t.Errorf("incorrect keyed checksum; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestRepinNotFound(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		err = mgr.Repin(ctx, ID("bogus-id"))
+		if diff := cmp.Diff(err, ErrKeyNotFound, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
@@ -17,8 +17,9 @@
 package keys
 
 import (
-	"errors"
+	"encoding/base64"
 	"fmt"
+	"reflect"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -29,18 +30,48 @@ import (
 // Server exposes a Manager instance via a messaging API so that a shared
 // instance can be invoked from a different page.
 type Server struct {
-	mgr Manager
+	mgr          Manager
+	capabilities CapabilitiesFunc
 }
 
 // NewServer returns a new Server that manages keys using the
-// supplied Manager.
-func NewServer(mgr Manager) *Server {
+// supplied Manager. Operations are only performed if permitted by the
+// Capabilities returned by capabilities; if nil, all operations are permitted.
+func NewServer(mgr Manager, capabilities CapabilitiesFunc) *Server {
 	result := &Server{
-		mgr: mgr,
+		mgr:          mgr,
+		capabilities: capabilities,
 	}
 	return result
 }
 
+// permitted returns an error if the operation (named by op) is not permitted
+// by the current Capabilities, as determined by allowed.
+func (s *Server) permitted(ctx jsutil.AsyncContext, op string, allowed func(c *Capabilities) bool) error {
+	if s.capabilities == nil {
+		return nil
+	}
+	c, err := s.capabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to determine capabilities: %w", ErrNotPermitted, err)
+	}
+	if !allowed(c) {
+		return fmt.Errorf("%w: %s", ErrNotPermitted, op)
+	}
+	return nil
+}
+
+// The messages below define the API exposed by Server. A machine-readable
+// description is generated from them (see generate.go); regenerate it whenever
+// they change.
+
+// protocolVersion is the version of the messaging API. It is included in every
+// request and response, so that a page and the background worker using
+// different versions (e.g., a page left open while the extension was updated)
+// do not misinterpret each other's messages. Increment it whenever a message
+// changes incompatibly; adding a message does not require a new version.
+const protocolVersion = 1
+
 // Define a distinct type for each message.  These are embedded in each
 // message.
 const (
@@ -57,11 +88,81 @@ const (
 	msgTypeUnload
 	msgTypeUnloadRsp
 	msgTypeErrorRsp
+	msgTypeSetLocal
+	msgTypeSetLocalRsp
+	msgTypeSetAutoLoad
+	msgTypeSetAutoLoadRsp
+	msgTypeMalformed
+	msgTypeMalformedRsp
+	msgTypeRemoveMalformed
+	msgTypeRemoveMalformedRsp
+	msgTypeRepin
+	msgTypeRepinRsp
+	msgTypeCheckSync
+	msgTypeCheckSyncRsp
+	msgTypeExport
+	msgTypeExportRsp
+	msgTypeImport
+	msgTypeImportRsp
+	msgTypeGenerate
+	msgTypeGenerateRsp
+	msgTypeSetIdleTimeout
+	msgTypeSetIdleTimeoutRsp
+	msgTypeSetConfirm
+	msgTypeSetConfirmRsp
+	msgTypeUpdate
+	msgTypeUpdateRsp
+	msgTypeSetCertificate
+	msgTypeSetCertificateRsp
+	msgTypeConnected
+	msgTypeConnectedRsp
+	msgTypeAuditEntries
+	msgTypeAuditEntriesRsp
+	msgTypeClearAuditLog
+	msgTypeClearAuditLogRsp
+	msgTypeSetNotify
+	msgTypeSetNotifyRsp
+	msgTypeAddLocal
+	msgTypeAddLocalRsp
+	msgTypeKeysEncrypted
+	msgTypeKeysEncryptedRsp
+	msgTypeSetKeysEncrypted
+	msgTypeSetKeysEncryptedRsp
+	msgTypeSnapshot
+	msgTypeSnapshotRsp
+	msgTypeKeysChanged
+	msgTypeStorageUsage
+	msgTypeStorageUsageRsp
+	msgTypeStatus
+	msgTypeStatusRsp
+	msgTypeLogEntries
+	msgTypeLogEntriesRsp
+	msgTypeSetKeyring
+	msgTypeSetKeyringRsp
+	msgTypeSetPersist
+	msgTypeSetPersistRsp
+	msgTypeAdoptLoaded
+	msgTypeAdoptLoadedRsp
+	msgTypeUndoRemove
+	msgTypeUndoRemoveRsp
+	msgTypeUndoUnload
+	msgTypeUndoUnloadRsp
+	msgTypeSetTags
+	msgTypeSetTagsRsp
+	msgTypeUnloadTagged
+	msgTypeUnloadTaggedRsp
+	msgTypeSetHosts
+	msgTypeSetHostsRsp
+	msgTypeSelfTest
+	msgTypeSelfTestRsp
+	msgTypeRunDiagnostics
+	msgTypeRunDiagnosticsRsp
 )
 
 // msgHeader are the common fields included in every message.
 type msgHeader struct {
-	Type int `js:"type"`
+	Type    int `js:"type"`
+	Version int `js:"version"`
 }
 
 type msgConfigured struct {
@@ -72,6 +173,7 @@ type rspConfigured struct {
 	Type int              `js:"type"`
 	Keys []*ConfiguredKey `js:"keys"`
 	Err  string           `js:"err"`
+	Code int              `js:"code"`
 }
 
 type msgLoaded struct {
@@ -82,6 +184,104 @@ type rspLoaded struct {
 	Type int          `js:"type"`
 	Keys []*LoadedKey `js:"keys"`
 	Err  string       `js:"err"`
+	Code int          `js:"code"`
+}
+
+type msgSnapshot struct {
+	Type int `js:"type"`
+}
+
+type rspSnapshot struct {
+	Type     int       `js:"type"`
+	Snapshot *Snapshot `js:"snapshot"`
+	Err      string    `js:"err"`
+	Code     int       `js:"code"`
+}
+
+type msgStorageUsage struct {
+	Type int `js:"type"`
+}
+
+type rspStorageUsage struct {
+	Type  int           `js:"type"`
+	Usage *StorageUsage `js:"usage"`
+	Err   string        `js:"err"`
+	Code  int           `js:"code"`
+}
+
+type msgStatus struct {
+	Type int `js:"type"`
+}
+
+type rspStatus struct {
+	Type   int     `js:"type"`
+	Status *Status `js:"status"`
+	Err    string  `js:"err"`
+	Code   int     `js:"code"`
+}
+
+type msgSelfTest struct {
+	Type int `js:"type"`
+}
+
+type rspSelfTest struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgRunDiagnostics struct {
+	Type int `js:"type"`
+}
+
+type rspRunDiagnostics struct {
+	Type    int                 `js:"type"`
+	Results []*DiagnosticResult `js:"results"`
+	Err     string              `js:"err"`
+	Code    int                 `js:"code"`
+}
+
+type msgConnected struct {
+	Type int `js:"type"`
+}
+
+type rspConnected struct {
+	Type        int           `js:"type"`
+	Connections []*Connection `js:"connections"`
+	Err         string        `js:"err"`
+	Code        int           `js:"code"`
+}
+
+type msgAuditEntries struct {
+	Type int `js:"type"`
+}
+
+type rspAuditEntries struct {
+	Type    int           `js:"type"`
+	Entries []*AuditEntry `js:"entries"`
+	Err     string        `js:"err"`
+	Code    int           `js:"code"`
+}
+
+type msgClearAuditLog struct {
+	Type int `js:"type"`
+}
+
+type rspClearAuditLog struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgLogEntries struct {
+	Type int `js:"type"`
+}
+
+type rspLogEntries struct {
+	Type    int         `js:"type"`
+	Entries []*LogEntry `js:"entries"`
+	Err     string      `js:"err"`
+	Code    int         `js:"code"`
 }
 
 type msgAdd struct {
@@ -91,8 +291,23 @@ type msgAdd struct {
 }
 
 type rspAdd struct {
-	Type int    `js:"type"`
-	Err  string `js:"err"`
+	Type int      `js:"type"`
+	Info *KeyInfo `js:"info"`
+	Err  string   `js:"err"`
+	Code int      `js:"code"`
+}
+
+type msgAddLocal struct {
+	Type          int    `js:"type"`
+	Name          string `js:"name"`
+	PEMPrivateKey string `js:"pemPrivateKey"`
+}
+
+type rspAddLocal struct {
+	Type int      `js:"type"`
+	Info *KeyInfo `js:"info"`
+	Err  string   `js:"err"`
+	Code int      `js:"code"`
 }
 
 type msgRemove struct {
@@ -103,6 +318,7 @@ type msgRemove struct {
 type rspRemove struct {
 	Type int    `js:"type"`
 	Err  string `js:"err"`
+	Code int    `js:"code"`
 }
 
 type msgLoad struct {
@@ -114,6 +330,7 @@ type msgLoad struct {
 type rspLoad struct {
 	Type int    `js:"type"`
 	Err  string `js:"err"`
+	Code int    `js:"code"`
 }
 
 type msgUnload struct {
@@ -124,20 +341,310 @@ type msgUnload struct {
 type rspUnload struct {
 	Type int    `js:"type"`
 	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetLocal struct {
+	Type  int    `js:"type"`
+	ID    string `js:"id"`
+	Local bool   `js:"local"`
+}
+
+type rspSetLocal struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetAutoLoad struct {
+	Type     int    `js:"type"`
+	ID       string `js:"id"`
+	AutoLoad bool   `js:"autoLoad"`
+}
+
+type rspSetAutoLoad struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgMalformed struct {
+	Type int `js:"type"`
+}
+
+type rspMalformed struct {
+	Type int             `js:"type"`
+	Keys []*MalformedKey `js:"keys"`
+	Err  string          `js:"err"`
+	Code int             `js:"code"`
+}
+
+type msgRemoveMalformed struct {
+	Type       int    `js:"type"`
+	StorageKey string `js:"storageKey"`
+	Local      bool   `js:"local"`
+}
+
+type rspRemoveMalformed struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgRepin struct {
+	Type int    `js:"type"`
+	ID   string `js:"id"`
+}
+
+type rspRepin struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgCheckSync struct {
+	Type int `js:"type"`
+}
+
+type rspCheckSync struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgKeysEncrypted struct {
+	Type int `js:"type"`
+}
+
+type rspKeysEncrypted struct {
+	Type      int    `js:"type"`
+	Encrypted bool   `js:"encrypted"`
+	Err       string `js:"err"`
+	Code      int    `js:"code"`
+}
+
+type msgSetKeysEncrypted struct {
+	Type      int  `js:"type"`
+	Encrypted bool `js:"encrypted"`
+}
+
+type rspSetKeysEncrypted struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgExport struct {
+	Type           int    `js:"type"`
+	IncludePrivate bool   `js:"includePrivate"`
+	Password       string `js:"password"`
+}
+
+type rspExport struct {
+	Type int    `js:"type"`
+	Data string `js:"data"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgImport struct {
+	Type int `js:"type"`
+	// Data is base64-encoded, since it may be a zip archive.
+	Data       string `js:"data"`
+	OnConflict string `js:"onConflict"`
+}
+
+type rspImport struct {
+	Type   int           `js:"type"`
+	Result *ImportResult `js:"result"`
+	Err    string        `js:"err"`
+	Code   int           `js:"code"`
+}
+
+type msgGenerate struct {
+	Type       int    `js:"type"`
+	Name       string `js:"name"`
+	KeyType    string `js:"keyType"`
+	Bits       int    `js:"bits"`
+	Passphrase string `js:"passphrase"`
+}
+
+type rspGenerate struct {
+	Type      int    `js:"type"`
+	PublicKey string `js:"publicKey"`
+	Err       string `js:"err"`
+	Code      int    `js:"code"`
+}
+
+type msgSetIdleTimeout struct {
+	Type    int    `js:"type"`
+	ID      string `js:"id"`
+	Minutes int    `js:"minutes"`
+}
+
+type rspSetIdleTimeout struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetConfirm struct {
+	Type    int    `js:"type"`
+	ID      string `js:"id"`
+	Confirm bool   `js:"confirm"`
+}
+
+type rspSetConfirm struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetKeyring struct {
+	Type    int    `js:"type"`
+	ID      string `js:"id"`
+	Keyring string `js:"keyring"`
+}
+
+type rspSetKeyring struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetPersist struct {
+	Type    int    `js:"type"`
+	ID      string `js:"id"`
+	Persist bool   `js:"persist"`
+}
+
+type rspSetPersist struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetTags struct {
+	Type int      `js:"type"`
+	ID   string   `js:"id"`
+	Tags []string `js:"tags"`
+}
+
+type rspSetTags struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgUnloadTagged struct {
+	Type int    `js:"type"`
+	Tag  string `js:"tag"`
+}
+
+type rspUnloadTagged struct {
+	Type     int      `js:"type"`
+	Unloaded []string `js:"unloaded"`
+	Err      string   `js:"err"`
+	Code     int      `js:"code"`
+}
+
+type msgSetHosts struct {
+	Type  int      `js:"type"`
+	ID    string   `js:"id"`
+	Hosts []string `js:"hosts"`
+}
+
+type rspSetHosts struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgAdoptLoaded struct {
+	Type int    `js:"type"`
+	Blob string `js:"blob"`
+	Name string `js:"name"`
+}
+
+type rspAdoptLoaded struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgUndoRemove struct {
+	Type int    `js:"type"`
+	ID   string `js:"id"`
+}
+
+type rspUndoRemove struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgUndoUnload struct {
+	Type int    `js:"type"`
+	ID   string `js:"id"`
+}
+
+type rspUndoUnload struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetNotify struct {
+	Type   int    `js:"type"`
+	ID     string `js:"id"`
+	Notify bool   `js:"notify"`
+}
+
+type rspSetNotify struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgUpdate struct {
+	Type          int    `js:"type"`
+	ID            string `js:"id"`
+	PEMPrivateKey string `js:"pemPrivateKey"`
+}
+
+type rspUpdate struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetCertificate struct {
+	Type        int    `js:"type"`
+	ID          string `js:"id"`
+	Certificate string `js:"certificate"`
+}
+
+type rspSetCertificate struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
 }
 
 type rspError struct {
 	Type int    `js:"type"`
 	Err  string `js:"err"`
+	Code int    `js:"code"`
 }
 
-// makeErr converts a string to an error. Empty string returns nil (i.e., no
-// error).
-func makeErr(s string) error {
+// makeErr converts a string and error code to an error. Empty string returns
+// nil (i.e., no error).
+func makeErr(s string, code int) error {
 	if s == "" {
 		return nil
 	}
-	return errors.New(s)
+	// Error categories determine how the error is presented to the user,
+	// so preserve them.
+	return errorFromCode(s, code)
 }
 
 // makeErrStr converts an error to a string. A nil error is converted to the
@@ -156,20 +663,60 @@ func (s *Server) makeErrorResponse(err error) js.Value {
 	rsp := rspError{
 		Type: msgTypeErrorRsp,
 		Err:  makeErrStr(err),
+		Code: errorCode(err),
 	}
 	return vert.ValueOf(rsp).JSValue()
 }
 
+// parseMessage parses the message into m, which must point to one of the
+// message structs above. All fields of the message are required; an error is
+// returned if any is missing, rather than silently assuming its zero value.
+func parseMessage(obj js.Value, m any) error {
+	if obj.Type() != js.TypeObject {
+		return fmt.Errorf("message is a %s, not an object", obj.Type())
+	}
+	t := reflect.TypeOf(m).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("js")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if obj.Get(tag).IsUndefined() {
+			return fmt.Errorf("missing required field %q", tag)
+		}
+	}
+	return vert.ValueOf(obj).AssignTo(m)
+}
+
 // OnMessage is the callback invoked when a message is received. It determines
 // the type of request received, invokes the appropriate method on the
 // underlying manager instance, and then returns the response to be sent to the
-// client.
+// client. Requests from a client using a different protocol version are
+// rejected with ErrIncompatibleVersion.
 func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Value) js.Value {
+	rsp := s.handle(ctx, headerObj)
+	if rsp.Type() == js.TypeObject {
+		rsp.Set("version", protocolVersion)
+	}
+	return rsp
+}
+
+// handle implements OnMessage, returning the response without its protocol
+// version.
+func (s *Server) handle(ctx jsutil.AsyncContext, headerObj js.Value) js.Value {
 	var header msgHeader
 	if err := vert.ValueOf(headerObj).AssignTo(&header); err != nil {
 		return s.makeErrorResponse(fmt.Errorf("failed to parse message header: %w", err))
 	}
 
+	if header.Type == msgTypeKeysChanged {
+		// Announcements are not requests, and are not answered.
+		return js.Undefined()
+	}
+	if header.Version != protocolVersion {
+		return s.makeErrorResponse(fmt.Errorf("%w: client uses version %d, background worker uses version %d", ErrIncompatibleVersion, header.Version, protocolVersion))
+	}
+
 	jsutil.LogDebug("Server.OnMessage(type = %d)", header.Type)
 	switch header.Type {
 	case msgTypeConfigured:
@@ -180,6 +727,7 @@ func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Val
 			Type: msgTypeConfiguredRsp,
 			Keys: keys,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
 		}
 		return vert.ValueOf(rsp).JSValue()
 	case msgTypeLoaded:
@@ -190,37 +738,164 @@ func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Val
 			Type: msgTypeLoadedRsp,
 			Keys: keys,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSnapshot:
+		jsutil.LogDebug("Server.OnMessage(Snapshot req)")
+		snapshot, err := s.mgr.Snapshot(ctx)
+		jsutil.LogDebug("Server.OnMessage(Snapshot rsp): err=%v", err)
+		rsp := rspSnapshot{
+			Type:     msgTypeSnapshotRsp,
+			Snapshot: snapshot,
+			Err:      makeErrStr(err),
+			Code:     errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeStorageUsage:
+		jsutil.LogDebug("Server.OnMessage(StorageUsage req)")
+		usage, err := s.mgr.StorageUsage(ctx)
+		jsutil.LogDebug("Server.OnMessage(StorageUsage rsp): err=%v", err)
+		rsp := rspStorageUsage{
+			Type:  msgTypeStorageUsageRsp,
+			Usage: usage,
+			Err:   makeErrStr(err),
+			Code:  errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeStatus:
+		jsutil.LogDebug("Server.OnMessage(Status req)")
+		status, err := s.mgr.Status(ctx)
+		jsutil.LogDebug("Server.OnMessage(Status rsp): err=%v", err)
+		rsp := rspStatus{
+			Type:   msgTypeStatusRsp,
+			Status: status,
+			Err:    makeErrStr(err),
+			Code:   errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSelfTest:
+		jsutil.LogDebug("Server.OnMessage(SelfTest req)")
+		err := s.mgr.SelfTest(ctx)
+		jsutil.LogDebug("Server.OnMessage(SelfTest rsp): err=%v", err)
+		rsp := rspSelfTest{
+			Type: msgTypeSelfTestRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRunDiagnostics:
+		jsutil.LogDebug("Server.OnMessage(RunDiagnostics req)")
+		results, err := s.mgr.RunDiagnostics(ctx)
+		jsutil.LogDebug("Server.OnMessage(RunDiagnostics rsp): %d results, err=%v", len(results), err)
+		rsp := rspRunDiagnostics{
+			Type:    msgTypeRunDiagnosticsRsp,
+			Results: results,
+			Err:     makeErrStr(err),
+			Code:    errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeConnected:
+		jsutil.LogDebug("Server.OnMessage(Connected req)")
+		conns, err := s.mgr.Connected(ctx)
+		jsutil.LogDebug("Server.OnMessage(Connected rsp): %d connections, err=%v", len(conns), err)
+		rsp := rspConnected{
+			Type:        msgTypeConnectedRsp,
+			Connections: conns,
+			Err:         makeErrStr(err),
+			Code:        errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeAuditEntries:
+		jsutil.LogDebug("Server.OnMessage(AuditEntries req)")
+		entries, err := s.mgr.AuditEntries(ctx)
+		jsutil.LogDebug("Server.OnMessage(AuditEntries rsp): %d entries, err=%v", len(entries), err)
+		rsp := rspAuditEntries{
+			Type:    msgTypeAuditEntriesRsp,
+			Entries: entries,
+			Err:     makeErrStr(err),
+			Code:    errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeClearAuditLog:
+		jsutil.LogDebug("Server.OnMessage(ClearAuditLog req)")
+		err := s.mgr.ClearAuditLog(ctx)
+		jsutil.LogDebug("Server.OnMessage(ClearAuditLog rsp): err=%v", err)
+		rsp := rspClearAuditLog{
+			Type: msgTypeClearAuditLogRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeLogEntries:
+		jsutil.LogDebug("Server.OnMessage(LogEntries req)")
+		entries, err := s.mgr.LogEntries(ctx)
+		jsutil.LogDebug("Server.OnMessage(LogEntries rsp): %d entries, err=%v", len(entries), err)
+		rsp := rspLogEntries{
+			Type:    msgTypeLogEntriesRsp,
+			Entries: entries,
+			Err:     makeErrStr(err),
+			Code:    errorCode(err),
 		}
 		return vert.ValueOf(rsp).JSValue()
 	case msgTypeAdd:
 		var m msgAdd
-		if err := vert.ValueOf(headerObj).AssignTo(&m); err != nil {
+		if err := parseMessage(headerObj, &m); err != nil {
 			return s.makeErrorResponse(fmt.Errorf("failed to parse Add message: %w", err))
 		}
 		jsutil.LogDebug("Server.OnMessage(Add req): name=%s", m.Name)
-		err := s.mgr.Add(ctx, m.Name, m.PEMPrivateKey)
+		var info *KeyInfo
+		err := s.permitted(ctx, "add key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			info, err = s.mgr.Add(ctx, m.Name, m.PEMPrivateKey)
+		}
 		rsp := rspAdd{
 			Type: msgTypeAddRsp,
+			Info: info,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
 		}
 		jsutil.LogDebug("Server.OnMessage(Add rsp): err=%v", err)
 		return vert.ValueOf(rsp).JSValue()
-	case msgTypeRemove:
-		var m msgRemove
-		if err := vert.ValueOf(headerObj).AssignTo(&m); err != nil {
-			return s.makeErrorResponse(fmt.Errorf("failed to parse Remove message: %w", err))
+	case msgTypeAddLocal:
+		var m msgAddLocal
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse AddLocal message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(AddLocal req): name=%s", m.Name)
+		var info *KeyInfo
+		err := s.permitted(ctx, "add key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			info, err = s.mgr.AddLocal(ctx, m.Name, m.PEMPrivateKey)
+		}
+		rsp := rspAddLocal{
+			Type: msgTypeAddLocalRsp,
+			Info: info,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(AddLocal rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRemove:
+		var m msgRemove
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Remove message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Remove req): id=%s", m.ID)
+		err := s.permitted(ctx, "remove key", func(c *Capabilities) bool { return c.Remove })
+		if err == nil {
+			err = s.mgr.Remove(ctx, ID(m.ID))
 		}
-		jsutil.LogDebug("Server.OnMessage(Remove req): id=%s", m.ID)
-		err := s.mgr.Remove(ctx, ID(m.ID))
 		rsp := rspRemove{
 			Type: msgTypeRemoveRsp,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
 		}
 		jsutil.LogDebug("Server.OnMessage(Remove rsp): err=%v", err)
 		return vert.ValueOf(rsp).JSValue()
 	case msgTypeLoad:
 		var m msgLoad
-		if err := vert.ValueOf(headerObj).AssignTo(&m); err != nil {
+		if err := parseMessage(headerObj, &m); err != nil {
 			return s.makeErrorResponse(fmt.Errorf("failed to parse Load message: %w", err))
 		}
 		jsutil.LogDebug("Server.OnMessage(Load req): id=%s", m.ID)
@@ -228,12 +903,13 @@ func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Val
 		rsp := rspLoad{
 			Type: msgTypeLoadRsp,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
 		}
 		jsutil.LogDebug("Server.OnMessage(Load rsp): err=%v", err)
 		return vert.ValueOf(rsp).JSValue()
 	case msgTypeUnload:
 		var m msgUnload
-		if err := vert.ValueOf(headerObj).AssignTo(&m); err != nil {
+		if err := parseMessage(headerObj, &m); err != nil {
 			return s.makeErrorResponse(fmt.Errorf("failed to parse Unload message: %w", err))
 		}
 		jsutil.LogDebug("Server.OnMessage(Unload req): id=%s", m.ID)
@@ -241,22 +917,424 @@ func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Val
 		rsp := rspUnload{
 			Type: msgTypeUnloadRsp,
 			Err:  makeErrStr(err),
+			Code: errorCode(err),
 		}
 		jsutil.LogDebug("Server.OnMessage(Unload rsp): err=%v", err)
 		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetLocal:
+		var m msgSetLocal
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetLocal message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetLocal req): id=%s, local=%t", m.ID, m.Local)
+		err := s.permitted(ctx, "change key storage location", func(c *Capabilities) bool { return c.SetLocal })
+		if err == nil {
+			err = s.mgr.SetLocal(ctx, ID(m.ID), m.Local)
+		}
+		rsp := rspSetLocal{
+			Type: msgTypeSetLocalRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetLocal rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetAutoLoad:
+		var m msgSetAutoLoad
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetAutoLoad message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetAutoLoad req): id=%s, autoLoad=%t", m.ID, m.AutoLoad)
+		err := s.mgr.SetAutoLoad(ctx, ID(m.ID), m.AutoLoad)
+		rsp := rspSetAutoLoad{
+			Type: msgTypeSetAutoLoadRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetAutoLoad rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeMalformed:
+		jsutil.LogDebug("Server.OnMessage(Malformed req)")
+		keys, err := s.mgr.Malformed(ctx)
+		jsutil.LogDebug("Server.OnMessage(Malformed rsp): %d keys, err=%v", len(keys), err)
+		rsp := rspMalformed{
+			Type: msgTypeMalformedRsp,
+			Keys: keys,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRemoveMalformed:
+		var m msgRemoveMalformed
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse RemoveMalformed message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(RemoveMalformed req): storageKey=%s, local=%t", m.StorageKey, m.Local)
+		err := s.permitted(ctx, "remove key", func(c *Capabilities) bool { return c.Remove })
+		if err == nil {
+			err = s.mgr.RemoveMalformed(ctx, m.StorageKey, m.Local)
+		}
+		rsp := rspRemoveMalformed{
+			Type: msgTypeRemoveMalformedRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(RemoveMalformed rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRepin:
+		var m msgRepin
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Repin message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Repin req): id=%s", m.ID)
+		// Trusting changed key material is equivalent to adding a key.
+		err := s.permitted(ctx, "trust changed key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			err = s.mgr.Repin(ctx, ID(m.ID))
+		}
+		rsp := rspRepin{
+			Type: msgTypeRepinRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(Repin rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeCheckSync:
+		jsutil.LogDebug("Server.OnMessage(CheckSync req)")
+		err := s.mgr.CheckSync(ctx)
+		rsp := rspCheckSync{
+			Type: msgTypeCheckSyncRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(CheckSync rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeKeysEncrypted:
+		jsutil.LogDebug("Server.OnMessage(KeysEncrypted req)")
+		encrypted, err := s.mgr.KeysEncrypted(ctx)
+		rsp := rspKeysEncrypted{
+			Type:      msgTypeKeysEncryptedRsp,
+			Encrypted: encrypted,
+			Err:       makeErrStr(err),
+			Code:      errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(KeysEncrypted rsp): encrypted=%t, err=%v", encrypted, err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetKeysEncrypted:
+		var m msgSetKeysEncrypted
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetKeysEncrypted message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetKeysEncrypted req): encrypted=%t", m.Encrypted)
+		err := s.permitted(ctx, "change key storage", func(c *Capabilities) bool { return c.SetLocal })
+		if err == nil {
+			err = s.mgr.SetKeysEncrypted(ctx, m.Encrypted)
+		}
+		rsp := rspSetKeysEncrypted{
+			Type: msgTypeSetKeysEncryptedRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetKeysEncrypted rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeExport:
+		var m msgExport
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Export message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Export req): includePrivate=%t", m.IncludePrivate)
+		data, err := s.mgr.Export(ctx, m.IncludePrivate, m.Password)
+		rsp := rspExport{
+			Type: msgTypeExportRsp,
+			Data: string(data),
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(Export rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeImport:
+		var m msgImport
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Import message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Import req): onConflict=%s", m.OnConflict)
+		// Importing keys is equivalent to adding them, and replacing
+		// keys also removes them.
+		var result *ImportResult
+		err := s.permitted(ctx, "import keys", func(c *Capabilities) bool {
+			return c.Add && (m.OnConflict != ImportReplace || c.Remove)
+		})
+		if err == nil {
+			var data []byte
+			if data, err = base64.StdEncoding.DecodeString(m.Data); err == nil {
+				result, err = s.mgr.Import(ctx, data, m.OnConflict)
+			}
+		}
+		rsp := rspImport{
+			Type:   msgTypeImportRsp,
+			Result: result,
+			Err:    makeErrStr(err),
+			Code:   errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(Import rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeGenerate:
+		var m msgGenerate
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Generate message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Generate req): name=%s, keyType=%s, bits=%d", m.Name, m.KeyType, m.Bits)
+		var pub string
+		err := s.permitted(ctx, "generate key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			pub, err = s.mgr.Generate(ctx, m.Name, m.KeyType, m.Bits, m.Passphrase)
+		}
+		rsp := rspGenerate{
+			Type:      msgTypeGenerateRsp,
+			PublicKey: pub,
+			Err:       makeErrStr(err),
+			Code:      errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(Generate rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetIdleTimeout:
+		var m msgSetIdleTimeout
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetIdleTimeout message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetIdleTimeout req): id=%s, minutes=%d", m.ID, m.Minutes)
+		err := s.mgr.SetIdleTimeout(ctx, ID(m.ID), m.Minutes)
+		rsp := rspSetIdleTimeout{
+			Type: msgTypeSetIdleTimeoutRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetIdleTimeout rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetConfirm:
+		var m msgSetConfirm
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetConfirm message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetConfirm req): id=%s, confirm=%t", m.ID, m.Confirm)
+		err := s.mgr.SetConfirm(ctx, ID(m.ID), m.Confirm)
+		rsp := rspSetConfirm{
+			Type: msgTypeSetConfirmRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetConfirm rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetKeyring:
+		var m msgSetKeyring
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetKeyring message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetKeyring req): id=%s, keyring=%s", m.ID, m.Keyring)
+		err := s.mgr.SetKeyring(ctx, ID(m.ID), m.Keyring)
+		rsp := rspSetKeyring{
+			Type: msgTypeSetKeyringRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetKeyring rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetPersist:
+		var m msgSetPersist
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetPersist message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetPersist req): id=%s, persist=%t", m.ID, m.Persist)
+		err := s.mgr.SetPersist(ctx, ID(m.ID), m.Persist)
+		rsp := rspSetPersist{
+			Type: msgTypeSetPersistRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetPersist rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetTags:
+		var m msgSetTags
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetTags message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetTags req): id=%s, tags=%v", m.ID, m.Tags)
+		err := s.mgr.SetTags(ctx, ID(m.ID), m.Tags)
+		rsp := rspSetTags{
+			Type: msgTypeSetTagsRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetTags rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeUnloadTagged:
+		var m msgUnloadTagged
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse UnloadTagged message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(UnloadTagged req): tag=%s", m.Tag)
+		ids, err := s.mgr.UnloadTagged(ctx, m.Tag)
+		var unloaded []string
+		for _, id := range ids {
+			unloaded = append(unloaded, string(id))
+		}
+		rsp := rspUnloadTagged{
+			Type:     msgTypeUnloadTaggedRsp,
+			Unloaded: unloaded,
+			Err:      makeErrStr(err),
+			Code:     errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(UnloadTagged rsp): unloaded=%v, err=%v", unloaded, err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetHosts:
+		var m msgSetHosts
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetHosts message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetHosts req): id=%s, hosts=%v", m.ID, m.Hosts)
+		err := s.mgr.SetHosts(ctx, ID(m.ID), m.Hosts)
+		rsp := rspSetHosts{
+			Type: msgTypeSetHostsRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetHosts rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeAdoptLoaded:
+		var m msgAdoptLoaded
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse AdoptLoaded message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(AdoptLoaded req): name=%s", m.Name)
+		err := s.permitted(ctx, "add key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			err = s.mgr.AdoptLoaded(ctx, m.Blob, m.Name)
+		}
+		rsp := rspAdoptLoaded{
+			Type: msgTypeAdoptLoadedRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(AdoptLoaded rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeUndoRemove:
+		var m msgUndoRemove
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse UndoRemove message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(UndoRemove req): id=%s", m.ID)
+		err := s.permitted(ctx, "add key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			err = s.mgr.UndoRemove(ctx, ID(m.ID))
+		}
+		rsp := rspUndoRemove{
+			Type: msgTypeUndoRemoveRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(UndoRemove rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeUndoUnload:
+		var m msgUndoUnload
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse UndoUnload message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(UndoUnload req): id=%s", m.ID)
+		err := s.mgr.UndoUnload(ctx, ID(m.ID))
+		rsp := rspUndoUnload{
+			Type: msgTypeUndoUnloadRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(UndoUnload rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetNotify:
+		var m msgSetNotify
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetNotify message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetNotify req): id=%s, notify=%t", m.ID, m.Notify)
+		err := s.mgr.SetNotify(ctx, ID(m.ID), m.Notify)
+		rsp := rspSetNotify{
+			Type: msgTypeSetNotifyRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetNotify rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeUpdate:
+		var m msgUpdate
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse Update message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(Update req): id=%s", m.ID)
+		// Replacing key material is equivalent to adding a key.
+		err := s.permitted(ctx, "replace key", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			err = s.mgr.Update(ctx, ID(m.ID), m.PEMPrivateKey)
+		}
+		rsp := rspUpdate{
+			Type: msgTypeUpdateRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(Update rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetCertificate:
+		var m msgSetCertificate
+		if err := parseMessage(headerObj, &m); err != nil {
+			return s.makeErrorResponse(fmt.Errorf("failed to parse SetCertificate message: %w", err))
+		}
+		jsutil.LogDebug("Server.OnMessage(SetCertificate req): id=%s", m.ID)
+		// Replacing a certificate is equivalent to adding a key.
+		err := s.permitted(ctx, "replace certificate", func(c *Capabilities) bool { return c.Add })
+		if err == nil {
+			err = s.mgr.SetCertificate(ctx, ID(m.ID), m.Certificate)
+		}
+		rsp := rspSetCertificate{
+			Type: msgTypeSetCertificateRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		jsutil.LogDebug("Server.OnMessage(SetCertificate rsp): err=%v", err)
+		return vert.ValueOf(rsp).JSValue()
 	default:
 		return s.makeErrorResponse(fmt.Errorf("received invalid message type: %d", header.Type))
 	}
 }
 
+// versionedSender includes the protocol version in each request sent using
+// msg, and rejects responses from a Server using a different version.
+type versionedSender struct {
+	msg message.Sender
+}
+
+// Send implements message.Sender.Send.
+func (v *versionedSender) Send(ctx jsutil.AsyncContext, msg js.Value) (js.Value, error) {
+	msg.Set("version", protocolVersion)
+	rsp, err := v.msg.Send(ctx, msg)
+	if err != nil || rsp.Type() != js.TypeObject {
+		return rsp, err
+	}
+	var header msgHeader
+	if err := vert.ValueOf(rsp).AssignTo(&header); err != nil {
+		return js.Undefined(), fmt.Errorf("failed to parse response header: %w", err)
+	}
+	if header.Version != protocolVersion {
+		return js.Undefined(), fmt.Errorf("%w: background worker uses version %d, client uses version %d", ErrIncompatibleVersion, header.Version, protocolVersion)
+	}
+	return rsp, nil
+}
+
 // client implements the Manager interface and forwards calls to a Server.
 type client struct {
 	msg message.Sender
 }
 
 // NewClient returns a Manager implementation that forwards calls to a Server.
+// Calls fail with ErrIncompatibleVersion if the Server uses a different
+// protocol version.
 func NewClient(msg message.Sender) Manager {
-	return &client{msg: msg}
+	return &client{msg: &versionedSender{msg: msg}}
 }
 
 // Configured implements Manager.Configured.
@@ -273,7 +1351,7 @@ func (c *client) Configured(ctx jsutil.AsyncContext) ([]*ConfiguredKey, error) {
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	return rsp.Keys, makeErr(rsp.Err)
+	return rsp.Keys, makeErr(rsp.Err, rsp.Code)
 }
 
 // Loaded implements Manager.Loaded.
@@ -290,11 +1368,164 @@ func (c *client) Loaded(ctx jsutil.AsyncContext) ([]*LoadedKey, error) {
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	return rsp.Keys, makeErr(rsp.Err)
+	return rsp.Keys, makeErr(rsp.Err, rsp.Code)
+}
+
+// Snapshot implements Manager.Snapshot.
+func (c *client) Snapshot(ctx jsutil.AsyncContext) (*Snapshot, error) {
+	var msg msgSnapshot
+	msg.Type = msgTypeSnapshot
+	jsutil.LogDebug("Client.Snapshot(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Snapshot(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSnapshot
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Snapshot, makeErr(rsp.Err, rsp.Code)
+}
+
+// StorageUsage implements Manager.StorageUsage.
+func (c *client) StorageUsage(ctx jsutil.AsyncContext) (*StorageUsage, error) {
+	var msg msgStorageUsage
+	msg.Type = msgTypeStorageUsage
+	jsutil.LogDebug("Client.StorageUsage(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.StorageUsage(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspStorageUsage
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Usage, makeErr(rsp.Err, rsp.Code)
+}
+
+// Status implements Manager.Status.
+func (c *client) Status(ctx jsutil.AsyncContext) (*Status, error) {
+	var msg msgStatus
+	msg.Type = msgTypeStatus
+	jsutil.LogDebug("Client.Status(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Status(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspStatus
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Status, makeErr(rsp.Err, rsp.Code)
+}
+
+// SelfTest implements Manager.SelfTest.
+func (c *client) SelfTest(ctx jsutil.AsyncContext) error {
+	var msg msgSelfTest
+	msg.Type = msgTypeSelfTest
+	jsutil.LogDebug("Client.SelfTest(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SelfTest(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSelfTest
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// RunDiagnostics implements Manager.RunDiagnostics.
+func (c *client) RunDiagnostics(ctx jsutil.AsyncContext) ([]*DiagnosticResult, error) {
+	var msg msgRunDiagnostics
+	msg.Type = msgTypeRunDiagnostics
+	jsutil.LogDebug("Client.RunDiagnostics(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.RunDiagnostics(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspRunDiagnostics
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Results, makeErr(rsp.Err, rsp.Code)
+}
+
+// Connected implements Manager.Connected.
+func (c *client) Connected(ctx jsutil.AsyncContext) ([]*Connection, error) {
+	var msg msgConnected
+	msg.Type = msgTypeConnected
+	jsutil.LogDebug("Client.Connected(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Connected(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspConnected
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Connections, makeErr(rsp.Err, rsp.Code)
+}
+
+// AuditEntries implements Manager.AuditEntries.
+func (c *client) AuditEntries(ctx jsutil.AsyncContext) ([]*AuditEntry, error) {
+	var msg msgAuditEntries
+	msg.Type = msgTypeAuditEntries
+	jsutil.LogDebug("Client.AuditEntries(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.AuditEntries(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspAuditEntries
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Entries, makeErr(rsp.Err, rsp.Code)
+}
+
+// LogEntries implements Manager.LogEntries.
+func (c *client) LogEntries(ctx jsutil.AsyncContext) ([]*LogEntry, error) {
+	var msg msgLogEntries
+	msg.Type = msgTypeLogEntries
+	jsutil.LogDebug("Client.LogEntries(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.LogEntries(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspLogEntries
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Entries, makeErr(rsp.Err, rsp.Code)
+}
+
+// ClearAuditLog implements Manager.ClearAuditLog.
+func (c *client) ClearAuditLog(ctx jsutil.AsyncContext) error {
+	var msg msgClearAuditLog
+	msg.Type = msgTypeClearAuditLog
+	jsutil.LogDebug("Client.ClearAuditLog(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.ClearAuditLog(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspClearAuditLog
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
 }
 
 // Add implements Manager.Add.
-func (c *client) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) error {
+func (c *client) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
 	var msg msgAdd
 	msg.Type = msgTypeAdd
 	msg.Name = name
@@ -303,13 +1534,32 @@ func (c *client) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string)
 	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
 	jsutil.LogDebug("Client.Add(rsp)")
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 	var rsp rspAdd
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Info, makeErr(rsp.Err, rsp.Code)
+}
+
+// AddLocal implements Manager.AddLocal.
+func (c *client) AddLocal(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	var msg msgAddLocal
+	msg.Type = msgTypeAddLocal
+	msg.Name = name
+	msg.PEMPrivateKey = pemPrivateKey
+	jsutil.LogDebug("Client.AddLocal(req): name=%s", msg.Name)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.AddLocal(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
-	return makeErr(rsp.Err)
+	var rsp rspAddLocal
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Info, makeErr(rsp.Err, rsp.Code)
 }
 
 // Remove implements Manager.Remove.
@@ -327,7 +1577,7 @@ func (c *client) Remove(ctx jsutil.AsyncContext, id ID) error {
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
-	return makeErr(rsp.Err)
+	return makeErr(rsp.Err, rsp.Code)
 }
 
 // Load implements Manager.Load.
@@ -346,7 +1596,7 @@ func (c *client) Load(ctx jsutil.AsyncContext, id ID, passphrase string) error {
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
-	return makeErr(rsp.Err)
+	return makeErr(rsp.Err, rsp.Code)
 }
 
 // Unload implements Manager.Unload.
@@ -364,5 +1614,459 @@ func (c *client) Unload(ctx jsutil.AsyncContext, id ID) error {
 	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
-	return makeErr(rsp.Err)
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetLocal implements Manager.SetLocal.
+func (c *client) SetLocal(ctx jsutil.AsyncContext, id ID, local bool) error {
+	var msg msgSetLocal
+	msg.Type = msgTypeSetLocal
+	msg.ID = string(id)
+	msg.Local = local
+	jsutil.LogDebug("Client.SetLocal(req): id=%s, local=%t", msg.ID, msg.Local)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetLocal(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetLocal
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetAutoLoad implements Manager.SetAutoLoad.
+func (c *client) SetAutoLoad(ctx jsutil.AsyncContext, id ID, autoLoad bool) error {
+	var msg msgSetAutoLoad
+	msg.Type = msgTypeSetAutoLoad
+	msg.ID = string(id)
+	msg.AutoLoad = autoLoad
+	jsutil.LogDebug("Client.SetAutoLoad(req): id=%s, autoLoad=%t", msg.ID, msg.AutoLoad)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetAutoLoad(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetAutoLoad
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// Malformed implements Manager.Malformed.
+func (c *client) Malformed(ctx jsutil.AsyncContext) ([]*MalformedKey, error) {
+	var msg msgMalformed
+	msg.Type = msgTypeMalformed
+	jsutil.LogDebug("Client.Malformed(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Malformed(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspMalformed
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Keys, makeErr(rsp.Err, rsp.Code)
+}
+
+// RemoveMalformed implements Manager.RemoveMalformed.
+func (c *client) RemoveMalformed(ctx jsutil.AsyncContext, storageKey string, local bool) error {
+	var msg msgRemoveMalformed
+	msg.Type = msgTypeRemoveMalformed
+	msg.StorageKey = storageKey
+	msg.Local = local
+	jsutil.LogDebug("Client.RemoveMalformed(req): storageKey=%s, local=%t", msg.StorageKey, msg.Local)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.RemoveMalformed(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspRemoveMalformed
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// Repin implements Manager.Repin.
+func (c *client) Repin(ctx jsutil.AsyncContext, id ID) error {
+	var msg msgRepin
+	msg.Type = msgTypeRepin
+	msg.ID = string(id)
+	jsutil.LogDebug("Client.Repin(req): id=%s", msg.ID)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Repin(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspRepin
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// CheckSync implements Manager.CheckSync.
+func (c *client) CheckSync(ctx jsutil.AsyncContext) error {
+	var msg msgCheckSync
+	msg.Type = msgTypeCheckSync
+	jsutil.LogDebug("Client.CheckSync(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.CheckSync(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspCheckSync
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// KeysEncrypted implements Manager.KeysEncrypted.
+func (c *client) KeysEncrypted(ctx jsutil.AsyncContext) (bool, error) {
+	var msg msgKeysEncrypted
+	msg.Type = msgTypeKeysEncrypted
+	jsutil.LogDebug("Client.KeysEncrypted(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.KeysEncrypted(rsp)")
+	if err != nil {
+		return false, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspKeysEncrypted
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Encrypted, makeErr(rsp.Err, rsp.Code)
+}
+
+// SetKeysEncrypted implements Manager.SetKeysEncrypted.
+func (c *client) SetKeysEncrypted(ctx jsutil.AsyncContext, encrypted bool) error {
+	var msg msgSetKeysEncrypted
+	msg.Type = msgTypeSetKeysEncrypted
+	msg.Encrypted = encrypted
+	jsutil.LogDebug("Client.SetKeysEncrypted(req): encrypted=%t", msg.Encrypted)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetKeysEncrypted(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetKeysEncrypted
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// Export implements Manager.Export.
+func (c *client) Export(ctx jsutil.AsyncContext, includePrivate bool, password string) ([]byte, error) {
+	var msg msgExport
+	msg.Type = msgTypeExport
+	msg.IncludePrivate = includePrivate
+	msg.Password = password
+	jsutil.LogDebug("Client.Export(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Export(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspExport
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := makeErr(rsp.Err, rsp.Code); err != nil {
+		return nil, err
+	}
+	return []byte(rsp.Data), nil
+}
+
+// Import implements Manager.Import.
+func (c *client) Import(ctx jsutil.AsyncContext, data []byte, onConflict string) (*ImportResult, error) {
+	var msg msgImport
+	msg.Type = msgTypeImport
+	msg.Data = base64.StdEncoding.EncodeToString(data)
+	msg.OnConflict = onConflict
+	jsutil.LogDebug("Client.Import(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Import(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspImport
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.Result, makeErr(rsp.Err, rsp.Code)
+}
+
+// Generate implements Manager.Generate.
+func (c *client) Generate(ctx jsutil.AsyncContext, name, keyType string, bits int, passphrase string) (string, error) {
+	var msg msgGenerate
+	msg.Type = msgTypeGenerate
+	msg.Name = name
+	msg.KeyType = keyType
+	msg.Bits = bits
+	msg.Passphrase = passphrase
+	jsutil.LogDebug("Client.Generate(req)")
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Generate(rsp)")
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspGenerate
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return rsp.PublicKey, makeErr(rsp.Err, rsp.Code)
+}
+
+// SetIdleTimeout implements Manager.SetIdleTimeout.
+func (c *client) SetIdleTimeout(ctx jsutil.AsyncContext, id ID, minutes int) error {
+	var msg msgSetIdleTimeout
+	msg.Type = msgTypeSetIdleTimeout
+	msg.ID = string(id)
+	msg.Minutes = minutes
+	jsutil.LogDebug("Client.SetIdleTimeout(req): id=%s, minutes=%d", msg.ID, msg.Minutes)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetIdleTimeout(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetIdleTimeout
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetConfirm implements Manager.SetConfirm.
+func (c *client) SetConfirm(ctx jsutil.AsyncContext, id ID, confirm bool) error {
+	var msg msgSetConfirm
+	msg.Type = msgTypeSetConfirm
+	msg.ID = string(id)
+	msg.Confirm = confirm
+	jsutil.LogDebug("Client.SetConfirm(req): id=%s, confirm=%t", msg.ID, msg.Confirm)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetConfirm(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetConfirm
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetKeyring implements Manager.SetKeyring.
+func (c *client) SetKeyring(ctx jsutil.AsyncContext, id ID, keyring string) error {
+	var msg msgSetKeyring
+	msg.Type = msgTypeSetKeyring
+	msg.ID = string(id)
+	msg.Keyring = keyring
+	jsutil.LogDebug("Client.SetKeyring(req): id=%s, keyring=%s", msg.ID, msg.Keyring)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetKeyring(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetKeyring
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetPersist implements Manager.SetPersist.
+func (c *client) SetPersist(ctx jsutil.AsyncContext, id ID, persist bool) error {
+	var msg msgSetPersist
+	msg.Type = msgTypeSetPersist
+	msg.ID = string(id)
+	msg.Persist = persist
+	jsutil.LogDebug("Client.SetPersist(req): id=%s, persist=%t", msg.ID, msg.Persist)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetPersist(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetPersist
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetTags implements Manager.SetTags.
+func (c *client) SetTags(ctx jsutil.AsyncContext, id ID, tags []string) error {
+	var msg msgSetTags
+	msg.Type = msgTypeSetTags
+	msg.ID = string(id)
+	msg.Tags = tags
+	jsutil.LogDebug("Client.SetTags(req): id=%s, tags=%v", msg.ID, msg.Tags)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetTags(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetTags
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// UnloadTagged implements Manager.UnloadTagged.
+func (c *client) UnloadTagged(ctx jsutil.AsyncContext, tag string) ([]ID, error) {
+	var msg msgUnloadTagged
+	msg.Type = msgTypeUnloadTagged
+	msg.Tag = tag
+	jsutil.LogDebug("Client.UnloadTagged(req): tag=%s", msg.Tag)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.UnloadTagged(rsp)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspUnloadTagged
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	var unloaded []ID
+	for _, id := range rsp.Unloaded {
+		unloaded = append(unloaded, ID(id))
+	}
+	return unloaded, makeErr(rsp.Err, rsp.Code)
+}
+
+// SetHosts implements Manager.SetHosts.
+func (c *client) SetHosts(ctx jsutil.AsyncContext, id ID, hosts []string) error {
+	var msg msgSetHosts
+	msg.Type = msgTypeSetHosts
+	msg.ID = string(id)
+	msg.Hosts = hosts
+	jsutil.LogDebug("Client.SetHosts(req): id=%s, hosts=%v", msg.ID, msg.Hosts)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetHosts(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetHosts
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// AdoptLoaded implements Manager.AdoptLoaded.
+func (c *client) AdoptLoaded(ctx jsutil.AsyncContext, blob, name string) error {
+	var msg msgAdoptLoaded
+	msg.Type = msgTypeAdoptLoaded
+	msg.Blob = blob
+	msg.Name = name
+	jsutil.LogDebug("Client.AdoptLoaded(req): name=%s", msg.Name)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.AdoptLoaded(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspAdoptLoaded
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// UndoRemove implements Manager.UndoRemove.
+func (c *client) UndoRemove(ctx jsutil.AsyncContext, id ID) error {
+	var msg msgUndoRemove
+	msg.Type = msgTypeUndoRemove
+	msg.ID = string(id)
+	jsutil.LogDebug("Client.UndoRemove(req): id=%s", msg.ID)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.UndoRemove(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspUndoRemove
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// UndoUnload implements Manager.UndoUnload.
+func (c *client) UndoUnload(ctx jsutil.AsyncContext, id ID) error {
+	var msg msgUndoUnload
+	msg.Type = msgTypeUndoUnload
+	msg.ID = string(id)
+	jsutil.LogDebug("Client.UndoUnload(req): id=%s", msg.ID)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.UndoUnload(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspUndoUnload
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetNotify implements Manager.SetNotify.
+func (c *client) SetNotify(ctx jsutil.AsyncContext, id ID, notify bool) error {
+	var msg msgSetNotify
+	msg.Type = msgTypeSetNotify
+	msg.ID = string(id)
+	msg.Notify = notify
+	jsutil.LogDebug("Client.SetNotify(req): id=%s, notify=%t", msg.ID, msg.Notify)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetNotify(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetNotify
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// Update implements Manager.Update.
+func (c *client) Update(ctx jsutil.AsyncContext, id ID, pemPrivateKey string) error {
+	var msg msgUpdate
+	msg.Type = msgTypeUpdate
+	msg.ID = string(id)
+	msg.PEMPrivateKey = pemPrivateKey
+	jsutil.LogDebug("Client.Update(req): id=%s", msg.ID)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.Update(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspUpdate
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// SetCertificate implements Manager.SetCertificate.
+func (c *client) SetCertificate(ctx jsutil.AsyncContext, id ID, certificate string) error {
+	var msg msgSetCertificate
+	msg.Type = msgTypeSetCertificate
+	msg.ID = string(id)
+	msg.Certificate = certificate
+	jsutil.LogDebug("Client.SetCertificate(req): id=%s", msg.ID)
+	rspObj, err := c.msg.Send(ctx, vert.ValueOf(msg).JSValue())
+	jsutil.LogDebug("Client.SetCertificate(rsp)")
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var rsp rspSetCertificate
+	if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return makeErr(rsp.Err, rsp.Code)
 }
@@ -15,13 +15,20 @@
 package keys
 
 import (
+	"bytes"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
+	"syscall/js"
 	"testing"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
 	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
 	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
 	"github.com/google/go-cmp/cmp"
+	"github.com/norunners/vert"
 )
 
 type dummyManager struct {
@@ -29,9 +36,44 @@ type dummyManager struct {
 	Name           string
 	PEMPrivateKey  string
 	Passphrase     string
+	Local          bool
+	AutoLoad       bool
+	StorageKey     string
 	ConfiguredKeys []*ConfiguredKey
 	LoadedKeys     []*LoadedKey
+	Connections    []*Connection
+	Audit          []*AuditEntry
+	AuditCleared   bool
+	Logs           []*LogEntry
+	MalformedKeys  []*MalformedKey
 	Key            *LoadedKey
+	Exported       []byte
+	IncludePrivate bool
+	Password       string
+	Imported       []byte
+	OnConflict     string
+	ImportResult   *ImportResult
+	KeyInfo        *KeyInfo
+	KeyType        string
+	Bits           int
+	PublicKey      string
+	IdleTimeout    int
+	Confirm        bool
+	Notify         bool
+	Keyring        string
+	Persist        bool
+	Blob           string
+	Encrypted      bool
+	Certificate    string
+	Usage          *StorageUsage
+	Health         *Status
+	SelfTested     bool
+	Diagnostics    []*DiagnosticResult
+	Undone         string
+	Tags           []string
+	Tag            string
+	Unloaded       []ID
+	Hosts          []string
 	Err            error
 }
 
@@ -39,10 +81,17 @@ func (m *dummyManager) Configured(_ jsutil.AsyncContext) ([]*ConfiguredKey, erro
 	return m.ConfiguredKeys, m.Err
 }
 
-func (m *dummyManager) Add(_ jsutil.AsyncContext, name string, pemPrivateKey string) error {
+func (m *dummyManager) Add(_ jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
 	m.Name = name
 	m.PEMPrivateKey = pemPrivateKey
-	return m.Err
+	return m.KeyInfo, m.Err
+}
+
+func (m *dummyManager) AddLocal(_ jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	m.Name = name
+	m.PEMPrivateKey = pemPrivateKey
+	m.Local = true
+	return m.KeyInfo, m.Err
 }
 
 func (m *dummyManager) Remove(_ jsutil.AsyncContext, id ID) error {
@@ -54,6 +103,44 @@ func (m *dummyManager) Loaded(_ jsutil.AsyncContext) ([]*LoadedKey, error) {
 	return m.LoadedKeys, m.Err
 }
 
+func (m *dummyManager) Snapshot(_ jsutil.AsyncContext) (*Snapshot, error) {
+	return &Snapshot{Configured: m.ConfiguredKeys, Loaded: m.LoadedKeys}, m.Err
+}
+
+func (m *dummyManager) StorageUsage(_ jsutil.AsyncContext) (*StorageUsage, error) {
+	return m.Usage, m.Err
+}
+
+func (m *dummyManager) Status(_ jsutil.AsyncContext) (*Status, error) {
+	return m.Health, m.Err
+}
+
+func (m *dummyManager) SelfTest(_ jsutil.AsyncContext) error {
+	m.SelfTested = true
+	return m.Err
+}
+
+func (m *dummyManager) RunDiagnostics(_ jsutil.AsyncContext) ([]*DiagnosticResult, error) {
+	return m.Diagnostics, m.Err
+}
+
+func (m *dummyManager) Connected(_ jsutil.AsyncContext) ([]*Connection, error) {
+	return m.Connections, m.Err
+}
+
+func (m *dummyManager) AuditEntries(_ jsutil.AsyncContext) ([]*AuditEntry, error) {
+	return m.Audit, m.Err
+}
+
+func (m *dummyManager) ClearAuditLog(_ jsutil.AsyncContext) error {
+	m.AuditCleared = true
+	return m.Err
+}
+
+func (m *dummyManager) LogEntries(_ jsutil.AsyncContext) ([]*LogEntry, error) {
+	return m.Logs, m.Err
+}
+
 func (m *dummyManager) Load(_ jsutil.AsyncContext, id ID, passphrase string) error {
 	m.ID = id
 	m.Passphrase = passphrase
@@ -65,6 +152,143 @@ func (m *dummyManager) Unload(_ jsutil.AsyncContext, id ID) error {
 	return m.Err
 }
 
+func (m *dummyManager) UndoRemove(_ jsutil.AsyncContext, id ID) error {
+	m.ID = id
+	m.Undone = "remove"
+	return m.Err
+}
+
+func (m *dummyManager) UndoUnload(_ jsutil.AsyncContext, id ID) error {
+	m.ID = id
+	m.Undone = "unload"
+	return m.Err
+}
+
+func (m *dummyManager) SetTags(_ jsutil.AsyncContext, id ID, tags []string) error {
+	m.ID = id
+	m.Tags = tags
+	return m.Err
+}
+
+func (m *dummyManager) UnloadTagged(_ jsutil.AsyncContext, tag string) ([]ID, error) {
+	m.Tag = tag
+	return m.Unloaded, m.Err
+}
+
+func (m *dummyManager) SetHosts(_ jsutil.AsyncContext, id ID, hosts []string) error {
+	m.ID = id
+	m.Hosts = hosts
+	return m.Err
+}
+
+func (m *dummyManager) SetLocal(_ jsutil.AsyncContext, id ID, local bool) error {
+	m.ID = id
+	m.Local = local
+	return m.Err
+}
+
+func (m *dummyManager) SetAutoLoad(_ jsutil.AsyncContext, id ID, autoLoad bool) error {
+	m.ID = id
+	m.AutoLoad = autoLoad
+	return m.Err
+}
+
+func (m *dummyManager) Malformed(_ jsutil.AsyncContext) ([]*MalformedKey, error) {
+	return m.MalformedKeys, m.Err
+}
+
+func (m *dummyManager) RemoveMalformed(_ jsutil.AsyncContext, storageKey string, local bool) error {
+	m.StorageKey = storageKey
+	m.Local = local
+	return m.Err
+}
+
+func (m *dummyManager) Repin(_ jsutil.AsyncContext, id ID) error {
+	m.ID = id
+	return m.Err
+}
+
+func (m *dummyManager) KeysEncrypted(_ jsutil.AsyncContext) (bool, error) {
+	return m.Encrypted, m.Err
+}
+
+func (m *dummyManager) SetKeysEncrypted(_ jsutil.AsyncContext, encrypted bool) error {
+	m.Encrypted = encrypted
+	return m.Err
+}
+
+func (m *dummyManager) CheckSync(_ jsutil.AsyncContext) error {
+	return m.Err
+}
+
+func (m *dummyManager) Import(_ jsutil.AsyncContext, data []byte, onConflict string) (*ImportResult, error) {
+	m.Imported = data
+	m.OnConflict = onConflict
+	return m.ImportResult, m.Err
+}
+
+func (m *dummyManager) SetIdleTimeout(_ jsutil.AsyncContext, id ID, minutes int) error {
+	m.ID = id
+	m.IdleTimeout = minutes
+	return m.Err
+}
+
+func (m *dummyManager) SetConfirm(_ jsutil.AsyncContext, id ID, confirm bool) error {
+	m.ID = id
+	m.Confirm = confirm
+	return m.Err
+}
+
+func (m *dummyManager) SetKeyring(_ jsutil.AsyncContext, id ID, keyring string) error {
+	m.ID = id
+	m.Keyring = keyring
+	return m.Err
+}
+
+func (m *dummyManager) SetPersist(_ jsutil.AsyncContext, id ID, persist bool) error {
+	m.ID = id
+	m.Persist = persist
+	return m.Err
+}
+
+func (m *dummyManager) AdoptLoaded(_ jsutil.AsyncContext, blob, name string) error {
+	m.Blob = blob
+	m.Name = name
+	return m.Err
+}
+
+func (m *dummyManager) SetNotify(_ jsutil.AsyncContext, id ID, notify bool) error {
+	m.ID = id
+	m.Notify = notify
+	return m.Err
+}
+
+func (m *dummyManager) Update(_ jsutil.AsyncContext, id ID, pemPrivateKey string) error {
+	m.ID = id
+	m.PEMPrivateKey = pemPrivateKey
+	return m.Err
+}
+
+func (m *dummyManager) SetCertificate(_ jsutil.AsyncContext, id ID, certificate string) error {
+	m.ID = id
+	m.Certificate = certificate
+	return m.Err
+}
+
+func (m *dummyManager) Generate(_ jsutil.AsyncContext, name, keyType string, bits int, passphrase string) (string, error) {
+	m.Name = name
+	m.KeyType = keyType
+	m.Bits = bits
+	m.Passphrase = passphrase
+	return m.PublicKey, m.Err
+}
+
+func (m *dummyManager) Export(_ jsutil.AsyncContext, includePrivate bool, password string) ([]byte, error) {
+	m.IncludePrivate = includePrivate
+	m.Password = password
+	return m.Exported, m.Err
+}
+
 func TestClientServerConfigured(t *testing.T) {
 	t.Parallel()
 
@@ -72,7 +296,7 @@ func TestClientServerConfigured(t *testing.T) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
 		k0 := &ConfiguredKey{}
@@ -81,6 +305,14 @@ func TestClientServerConfigured(t *testing.T) {
 		k1 := &ConfiguredKey{}
 		k1.ID = "id-1"
 		k1.Name = "key-1"
+		k1.Certificate = &CertificateInfo{
+			Type:          "user",
+			KeyID:         "cert-id",
+			Principals:    []string{"alice", "bob"},
+			ValidAfter:    1577836800,
+			ValidBefore:   4102358400,
+			CAFingerprint: "SHA256:fingerprint",
+		}
 
 		wantConfiguredKeys := []*ConfiguredKey{k0, k1}
 		wantErr := errors.New("failed")
@@ -107,7 +339,7 @@ func TestClientServerAdd(t *testing.T) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
 		wantName := "some-name"
@@ -116,7 +348,7 @@ func TestClientServerAdd(t *testing.T) {
 
 		mgr.Err = wantErr
 
-		err := cli.Add(ctx, wantName, wantPrivateKey)
+		_, err := cli.Add(ctx, wantName, wantPrivateKey)
 		if diff := cmp.Diff(mgr.Name, wantName); diff != "" {
 			t.Errorf("incorrect name; -got +want: %s", diff)
 		}
@@ -131,6 +363,71 @@ func TestClientServerAdd(t *testing.T) {
 	})
 }
 
+func TestClientServerAddInfo(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantInfo := &KeyInfo{Type: "ssh-ed25519", Bits: 256, Encrypted: true}
+		mgr.KeyInfo = wantInfo
+
+		info, err := cli.Add(ctx, "some-name", "private-key")
+		if err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		if diff := cmp.Diff(info, wantInfo); diff != "" {
+			t.Errorf("incorrect key info; -got +want: %s", diff)
+		}
+
+		info, err = cli.AddLocal(ctx, "some-name", "private-key")
+		if err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		if diff := cmp.Diff(info, wantInfo); diff != "" {
+			t.Errorf("incorrect key info for local key; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerAddLocal(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantName := "some-name"
+		wantPrivateKey := "private-key"
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		_, err := cli.AddLocal(ctx, wantName, wantPrivateKey)
+		if diff := cmp.Diff(mgr.Name, wantName); diff != "" {
+			t.Errorf("incorrect name; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.PEMPrivateKey, wantPrivateKey); diff != "" {
+			t.Errorf("incorrect private key; -got +want: %s", diff)
+		}
+		if !mgr.Local {
+			t.Errorf("incorrect local: got false, want true")
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
 func TestClientServerRemove(t *testing.T) {
 	t.Parallel()
 
@@ -138,7 +435,7 @@ func TestClientServerRemove(t *testing.T) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
 		wantID := ID("id-0")
@@ -165,7 +462,7 @@ func TestClientServerLoaded(t *testing.T) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
 		k0 := &LoadedKey{}
@@ -195,28 +492,73 @@ func TestClientServerLoaded(t *testing.T) {
 	})
 }
 
-func TestClientServerLoad(t *testing.T) {
+func TestClientServerSnapshot(t *testing.T) {
 	t.Parallel()
 
 	jut.DoSync(func(ctx jsutil.AsyncContext) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
-		wantID := ID("id-0")
-		wantPassphrase := "secret"
+		c0 := &ConfiguredKey{}
+		c0.ID = "id-0"
+		c0.Name = "key-0"
+		c0.Encrypted = true
+		l0 := &LoadedKey{}
+		l0.Type = "type-0"
+		l0.SetBlob([]byte("blob-0"))
+		l0.Comment = "comment-0"
+
+		wantSnapshot := &Snapshot{
+			Configured: []*ConfiguredKey{c0},
+			Loaded:     []*LoadedKey{l0},
+		}
 		wantErr := errors.New("failed")
 
+		mgr.ConfiguredKeys = wantSnapshot.Configured
+		mgr.LoadedKeys = wantSnapshot.Loaded
 		mgr.Err = wantErr
 
-		err := cli.Load(ctx, wantID, wantPassphrase)
-		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
-			t.Errorf("incorrect ID; -got +want: %s", diff)
+		snapshot, err := cli.Snapshot(ctx)
+		if diff := cmp.Diff(snapshot, wantSnapshot, loadedKeyCmp); diff != "" {
+			t.Errorf("incorrect snapshot; -got +want: %s", diff)
 		}
-		if diff := cmp.Diff(mgr.Passphrase, wantPassphrase); diff != "" {
-			t.Errorf("incorrect passphrase; -got +want: %s", diff)
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerStorageUsage(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantUsage := &StorageUsage{
+			Synced: &AreaUsage{BytesInUse: 1000, Quota: 102400},
+			Local:  &AreaUsage{BytesInUse: 500},
+			Keys: []*KeyUsage{
+				{ID: "id-0", Bytes: 900},
+				{ID: "id-1", Local: true, Bytes: 400},
+			},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.Usage = wantUsage
+		mgr.Err = wantErr
+
+		usage, err := cli.StorageUsage(ctx)
+		if diff := cmp.Diff(usage, wantUsage); diff != "" {
+			t.Errorf("incorrect storage usage; -got +want: %s", diff)
 		}
 		// Compare by error string; cmp.EquateErrors doesn't work since type
 		// information is lost on conversion to/from JSON in message hub.
@@ -226,24 +568,90 @@ func TestClientServerLoad(t *testing.T) {
 	})
 }
 
-func TestClientServerUnload(t *testing.T) {
+func TestClientServerStatus(t *testing.T) {
 	t.Parallel()
 
 	jut.DoSync(func(ctx jsutil.AsyncContext) {
 		hub := mfakes.NewHub()
 		mgr := &dummyManager{}
 		cli := NewClient(hub)
-		srv := NewServer(mgr)
+		srv := NewServer(mgr, nil)
 		hub.AddReceiver(srv)
 
-		wantID := ID("some-id")
+		wantStatus := &Status{
+			Uptime:      3600,
+			Connections: 2,
+			LoadedKeys:  1,
+			LastError:   "something failed",
+			Storage: []*StorageStatus{
+				{Area: "sync", Err: "sync unavailable"},
+				{Area: "local"},
+			},
+		}
 		wantErr := errors.New("failed")
 
+		mgr.Health = wantStatus
 		mgr.Err = wantErr
 
-		err := cli.Unload(ctx, wantID)
-		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
-			t.Errorf("incorrect key; -got +want: %s", diff)
+		status, err := cli.Status(ctx)
+		if diff := cmp.Diff(status, wantStatus); diff != "" {
+			t.Errorf("incorrect status; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSelfTest(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantErr := errors.New("failed")
+		mgr.Err = wantErr
+
+		err := cli.SelfTest(ctx)
+		if !mgr.SelfTested {
+			t.Errorf("self-test not run")
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerRunDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantResults := []*DiagnosticResult{
+			{Name: "agent round trip"},
+			{Name: "agent port round trip", Err: "failed to sign"},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.Diagnostics = wantResults
+		mgr.Err = wantErr
+
+		results, err := cli.RunDiagnostics(ctx)
+		if diff := cmp.Diff(results, wantResults); diff != "" {
+			t.Errorf("incorrect results; -got +want: %s", diff)
 		}
 		// Compare by error string; cmp.EquateErrors doesn't work since type
 		// information is lost on conversion to/from JSON in message hub.
@@ -252,3 +660,1279 @@ func TestClientServerUnload(t *testing.T) {
 		}
 	})
 }
+
+func TestClientServerConnected(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantConnections := []*Connection{
+			{Client: "extension-id", Origin: "chrome-extension://extension-id", Connected: 1000},
+			{Client: "https://example.com", Origin: "https://example.com", Connected: 2000},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.Connections = wantConnections
+		mgr.Err = wantErr
+
+		conns, err := cli.Connected(ctx)
+		if diff := cmp.Diff(conns, wantConnections); diff != "" {
+			t.Errorf("incorrect connections; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantEntries := []*AuditEntry{
+			{Time: 1000, Fingerprint: "SHA256:abc", Client: "extension-id"},
+			{Time: 2000, Fingerprint: "SHA256:def", Client: "extension-id", Err: "denied"},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.Audit = wantEntries
+		mgr.Err = wantErr
+
+		entries, err := cli.AuditEntries(ctx)
+		if diff := cmp.Diff(entries, wantEntries); diff != "" {
+			t.Errorf("incorrect entries; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerLogEntries(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantEntries := []*LogEntry{
+			{Time: 1000, Level: "INFO", Message: "started"},
+			{Time: 2000, Level: "ERROR", Message: "failed"},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.Logs = wantEntries
+		mgr.Err = wantErr
+
+		entries, err := cli.LogEntries(ctx)
+		if diff := cmp.Diff(entries, wantEntries); diff != "" {
+			t.Errorf("incorrect entries; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerClearAuditLog(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantErr := errors.New("failed")
+		mgr.Err = wantErr
+
+		err := cli.ClearAuditLog(ctx)
+		if !mgr.AuditCleared {
+			t.Errorf("audit log not cleared")
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestLoadedKeyTransport(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		blob        []byte
+	}{
+		{
+			description: "empty",
+			blob:        []byte{},
+		},
+		{
+			description: "all byte values",
+			blob: func() []byte {
+				var b []byte
+				for i := 0; i < 256; i++ {
+					b = append(b, byte(i))
+				}
+				return b
+			}(),
+		},
+		{
+			description: "large",
+			blob:        bytes.Repeat([]byte{0x00, 0x7f, 0x80, 0xff}, 16*1024),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			k := &LoadedKey{Type: "some-type", Comment: "some-comment"}
+			k.SetBlob(tc.blob)
+
+			// Mirror Chrome's messaging, which serializes messages
+			// as JSON.
+			serialized := jsutil.ToJSON(vert.ValueOf(k).JSValue())
+			var got LoadedKey
+			if err := vert.ValueOf(jsutil.FromJSON(serialized)).AssignTo(&got); err != nil {
+				t.Fatalf("failed to parse key: %v", err)
+			}
+
+			if diff := cmp.Diff(&got, k, loadedKeyCmp); diff != "" {
+				t.Errorf("incorrect key; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(got.Blob(), tc.blob); diff != "" {
+				t.Errorf("incorrect blob; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(got.EncodedBlob(), base64.StdEncoding.EncodeToString(tc.blob)); diff != "" {
+				t.Errorf("incorrect encoded blob; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestClientServerLoad(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("id-0")
+		wantPassphrase := "secret"
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.Load(ctx, wantID, wantPassphrase)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect ID; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Passphrase, wantPassphrase); diff != "" {
+			t.Errorf("incorrect passphrase; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerErrorCategory(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		err         error
+		wantErr     error
+	}{
+		{
+			description: "incorrect passphrase",
+			err:         fmt.Errorf("failed to decrypt key: %w", ErrIncorrectPassphrase),
+			wantErr:     ErrIncorrectPassphrase,
+		},
+		{
+			description: "key not found",
+			err:         fmt.Errorf("%w: failed to find key with ID id-0", ErrKeyNotFound),
+			wantErr:     ErrKeyNotFound,
+		},
+		{
+			description: "unsupported algorithm",
+			err:         fmt.Errorf("%w: some reason", ErrUnsupportedAlgorithm),
+			wantErr:     ErrUnsupportedAlgorithm,
+		},
+		{
+			description: "storage failure",
+			err:         fmt.Errorf("failed to store key: %w", fmt.Errorf("%w: QUOTA_BYTES quota exceeded", storage.ErrQuota)),
+			wantErr:     storage.ErrQuota,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				hub := mfakes.NewHub()
+				mgr := &dummyManager{}
+				cli := NewClient(hub)
+				srv := NewServer(mgr, nil)
+				hub.AddReceiver(srv)
+
+				mgr.Err = tc.err
+
+				// The category survives conversion to/from JSON
+				// in the message hub.
+				err := cli.Load(ctx, ID("id-0"), "")
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("incorrect category for error %v: want %v", err, tc.wantErr)
+					return
+				}
+				if diff := cmp.Diff(err.Error(), tc.err.Error()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestClientServerUnload(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.Unload(ctx, wantID)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerUndo(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		undo        func(ctx jsutil.AsyncContext, cli Manager, id ID) error
+		wantUndone  string
+	}{
+		{
+			description: "undo remove",
+			undo: func(ctx jsutil.AsyncContext, cli Manager, id ID) error {
+				return cli.UndoRemove(ctx, id)
+			},
+			wantUndone: "remove",
+		},
+		{
+			description: "undo unload",
+			undo: func(ctx jsutil.AsyncContext, cli Manager, id ID) error {
+				return cli.UndoUnload(ctx, id)
+			},
+			wantUndone: "unload",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				hub := mfakes.NewHub()
+				mgr := &dummyManager{}
+				cli := NewClient(hub)
+				srv := NewServer(mgr, nil)
+				hub.AddReceiver(srv)
+
+				wantID := ID("some-id")
+				wantErr := errors.New("failed")
+
+				mgr.Err = wantErr
+
+				err := tc.undo(ctx, cli, wantID)
+				if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+					t.Errorf("incorrect key; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(mgr.Undone, tc.wantUndone); diff != "" {
+					t.Errorf("incorrect operation undone; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestClientServerSetTags(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantTags := []string{"prod", "work"}
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetTags(ctx, wantID, wantTags)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect ID; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Tags, wantTags); diff != "" {
+			t.Errorf("incorrect tags; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerUnloadTagged(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantTag := "work"
+		wantUnloaded := []ID{ID("id-1"), ID("id-2")}
+		wantErr := errors.New("failed")
+
+		mgr.Unloaded = wantUnloaded
+		mgr.Err = wantErr
+
+		unloaded, err := cli.UnloadTagged(ctx, wantTag)
+		if diff := cmp.Diff(mgr.Tag, wantTag); diff != "" {
+			t.Errorf("incorrect tag; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(unloaded, wantUnloaded); diff != "" {
+			t.Errorf("incorrect unloaded keys; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetHosts(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantHosts := []string{"*.example.com", "github.com"}
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetHosts(ctx, wantID, wantHosts)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect ID; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Hosts, wantHosts); diff != "" {
+			t.Errorf("incorrect hosts; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetLocal(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetLocal(ctx, wantID, true)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Local, true); diff != "" {
+			t.Errorf("incorrect local; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetAutoLoad(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetAutoLoad(ctx, wantID, true)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.AutoLoad, true); diff != "" {
+			t.Errorf("incorrect autoLoad; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerMalformed(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantKeys := []*MalformedKey{
+			{StorageKey: "1", Name: "key-0", Reason: "malformed key: missing ID"},
+			{StorageKey: "2", Local: true, Reason: "failed to parse value"},
+		}
+		wantErr := errors.New("failed")
+
+		mgr.MalformedKeys = wantKeys
+		mgr.Err = wantErr
+
+		keys, err := cli.Malformed(ctx)
+		if diff := cmp.Diff(keys, wantKeys); diff != "" {
+			t.Errorf("incorrect keys; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerRemoveMalformed(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.RemoveMalformed(ctx, "some-key", true)
+		if diff := cmp.Diff(mgr.StorageKey, "some-key"); diff != "" {
+			t.Errorf("incorrect storage key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Local, true); diff != "" {
+			t.Errorf("incorrect local; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerRepin(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.Repin(ctx, wantID)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerCheckSync(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		if err := cli.CheckSync(ctx); err != nil {
+			t.Errorf("CheckSync failed: %v", err)
+		}
+
+		mgr.Err = fmt.Errorf("%w: not supported", storage.ErrUnavailable)
+		err := cli.CheckSync(ctx)
+		if err == nil {
+			t.Fatalf("CheckSync succeeded, want error")
+		}
+		if diff := cmp.Diff(err.Error(), mgr.Err.Error()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+		// The category of storage errors survives messaging.
+		if !errors.Is(err, storage.ErrUnavailable) {
+			t.Errorf("incorrect error category: got %v, want %v", err, storage.ErrUnavailable)
+		}
+	})
+}
+
+func TestClientServerKeysEncrypted(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		if err := cli.SetKeysEncrypted(ctx, true); err != nil {
+			t.Errorf("SetKeysEncrypted failed: %v", err)
+		}
+		encrypted, err := cli.KeysEncrypted(ctx)
+		if err != nil {
+			t.Errorf("KeysEncrypted failed: %v", err)
+		}
+		if !encrypted {
+			t.Errorf("incorrect encrypted: got false, want true")
+		}
+
+		wantErr := errors.New("failed")
+		mgr.Err = wantErr
+		err = cli.SetKeysEncrypted(ctx, false)
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerExport(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{
+			Exported: []byte(`{"version":1}`),
+		}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		data, err := cli.Export(ctx, true, "master")
+		if err != nil {
+			t.Errorf("Export failed: %v", err)
+		}
+		if diff := cmp.Diff(string(data), string(mgr.Exported)); diff != "" {
+			t.Errorf("incorrect exported data; -got +want: %s", diff)
+		}
+		if !mgr.IncludePrivate {
+			t.Errorf("includePrivate not passed to manager")
+		}
+		if diff := cmp.Diff(mgr.Password, "master"); diff != "" {
+			t.Errorf("incorrect password; -got +want: %s", diff)
+		}
+
+		mgr.Err = errors.New("export failed")
+		_, err = cli.Export(ctx, false, "")
+		if diff := cmp.Diff(err, mgr.Err, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerImport(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{
+			ImportResult: &ImportResult{
+				Imported: []string{"key-1"},
+				Skipped:  []string{"key-2"},
+			},
+		}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		// Binary data (e.g., a zip archive) survives messaging.
+		data := []byte(zipMagic + "\x00\xff")
+		res, err := cli.Import(ctx, data, ImportRename)
+		if err != nil {
+			t.Errorf("Import failed: %v", err)
+		}
+		if diff := cmp.Diff(res, mgr.ImportResult); diff != "" {
+			t.Errorf("incorrect result; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Imported, data); diff != "" {
+			t.Errorf("incorrect data; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.OnConflict, ImportRename); diff != "" {
+			t.Errorf("incorrect conflict handling; -got +want: %s", diff)
+		}
+
+		mgr.Err = errors.New("import failed")
+		_, err = cli.Import(ctx, data, ImportSkip)
+		if diff := cmp.Diff(err, mgr.Err, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetIdleTimeout(ctx, wantID, 30)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.IdleTimeout, 30); diff != "" {
+			t.Errorf("incorrect idle timeout; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetConfirm(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetConfirm(ctx, wantID, true)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Confirm, true); diff != "" {
+			t.Errorf("incorrect confirm; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetKeyring(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetKeyring(ctx, wantID, "work")
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Keyring, "work"); diff != "" {
+			t.Errorf("incorrect keyring; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetPersist(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetPersist(ctx, wantID, true)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Persist, true); diff != "" {
+			t.Errorf("incorrect persist; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerAdoptLoaded(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantBlob := "some-blob"
+		wantName := "some-name"
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.AdoptLoaded(ctx, wantBlob, wantName)
+		if diff := cmp.Diff(mgr.Blob, wantBlob); diff != "" {
+			t.Errorf("incorrect blob; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Name, wantName); diff != "" {
+			t.Errorf("incorrect name; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetNotify(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetNotify(ctx, wantID, true)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Notify, true); diff != "" {
+			t.Errorf("incorrect notify; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerUpdate(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantPrivateKey := "private-key"
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.Update(ctx, wantID, wantPrivateKey)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.PEMPrivateKey, wantPrivateKey); diff != "" {
+			t.Errorf("incorrect private key; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerSetCertificate(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		wantID := ID("some-id")
+		wantCertificate := "certificate"
+		wantErr := errors.New("failed")
+
+		mgr.Err = wantErr
+
+		err := cli.SetCertificate(ctx, wantID, wantCertificate)
+		if diff := cmp.Diff(mgr.ID, wantID); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(mgr.Certificate, wantCertificate); diff != "" {
+			t.Errorf("incorrect certificate; -got +want: %s", diff)
+		}
+		// Compare by error string; cmp.EquateErrors doesn't work since type
+		// information is lost on conversion to/from JSON in message hub.
+		if diff := cmp.Diff(err, wantErr, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerGenerate(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		mgr := &dummyManager{
+			PublicKey: "ssh-ed25519 AAAA new-key",
+		}
+		cli := NewClient(hub)
+		srv := NewServer(mgr, nil)
+		hub.AddReceiver(srv)
+
+		pub, err := cli.Generate(ctx, "new-key", KeyTypeECDSA, 384, "secret")
+		if err != nil {
+			t.Errorf("Generate failed: %v", err)
+		}
+		if diff := cmp.Diff(pub, mgr.PublicKey); diff != "" {
+			t.Errorf("incorrect public key; -got +want: %s", diff)
+		}
+		got := []interface{}{mgr.Name, mgr.KeyType, mgr.Bits, mgr.Passphrase}
+		want := []interface{}{"new-key", KeyTypeECDSA, 384, "secret"}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect parameters; -got +want: %s", diff)
+		}
+
+		mgr.Err = errors.New("generate failed")
+		_, err = cli.Generate(ctx, "new-key", KeyTypeED25519, 0, "secret")
+		if diff := cmp.Diff(err, mgr.Err, errStringCmp); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientServerCapabilities(t *testing.T) {
+	t.Parallel()
+
+	errCapabilities := errors.New("failed")
+
+	testcases := []struct {
+		description  string
+		capabilities *Capabilities
+		capErr       error
+		op           func(ctx jsutil.AsyncContext, cli Manager) error
+		wantCalled   bool
+	}{
+		{
+			description:  "add permitted",
+			capabilities: AllCapabilities(),
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Add(ctx, "name", "key")
+				return err
+			},
+			wantCalled: true,
+		},
+		{
+			description:  "add not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Add(ctx, "name", "key")
+				return err
+			},
+		},
+		{
+			description:  "update permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Update(ctx, ID("id-0"), "key") },
+			wantCalled:   true,
+		},
+		{
+			description:  "update not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Update(ctx, ID("id-0"), "key") },
+		},
+		{
+			description:  "set certificate permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.SetCertificate(ctx, ID("id-0"), "cert") },
+			wantCalled:   true,
+		},
+		{
+			description:  "set certificate not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.SetCertificate(ctx, ID("id-0"), "cert") },
+		},
+		{
+			description:  "remove permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Remove(ctx, ID("id-0")) },
+			wantCalled:   true,
+		},
+		{
+			description:  "remove not permitted",
+			capabilities: &Capabilities{Add: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Remove(ctx, ID("id-0")) },
+		},
+		{
+			description:  "remove malformed permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.RemoveMalformed(ctx, "1", false) },
+			wantCalled:   true,
+		},
+		{
+			description:  "remove malformed not permitted",
+			capabilities: &Capabilities{Add: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.RemoveMalformed(ctx, "1", false) },
+		},
+		{
+			description:  "repin permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Repin(ctx, ID("id-0")) },
+			wantCalled:   true,
+		},
+		{
+			description:  "repin not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Repin(ctx, ID("id-0")) },
+		},
+		{
+			description:  "set local permitted",
+			capabilities: AllCapabilities(),
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.SetLocal(ctx, ID("id-0"), true) },
+			wantCalled:   true,
+		},
+		{
+			description:  "set local not permitted",
+			capabilities: &Capabilities{Add: true, Remove: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.SetLocal(ctx, ID("id-0"), true) },
+		},
+		{
+			description:  "import permitted",
+			capabilities: &Capabilities{Add: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Import(ctx, []byte("{}"), ImportRename)
+				return err
+			},
+			wantCalled: true,
+		},
+		{
+			description:  "import not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Import(ctx, []byte("{}"), ImportRename)
+				return err
+			},
+		},
+		{
+			description:  "import replacing keys requires remove",
+			capabilities: &Capabilities{Add: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Import(ctx, []byte("{}"), ImportReplace)
+				return err
+			},
+		},
+		{
+			description:  "generate permitted",
+			capabilities: &Capabilities{Add: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Generate(ctx, "name", KeyTypeED25519, 0, "secret")
+				return err
+			},
+			wantCalled: true,
+		},
+		{
+			description:  "generate not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op: func(ctx jsutil.AsyncContext, cli Manager) error {
+				_, err := cli.Generate(ctx, "name", KeyTypeED25519, 0, "secret")
+				return err
+			},
+		},
+		{
+			description:  "adopt loaded permitted",
+			capabilities: &Capabilities{Add: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.AdoptLoaded(ctx, "blob", "name") },
+			wantCalled:   true,
+		},
+		{
+			description:  "adopt loaded not permitted",
+			capabilities: &Capabilities{Remove: true, SetLocal: true},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.AdoptLoaded(ctx, "blob", "name") },
+		},
+		{
+			description:  "load always permitted",
+			capabilities: &Capabilities{},
+			op:           func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Load(ctx, ID("id-0"), "passphrase") },
+			wantCalled:   true,
+		},
+		{
+			description: "fail closed if capabilities unknown",
+			capErr:      errCapabilities,
+			op:          func(ctx jsutil.AsyncContext, cli Manager) error { return cli.Remove(ctx, ID("id-0")) },
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				hub := mfakes.NewHub()
+				mgr := &dummyManager{}
+				cli := NewClient(hub)
+				srv := NewServer(mgr, func(ctx jsutil.AsyncContext) (*Capabilities, error) {
+					return tc.capabilities, tc.capErr
+				})
+				hub.AddReceiver(srv)
+
+				err := tc.op(ctx, cli)
+				called := mgr.ID != InvalidID || mgr.Name != "" || mgr.StorageKey != "" || mgr.OnConflict != ""
+				if diff := cmp.Diff(called, tc.wantCalled); diff != "" {
+					t.Errorf("incorrect manager invocation; -got +want: %s", diff)
+				}
+				if tc.wantCalled && err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !tc.wantCalled && !errors.Is(err, ErrNotPermitted) {
+					t.Errorf("incorrect error: got %v, want %v", err, ErrNotPermitted)
+				}
+			})
+		})
+	}
+}
+
+// outdatedServer answers requests as a Server that predates protocol
+// versioning would.
+type outdatedServer struct{}
+
+func (outdatedServer) OnMessage(_ jsutil.AsyncContext, _ js.Value, _ js.Value) js.Value {
+	return vert.ValueOf(rspConfigured{Type: msgTypeConfiguredRsp}).JSValue()
+}
+
+func TestClientIncompatibleVersion(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		cli := NewClient(hub)
+		hub.AddReceiver(outdatedServer{})
+
+		_, err := cli.Configured(ctx)
+		if !errors.Is(err, ErrIncompatibleVersion) {
+			t.Errorf("incorrect error: got %v, want %v", err, ErrIncompatibleVersion)
+		}
+	})
+}
+
+func TestServerValidation(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		msg         func() js.Value
+		wantType    int
+		wantErr     string
+		wantCode    int
+	}{
+		{
+			description: "valid message",
+			msg: func() js.Value {
+				m := vert.ValueOf(msgConfigured{Type: msgTypeConfigured}).JSValue()
+				m.Set("version", protocolVersion)
+				return m
+			},
+			wantType: msgTypeConfiguredRsp,
+		},
+		{
+			description: "missing version",
+			msg: func() js.Value {
+				return vert.ValueOf(msgConfigured{Type: msgTypeConfigured}).JSValue()
+			},
+			wantType: msgTypeErrorRsp,
+			wantErr:  "version 0",
+			wantCode: errCodeIncompatibleVersion,
+		},
+		{
+			description: "newer version",
+			msg: func() js.Value {
+				m := vert.ValueOf(msgConfigured{Type: msgTypeConfigured}).JSValue()
+				m.Set("version", protocolVersion+1)
+				return m
+			},
+			wantType: msgTypeErrorRsp,
+			wantErr:  fmt.Sprintf("version %d", protocolVersion+1),
+			wantCode: errCodeIncompatibleVersion,
+		},
+		{
+			description: "missing field",
+			msg: func() js.Value {
+				m := vert.ValueOf(msgAdd{Type: msgTypeAdd, Name: "some-name"}).JSValue()
+				m.Set("version", protocolVersion)
+				m.Delete("pemPrivateKey")
+				return m
+			},
+			wantType: msgTypeErrorRsp,
+			wantErr:  `missing required field "pemPrivateKey"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				srv := NewServer(&dummyManager{}, nil)
+
+				rspObj := srv.OnMessage(ctx, tc.msg(), js.Null())
+				var header msgHeader
+				var rsp rspError
+				if err := vert.ValueOf(rspObj).AssignTo(&header); err != nil {
+					t.Errorf("failed to parse response header: %v", err)
+					return
+				}
+				if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+					t.Errorf("failed to parse response: %v", err)
+					return
+				}
+				if header.Version != protocolVersion {
+					t.Errorf("incorrect response version: got %d, want %d", header.Version, protocolVersion)
+				}
+				if header.Type != tc.wantType {
+					t.Errorf("incorrect response type: got %d, want %d", header.Type, tc.wantType)
+				}
+				if !strings.Contains(rsp.Err, tc.wantErr) || (tc.wantErr == "") != (rsp.Err == "") {
+					t.Errorf("incorrect error: got %q, want substring %q", rsp.Err, tc.wantErr)
+				}
+				if rsp.Code != tc.wantCode {
+					t.Errorf("incorrect error code: got %d, want %d", rsp.Code, tc.wantCode)
+				}
+			})
+		})
+	}
+}
@@ -53,6 +53,15 @@ func configuredKeyNames(keys []*ConfiguredKey) []string {
 	return result
 }
 
+func storedKeyNames(keys []*storedKey) []string {
+	var result []string
+	for _, k := range keys {
+		result = append(result, k.Name)
+	}
+	sort.Strings(result)
+	return result
+}
+
 func loadedKeyIds(keys []*LoadedKey) []ID {
 	var result []ID
 	for _, k := range keys {
@@ -69,6 +78,14 @@ func loadedKeyBlobs(keys []*LoadedKey) []string {
 	return result
 }
 
+func loadedKeyTypes(keys []*LoadedKey) []string {
+	var res []string
+	for _, k := range keys {
+		res = append(res, k.Type)
+	}
+	return res
+}
+
 func loadedKeyIDs(keys []*LoadedKey) []ID {
 	var res []ID
 	for _, k := range keys {
@@ -0,0 +1,87 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+)
+
+// SetConfirm implements Manager.SetConfirm.
+func (m *DefaultManager) SetConfirm(ctx jsutil.AsyncContext, id ID, confirm bool) error {
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Confirm = confirm }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadedID returns the ID of the key loaded into the agent with the specified
+// public key. InvalidID is returned if the key was not loaded by the
+// manager.
+func (m *DefaultManager) loadedID(ctx jsutil.AsyncContext, pub ssh.PublicKey) (ID, error) {
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return InvalidID, fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+
+	blob := pub.Marshal()
+	for _, l := range loaded {
+		if bytes.Equal(l.Blob(), blob) {
+			return l.ID(), nil
+		}
+	}
+	return InvalidID, nil
+}
+
+// KeyFor returns the configured key that is loaded into the agent with the
+// specified public key (or certificate). Nil is returned if the key was not
+// loaded by the manager, or is no longer configured.
+func (m *DefaultManager) KeyFor(ctx jsutil.AsyncContext, pub ssh.PublicKey) (*ConfiguredKey, error) {
+	id, err := m.loadedID(ctx, pub)
+	if err != nil {
+		return nil, err
+	}
+	if id == InvalidID {
+		return nil, nil
+	}
+
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+	for _, k := range configured {
+		if ID(k.ID) == id {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
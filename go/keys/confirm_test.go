@@ -0,0 +1,149 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSetConfirm(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		byID        ID
+		confirm     bool
+		wantConfirm bool
+		wantErr     error
+	}{
+		{
+			description: "enable",
+			confirm:     true,
+			wantConfirm: true,
+		},
+		{
+			description: "disable",
+			confirm:     false,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			confirm:     true,
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.SetConfirm(ctx, id, tc.confirm)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if len(configured) != 1 {
+					t.Fatalf("incorrect number of configured keys: got %d, want 1", len(configured))
+				}
+				if diff := cmp.Diff(configured[0].Confirm, tc.wantConfirm); diff != "" {
+					t.Errorf("incorrect confirm; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestKeyFor(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agt, syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "cert-key",
+				PEMPrivateKey: testdata.ED25519WithCertificate.Private + "\n" + testdata.ED25519WithCertificate.Certificate + "\n",
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		// Load a key the manager does not know about.
+		priv, err := ssh.ParseRawPrivateKey([]byte(testdata.WithoutPassphrase.Private))
+		if err != nil {
+			t.Fatalf("failed to parse key: %v", err)
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		loaded, err := agt.List()
+		if err != nil {
+			t.Fatalf("failed to list keys: %v", err)
+		}
+		var got []string
+		for _, l := range loaded {
+			k, err := mgr.KeyFor(ctx, l)
+			if err != nil {
+				t.Errorf("KeyFor failed: %v", err)
+			}
+			name := "<none>"
+			if k != nil {
+				name = k.Name
+			}
+			got = append(got, name)
+		}
+		// The key is found both with and without its certificate.
+		want := []string{"<none>", "cert-key", "cert-key"}
+		if diff := cmp.Diff(got, want, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+			t.Errorf("incorrect keys; -got +want: %s", diff)
+		}
+	})
+}
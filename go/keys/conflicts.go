@@ -0,0 +1,108 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Keys are synced between the user's devices with last-write-wins semantics
+// for each stored item. Keys are always added under a new item, so keys added
+// concurrently on different devices are all retained, even with the same name.
+// Such keys are reported with ConfiguredKey.Conflict so that the user can
+// choose between them.
+//
+// However, copies of a key with the same ID but different key material can
+// also arise; for example, if a key is moved between synced and local-only
+// storage on one device while it is replaced on another. Only one copy would
+// be reported, so SeparateConflicts gives the others their own IDs.
+
+// SeparateConflicts gives a new ID to each stored key that shares its ID with
+// another stored key, but has different key material. The synced copy retains
+// the ID. New IDs are derived from the key material, so that devices
+// separating the same keys concurrently agree on the result.
+func (m *DefaultManager) SeparateConflicts(ctx jsutil.AsyncContext) error {
+	synced, err := m.storedKeys.ReadAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+	local, err := m.localKeys.ReadAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read local keys: %w", err)
+	}
+
+	// Determine the copy that retains each ID. Synced copies take
+	// precedence, as in Configured; otherwise, the choice is arbitrary
+	// but consistent between devices.
+	retained := map[string]string{}
+	for _, keys := range [][]*storedKey{synced, local} {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].computeChecksum() < keys[j].computeChecksum()
+		})
+		for _, k := range keys {
+			if _, ok := retained[k.ID]; !ok {
+				retained[k.ID] = k.computeChecksum()
+			}
+		}
+	}
+
+	conflicting := func(k *storedKey) bool {
+		return k.computeChecksum() != retained[k.ID]
+	}
+	separate := func(k *storedKey) {
+		id := conflictID(k)
+		jsutil.Log("DefaultManager.SeparateConflicts: key %s (ID %s) conflicts with another copy; now ID %s", k.Name, k.ID, id)
+		k.ID = id
+	}
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, conflicting, separate); err != nil {
+			return fmt.Errorf("failed to separate conflicting keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// conflictID returns a new ID for a key whose ID conflicts with another copy.
+// It is derived from the key's ID and key material.
+func conflictID(k *storedKey) string {
+	h := sha256.New()
+	h.Write([]byte(k.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(k.computeChecksum()))
+	n := binary.BigEndian.Uint64(h.Sum(nil)) & math.MaxInt64
+	return strconv.FormatUint(n, 10)
+}
+
+// markConflicts sets ConfiguredKey.Conflict for keys that share their name
+// with another configured key.
+func markConflicts(configured []*ConfiguredKey) {
+	names := map[string]int{}
+	for _, k := range configured {
+		names[k.Name]++
+	}
+	for _, k := range configured {
+		k.Conflict = names[k.Name] > 1
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// conflictTestKey summarizes a configured key for comparison.
+type conflictTestKey struct {
+	ID       string
+	Name     string
+	Local    bool
+	Conflict bool
+}
+
+func TestSeparateConflicts(t *testing.T) {
+	t.Parallel()
+
+	first := &storedKey{ID: "1", Name: "key", PEMPrivateKey: testdata.WithoutPassphrase.Private}
+	second := &storedKey{ID: "1", Name: "key", PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private}
+	other := &storedKey{ID: "2", Name: "other", PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private}
+	// lower and higher order the conflicting copies as SeparateConflicts
+	// does when both are in the same location.
+	lower, higher := first, second
+	if lower.computeChecksum() > higher.computeChecksum() {
+		lower, higher = higher, lower
+	}
+
+	testcases := []struct {
+		description string
+		synced      []*storedKey
+		local       []*storedKey
+		want        []*conflictTestKey
+	}{
+		{
+			description: "no conflicts",
+			synced:      []*storedKey{first, other},
+			want: []*conflictTestKey{
+				{ID: "1", Name: "key"},
+				{ID: "2", Name: "other"},
+			},
+		},
+		{
+			description: "identical copies",
+			synced:      []*storedKey{first},
+			local:       []*storedKey{first},
+			want: []*conflictTestKey{
+				{ID: "1", Name: "key"},
+			},
+		},
+		{
+			description: "synced and local copies differ",
+			synced:      []*storedKey{first, other},
+			local:       []*storedKey{second},
+			want: []*conflictTestKey{
+				{ID: "1", Name: "key", Conflict: true},
+				{ID: conflictID(second), Name: "key", Local: true, Conflict: true},
+				{ID: "2", Name: "other"},
+			},
+		},
+		{
+			description: "synced copies differ",
+			synced:      []*storedKey{first, second},
+			want: []*conflictTestKey{
+				{ID: "1", Name: "key", Conflict: true},
+				{ID: conflictID(higher), Name: "key", Conflict: true},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr := NewManager(agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+				for _, k := range tc.synced {
+					if err := mgr.storedKeys.Write(ctx, k); err != nil {
+						t.Fatalf("failed to write synced key: %v", err)
+					}
+				}
+				for _, k := range tc.local {
+					if err := mgr.localKeys.Write(ctx, k); err != nil {
+						t.Fatalf("failed to write local key: %v", err)
+					}
+				}
+
+				// Separating again has no further effect.
+				for i := 0; i < 2; i++ {
+					if err := mgr.SeparateConflicts(ctx); err != nil {
+						t.Fatalf("SeparateConflicts failed: %v", err)
+					}
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				var got []*conflictTestKey
+				for _, k := range configured {
+					got = append(got, &conflictTestKey{ID: k.ID, Name: k.Name, Local: k.Local, Conflict: k.Conflict})
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.SortSlices(func(a, b *conflictTestKey) bool { return a.ID < b.ID })); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestConfiguredConflict(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), []*initialKey{
+			{Name: "key", PEMPrivateKey: testdata.WithoutPassphrase.Private},
+			{Name: "key", PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private},
+			{Name: "other", PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		configured, err := mgr.Configured(ctx)
+		if err != nil {
+			t.Fatalf("failed to get configured keys: %v", err)
+		}
+		got := map[string][]bool{}
+		for _, k := range configured {
+			got[k.Name] = append(got[k.Name], k.Conflict)
+		}
+		want := map[string][]bool{
+			"key":   {true, true},
+			"other": {false},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect conflicts; -got +want: %s", diff)
+		}
+	})
+}
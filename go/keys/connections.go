@@ -0,0 +1,51 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Connection describes a client currently connected to the agent.
+type Connection struct {
+	// Client identifies the client: the ID of the extension, or the
+	// origin of the web application, that opened the connection.
+	Client string `js:"client"`
+	// Origin is the origin of the page that opened the connection, or
+	// empty if it is not known.
+	Origin string `js:"origin"`
+	// Connected is the time at which the connection was opened, in
+	// seconds since the Unix epoch.
+	Connected int64 `js:"connected"`
+}
+
+// ConnectionSource returns the clients currently connected to the agent.
+type ConnectionSource func() []*Connection
+
+// SetConnectionSource configures how the clients currently connected to the
+// agent are determined.
+func (m *DefaultManager) SetConnectionSource(s ConnectionSource) {
+	m.connections = s
+}
+
+// Connected implements Manager.Connected.
+func (m *DefaultManager) Connected(ctx jsutil.AsyncContext) ([]*Connection, error) {
+	if m.connections == nil {
+		return nil, nil
+	}
+	return m.connections(), nil
+}
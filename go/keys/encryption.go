@@ -0,0 +1,62 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+var (
+	errEncryptionUnavailable = i18n.NewError("errEncryptionUnavailable")
+)
+
+// SetKeySource configures the source of the key with which the private keys in
+// synced storage may be encrypted, and their key material pinned. It must be
+// called before the manager is used. Encryption remains disabled until
+// SetKeysEncrypted is called, but keys that are already encrypted can only be
+// read once the key is available.
+func (m *DefaultManager) SetKeySource(keys storage.KeySource) {
+	m.keySource = keys
+	m.encryptedSync = storage.NewEncrypted(keys, storedKeyPrefixes, m.syncStorage)
+	m.storedKeys = storage.NewTyped[storedKey](m.encryptedSync, storedKeyPrefixes)
+}
+
+// KeysEncrypted implements Manager.KeysEncrypted.
+func (m *DefaultManager) KeysEncrypted(ctx jsutil.AsyncContext) (bool, error) {
+	if m.encryptedSync == nil {
+		return false, nil
+	}
+	return m.encryptedSync.Enabled(ctx)
+}
+
+// SetKeysEncrypted implements Manager.SetKeysEncrypted.
+func (m *DefaultManager) SetKeysEncrypted(ctx jsutil.AsyncContext, encrypted bool) error {
+	if m.encryptedSync == nil {
+		return errEncryptionUnavailable
+	}
+	if err := m.CheckSync(ctx); err != nil {
+		return err
+	}
+	if err := m.encryptedSync.SetEnabled(ctx, encrypted); err != nil {
+		return fmt.Errorf("failed to update stored keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var errLocked = errors.New("locked")
+
+// fakeKeySource returns a fixed key, or errLocked if it is locked.
+type fakeKeySource struct {
+	locked bool
+}
+
+func (f *fakeKeySource) EncryptionKey(ctx jsutil.AsyncContext) ([]byte, error) {
+	if f.locked {
+		return nil, errLocked
+	}
+	return bytes.Repeat([]byte{1}, 32), nil
+}
+
+func TestSetKeysEncrypted(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		localStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+		keySource := &fakeKeySource{}
+		mgr.SetKeySource(keySource)
+
+		if _, err := mgr.Add(ctx, "good-key", testdata.WithPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		if err := mgr.SetKeysEncrypted(ctx, true); err != nil {
+			t.Errorf("SetKeysEncrypted failed: %v", err)
+			return
+		}
+		if encrypted, err := mgr.KeysEncrypted(ctx); err != nil || !encrypted {
+			t.Errorf("incorrect KeysEncrypted: got %t, %v; want true", encrypted, err)
+		}
+
+		// The private key is no longer stored in the clear.
+		data, err := syncStorage.Get(ctx)
+		if err != nil {
+			t.Errorf("failed to read synced storage: %v", err)
+			return
+		}
+		for k, v := range data {
+			if strings.Contains(jsutil.ToJSON(v), "PRIVATE KEY") {
+				t.Errorf("private key stored in the clear at %s", k)
+			}
+		}
+
+		// Keys can be read only while the key source is unlocked. While
+		// it is locked, they are reported as needing attention rather
+		// than failing the read.
+		if configured, err := mgr.Configured(ctx); err != nil || len(configured) != 1 {
+			t.Errorf("incorrect configured keys: got %v, %v; want single key", configured, err)
+		}
+		keySource.locked = true
+		if configured, err := mgr.Configured(ctx); err != nil || len(configured) != 0 {
+			t.Errorf("incorrect configured keys while locked: got %v, %v; want none", configured, err)
+		}
+		malformed, err := mgr.Malformed(ctx)
+		if err != nil || len(malformed) != 1 {
+			t.Errorf("incorrect malformed keys while locked: got %v, %v; want single key", malformed, err)
+		}
+		if err := mgr.SetKeysEncrypted(ctx, false); !errors.Is(err, errLocked) {
+			t.Errorf("incorrect error while locked: got %v, want %v", err, errLocked)
+		}
+	})
+}
+
+func TestKeysEncryptedUnavailable(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		localStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+
+		if encrypted, err := mgr.KeysEncrypted(ctx); err != nil || encrypted {
+			t.Errorf("incorrect KeysEncrypted: got %t, %v; want false", encrypted, err)
+		}
+		if err := mgr.SetKeysEncrypted(ctx, true); !errors.Is(err, errEncryptionUnavailable) {
+			t.Errorf("incorrect error: got %v, want %v", err, errEncryptionUnavailable)
+		}
+	})
+}
+
+// recordingArea records every value written to the underlying area.
+type recordingArea struct {
+	storage.Area
+	written []string
+}
+
+func (r *recordingArea) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	for _, v := range data {
+		r.written = append(r.written, jsutil.ToJSON(v))
+	}
+	return r.Area.Set(ctx, data)
+}
+
+// newEncryptedManager returns a manager whose synced keys are encrypted, with
+// an existing key configured. All values written to synced storage are
+// recorded.
+func newEncryptedManager(ctx jsutil.AsyncContext) (*DefaultManager, *recordingArea, error) {
+	syncStorage := &recordingArea{Area: storage.NewRaw(st.NewMemArea())}
+	localStorage := storage.NewRaw(st.NewMemArea())
+	sessionStorage := storage.NewRaw(st.NewMemArea())
+	mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+	mgr.SetKeySource(&fakeKeySource{})
+	if _, err := mgr.Add(ctx, "existing", testdata.WithPassphrase.Private); err != nil {
+		return nil, nil, err
+	}
+	if err := mgr.SetKeysEncrypted(ctx, true); err != nil {
+		return nil, nil, err
+	}
+	syncStorage.written = nil
+	return mgr, syncStorage, nil
+}
+
+// checkNotInClear reports an error if a private key was written to the area.
+func checkNotInClear(t *testing.T, area *recordingArea) {
+	t.Helper()
+	if len(area.written) == 0 {
+		t.Errorf("nothing written to synced storage")
+	}
+	for _, w := range area.written {
+		if strings.Contains(w, "PRIVATE KEY") {
+			t.Errorf("private key written in the clear: %s", w)
+		}
+	}
+}
+
+func TestImportEncrypted(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr, syncStorage, err := newEncryptedManager(ctx)
+		if err != nil {
+			t.Errorf("failed to initialize manager: %v", err)
+			return
+		}
+
+		// The existing key is recognized, even though it is encrypted.
+		bundle := mustEncodeBundle(t,
+			&BackupKey{Name: "existing", PrivateKey: testdata.ED25519WithPassphrase.Private},
+			&BackupKey{Name: "new", PrivateKey: testdata.ECDSAWithPassphrase.Private},
+		)
+		res, err := mgr.Import(ctx, bundle, ImportReplace)
+		if err != nil {
+			t.Errorf("Import failed: %v", err)
+			return
+		}
+		want := &ImportResult{Imported: []string{"existing", "new"}}
+		if diff := cmp.Diff(res, want); diff != "" {
+			t.Errorf("incorrect result; -got +want: %s", diff)
+		}
+
+		configured, err := mgr.Configured(ctx)
+		if err != nil {
+			t.Errorf("failed to get configured keys: %v", err)
+			return
+		}
+		if diff := cmp.Diff(configuredKeyNames(configured), []string{"existing", "new"}); diff != "" {
+			t.Errorf("incorrect configured keys; -got +want: %s", diff)
+		}
+		checkNotInClear(t, syncStorage)
+	})
+}
+
+func TestImportEncryptedRename(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr, syncStorage, err := newEncryptedManager(ctx)
+		if err != nil {
+			t.Errorf("failed to initialize manager: %v", err)
+			return
+		}
+
+		// The existing key is recognized, even though it is encrypted.
+		bundle := mustEncodeBundle(t,
+			&BackupKey{Name: "duplicate", PrivateKey: testdata.WithPassphrase.Private},
+			&BackupKey{Name: "other", PrivateKey: testdata.ED25519WithPassphrase.Private},
+		)
+		res, err := mgr.Import(ctx, bundle, ImportRename)
+		if err != nil {
+			t.Errorf("Import failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(res.Imported, []string{"other"}); diff != "" {
+			t.Errorf("incorrect imported keys; -got +want: %s", diff)
+		}
+
+		configured, err := mgr.Configured(ctx)
+		if err != nil {
+			t.Errorf("failed to get configured keys: %v", err)
+			return
+		}
+		if diff := cmp.Diff(configuredKeyNames(configured), []string{"existing", "other"}); diff != "" {
+			t.Errorf("incorrect configured keys; -got +want: %s", diff)
+		}
+		checkNotInClear(t, syncStorage)
+	})
+}
@@ -0,0 +1,134 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Categories of key errors. Errors returned by a Manager wrap at most one of
+// these, so that callers can explain the problem and suggest a remedy using
+// errors.Is.
+var (
+	// ErrIncorrectPassphrase indicates that the passphrase supplied to
+	// decrypt a key was incorrect.
+	ErrIncorrectPassphrase = x509.IncorrectPasswordError
+	// ErrKeyNotFound indicates that no key has the requested ID.
+	ErrKeyNotFound = i18n.NewError("errKeyNotFound")
+	// ErrNotPermitted indicates that administrator policy does not permit
+	// the requested operation.
+	ErrNotPermitted = i18n.NewError("errNotPermitted")
+	// ErrUnsupportedAlgorithm indicates that a key uses a legacy algorithm
+	// (e.g., DSA) that the agent cannot serve.
+	ErrUnsupportedAlgorithm = i18n.NewError("errUnsupportedAlgorithm")
+	// ErrAgentLocked indicates that a client locked the agent using the
+	// agent protocol; see LockAgent.
+	ErrAgentLocked = i18n.NewError("errAgentLocked")
+	// ErrIncompatibleVersion indicates that a page and the background
+	// worker use different versions of the messaging API, typically
+	// because the extension was updated while the page was open.
+	ErrIncompatibleVersion = i18n.NewError("errIncompatibleVersion")
+	// ErrReauthRequired indicates that the master password must be
+	// entered again to export private keys; see SetReauthWindow.
+	ErrReauthRequired = i18n.NewError("errReauthRequired")
+)
+
+var categories = []error{ErrIncorrectPassphrase, ErrKeyNotFound, ErrNotPermitted, ErrUnsupportedAlgorithm, ErrAgentLocked, ErrIncompatibleVersion, ErrReauthRequired}
+
+// Category returns the category of the key error, or nil if it does not have
+// one.
+func Category(err error) error {
+	for _, c := range categories {
+		if errors.Is(err, c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Error codes are sent along with error messages by Server, identifying the
+// category of the error. Codes are part of the messaging API; existing values
+// must not be changed.
+const (
+	errCodeNone int = iota
+	errCodeIncorrectPassphrase
+	errCodeKeyNotFound
+	errCodeNotPermitted
+	errCodeUnsupportedAlgorithm
+	errCodeStorageQuota
+	errCodeStorageUnavailable
+	errCodeStorageCorrupted
+	errCodeStorageTransient
+	errCodeAgentLocked
+	errCodeIncompatibleVersion
+	errCodeReauthRequired
+)
+
+// errorCodes maps each error code to the category it represents.
+var errorCodes = []struct {
+	code     int
+	category error
+}{
+	{errCodeIncorrectPassphrase, ErrIncorrectPassphrase},
+	{errCodeKeyNotFound, ErrKeyNotFound},
+	{errCodeNotPermitted, ErrNotPermitted},
+	{errCodeUnsupportedAlgorithm, ErrUnsupportedAlgorithm},
+	{errCodeStorageQuota, storage.ErrQuota},
+	{errCodeStorageUnavailable, storage.ErrUnavailable},
+	{errCodeStorageCorrupted, storage.ErrCorrupted},
+	{errCodeStorageTransient, storage.ErrTransient},
+	{errCodeAgentLocked, ErrAgentLocked},
+	{errCodeIncompatibleVersion, ErrIncompatibleVersion},
+	{errCodeReauthRequired, ErrReauthRequired},
+}
+
+// errorCode returns the code identifying the category of the key or storage
+// error. errCodeNone is returned if the error has no category.
+func errorCode(err error) int {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.category) {
+			return ec.code
+		}
+	}
+	return errCodeNone
+}
+
+// categorizedError is an error whose type information was lost (e.g., when
+// sent via messaging), but whose category was recovered.
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string { return e.msg }
+func (e *categorizedError) Unwrap() error { return e.category }
+
+// errorFromCode returns an error with the specified message. If the code
+// identifies a category, the returned error wraps it, allowing callers to use
+// errors.Is on errors received via messaging.
+func errorFromCode(msg string, code int) error {
+	for _, ec := range errorCodes {
+		if ec.code == code {
+			return &categorizedError{msg: msg, category: ec.category}
+		}
+	}
+	return errors.New(msg)
+}
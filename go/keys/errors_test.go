@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestErrorCode(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description  string
+		err          error
+		wantCategory error
+		wantStorage  error
+	}{
+		{
+			description:  "incorrect passphrase",
+			err:          fmt.Errorf("failed to load key: %w", fmt.Errorf("failed to decrypt key: %w", ErrIncorrectPassphrase)),
+			wantCategory: ErrIncorrectPassphrase,
+		},
+		{
+			description:  "key not found",
+			err:          fmt.Errorf("%w: failed to find key with ID id-0", ErrKeyNotFound),
+			wantCategory: ErrKeyNotFound,
+		},
+		{
+			description:  "not permitted",
+			err:          fmt.Errorf("%w: Add", ErrNotPermitted),
+			wantCategory: ErrNotPermitted,
+		},
+		{
+			description:  "unsupported algorithm",
+			err:          fmt.Errorf("failed to load key: %w", fmt.Errorf("%w: some reason", ErrUnsupportedAlgorithm)),
+			wantCategory: ErrUnsupportedAlgorithm,
+		},
+		{
+			description:  "incompatible version",
+			err:          fmt.Errorf("%w: client uses version 0", ErrIncompatibleVersion),
+			wantCategory: ErrIncompatibleVersion,
+		},
+		{
+			description: "storage error",
+			err:         fmt.Errorf("failed to add key: %w", fmt.Errorf("%w: some failure", storage.ErrQuota)),
+			wantStorage: storage.ErrQuota,
+		},
+		{
+			description: "uncategorized",
+			err:         errors.New("failed to load key"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			// Errors are sent via messaging as a message and code.
+			got := errorFromCode(tc.err.Error(), errorCode(tc.err))
+			if diff := cmp.Diff(got.Error(), tc.err.Error()); diff != "" {
+				t.Errorf("incorrect message; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(Category(got), tc.wantCategory, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect category; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(storage.Category(got), tc.wantStorage, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect storage category; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExportedKey describes the public key of a configured key, in a form
+// suitable for provisioning authorized_keys files using configuration
+// management tools (e.g., Ansible or Terraform).
+type ExportedKey struct {
+	// Name is the name of the configured key.
+	Name string `json:"name"`
+	// Type is the SSH key type (e.g., 'ssh-ed25519').
+	Type string `json:"type"`
+	// PublicKey is the public key in authorized_keys format.
+	PublicKey string `json:"public_key"`
+	// Fingerprint is the SHA256 fingerprint of the key.
+	Fingerprint string `json:"fingerprint"`
+	// Tags are the labels the user attached to the key, in order. Empty
+	// (rather than null) if the key has none.
+	Tags []string `json:"tags"`
+}
+
+// ExportPublicKeys returns a JSON array of ExportedKey describing the
+// configured keys. The public key of an encrypted key may not be known until
+// it is loaded; keys whose public key is unknown are omitted, and their names
+// are returned in skipped.
+func ExportPublicKeys(configured []*ConfiguredKey, loaded []*LoadedKey) (data []byte, skipped []string, err error) {
+	loadedByID := map[ID]*LoadedKey{}
+	for _, l := range loaded {
+		loadedByID[l.ID()] = l
+	}
+
+	result := []*ExportedKey{}
+	for _, c := range configured {
+		pub, err := exportedPublicKey(c, loadedByID[ID(c.ID)])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse public key for %s: %w", c.Name, err)
+		}
+		if pub == nil {
+			skipped = append(skipped, c.Name)
+			continue
+		}
+		tags := append([]string{}, c.Tags...)
+		result = append(result, &ExportedKey{
+			Name:        c.Name,
+			Type:        pub.Type(),
+			PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+			Fingerprint: ssh.FingerprintSHA256(pub),
+			Tags:        tags,
+		})
+	}
+
+	data, err = json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode keys: %w", err)
+	}
+	return data, skipped, nil
+}
+
+// exportedPublicKey returns the public key for the configured key, or nil if
+// it is not known. If the key is loaded, l is the loaded key.
+func exportedPublicKey(c *ConfiguredKey, l *LoadedKey) (ssh.PublicKey, error) {
+	if c.PublicKey != "" {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(c.PublicKey))
+		return pub, err
+	}
+	if l != nil {
+		pub, err := ssh.ParsePublicKey(l.Blob())
+		if err != nil {
+			return nil, err
+		}
+		return plainKey(pub), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+)
+
+func authorizedKey(t *testing.T, blob string) string {
+	t.Helper()
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(mustParseBlob(t, blob))))
+}
+
+func TestExportPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	configured := []*ConfiguredKey{
+		{ID: "1", Name: "unloaded-key", PublicKey: authorizedKey(t, testdata.ED25519WithoutPassphrase.Blob), Tags: []string{"work", "prod"}},
+		{ID: "2", Name: "loaded-key"},
+		{ID: "3", Name: "unknown-key"},
+	}
+	loaded := &LoadedKey{Type: ssh.KeyAlgoRSA, Comment: commentPrefix + "2"}
+	loaded.SetBlob(mustParseBlob(t, testdata.WithoutPassphrase.Blob).Marshal())
+
+	data, skipped, err := ExportPublicKeys(configured, []*LoadedKey{loaded})
+	if err != nil {
+		t.Fatalf("ExportPublicKeys failed: %v", err)
+	}
+	var got []*ExportedKey
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed to parse exported keys: %v", err)
+	}
+	want := []*ExportedKey{
+		{
+			Name:        "unloaded-key",
+			Type:        ssh.KeyAlgoED25519,
+			PublicKey:   authorizedKey(t, testdata.ED25519WithoutPassphrase.Blob),
+			Fingerprint: blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob),
+			Tags:        []string{"work", "prod"},
+		},
+		{
+			Name:        "loaded-key",
+			Type:        ssh.KeyAlgoRSA,
+			PublicKey:   authorizedKey(t, testdata.WithoutPassphrase.Blob),
+			Fingerprint: blobFingerprint(t, testdata.WithoutPassphrase.Blob),
+			Tags:        []string{},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect exported keys; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(skipped, []string{"unknown-key"}); diff != "" {
+		t.Errorf("incorrect skipped keys; -got +want: %s", diff)
+	}
+}
+
+func TestExportPublicKeysEmpty(t *testing.T) {
+	t.Parallel()
+
+	data, skipped, err := ExportPublicKeys(nil, nil)
+	if err != nil {
+		t.Fatalf("ExportPublicKeys failed: %v", err)
+	}
+	// An empty array, rather than null, is easier for consumers.
+	if diff := cmp.Diff(string(data), "[]"); diff != "" {
+		t.Errorf("incorrect exported keys; -got +want: %s", diff)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("unexpected skipped keys: %v", skipped)
+	}
+}
+
+func TestExportPublicKeysUntagged(t *testing.T) {
+	t.Parallel()
+
+	configured := []*ConfiguredKey{
+		{ID: "1", Name: "untagged-key", PublicKey: authorizedKey(t, testdata.ED25519WithoutPassphrase.Blob)},
+	}
+	data, _, err := ExportPublicKeys(configured, nil)
+	if err != nil {
+		t.Fatalf("ExportPublicKeys failed: %v", err)
+	}
+	// An empty array, rather than null, is easier for consumers.
+	if !strings.Contains(string(data), `"tags": []`) {
+		t.Errorf("tags not exported as an empty array: %s", data)
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+// This file intentionally has no build constraints so that the generator can
+// be invoked from the host platform (e.g., 'go generate ./go/keys').
+
+// Generate the machine-readable description of the messaging API defined in
+// client.go, together with that defined in go/settings/server.go.
+//go:generate go run ../../tools/apischema -src_dir=.,../settings -out=../../html/api-schema.json
@@ -0,0 +1,236 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// HostHintsExtension is the name of the agent protocol extension with
+	// which clients ask for the keys intended for a host. The request
+	// contains the hostname as a string. The response is
+	// SSH_AGENT_SUCCESS followed by the matching keys, in the same format
+	// as SSH_AGENT_IDENTITIES_ANSWER.
+	HostHintsExtension = "host-hints@chrome-ssh-agent"
+	// maxHostLength is the maximum length of a host pattern, matching the
+	// maximum length of a hostname.
+	maxHostLength = 253
+	// maxHosts is the maximum number of host patterns that may be
+	// attached to a key.
+	maxHosts = 32
+	// agentSuccess is the SSH_AGENT_SUCCESS message type.
+	agentSuccess = 6
+)
+
+var (
+	errInvalidHost        = i18n.NewError("errInvalidHost")
+	errInvalidHintRequest = errors.New("invalid host-hints request")
+)
+
+// validHostChar returns true if the character may appear in a host pattern.
+// Patterns are hostnames, optionally with '*' and '?' wildcards.
+func validHostChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || strings.ContainsRune(".-_*?", c)
+}
+
+// normalizeHosts trims and lowercases the host patterns, and removes those
+// that are empty or duplicates. The remaining patterns are sorted. nil is
+// returned if no patterns remain.
+func normalizeHosts(hosts []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" || seen[h] {
+			continue
+		}
+		if len(h) > maxHostLength || strings.IndexFunc(h, func(c rune) bool { return !validHostChar(c) }) >= 0 {
+			return nil, fmt.Errorf("%w: %q is not a hostname", errInvalidHost, h)
+		}
+		seen[h] = true
+		result = append(result, h)
+	}
+	if len(result) > maxHosts {
+		return nil, fmt.Errorf("%w: at most %d hosts may be attached to a key", errInvalidHost, maxHosts)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// matchHost returns true if the host matches the pattern, ignoring case.
+// Wildcards in the pattern match as in ssh_config (e.g., '*.example.com').
+func matchHost(pattern, host string) bool {
+	// Patterns contain no characters that make them malformed.
+	ok, _ := path.Match(pattern, strings.ToLower(host))
+	return ok
+}
+
+// MatchesHost returns true if the key is intended for the specified host.
+func (k *ConfiguredKey) MatchesHost(host string) bool {
+	for _, h := range k.Hosts {
+		if matchHost(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetHosts implements Manager.SetHosts.
+func (m *DefaultManager) SetHosts(ctx jsutil.AsyncContext, id ID, hosts []string) error {
+	hosts, err := normalizeHosts(hosts)
+	if err != nil {
+		return err
+	}
+
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Hosts = hosts }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// HintAgent wraps the agent for a single connection, and handles
+// HostHintsExtension requests. Only keys listed by the wrapped agent are
+// returned, so clients learn nothing about keys they could not otherwise
+// use. Other extension requests are passed to the wrapped agent.
+//
+// HintAgent implements the agent.ExtendedAgent interface.
+type HintAgent struct {
+	agent.Agent
+	mgr *DefaultManager
+}
+
+// NewHintAgent returns a HintAgent.
+func NewHintAgent(agt agent.Agent, mgr *DefaultManager) *HintAgent {
+	return &HintAgent{
+		Agent: agt,
+		mgr:   mgr,
+	}
+}
+
+// hintRequest is the contents of a HostHintsExtension request.
+type hintRequest struct {
+	Host string
+}
+
+// hintKey is a key in the response to a HostHintsExtension request.
+type hintKey struct {
+	Blob    []byte
+	Comment string
+}
+
+// intended returns the IDs of the configured keys intended for the host.
+// Requests are served outside of an AsyncContext, so they are read
+// asynchronously.
+func (a *HintAgent) intended(host string) (map[ID]bool, error) {
+	result := map[ID]bool{}
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		var configured []*ConfiguredKey
+		configured, err = a.mgr.Configured(ctx)
+		for _, k := range configured {
+			if k.MatchesHost(host) {
+				result[ID(k.ID)] = true
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configured keys: %w", err)
+	}
+	return result, nil
+}
+
+// hints returns the listed keys intended for the host, in wire format.
+func (a *HintAgent) hints(host string) ([]byte, error) {
+	intended, err := a.intended(host)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.Agent.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*agent.Key
+	for _, k := range keys {
+		l := &LoadedKey{Comment: k.Comment}
+		if intended[l.ID()] {
+			matched = append(matched, k)
+		}
+	}
+
+	result := []byte{agentSuccess}
+	result = append(result, ssh.Marshal(struct{ N uint32 }{uint32(len(matched))})...)
+	for _, k := range matched {
+		result = append(result, ssh.Marshal(hintKey{Blob: k.Blob, Comment: k.Comment})...)
+	}
+	return result, nil
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *HintAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Agent.Sign(key, data)
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *HintAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if extensionType == HostHintsExtension {
+		var req hintRequest
+		if err := ssh.Unmarshal(contents, &req); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidHintRequest, err)
+		}
+		rsp, err := a.hints(req.Host)
+		if err != nil {
+			jsutil.LogDebug("HintAgent: failed to find keys for host %s: %v", req.Host, err)
+			return nil, err
+		}
+		return rsp, nil
+	}
+
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
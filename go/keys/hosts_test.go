@@ -0,0 +1,262 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestMatchHost(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{pattern: "github.com", host: "github.com", want: true},
+		{pattern: "github.com", host: "GitHub.com", want: true},
+		{pattern: "github.com", host: "gitlab.com", want: false},
+		{pattern: "*.example.com", host: "db.example.com", want: true},
+		{pattern: "*.example.com", host: "a.b.example.com", want: true},
+		{pattern: "*.example.com", host: "example.com", want: false},
+		{pattern: "web?", host: "web1", want: true},
+		{pattern: "web?", host: "web10", want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := matchHost(tc.pattern, tc.host); got != tc.want {
+			t.Errorf("matchHost(%q, %q) = %t, want %t", tc.pattern, tc.host, got, tc.want)
+		}
+	}
+}
+
+func TestSetHosts(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		byID        ID
+		byName      string
+		hosts       []string
+		wantHosts   []string
+		wantErr     error
+	}{
+		{
+			description: "set hosts",
+			byName:      "good-key",
+			hosts:       []string{"github.com", "*.example.com"},
+			wantHosts:   []string{"*.example.com", "github.com"},
+		},
+		{
+			description: "normalize hosts",
+			byName:      "good-key",
+			hosts:       []string{" GitHub.com ", "", "github.com"},
+			wantHosts:   []string{"github.com"},
+		},
+		{
+			description: "clear hosts",
+			byName:      "good-key",
+			hosts:       []string{""},
+		},
+		{
+			description: "reject invalid host",
+			byName:      "good-key",
+			hosts:       []string{"user@github.com"},
+			wantErr:     errInvalidHost,
+		},
+		{
+			description: "reject pattern with brackets",
+			byName:      "good-key",
+			hosts:       []string{"web[0-9]"},
+			wantErr:     errInvalidHost,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			hosts:       []string{"github.com"},
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, tc.byName)
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.SetHosts(ctx, id, tc.hosts)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to enumerate configured keys: %v", err)
+				}
+				var gotHosts []string
+				for _, k := range configured {
+					gotHosts = append(gotHosts, k.Hosts...)
+				}
+				if diff := cmp.Diff(gotHosts, tc.wantHosts); diff != "" {
+					t.Errorf("incorrect hosts; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+// parseHints parses the response to a HostHintsExtension request, returning
+// the comments of the keys.
+func parseHints(t *testing.T, rsp []byte) []string {
+	t.Helper()
+
+	if len(rsp) == 0 || rsp[0] != agentSuccess {
+		t.Fatalf("response is not SSH_AGENT_SUCCESS: %v", rsp)
+	}
+	var hdr struct {
+		N    uint32
+		Rest []byte `ssh:"rest"`
+	}
+	if err := ssh.Unmarshal(rsp[1:], &hdr); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	var result []string
+	rest := hdr.Rest
+	for i := uint32(0); i < hdr.N; i++ {
+		var k struct {
+			Blob    []byte
+			Comment string
+			Rest    []byte `ssh:"rest"`
+		}
+		if err := ssh.Unmarshal(rest, &k); err != nil {
+			t.Fatalf("failed to parse key %d: %v", i, err)
+		}
+		result = append(result, k.Comment)
+		rest = k.Rest
+	}
+	return result
+}
+
+func TestHintAgent(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agt, syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "github-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "work-key",
+				PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "unloaded-key",
+				PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		comments := map[ID]string{}
+		for name, hosts := range map[string][]string{
+			"github-key":   {"github.com"},
+			"work-key":     {"*.example.com"},
+			"unloaded-key": {"github.com"},
+		} {
+			id, err := findKey(ctx, mgr, InvalidID, name)
+			if err != nil {
+				t.Fatalf("failed to find key: %v", err)
+			}
+			if err := mgr.SetHosts(ctx, id, hosts); err != nil {
+				t.Fatalf("failed to set hosts: %v", err)
+			}
+			comments[id] = name
+		}
+		loaded, err := mgr.Loaded(ctx)
+		if err != nil {
+			t.Fatalf("failed to list loaded keys: %v", err)
+		}
+		names := map[string]string{}
+		for _, l := range loaded {
+			names[l.Comment] = comments[l.ID()]
+		}
+
+		a := NewHintAgent(agt, mgr)
+		for _, tc := range []struct {
+			host string
+			want []string
+		}{
+			{host: "github.com", want: []string{"github-key"}},
+			{host: "db.example.com", want: []string{"work-key"}},
+			{host: "gitlab.com"},
+		} {
+			rsp, err := a.Extension(HostHintsExtension, ssh.Marshal(hintRequest{Host: tc.host}))
+			if err != nil {
+				t.Errorf("Extension(%s) failed: %v", tc.host, err)
+				continue
+			}
+			var got []string
+			for _, c := range parseHints(t, rsp) {
+				got = append(got, names[c])
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect keys for host %s; -got +want: %s", tc.host, diff)
+			}
+		}
+
+		// Malformed requests are rejected.
+		if _, err := a.Extension(HostHintsExtension, []byte("bogus")); !cmp.Equal(err, errInvalidHintRequest, cmpopts.EquateErrors()) {
+			t.Errorf("incorrect error for malformed request: %v", err)
+		}
+
+		// Other extensions are passed to the wrapped agent.
+		if _, err := a.Extension("unknown@example.com", nil); !cmp.Equal(err, agent.ErrExtensionUnsupported, cmpopts.EquateErrors()) {
+			t.Errorf("incorrect error for unknown extension: %v", err)
+		}
+	})
+}
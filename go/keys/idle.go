@@ -0,0 +1,207 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Values for ConfiguredKey.IdleTimeout with special meaning.
+const (
+	// IdleTimeoutDefault applies the default idle timeout to the key.
+	IdleTimeoutDefault = 0
+	// IdleTimeoutNever keeps the key loaded regardless of how long it
+	// goes unused.
+	IdleTimeoutNever = -1
+)
+
+var (
+	errInvalidIdleTimeout = i18n.NewError("errInvalidIdleTimeout")
+)
+
+// SetIdleTimeout implements Manager.SetIdleTimeout.
+func (m *DefaultManager) SetIdleTimeout(ctx jsutil.AsyncContext, id ID, minutes int) error {
+	if minutes < IdleTimeoutNever {
+		return fmt.Errorf("%w: %d minutes", errInvalidIdleTimeout, minutes)
+	}
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.IdleTimeout = minutes }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordUse records that the loaded key with the specified public key was
+// used to sign at the specified time, restarting its idle timeout and
+// updating the time at which the configured key was last used. Keys that
+// were not loaded by the manager are ignored.
+func (m *DefaultManager) RecordUse(ctx jsutil.AsyncContext, pub ssh.PublicKey, now time.Time) error {
+	id, err := m.loadedID(ctx, pub)
+	if err != nil {
+		return err
+	}
+	if id == InvalidID {
+		return nil
+	}
+
+	byID := func(sk *sessionKey) bool { return ID(sk.ID) == id }
+	if err := m.sessionKeys.Update(ctx, byID, func(sk *sessionKey) { sk.LastUsed = now.Unix() }); err != nil {
+		return fmt.Errorf("failed to record use of key ID %s: %w", id, err)
+	}
+	return m.recordLastUsed(ctx, id, now)
+}
+
+// UnloadIdle unloads keys that have not been used to sign for longer than
+// their idle timeout, and returns their IDs. defaultTimeout applies to keys
+// that do not override it; zero disables it.
+//
+// A key that has not been used since it was loaded is considered idle from
+// the first time UnloadIdle observes it, so UnloadIdle should be invoked
+// periodically at an interval that is small relative to the timeouts.
+func (m *DefaultManager) UnloadIdle(ctx jsutil.AsyncContext, defaultTimeout time.Duration, now time.Time) ([]ID, error) {
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+	overrides := map[ID]int{}
+	for _, k := range configured {
+		overrides[ID(k.ID)] = k.IdleTimeout
+	}
+
+	sessionKeys, err := m.sessionKeys.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session keys: %w", err)
+	}
+
+	var unloaded []ID
+	var errs []error
+	for _, sk := range sessionKeys {
+		id := ID(sk.ID)
+		timeout := defaultTimeout
+		switch minutes := overrides[id]; {
+		case minutes == IdleTimeoutNever:
+			continue
+		case minutes > 0:
+			timeout = time.Duration(minutes) * time.Minute
+		}
+		if timeout <= 0 {
+			continue
+		}
+
+		if sk.LastUsed == 0 {
+			byID := func(s *sessionKey) bool { return s.ID == sk.ID }
+			if err := m.sessionKeys.Update(ctx, byID, func(s *sessionKey) { s.LastUsed = now.Unix() }); err != nil {
+				errs = append(errs, fmt.Errorf("failed to record idle start for key ID %s: %w", id, err))
+			}
+			continue
+		}
+		if now.Sub(time.Unix(sk.LastUsed, 0)) < timeout {
+			continue
+		}
+
+		jsutil.Log("DefaultManager.UnloadIdle: unloading key ID %s after %s idle", id, timeout)
+		if err := m.lockedUnload(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unload key ID %s: %w", id, err))
+			continue
+		}
+		unloaded = append(unloaded, id)
+	}
+	return unloaded, errors.Join(errs...)
+}
+
+// UsageAgent wraps an agent, and records each successful signature with
+// the manager such that idle keys can be unloaded.
+//
+// UsageAgent implements the agent.ExtendedAgent interface.
+type UsageAgent struct {
+	agent.Agent
+	mgr   *DefaultManager
+	clock clock.Clock
+}
+
+// NewUsageAgent returns a UsageAgent that records signatures made by agt
+// with mgr.
+func NewUsageAgent(agt agent.Agent, mgr *DefaultManager, clk clock.Clock) *UsageAgent {
+	return &UsageAgent{
+		Agent: agt,
+		mgr:   mgr,
+		clock: clk,
+	}
+}
+
+// record records the use of the key. Signatures are requested outside of an
+// AsyncContext, so the use is recorded asynchronously. Failures are logged;
+// they must not prevent the signature from being returned.
+func (a *UsageAgent) record(key ssh.PublicKey) {
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		if err := a.mgr.RecordUse(ctx, key, a.clock.Now()); err != nil {
+			jsutil.LogError("UsageAgent: %v", err)
+		}
+	})
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *UsageAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	sig, err := a.Agent.Sign(key, data)
+	if err == nil {
+		a.record(key)
+	}
+	return sig, err
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *UsageAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	sig, err := ext.SignWithFlags(key, data, flags)
+	if err == nil {
+		a.record(key)
+	}
+	return sig, err
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *UsageAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,247 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSetIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		byID        ID
+		minutes     int
+		want        int
+		wantErr     error
+	}{
+		{
+			description: "override default",
+			minutes:     15,
+			want:        15,
+		},
+		{
+			description: "never unload",
+			minutes:     IdleTimeoutNever,
+			want:        IdleTimeoutNever,
+		},
+		{
+			description: "restore default",
+			minutes:     IdleTimeoutDefault,
+			want:        IdleTimeoutDefault,
+		},
+		{
+			description: "fail on invalid timeout",
+			minutes:     -2,
+			wantErr:     errInvalidIdleTimeout,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			minutes:     15,
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.SetIdleTimeout(ctx, id, tc.minutes)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if len(configured) != 1 {
+					t.Fatalf("incorrect number of configured keys: got %d, want 1", len(configured))
+				}
+				if diff := cmp.Diff(configured[0].IdleTimeout, tc.want); diff != "" {
+					t.Errorf("incorrect idle timeout; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestUnloadIdle(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agt, syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "default-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "short-key",
+				PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "never-key",
+				PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private,
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		ids := map[string]ID{}
+		for _, name := range []string{"default-key", "short-key", "never-key"} {
+			if ids[name], err = findKey(ctx, mgr, InvalidID, name); err != nil {
+				t.Fatalf("failed to find key: %v", err)
+			}
+		}
+		if err := mgr.SetIdleTimeout(ctx, ids["short-key"], 5); err != nil {
+			t.Fatalf("failed to set idle timeout: %v", err)
+		}
+		if err := mgr.SetIdleTimeout(ctx, ids["never-key"], IdleTimeoutNever); err != nil {
+			t.Fatalf("failed to set idle timeout: %v", err)
+		}
+
+		start := time.Unix(1700000000, 0)
+		clk := fakes.NewClock(start)
+		usage := NewUsageAgent(agt, mgr, clk)
+
+		unloadIdle := func(elapsed time.Duration) []ID {
+			t.Helper()
+			unloaded, err := mgr.UnloadIdle(ctx, 30*time.Minute, start.Add(elapsed))
+			if err != nil {
+				t.Errorf("UnloadIdle failed: %v", err)
+			}
+			return unloaded
+		}
+
+		// Keys are considered idle from the time they are first
+		// observed.
+		if diff := cmp.Diff(unloadIdle(0), []ID(nil)); diff != "" {
+			t.Errorf("incorrect keys unloaded at start; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(unloadIdle(6*time.Minute), []ID{ids["short-key"]}); diff != "" {
+			t.Errorf("incorrect keys unloaded after override; -got +want: %s", diff)
+		}
+
+		// Signing with a key restarts its idle timeout.
+		loaded, err := agt.List()
+		if err != nil {
+			t.Fatalf("failed to list keys: %v", err)
+		}
+		clk.Advance(20 * time.Minute)
+		for _, l := range loaded {
+			if l.Comment != commentPrefix+string(ids["default-key"]) {
+				continue
+			}
+			if _, err := usage.Sign(l, []byte("data")); err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+		}
+		if diff := cmp.Diff(unloadIdle(40*time.Minute), []ID(nil)); diff != "" {
+			t.Errorf("incorrect keys unloaded after use; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(unloadIdle(51*time.Minute), []ID{ids["default-key"]}); diff != "" {
+			t.Errorf("incorrect keys unloaded after default; -got +want: %s", diff)
+		}
+
+		// Keys that are never unloaded remain, both in the agent and
+		// in session storage.
+		if diff := cmp.Diff(unloadIdle(24*time.Hour), []ID(nil)); diff != "" {
+			t.Errorf("incorrect keys unloaded after a day; -got +want: %s", diff)
+		}
+		loadedKeys, err := mgr.Loaded(ctx)
+		if err != nil {
+			t.Fatalf("failed to get loaded keys: %v", err)
+		}
+		if diff := cmp.Diff(loadedKeyIDs(loadedKeys), []ID{ids["never-key"]}); diff != "" {
+			t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+		}
+		sessionIDs, err := sessionKeyIDs(ctx, mgr.sessionKeys)
+		if err != nil {
+			t.Fatalf("failed to read session keys: %v", err)
+		}
+		sort.Slice(sessionIDs, func(i, j int) bool { return sessionIDs[i] < sessionIDs[j] })
+		if diff := cmp.Diff(sessionIDs, []ID{ids["never-key"]}); diff != "" {
+			t.Errorf("incorrect session keys; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestUnloadIdleDisabled(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "good-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		start := time.Unix(1700000000, 0)
+		for _, elapsed := range []time.Duration{0, 24 * time.Hour} {
+			unloaded, err := mgr.UnloadIdle(ctx, 0, start.Add(elapsed))
+			if err != nil {
+				t.Errorf("UnloadIdle failed: %v", err)
+			}
+			if diff := cmp.Diff(unloaded, []ID(nil)); diff != "" {
+				t.Errorf("incorrect keys unloaded; -got +want: %s", diff)
+			}
+		}
+	})
+}
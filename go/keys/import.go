@@ -0,0 +1,233 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Values for the onConflict parameter to Manager.Import, which determine how
+// a key is handled if a configured key already has the same name.
+const (
+	// ImportSkip does not import the key.
+	ImportSkip = "skip"
+	// ImportRename imports the key with a name that is not in use.
+	ImportRename = "rename"
+	// ImportReplace removes the configured keys with the same name, then
+	// imports the key.
+	ImportReplace = "replace"
+)
+
+// ImportResult describes the outcome of importing keys.
+type ImportResult struct {
+	// Imported are the names under which keys were configured.
+	Imported []string `js:"imported"`
+	// Skipped are the names of keys that were not imported, either
+	// because the private key is not included, the key is already
+	// configured, or (with ImportSkip) the name is already in use.
+	Skipped []string `js:"skipped"`
+}
+
+// zipMagic is the prefix of a zip archive.
+const zipMagic = "PK\x03\x04"
+
+// ParseBundle parses keys to be imported. data is either a JSON-encoded
+// BackupBundle (as produced by Manager.Export), or a zip archive containing
+// such bundles and/or private key files. A private key file is named after
+// the key; an OpenSSH certificate for it may be supplied in a file with the
+// same name and a '-cert.pub' suffix.
+func ParseBundle(data []byte) (*BackupBundle, error) {
+	if !bytes.HasPrefix(data, []byte(zipMagic)) {
+		return parseJSONBundle(data)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBackup, err)
+	}
+	result := &BackupBundle{Version: backupVersion}
+	files := map[string]*BackupKey{}
+	certs := map[string]string{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read %s: %v", errInvalidBackup, f.Name, err)
+		}
+		base := path.Base(f.Name)
+		switch {
+		case strings.HasSuffix(base, ".json"):
+			b, err := parseJSONBundle(content)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			result.Keys = append(result.Keys, b.Keys...)
+		case strings.HasSuffix(base, "-cert.pub"):
+			certs[strings.TrimSuffix(base, "-cert.pub")] = strings.TrimSpace(string(content))
+		case strings.HasSuffix(base, ".pub"):
+			// Public keys are derived from the private key.
+		case bytes.Contains(content, []byte("PRIVATE KEY-----")):
+			name := strings.TrimSuffix(base, path.Ext(base))
+			files[name] = &BackupKey{Name: name, PrivateKey: string(content)}
+		}
+	}
+
+	// Add keys from individual files in a consistent order.
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		k := files[name]
+		k.Certificate = certs[name]
+		result.Keys = append(result.Keys, k)
+	}
+	return result, nil
+}
+
+// readZipFile returns the content of a file in a zip archive.
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// parseJSONBundle parses a JSON-encoded BackupBundle.
+func parseJSONBundle(data []byte) (*BackupBundle, error) {
+	var b BackupBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBackup, err)
+	}
+	if b.Version != backupVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", errInvalidBackup, b.Version)
+	}
+	return &b, nil
+}
+
+// Import implements Manager.Import.
+func (m *DefaultManager) Import(ctx jsutil.AsyncContext, data []byte, onConflict string) (*ImportResult, error) {
+	switch onConflict {
+	case ImportSkip, ImportRename, ImportReplace:
+	default:
+		return nil, fmt.Errorf("%w: unknown conflict handling %q", errInvalidBackup, onConflict)
+	}
+
+	b, err := ParseBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.importBundle(ctx, b, onConflict)
+}
+
+// importBundle configures the keys in the bundle that are not already
+// configured. Name conflicts are handled as specified by onConflict. Changes
+// are made in a single transaction; if any key fails to import, none are.
+func (m *DefaultManager) importBundle(ctx jsutil.AsyncContext, b *BackupBundle, onConflict string) (*ImportResult, error) {
+	var res *ImportResult
+	err := m.inTransaction(ctx, func(tm *DefaultManager) error {
+		var err error
+		res, err = tm.importKeys(ctx, b, onConflict)
+		return err
+	})
+	if err != nil {
+		return &ImportResult{}, err
+	}
+	return res, nil
+}
+
+// importKeys implements importBundle, with changes staged by the caller.
+func (m *DefaultManager) importKeys(ctx jsutil.AsyncContext, b *BackupBundle, onConflict string) (*ImportResult, error) {
+	res := &ImportResult{}
+
+	stored, err := m.readAllStoredKeys(ctx)
+	if err != nil {
+		return res, err
+	}
+	configured := map[string]bool{}
+	names := map[string][]ID{}
+	for _, sk := range stored {
+		configured[sk.PEMPrivateKey] = true
+		names[sk.Name] = append(names[sk.Name], ID(sk.ID))
+	}
+
+	for _, bk := range b.Keys {
+		if bk.PrivateKey == "" || configured[bk.PrivateKey] {
+			res.Skipped = append(res.Skipped, bk.Name)
+			continue
+		}
+
+		name := bk.Name
+		if len(names[name]) > 0 {
+			switch onConflict {
+			case ImportSkip:
+				res.Skipped = append(res.Skipped, bk.Name)
+				continue
+			case ImportRename:
+				name = unusedName(name, names)
+			case ImportReplace:
+				for _, id := range names[name] {
+					if id == InvalidID {
+						// Imported earlier from this bundle.
+						continue
+					}
+					if err := m.remove(ctx, id); err != nil {
+						return res, fmt.Errorf("failed to replace key %s: %w", name, err)
+					}
+				}
+				delete(names, name)
+			}
+		}
+
+		pemPrivateKey := bk.PrivateKey
+		if bk.Certificate != "" {
+			pemPrivateKey += "\n" + bk.Certificate
+		}
+		if _, err := m.Add(ctx, name, pemPrivateKey); err != nil {
+			return res, fmt.Errorf("failed to import key %s: %w", name, err)
+		}
+		configured[bk.PrivateKey] = true
+		// The ID is not needed; the name is merely reserved.
+		names[name] = append(names[name], InvalidID)
+		res.Imported = append(res.Imported, name)
+	}
+	return res, nil
+}
+
+// unusedName returns a variant of name that is not in use.
+func unusedName(name string, names map[string][]ID) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if len(names[candidate]) == 0 {
+			return candidate
+		}
+	}
+}
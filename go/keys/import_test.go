@@ -0,0 +1,252 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func mustEncodeBundle(t *testing.T, keys ...*BackupKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(&BackupBundle{Version: backupVersion, Keys: keys})
+	if err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	return data
+}
+
+func mustZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseBundle(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		data        []byte
+		wantKeys    []*BackupKey
+		wantErr     error
+	}{
+		{
+			description: "json bundle",
+			data:        mustEncodeBundle(t, &BackupKey{Name: "key", PrivateKey: testdata.WithPassphrase.Private}),
+			wantKeys:    []*BackupKey{{Name: "key", PrivateKey: testdata.WithPassphrase.Private}},
+		},
+		{
+			description: "zip archive",
+			data: mustZip(t, map[string]string{
+				"keys/id_ed25519":          testdata.ED25519WithCertificate.Private,
+				"keys/id_ed25519.pub":      "ssh-ed25519 AAAA",
+				"keys/id_ed25519-cert.pub": testdata.ED25519WithCertificate.Certificate + "\n",
+				"keys/id_rsa.pem":          testdata.WithPassphrase.Private,
+				"keys/README":              "not a key",
+				"bundle.json":              string(mustEncodeBundle(t, &BackupKey{Name: "bundled", PrivateKey: testdata.WithoutPassphrase.Private})),
+			}),
+			wantKeys: []*BackupKey{
+				{Name: "bundled", PrivateKey: testdata.WithoutPassphrase.Private},
+				{Name: "id_ed25519", PrivateKey: testdata.ED25519WithCertificate.Private, Certificate: testdata.ED25519WithCertificate.Certificate},
+				{Name: "id_rsa", PrivateKey: testdata.WithPassphrase.Private},
+			},
+		},
+		{
+			description: "not a bundle",
+			data:        []byte("bogus"),
+			wantErr:     errInvalidBackup,
+		},
+		{
+			description: "unsupported version",
+			data:        []byte(`{"version": 100}`),
+			wantErr:     errInvalidBackup,
+		},
+		{
+			description: "invalid zip archive",
+			data:        []byte(zipMagic + "bogus"),
+			wantErr:     errInvalidBackup,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := ParseBundle(tc.data)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			var got []*BackupKey
+			if b != nil {
+				got = b.Keys
+			}
+			if diff := cmp.Diff(got, tc.wantKeys); diff != "" {
+				t.Errorf("incorrect keys; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	bundle := mustEncodeBundle(t,
+		&BackupKey{Name: "existing", PrivateKey: testdata.ED25519WithPassphrase.Private},
+		&BackupKey{Name: "same-key", PrivateKey: testdata.WithPassphrase.Private},
+		&BackupKey{Name: "new", PrivateKey: testdata.ECDSAWithPassphrase.Private},
+		&BackupKey{Name: "public-only", PublicKey: "ssh-ed25519 AAAA"},
+	)
+	initial := []*initialKey{
+		{Name: "existing", PEMPrivateKey: testdata.WithoutPassphrase.Private},
+		{Name: "original", PEMPrivateKey: testdata.WithPassphrase.Private},
+	}
+
+	testcases := []struct {
+		description    string
+		onConflict     string
+		wantResult     *ImportResult
+		wantConfigured []string
+		wantErr        error
+	}{
+		{
+			description: "skip conflicting names",
+			onConflict:  ImportSkip,
+			wantResult: &ImportResult{
+				Imported: []string{"new"},
+				Skipped:  []string{"existing", "same-key", "public-only"},
+			},
+			wantConfigured: []string{"existing", "new", "original"},
+		},
+		{
+			description: "rename conflicting names",
+			onConflict:  ImportRename,
+			wantResult: &ImportResult{
+				Imported: []string{"existing (2)", "new"},
+				Skipped:  []string{"same-key", "public-only"},
+			},
+			wantConfigured: []string{"existing", "existing (2)", "new", "original"},
+		},
+		{
+			description: "replace conflicting names",
+			onConflict:  ImportReplace,
+			wantResult: &ImportResult{
+				Imported: []string{"existing", "new"},
+				Skipped:  []string{"same-key", "public-only"},
+			},
+			wantConfigured: []string{"existing", "new", "original"},
+		},
+		{
+			description:    "unknown conflict handling",
+			onConflict:     "bogus",
+			wantConfigured: []string{"existing", "original"},
+			wantErr:        errInvalidBackup,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), initial)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+
+				res, err := mgr.Import(ctx, bundle, tc.onConflict)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(res, tc.wantResult); diff != "" {
+					t.Errorf("incorrect result; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Errorf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configuredKeyNames(configured), tc.wantConfigured); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestImportAtomic(t *testing.T) {
+	t.Parallel()
+
+	bundle := mustEncodeBundle(t,
+		&BackupKey{Name: "existing", PrivateKey: testdata.ED25519WithPassphrase.Private},
+		&BackupKey{Name: "new", PrivateKey: testdata.ECDSAWithPassphrase.Private},
+		&BackupKey{Name: "bad", PrivateKey: "not a private key"},
+	)
+	initial := []*initialKey{
+		{Name: "existing", PEMPrivateKey: testdata.WithoutPassphrase.Private},
+		{Name: "original", PEMPrivateKey: testdata.WithPassphrase.Private},
+	}
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), initial)
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		// The last key fails to import, so neither the replacement nor
+		// the addition of earlier keys are applied.
+		res, err := mgr.Import(ctx, bundle, ImportReplace)
+		if err == nil {
+			t.Errorf("Import unexpectedly succeeded")
+		}
+		if diff := cmp.Diff(res, &ImportResult{}); diff != "" {
+			t.Errorf("incorrect result; -got +want: %s", diff)
+		}
+
+		configured, err := mgr.Configured(ctx)
+		if err != nil {
+			t.Errorf("failed to get configured keys: %v", err)
+		}
+		if diff := cmp.Diff(configuredKeyNames(configured), []string{"existing", "original"}); diff != "" {
+			t.Errorf("incorrect configured keys; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/ppk"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errNotPrivateKey = i18n.NewError("errNotPrivateKey")
+)
+
+// KeyInfo describes a private key, as determined when it is configured.
+type KeyInfo struct {
+	// Type is the type of key (e.g., 'ssh-ed25519'). Empty if it cannot be
+	// determined without the passphrase.
+	Type string `js:"type"`
+	// Bits is the size of the key in bits. Zero if it cannot be determined
+	// without the passphrase.
+	Bits int `js:"bits"`
+	// Encrypted indicates that a passphrase is required to load the key.
+	Encrypted bool `js:"encrypted"`
+}
+
+// inspectKey checks that a PEM-encoded private key (without any certificate)
+// can be parsed, and describes it. Encrypted keys are described as far as is
+// possible without the passphrase.
+func inspectKey(pemPrivateKey string) (*KeyInfo, error) {
+	if err := checkAlgorithm(pemPrivateKey); err != nil {
+		return nil, err
+	}
+
+	sk := &storedKey{PEMPrivateKey: pemPrivateKey}
+
+	// Keys backed by security keys hold no secrets, and can always be
+	// parsed.
+	if securitykey.IsSecurityKey([]byte(pemPrivateKey)) {
+		k, err := securitykey.ParsePrivateKey([]byte(pemPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errParseFailed, err)
+		}
+		return describePublicKey(k.PublicKey(), false), nil
+	}
+
+	// The public key of a PuTTY key is not encrypted.
+	if ppk.IsPPK([]byte(pemPrivateKey)) {
+		k, err := ppk.Parse([]byte(pemPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errNotPrivateKey, err)
+		}
+		if !k.Encrypted() {
+			if _, err := k.Decrypt(""); err != nil {
+				return nil, fmt.Errorf("%w: %w", errNotPrivateKey, err)
+			}
+		}
+		return describePublicKey(k.PublicKey(), k.Encrypted()), nil
+	}
+
+	// Crypto libraries don't yet support encrypted PKCS#8 keys, so nothing
+	// more can be determined about them; see decryptKey.
+	if sk.EncryptedPKCS8() {
+		return &KeyInfo{Encrypted: true}, nil
+	}
+
+	priv, err := ssh.ParseRawPrivateKey([]byte(pemPrivateKey))
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		// The public key of an encrypted OpenSSH key is not encrypted;
+		// for other formats, it is unavailable.
+		if missing.PublicKey == nil {
+			return &KeyInfo{Encrypted: true}, nil
+		}
+		return describePublicKey(missing.PublicKey, true), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotPrivateKey, err)
+	}
+
+	pub, err := publicKeyOf(priv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotPrivateKey, err)
+	}
+	return describePublicKey(pub, false), nil
+}
+
+// describePublicKey returns a KeyInfo describing the supplied public key.
+func describePublicKey(pub ssh.PublicKey, encrypted bool) *KeyInfo {
+	info := &KeyInfo{
+		Type:      pub.Type(),
+		Encrypted: encrypted,
+	}
+	cpk, ok := pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return info
+	}
+	switch k := cpk.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		info.Bits = k.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.Bits = k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		info.Bits = 8 * ed25519.PublicKeySize
+	}
+	return info
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestInspectKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		want        *KeyInfo
+		wantErr     error
+	}{
+		{
+			description: "unencrypted RSA key",
+			key:         testdata.WithoutPassphrase.Private,
+			want:        &KeyInfo{Type: "ssh-rsa", Bits: 2048},
+		},
+		{
+			description: "encrypted RSA key",
+			key:         testdata.WithPassphrase.Private,
+			want:        &KeyInfo{Encrypted: true},
+		},
+		{
+			description: "unencrypted OpenSSH key",
+			key:         testdata.ED25519WithoutPassphrase.Private,
+			want:        &KeyInfo{Type: "ssh-ed25519", Bits: 256},
+		},
+		{
+			description: "encrypted OpenSSH key",
+			key:         testdata.ED25519WithPassphrase.Private,
+			want:        &KeyInfo{Type: "ssh-ed25519", Bits: 256, Encrypted: true},
+		},
+		{
+			description: "encrypted PKCS#8 key",
+			key:         testdata.PKCS8Format.Private,
+			want:        &KeyInfo{Encrypted: true},
+		},
+		{
+			description: "unencrypted ECDSA key",
+			key:         testdata.ECDSAWithoutPassphrase.Private,
+			want:        &KeyInfo{Type: "ecdsa-sha2-nistp521", Bits: 521},
+		},
+		{
+			description: "encrypted PuTTY key",
+			key:         testdata.PPKv3ED25519WithPassphrase.Private,
+			want:        &KeyInfo{Type: "ssh-ed25519", Bits: 256, Encrypted: true},
+		},
+		{
+			description: "unencrypted PuTTY key",
+			key:         testdata.PPKv3ECDSAWithoutPassphrase.Private,
+			want:        &KeyInfo{Type: "ecdsa-sha2-nistp256", Bits: 256},
+		},
+		{
+			description: "corrupted PuTTY key",
+			key:         strings.Replace(testdata.PPKv3ECDSAWithoutPassphrase.Private, "Comment: ppk-test", "Comment: modified", 1),
+			wantErr:     errNotPrivateKey,
+		},
+		{
+			description: "security key",
+			key:         testdata.SecurityKeyECDSA.Private,
+			want:        &KeyInfo{Type: "sk-ecdsa-sha2-nistp256@openssh.com", Bits: 256},
+		},
+		{
+			description: "unsupported security key",
+			key:         testdata.SecurityKeyED25519.Private,
+			wantErr:     errParseFailed,
+		},
+		{
+			description: "not a private key",
+			key:         bogusPEMKey,
+			wantErr:     errNotPrivateKey,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := inspectKey(tc.key)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect key info; -got +want: %s", diff)
+			}
+		})
+	}
+}
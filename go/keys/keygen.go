@@ -0,0 +1,141 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+)
+
+// Key types that may be generated.
+const (
+	KeyTypeED25519 = "ed25519"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeRSA     = "rsa"
+)
+
+const (
+	// defaultECDSABits is the curve size used for ECDSA keys if none is
+	// specified.
+	defaultECDSABits = 256
+	// defaultRSABits is the size of RSA keys if none is specified; this
+	// matches ssh-keygen.
+	defaultRSABits = 3072
+	// minRSABits and maxRSABits bound the size of RSA keys.
+	minRSABits = 2048
+	maxRSABits = 8192
+)
+
+var (
+	errInvalidKeyType      = i18n.NewError("errInvalidKeyType")
+	errPassphraseRequired  = i18n.NewError("errPassphraseRequired")
+	errKeyGenerationFailed = i18n.NewError("errKeyGenerationFailed")
+)
+
+// newPrivateKey generates a private key of the specified type. bits is the
+// size of the key; if zero, a default is used.
+func newPrivateKey(keyType string, bits int) (crypto.PrivateKey, error) {
+	switch keyType {
+	case KeyTypeED25519:
+		if bits != 0 && bits != 256 {
+			return nil, fmt.Errorf("%w: ed25519 keys are 256 bits", errInvalidKeyType)
+		}
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	case KeyTypeECDSA:
+		if bits == 0 {
+			bits = defaultECDSABits
+		}
+		var curve elliptic.Curve
+		switch bits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: ecdsa keys must be 256, 384 or 521 bits", errInvalidKeyType)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case KeyTypeRSA:
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits < minRSABits || bits > maxRSABits {
+			return nil, fmt.Errorf("%w: rsa keys must be between %d and %d bits", errInvalidKeyType, minRSABits, maxRSABits)
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return nil, fmt.Errorf("%w: %q", errInvalidKeyType, keyType)
+	}
+}
+
+// GenerateKey returns a new private key of the specified type, encrypted with
+// the passphrase and PEM-encoded in the OpenSSH format, along with the
+// public key in authorized_keys format. bits is the size of the key; if zero,
+// a default is used. comment is recorded in both keys.
+func GenerateKey(keyType string, bits int, passphrase, comment string) (pemPrivateKey, authorizedKey string, err error) {
+	if passphrase == "" {
+		return "", "", errPassphraseRequired
+	}
+
+	priv, err := newPrivateKey(keyType, bits)
+	if err != nil {
+		return "", "", err
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, comment, []byte(passphrase))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: failed to encode private key: %v", errKeyGenerationFailed, err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: failed to derive public key: %v", errKeyGenerationFailed, err)
+	}
+	authorizedKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+	return string(pem.EncodeToMemory(block)), authorizedKey, nil
+}
+
+// Generate implements Manager.Generate.
+func (m *DefaultManager) Generate(ctx jsutil.AsyncContext, name, keyType string, bits int, passphrase string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: name must not be empty", errInvalidName)
+	}
+
+	pemPrivateKey, authorizedKey, err := GenerateKey(keyType, bits, passphrase, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := m.Add(ctx, name, pemPrivateKey); err != nil {
+		return "", err
+	}
+	return authorizedKey, nil
+}
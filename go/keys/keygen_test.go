@@ -0,0 +1,217 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestGenerateKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		keyType     string
+		bits        int
+		passphrase  string
+		wantType    string
+		wantErr     error
+	}{
+		{
+			description: "ed25519",
+			keyType:     KeyTypeED25519,
+			passphrase:  "secret",
+			wantType:    ssh.KeyAlgoED25519,
+		},
+		{
+			description: "ecdsa default size",
+			keyType:     KeyTypeECDSA,
+			passphrase:  "secret",
+			wantType:    ssh.KeyAlgoECDSA256,
+		},
+		{
+			description: "ecdsa 384",
+			keyType:     KeyTypeECDSA,
+			bits:        384,
+			passphrase:  "secret",
+			wantType:    ssh.KeyAlgoECDSA384,
+		},
+		{
+			description: "rsa 2048",
+			keyType:     KeyTypeRSA,
+			bits:        2048,
+			passphrase:  "secret",
+			wantType:    ssh.KeyAlgoRSA,
+		},
+		{
+			description: "reject unknown type",
+			keyType:     "dsa",
+			passphrase:  "secret",
+			wantErr:     errInvalidKeyType,
+		},
+		{
+			description: "reject invalid ecdsa size",
+			keyType:     KeyTypeECDSA,
+			bits:        512,
+			passphrase:  "secret",
+			wantErr:     errInvalidKeyType,
+		},
+		{
+			description: "reject small rsa key",
+			keyType:     KeyTypeRSA,
+			bits:        1024,
+			passphrase:  "secret",
+			wantErr:     errInvalidKeyType,
+		},
+		{
+			description: "reject empty passphrase",
+			keyType:     KeyTypeED25519,
+			wantErr:     errPassphraseRequired,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			priv, pub, err := GenerateKey(tc.keyType, tc.bits, tc.passphrase, "comment")
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			wantPub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(pub))
+			if err != nil {
+				t.Fatalf("failed to parse public key: %v", err)
+			}
+			if diff := cmp.Diff(wantPub.Type(), tc.wantType); diff != "" {
+				t.Errorf("incorrect key type; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(comment, "comment"); diff != "" {
+				t.Errorf("incorrect comment; -got +want: %s", diff)
+			}
+
+			if _, err := ssh.ParseRawPrivateKey([]byte(priv)); err == nil {
+				t.Errorf("private key unexpectedly parsed without passphrase")
+			}
+			signer, err := ssh.ParsePrivateKeyWithPassphrase([]byte(priv), []byte(tc.passphrase))
+			if err != nil {
+				t.Fatalf("failed to parse private key: %v", err)
+			}
+			if diff := cmp.Diff(signer.PublicKey().Marshal(), wantPub.Marshal()); diff != "" {
+				t.Errorf("private key does not match public key; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description    string
+		name           string
+		keyType        string
+		passphrase     string
+		wantConfigured []string
+		wantErr        error
+	}{
+		{
+			description:    "generate key",
+			name:           "new-key",
+			keyType:        KeyTypeED25519,
+			passphrase:     "secret",
+			wantConfigured: []string{"new-key"},
+		},
+		{
+			description: "reject invalid name",
+			keyType:     KeyTypeED25519,
+			passphrase:  "secret",
+			wantErr:     errInvalidName,
+		},
+		{
+			description: "reject invalid type",
+			name:        "new-key",
+			keyType:     "dsa",
+			passphrase:  "secret",
+			wantErr:     errInvalidKeyType,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+
+				pub, err := mgr.Generate(ctx, tc.name, tc.keyType, 0, tc.passphrase)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				names := configuredKeyNames(configured)
+				if diff := cmp.Diff(names, tc.wantConfigured); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+				if len(configured) == 0 {
+					return
+				}
+
+				// The generated key must be loadable with the passphrase,
+				// and match the returned public key.
+				if err := mgr.Load(ctx, ID(configured[0].ID), tc.passphrase); err != nil {
+					t.Fatalf("failed to load generated key: %v", err)
+				}
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if len(loaded) != 1 {
+					t.Fatalf("incorrect number of loaded keys: got %d, want 1", len(loaded))
+				}
+				wantPub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pub))
+				if err != nil {
+					t.Fatalf("failed to parse public key: %v", err)
+				}
+				if diff := cmp.Diff(loaded[0].Blob(), wantPub.Marshal()); diff != "" {
+					t.Errorf("loaded key does not match public key; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
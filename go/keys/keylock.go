@@ -0,0 +1,83 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/lock"
+)
+
+const (
+	// keyLockPrefix prefixes the name of the lock held while operating on
+	// a key; see withKeyLock.
+	keyLockPrefix = "key/"
+
+	// keyLockTimeout is the maximum time to wait for the lock on a key.
+	// Operations holding the lock do not wait on the user, so this is only
+	// exceeded if an operation is stuck.
+	keyLockTimeout = time.Minute
+)
+
+// withKeyLock invokes f while holding the lock for the key with the specified
+// ID. Loading, unloading and removing a key each read state (e.g., the keys
+// loaded into the agent), and then modify it across several asynchronous
+// steps; the lock ensures that concurrent operations on the same key (e.g.,
+// from the popup and the options page) do not interleave. Operations on
+// different keys proceed concurrently.
+//
+// The lock is not reentrant; f must not invoke another operation that takes
+// the lock for the same key.
+func (m *DefaultManager) withKeyLock(ctx jsutil.AsyncContext, id ID, f func(ctx jsutil.AsyncContext) error) error {
+	var err error
+	lerr := lock.WithLockTimeout(ctx, keyLockPrefix+string(id), keyLockTimeout, func(ctx jsutil.AsyncContext) error {
+		err = f(ctx)
+		return nil
+	})
+	if lerr != nil {
+		return fmt.Errorf("failed to lock key ID %s: %w", id, lerr)
+	}
+	return err
+}
+
+// isLoaded returns true if the key with the specified ID is loaded into the
+// agent, and stored in the session such that it remains loaded if the
+// background worker restarts.
+func (m *DefaultManager) isLoaded(ctx jsutil.AsyncContext, id ID) (bool, error) {
+	loaded, err := m.agent.List()
+	if err != nil {
+		return false, fmt.Errorf("failed to list loaded keys: %w", err)
+	}
+	found := false
+	for _, l := range loaded {
+		lk := LoadedKey{Comment: l.Comment}
+		if lk.ID() == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	sk, err := m.sessionKeys.Read(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id })
+	if err != nil {
+		return false, fmt.Errorf("failed to read session key: %w", err)
+	}
+	return sk != nil, nil
+}
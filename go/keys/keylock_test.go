@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		load        bool
+		op          func(ctx jsutil.AsyncContext, mgr *DefaultManager, id ID) error
+		wantLoaded  []string
+		wantStored  bool
+	}{
+		{
+			description: "load",
+			op: func(ctx jsutil.AsyncContext, mgr *DefaultManager, id ID) error {
+				return mgr.Load(ctx, id, testdata.WithPassphrase.Passphrase)
+			},
+			wantLoaded: []string{testdata.WithPassphrase.Blob},
+			wantStored: true,
+		},
+		{
+			description: "unload",
+			load:        true,
+			op: func(ctx jsutil.AsyncContext, mgr *DefaultManager, id ID) error {
+				return mgr.Unload(ctx, id)
+			},
+			wantStored: true,
+		},
+		{
+			description: "remove",
+			op: func(ctx jsutil.AsyncContext, mgr *DefaultManager, id ID) error {
+				return mgr.Remove(ctx, id)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+						Load:          tc.load,
+						Passphrase:    testdata.WithPassphrase.Passphrase,
+					},
+				})
+				if err != nil {
+					t.Errorf("failed to initialize manager: %v", err)
+					return
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "good-key")
+				if err != nil {
+					t.Errorf("failed to find key: %v", err)
+					return
+				}
+
+				// Start the operations concurrently, as if invoked
+				// from different pages. Each must succeed.
+				const concurrent = 5
+				var promises []*jsutil.Promise
+				for i := 0; i < concurrent; i++ {
+					promises = append(promises, jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+						return js.Undefined(), tc.op(ctx, mgr, id)
+					}))
+				}
+				for _, p := range promises {
+					if _, err := p.Await(ctx); err != nil {
+						t.Errorf("operation failed: %v", err)
+					}
+				}
+
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Errorf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(loadedKeyBlobs(loaded), tc.wantLoaded); diff != "" {
+					t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+				}
+				sessionKeys, err := sessionKeyIDs(ctx, mgr.sessionKeys)
+				if err != nil {
+					t.Errorf("failed to get session keys: %v", err)
+				}
+				if diff := cmp.Diff(sessionKeys, loadedKeyIDs(loaded), idSlice); diff != "" {
+					t.Errorf("incorrect session keys; -got +want: %s", diff)
+				}
+				stored, err := mgr.readStoredKey(ctx, id)
+				if err != nil {
+					t.Errorf("failed to read key: %v", err)
+				}
+				if diff := cmp.Diff(stored != nil, tc.wantStored); diff != "" {
+					t.Errorf("incorrect stored state; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestIdempotentOperations(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "good-key",
+				PEMPrivateKey: testdata.WithPassphrase.Private,
+				Load:          true,
+				Passphrase:    testdata.WithPassphrase.Passphrase,
+			},
+		})
+		if err != nil {
+			t.Errorf("failed to initialize manager: %v", err)
+			return
+		}
+		id, err := findKey(ctx, mgr, InvalidID, "good-key")
+		if err != nil {
+			t.Errorf("failed to find key: %v", err)
+			return
+		}
+
+		// The passphrase is not needed to load a key that is already
+		// loaded.
+		if err := mgr.Load(ctx, id, "incorrect passphrase"); err != nil {
+			t.Errorf("failed to load loaded key: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := mgr.Unload(ctx, id); err != nil {
+				t.Errorf("unload %d failed: %v", i, err)
+			}
+		}
+		for i := 0; i < 2; i++ {
+			if err := mgr.Remove(ctx, id); err != nil {
+				t.Errorf("remove %d failed: %v", i, err)
+			}
+		}
+	})
+}
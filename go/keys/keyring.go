@@ -0,0 +1,242 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// DefaultKeyring is the keyring to which keys belong unless they are
+	// assigned to another.
+	DefaultKeyring = "default"
+	// AllKeyrings selects the keys in every keyring, for
+	// KeyringAgent.
+	AllKeyrings = ""
+	// maxKeyringLength is the maximum length of a keyring's name, in
+	// characters.
+	maxKeyringLength = 64
+)
+
+var (
+	// ErrKeyNotInKeyring indicates that a client requested a signature
+	// with a key outside the active keyring.
+	ErrKeyNotInKeyring = i18n.NewError("errKeyNotInKeyring")
+
+	errInvalidKeyring = i18n.NewError("errInvalidKeyring")
+)
+
+// keyringOf returns the keyring to which a stored key belongs.
+func keyringOf(sk *storedKey) string {
+	if sk.Keyring == "" {
+		return DefaultKeyring
+	}
+	return sk.Keyring
+}
+
+// ValidateKeyring returns an error if keyring is not a valid name for a
+// keyring.
+func ValidateKeyring(keyring string) error {
+	if keyring == "" || keyring != strings.TrimSpace(keyring) || utf8.RuneCountInString(keyring) > maxKeyringLength {
+		return fmt.Errorf("%w: must be between 1 and %d characters", errInvalidKeyring, maxKeyringLength)
+	}
+	return nil
+}
+
+// SetKeyring implements Manager.SetKeyring.
+func (m *DefaultManager) SetKeyring(ctx jsutil.AsyncContext, id ID, keyring string) error {
+	keyring = strings.TrimSpace(keyring)
+	if err := ValidateKeyring(keyring); err != nil {
+		return err
+	}
+	if keyring == DefaultKeyring {
+		// Stored as empty, such that keys configured before
+		// keyrings existed are in the default keyring.
+		keyring = ""
+	}
+
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Keyring = keyring }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// Keyrings returns the names of the keyrings to which the specified keys
+// belong, in order. The default keyring is always included first.
+func Keyrings(configured []*ConfiguredKey) []string {
+	seen := map[string]bool{DefaultKeyring: true}
+	var named []string
+	for _, k := range configured {
+		if !seen[k.Keyring] {
+			seen[k.Keyring] = true
+			named = append(named, k.Keyring)
+		}
+	}
+	sort.Strings(named)
+	return append([]string{DefaultKeyring}, named...)
+}
+
+// KeyringAgent wraps the agent for a single connection, exposing only the
+// keys in the active keyring: configured keys in other keyrings are hidden
+// and cannot be used for signing. Keys that are not configured (e.g., those
+// added by clients) belong to no keyring, and are always exposed. The active
+// keyring is read on every request, so changes take effect without the
+// client reconnecting.
+//
+// KeyringAgent implements the agent.ExtendedAgent interface.
+type KeyringAgent struct {
+	agent.Agent
+	mgr    *DefaultManager
+	active func(ctx jsutil.AsyncContext) (string, error)
+}
+
+// NewKeyringAgent returns a KeyringAgent. active returns the name of the
+// active keyring, or AllKeyrings to expose the keys in every keyring.
+func NewKeyringAgent(agt agent.Agent, mgr *DefaultManager, active func(ctx jsutil.AsyncContext) (string, error)) *KeyringAgent {
+	return &KeyringAgent{
+		Agent:  agt,
+		mgr:    mgr,
+		active: active,
+	}
+}
+
+// hidden returns the IDs of the configured keys outside the active keyring.
+// Requests are served outside of an AsyncContext, so they are read
+// asynchronously.
+func (a *KeyringAgent) hidden() (map[ID]bool, error) {
+	result := map[ID]bool{}
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		var active string
+		active, err = a.active(ctx)
+		if err != nil || active == AllKeyrings {
+			return
+		}
+		var configured []*ConfiguredKey
+		configured, err = a.mgr.Configured(ctx)
+		for _, k := range configured {
+			if k.Keyring != active {
+				result[ID(k.ID)] = true
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine active keyring: %w", err)
+	}
+	return result, nil
+}
+
+// isHidden returns true if the loaded key is a configured key that is
+// hidden.
+func isHidden(k *agent.Key, hidden map[ID]bool) bool {
+	l := &LoadedKey{Comment: k.Comment}
+	return hidden[l.ID()]
+}
+
+// List implements agent.Agent.List.
+func (a *KeyringAgent) List() ([]*agent.Key, error) {
+	hidden, err := a.hidden()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.Agent.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*agent.Key
+	for _, k := range keys {
+		if !isHidden(k, hidden) {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+// check returns an error if the key is hidden.
+func (a *KeyringAgent) check(key ssh.PublicKey) error {
+	hidden, err := a.hidden()
+	if err != nil {
+		return err
+	}
+	if len(hidden) == 0 {
+		return nil
+	}
+	keys, err := a.Agent.List()
+	if err != nil {
+		return err
+	}
+	blob := key.Marshal()
+	for _, k := range keys {
+		if bytes.Equal(k.Blob, blob) && isHidden(k, hidden) {
+			return fmt.Errorf("%w: key %s", ErrKeyNotInKeyring, ssh.FingerprintSHA256(key))
+		}
+	}
+	return nil
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *KeyringAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if err := a.check(key); err != nil {
+		return nil, err
+	}
+	return a.Agent.Sign(key, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *KeyringAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Sign(key, data)
+	}
+	if err := a.check(key); err != nil {
+		return nil, err
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *KeyringAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
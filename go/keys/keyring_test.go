@@ -0,0 +1,244 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSetKeyring(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		byID        ID
+		keyring     string
+		wantKeyring string
+		wantErr     error
+	}{
+		{
+			description: "named keyring",
+			keyring:     " work ",
+			wantKeyring: "work",
+		},
+		{
+			description: "default keyring",
+			keyring:     DefaultKeyring,
+			wantKeyring: DefaultKeyring,
+		},
+		{
+			description: "fail on empty name",
+			keyring:     " ",
+			wantKeyring: DefaultKeyring,
+			wantErr:     errInvalidKeyring,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			keyring:     "work",
+			wantKeyring: DefaultKeyring,
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.SetKeyring(ctx, id, tc.keyring)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if len(configured) != 1 {
+					t.Fatalf("incorrect number of configured keys: got %d, want 1", len(configured))
+				}
+				if diff := cmp.Diff(configured[0].Keyring, tc.wantKeyring); diff != "" {
+					t.Errorf("incorrect keyring; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestKeyrings(t *testing.T) {
+	t.Parallel()
+
+	configured := []*ConfiguredKey{
+		{Keyring: "work"},
+		{Keyring: DefaultKeyring},
+		{Keyring: "personal"},
+		{Keyring: "work"},
+	}
+	if diff := cmp.Diff(Keyrings(configured), []string{DefaultKeyring, "personal", "work"}); diff != "" {
+		t.Errorf("incorrect keyrings; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(Keyrings(nil), []string{DefaultKeyring}); diff != "" {
+		t.Errorf("incorrect keyrings without keys; -got +want: %s", diff)
+	}
+}
+
+func TestKeyringAgent(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		active      string
+		wantList    []string
+		wantErr     map[string]error
+	}{
+		{
+			description: "all keyrings",
+			active:      AllKeyrings,
+			wantList:    []string{"client-key", "personal-key", "work-key"},
+		},
+		{
+			description: "named keyring",
+			active:      "work",
+			wantList:    []string{"client-key", "work-key"},
+			wantErr:     map[string]error{"personal-key": ErrKeyNotInKeyring},
+		},
+		{
+			description: "default keyring",
+			active:      DefaultKeyring,
+			wantList:    []string{"client-key", "personal-key"},
+			wantErr:     map[string]error{"work-key": ErrKeyNotInKeyring},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				agt := agent.NewKeyring()
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agt, syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "work-key",
+						PEMPrivateKey: testdata.WithoutPassphrase.Private,
+						Load:          true,
+					},
+					{
+						Name:          "personal-key",
+						PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+						Load:          true,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				workID, err := findKey(ctx, mgr, InvalidID, "work-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+				if err := mgr.SetKeyring(ctx, workID, "work"); err != nil {
+					t.Fatalf("failed to set keyring: %v", err)
+				}
+
+				// A key added by a client belongs to no keyring.
+				priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+				if err != nil {
+					t.Fatalf("failed to parse key: %v", err)
+				}
+				if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "client-key"}); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+
+				// Name each loaded key by its configured name, or its
+				// comment if it is not configured.
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to list loaded keys: %v", err)
+				}
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				names := map[string]string{}
+				pubs := map[string]ssh.PublicKey{}
+				for _, l := range loaded {
+					name := l.Comment
+					for _, c := range configured {
+						if ID(c.ID) == l.ID() {
+							name = c.Name
+						}
+					}
+					pub, err := ssh.ParsePublicKey(l.Blob())
+					if err != nil {
+						t.Fatalf("failed to parse public key: %v", err)
+					}
+					names[string(l.Blob())] = name
+					pubs[name] = pub
+				}
+
+				a := NewKeyringAgent(agt, mgr, func(jsutil.AsyncContext) (string, error) { return tc.active, nil })
+				listed, err := a.List()
+				if err != nil {
+					t.Fatalf("List() failed: %v", err)
+				}
+				var gotList []string
+				for _, k := range listed {
+					gotList = append(gotList, names[string(k.Blob)])
+				}
+				if diff := cmp.Diff(gotList, tc.wantList, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect keys listed; -got +want: %s", diff)
+				}
+
+				for name, pub := range pubs {
+					_, err := a.Sign(pub, []byte("data"))
+					if want := tc.wantErr[name]; !errors.Is(err, want) {
+						t.Errorf("Sign(%s) returned incorrect error: got %v, want %v", name, err, want)
+					}
+				}
+			})
+		})
+	}
+}
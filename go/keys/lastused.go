@@ -0,0 +1,79 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// keyUse is the raw object stored in local storage recording when a
+// configured key was last used to sign on this device. It is stored
+// separately from the key so that signing does not write to synced storage,
+// which limits the rate of writes.
+type keyUse struct {
+	ID string `js:"id"`
+	// LastUsed is when the key was last used to sign, in seconds since
+	// the Unix epoch.
+	LastUsed int64 `js:"lastUsed"`
+}
+
+var (
+	// keyUsePrefixes is the prefix for records of key use in local
+	// storage.
+	keyUsePrefixes = []string{"keyUse"}
+)
+
+// recordLastUsed records that the configured key with the specified ID was
+// used to sign at the specified time.
+func (m *DefaultManager) recordLastUsed(ctx jsutil.AsyncContext, id ID, now time.Time) error {
+	byID := func(u *keyUse) bool { return ID(u.ID) == id }
+	existing, err := m.keyUses.Read(ctx, byID)
+	if err != nil {
+		return fmt.Errorf("failed to read last use of key ID %s: %w", id, err)
+	}
+	if existing == nil {
+		err = m.keyUses.Write(ctx, &keyUse{ID: string(id), LastUsed: now.Unix()})
+	} else {
+		err = m.keyUses.Update(ctx, byID, func(u *keyUse) { u.LastUsed = now.Unix() })
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record last use of key ID %s: %w", id, err)
+	}
+	return nil
+}
+
+// lastUsed returns the time at which each configured key was last used to
+// sign on this device, in seconds since the Unix epoch, indexed by ID. Keys
+// that have not been used are absent.
+func (m *DefaultManager) lastUsed(ctx jsutil.AsyncContext) (map[string]int64, error) {
+	uses, err := m.keyUses.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last use of keys: %w", err)
+	}
+	result := map[string]int64{}
+	for _, u := range uses {
+		// Concurrent signatures may have recorded the first use of a
+		// key more than once; the latest applies.
+		if u.LastUsed > result[u.ID] {
+			result[u.ID] = u.LastUsed
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestLastUsed(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agt, syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "used-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "unused-key",
+				PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		usedID, err := findKey(ctx, mgr, InvalidID, "used-key")
+		if err != nil {
+			t.Fatalf("failed to find key: %v", err)
+		}
+
+		lastUsed := func() map[string]int64 {
+			t.Helper()
+			configured, err := mgr.Configured(ctx)
+			if err != nil {
+				t.Fatalf("failed to get configured keys: %v", err)
+			}
+			result := map[string]int64{}
+			for _, k := range configured {
+				result[k.Name] = k.LastUsed
+			}
+			return result
+		}
+
+		start := time.Unix(1700000000, 0)
+		clk := fakes.NewClock(start)
+		usage := NewUsageAgent(agt, mgr, clk)
+		sign := func() {
+			t.Helper()
+			loaded, err := agt.List()
+			if err != nil {
+				t.Fatalf("failed to list keys: %v", err)
+			}
+			for _, l := range loaded {
+				if l.Comment != commentPrefix+string(usedID) {
+					continue
+				}
+				if _, err := usage.Sign(l, []byte("data")); err != nil {
+					t.Fatalf("failed to sign: %v", err)
+				}
+			}
+		}
+
+		if diff := cmp.Diff(lastUsed(), map[string]int64{"used-key": 0, "unused-key": 0}); diff != "" {
+			t.Errorf("incorrect last use before signing; -got +want: %s", diff)
+		}
+
+		sign()
+		if diff := cmp.Diff(lastUsed(), map[string]int64{"used-key": start.Unix(), "unused-key": 0}); diff != "" {
+			t.Errorf("incorrect last use after signing; -got +want: %s", diff)
+		}
+
+		// The time is retained after the key is unloaded, and updated
+		// on subsequent use.
+		clk.Advance(time.Hour)
+		sign()
+		if err := mgr.Unload(ctx, usedID); err != nil {
+			t.Fatalf("failed to unload key: %v", err)
+		}
+		if diff := cmp.Diff(lastUsed(), map[string]int64{"used-key": start.Add(time.Hour).Unix(), "unused-key": 0}); diff != "" {
+			t.Errorf("incorrect last use after signing again; -got +want: %s", diff)
+		}
+
+		// The record is removed along with the key.
+		if err := mgr.Remove(ctx, usedID); err != nil {
+			t.Fatalf("failed to remove key: %v", err)
+		}
+		uses, err := mgr.keyUses.ReadAll(ctx)
+		if err != nil {
+			t.Fatalf("failed to read last use of keys: %v", err)
+		}
+		if len(uses) != 0 {
+			t.Errorf("incorrect records of last use after removal: got %d, want 0", len(uses))
+		}
+	})
+}
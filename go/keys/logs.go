@@ -0,0 +1,53 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// LogEntry is a single entry logged by the background worker.
+type LogEntry struct {
+	// Time is the time at which the entry was logged, in milliseconds
+	// since the Unix epoch.
+	Time int64 `js:"time"`
+	// Level is the severity of the entry: 'INFO', 'ERROR' or 'DEBUG'.
+	Level string `js:"level"`
+	// Message is the logged message.
+	Message string `js:"message"`
+}
+
+// LogBuffer retains the entries recently logged by the background worker, so
+// they can be viewed without access to its console.
+type LogBuffer interface {
+	// Entries returns the retained entries, oldest first.
+	Entries(ctx jsutil.AsyncContext) ([]*LogEntry, error)
+}
+
+// SetLogBuffer configures the buffer in which entries logged by the
+// background worker are retained.
+func (m *DefaultManager) SetLogBuffer(b LogBuffer) {
+	m.logBuffer = b
+}
+
+// LogEntries implements Manager.LogEntries.
+func (m *DefaultManager) LogEntries(ctx jsutil.AsyncContext) ([]*LogEntry, error) {
+	if m.logBuffer == nil {
+		return nil, nil
+	}
+	return m.logBuffer.Entries(ctx)
+}
@@ -0,0 +1,110 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/pem"
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/ppk"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+var (
+	errMalformedKey = i18n.NewError("errMalformedKey")
+)
+
+// Validate implements storage.Validator. It checks that the fields required to
+// use the key are present; keys that fail validation are reported by
+// Manager.Malformed rather than Manager.Configured.
+func (s *storedKey) Validate() error {
+	if s.ID == "" {
+		return fmt.Errorf("%w: missing ID", errMalformedKey)
+	}
+	if s.Name == "" {
+		return fmt.Errorf("%w: missing name", errMalformedKey)
+	}
+	if block, _ := pem.Decode([]byte(s.PEMPrivateKey)); block == nil && !ppk.IsPPK([]byte(s.PEMPrivateKey)) {
+		return fmt.Errorf("%w: private key is not PEM-encoded", errMalformedKey)
+	}
+	return nil
+}
+
+// MalformedKey describes a stored key that cannot be used because it could
+// not be read, or is missing required fields. This may happen if the key was
+// written by an incompatible version of the extension.
+type MalformedKey struct {
+	// StorageKey identifies the key's record in storage.
+	StorageKey string `js:"storageKey"`
+	// Name is the key's name, if it could be determined.
+	Name string `js:"name"`
+	// Local indicates that the key is in local-only storage.
+	Local bool `js:"local"`
+	// Reason describes why the key is malformed.
+	Reason string `js:"reason"`
+}
+
+// Malformed implements Manager.Malformed.
+func (m *DefaultManager) Malformed(ctx jsutil.AsyncContext) ([]*MalformedKey, error) {
+	var result []*MalformedKey
+	for _, loc := range []struct {
+		keys  *storage.Typed[storedKey]
+		local bool
+	}{
+		{m.storedKeys, false},
+		{m.localKeys, true},
+	} {
+		malformed, err := loc.keys.Malformed(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keys: %w", err)
+		}
+		for _, mk := range malformed {
+			result = append(result, &MalformedKey{
+				StorageKey: mk.Key,
+				Name:       malformedName(mk),
+				Local:      loc.local,
+				Reason:     mk.Err.Error(),
+			})
+		}
+	}
+	return result, nil
+}
+
+// malformedName makes a best-effort attempt to determine the name of a
+// malformed key.
+func malformedName(mk *storage.Malformed) string {
+	if mk.Value.Type() != js.TypeObject {
+		return ""
+	}
+	name := mk.Value.Get("name")
+	if name.Type() != js.TypeString {
+		return ""
+	}
+	return name.String()
+}
+
+// RemoveMalformed implements Manager.RemoveMalformed.
+func (m *DefaultManager) RemoveMalformed(ctx jsutil.AsyncContext, storageKey string, local bool) error {
+	keys := m.storedKeys
+	if local {
+		keys = m.localKeys
+	}
+	return keys.DeleteMalformed(ctx, storageKey)
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"sort"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestMalformed(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description    string
+		local          bool
+		records        map[string]js.Value
+		wantMalformed  []*MalformedKey
+		wantConfigured []string
+	}{
+		{
+			description:    "no malformed keys",
+			wantConfigured: []string{"good-key"},
+		},
+		{
+			description: "missing ID",
+			records: map[string]js.Value{
+				"key.1": js.ValueOf(map[string]any{
+					"name":          "old-key",
+					"pemPrivateKey": testdata.WithPassphrase.Private,
+				}),
+			},
+			wantMalformed: []*MalformedKey{
+				{StorageKey: "1", Name: "old-key", Reason: "invalid value: malformed key: missing ID"},
+			},
+			wantConfigured: []string{"good-key"},
+		},
+		{
+			description: "private key not PEM-encoded",
+			records: map[string]js.Value{
+				"key.1": js.ValueOf(map[string]any{
+					"id":            "1",
+					"name":          "old-key",
+					"pemPrivateKey": "bogus-key-data",
+				}),
+			},
+			wantMalformed: []*MalformedKey{
+				{StorageKey: "1", Name: "old-key", Reason: "invalid value: malformed key: private key is not PEM-encoded"},
+			},
+			wantConfigured: []string{"good-key"},
+		},
+		{
+			description: "local key",
+			local:       true,
+			records: map[string]js.Value{
+				"key.1": js.ValueOf(map[string]any{
+					"id":            "1",
+					"pemPrivateKey": testdata.WithPassphrase.Private,
+				}),
+			},
+			wantMalformed: []*MalformedKey{
+				{StorageKey: "1", Local: true, Reason: "invalid value: malformed key: missing name"},
+			},
+			wantConfigured: []string{"good-key"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				localStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, sessionStorage)
+				if _, err := mgr.Add(ctx, "good-key", testdata.WithPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				area := syncStorage
+				if tc.local {
+					area = localStorage
+				}
+				if err := area.Set(ctx, tc.records); err != nil {
+					t.Fatalf("failed to write records: %v", err)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configuredKeyNames(configured), tc.wantConfigured); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+
+				malformed, err := mgr.Malformed(ctx)
+				if err != nil {
+					t.Fatalf("failed to get malformed keys: %v", err)
+				}
+				sort.Slice(malformed, func(i, j int) bool { return malformed[i].StorageKey < malformed[j].StorageKey })
+				if diff := cmp.Diff(malformed, tc.wantMalformed); diff != "" {
+					t.Errorf("incorrect malformed keys; -got +want: %s", diff)
+				}
+
+				// Valid keys cannot be removed as malformed.
+				configuredData, err := syncStorage.Get(ctx)
+				if err != nil {
+					t.Fatalf("failed to read storage: %v", err)
+				}
+				for k := range configuredData {
+					if _, ok := tc.records[k]; ok {
+						continue
+					}
+					err := mgr.RemoveMalformed(ctx, k[len("key."):], false)
+					if !errors.Is(err, storage.ErrNotMalformed) {
+						t.Errorf("incorrect error removing valid key; got %v, want %v", err, storage.ErrNotMalformed)
+					}
+				}
+
+				for _, mk := range malformed {
+					if err := mgr.RemoveMalformed(ctx, mk.StorageKey, mk.Local); err != nil {
+						t.Errorf("failed to remove malformed key: %v", err)
+					}
+				}
+				malformed, err = mgr.Malformed(ctx)
+				if err != nil {
+					t.Fatalf("failed to get malformed keys: %v", err)
+				}
+				if len(malformed) != 0 {
+					t.Errorf("malformed keys not removed: %v", malformed)
+				}
+
+				configured, err = mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configuredKeyNames(configured), tc.wantConfigured); diff != "" {
+					t.Errorf("incorrect configured keys after removal; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -19,6 +19,7 @@
 package keys
 
 import (
+	"bytes"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/x509"
@@ -28,10 +29,18 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"reflect"
 	"strings"
+	"sync/atomic"
+	"syscall/js"
+	"time"
 
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/ppk"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
 	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
 	"github.com/youmark/pkcs8"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
@@ -54,6 +63,55 @@ type ConfiguredKey struct {
 	// Encrypted indicates if the key is encrypted and requires a passphrase
 	// to load.
 	Encrypted bool `js:"encrypted"`
+	// Local indicates if the key is stored only on the local device (i.e.,
+	// it is not synced between the user's devices).
+	Local bool `js:"local"`
+	// Certificate describes the certificate associated with the key. Nil
+	// if the key does not have a certificate.
+	Certificate *CertificateInfo `js:"certificate"`
+	// AutoLoad indicates that the key is loaded whenever the agent
+	// starts. Only unencrypted keys may be loaded automatically.
+	AutoLoad bool `js:"autoLoad"`
+	// IdleTimeout is the number of minutes the key may go unused before
+	// it is unloaded, overriding the default. IdleTimeoutDefault applies
+	// the default, and IdleTimeoutNever keeps the key loaded.
+	IdleTimeout int `js:"idleTimeout"`
+	// Confirm indicates that the user must confirm each signature made
+	// with the key.
+	Confirm bool `js:"confirm"`
+	// Notify indicates that the user is notified of each signature made,
+	// or refused, with the key.
+	Notify bool `js:"notify"`
+	// Fingerprint is the SHA256 fingerprint of the key. Empty if it
+	// cannot be determined without the passphrase.
+	Fingerprint string `js:"fingerprint"`
+	// PublicKey is the public key in authorized_keys format. Empty if it
+	// cannot be determined without the passphrase.
+	PublicKey string `js:"publicKey"`
+	// ChecksumMismatch indicates that the key material no longer matches
+	// the checksum recorded when it was pinned. The key cannot be loaded
+	// until it is re-pinned.
+	ChecksumMismatch bool `js:"checksumMismatch"`
+	// Conflict indicates that another configured key has the same name;
+	// for example, because it was added on another of the user's devices.
+	// The user should choose which to keep.
+	Conflict bool `js:"conflict"`
+	// LastUsed is when the key was last used to sign on this device, in
+	// seconds since the Unix epoch. Zero if it has not been used.
+	LastUsed int64 `js:"lastUsed"`
+	// Keyring is the keyring to which the key belongs; DefaultKeyring
+	// unless the key was assigned to another.
+	Keyring string `js:"keyring"`
+	// Persist indicates that the key remains loaded after the browser
+	// restarts. The decrypted key is then stored on disk.
+	Persist bool `js:"persist"`
+	// Tags are the labels the user attached to the key to group it with
+	// others, in order. Empty if the key has none.
+	Tags []string `js:"tags"`
+	// Hosts are the patterns (e.g., '*.example.com') matching the hosts
+	// for which the key is intended, in order. Empty if the user did not
+	// specify any.
+	Hosts []string `js:"hosts"`
 }
 
 // LoadedKey is a key loaded into the agent.
@@ -65,19 +123,52 @@ type LoadedKey struct {
 	InternalBlob string `js:"blob"`
 	// Comment is a comment for the loaded key.
 	Comment string `js:"comment"`
+	// Fingerprint is the SHA256 fingerprint of the loaded key. As with
+	// 'ssh-add -l', the fingerprint of a certificate is that of the
+	// underlying key.
+	Fingerprint string `js:"fingerprint"`
+	// Captured indicates that a client added the key, and that it was
+	// captured such that it can be adopted; see Manager.AdoptLoaded.
+	Captured bool `js:"captured"`
+}
+
+// Snapshot describes the configured keys and the keys loaded into the agent,
+// as read together.
+type Snapshot struct {
+	// Configured is the full set of keys that are configured.
+	Configured []*ConfiguredKey `js:"configured"`
+	// Loaded is the full set of keys loaded into the agent.
+	Loaded []*LoadedKey `js:"loaded"`
+	// Locked indicates that a client locked the agent, in which case no
+	// keys are reported as loaded.
+	Locked bool `js:"locked"`
 }
 
 // SetBlob sets the given public key material for the loaded key.
 func (k *LoadedKey) SetBlob(b []byte) {
-	// Store as base64-encoded string. Two simpler solutions did not appear
+	// Store as base64-encoded string. Simpler solutions did not appear
 	// to work:
 	// - Storing as a []byte resulted in data not being passed via Chrome's
 	//   messaging.
 	// - Casting to a string resulted in different data being read from the
 	//   field.
+	// - Transferring as a Uint8Array or ArrayBuffer does not survive
+	//   Chrome's messaging, which serializes messages as JSON; typed
+	//   arrays arrive as objects keyed by index. An array of numbers does
+	//   survive, but is roughly three times the size of the base64
+	//   encoding and is converted element-by-element by vert.
+	// base64 is therefore the most compact representation that can be
+	// sent over messaging. Callers that only need the encoded form (e.g.,
+	// for display) should use EncodedBlob to avoid decoding it.
 	k.InternalBlob = base64.StdEncoding.EncodeToString(b)
 }
 
+// EncodedBlob returns the base64-encoded public key material for the loaded
+// key, without decoding it.
+func (k *LoadedKey) EncodedBlob() string {
+	return k.InternalBlob
+}
+
 // Blob returns the public key material for the loaded key.
 func (k *LoadedKey) Blob() []byte {
 	b, err := base64.StdEncoding.DecodeString(k.InternalBlob)
@@ -102,6 +193,17 @@ func (k *LoadedKey) ID() ID {
 	return ID(strings.TrimPrefix(k.Comment, commentPrefix))
 }
 
+// IsCertificate returns true if the loaded key is an OpenSSH certificate.
+func (k *LoadedKey) IsCertificate() bool {
+	return strings.HasSuffix(k.Type, certTypeSuffix)
+}
+
+const (
+	// certTypeSuffix is the suffix for the types of OpenSSH certificates
+	// (e.g., 'ssh-ed25519-cert-v01@openssh.com').
+	certTypeSuffix = "-cert-v01@openssh.com"
+)
+
 // Manager provides an API for managing configured keys and loading them into
 // an SSH agent.
 type Manager interface {
@@ -109,10 +211,33 @@ type Manager interface {
 	Configured(ctx jsutil.AsyncContext) ([]*ConfiguredKey, error)
 
 	// Add configures a new key.  name is a human-readable name describing
-	// the key, and pemPrivateKey is the PEM-encoded private key.
-	Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) error
-
-	// Remove removes the key with the specified ID.
+	// the key, and pemPrivateKey is the PEM-encoded private key. The
+	// private key may optionally be followed by an OpenSSH certificate for
+	// the key (i.e., the contents of the corresponding -cert.pub file).
+	// The private key is parsed to check that it is usable, and a
+	// description of it is returned.
+	Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error)
+
+	// AddLocal configures a new key in the same way as Add, but stores it
+	// only on the local device, such that the private key is never synced
+	// between the user's devices.
+	AddLocal(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error)
+
+	// Update replaces the private key (and any certificate) of the key
+	// with the specified ID, keeping its ID, name and other settings.
+	// pemPrivateKey is in the same form as for Add. If the key is loaded,
+	// it is unloaded, such that the previous key can no longer be used.
+	Update(ctx jsutil.AsyncContext, id ID, pemPrivateKey string) error
+
+	// SetCertificate replaces the OpenSSH certificate (i.e., the contents
+	// of the -cert.pub file) of the key with the specified ID, such that a
+	// renewed certificate can be used without supplying the private key
+	// again. An empty certificate removes it. If the key is loaded, the
+	// certificate loaded into the agent is replaced.
+	SetCertificate(ctx jsutil.AsyncContext, id ID, certificate string) error
+
+	// Remove removes the key with the specified ID. The key is held for
+	// UndoPeriod, during which the removal can be undone; see UndoRemove.
 	//
 	// Note that it might be nice to return an error here, but
 	// the underlying Chrome APIs don't make it trivial to determine
@@ -124,35 +249,273 @@ type Manager interface {
 	// Loaded returns the full set of keys loaded into the agent.
 	Loaded(ctx jsutil.AsyncContext) ([]*LoadedKey, error)
 
+	// Snapshot returns the configured keys together with the keys loaded
+	// into the agent. It is equivalent to calling Configured and Loaded,
+	// but both are read at once, so callers accessing the manager via
+	// messaging need only a single round trip and do not observe changes
+	// made between the two reads.
+	Snapshot(ctx jsutil.AsyncContext) (*Snapshot, error)
+
+	// StorageUsage returns the storage used by the configured keys, and
+	// the usage and quota of the areas in which they are stored.
+	StorageUsage(ctx jsutil.AsyncContext) (*StorageUsage, error)
+
+	// Status returns the health of the background worker.
+	Status(ctx jsutil.AsyncContext) (*Status, error)
+
+	// SelfTest checks that the agent works: a temporary key is added to
+	// the agent, used to sign, and removed again. The user's keys are not
+	// affected. An error describes the step that failed.
+	SelfTest(ctx jsutil.AsyncContext) error
+
+	// RunDiagnostics checks that the agent works, both directly and
+	// through an AgentPort as used by other extensions, and reports the
+	// outcome of each check. As with SelfTest, only a temporary key is
+	// used.
+	RunDiagnostics(ctx jsutil.AsyncContext) ([]*DiagnosticResult, error)
+
+	// Connected returns the clients currently connected to the agent,
+	// ordered by the time at which they connected.
+	Connected(ctx jsutil.AsyncContext) ([]*Connection, error)
+
+	// AuditEntries returns the signatures recently requested from the
+	// agent, oldest first.
+	AuditEntries(ctx jsutil.AsyncContext) ([]*AuditEntry, error)
+
+	// ClearAuditLog removes the record of signatures requested from the
+	// agent.
+	ClearAuditLog(ctx jsutil.AsyncContext) error
+
+	// LogEntries returns the entries recently logged by the background
+	// worker, oldest first. Debug entries are included only while
+	// verbose logging is enabled.
+	LogEntries(ctx jsutil.AsyncContext) ([]*LogEntry, error)
+
 	// Load loads a new key into to the agent, using the passphrase to
 	// decrypt the private key.
 	//
 	// NOTE: Unencrypted private keys are not currently supported.
 	Load(ctx jsutil.AsyncContext, id ID, passphrase string) error
 
-	// Unload unloads a key from the agent.
+	// Unload unloads a key from the agent. The decrypted key is held for
+	// UndoPeriod, during which it can be loaded again; see UndoUnload.
 	Unload(ctx jsutil.AsyncContext, id ID) error
+
+	// UndoRemove configures the key with the specified ID again, if it
+	// was removed within UndoPeriod. It is stored in the same location
+	// as before, with the same settings.
+	UndoRemove(ctx jsutil.AsyncContext, id ID) error
+
+	// UndoUnload loads the key with the specified ID into the agent
+	// again, if it was unloaded within UndoPeriod. The passphrase is not
+	// required.
+	UndoUnload(ctx jsutil.AsyncContext, id ID) error
+
+	// SetLocal moves the key with the specified ID between storage that is
+	// synced between the user's devices (local is false) and storage that
+	// is kept only on the local device (local is true).
+	//
+	// The key is copied to the new location and verified before it is
+	// removed from its original location. If any step fails, the key is
+	// left in its original location.
+	SetLocal(ctx jsutil.AsyncContext, id ID, local bool) error
+
+	// SetAutoLoad configures whether the key with the specified ID is
+	// loaded whenever the agent starts, regardless of whether it was
+	// loaded previously. Only unencrypted keys may be loaded
+	// automatically.
+	SetAutoLoad(ctx jsutil.AsyncContext, id ID, autoLoad bool) error
+
+	// SetIdleTimeout configures the number of minutes the key with the
+	// specified ID may go unused before it is unloaded, overriding the
+	// default. IdleTimeoutDefault applies the default, and
+	// IdleTimeoutNever keeps the key loaded.
+	SetIdleTimeout(ctx jsutil.AsyncContext, id ID, minutes int) error
+
+	// SetConfirm configures whether the user must confirm each signature
+	// made with the key with the specified ID.
+	SetConfirm(ctx jsutil.AsyncContext, id ID, confirm bool) error
+
+	// SetNotify configures whether the user is notified of each signature
+	// made, or refused, with the key with the specified ID.
+	SetNotify(ctx jsutil.AsyncContext, id ID, notify bool) error
+
+	// SetKeyring assigns the key with the specified ID to the named
+	// keyring. Clients are only offered the keys in the active keyring;
+	// see KeyringAgent.
+	SetKeyring(ctx jsutil.AsyncContext, id ID, keyring string) error
+
+	// SetTags replaces the tags attached to the key with the specified
+	// ID. Tags are trimmed, and duplicates (ignoring case) are removed;
+	// an empty list removes all tags.
+	SetTags(ctx jsutil.AsyncContext, id ID, tags []string) error
+
+	// UnloadTagged unloads the keys with the specified tag from the
+	// agent, and returns the IDs of those that were unloaded. Keys are
+	// loaded by tag using Load, since each may require its passphrase.
+	UnloadTagged(ctx jsutil.AsyncContext, tag string) ([]ID, error)
+
+	// SetHosts replaces the patterns matching the hosts for which the key
+	// with the specified ID is intended. Patterns are hostnames, and may
+	// contain '*' and '?' wildcards; an empty list removes all patterns.
+	// Clients may ask which keys are intended for a host; see HintAgent.
+	SetHosts(ctx jsutil.AsyncContext, id ID, hosts []string) error
+
+	// SetPersist configures whether the key with the specified ID, once
+	// loaded, remains loaded after the browser restarts. The decrypted
+	// key is then stored on disk, rather than only in memory.
+	SetPersist(ctx jsutil.AsyncContext, id ID, persist bool) error
+
+	// AdoptLoaded configures a key that a client added to the agent
+	// (e.g., using 'ssh-add'), and loads it in place of the client's
+	// copy. blob identifies the loaded key; see LoadedKey.EncodedBlob.
+	// Only keys that were captured when added can be adopted; see
+	// LoadedKey.Captured. If name is already in use, a variant of it is
+	// used.
+	AdoptLoaded(ctx jsutil.AsyncContext, blob, name string) error
+
+	// Malformed returns the stored keys that cannot be used because they
+	// could not be read or are missing required fields. Such keys are
+	// not included in Configured.
+	Malformed(ctx jsutil.AsyncContext) ([]*MalformedKey, error)
+
+	// RemoveMalformed removes a malformed key from storage. storageKey
+	// and local identify the key as returned by Malformed.
+	RemoveMalformed(ctx jsutil.AsyncContext, storageKey string, local bool) error
+
+	// KeysEncrypted returns true if the private keys in storage that is
+	// synced between the user's devices are encrypted.
+	KeysEncrypted(ctx jsutil.AsyncContext) (bool, error)
+
+	// SetKeysEncrypted configures whether the private keys in storage that
+	// is synced between the user's devices are encrypted (using the master
+	// password that protects saved passphrases), and rewrites the keys
+	// accordingly. The master password must have been entered. While
+	// keys are encrypted, they can only be used once the master password
+	// has been entered.
+	SetKeysEncrypted(ctx jsutil.AsyncContext, encrypted bool) error
+
+	// CheckSync returns nil if storage that is synced between the user's
+	// devices is available. Otherwise, an error describing why it is
+	// unavailable is returned; keys are then stored only on the local
+	// device.
+	CheckSync(ctx jsutil.AsyncContext) error
+
+	// Repin updates the checksum pinning the key material for the key
+	// with the specified ID, such that it can be loaded again after the
+	// material legitimately changed.
+	Repin(ctx jsutil.AsyncContext, id ID) error
+
+	// Export returns the configured keys as a JSON-encoded BackupBundle,
+	// so they can be moved to another browser or profile. Private keys
+	// are included only if includePrivate is true, in which case password
+	// must be the master password if one is set; see SetPasswordVerifier.
+	// An empty password is accepted if the master password was entered
+	// within the re-authentication window (see SetReauthWindow);
+	// otherwise ErrReauthRequired is returned.
+	Export(ctx jsutil.AsyncContext, includePrivate bool, password string) ([]byte, error)
+
+	// Import configures the keys in data, which is parsed by ParseBundle.
+	// Keys that are already configured are skipped. onConflict (one of
+	// the Import* values) determines how a key is handled if a
+	// configured key has the same name. If any key fails to import, no
+	// changes are made.
+	Import(ctx jsutil.AsyncContext, data []byte, onConflict string) (*ImportResult, error)
+
+	// Generate creates a new key of the specified type (one of the
+	// KeyType* values) and size in bits (or zero for the default), and
+	// configures it with the specified name. The private key is encrypted
+	// with passphrase, which must not be empty. The public key is
+	// returned in authorized_keys format.
+	Generate(ctx jsutil.AsyncContext, name, keyType string, bits int, passphrase string) (string, error)
 }
 
 // NewManager returns a Manager implementation that can manage keys in the
-// supplied agent, and store configured keys in the supplied storage.
-func NewManager(agt agent.Agent, syncStorage, sessionStorage storage.Area) *DefaultManager {
-	return &DefaultManager{
+// supplied agent, and store configured keys in the supplied storage. Keys are
+// stored in syncStorage unless the user requests that they only be stored in
+// localStorage.
+//
+// If syncStorage is unavailable (e.g., in a guest profile), the manager
+// operates in local-only mode: new keys are stored in localStorage, and keys
+// cannot be moved to syncStorage.
+func NewManager(agt agent.Agent, syncStorage, localStorage, sessionStorage storage.Area) *DefaultManager {
+	optionalSync := storage.NewOptional(syncStorage)
+	m := &DefaultManager{
 		agent:          agt,
-		syncStorage:    syncStorage,
+		syncStorage:    optionalSync,
+		localStorage:   localStorage,
 		sessionStorage: sessionStorage,
-		storedKeys:     storage.NewTyped[storedKey](syncStorage, storedKeyPrefixes),
+		storedKeys:     storage.NewTyped[storedKey](optionalSync, storedKeyPrefixes),
+		localKeys:      storage.NewTyped[storedKey](localStorage, storedKeyPrefixes),
 		sessionKeys:    storage.NewTyped[sessionKey](sessionStorage, sessionKeyPrefixes),
+		journal:        storage.NewJournal(sessionStorage, journalPrefixes),
+		keyUses:        storage.NewTyped[keyUse](localStorage, keyUsePrefixes),
+		persistedKeys:  storage.NewTyped[sessionKey](localStorage, persistedKeyPrefixes),
+		capturedKeys:   storage.NewTyped[capturedKey](sessionStorage, capturedKeyPrefixes),
+		heldKeys:       storage.NewTyped[heldKey](sessionStorage, heldKeyPrefixes),
+		agentLock:      storage.NewTyped[agentLock](sessionStorage, agentLockPrefixes),
+		agentLocked:    &atomic.Bool{},
+		lastVerified:   &atomic.Int64{},
+		started:        time.Now(),
 	}
+	m.journal.Register(journalOpLoad, m.recoverLoad)
+	m.journal.Register(journalOpUnload, m.recoverUnload)
+	return m
 }
 
 // DefaultManager is an implementation of Manager.
 type DefaultManager struct {
 	agent          agent.Agent
-	syncStorage    storage.Area
+	syncStorage    *storage.Optional
+	localStorage   storage.Area
 	sessionStorage storage.Area
 	storedKeys     *storage.Typed[storedKey]
+	localKeys      *storage.Typed[storedKey]
 	sessionKeys    *storage.Typed[sessionKey]
+	journal        *storage.Journal
+	keyUses        *storage.Typed[keyUse]
+	persistedKeys  *storage.Typed[sessionKey]
+	capturedKeys   *storage.Typed[capturedKey]
+	heldKeys       *storage.Typed[heldKey]
+	// agentLock records in session storage that a client locked the
+	// agent; see LockAgent.
+	agentLock *storage.Typed[agentLock]
+	// agentLocked indicates that a client locked the agent; see
+	// LockAgent. It is shared with copies made by inTransaction.
+	agentLocked *atomic.Bool
+	// started is the time at which the manager was created; that is,
+	// when the background worker started.
+	started time.Time
+	// connections returns the clients currently connected to the agent,
+	// or is nil if they are not known.
+	connections ConnectionSource
+	// auditLog records the signatures requested from the agent, or nil
+	// if they are not recorded.
+	auditLog AuditLog
+	// logBuffer retains the entries logged by the background worker, or
+	// is nil if they are not retained.
+	logBuffer LogBuffer
+	// encryptedSync encrypts the keys in syncStorage, or is nil if they
+	// cannot be encrypted.
+	encryptedSync *storage.Encrypted
+	// keySource supplies the key from which checksums pinning key
+	// material are derived, or is nil if they are unkeyed.
+	keySource storage.KeySource
+	// passwordVerifier verifies the master password required to export
+	// private keys, or is nil if none is required.
+	passwordVerifier PasswordVerifier
+	// reauthWindow returns the time during which private keys may be
+	// exported again without the master password, or is nil if it is
+	// required for every export.
+	reauthWindow ReauthWindowSource
+	// lastVerified is the time, in nanoseconds since the Unix epoch, at
+	// which the master password was last entered to export private
+	// keys, or zero if it has not been. It is shared with copies made by
+	// inTransaction.
+	lastVerified *atomic.Int64
+	// diagnostics runs the checks reported by RunDiagnostics, or is nil
+	// to run the default checks.
+	diagnostics DiagnosticsRunner
 }
 
 // storedKey is the raw object stored in persistent storage for a configured
@@ -161,6 +524,65 @@ type storedKey struct {
 	ID            string `js:"id"`
 	Name          string `js:"name"`
 	PEMPrivateKey string `js:"pemPrivateKey"`
+	// Certificate is an OpenSSH certificate for the key, or empty if
+	// there is none.
+	Certificate string `js:"certificate"`
+	// AutoLoad indicates that the key is loaded whenever the agent
+	// starts.
+	AutoLoad bool `js:"autoLoad"`
+	// IdleTimeout overrides the default idle timeout; see
+	// ConfiguredKey.IdleTimeout.
+	IdleTimeout int `js:"idleTimeout"`
+	// Confirm indicates that the user must confirm each signature made
+	// with the key.
+	Confirm bool `js:"confirm"`
+	// Notify indicates that the user is notified of each signature made,
+	// or refused, with the key.
+	Notify bool `js:"notify"`
+	// Checksum pins the key material, or is empty if it is not pinned.
+	Checksum string `js:"checksum"`
+	// Keyring is the keyring to which the key belongs, or empty for
+	// DefaultKeyring.
+	Keyring string `js:"keyring"`
+	// Persist indicates that the key, once loaded, remains loaded after
+	// the browser restarts.
+	Persist bool `js:"persist"`
+	// Tags are the labels attached to the key, in order.
+	Tags []string `js:"tags"`
+	// Hosts are the patterns matching the hosts for which the key is
+	// intended, in order.
+	Hosts []string `js:"hosts"`
+}
+
+// equal returns true if the stored keys are identical. A key without tags or
+// hosts equals one with empty lists of them, since storage does not
+// distinguish them.
+func (s *storedKey) equal(o *storedKey) bool {
+	a, b := *s, *o
+	for _, k := range []*storedKey{&a, &b} {
+		if len(k.Tags) == 0 {
+			k.Tags = nil
+		}
+		if len(k.Hosts) == 0 {
+			k.Hosts = nil
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// CertificateInfo describes the key's certificate. Nil is returned if the key
+// does not have a certificate, or it cannot be parsed.
+func (s *storedKey) CertificateInfo() *CertificateInfo {
+	if s.Certificate == "" {
+		return nil
+	}
+
+	cert, err := parseCertificate(s.Certificate)
+	if err != nil {
+		jsutil.LogError("failed to parse certificate for key ID %s: %v", s.ID, err)
+		return nil
+	}
+	return describeCertificate(cert)
 }
 
 // EncryptedPKCS8 determines if the private key is an encrypted PKCS#8 formatted
@@ -182,6 +604,12 @@ func (s *storedKey) EncryptedPKCS8() bool {
 // Encrypted determines if the private key is encrypted. The Proc-Type header
 // contains 'ENCRYPTED' if the key is encrypted. See RFC 1421 Section 4.6.1.1.
 func (s *storedKey) Encrypted() bool {
+	// PuTTY keys record whether they are encrypted in their header.
+	if ppk.IsPPK([]byte(s.PEMPrivateKey)) {
+		k, err := ppk.Parse([]byte(s.PEMPrivateKey))
+		return err == nil && k.Encrypted()
+	}
+
 	block, _ := pem.Decode([]byte(s.PEMPrivateKey))
 	if block == nil {
 		// Attempt to handle this gracefully and guess that it isn't
@@ -201,6 +629,11 @@ func (s *storedKey) Encrypted() bool {
 	// reimplement the underlying crypto libraries. Instead, just attempt to
 	// decrypt it without a passphrase.
 	if block.Type == "OPENSSH PRIVATE KEY" {
+		// Keys backed by security keys hold no secrets, and are
+		// never encrypted.
+		if securitykey.IsSecurityKey([]byte(s.PEMPrivateKey)) {
+			return false
+		}
 		_, err := ssh.ParseRawPrivateKey([]byte(s.PEMPrivateKey))
 		return err != nil
 	}
@@ -216,8 +649,13 @@ func (s *storedKey) Encrypted() bool {
 // we need to resume without re-prompting the user for their passphrase each
 // time.
 type sessionKey struct {
-	ID         string `js:"id"`
-	PrivateKey string `js:"privateKey"`
+	ID          string `js:"id"`
+	PrivateKey  string `js:"privateKey"`
+	Certificate string `js:"certificate"`
+	// LastUsed is when the key was last used to sign, in seconds since
+	// the Unix epoch. Zero if the key has not been observed since it was
+	// loaded.
+	LastUsed int64 `js:"lastUsed"`
 }
 
 var (
@@ -226,6 +664,9 @@ var (
 	// sessionKeyPrefix is the prefix for key material stored in-memory
 	// for our current session.
 	sessionKeyPrefixes = []string{"key"}
+	// journalPrefixes is the prefix for load and unload operations in
+	// progress, stored in-memory for our current session.
+	journalPrefixes = []string{"journal"}
 
 	// oldStoredKeyPrefixes are the prefixes for stored keys that we
 	// previously used which are safe to delete from storage.
@@ -271,51 +712,257 @@ func (m *DefaultManager) Configured(ctx jsutil.AsyncContext) ([]*ConfiguredKey,
 	if err != nil {
 		return nil, fmt.Errorf("failed to read keys: %w", err)
 	}
+	localKeys, err := m.localKeys.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local keys: %w", err)
+	}
+	// The time at which keys were last used is informational; keys are
+	// still reported if it cannot be read.
+	lastUsed, err := m.lastUsed(ctx)
+	if err != nil {
+		jsutil.LogError("DefaultManager.Configured: %v", err)
+	}
+	// Keys pinned with the master password are reported as mismatched
+	// only if they are known to be; until it is entered, they cannot be
+	// verified.
+	checksumKey, checksumErr := m.checksumKey(ctx)
 
 	var result []*ConfiguredKey
-	for _, k := range keys {
-		c := ConfiguredKey{
-			ID:        k.ID,
-			Name:      k.Name,
-			Encrypted: k.Encrypted(),
+	seen := map[string]bool{}
+	add := func(k *storedKey, local bool) {
+		// A key may be present in both locations if a move between
+		// them was interrupted. Report it only once; the synced copy
+		// takes precedence.
+		if seen[k.ID] {
+			return
 		}
-		result = append(result, &c)
+		seen[k.ID] = true
+		result = append(result, &ConfiguredKey{
+			ID:               k.ID,
+			Name:             k.Name,
+			Encrypted:        k.Encrypted(),
+			Local:            local,
+			Certificate:      k.CertificateInfo(),
+			AutoLoad:         k.AutoLoad,
+			IdleTimeout:      k.IdleTimeout,
+			Confirm:          k.Confirm,
+			Notify:           k.Notify,
+			Fingerprint:      k.Fingerprint(),
+			PublicKey:        k.AuthorizedKey(),
+			ChecksumMismatch: errors.Is(k.verifyChecksum(checksumKey, checksumErr), errChecksumMismatch),
+			LastUsed:         lastUsed[k.ID],
+			Keyring:          keyringOf(k),
+			Persist:          k.Persist,
+			Tags:             k.Tags,
+			Hosts:            k.Hosts,
+		})
+	}
+	for _, k := range keys {
+		add(k, false)
+	}
+	for _, k := range localKeys {
+		add(k, true)
 	}
+	markConflicts(result)
 	return result, nil
 }
 
-var errInvalidName = errors.New("invalid name")
+// readStoredKey reads the stored key with the specified ID from either
+// location. A nil key is returned if it is not found.
+func (m *DefaultManager) readStoredKey(ctx jsutil.AsyncContext, id ID) (*storedKey, error) {
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		key, err := keys.Read(ctx, func(key *storedKey) bool { return ID(key.ID) == id })
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+var errInvalidName = i18n.NewError("errInvalidName")
 
 // Add implements Manager.Add.
-func (m *DefaultManager) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) error {
+func (m *DefaultManager) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	_, info, err := m.add(ctx, name, pemPrivateKey, false)
+	return info, err
+}
+
+// AddLocal implements Manager.AddLocal.
+func (m *DefaultManager) AddLocal(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	_, info, err := m.add(ctx, name, pemPrivateKey, true)
+	return info, err
+}
+
+// add configures a new key, and returns its ID. The key is stored in synced
+// storage unless local is true or synced storage is unavailable.
+func (m *DefaultManager) add(ctx jsutil.AsyncContext, name string, pemPrivateKey string, local bool) (ID, *KeyInfo, error) {
 	if name == "" {
-		return fmt.Errorf("%w: name must not be empty", errInvalidName)
+		return InvalidID, nil, fmt.Errorf("%w: name must not be empty", errInvalidName)
+	}
+
+	pemPrivateKey, cert, err := splitCertificate(pemPrivateKey)
+	if err != nil {
+		return InvalidID, nil, err
 	}
 
 	i, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
 	if err != nil {
-		return fmt.Errorf("failed to generate new ID: %w", err)
+		return InvalidID, nil, fmt.Errorf("failed to generate new ID: %w", err)
 	}
 
 	sk := &storedKey{
 		ID:            i.String(),
 		Name:          name,
 		PEMPrivateKey: pemPrivateKey,
+		Certificate:   cert,
+	}
+	if err := sk.Validate(); err != nil {
+		return InvalidID, nil, err
 	}
-	return m.storedKeys.Write(ctx, sk)
+	info, err := inspectKey(pemPrivateKey)
+	if err != nil {
+		return InvalidID, nil, err
+	}
+	checksum, err := m.checksumFunc(ctx)
+	if err != nil {
+		return InvalidID, nil, err
+	}
+	sk.Checksum = checksum(sk)
+	store := m.storedKeys
+	if local {
+		store = m.localKeys
+	} else if err := m.CheckSync(ctx); err != nil {
+		jsutil.Log("DefaultManager.Add: storing key locally: %v", err)
+		store = m.localKeys
+	}
+	if err := store.Write(ctx, sk); err != nil {
+		return InvalidID, nil, err
+	}
+	return ID(sk.ID), info, nil
 }
 
-// Remove implements Manager.Remove.
+// Remove implements Manager.Remove. Removing a key that is not configured
+// has no effect.
 func (m *DefaultManager) Remove(ctx jsutil.AsyncContext, id ID) error {
-	return m.storedKeys.Delete(ctx, func(sk *storedKey) bool { return ID(sk.ID) == id })
+	return m.withKeyLock(ctx, id, func(ctx jsutil.AsyncContext) error {
+		return m.removeAndHold(ctx, id)
+	})
+}
+
+// removeAndHold implements Remove, once the key's lock is held.
+func (m *DefaultManager) removeAndHold(ctx jsutil.AsyncContext, id ID) error {
+	hk, err := m.heldForRemove(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.remove(ctx, id); err != nil {
+		return err
+	}
+	if hk != nil {
+		m.hold(ctx, hk)
+	}
+	return nil
+}
+
+// remove removes the key with the specified ID, without holding it such that
+// the removal can be undone.
+func (m *DefaultManager) remove(ctx jsutil.AsyncContext, id ID) error {
+	if err := m.storedKeys.Delete(ctx, func(sk *storedKey) bool { return ID(sk.ID) == id }); err != nil {
+		return err
+	}
+	if err := m.localKeys.Delete(ctx, func(sk *storedKey) bool { return ID(sk.ID) == id }); err != nil {
+		return err
+	}
+	if err := m.keyUses.Delete(ctx, func(u *keyUse) bool { return ID(u.ID) == id }); err != nil {
+		jsutil.LogError("DefaultManager.Remove: failed to remove last use of key ID %s: %v", id, err)
+	}
+	if err := m.deletePersistedKey(ctx, id); err != nil {
+		jsutil.LogError("DefaultManager.Remove: %v", err)
+	}
+	return nil
+}
+
+var (
+	errMoveFailed   = i18n.NewError("errMoveFailed")
+	errMoveRollback = i18n.NewError("errMoveRollback")
+)
+
+// SetLocal implements Manager.SetLocal.
+func (m *DefaultManager) SetLocal(ctx jsutil.AsyncContext, id ID, local bool) error {
+	if !local {
+		if err := m.CheckSync(ctx); err != nil {
+			return fmt.Errorf("%w: %w", errMoveFailed, err)
+		}
+	}
+
+	src, dst := m.storedKeys, m.localKeys
+	if !local {
+		src, dst = m.localKeys, m.storedKeys
+	}
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+
+	key, err := src.Read(ctx, byID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read key: %w", errMoveFailed, err)
+	}
+	if key == nil {
+		existing, err := dst.Read(ctx, byID)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read key: %w", errMoveFailed, err)
+		}
+		if existing != nil {
+			return nil // Already in the requested location.
+		}
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	// rollback removes any copy written to the destination. It is only safe
+	// to call while the source is still intact.
+	rollback := func(cause error) error {
+		if err := dst.Delete(ctx, byID); err != nil {
+			return fmt.Errorf("%w: %w (after %w)", errMoveRollback, err, cause)
+		}
+		return fmt.Errorf("%w: key left in original location: %w", errMoveFailed, cause)
+	}
+
+	// Copy to the destination, and verify that it was written correctly.
+	jsutil.LogDebug("DefaultManager.SetLocal: copying key %s", id)
+	if err := dst.Write(ctx, key); err != nil {
+		return rollback(fmt.Errorf("failed to write key: %w", err))
+	}
+	copied, err := dst.Read(ctx, byID)
+	if err != nil {
+		return rollback(fmt.Errorf("failed to verify key: %w", err))
+	}
+	if copied == nil || !copied.equal(key) {
+		return rollback(errors.New("failed to verify key: copy does not match original"))
+	}
+
+	// Remove from the source now that the copy is safely in place. If this
+	// fails, we intentionally leave both copies in place rather than risk
+	// losing the key; Configured() reports it only once.
+	jsutil.LogDebug("DefaultManager.SetLocal: removing original key %s", id)
+	if err := src.Delete(ctx, byID); err != nil {
+		return fmt.Errorf("%w: key copied, but failed to remove original: %w", errMoveFailed, err)
+	}
+	return nil
 }
 
 // Loaded implements Manager.Loaded.
-func (m *DefaultManager) Loaded(_ jsutil.AsyncContext) ([]*LoadedKey, error) {
+func (m *DefaultManager) Loaded(ctx jsutil.AsyncContext) ([]*LoadedKey, error) {
 	loaded, err := m.agent.List()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list loaded keys: %w", err)
 	}
+	// Whether keys can be adopted is informational; keys are still
+	// reported if it cannot be determined.
+	captured, err := m.capturedBlobs(ctx)
+	if err != nil {
+		jsutil.LogError("DefaultManager.Loaded: %v", err)
+	}
 
 	var result []*LoadedKey
 	for _, l := range loaded {
@@ -324,17 +971,102 @@ func (m *DefaultManager) Loaded(_ jsutil.AsyncContext) ([]*LoadedKey, error) {
 			Comment: l.Comment,
 		}
 		k.SetBlob(l.Marshal())
+		k.Captured = k.ID() == InvalidID && captured[k.EncodedBlob()]
+		if pub, err := ssh.ParsePublicKey(l.Marshal()); err == nil {
+			k.Fingerprint = ssh.FingerprintSHA256(plainKey(pub))
+		} else {
+			jsutil.LogError("failed to parse loaded key: %v", err)
+		}
 		result = append(result, &k)
 	}
 
 	return result, nil
 }
 
+// Snapshot implements Manager.Snapshot.
+func (m *DefaultManager) Snapshot(ctx jsutil.AsyncContext) (*Snapshot, error) {
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Configured: configured, Loaded: loaded, Locked: m.agentLocked.Load()}, nil
+}
+
 var (
-	errKeyNotFound   = errors.New("key not found")
-	errDecodeFailed  = errors.New("key decode failed")
-	errParseFailed   = errors.New("key parse failed")
-	errMarshalFailed = errors.New("key marshalling failed")
+	errAutoLoadEncrypted = i18n.NewError("errAutoLoadEncrypted")
+)
+
+var (
+	errSyncUnavailable = i18n.NewError("errSyncUnavailable")
+)
+
+// CheckSync implements Manager.CheckSync.
+func (m *DefaultManager) CheckSync(ctx jsutil.AsyncContext) error {
+	if err := m.syncStorage.Available(ctx); err != nil {
+		return fmt.Errorf("%w: %w", errSyncUnavailable, err)
+	}
+	return nil
+}
+
+// SetAutoLoad implements Manager.SetAutoLoad.
+func (m *DefaultManager) SetAutoLoad(ctx jsutil.AsyncContext, id ID, autoLoad bool) error {
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+	if autoLoad && key.Encrypted() {
+		return errAutoLoadEncrypted
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.AutoLoad = autoLoad }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// AutoLoad loads all keys configured to be loaded automatically that are not
+// already loaded. It should be invoked after LoadFromSession.
+func (m *DefaultManager) AutoLoad(ctx jsutil.AsyncContext) error {
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+	isLoaded := map[ID]bool{}
+	for _, l := range loaded {
+		isLoaded[l.ID()] = true
+	}
+
+	var errs []error
+	for _, k := range configured {
+		if !k.AutoLoad || isLoaded[ID(k.ID)] {
+			continue
+		}
+		jsutil.Log("DefaultManager.AutoLoad: loading key ID %s", k.ID)
+		if err := m.Load(ctx, ID(k.ID), ""); err != nil {
+			errs = append(errs, fmt.Errorf("failed to load key ID %s: %w", k.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	errDecodeFailed  = i18n.NewError("errDecodeFailed")
+	errParseFailed   = i18n.NewError("errParseFailed")
+	errMarshalFailed = i18n.NewError("errMarshalFailed")
 )
 
 // CleanupOldData removes storage data that is no longer required.
@@ -343,6 +1075,7 @@ func (m *DefaultManager) CleanupOldData(ctx jsutil.AsyncContext) {
 
 	areas := []storage.Area{
 		m.syncStorage,
+		m.localStorage,
 		m.sessionStorage,
 	}
 	prefixesLists := [][]string{
@@ -360,6 +1093,19 @@ func (m *DefaultManager) CleanupOldData(ctx jsutil.AsyncContext) {
 
 // LoadFromSession loads all keys for the current session into the agent.
 func (m *DefaultManager) LoadFromSession(ctx jsutil.AsyncContext) error {
+	// Recover any load or unload operations that were interrupted (e.g.,
+	// the worker was suspended) before reading the session keys.
+	jsutil.LogDebug("DefaultManager.LoadFromSession: Recover interrupted operations")
+	if err := m.journal.Recover(ctx); err != nil {
+		jsutil.LogError("failed to recover interrupted operations: %v", err)
+	}
+
+	// Restore keys that remain loaded after the browser restarts.
+	jsutil.LogDebug("DefaultManager.LoadFromSession: Restore persisted keys")
+	if err := m.restorePersisted(ctx); err != nil {
+		jsutil.LogError("failed to restore persisted keys: %v", err)
+	}
+
 	// Read session keys. We'll load these into the agent.
 	jsutil.LogDebug("DefaultManager.LoadFromSession: Read session keys")
 	sessionKeys, err := m.sessionKeys.ReadAll(ctx)
@@ -370,10 +1116,57 @@ func (m *DefaultManager) LoadFromSession(ctx jsutil.AsyncContext) error {
 	// Attempt to load each into the agent.
 	jsutil.LogDebug("DefaultManager.LoadFromSession: Load session keys")
 	for _, k := range sessionKeys {
-		if err := m.addToAgent(ID(k.ID), decryptedKey(k.PrivateKey)); err != nil {
+		if err := m.addToAgent(ID(k.ID), decryptedKey(k.PrivateKey), k.Certificate); err != nil {
 			jsutil.LogError("failed to load session key ID %s into agent: %v; skipping", k.ID, err)
 		}
 	}
+
+	// Lock the agent again if a client had locked it.
+	jsutil.LogDebug("DefaultManager.LoadFromSession: Restore agent lock")
+	if err := m.restoreAgentLock(ctx); err != nil {
+		return fmt.Errorf("failed to restore agent lock: %w", err)
+	}
+	return nil
+}
+
+var (
+	errSessionMismatch = i18n.NewError("errSessionMismatch")
+)
+
+// VerifySession checks that each key stored for the current session can be
+// parsed and is loaded into the agent; that is, that the keys would be
+// correctly restored by LoadFromSession.
+func (m *DefaultManager) VerifySession(ctx jsutil.AsyncContext) error {
+	sessionKeys, err := m.sessionKeys.ReadAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read session keys: %w", err)
+	}
+
+	loaded, err := m.agent.List()
+	if err != nil {
+		return fmt.Errorf("failed to list loaded keys: %w", err)
+	}
+
+	for _, k := range sessionKeys {
+		priv, err := parseDecryptedKey(decryptedKey(k.PrivateKey))
+		if err != nil {
+			return fmt.Errorf("failed to parse session key ID %s: %w", k.ID, err)
+		}
+		pub, err := publicKeyOf(priv)
+		if err != nil {
+			return err
+		}
+		blob := pub.Marshal()
+		found := false
+		for _, l := range loaded {
+			if bytes.Equal(l.Blob, blob) {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: ID %s", errSessionMismatch, k.ID)
+		}
+	}
 	return nil
 }
 
@@ -384,10 +1177,32 @@ const (
 )
 
 func decryptKey(key *storedKey, passphrase string) (decryptedKey, error) {
+	// Keys backed by security keys hold no secrets, and are stored in the
+	// session as-is.
+	if securitykey.IsSecurityKey([]byte(key.PEMPrivateKey)) {
+		if _, err := securitykey.ParsePrivateKey([]byte(key.PEMPrivateKey)); err != nil {
+			return "", fmt.Errorf("%w: %w", errParseFailed, err)
+		}
+		return decryptedKey(key.PEMPrivateKey), nil
+	}
+
+	// Reject legacy algorithms up front, with an explanation, rather than
+	// failing to parse or use the key.
+	if err := checkAlgorithm(key.PEMPrivateKey); err != nil {
+		return "", err
+	}
+
 	// Decode and decrypt the key.
 	var err error
 	var priv interface{}
 	switch {
+	case ppk.IsPPK([]byte(key.PEMPrivateKey)):
+		// PuTTY keys are converted to the same format as any other
+		// key once decrypted.
+		var k *ppk.Key
+		if k, err = ppk.Parse([]byte(key.PEMPrivateKey)); err == nil {
+			priv, err = k.Decrypt(passphrase)
+		}
 	case key.EncryptedPKCS8():
 		// Crypto libraries don't yet support encrypted PKCS#8 keys:
 		//   https://github.com/golang/go/issues/8860
@@ -435,35 +1250,103 @@ func decryptKey(key *storedKey, passphrase string) (decryptedKey, error) {
 	})), nil
 }
 
+// parseDecryptedKey parses a decrypted key. Keys backed by security keys are
+// returned as a *securitykey.Key.
 func parseDecryptedKey(pemPrivateKey decryptedKey) (interface{}, error) {
+	if securitykey.IsSecurityKey([]byte(pemPrivateKey)) {
+		return securitykey.ParsePrivateKey([]byte(pemPrivateKey))
+	}
 	return ssh.ParseRawPrivateKey([]byte(pemPrivateKey))
 }
 
-func (m *DefaultManager) addToAgent(id ID, key decryptedKey) error {
+// publicKeyOf returns the public key for a key returned by parseDecryptedKey.
+func publicKeyOf(priv interface{}) (ssh.PublicKey, error) {
+	if k, ok := priv.(*securitykey.Key); ok {
+		return k.PublicKey(), nil
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errParseFailed, err)
+	}
+	return signer.PublicKey(), nil
+}
+
+// addToAgent adds the key to the agent. If a certificate is supplied, the key
+// is added both with and without the certificate, just as ssh-add does; this
+// allows the key to be used with servers that do not trust the CA.
+func (m *DefaultManager) addToAgent(id ID, key decryptedKey, certificate string) error {
 	priv, err := parseDecryptedKey(key)
 	if err != nil {
 		return err
 	}
 
+	var cert *ssh.Certificate
+	if certificate != "" {
+		cert, err = parseCertificate(certificate)
+		if err != nil {
+			return err
+		}
+		pub, err := publicKeyOf(priv)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(pub.Marshal(), cert.Key.Marshal()) {
+			return errCertificateMismatch
+		}
+	}
+
+	comment := fmt.Sprintf("%s%s", commentPrefix, id)
 	err = m.agent.Add(agent.AddedKey{
 		PrivateKey: priv,
-		Comment:    fmt.Sprintf("%s%s", commentPrefix, id),
+		Comment:    comment,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to add key to agent: %w", err)
 	}
+	if cert != nil {
+		err = m.agent.Add(agent.AddedKey{
+			PrivateKey:  priv,
+			Certificate: cert,
+			Comment:     comment,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add certificate to agent: %w", err)
+		}
+	}
 	return nil
 }
 
 // Load implements Manager.Load.
 func (m *DefaultManager) Load(ctx jsutil.AsyncContext, id ID, passphrase string) error {
-	key, err := m.storedKeys.Read(ctx, func(key *storedKey) bool { return ID(key.ID) == id })
+	return m.withKeyLock(ctx, id, func(ctx jsutil.AsyncContext) error {
+		return m.load(ctx, id, passphrase)
+	})
+}
+
+// load implements Load, once the key's lock is held. Loading a key that is
+// already loaded has no effect.
+func (m *DefaultManager) load(ctx jsutil.AsyncContext, id ID, passphrase string) error {
+	key, err := m.readStoredKey(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to read key: %w", err)
 	}
 
 	if key == nil {
-		return fmt.Errorf("%w: failed to find key with ID %s", errKeyNotFound, id)
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+	checksumKey, err := m.checksumKey(ctx)
+	if err := key.verifyChecksum(checksumKey, err); err != nil {
+		return err
+	}
+	if m.agentLocked.Load() {
+		return fmt.Errorf("%w: unlock it to load keys", ErrAgentLocked)
+	}
+	// The key may have been loaded by a concurrent operation (e.g., from
+	// another page) while this one waited for the lock.
+	if loaded, err := m.isLoaded(ctx, id); err != nil {
+		return err
+	} else if loaded {
+		return nil
 	}
 
 	decrypted, err := decryptKey(key, passphrase)
@@ -471,27 +1354,114 @@ func (m *DefaultManager) Load(ctx jsutil.AsyncContext, id ID, passphrase string)
 		return fmt.Errorf("failed to decrypt key: %w", err)
 	}
 
-	if err := m.addToAgent(id, decrypted); err != nil {
+	jid, err := m.journal.Begin(ctx, journalOpLoad, vert.ValueOf(&journalData{ID: string(id)}).JSValue())
+	if err != nil {
+		return fmt.Errorf("failed to record load: %w", err)
+	}
+
+	if err := m.addToAgent(id, decrypted, key.Certificate); err != nil {
 		return err
 	}
 
 	sk := &sessionKey{
-		ID:         string(id),
-		PrivateKey: string(decrypted),
+		ID:          string(id),
+		PrivateKey:  string(decrypted),
+		Certificate: key.Certificate,
 	}
 	if err := m.sessionKeys.Write(ctx, sk); err != nil {
 		return fmt.Errorf("failed to store loaded key to session: %w", err)
 	}
+	if key.Persist {
+		if err := m.persistedKeys.Write(ctx, sk); err != nil {
+			return fmt.Errorf("failed to persist loaded key: %w", err)
+		}
+	}
+
+	if err := m.journal.Commit(ctx, jid); err != nil {
+		return fmt.Errorf("failed to record load completion: %w", err)
+	}
+
+	// The key material matched its unkeyed checksum, recorded before the
+	// master password was set; pin it with a keyed one instead.
+	if key.Pinned() && !key.keyedChecksum() && checksumKey != nil {
+		if err := m.pin(ctx, id); err != nil {
+			jsutil.LogError("failed to pin key ID %s: %v", id, err)
+		}
+	}
 	return nil
 }
 
+const (
+	// journalOpLoad identifies a Load operation in the journal.
+	journalOpLoad = "load"
+	// journalOpUnload identifies an Unload operation in the journal.
+	journalOpUnload = "unload"
+)
+
+// journalData is the data recorded in the journal for Load and Unload
+// operations.
+type journalData struct {
+	ID string `js:"id"`
+}
+
+// recoverLoad recovers an interrupted Load operation. The key may or may not
+// have been written to session storage; we roll back the operation by removing
+// it. The user can simply load the key again.
+func (m *DefaultManager) recoverLoad(ctx jsutil.AsyncContext, data js.Value) error {
+	return m.deleteSessionKey(ctx, data)
+}
+
+// recoverUnload recovers an interrupted Unload operation. The key may or may
+// not have been removed from session storage; we complete the operation by
+// removing it, such that it is not loaded into the agent again.
+func (m *DefaultManager) recoverUnload(ctx jsutil.AsyncContext, data js.Value) error {
+	return m.deleteSessionKey(ctx, data)
+}
+
+// deleteSessionKey removes the session key identified by the journal data.
+func (m *DefaultManager) deleteSessionKey(ctx jsutil.AsyncContext, data js.Value) error {
+	var jd journalData
+	if err := vert.ValueOf(data).AssignTo(&jd); err != nil {
+		return fmt.Errorf("failed to parse journal data: %w", err)
+	}
+	if err := m.sessionKeys.Delete(ctx, func(sk *sessionKey) bool { return sk.ID == jd.ID }); err != nil {
+		return fmt.Errorf("failed to delete session key: %w", err)
+	}
+	return m.deletePersistedKey(ctx, ID(jd.ID))
+}
+
 var (
-	errAgentUnloadFailed   = errors.New("key unload from agent failed")
-	errStorageUnloadFailed = errors.New("key removal from session storage failed")
+	errAgentUnloadFailed   = i18n.NewError("errAgentUnloadFailed")
+	errStorageUnloadFailed = i18n.NewError("errStorageUnloadFailed")
 )
 
 // Unload implements Manager.Unload.
 func (m *DefaultManager) Unload(ctx jsutil.AsyncContext, id ID) error {
+	return m.withKeyLock(ctx, id, func(ctx jsutil.AsyncContext) error {
+		return m.unloadAndHold(ctx, id)
+	})
+}
+
+// unloadAndHold implements Unload, once the key's lock is held.
+func (m *DefaultManager) unloadAndHold(ctx jsutil.AsyncContext, id ID) error {
+	sk, err := m.sessionKeys.Read(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id })
+	if err != nil {
+		return fmt.Errorf("%w: failed to read session key: %w", errAgentUnloadFailed, err)
+	}
+	if err := m.unload(ctx, id); err != nil {
+		return err
+	}
+	if sk != nil {
+		m.hold(ctx, &heldKey{ID: string(id), Op: heldUnloaded, Session: sk})
+	}
+	return nil
+}
+
+// unload unloads the key with the specified ID from the agent, without
+// holding it such that the unload can be undone. Unloading a configured key
+// that is not loaded has no effect. The caller must hold the key's lock; see
+// lockedUnload.
+func (m *DefaultManager) unload(ctx jsutil.AsyncContext, id ID) error {
 	if id == InvalidID {
 		return fmt.Errorf("%w: invalid id", errAgentUnloadFailed)
 	}
@@ -501,28 +1471,94 @@ func (m *DefaultManager) Unload(ctx jsutil.AsyncContext, id ID) error {
 		return fmt.Errorf("%w: failed to enumerate loaded keys: %v", errAgentUnloadFailed, id)
 	}
 
-	var lk *LoadedKey
+	// A key with a certificate is loaded twice; once with the certificate,
+	// and once without. Remove all of them.
+	var lks []*LoadedKey
 	for _, l := range loaded {
 		if l.ID() == id {
-			lk = l
-			break
+			lks = append(lks, l)
 		}
 	}
-	if lk == nil {
+	if len(lks) == 0 {
+		// The key may have been unloaded by a concurrent operation
+		// (e.g., from another page).
+		key, err := m.readStoredKey(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read key: %w", errAgentUnloadFailed, err)
+		}
+		if key != nil {
+			return nil
+		}
 		return fmt.Errorf("%w: invalid id: %s", errAgentUnloadFailed, id)
 	}
 
-	pub := &agent.Key{
-		Format: lk.Type,
-		Blob:   lk.Blob(),
+	jid, err := m.journal.Begin(ctx, journalOpUnload, vert.ValueOf(&journalData{ID: string(id)}).JSValue())
+	if err != nil {
+		return fmt.Errorf("%w: failed to record unload: %w", errAgentUnloadFailed, err)
 	}
-	if err := m.agent.Remove(pub); err != nil {
-		return fmt.Errorf("%w: %w", errAgentUnloadFailed, err)
+
+	for _, lk := range lks {
+		pub := &agent.Key{
+			Format: lk.Type,
+			Blob:   lk.Blob(),
+		}
+		if err := m.agent.Remove(pub); err != nil {
+			return fmt.Errorf("%w: %w", errAgentUnloadFailed, err)
+		}
 	}
 
 	if err := m.sessionKeys.Delete(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id }); err != nil {
 		return fmt.Errorf("%w: %w", errStorageUnloadFailed, err)
 	}
+	if err := m.deletePersistedKey(ctx, id); err != nil {
+		return fmt.Errorf("%w: %w", errStorageUnloadFailed, err)
+	}
 
+	if err := m.journal.Commit(ctx, jid); err != nil {
+		return fmt.Errorf("%w: failed to record unload completion: %w", errStorageUnloadFailed, err)
+	}
 	return nil
 }
+
+// lockedUnload is similar to unload, but first takes the key's lock.
+func (m *DefaultManager) lockedUnload(ctx jsutil.AsyncContext, id ID) error {
+	return m.withKeyLock(ctx, id, func(ctx jsutil.AsyncContext) error {
+		return m.unload(ctx, id)
+	})
+}
+
+// UnloadAll unloads all keys from the agent, including those added by
+// clients, and returns the IDs of the configured keys that were unloaded. It
+// is the equivalent of 'ssh-add -D'.
+func (m *DefaultManager) UnloadAll(ctx jsutil.AsyncContext) ([]ID, error) {
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to enumerate loaded keys: %w", errAgentUnloadFailed, err)
+	}
+
+	// Unload keys loaded by the manager individually, so that they are
+	// also removed from the session.
+	seen := map[ID]bool{}
+	var unloaded []ID
+	var errs []error
+	for _, l := range loaded {
+		id := l.ID()
+		if id == InvalidID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := m.lockedUnload(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unload key ID %s: %w", id, err))
+			continue
+		}
+		unloaded = append(unloaded, id)
+	}
+	if len(errs) > 0 {
+		return unloaded, errors.Join(errs...)
+	}
+
+	if err := m.agent.RemoveAll(); err != nil {
+		return unloaded, fmt.Errorf("%w: %w", errAgentUnloadFailed, err)
+	}
+	return unloaded, nil
+}
@@ -0,0 +1,513 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// OpName identifies a Manager operation.
+type OpName string
+
+const (
+	OpConfigured       OpName = "Configured"
+	OpAdd              OpName = "Add"
+	OpAddLocal         OpName = "AddLocal"
+	OpRemove           OpName = "Remove"
+	OpLoaded           OpName = "Loaded"
+	OpSnapshot         OpName = "Snapshot"
+	OpStorageUsage     OpName = "StorageUsage"
+	OpStatus           OpName = "Status"
+	OpSelfTest         OpName = "SelfTest"
+	OpRunDiagnostics   OpName = "RunDiagnostics"
+	OpConnected        OpName = "Connected"
+	OpAuditEntries     OpName = "AuditEntries"
+	OpClearAuditLog    OpName = "ClearAuditLog"
+	OpLogEntries       OpName = "LogEntries"
+	OpLoad             OpName = "Load"
+	OpUnload           OpName = "Unload"
+	OpUndoRemove       OpName = "UndoRemove"
+	OpUndoUnload       OpName = "UndoUnload"
+	OpSetLocal         OpName = "SetLocal"
+	OpSetAutoLoad      OpName = "SetAutoLoad"
+	OpMalformed        OpName = "Malformed"
+	OpRemoveMalformed  OpName = "RemoveMalformed"
+	OpRepin            OpName = "Repin"
+	OpCheckSync        OpName = "CheckSync"
+	OpKeysEncrypted    OpName = "KeysEncrypted"
+	OpSetKeysEncrypted OpName = "SetKeysEncrypted"
+	OpExport           OpName = "Export"
+	OpImport           OpName = "Import"
+	OpGenerate         OpName = "Generate"
+	OpSetIdleTimeout   OpName = "SetIdleTimeout"
+	OpSetConfirm       OpName = "SetConfirm"
+	OpSetNotify        OpName = "SetNotify"
+	OpUpdate           OpName = "Update"
+	OpSetCertificate   OpName = "SetCertificate"
+	OpSetKeyring       OpName = "SetKeyring"
+	OpSetTags          OpName = "SetTags"
+	OpUnloadTagged     OpName = "UnloadTagged"
+	OpSetHosts         OpName = "SetHosts"
+	OpSetPersist       OpName = "SetPersist"
+	OpAdoptLoaded      OpName = "AdoptLoaded"
+)
+
+// Op describes a Manager operation intercepted by a Middleware.
+type Op struct {
+	// Name identifies the operation.
+	Name OpName
+	// ID is the key to which the operation applies, or InvalidID if the
+	// operation does not apply to a configured key.
+	ID ID
+	// KeyName is the name of the key being added, for OpAdd, OpAddLocal
+	// and OpAdoptLoaded.
+	KeyName string
+	// StorageKey identifies the key being removed, for OpRemoveMalformed.
+	StorageKey string
+}
+
+// Middleware intercepts operations on a Manager, allowing features such as
+// auditing or rate limiting to be layered around an existing Manager.
+// Either function may be nil.
+type Middleware struct {
+	// Before is invoked before the operation is performed. If it returns
+	// an error, the operation is not performed, and the error is treated
+	// as the result of the operation.
+	Before func(ctx jsutil.AsyncContext, op *Op) error
+	// After is invoked once the operation completes, with the error it
+	// returned (if any). The error returned by After replaces the result
+	// of the operation, allowing errors to be intercepted.
+	After func(ctx jsutil.AsyncContext, op *Op, err error) error
+}
+
+// Chain returns a Manager that performs operations using mgr, with each
+// Middleware applied around them. The first Middleware is outermost: its
+// Before function is invoked first, and its After function last.
+func Chain(mgr Manager, mw ...Middleware) Manager {
+	return &chained{
+		mgr: mgr,
+		mw:  mw,
+	}
+}
+
+// chained is a Manager that applies a chain of Middleware.
+type chained struct {
+	mgr Manager
+	mw  []Middleware
+}
+
+// do performs the operation f, applying the Middleware starting from
+// the i-th.
+func (c *chained) do(ctx jsutil.AsyncContext, op *Op, i int, f func() error) error {
+	if i == len(c.mw) {
+		return f()
+	}
+
+	mw := c.mw[i]
+	var err error
+	if mw.Before != nil {
+		err = mw.Before(ctx, op)
+	}
+	if err == nil {
+		err = c.do(ctx, op, i+1, f)
+	}
+	if mw.After != nil {
+		err = mw.After(ctx, op, err)
+	}
+	return err
+}
+
+// Configured implements Manager.Configured.
+func (c *chained) Configured(ctx jsutil.AsyncContext) ([]*ConfiguredKey, error) {
+	var result []*ConfiguredKey
+	err := c.do(ctx, &Op{Name: OpConfigured}, 0, func() error {
+		var err error
+		result, err = c.mgr.Configured(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Add implements Manager.Add.
+func (c *chained) Add(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	var result *KeyInfo
+	err := c.do(ctx, &Op{Name: OpAdd, KeyName: name}, 0, func() error {
+		var err error
+		result, err = c.mgr.Add(ctx, name, pemPrivateKey)
+		return err
+	})
+	return result, err
+}
+
+// AddLocal implements Manager.AddLocal.
+func (c *chained) AddLocal(ctx jsutil.AsyncContext, name string, pemPrivateKey string) (*KeyInfo, error) {
+	var result *KeyInfo
+	err := c.do(ctx, &Op{Name: OpAddLocal, KeyName: name}, 0, func() error {
+		var err error
+		result, err = c.mgr.AddLocal(ctx, name, pemPrivateKey)
+		return err
+	})
+	return result, err
+}
+
+// Remove implements Manager.Remove.
+func (c *chained) Remove(ctx jsutil.AsyncContext, id ID) error {
+	return c.do(ctx, &Op{Name: OpRemove, ID: id}, 0, func() error {
+		return c.mgr.Remove(ctx, id)
+	})
+}
+
+// Loaded implements Manager.Loaded.
+func (c *chained) Loaded(ctx jsutil.AsyncContext) ([]*LoadedKey, error) {
+	var result []*LoadedKey
+	err := c.do(ctx, &Op{Name: OpLoaded}, 0, func() error {
+		var err error
+		result, err = c.mgr.Loaded(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Snapshot implements Manager.Snapshot.
+func (c *chained) Snapshot(ctx jsutil.AsyncContext) (*Snapshot, error) {
+	var result *Snapshot
+	err := c.do(ctx, &Op{Name: OpSnapshot}, 0, func() error {
+		var err error
+		result, err = c.mgr.Snapshot(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StorageUsage implements Manager.StorageUsage.
+func (c *chained) StorageUsage(ctx jsutil.AsyncContext) (*StorageUsage, error) {
+	var result *StorageUsage
+	err := c.do(ctx, &Op{Name: OpStorageUsage}, 0, func() error {
+		var err error
+		result, err = c.mgr.StorageUsage(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Status implements Manager.Status.
+func (c *chained) Status(ctx jsutil.AsyncContext) (*Status, error) {
+	var result *Status
+	err := c.do(ctx, &Op{Name: OpStatus}, 0, func() error {
+		var err error
+		result, err = c.mgr.Status(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SelfTest implements Manager.SelfTest.
+func (c *chained) SelfTest(ctx jsutil.AsyncContext) error {
+	return c.do(ctx, &Op{Name: OpSelfTest}, 0, func() error {
+		return c.mgr.SelfTest(ctx)
+	})
+}
+
+// RunDiagnostics implements Manager.RunDiagnostics.
+func (c *chained) RunDiagnostics(ctx jsutil.AsyncContext) ([]*DiagnosticResult, error) {
+	var result []*DiagnosticResult
+	err := c.do(ctx, &Op{Name: OpRunDiagnostics}, 0, func() error {
+		var err error
+		result, err = c.mgr.RunDiagnostics(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Connected implements Manager.Connected.
+func (c *chained) Connected(ctx jsutil.AsyncContext) ([]*Connection, error) {
+	var result []*Connection
+	err := c.do(ctx, &Op{Name: OpConnected}, 0, func() error {
+		var err error
+		result, err = c.mgr.Connected(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AuditEntries implements Manager.AuditEntries.
+func (c *chained) AuditEntries(ctx jsutil.AsyncContext) ([]*AuditEntry, error) {
+	var result []*AuditEntry
+	err := c.do(ctx, &Op{Name: OpAuditEntries}, 0, func() error {
+		var err error
+		result, err = c.mgr.AuditEntries(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ClearAuditLog implements Manager.ClearAuditLog.
+func (c *chained) ClearAuditLog(ctx jsutil.AsyncContext) error {
+	return c.do(ctx, &Op{Name: OpClearAuditLog}, 0, func() error {
+		return c.mgr.ClearAuditLog(ctx)
+	})
+}
+
+// LogEntries implements Manager.LogEntries.
+func (c *chained) LogEntries(ctx jsutil.AsyncContext) ([]*LogEntry, error) {
+	var result []*LogEntry
+	err := c.do(ctx, &Op{Name: OpLogEntries}, 0, func() error {
+		var err error
+		result, err = c.mgr.LogEntries(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Load implements Manager.Load.
+func (c *chained) Load(ctx jsutil.AsyncContext, id ID, passphrase string) error {
+	return c.do(ctx, &Op{Name: OpLoad, ID: id}, 0, func() error {
+		return c.mgr.Load(ctx, id, passphrase)
+	})
+}
+
+// Unload implements Manager.Unload.
+func (c *chained) Unload(ctx jsutil.AsyncContext, id ID) error {
+	return c.do(ctx, &Op{Name: OpUnload, ID: id}, 0, func() error {
+		return c.mgr.Unload(ctx, id)
+	})
+}
+
+// UndoRemove implements Manager.UndoRemove.
+func (c *chained) UndoRemove(ctx jsutil.AsyncContext, id ID) error {
+	return c.do(ctx, &Op{Name: OpUndoRemove, ID: id}, 0, func() error {
+		return c.mgr.UndoRemove(ctx, id)
+	})
+}
+
+// UndoUnload implements Manager.UndoUnload.
+func (c *chained) UndoUnload(ctx jsutil.AsyncContext, id ID) error {
+	return c.do(ctx, &Op{Name: OpUndoUnload, ID: id}, 0, func() error {
+		return c.mgr.UndoUnload(ctx, id)
+	})
+}
+
+// SetLocal implements Manager.SetLocal.
+func (c *chained) SetLocal(ctx jsutil.AsyncContext, id ID, local bool) error {
+	return c.do(ctx, &Op{Name: OpSetLocal, ID: id}, 0, func() error {
+		return c.mgr.SetLocal(ctx, id, local)
+	})
+}
+
+// SetAutoLoad implements Manager.SetAutoLoad.
+func (c *chained) SetAutoLoad(ctx jsutil.AsyncContext, id ID, autoLoad bool) error {
+	return c.do(ctx, &Op{Name: OpSetAutoLoad, ID: id}, 0, func() error {
+		return c.mgr.SetAutoLoad(ctx, id, autoLoad)
+	})
+}
+
+// Malformed implements Manager.Malformed.
+func (c *chained) Malformed(ctx jsutil.AsyncContext) ([]*MalformedKey, error) {
+	var result []*MalformedKey
+	err := c.do(ctx, &Op{Name: OpMalformed}, 0, func() error {
+		var err error
+		result, err = c.mgr.Malformed(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RemoveMalformed implements Manager.RemoveMalformed.
+func (c *chained) RemoveMalformed(ctx jsutil.AsyncContext, storageKey string, local bool) error {
+	return c.do(ctx, &Op{Name: OpRemoveMalformed, StorageKey: storageKey}, 0, func() error {
+		return c.mgr.RemoveMalformed(ctx, storageKey, local)
+	})
+}
+
+// Repin implements Manager.Repin.
+func (c *chained) Repin(ctx jsutil.AsyncContext, id ID) error {
+	return c.do(ctx, &Op{Name: OpRepin, ID: id}, 0, func() error {
+		return c.mgr.Repin(ctx, id)
+	})
+}
+
+// CheckSync implements Manager.CheckSync.
+func (c *chained) CheckSync(ctx jsutil.AsyncContext) error {
+	return c.do(ctx, &Op{Name: OpCheckSync}, 0, func() error {
+		return c.mgr.CheckSync(ctx)
+	})
+}
+
+// KeysEncrypted implements Manager.KeysEncrypted.
+func (c *chained) KeysEncrypted(ctx jsutil.AsyncContext) (bool, error) {
+	var result bool
+	err := c.do(ctx, &Op{Name: OpKeysEncrypted}, 0, func() error {
+		var err error
+		result, err = c.mgr.KeysEncrypted(ctx)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result, nil
+}
+
+// SetKeysEncrypted implements Manager.SetKeysEncrypted.
+func (c *chained) SetKeysEncrypted(ctx jsutil.AsyncContext, encrypted bool) error {
+	return c.do(ctx, &Op{Name: OpSetKeysEncrypted}, 0, func() error {
+		return c.mgr.SetKeysEncrypted(ctx, encrypted)
+	})
+}
+
+// Export implements Manager.Export.
+func (c *chained) Export(ctx jsutil.AsyncContext, includePrivate bool, password string) ([]byte, error) {
+	var result []byte
+	err := c.do(ctx, &Op{Name: OpExport}, 0, func() error {
+		var err error
+		result, err = c.mgr.Export(ctx, includePrivate, password)
+		return err
+	})
+	return result, err
+}
+
+// Import implements Manager.Import.
+func (c *chained) Import(ctx jsutil.AsyncContext, data []byte, onConflict string) (*ImportResult, error) {
+	var result *ImportResult
+	err := c.do(ctx, &Op{Name: OpImport}, 0, func() error {
+		var err error
+		result, err = c.mgr.Import(ctx, data, onConflict)
+		return err
+	})
+	return result, err
+}
+
+// Generate implements Manager.Generate.
+func (c *chained) Generate(ctx jsutil.AsyncContext, name, keyType string, bits int, passphrase string) (string, error) {
+	var result string
+	err := c.do(ctx, &Op{Name: OpGenerate}, 0, func() error {
+		var err error
+		result, err = c.mgr.Generate(ctx, name, keyType, bits, passphrase)
+		return err
+	})
+	return result, err
+}
+
+// SetIdleTimeout implements Manager.SetIdleTimeout.
+func (c *chained) SetIdleTimeout(ctx jsutil.AsyncContext, id ID, minutes int) error {
+	return c.do(ctx, &Op{Name: OpSetIdleTimeout, ID: id}, 0, func() error {
+		return c.mgr.SetIdleTimeout(ctx, id, minutes)
+	})
+}
+
+// SetConfirm implements Manager.SetConfirm.
+func (c *chained) SetConfirm(ctx jsutil.AsyncContext, id ID, confirm bool) error {
+	return c.do(ctx, &Op{Name: OpSetConfirm, ID: id}, 0, func() error {
+		return c.mgr.SetConfirm(ctx, id, confirm)
+	})
+}
+
+// SetKeyring implements Manager.SetKeyring.
+func (c *chained) SetKeyring(ctx jsutil.AsyncContext, id ID, keyring string) error {
+	return c.do(ctx, &Op{Name: OpSetKeyring, ID: id}, 0, func() error {
+		return c.mgr.SetKeyring(ctx, id, keyring)
+	})
+}
+
+// SetTags implements Manager.SetTags.
+func (c *chained) SetTags(ctx jsutil.AsyncContext, id ID, tags []string) error {
+	return c.do(ctx, &Op{Name: OpSetTags, ID: id}, 0, func() error {
+		return c.mgr.SetTags(ctx, id, tags)
+	})
+}
+
+// UnloadTagged implements Manager.UnloadTagged.
+func (c *chained) UnloadTagged(ctx jsutil.AsyncContext, tag string) ([]ID, error) {
+	var result []ID
+	err := c.do(ctx, &Op{Name: OpUnloadTagged}, 0, func() error {
+		var err error
+		result, err = c.mgr.UnloadTagged(ctx, tag)
+		return err
+	})
+	return result, err
+}
+
+// SetHosts implements Manager.SetHosts.
+func (c *chained) SetHosts(ctx jsutil.AsyncContext, id ID, hosts []string) error {
+	return c.do(ctx, &Op{Name: OpSetHosts, ID: id}, 0, func() error {
+		return c.mgr.SetHosts(ctx, id, hosts)
+	})
+}
+
+// SetPersist implements Manager.SetPersist.
+func (c *chained) SetPersist(ctx jsutil.AsyncContext, id ID, persist bool) error {
+	return c.do(ctx, &Op{Name: OpSetPersist, ID: id}, 0, func() error {
+		return c.mgr.SetPersist(ctx, id, persist)
+	})
+}
+
+// AdoptLoaded implements Manager.AdoptLoaded.
+func (c *chained) AdoptLoaded(ctx jsutil.AsyncContext, blob, name string) error {
+	return c.do(ctx, &Op{Name: OpAdoptLoaded, KeyName: name}, 0, func() error {
+		return c.mgr.AdoptLoaded(ctx, blob, name)
+	})
+}
+
+// SetNotify implements Manager.SetNotify.
+func (c *chained) SetNotify(ctx jsutil.AsyncContext, id ID, notify bool) error {
+	return c.do(ctx, &Op{Name: OpSetNotify, ID: id}, 0, func() error {
+		return c.mgr.SetNotify(ctx, id, notify)
+	})
+}
+
+// Update implements Manager.Update.
+func (c *chained) Update(ctx jsutil.AsyncContext, id ID, pemPrivateKey string) error {
+	return c.do(ctx, &Op{Name: OpUpdate, ID: id}, 0, func() error {
+		return c.mgr.Update(ctx, id, pemPrivateKey)
+	})
+}
+
+// SetCertificate implements Manager.SetCertificate.
+func (c *chained) SetCertificate(ctx jsutil.AsyncContext, id ID, certificate string) error {
+	return c.do(ctx, &Op{Name: OpSetCertificate, ID: id}, 0, func() error {
+		return c.mgr.SetCertificate(ctx, id, certificate)
+	})
+}
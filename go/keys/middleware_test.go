@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingMiddleware returns Middleware that appends the hooks invoked to
+// events, labelled with the supplied name.
+func recordingMiddleware(name string, events *[]string) Middleware {
+	return Middleware{
+		Before: func(ctx jsutil.AsyncContext, op *Op) error {
+			*events = append(*events, fmt.Sprintf("%s before %s(%s)", name, op.Name, op.ID))
+			return nil
+		},
+		After: func(ctx jsutil.AsyncContext, op *Op, err error) error {
+			*events = append(*events, fmt.Sprintf("%s after %s(%s): %v", name, op.Name, op.ID, err))
+			return err
+		},
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		var events []string
+		mgr := &dummyManager{}
+		m := Chain(mgr, recordingMiddleware("outer", &events), recordingMiddleware("inner", &events))
+
+		if err := m.Load(ctx, ID("1"), "secret"); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if diff := cmp.Diff(mgr.ID, ID("1")); diff != "" {
+			t.Errorf("incorrect loaded key; -got +want: %s", diff)
+		}
+		want := []string{
+			"outer before Load(1)",
+			"inner before Load(1)",
+			"inner after Load(1): <nil>",
+			"outer after Load(1): <nil>",
+		}
+		if diff := cmp.Diff(events, want); diff != "" {
+			t.Errorf("incorrect events; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestChainBeforeRejects(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		rejected := errors.New("rejected")
+		var events []string
+		mgr := &dummyManager{}
+		m := Chain(mgr,
+			recordingMiddleware("outer", &events),
+			Middleware{
+				Before: func(ctx jsutil.AsyncContext, op *Op) error {
+					if op.Name == OpRemove {
+						return rejected
+					}
+					return nil
+				},
+			},
+			recordingMiddleware("inner", &events),
+		)
+
+		if err := m.Remove(ctx, ID("1")); !errors.Is(err, rejected) {
+			t.Errorf("incorrect error: got %v, want %v", err, rejected)
+		}
+		if mgr.ID != InvalidID {
+			t.Errorf("rejected operation performed on key ID %s", mgr.ID)
+		}
+		want := []string{
+			"outer before Remove(1)",
+			"outer after Remove(1): rejected",
+		}
+		if diff := cmp.Diff(events, want); diff != "" {
+			t.Errorf("incorrect events; -got +want: %s", diff)
+		}
+
+		// Other operations are unaffected.
+		if err := m.Unload(ctx, ID("2")); err != nil {
+			t.Errorf("Unload failed: %v", err)
+		}
+		if diff := cmp.Diff(mgr.ID, ID("2")); diff != "" {
+			t.Errorf("incorrect unloaded key; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestChainAfterIntercepts(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		failed := errors.New("failed")
+		translated := errors.New("translated")
+		mgr := &dummyManager{Err: failed}
+		m := Chain(mgr, Middleware{
+			After: func(ctx jsutil.AsyncContext, op *Op, err error) error {
+				if errors.Is(err, failed) {
+					return fmt.Errorf("%w: %s", translated, op.KeyName)
+				}
+				return err
+			},
+		})
+
+		_, err := m.Add(ctx, "new-key", "private-key")
+		if !errors.Is(err, translated) {
+			t.Errorf("incorrect error: got %v, want %v", err, translated)
+		}
+		if diff := cmp.Diff(err.Error(), "translated: new-key"); diff != "" {
+			t.Errorf("incorrect error message; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestChainResults(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr := &dummyManager{
+			ConfiguredKeys: []*ConfiguredKey{{ID: "1", Name: "key"}},
+			LoadedKeys:     []*LoadedKey{{Type: "ssh-rsa"}},
+			MalformedKeys:  []*MalformedKey{{StorageKey: "key.2"}},
+			Usage:          &StorageUsage{Keys: []*KeyUsage{{ID: "1", Bytes: 100}}},
+			Health:         &Status{Uptime: 60, LoadedKeys: 1},
+		}
+		var ops []OpName
+		m := Chain(mgr, Middleware{
+			Before: func(ctx jsutil.AsyncContext, op *Op) error {
+				ops = append(ops, op.Name)
+				return nil
+			},
+		})
+
+		configured, err := m.Configured(ctx)
+		if err != nil {
+			t.Fatalf("Configured failed: %v", err)
+		}
+		if diff := cmp.Diff(configured, mgr.ConfiguredKeys); diff != "" {
+			t.Errorf("incorrect configured keys; -got +want: %s", diff)
+		}
+		loaded, err := m.Loaded(ctx)
+		if err != nil {
+			t.Fatalf("Loaded failed: %v", err)
+		}
+		if diff := cmp.Diff(loaded, mgr.LoadedKeys); diff != "" {
+			t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+		}
+		snapshot, err := m.Snapshot(ctx)
+		if err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+		if diff := cmp.Diff(snapshot, &Snapshot{Configured: mgr.ConfiguredKeys, Loaded: mgr.LoadedKeys}); diff != "" {
+			t.Errorf("incorrect snapshot; -got +want: %s", diff)
+		}
+		usage, err := m.StorageUsage(ctx)
+		if err != nil {
+			t.Fatalf("StorageUsage failed: %v", err)
+		}
+		if diff := cmp.Diff(usage, mgr.Usage); diff != "" {
+			t.Errorf("incorrect storage usage; -got +want: %s", diff)
+		}
+		status, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		if diff := cmp.Diff(status, mgr.Health); diff != "" {
+			t.Errorf("incorrect status; -got +want: %s", diff)
+		}
+		logs, err := m.LogEntries(ctx)
+		if err != nil {
+			t.Fatalf("LogEntries failed: %v", err)
+		}
+		if diff := cmp.Diff(logs, mgr.Logs); diff != "" {
+			t.Errorf("incorrect log entries; -got +want: %s", diff)
+		}
+		malformed, err := m.Malformed(ctx)
+		if err != nil {
+			t.Fatalf("Malformed failed: %v", err)
+		}
+		if diff := cmp.Diff(malformed, mgr.MalformedKeys); diff != "" {
+			t.Errorf("incorrect malformed keys; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(ops, []OpName{OpConfigured, OpLoaded, OpSnapshot, OpStorageUsage, OpStatus, OpLogEntries, OpMalformed}); diff != "" {
+			t.Errorf("incorrect operations; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// RemoveOrphanedSessionKeys removes the session keys whose ID is neither
+// configured nor loaded into the agent, and returns their IDs. Such keys can
+// linger if a configured key is removed while loaded and later removed from
+// the agent (e.g., by 'ssh-add -D').
+//
+// It must only be invoked after LoadFromSession; until then, the agent is
+// empty, and the session keys of keys added by clients would appear orphaned.
+// Nothing is removed if some stored key cannot be identified (e.g., because
+// it cannot be decrypted) or synced storage is unavailable, since the session
+// key of a configured key could then appear orphaned too.
+func (m *DefaultManager) RemoveOrphanedSessionKeys(ctx jsutil.AsyncContext) ([]ID, error) {
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+	// Captured keys similarly linger once the client removes them.
+	if err := m.removeStaleCaptured(ctx, loaded); err != nil {
+		jsutil.LogError("DefaultManager.RemoveOrphanedSessionKeys: %v", err)
+	}
+
+	known, complete, err := m.storedKeyIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		jsutil.LogDebug("DefaultManager.RemoveOrphanedSessionKeys: stored keys not fully readable; skipping")
+		return nil, nil
+	}
+	for _, l := range loaded {
+		known[l.ID()] = true
+	}
+
+	sessionKeys, err := m.sessionKeys.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session keys: %w", err)
+	}
+	orphaned := map[ID]bool{}
+	var removed []ID
+	for _, sk := range sessionKeys {
+		id := ID(sk.ID)
+		if known[id] || orphaned[id] {
+			continue
+		}
+		jsutil.Log("DefaultManager.RemoveOrphanedSessionKeys: removing session key for unknown key ID %s", id)
+		orphaned[id] = true
+		removed = append(removed, id)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	if err := m.sessionKeys.Delete(ctx, func(sk *sessionKey) bool { return orphaned[ID(sk.ID)] }); err != nil {
+		return nil, fmt.Errorf("failed to delete session keys: %w", err)
+	}
+	return removed, nil
+}
+
+// storedKeyIDs returns the IDs of the stored keys in both locations,
+// including those of malformed keys. complete is false if the ID of some
+// malformed key cannot be determined, or if synced storage is unavailable.
+func (m *DefaultManager) storedKeyIDs(ctx jsutil.AsyncContext) (ids map[ID]bool, complete bool, err error) {
+	if err := m.syncStorage.Available(ctx); err != nil {
+		return nil, false, nil
+	}
+
+	stored, err := m.readAllStoredKeys(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	ids = map[ID]bool{}
+	for _, sk := range stored {
+		ids[ID(sk.ID)] = true
+	}
+
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		malformed, err := keys.Malformed(ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read keys: %w", err)
+		}
+		for _, mk := range malformed {
+			id := malformedID(mk)
+			if id == InvalidID {
+				return nil, false, nil
+			}
+			ids[id] = true
+		}
+	}
+	return ids, true, nil
+}
+
+// malformedID returns the ID of a malformed key, or InvalidID if it cannot be
+// determined.
+func malformedID(mk *storage.Malformed) ID {
+	if mk.Value.Type() != js.TypeObject {
+		return InvalidID
+	}
+	id := mk.Value.Get("id")
+	if id.Type() != js.TypeString {
+		return InvalidID
+	}
+	return ID(id.String())
+}
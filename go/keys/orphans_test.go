@@ -0,0 +1,217 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestRemoveOrphanedSessionKeys(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		mgr, err := newTestManager(ctx, agt, storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), []*initialKey{
+			{Name: "removed", PEMPrivateKey: testdata.WithoutPassphrase.Private},
+			{Name: "kept", PEMPrivateKey: testdata.ED25519WithoutPassphrase.Private},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+
+		var removedID, keptID ID
+		for name, id := range map[string]*ID{"removed": &removedID, "kept": &keptID} {
+			if *id, err = findKey(ctx, mgr, InvalidID, name); err != nil {
+				t.Fatalf("failed to find ID for %s: %v", name, err)
+			}
+			if err := mgr.Load(ctx, *id, ""); err != nil {
+				t.Fatalf("failed to load %s: %v", name, err)
+			}
+		}
+
+		sessionKeyIDs := func() []ID {
+			sks, err := mgr.sessionKeys.ReadAll(ctx)
+			if err != nil {
+				t.Fatalf("failed to read session keys: %v", err)
+			}
+			var ids []ID
+			for _, sk := range sks {
+				ids = append(ids, ID(sk.ID))
+			}
+			return ids
+		}
+		sorted := cmpopts.SortSlices(func(a, b ID) bool { return a < b })
+
+		// A removed key that is still loaded retains its session key.
+		if err := mgr.Remove(ctx, removedID); err != nil {
+			t.Fatalf("failed to remove key: %v", err)
+		}
+		removed, err := mgr.RemoveOrphanedSessionKeys(ctx)
+		if err != nil {
+			t.Errorf("RemoveOrphanedSessionKeys failed: %v", err)
+		}
+		if diff := cmp.Diff(removed, []ID(nil)); diff != "" {
+			t.Errorf("incorrect removed IDs; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(sessionKeyIDs(), []ID{removedID, keptID}, sorted); diff != "" {
+			t.Errorf("incorrect session keys; -got +want: %s", diff)
+		}
+
+		// Once also removed from the agent, the session key is orphaned.
+		// The configured key's session key is retained, even though it
+		// is no longer loaded either.
+		if err := agt.RemoveAll(); err != nil {
+			t.Fatalf("failed to remove keys from agent: %v", err)
+		}
+		removed, err = mgr.RemoveOrphanedSessionKeys(ctx)
+		if err != nil {
+			t.Errorf("RemoveOrphanedSessionKeys failed: %v", err)
+		}
+		if diff := cmp.Diff(removed, []ID{removedID}); diff != "" {
+			t.Errorf("incorrect removed IDs; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(sessionKeyIDs(), []ID{keptID}); diff != "" {
+			t.Errorf("incorrect session keys; -got +want: %s", diff)
+		}
+	})
+}
+
+// toggledArea is a storage area that becomes unavailable once unavailable is
+// set.
+type toggledArea struct {
+	storage.Area
+	unavailable bool
+}
+
+func (a *toggledArea) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	if a.unavailable {
+		return nil, storage.ErrUnavailable
+	}
+	return a.Area.Get(ctx)
+}
+
+func TestRemoveOrphanedSessionKeysDegraded(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		degrade     func(ctx jsutil.AsyncContext, syncStorage *toggledArea, id ID) error
+		wantRemoved bool
+	}{
+		{
+			description: "stored keys readable",
+			wantRemoved: true,
+		},
+		{
+			description: "malformed key with same ID",
+			degrade: func(ctx jsutil.AsyncContext, syncStorage *toggledArea, id ID) error {
+				return syncStorage.Set(ctx, map[string]js.Value{
+					"key.1": js.ValueOf(map[string]any{
+						"id":            string(id),
+						"name":          "old-key",
+						"pemPrivateKey": "bogus-key-data",
+					}),
+				})
+			},
+		},
+		{
+			description: "malformed key with other ID",
+			degrade: func(ctx jsutil.AsyncContext, syncStorage *toggledArea, _ ID) error {
+				return syncStorage.Set(ctx, map[string]js.Value{
+					"key.1": js.ValueOf(map[string]any{
+						"id":            "other-id",
+						"name":          "old-key",
+						"pemPrivateKey": "bogus-key-data",
+					}),
+				})
+			},
+			wantRemoved: true,
+		},
+		{
+			description: "unreadable stored key",
+			degrade: func(ctx jsutil.AsyncContext, syncStorage *toggledArea, _ ID) error {
+				return syncStorage.Set(ctx, map[string]js.Value{
+					"key.1": js.ValueOf("unreadable"),
+				})
+			},
+		},
+		{
+			description: "synced storage unavailable",
+			degrade: func(_ jsutil.AsyncContext, syncStorage *toggledArea, _ ID) error {
+				syncStorage.unavailable = true
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				agt := agent.NewKeyring()
+				syncStorage := &toggledArea{Area: storage.NewRaw(st.NewMemArea())}
+				mgr, err := newTestManager(ctx, agt, syncStorage, storage.NewRaw(st.NewMemArea()), []*initialKey{
+					{Name: "removed", PEMPrivateKey: testdata.WithoutPassphrase.Private, Load: true},
+				})
+				if err != nil {
+					t.Errorf("failed to initialize manager: %v", err)
+					return
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "removed")
+				if err != nil {
+					t.Errorf("failed to find key: %v", err)
+					return
+				}
+				if err := mgr.Remove(ctx, id); err != nil {
+					t.Errorf("failed to remove key: %v", err)
+					return
+				}
+				if err := agt.RemoveAll(); err != nil {
+					t.Errorf("failed to remove keys from agent: %v", err)
+					return
+				}
+				if tc.degrade != nil {
+					if err := tc.degrade(ctx, syncStorage, id); err != nil {
+						t.Errorf("failed to degrade storage: %v", err)
+						return
+					}
+				}
+
+				removed, err := mgr.RemoveOrphanedSessionKeys(ctx)
+				if err != nil {
+					t.Errorf("RemoveOrphanedSessionKeys failed: %v", err)
+				}
+				var want []ID
+				if tc.wantRemoved {
+					want = []ID{id}
+				}
+				if diff := cmp.Diff(removed, want); diff != "" {
+					t.Errorf("incorrect removed IDs; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,125 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+var (
+	// persistedKeyPrefixes is the prefix for keys in local storage that
+	// remain loaded after the browser restarts.
+	persistedKeyPrefixes = []string{"persistedKey"}
+)
+
+// SetPersist implements Manager.SetPersist.
+//
+// Session storage is cleared when the browser exits, so a persisted key is
+// additionally stored in local storage and restored to the session by
+// LoadFromSession. The decrypted key is then stored on disk, where it is
+// protected only by the user's operating system account.
+func (m *DefaultManager) SetPersist(ctx jsutil.AsyncContext, id ID, persist bool) error {
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Persist = persist }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+
+	if !persist {
+		return m.deletePersistedKey(ctx, id)
+	}
+
+	// If the key is already loaded, it remains loaded after the browser
+	// restarts without needing to be loaded again.
+	sk, err := m.sessionKeys.Read(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id })
+	if err != nil {
+		return fmt.Errorf("failed to read session key: %w", err)
+	}
+	if sk == nil {
+		return nil
+	}
+	if err := m.persistedKeys.Write(ctx, sk); err != nil {
+		return fmt.Errorf("failed to persist key: %w", err)
+	}
+	return nil
+}
+
+// deletePersistedKey removes the key with the specified ID from local
+// storage, such that it is not restored after the browser restarts.
+func (m *DefaultManager) deletePersistedKey(ctx jsutil.AsyncContext, id ID) error {
+	if err := m.persistedKeys.Delete(ctx, func(sk *sessionKey) bool { return ID(sk.ID) == id }); err != nil {
+		return fmt.Errorf("failed to delete persisted key: %w", err)
+	}
+	return nil
+}
+
+// restorePersisted copies persisted keys that are missing from the current
+// session (e.g., because the browser restarted) into the session. Persisted
+// keys that are no longer configured to persist are removed.
+func (m *DefaultManager) restorePersisted(ctx jsutil.AsyncContext) error {
+	persisted, err := m.persistedKeys.ReadAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read persisted keys: %w", err)
+	}
+	if len(persisted) == 0 {
+		return nil
+	}
+
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+	persist := map[string]bool{}
+	for _, k := range configured {
+		persist[k.ID] = k.Persist
+	}
+
+	for _, pk := range persisted {
+		if !persist[pk.ID] {
+			jsutil.Log("DefaultManager.restorePersisted: removing key ID %s that is no longer persisted", pk.ID)
+			if err := m.deletePersistedKey(ctx, ID(pk.ID)); err != nil {
+				jsutil.LogError("DefaultManager.restorePersisted: %v", err)
+			}
+			continue
+		}
+
+		byID := func(sk *sessionKey) bool { return sk.ID == pk.ID }
+		existing, err := m.sessionKeys.Read(ctx, byID)
+		if err != nil {
+			return fmt.Errorf("failed to read session key: %w", err)
+		}
+		if existing != nil {
+			continue
+		}
+		if err := m.sessionKeys.Write(ctx, pk); err != nil {
+			return fmt.Errorf("failed to restore key ID %s to session: %w", pk.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSetPersist(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description  string
+		byID         ID
+		load         bool
+		persist      bool
+		unload       bool
+		wantPersist  bool
+		wantRestored bool
+		wantErr      error
+	}{
+		{
+			description:  "enable before load",
+			persist:      true,
+			load:         true,
+			wantPersist:  true,
+			wantRestored: true,
+		},
+		{
+			description: "enable without load",
+			persist:     true,
+			wantPersist: true,
+		},
+		{
+			description: "disable",
+			persist:     false,
+			load:        true,
+		},
+		{
+			description: "unload after enable",
+			persist:     true,
+			load:        true,
+			unload:      true,
+			wantPersist: true,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			persist:     true,
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.SetPersist(ctx, id, tc.persist)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if tc.wantErr != nil {
+					return
+				}
+				if tc.load {
+					if err := mgr.Load(ctx, id, testdata.WithPassphrase.Passphrase); err != nil {
+						t.Fatalf("failed to load key: %v", err)
+					}
+				}
+				if tc.unload {
+					if err := mgr.Unload(ctx, id); err != nil {
+						t.Fatalf("failed to unload key: %v", err)
+					}
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configured[0].Persist, tc.wantPersist); diff != "" {
+					t.Errorf("incorrect persist; -got +want: %s", diff)
+				}
+
+				// Simulate a browser restart, which clears the
+				// session.
+				restartedAgent := agent.NewKeyring()
+				restarted := NewManager(restartedAgent, syncStorage, mgr.localStorage, storage.NewRaw(st.NewMemArea()))
+				if err := restarted.LoadFromSession(ctx); err != nil {
+					t.Fatalf("failed to load from session: %v", err)
+				}
+				loaded, err := restarted.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				var restored bool
+				for _, l := range loaded {
+					restored = restored || l.ID() == id
+				}
+				if diff := cmp.Diff(restored, tc.wantRestored); diff != "" {
+					t.Errorf("incorrect loaded keys after restart; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestSetPersistLoadedKey(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "good-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		id, err := findKey(ctx, mgr, InvalidID, "good-key")
+		if err != nil {
+			t.Fatalf("failed to find key: %v", err)
+		}
+
+		persisted := func() int {
+			t.Helper()
+			keys, err := mgr.persistedKeys.ReadAll(ctx)
+			if err != nil {
+				t.Fatalf("failed to read persisted keys: %v", err)
+			}
+			return len(keys)
+		}
+
+		// Enabling persistence for a key that is already loaded
+		// persists it immediately.
+		if err := mgr.SetPersist(ctx, id, true); err != nil {
+			t.Fatalf("failed to enable persist: %v", err)
+		}
+		if diff := cmp.Diff(persisted(), 1); diff != "" {
+			t.Errorf("incorrect persisted keys after enabling; -got +want: %s", diff)
+		}
+
+		// Disabling it removes the decrypted key from disk.
+		if err := mgr.SetPersist(ctx, id, false); err != nil {
+			t.Fatalf("failed to disable persist: %v", err)
+		}
+		if diff := cmp.Diff(persisted(), 0); diff != "" {
+			t.Errorf("incorrect persisted keys after disabling; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/pkcs12"
+	"golang.org/x/crypto/ssh"
+)
+
+// ConvertPKCS12 extracts the private key from a PKCS#12 archive (i.e., a .p12
+// or .pfx file), and returns it PEM-encoded in the OpenSSH format so that it
+// can be added like any other key. If the archive is protected by a password,
+// the returned key is encrypted with the same password. comment is recorded in
+// the returned key.
+func ConvertPKCS12(data []byte, password, comment string) (pemPrivateKey string, err error) {
+	priv, err := pkcs12.PrivateKey(data, password)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PKCS#12 archive: %w", err)
+	}
+
+	var block *pem.Block
+	if password != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, comment, []byte(password))
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, comment)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errMarshalFailed, err)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestConvertPKCS12(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		archive     testdata.TestArchive
+		password    string
+		wantErr     error
+	}{
+		{
+			description: "convert RSA key",
+			archive:     testdata.PKCS12RSA,
+			password:    testdata.PKCS12RSA.Password,
+		},
+		{
+			description: "convert ECDSA key",
+			archive:     testdata.PKCS12ECDSALegacy,
+			password:    testdata.PKCS12ECDSALegacy.Password,
+		},
+		{
+			description: "fail on incorrect password",
+			archive:     testdata.PKCS12RSA,
+			password:    "incorrect",
+			wantErr:     x509.IncorrectPasswordError,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := base64.StdEncoding.DecodeString(tc.archive.Data)
+			if err != nil {
+				t.Fatalf("failed to decode archive: %v", err)
+			}
+			pemPrivateKey, err := ConvertPKCS12(data, tc.password, "new-key")
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			// The converted key is encrypted with the archive's
+			// password.
+			info, err := inspectKey(pemPrivateKey)
+			if err != nil {
+				t.Fatalf("inspectKey() failed: %v", err)
+			}
+			if diff := cmp.Diff(info.Encrypted, true); diff != "" {
+				t.Errorf("incorrect encryption; -got +want: %s", diff)
+			}
+			decrypted, err := decryptKey(&storedKey{PEMPrivateKey: pemPrivateKey}, tc.password)
+			if err != nil {
+				t.Fatalf("decryptKey() failed: %v", err)
+			}
+			priv, err := parseDecryptedKey(decrypted)
+			if err != nil {
+				t.Fatalf("parseDecryptedKey() failed: %v", err)
+			}
+			pub, err := publicKeyOf(priv)
+			if err != nil {
+				t.Fatalf("publicKeyOf() failed: %v", err)
+			}
+			if diff := cmp.Diff(base64.StdEncoding.EncodeToString(pub.Marshal()), tc.archive.Blob); diff != "" {
+				t.Errorf("incorrect key; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+)
+
+// SelfTest implements Manager.SelfTest.
+func (m *DefaultManager) SelfTest(ctx jsutil.AsyncContext) error {
+	if m.agentLocked.Load() {
+		return fmt.Errorf("%w: unlock it to test it", ErrAgentLocked)
+	}
+	return selftest.AgentRoundTrip(m.agent).Run(ctx)
+}
+
+// DiagnosticResult is the outcome of a single check run by RunDiagnostics.
+type DiagnosticResult struct {
+	// Name is the name of the check.
+	Name string `js:"name"`
+	// Err describes why the check failed, or is empty if it succeeded.
+	Err string `js:"err"`
+}
+
+// DiagnosticsRunner runs the checks that diagnose the agent, and returns
+// their outcome.
+type DiagnosticsRunner func(ctx jsutil.AsyncContext) *selftest.Report
+
+// SetDiagnosticsRunner configures how RunDiagnostics runs its checks; for
+// example, such that their outcome is recorded for debug reports. By default,
+// the agent is tested directly and through an AgentPort.
+func (m *DefaultManager) SetDiagnosticsRunner(r DiagnosticsRunner) {
+	m.diagnostics = r
+}
+
+// RunDiagnostics implements Manager.RunDiagnostics.
+func (m *DefaultManager) RunDiagnostics(ctx jsutil.AsyncContext) ([]*DiagnosticResult, error) {
+	var r *selftest.Report
+	if m.diagnostics != nil {
+		r = m.diagnostics(ctx)
+	} else {
+		checks := []selftest.Check{
+			selftest.AgentRoundTrip(m.agent),
+			selftest.PortRoundTrip(m.agent),
+		}
+		r = selftest.Run(ctx, "", checks, time.Now())
+	}
+
+	results := []*DiagnosticResult{}
+	for _, res := range r.Results {
+		results = append(results, &DiagnosticResult{Name: res.Name, Err: res.Err})
+	}
+	return results, nil
+}
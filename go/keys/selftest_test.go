@@ -0,0 +1,119 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSelfTest(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "loaded-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+		})
+		if err != nil {
+			t.Errorf("failed to initialize manager: %v", err)
+			return
+		}
+		loaded := func() []*LoadedKey {
+			l, err := mgr.Loaded(ctx)
+			if err != nil {
+				t.Errorf("failed to enumerate loaded keys: %v", err)
+			}
+			return l
+		}
+		before := loaded()
+
+		if err := mgr.SelfTest(ctx); err != nil {
+			t.Errorf("self-test failed: %v", err)
+		}
+		// The temporary key is removed, and the user's keys are
+		// unaffected.
+		if diff := cmp.Diff(loaded(), before); diff != "" {
+			t.Errorf("incorrect loaded keys after self-test; -got +want: %s", diff)
+		}
+
+		// The agent cannot be tested while it is locked.
+		mgr.agentLocked.Store(true)
+		err = mgr.SelfTest(ctx)
+		if diff := cmp.Diff(err, ErrAgentLocked, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error while locked; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestRunDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, nil)
+		if err != nil {
+			t.Errorf("failed to initialize manager: %v", err)
+			return
+		}
+
+		// By default, the agent is tested directly and through an
+		// AgentPort.
+		results, err := mgr.RunDiagnostics(ctx)
+		if err != nil {
+			t.Errorf("failed to run diagnostics: %v", err)
+			return
+		}
+		if diff := cmp.Diff(results, []*DiagnosticResult{
+			{Name: "agent round trip"},
+			{Name: "agent port round trip"},
+		}); diff != "" {
+			t.Errorf("incorrect results; -got +want: %s", diff)
+		}
+		if loaded, err := mgr.Loaded(ctx); err != nil || len(loaded) != 0 {
+			t.Errorf("temporary keys not removed: got (%v, %v), want none", loaded, err)
+		}
+
+		// A runner replaces the default checks.
+		mgr.SetDiagnosticsRunner(func(jsutil.AsyncContext) *selftest.Report {
+			return &selftest.Report{
+				Version: "1.2.3",
+				Results: []*selftest.Result{{Name: "custom", Err: "failed"}},
+			}
+		})
+		results, err = mgr.RunDiagnostics(ctx)
+		if err != nil {
+			t.Errorf("failed to run diagnostics with runner: %v", err)
+		}
+		if diff := cmp.Diff(results, []*DiagnosticResult{{Name: "custom", Err: "failed"}}); diff != "" {
+			t.Errorf("incorrect results with runner; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,230 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/ppk"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
+	"golang.org/x/crypto/ssh"
+)
+
+// This file supports comparing keys with those in another SSH agent, using the
+// formats output by OpenSSH's ssh-add.
+
+var (
+	errInvalidAgentList = i18n.NewError("errInvalidAgentList")
+)
+
+const (
+	// noIdentities is output by 'ssh-add -L' if the agent has no keys.
+	noIdentities = "The agent has no identities."
+)
+
+// plainKey returns the key underlying a certificate. Other keys are returned
+// unmodified. OpenSSH fingerprints certificates using the underlying key.
+func plainKey(pub ssh.PublicKey) ssh.PublicKey {
+	if cert, ok := pub.(*ssh.Certificate); ok {
+		return cert.Key
+	}
+	return pub
+}
+
+// PublicKey returns the public key for the stored key, or nil if it cannot be
+// determined without the passphrase.
+func (s *storedKey) PublicKey() ssh.PublicKey {
+	if s.Certificate != "" {
+		if cert, err := parseCertificate(s.Certificate); err == nil {
+			return cert.Key
+		}
+	}
+
+	if securitykey.IsSecurityKey([]byte(s.PEMPrivateKey)) {
+		if k, err := securitykey.ParsePrivateKey([]byte(s.PEMPrivateKey)); err == nil {
+			return k.PublicKey()
+		}
+		return nil
+	}
+
+	// The public key of a PuTTY key is not encrypted.
+	if ppk.IsPPK([]byte(s.PEMPrivateKey)) {
+		if k, err := ppk.Parse([]byte(s.PEMPrivateKey)); err == nil {
+			return k.PublicKey()
+		}
+		return nil
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(s.PEMPrivateKey))
+	if err == nil {
+		return signer.PublicKey()
+	}
+	// The public key of an encrypted OpenSSH key is not encrypted.
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) && missing.PublicKey != nil {
+		return missing.PublicKey
+	}
+	return nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the stored key, or an empty
+// string if it cannot be determined without the passphrase.
+func (s *storedKey) Fingerprint() string {
+	pub := s.PublicKey()
+	if pub == nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(pub)
+}
+
+// AuthorizedKey returns the public key of the stored key in authorized_keys
+// format, or an empty string if it cannot be determined without the
+// passphrase.
+func (s *storedKey) AuthorizedKey() string {
+	pub := s.PublicKey()
+	if pub == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+}
+
+// ParseAgentList parses the output of 'ssh-add -L', which lists the public
+// keys loaded in an agent in authorized_keys format.
+func ParseAgentList(text string) ([]ssh.PublicKey, error) {
+	var result []ssh.PublicKey
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == noIdentities {
+			continue
+		}
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", errInvalidAgentList, i+1, err)
+		}
+		result = append(result, pub)
+	}
+	return result, nil
+}
+
+// keyBits returns the size of the key in bits, as reported by ssh-add.
+func keyBits(pub ssh.PublicKey) int {
+	cpk, ok := plainKey(pub).(ssh.CryptoPublicKey)
+	if !ok {
+		return 256 // Security keys (e.g., sk-ssh-ed25519@openssh.com).
+	}
+	switch k := cpk.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	}
+	return 256 // Ed25519
+}
+
+// keyTypeLabel returns the label for the key's type, as reported by ssh-add.
+func keyTypeLabel(pub ssh.PublicKey) string {
+	label := "UNKNOWN"
+	switch t := plainKey(pub).Type(); {
+	case t == ssh.KeyAlgoRSA:
+		label = "RSA"
+	case t == ssh.KeyAlgoDSA:
+		label = "DSA"
+	case t == ssh.KeyAlgoED25519:
+		label = "ED25519"
+	case t == ssh.KeyAlgoSKED25519:
+		label = "ED25519-SK"
+	case t == ssh.KeyAlgoSKECDSA256:
+		label = "ECDSA-SK"
+	case strings.HasPrefix(t, "ecdsa-sha2-"):
+		label = "ECDSA"
+	}
+	if _, ok := pub.(*ssh.Certificate); ok {
+		label += "-CERT"
+	}
+	return label
+}
+
+// FingerprintLine describes the key in the format output by 'ssh-add -l'.
+func FingerprintLine(pub ssh.PublicKey, comment string) string {
+	if comment == "" {
+		comment = "no comment"
+	}
+	return fmt.Sprintf("%d %s %s (%s)", keyBits(pub), ssh.FingerprintSHA256(plainKey(pub)), comment, keyTypeLabel(pub))
+}
+
+// FormatLoaded describes the loaded keys in the format output by 'ssh-add -l'.
+// Keys are described by their configured name, if known, rather than their
+// comment.
+func FormatLoaded(configured []*ConfiguredKey, loaded []*LoadedKey) string {
+	names := map[ID]string{}
+	for _, c := range configured {
+		names[ID(c.ID)] = c.Name
+	}
+
+	var lines []string
+	for _, l := range loaded {
+		pub, err := ssh.ParsePublicKey(l.Blob())
+		if err != nil {
+			continue
+		}
+		comment := l.Comment
+		if n, ok := names[l.ID()]; ok {
+			comment = n
+		}
+		lines = append(lines, FingerprintLine(pub, comment))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExternalMatch indicates whether a configured key is loaded in another agent.
+type ExternalMatch struct {
+	// Name is the name of the configured key.
+	Name string
+	// Fingerprint is the SHA256 fingerprint of the configured key, or
+	// empty if it cannot be determined without the passphrase.
+	Fingerprint string
+	// Loaded indicates if the key is loaded in the other agent.
+	Loaded bool
+}
+
+// MatchExternal determines which configured keys are loaded in another agent,
+// given the keys loaded in that agent. Results are ordered by name.
+func MatchExternal(configured []*ConfiguredKey, external []ssh.PublicKey) []*ExternalMatch {
+	loaded := map[string]bool{}
+	for _, pub := range external {
+		loaded[ssh.FingerprintSHA256(plainKey(pub))] = true
+	}
+
+	var result []*ExternalMatch
+	for _, c := range configured {
+		result = append(result, &ExternalMatch{
+			Name:        c.Name,
+			Fingerprint: c.Fingerprint,
+			Loaded:      c.Fingerprint != "" && loaded[c.Fingerprint],
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
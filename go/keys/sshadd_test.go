@@ -0,0 +1,238 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+)
+
+func mustParseBlob(t *testing.T, blob string) ssh.PublicKey {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		t.Fatalf("failed to decode blob: %v", err)
+	}
+	pub, err := ssh.ParsePublicKey(b)
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+	return pub
+}
+
+func blobFingerprint(t *testing.T, blob string) string {
+	t.Helper()
+	return ssh.FingerprintSHA256(mustParseBlob(t, blob))
+}
+
+func TestStoredKeyFingerprint(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         testdata.TestKey
+		want        string
+	}{
+		{
+			description: "unencrypted",
+			key:         testdata.WithoutPassphrase,
+			want:        "blob",
+		},
+		{
+			description: "encrypted OpenSSH format",
+			key:         testdata.ED25519WithPassphrase,
+			want:        "blob",
+		},
+		{
+			description: "with certificate",
+			key:         testdata.ED25519WithCertificate,
+			want:        "blob",
+		},
+		{
+			description: "encrypted PEM format",
+			key:         testdata.WithPassphrase,
+			want:        "",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			sk := &storedKey{PEMPrivateKey: tc.key.Private, Certificate: tc.key.Certificate}
+			want := tc.want
+			if want == "blob" {
+				want = blobFingerprint(t, tc.key.Blob)
+			}
+			if diff := cmp.Diff(sk.Fingerprint(), want); diff != "" {
+				t.Errorf("incorrect fingerprint; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseAgentList(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		text        string
+		want        []string
+		wantErr     error
+	}{
+		{
+			description: "multiple keys",
+			text: fmt.Sprintf("ssh-rsa %s user@host\nssh-ed25519 %s\n\n%s\n",
+				testdata.WithoutPassphrase.Blob,
+				testdata.ED25519WithoutPassphrase.Blob,
+				testdata.ED25519WithCertificate.Certificate),
+			want: []string{
+				testdata.WithoutPassphrase.Blob,
+				testdata.ED25519WithoutPassphrase.Blob,
+				testdata.ED25519WithCertificate.Blob,
+			},
+		},
+		{
+			description: "no identities",
+			text:        "The agent has no identities.\n",
+		},
+		{
+			description: "invalid line",
+			text:        fmt.Sprintf("ssh-rsa %s\nbogus\n", testdata.WithoutPassphrase.Blob),
+			wantErr:     errInvalidAgentList,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := ParseAgentList(tc.text)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			var got []string
+			for _, k := range keys {
+				got = append(got, base64.StdEncoding.EncodeToString(plainKey(k).Marshal()))
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect keys; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFingerprintLine(t *testing.T) {
+	t.Parallel()
+
+	cert, err := parseCertificate(testdata.ED25519WithCertificate.Certificate)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	testcases := []struct {
+		description string
+		pub         ssh.PublicKey
+		comment     string
+		want        string
+	}{
+		{
+			description: "rsa",
+			pub:         mustParseBlob(t, testdata.WithoutPassphrase.Blob),
+			comment:     "user@host",
+			want:        fmt.Sprintf("2048 %s user@host (RSA)", blobFingerprint(t, testdata.WithoutPassphrase.Blob)),
+		},
+		{
+			description: "ecdsa",
+			pub:         mustParseBlob(t, testdata.ECDSAWithoutPassphrase.Blob),
+			comment:     "user@host",
+			want:        fmt.Sprintf("521 %s user@host (ECDSA)", blobFingerprint(t, testdata.ECDSAWithoutPassphrase.Blob)),
+		},
+		{
+			description: "ed25519 without comment",
+			pub:         mustParseBlob(t, testdata.ED25519WithoutPassphrase.Blob),
+			want:        fmt.Sprintf("256 %s no comment (ED25519)", blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob)),
+		},
+		{
+			description: "certificate",
+			pub:         cert,
+			comment:     "user@host",
+			want:        fmt.Sprintf("256 %s user@host (ED25519-CERT)", blobFingerprint(t, testdata.ED25519WithCertificate.Blob)),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(FingerprintLine(tc.pub, tc.comment), tc.want); diff != "" {
+				t.Errorf("incorrect line; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatLoaded(t *testing.T) {
+	t.Parallel()
+
+	configured := []*ConfiguredKey{{ID: "some-id", Name: "my-key"}}
+	ours := &LoadedKey{Type: ssh.KeyAlgoED25519, Comment: commentPrefix + "some-id"}
+	ours.SetBlob(mustParseBlob(t, testdata.ED25519WithoutPassphrase.Blob).Marshal())
+	other := &LoadedKey{Type: ssh.KeyAlgoRSA, Comment: "user@host"}
+	other.SetBlob(mustParseBlob(t, testdata.WithoutPassphrase.Blob).Marshal())
+
+	got := FormatLoaded(configured, []*LoadedKey{ours, other})
+	want := strings.Join([]string{
+		fmt.Sprintf("256 %s my-key (ED25519)", blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob)),
+		fmt.Sprintf("2048 %s user@host (RSA)", blobFingerprint(t, testdata.WithoutPassphrase.Blob)),
+	}, "\n")
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect output; -got +want: %s", diff)
+	}
+}
+
+func TestMatchExternal(t *testing.T) {
+	t.Parallel()
+
+	configured := []*ConfiguredKey{
+		{Name: "unknown-key"},
+		{Name: "loaded-key", Fingerprint: blobFingerprint(t, testdata.ED25519WithoutPassphrase.Blob)},
+		{Name: "unloaded-key", Fingerprint: blobFingerprint(t, testdata.WithoutPassphrase.Blob)},
+	}
+	external := []ssh.PublicKey{
+		mustParseBlob(t, testdata.ED25519WithoutPassphrase.Blob),
+		mustParseBlob(t, testdata.ECDSAWithoutPassphrase.Blob),
+	}
+
+	got := MatchExternal(configured, external)
+	want := []*ExternalMatch{
+		{Name: "loaded-key", Fingerprint: configured[1].Fingerprint, Loaded: true},
+		{Name: "unknown-key"},
+		{Name: "unloaded-key", Fingerprint: configured[2].Fingerprint},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect matches; -got +want: %s", diff)
+	}
+}
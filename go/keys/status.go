@@ -0,0 +1,86 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Status describes the health of the background worker, to help diagnose
+// an agent that is not responding.
+type Status struct {
+	// Uptime is the number of seconds since the background worker
+	// started.
+	Uptime int64 `js:"uptime"`
+	// Connections is the number of clients currently connected to the
+	// agent.
+	Connections int `js:"connections"`
+	// LoadedKeys is the number of keys loaded into the agent, including
+	// any added by clients. A key with a certificate is counted twice.
+	LoadedKeys int `js:"loadedKeys"`
+	// LastError is the most recent error logged by the background
+	// worker, or empty if none has been logged.
+	LastError string `js:"lastError"`
+	// Storage describes the health of each storage area.
+	Storage []*StorageStatus `js:"storage"`
+}
+
+// StorageStatus describes the health of a storage area.
+type StorageStatus struct {
+	// Area is the name of the storage area: 'sync', 'local' or
+	// 'session'.
+	Area string `js:"area"`
+	// Err describes why the area cannot be read, or is empty if it can.
+	Err string `js:"err"`
+}
+
+// Status implements Manager.Status.
+func (m *DefaultManager) Status(ctx jsutil.AsyncContext) (*Status, error) {
+	loaded, err := m.agent.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+
+	s := &Status{
+		Uptime:     int64(time.Since(m.started) / time.Second),
+		LoadedKeys: len(loaded),
+		LastError:  jsutil.LastError(),
+	}
+	if m.connections != nil {
+		s.Connections = len(m.connections())
+	}
+
+	checks := []struct {
+		area  string
+		check func() error
+	}{
+		{"sync", func() error { return m.CheckSync(ctx) }},
+		{"local", func() error { _, err := m.localStorage.Get(ctx); return err }},
+		{"session", func() error { _, err := m.sessionStorage.Get(ctx); return err }},
+	}
+	for _, c := range checks {
+		ss := &StorageStatus{Area: c.area}
+		if err := c.check(); err != nil {
+			ss.Err = err.Error()
+		}
+		s.Storage = append(s.Storage, ss)
+	}
+	return s, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		syncStorage storage.Area
+		connections ConnectionSource
+		want        *Status
+		wantSyncErr bool
+	}{
+		{
+			description: "healthy",
+			syncStorage: storage.NewRaw(st.NewMemArea()),
+			connections: func() []*Connection {
+				return []*Connection{{Client: "a"}, {Client: "b"}}
+			},
+			want: &Status{
+				Connections: 2,
+				LoadedKeys:  1,
+				Storage: []*StorageStatus{
+					{Area: "sync"},
+					{Area: "local"},
+					{Area: "session"},
+				},
+			},
+		},
+		{
+			description: "sync unavailable",
+			syncStorage: storage.NewUnavailable("sync"),
+			want: &Status{
+				LoadedKeys: 1,
+				Storage: []*StorageStatus{
+					{Area: "sync"},
+					{Area: "local"},
+					{Area: "session"},
+				},
+			},
+			wantSyncErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				mgr := NewManager(agent.NewKeyring(), tc.syncStorage, storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+				mgr.SetConnectionSource(tc.connections)
+				if _, err := mgr.AddLocal(ctx, "key", testdata.WithoutPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+				if err := mgr.Load(ctx, id, ""); err != nil {
+					t.Fatalf("failed to load key: %v", err)
+				}
+
+				status, err := mgr.Status(ctx)
+				if err != nil {
+					t.Fatalf("Status failed: %v", err)
+				}
+				if status.Uptime < 0 {
+					t.Errorf("incorrect uptime: got %d, want non-negative", status.Uptime)
+				}
+				if syncErr := status.Storage[0].Err != ""; syncErr != tc.wantSyncErr {
+					t.Errorf("incorrect sync error: got %q, want error %v", status.Storage[0].Err, tc.wantSyncErr)
+				}
+				// The last error is shared with other tests, and
+				// uptime depends on the time taken.
+				opts := []cmp.Option{
+					cmpopts.IgnoreFields(Status{}, "Uptime", "LastError"),
+					cmpopts.IgnoreFields(StorageStatus{}, "Err"),
+				}
+				if diff := cmp.Diff(status, tc.want, opts...); diff != "" {
+					t.Errorf("incorrect status; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,150 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+const (
+	// maxTagLength is the maximum length of a tag, in characters.
+	maxTagLength = 32
+	// maxTags is the maximum number of tags that may be attached to a
+	// key.
+	maxTags = 16
+)
+
+var errInvalidTag = i18n.NewError("errInvalidTag")
+
+// sortTags sorts the tags in place, ignoring case.
+func sortTags(tags []string) {
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i]) < strings.ToLower(tags[j]) })
+}
+
+// normalizeTags trims the tags, and removes those that are empty or
+// duplicate another (ignoring case). The remaining tags are sorted. nil is
+// returned if no tags remain.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	var result []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > maxTagLength || strings.Contains(t, ",") {
+			return nil, fmt.Errorf("%w: tags must be at most %d characters, and not contain commas", errInvalidTag, maxTagLength)
+		}
+		seen[strings.ToLower(t)] = true
+		result = append(result, t)
+	}
+	if len(result) > maxTags {
+		return nil, fmt.Errorf("%w: at most %d tags may be attached to a key", errInvalidTag, maxTags)
+	}
+	sortTags(result)
+	return result, nil
+}
+
+// HasTag returns true if the tag is attached to the key, ignoring case.
+func (k *ConfiguredKey) HasTag(tag string) bool {
+	for _, t := range k.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags returns the tags attached to any of the specified keys, sorted. Tags
+// differing only in case are reported once.
+func Tags(configured []*ConfiguredKey) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, k := range configured {
+		for _, t := range k.Tags {
+			if !seen[strings.ToLower(t)] {
+				seen[strings.ToLower(t)] = true
+				result = append(result, t)
+			}
+		}
+	}
+	sortTags(result)
+	return result
+}
+
+// SetTags implements Manager.SetTags.
+func (m *DefaultManager) SetTags(ctx jsutil.AsyncContext, id ID, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		if err := keys.Update(ctx, byID, func(sk *storedKey) { sk.Tags = tags }); err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnloadTagged implements Manager.UnloadTagged.
+func (m *DefaultManager) UnloadTagged(ctx jsutil.AsyncContext, tag string) ([]ID, error) {
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to enumerate loaded keys: %w", errAgentUnloadFailed, err)
+	}
+	isLoaded := map[ID]bool{}
+	for _, l := range loaded {
+		isLoaded[l.ID()] = true
+	}
+
+	var unloaded []ID
+	var errs []error
+	for _, k := range configured {
+		id := ID(k.ID)
+		if !k.HasTag(tag) || !isLoaded[id] {
+			continue
+		}
+		if err := m.lockedUnload(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unload key ID %s: %w", id, err))
+			continue
+		}
+		unloaded = append(unloaded, id)
+	}
+	return unloaded, errors.Join(errs...)
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSetTags(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		local       bool
+		byID        ID
+		byName      string
+		tags        []string
+		wantTags    []string
+		wantErr     error
+	}{
+		{
+			description: "set tags",
+			byName:      "good-key",
+			tags:        []string{"work", "prod"},
+			wantTags:    []string{"prod", "work"},
+		},
+		{
+			description: "set tags on local key",
+			local:       true,
+			byName:      "good-key",
+			tags:        []string{"work"},
+			wantTags:    []string{"work"},
+		},
+		{
+			description: "normalize tags",
+			byName:      "good-key",
+			tags:        []string{" Work ", "", "work", "Prod"},
+			wantTags:    []string{"Prod", "Work"},
+		},
+		{
+			description: "clear tags",
+			byName:      "good-key",
+			tags:        []string{" "},
+		},
+		{
+			description: "reject tag with comma",
+			byName:      "good-key",
+			tags:        []string{"a,b"},
+			wantErr:     errInvalidTag,
+		},
+		{
+			description: "reject overlong tag",
+			byName:      "good-key",
+			tags:        []string{"0123456789012345678901234567890123456789"},
+			wantErr:     errInvalidTag,
+		},
+		{
+			description: "fail on invalid ID",
+			byID:        ID("bogus-id"),
+			tags:        []string{"work"},
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, tc.byID, tc.byName)
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+				if tc.local {
+					if err := mgr.SetLocal(ctx, id, true); err != nil {
+						t.Fatalf("failed to make key local: %v", err)
+					}
+				}
+
+				err = mgr.SetTags(ctx, id, tc.tags)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to enumerate configured keys: %v", err)
+				}
+				if diff := cmp.Diff(Tags(configured), tc.wantTags); diff != "" {
+					t.Errorf("incorrect tags; -got +want: %s", diff)
+				}
+
+				// Tags should be retained when the key moves between
+				// storage areas.
+				if tc.wantErr == nil {
+					if err := mgr.SetLocal(ctx, id, !tc.local); err != nil {
+						t.Fatalf("failed to change key storage: %v", err)
+					}
+					configured, err := mgr.Configured(ctx)
+					if err != nil {
+						t.Fatalf("failed to enumerate configured keys: %v", err)
+					}
+					if diff := cmp.Diff(Tags(configured), tc.wantTags); diff != "" {
+						t.Errorf("incorrect tags after changing storage; -got +want: %s", diff)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestUnloadTagged(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "work-key",
+				PEMPrivateKey: testdata.WithPassphrase.Private,
+				Load:          true,
+				Passphrase:    testdata.WithPassphrase.Passphrase,
+			},
+			{
+				Name:          "other-key",
+				PEMPrivateKey: testdata.WithoutPassphrase.Private,
+				Load:          true,
+			},
+			{
+				Name:          "unloaded-work-key",
+				PEMPrivateKey: testdata.ECDSAWithoutPassphrase.Private,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		for _, name := range []string{"work-key", "unloaded-work-key"} {
+			id, err := findKey(ctx, mgr, InvalidID, name)
+			if err != nil {
+				t.Fatalf("failed to find key: %v", err)
+			}
+			if err := mgr.SetTags(ctx, id, []string{"Work"}); err != nil {
+				t.Fatalf("failed to set tags: %v", err)
+			}
+		}
+		wantID, err := findKey(ctx, mgr, InvalidID, "work-key")
+		if err != nil {
+			t.Fatalf("failed to find key: %v", err)
+		}
+
+		unloaded, err := mgr.UnloadTagged(ctx, "work")
+		if err != nil {
+			t.Errorf("failed to unload tagged keys: %v", err)
+		}
+		if diff := cmp.Diff(unloaded, []ID{wantID}); diff != "" {
+			t.Errorf("incorrect unloaded keys; -got +want: %s", diff)
+		}
+
+		loaded, err := mgr.Loaded(ctx)
+		if err != nil {
+			t.Errorf("failed to get loaded keys: %v", err)
+		}
+		if diff := cmp.Diff(loadedKeyBlobs(loaded), []string{testdata.WithoutPassphrase.Blob}); diff != "" {
+			t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+		}
+	})
+}
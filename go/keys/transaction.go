@@ -0,0 +1,72 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// inTransaction invokes f with a manager whose changes to configured keys are
+// staged, and commits them once f returns successfully. If f fails, no changes
+// are made.
+//
+// Synced and local storage are committed separately, synced storage first.
+// Should the latter fail, the changes to synced storage remain; compound
+// updates should therefore avoid removing a key from synced storage in favor
+// of one added to local storage.
+//
+// If the keys in synced storage may be encrypted (see SetKeySource), changes
+// are encrypted before being staged, such that private keys are never
+// written in the clear, even in the commit record.
+func (m *DefaultManager) inTransaction(ctx jsutil.AsyncContext, f func(tm *DefaultManager) error) error {
+	synced := storage.NewTransaction(m.syncStorage)
+	local := storage.NewTransaction(m.localStorage)
+
+	var syncedKeys storage.Area = synced
+	if m.encryptedSync != nil {
+		syncedKeys = m.encryptedSync.WithStore(synced)
+	}
+
+	tm := *m
+	tm.storedKeys = storage.NewTyped[storedKey](syncedKeys, storedKeyPrefixes)
+	tm.localKeys = storage.NewTyped[storedKey](local, storedKeyPrefixes)
+	if err := f(&tm); err != nil {
+		return err
+	}
+
+	if err := synced.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit changes to synced storage: %w", err)
+	}
+	if err := local.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit changes to local storage: %w", err)
+	}
+	return nil
+}
+
+// RecoverTransactions completes changes to configured keys that were
+// interrupted (e.g., the worker was suspended) while being committed.
+func (m *DefaultManager) RecoverTransactions(ctx jsutil.AsyncContext) {
+	jsutil.LogDebug("DefaultManager.RecoverTransactions: Recovering interrupted changes")
+	for _, area := range []storage.Area{m.syncStorage, m.localStorage} {
+		if err := storage.RecoverTransactions(ctx, area); err != nil {
+			jsutil.LogError("failed to recover interrupted changes: %v", err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+// UndoPeriod is how long a removed or unloaded key is held after the
+// operation, during which it can be restored with Manager.UndoRemove or
+// Manager.UndoUnload.
+const UndoPeriod = 30 * time.Second
+
+const (
+	// heldRemoved identifies a key held after it was removed.
+	heldRemoved = "remove"
+	// heldUnloaded identifies a key held after it was unloaded.
+	heldUnloaded = "unload"
+)
+
+// heldKey is the raw object stored in session storage for a key that was
+// recently removed or unloaded, such that the operation can be undone.
+type heldKey struct {
+	ID string `js:"id"`
+	// Op is the operation that can be undone; one of the held* values.
+	Op string `js:"op"`
+	// Expires is when the key is discarded, in milliseconds since the
+	// Unix epoch.
+	Expires int64 `js:"expires"`
+	// Stored is the removed key, for heldRemoved.
+	Stored *storedKey `js:"stored"`
+	// Local indicates that the removed key was stored only on the local
+	// device, for heldRemoved.
+	Local bool `js:"local"`
+	// Session is the decrypted key that was unloaded, for heldUnloaded.
+	Session *sessionKey `js:"session"`
+}
+
+var (
+	// heldKeyPrefixes is the prefix for held keys in session storage.
+	heldKeyPrefixes = []string{"held"}
+)
+
+var errUndoExpired = i18n.NewError("errUndoExpired")
+
+// hold retains the key until UndoPeriod elapses, replacing any held
+// previously for the same operation on the same key. Failures are logged;
+// they must not fail the operation being held.
+func (m *DefaultManager) hold(ctx jsutil.AsyncContext, hk *heldKey) {
+	now := time.Now()
+	hk.Expires = now.Add(UndoPeriod).UnixMilli()
+	if err := m.heldKeys.Delete(ctx, func(h *heldKey) bool {
+		return (h.ID == hk.ID && h.Op == hk.Op) || h.expired(now)
+	}); err != nil {
+		jsutil.LogError("DefaultManager.hold: failed to replace held key: %v", err)
+		return
+	}
+	if err := m.heldKeys.Write(ctx, hk); err != nil {
+		jsutil.LogError("DefaultManager.hold: failed to hold key ID %s: %v", hk.ID, err)
+	}
+}
+
+// expired returns true if the held key should be discarded.
+func (h *heldKey) expired(now time.Time) bool {
+	return now.UnixMilli() >= h.Expires
+}
+
+// takeHeld returns the key held for the operation on the key with the
+// specified ID, and stops holding it. An error is returned if none is held,
+// or UndoPeriod has elapsed.
+func (m *DefaultManager) takeHeld(ctx jsutil.AsyncContext, id ID, op string) (*heldKey, error) {
+	match := func(h *heldKey) bool { return ID(h.ID) == id && h.Op == op }
+	hk, err := m.heldKeys.Read(ctx, match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read held key: %w", err)
+	}
+	if hk == nil || hk.expired(time.Now()) {
+		return nil, fmt.Errorf("%w: %w", errUndoExpired, ErrKeyNotFound)
+	}
+	if err := m.heldKeys.Delete(ctx, match); err != nil {
+		return nil, fmt.Errorf("failed to release held key: %w", err)
+	}
+	return hk, nil
+}
+
+// DiscardExpiredHeld discards the keys held for longer than UndoPeriod, and
+// returns their IDs. Held keys may include decrypted keys that were unloaded,
+// so they should not linger; it should be invoked periodically.
+func (m *DefaultManager) DiscardExpiredHeld(ctx jsutil.AsyncContext, now time.Time) ([]ID, error) {
+	held, err := m.heldKeys.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read held keys: %w", err)
+	}
+	var discarded []ID
+	for _, h := range held {
+		if h.expired(now) {
+			discarded = append(discarded, ID(h.ID))
+		}
+	}
+	if len(discarded) == 0 {
+		return nil, nil
+	}
+	if err := m.heldKeys.Delete(ctx, func(h *heldKey) bool { return h.expired(now) }); err != nil {
+		return nil, fmt.Errorf("failed to delete held keys: %w", err)
+	}
+	return discarded, nil
+}
+
+// UndoRemove implements Manager.UndoRemove.
+func (m *DefaultManager) UndoRemove(ctx jsutil.AsyncContext, id ID) error {
+	hk, err := m.takeHeld(ctx, id, heldRemoved)
+	if err != nil {
+		return err
+	}
+	if hk.Stored == nil {
+		return fmt.Errorf("%w: held key is missing", ErrKeyNotFound)
+	}
+
+	store := m.storedKeys
+	if hk.Local {
+		store = m.localKeys
+	} else if err := m.CheckSync(ctx); err != nil {
+		jsutil.Log("DefaultManager.UndoRemove: storing key locally: %v", err)
+		store = m.localKeys
+	}
+	if err := store.Write(ctx, hk.Stored); err != nil {
+		return fmt.Errorf("failed to restore key: %w", err)
+	}
+	return nil
+}
+
+// UndoUnload implements Manager.UndoUnload.
+func (m *DefaultManager) UndoUnload(ctx jsutil.AsyncContext, id ID) error {
+	hk, err := m.takeHeld(ctx, id, heldUnloaded)
+	if err != nil {
+		return err
+	}
+	if hk.Session == nil {
+		return fmt.Errorf("%w: held key is missing", ErrKeyNotFound)
+	}
+	// The key may have been removed or replaced since it was unloaded.
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+	if m.agentLocked.Load() {
+		return fmt.Errorf("%w: unlock it to load keys", ErrAgentLocked)
+	}
+
+	jid, err := m.journal.Begin(ctx, journalOpLoad, vert.ValueOf(&journalData{ID: string(id)}).JSValue())
+	if err != nil {
+		return fmt.Errorf("failed to record load: %w", err)
+	}
+	if err := m.addToAgent(id, decryptedKey(hk.Session.PrivateKey), key.Certificate); err != nil {
+		return err
+	}
+	sk := &sessionKey{
+		ID:          string(id),
+		PrivateKey:  hk.Session.PrivateKey,
+		Certificate: key.Certificate,
+	}
+	if err := m.sessionKeys.Write(ctx, sk); err != nil {
+		return fmt.Errorf("failed to store loaded key to session: %w", err)
+	}
+	if key.Persist {
+		if err := m.persistedKeys.Write(ctx, sk); err != nil {
+			return fmt.Errorf("failed to persist loaded key: %w", err)
+		}
+	}
+	if err := m.journal.Commit(ctx, jid); err != nil {
+		return fmt.Errorf("failed to record load completion: %w", err)
+	}
+	return nil
+}
+
+// heldForRemove returns the key to hold once the key with the specified ID is
+// removed, or nil if it is not configured.
+func (m *DefaultManager) heldForRemove(ctx jsutil.AsyncContext, id ID) (*heldKey, error) {
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, s := range []struct {
+		keys  *storage.Typed[storedKey]
+		local bool
+	}{{m.storedKeys, false}, {m.localKeys, true}} {
+		key, err := s.keys.Read(ctx, byID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+		if key != nil {
+			return &heldKey{ID: string(id), Op: heldRemoved, Stored: key, Local: s.local}, nil
+		}
+	}
+	return nil, nil
+}
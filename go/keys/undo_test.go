@@ -0,0 +1,257 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestUndoRemove(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description    string
+		remove         bool
+		expire         bool
+		wantConfigured []string
+		wantErr        error
+	}{
+		{
+			description:    "restore removed key",
+			remove:         true,
+			wantConfigured: []string{"good-key"},
+		},
+		{
+			description: "fail after undo period",
+			remove:      true,
+			expire:      true,
+			wantErr:     ErrKeyNotFound,
+		},
+		{
+			description:    "fail if not removed",
+			wantConfigured: []string{"good-key"},
+			wantErr:        ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+				if err := mgr.SetConfirm(ctx, id, true); err != nil {
+					t.Fatalf("failed to configure key: %v", err)
+				}
+
+				if tc.remove {
+					if err := mgr.Remove(ctx, id); err != nil {
+						t.Fatalf("failed to remove key: %v", err)
+					}
+				}
+				if tc.expire {
+					if _, err := mgr.DiscardExpiredHeld(ctx, time.Now().Add(UndoPeriod)); err != nil {
+						t.Fatalf("failed to discard held keys: %v", err)
+					}
+				}
+
+				err = mgr.UndoRemove(ctx, id)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Errorf("failed to get configured keys: %v", err)
+				}
+				if diff := cmp.Diff(configuredKeyNames(configured), tc.wantConfigured); diff != "" {
+					t.Errorf("incorrect configured keys; -got +want: %s", diff)
+				}
+				// The restored key keeps its ID and settings.
+				for _, k := range configured {
+					if ID(k.ID) != id || !k.Confirm {
+						t.Errorf("restored key changed: got ID %s, confirm %t", k.ID, k.Confirm)
+					}
+				}
+
+				// A removal can only be undone once.
+				if err := mgr.UndoRemove(ctx, id); !cmp.Equal(err, ErrKeyNotFound, cmpopts.EquateErrors()) {
+					t.Errorf("incorrect error undoing again: got %v, want %v", err, ErrKeyNotFound)
+				}
+			})
+		})
+	}
+}
+
+func TestUndoUnload(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		remove      bool
+		expire      bool
+		wantLoaded  []string
+		wantErr     error
+	}{
+		{
+			description: "reload unloaded key",
+			wantLoaded:  []string{testdata.WithPassphrase.Blob},
+		},
+		{
+			description: "fail after undo period",
+			expire:      true,
+			wantErr:     ErrKeyNotFound,
+		},
+		{
+			description: "fail if key removed",
+			remove:      true,
+			wantErr:     ErrKeyNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+						Load:          true,
+						Passphrase:    testdata.WithPassphrase.Passphrase,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				id, err := findKey(ctx, mgr, InvalidID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				if err := mgr.Unload(ctx, id); err != nil {
+					t.Fatalf("failed to unload key: %v", err)
+				}
+				if tc.remove {
+					if err := mgr.Remove(ctx, id); err != nil {
+						t.Fatalf("failed to remove key: %v", err)
+					}
+				}
+				if tc.expire {
+					if _, err := mgr.DiscardExpiredHeld(ctx, time.Now().Add(UndoPeriod)); err != nil {
+						t.Fatalf("failed to discard held keys: %v", err)
+					}
+				}
+
+				err = mgr.UndoUnload(ctx, id)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Errorf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(loadedKeyBlobs(loaded), tc.wantLoaded); diff != "" {
+					t.Errorf("incorrect loaded keys; -got +want: %s", diff)
+				}
+				gotSessionKeys, err := sessionKeyIDs(ctx, mgr.sessionKeys)
+				if err != nil {
+					t.Errorf("failed to get session keys: %v", err)
+				}
+				if diff := cmp.Diff(gotSessionKeys, loadedKeyIDs(loaded), idSlice); diff != "" {
+					t.Errorf("incorrect session keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestDiscardExpiredHeld(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		sessionStorage := storage.NewRaw(st.NewMemArea())
+		mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+			{
+				Name:          "good-key",
+				PEMPrivateKey: testdata.WithPassphrase.Private,
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to initialize manager: %v", err)
+		}
+		id, err := findKey(ctx, mgr, InvalidID, "good-key")
+		if err != nil {
+			t.Fatalf("failed to find key: %v", err)
+		}
+		if err := mgr.Remove(ctx, id); err != nil {
+			t.Fatalf("failed to remove key: %v", err)
+		}
+
+		// Keys are held until the undo period elapses.
+		discarded, err := mgr.DiscardExpiredHeld(ctx, time.Now())
+		if err != nil {
+			t.Errorf("failed to discard held keys: %v", err)
+		}
+		if len(discarded) > 0 {
+			t.Errorf("unexpectedly discarded keys %v", discarded)
+		}
+		discarded, err = mgr.DiscardExpiredHeld(ctx, time.Now().Add(UndoPeriod))
+		if err != nil {
+			t.Errorf("failed to discard held keys: %v", err)
+		}
+		if diff := cmp.Diff(discarded, []ID{id}); diff != "" {
+			t.Errorf("incorrect discarded keys; -got +want: %s", diff)
+		}
+		held, err := mgr.heldKeys.ReadAll(ctx)
+		if err != nil {
+			t.Errorf("failed to read held keys: %v", err)
+		}
+		if len(held) > 0 {
+			t.Errorf("held keys remain after discarding: %v", held)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+// Update implements Manager.Update.
+func (m *DefaultManager) Update(ctx jsutil.AsyncContext, id ID, pemPrivateKey string) error {
+	pemPrivateKey, cert, err := splitCertificate(pemPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	key, err := m.readStoredKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if key == nil {
+		return fmt.Errorf("%w: failed to find key with ID %s", ErrKeyNotFound, id)
+	}
+
+	updated := *key
+	updated.PEMPrivateKey = pemPrivateKey
+	updated.Certificate = cert
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	if _, err := inspectKey(pemPrivateKey); err != nil {
+		return err
+	}
+	if updated.AutoLoad && updated.Encrypted() {
+		return errAutoLoadEncrypted
+	}
+	checksum, err := m.checksumFunc(ctx)
+	if err != nil {
+		return err
+	}
+	updated.Checksum = checksum(&updated)
+
+	// Unload the previous key material; the agent would otherwise continue
+	// to sign with it under the key's ID.
+	loaded, err := m.Loaded(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+	}
+	for _, l := range loaded {
+		if l.ID() == id {
+			jsutil.LogDebug("DefaultManager.Update: unloading previous key material for %s", id)
+			if err := m.lockedUnload(ctx, id); err != nil {
+				return fmt.Errorf("failed to unload key: %w", err)
+			}
+			break
+		}
+	}
+
+	byID := func(sk *storedKey) bool { return ID(sk.ID) == id }
+	for _, keys := range []*storage.Typed[storedKey]{m.storedKeys, m.localKeys} {
+		err := keys.Update(ctx, byID, func(sk *storedKey) {
+			sk.PEMPrivateKey = updated.PEMPrivateKey
+			sk.Certificate = updated.Certificate
+			sk.Checksum = updated.Checksum
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update key: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description     string
+		load            bool
+		autoLoad        bool
+		byID            ID
+		pemPrivateKey   string
+		wantErr         error
+		wantFingerprint string
+		wantCertificate bool
+		wantLoaded      int
+	}{
+		{
+			description:     "replace key",
+			pemPrivateKey:   testdata.ED25519WithoutPassphrase.Private,
+			wantFingerprint: testdata.ED25519WithoutPassphrase.Blob,
+		},
+		{
+			description:     "replace loaded key",
+			load:            true,
+			pemPrivateKey:   testdata.ED25519WithoutPassphrase.Private,
+			wantFingerprint: testdata.ED25519WithoutPassphrase.Blob,
+		},
+		{
+			description:     "replace key with certificate",
+			pemPrivateKey:   testdata.ED25519WithCertificate.Private + "\n" + testdata.ED25519WithCertificate.Certificate + "\n",
+			wantFingerprint: testdata.ED25519WithCertificate.Blob,
+			wantCertificate: true,
+		},
+		{
+			description:     "fail on invalid ID",
+			load:            true,
+			byID:            ID("bogus-id"),
+			pemPrivateKey:   testdata.ED25519WithoutPassphrase.Private,
+			wantErr:         ErrKeyNotFound,
+			wantFingerprint: testdata.WithoutPassphrase.Blob,
+			wantLoaded:      1,
+		},
+		{
+			description:     "fail on key that is not PEM-encoded",
+			load:            true,
+			pemPrivateKey:   "bogus-key",
+			wantErr:         errMalformedKey,
+			wantFingerprint: testdata.WithoutPassphrase.Blob,
+			wantLoaded:      1,
+		},
+		{
+			description:     "fail on encrypted key loaded at startup",
+			autoLoad:        true,
+			pemPrivateKey:   testdata.WithPassphrase.Private,
+			wantErr:         errAutoLoadEncrypted,
+			wantFingerprint: testdata.WithoutPassphrase.Blob,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "good-key",
+						PEMPrivateKey: testdata.WithoutPassphrase.Private,
+						Load:          tc.load,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				origID, err := findKey(ctx, mgr, InvalidID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+				if tc.autoLoad {
+					if err := mgr.SetAutoLoad(ctx, origID, true); err != nil {
+						t.Fatalf("failed to configure auto-load: %v", err)
+					}
+				}
+				id, err := findKey(ctx, mgr, tc.byID, "good-key")
+				if err != nil {
+					t.Fatalf("failed to find key: %v", err)
+				}
+
+				err = mgr.Update(ctx, id, tc.pemPrivateKey)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				// The key keeps its ID and name.
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					t.Fatalf("failed to get configured keys: %v", err)
+				}
+				if len(configured) != 1 {
+					t.Fatalf("incorrect number of configured keys: got %d, want 1", len(configured))
+				}
+				got := configured[0]
+				if diff := cmp.Diff([]string{got.ID, got.Name}, []string{string(origID), "good-key"}); diff != "" {
+					t.Errorf("incorrect key; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(got.Fingerprint, ssh.FingerprintSHA256(mustParseBlob(t, tc.wantFingerprint))); diff != "" {
+					t.Errorf("incorrect fingerprint; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(got.Certificate != nil, tc.wantCertificate); diff != "" {
+					t.Errorf("incorrect certificate; -got +want: %s", diff)
+				}
+				if got.ChecksumMismatch {
+					t.Errorf("unexpected checksum mismatch")
+				}
+
+				// A key that was replaced is unloaded.
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					t.Fatalf("failed to get loaded keys: %v", err)
+				}
+				if diff := cmp.Diff(len(loaded), tc.wantLoaded); diff != "" {
+					t.Errorf("incorrect number of loaded keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
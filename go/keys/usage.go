@@ -0,0 +1,135 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+// StorageUsage describes the storage used by the configured keys.
+type StorageUsage struct {
+	// Synced is the usage of synced storage.
+	Synced *AreaUsage `js:"synced"`
+	// Local is the usage of storage on the local device.
+	Local *AreaUsage `js:"local"`
+	// Keys is the storage used by each configured key.
+	Keys []*KeyUsage `js:"keys"`
+}
+
+// AreaUsage describes the usage of a storage area.
+type AreaUsage struct {
+	// BytesInUse is the number of bytes used by all data in the area,
+	// not just keys.
+	BytesInUse int `js:"bytesInUse"`
+	// Quota is the number of bytes that may be stored in the area, or 0
+	// if it is not known.
+	Quota int `js:"quota"`
+}
+
+// Remaining returns the number of bytes that may still be stored in the area,
+// or -1 if the quota is not known.
+func (a *AreaUsage) Remaining() int {
+	if a.Quota <= 0 {
+		return -1
+	}
+	if a.BytesInUse > a.Quota {
+		return 0
+	}
+	return a.Quota - a.BytesInUse
+}
+
+// KeyUsage describes the storage used by a configured key.
+type KeyUsage struct {
+	// ID is the unique ID of the key.
+	ID string `js:"id"`
+	// Local indicates if the key is stored only on the local device.
+	Local bool `js:"local"`
+	// Bytes is the number of bytes used by the key.
+	Bytes int `js:"bytes"`
+}
+
+// StorageUsage implements Manager.StorageUsage.
+func (m *DefaultManager) StorageUsage(ctx jsutil.AsyncContext) (*StorageUsage, error) {
+	synced, err := areaUsage(ctx, m.syncStorage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get synced storage usage: %w", err)
+	}
+	local, err := areaUsage(ctx, m.localStorage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local storage usage: %w", err)
+	}
+
+	configured, err := m.Configured(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*KeyUsage
+	for _, k := range configured {
+		store := m.storedKeys
+		if k.Local {
+			store = m.localKeys
+		}
+		id := k.ID
+		n, err := store.BytesInUse(ctx, func(sk *storedKey) bool { return sk.ID == id })
+		if err != nil {
+			return nil, fmt.Errorf("failed to get usage for key %s: %w", k.Name, err)
+		}
+		keys = append(keys, &KeyUsage{ID: k.ID, Local: k.Local, Bytes: n})
+	}
+
+	return &StorageUsage{Synced: synced, Local: local, Keys: keys}, nil
+}
+
+// areaUsage returns the usage of the supplied area.
+func areaUsage(ctx jsutil.AsyncContext, area storage.Area) (*AreaUsage, error) {
+	n, err := area.BytesInUse(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &AreaUsage{BytesInUse: n, Quota: area.Quota()}, nil
+}
+
+// EstimateBytes returns approximately the number of bytes of storage required
+// to add a key with the specified name and private key (in any format
+// accepted by Add). It allows callers to check whether the key will fit
+// before adding it.
+func EstimateBytes(name, pemPrivateKey string) int {
+	pem, cert, err := splitCertificate(pemPrivateKey)
+	if err != nil {
+		pem, cert = pemPrivateKey, ""
+	}
+	sk := &storedKey{
+		// The longest ID that may be allocated.
+		ID:            strconv.FormatInt(math.MaxInt64, 10),
+		Name:          name,
+		PEMPrivateKey: pem,
+		Certificate:   cert,
+	}
+	// Keyed checksums are the longer.
+	sk.Checksum = sk.computeMAC(make([]byte, sha256.Size))
+	// As in Chrome, each item uses the length of its key and of its value
+	// in JSON format.
+	key := storedKeyPrefixes[0] + "." + sk.ID
+	return len(key) + len(jsutil.ToJSON(vert.ValueOf(sk).JSValue()))
+}
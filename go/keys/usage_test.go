@@ -0,0 +1,119 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestStorageUsage(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		syncStorage := storage.NewRaw(st.NewMemArea())
+		localStorage := storage.NewRaw(st.NewMemArea())
+		mgr := NewManager(agent.NewKeyring(), syncStorage, localStorage, storage.NewRaw(st.NewMemArea()))
+		if _, err := mgr.Add(ctx, "synced-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		if _, err := mgr.AddLocal(ctx, "local-key", testdata.ED25519WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		usage, err := mgr.StorageUsage(ctx)
+		if err != nil {
+			t.Fatalf("StorageUsage failed: %v", err)
+		}
+
+		// Keys are the only data stored, so each area's usage is that
+		// of its key.
+		if len(usage.Keys) != 2 {
+			t.Fatalf("incorrect number of keys: got %d, want 2", len(usage.Keys))
+		}
+		for _, k := range usage.Keys {
+			area := usage.Synced
+			if k.Local {
+				area = usage.Local
+			}
+			if k.Bytes == 0 || k.Bytes != area.BytesInUse {
+				t.Errorf("incorrect usage for key %s: got %d, want %d", k.ID, k.Bytes, area.BytesInUse)
+			}
+		}
+	})
+}
+
+func TestStorageUsageSyncUnavailable(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr := NewManager(agent.NewKeyring(), storage.NewUnavailable("sync"), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		usage, err := mgr.StorageUsage(ctx)
+		if err != nil {
+			t.Fatalf("StorageUsage failed: %v", err)
+		}
+		if usage.Synced.BytesInUse != 0 || usage.Synced.Remaining() != -1 {
+			t.Errorf("incorrect synced usage: %+v", usage.Synced)
+		}
+	})
+}
+
+func TestAreaUsageRemaining(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		usage *AreaUsage
+		want  int
+	}{
+		{usage: &AreaUsage{BytesInUse: 100, Quota: 1000}, want: 900},
+		{usage: &AreaUsage{BytesInUse: 1100, Quota: 1000}, want: 0},
+		{usage: &AreaUsage{BytesInUse: 100}, want: -1},
+	}
+
+	for _, tc := range testcases {
+		if got := tc.usage.Remaining(); got != tc.want {
+			t.Errorf("incorrect Remaining for %+v: got %d, want %d", tc.usage, got, tc.want)
+		}
+	}
+}
+
+func TestEstimateBytes(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mgr := NewManager(agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		if _, err := mgr.Add(ctx, "new-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		usage, err := mgr.StorageUsage(ctx)
+		if err != nil {
+			t.Fatalf("StorageUsage failed: %v", err)
+		}
+
+		// The estimate allows for the longest ID, so may exceed the
+		// actual usage slightly.
+		estimate := EstimateBytes("new-key", testdata.WithoutPassphrase.Private)
+		actual := usage.Keys[0].Bytes
+		if estimate < actual || estimate > actual+len("9223372036854775807") {
+			t.Errorf("incorrect estimate: got %d, want approximately %d", estimate, actual)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/selftest"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errInconsistent = i18n.NewError("errInconsistent")
+)
+
+// VerifyChecks returns checks that verify the keys reported by mgr are
+// usable and consistent with the state of the agent. Unlike an end-to-end
+// test, the checks only read state; they are safe to run against the user's
+// real keys.
+func VerifyChecks(mgr Manager) []selftest.Check {
+	return []selftest.Check{
+		{
+			Name: "messaging round trip",
+			Run: func(ctx jsutil.AsyncContext) error {
+				if _, err := mgr.Configured(ctx); err != nil {
+					return fmt.Errorf("failed to enumerate configured keys: %w", err)
+				}
+				if _, err := mgr.Loaded(ctx); err != nil {
+					return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "stored keys readable",
+			Run: func(ctx jsutil.AsyncContext) error {
+				malformed, err := mgr.Malformed(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to enumerate malformed keys: %w", err)
+				}
+				var errs []error
+				for _, mk := range malformed {
+					errs = append(errs, fmt.Errorf("%w: stored key %q cannot be read: %s", errInconsistent, mk.Name, mk.Reason))
+				}
+				return errors.Join(errs...)
+			},
+		},
+		{
+			Name: "configured keys",
+			Run: func(ctx jsutil.AsyncContext) error {
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to enumerate configured keys: %w", err)
+				}
+				return verifyConfigured(configured)
+			},
+		},
+		{
+			Name: "loaded keys",
+			Run: func(ctx jsutil.AsyncContext) error {
+				configured, err := mgr.Configured(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to enumerate configured keys: %w", err)
+				}
+				loaded, err := mgr.Loaded(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to enumerate loaded keys: %w", err)
+				}
+				return verifyLoaded(configured, loaded)
+			},
+		},
+	}
+}
+
+// verifyConfigured checks that the encryption state and fingerprint of each
+// configured key are consistent.
+func verifyConfigured(configured []*ConfiguredKey) error {
+	var errs []error
+	for _, k := range configured {
+		// An unencrypted key can always be parsed, so its fingerprint
+		// is known.
+		if !k.Encrypted && k.Fingerprint == "" {
+			errs = append(errs, fmt.Errorf("%w: key %q is not encrypted, but its fingerprint cannot be determined", errInconsistent, k.Name))
+		}
+		if k.Encrypted && k.AutoLoad {
+			errs = append(errs, fmt.Errorf("%w: key %q is encrypted, but configured to load automatically", errInconsistent, k.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifyLoaded checks that each key loaded by the extension is configured,
+// and matches the configured key's fingerprint.
+func verifyLoaded(configured []*ConfiguredKey, loaded []*LoadedKey) error {
+	byID := map[ID]*ConfiguredKey{}
+	for _, k := range configured {
+		byID[ID(k.ID)] = k
+	}
+
+	var errs []error
+	for _, l := range loaded {
+		id := l.ID()
+		if id == InvalidID {
+			// Not loaded by the extension.
+			continue
+		}
+		k, ok := byID[id]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: loaded key ID %s is not configured", errInconsistent, id))
+			continue
+		}
+		pub, err := ssh.ParsePublicKey(l.Blob())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: loaded key %q cannot be parsed: %w", errInconsistent, k.Name, err))
+			continue
+		}
+		if k.Fingerprint == "" {
+			// Cannot be determined without the passphrase.
+			continue
+		}
+		if fp := ssh.FingerprintSHA256(plainKey(pub)); fp != k.Fingerprint {
+			errs = append(errs, fmt.Errorf("%w: loaded key %q has fingerprint %s, but configured key has %s", errInconsistent, k.Name, fp, k.Fingerprint))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestVerifyConfigured(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		configured  []*ConfiguredKey
+		wantErr     error
+	}{
+		{
+			description: "consistent keys",
+			configured: []*ConfiguredKey{
+				{Name: "unencrypted", Fingerprint: "SHA256:abc"},
+				{Name: "encrypted", Encrypted: true},
+				{Name: "auto-load", AutoLoad: true, Fingerprint: "SHA256:def"},
+			},
+		},
+		{
+			description: "unencrypted key without fingerprint",
+			configured: []*ConfiguredKey{
+				{Name: "unencrypted"},
+			},
+			wantErr: errInconsistent,
+		},
+		{
+			description: "encrypted key loaded automatically",
+			configured: []*ConfiguredKey{
+				{Name: "encrypted", Encrypted: true, AutoLoad: true},
+			},
+			wantErr: errInconsistent,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifyConfigured(tc.configured)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyLoaded(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		configured  []*ConfiguredKey
+		loaded      []*LoadedKey
+		wantErr     error
+	}{
+		{
+			description: "loaded key matches",
+			configured: []*ConfiguredKey{
+				{ID: "1", Name: "key", Fingerprint: blobFingerprint(t, testdata.WithoutPassphrase.Blob)},
+			},
+			loaded: []*LoadedKey{
+				{InternalBlob: testdata.WithoutPassphrase.Blob, Comment: commentPrefix + "1"},
+			},
+		},
+		{
+			description: "fingerprint unknown",
+			configured: []*ConfiguredKey{
+				{ID: "1", Name: "key", Encrypted: true},
+			},
+			loaded: []*LoadedKey{
+				{InternalBlob: testdata.WithPassphrase.Blob, Comment: commentPrefix + "1"},
+			},
+		},
+		{
+			description: "ignore keys loaded by others",
+			loaded: []*LoadedKey{
+				{InternalBlob: testdata.WithoutPassphrase.Blob, Comment: "someone@example.com"},
+			},
+		},
+		{
+			description: "loaded key not configured",
+			loaded: []*LoadedKey{
+				{InternalBlob: testdata.WithoutPassphrase.Blob, Comment: commentPrefix + "1"},
+			},
+			wantErr: errInconsistent,
+		},
+		{
+			description: "fingerprint mismatch",
+			configured: []*ConfiguredKey{
+				{ID: "1", Name: "key", Fingerprint: blobFingerprint(t, testdata.WithPassphrase.Blob)},
+			},
+			loaded: []*LoadedKey{
+				{InternalBlob: testdata.WithoutPassphrase.Blob, Comment: commentPrefix + "1"},
+			},
+			wantErr: errInconsistent,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifyLoaded(tc.configured, tc.loaded)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyChecks(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		records     map[string]js.Value
+		wantFailed  []string
+	}{
+		{
+			description: "consistent state",
+		},
+		{
+			description: "malformed stored key",
+			records: map[string]js.Value{
+				"key.1": js.ValueOf(map[string]any{"name": "old-key"}),
+			},
+			wantFailed: []string{"stored keys readable"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				mgr, err := newTestManager(ctx, agent.NewKeyring(), syncStorage, sessionStorage, []*initialKey{
+					{
+						Name:          "unencrypted",
+						PEMPrivateKey: testdata.WithoutPassphrase.Private,
+						Load:          true,
+					},
+					{
+						Name:          "encrypted",
+						PEMPrivateKey: testdata.WithPassphrase.Private,
+						Load:          true,
+						Passphrase:    testdata.WithPassphrase.Passphrase,
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to initialize manager: %v", err)
+				}
+				if err := syncStorage.Set(ctx, tc.records); err != nil {
+					t.Fatalf("failed to write records: %v", err)
+				}
+				before, err := syncStorage.Get(ctx)
+				if err != nil {
+					t.Fatalf("failed to read storage: %v", err)
+				}
+
+				var failed []string
+				for _, c := range VerifyChecks(mgr) {
+					if err := c.Run(ctx); err != nil {
+						if !errors.Is(err, errInconsistent) {
+							t.Errorf("%s: unexpected error: %v", c.Name, err)
+						}
+						failed = append(failed, c.Name)
+					}
+				}
+				if diff := cmp.Diff(failed, tc.wantFailed); diff != "" {
+					t.Errorf("incorrect failed checks; -got +want: %s", diff)
+				}
+
+				// The checks must not modify stored keys.
+				after, err := syncStorage.Get(ctx)
+				if err != nil {
+					t.Fatalf("failed to read storage: %v", err)
+				}
+				if diff := cmp.Diff(storageKeys(after), storageKeys(before), cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("stored keys modified; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func storageKeys(data map[string]js.Value) []string {
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	return keys
+}
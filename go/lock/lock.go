@@ -17,11 +17,20 @@
 package lock
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"syscall/js"
+	"time"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/metrics"
 )
 
+// ErrTimeout indicates that a lock was not granted within the allotted time.
+var ErrTimeout = errors.New("timed out waiting for lock")
+
 var locks = func() js.Value {
 	// Prefer Web Locks API is defined under 'navigator.locks'
 	if navigator := js.Global().Get("navigator"); !navigator.IsUndefined() {
@@ -34,17 +43,144 @@ var locks = func() js.Value {
 	}`)
 }()
 
-// Async runs a routine asynchronously once access to the resource has been
-// granted. Access to the resource is released when the routine returns.
-func Async(resource string, f func(ctx jsutil.AsyncContext)) *jsutil.Promise {
+// stats aggregates contention statistics for locks acquired in this context.
+var stats = metrics.NewRegistry()
+
+// Stats returns contention statistics for locks acquired in this context.
+// Statistics are aggregated by lock group; see WithLock. For each group,
+// the following metrics are reported:
+//
+//	lock.<group>.acquired: Locks granted, and the total time spent waiting.
+//	lock.<group>.contended: Locks that were held elsewhere when requested,
+//	  and the total time spent waiting for them.
+//	lock.<group>.timeout: Requests that timed out, and the total time spent
+//	  waiting before giving up.
+func Stats() map[string]metrics.Stat {
+	return stats.Snapshot()
+}
+
+// group returns the name under which statistics for the named lock are
+// aggregated.
+func group(name string) string {
+	g, _, _ := strings.Cut(name, "/")
+	return g
+}
+
+func metricName(name, kind string) string {
+	return fmt.Sprintf("lock.%s.%s", group(name), kind)
+}
+
+// request requests the named lock, and invokes f once the request is
+// resolved. If ifAvailable is true and the lock is held elsewhere, f is
+// invoked immediately with granted set to false. Otherwise, f is invoked once
+// the lock is granted, and the lock is released when f returns.
+func request(name string, ifAvailable bool, f func(ctx jsutil.AsyncContext, granted bool)) *jsutil.Promise {
+	opts := jsutil.NewObject()
+	opts.Set("ifAvailable", ifAvailable)
 	return jsutil.AsPromise(locks.Call(
-		"request", resource,
+		"request", name, opts,
 		// Return a promise encapsulating the supplied function.
 		jsutil.OneTimeFuncOf(func(this js.Value, args []js.Value) interface{} {
+			granted := !jsutil.SingleArg(args).IsNull()
 			return jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
-				f(ctx)
+				f(ctx, granted)
 				return js.Undefined(), nil
 			}).JSValue()
 		}),
 	))
 }
+
+// Async runs a routine asynchronously once access to the resource has been
+// granted. Access to the resource is released when the routine returns.
+func Async(resource string, f func(ctx jsutil.AsyncContext)) *jsutil.Promise {
+	return request(resource, false, func(ctx jsutil.AsyncContext, granted bool) {
+		f(ctx)
+	})
+}
+
+// WithLock invokes fn while holding the named lock, waiting as long as
+// necessary for the lock to be granted. The lock is exclusive across all of
+// the extension's contexts (e.g., the background worker and the options
+// page), and is released when fn returns. Locks are not reentrant; fn must
+// not request the same lock.
+//
+// Names may be qualified with a '/'-separated suffix (e.g., "key/<ID>") to
+// create a family of locks that share statistics; see Stats.
+func WithLock(ctx jsutil.AsyncContext, name string, fn func(ctx jsutil.AsyncContext) error) error {
+	return WithLockTimeout(ctx, name, 0, fn)
+}
+
+// WithLockTimeout is like WithLock, but returns ErrTimeout if the lock is not
+// granted within the specified timeout. A timeout of zero waits indefinitely.
+func WithLockTimeout(ctx jsutil.AsyncContext, name string, timeout time.Duration, fn func(ctx jsutil.AsyncContext) error) error {
+	start := time.Now()
+
+	// Attempt to take the lock without waiting. This is the common case,
+	// and tells us whether the lock is contended.
+	var err error
+	acquired := false
+	_, aerr := request(name, true, func(ctx jsutil.AsyncContext, granted bool) {
+		if !granted {
+			return
+		}
+		acquired = true
+		stats.Observe(metricName(name, "acquired"), 0, 0)
+		err = fn(ctx)
+	}).Await(ctx)
+	if aerr != nil {
+		return aerr
+	}
+	if acquired {
+		return err
+	}
+
+	// The lock is held elsewhere; queue for it. If we time out first, the
+	// request remains queued (the Web Locks API offers no portable way to
+	// withdraw it), but the lock is released as soon as it is granted.
+	var mu sync.Mutex
+	abandoned := false
+	done := make(chan error, 1)
+	request(name, false, func(ctx jsutil.AsyncContext, granted bool) {
+		mu.Lock()
+		if abandoned {
+			mu.Unlock()
+			return
+		}
+		acquired = true
+		mu.Unlock()
+
+		wait := time.Since(start)
+		stats.Observe(metricName(name, "acquired"), 0, wait)
+		stats.Observe(metricName(name, "contended"), 0, wait)
+		err = fn(ctx)
+	}).Then(
+		func(value js.Value) { done <- nil },
+		func(err error) { done <- err },
+	)
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case aerr = <-done:
+	case <-expired:
+		mu.Lock()
+		if !acquired {
+			abandoned = true
+			mu.Unlock()
+			stats.Observe(metricName(name, "timeout"), 0, time.Since(start))
+			return fmt.Errorf("%w %s after %s", ErrTimeout, name, timeout)
+		}
+		mu.Unlock()
+		// The lock was granted just in time; wait for fn to finish.
+		aerr = <-done
+	}
+	if aerr != nil {
+		return aerr
+	}
+	return err
+}
@@ -15,7 +15,9 @@
 package lock
 
 import (
+	"errors"
 	"sync/atomic"
+	"syscall/js"
 	"testing"
 	"time"
 
@@ -70,3 +72,107 @@ func TestExclusive(t *testing.T) {
 		}
 	})
 }
+
+func TestWithLock(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		const workers = 10
+		var count, concurrent int32
+
+		// Spawn all workers concurrently.
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				errs <- WithLock(ctx, "with-lock/resource", func(ctx jsutil.AsyncContext) error {
+					atomic.AddInt32(&count, 1)
+					defer atomic.AddInt32(&count, -1)
+
+					time.Sleep(50 * time.Millisecond)
+					if atomic.LoadInt32(&count) >= 2 {
+						atomic.AddInt32(&concurrent, 1)
+					}
+					return nil
+				})
+				return js.Undefined(), nil
+			})
+		}
+
+		for i := 0; i < workers; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("WithLock failed: %v", err)
+			}
+		}
+		if c := atomic.LoadInt32(&concurrent); c > 0 {
+			t.Errorf("%d workers observed concurrent access", c)
+		}
+
+		// Validate that every acquisition was counted, and that some
+		// observed contention.
+		s := Stats()
+		if got := s["lock.with-lock.acquired"].Count; got != workers {
+			t.Errorf("incorrect acquired count; got %d, want %d", got, workers)
+		}
+		if got := s["lock.with-lock.contended"].Count; got == 0 {
+			t.Errorf("no contention recorded")
+		}
+	})
+}
+
+func TestWithLockReturnsError(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		want := errors.New("failed")
+		err := WithLock(ctx, "with-lock-error", func(ctx jsutil.AsyncContext) error {
+			return want
+		})
+		if !errors.Is(err, want) {
+			t.Errorf("incorrect error; got %v, want %v", err, want)
+		}
+	})
+}
+
+func TestWithLockTimeout(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		const name = "with-lock-timeout"
+
+		// Hold the lock until released.
+		held := make(chan struct{})
+		release := make(chan struct{})
+		holder := Async(name, func(ctx jsutil.AsyncContext) {
+			close(held)
+			<-release
+		})
+		<-held
+
+		ran := false
+		err := WithLockTimeout(ctx, name, 50*time.Millisecond, func(ctx jsutil.AsyncContext) error {
+			ran = true
+			return nil
+		})
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("incorrect error; got %v, want %v", err, ErrTimeout)
+		}
+		if got := Stats()["lock."+name+".timeout"].Count; got != 1 {
+			t.Errorf("incorrect timeout count; got %d, want 1", got)
+		}
+
+		// Once released, the lock can be acquired again, and the routine
+		// that timed out is never invoked.
+		close(release)
+		if _, err := holder.Await(ctx); err != nil {
+			t.Errorf("holder failed: %v", err)
+		}
+		if err := WithLockTimeout(ctx, name, time.Second, func(ctx jsutil.AsyncContext) error {
+			return nil
+		}); err != nil {
+			t.Errorf("WithLockTimeout failed: %v", err)
+		}
+		if ran {
+			t.Errorf("routine invoked after timeout")
+		}
+	})
+}
@@ -0,0 +1,178 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logbuf retains the entries logged by the background worker, so the
+// user can review them from the options page when diagnosing a problem.
+//
+// The most recent entries are kept in a fixed-size buffer in session storage;
+// the buffer is therefore discarded when the browser exits.
+package logbuf
+
+import (
+	"fmt"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/norunners/vert"
+)
+
+const (
+	// bufferKey is the key under which the buffer is stored.
+	bufferKey = "logBuffer"
+	// DefaultCapacity is the number of entries retained by default.
+	DefaultCapacity = 500
+)
+
+// console is the default 'console' object for the browser.
+var console = js.Global().Get("console")
+
+// storedBuffer is the raw object stored in session storage.
+type storedBuffer struct {
+	Entries []*keys.LogEntry `js:"entries"`
+}
+
+// Buffer is a fixed-size buffer of log entries. When full, the oldest entries
+// are discarded.
+//
+// Buffer implements the keys.LogBuffer interface, and its Add method may be
+// supplied to jsutil.SetLogSink.
+//
+// Buffer accesses the storage area directly rather than through
+// storage.Area, since the latter logs its own operations; writing an entry
+// would then log further entries without end. For the same reason, Buffer
+// must never log through jsutil.
+type Buffer struct {
+	area     js.Value
+	capacity int
+
+	mu sync.Mutex
+	// pending holds entries that have not yet been written.
+	pending []*keys.LogEntry
+	// writing holds entries that are currently being written.
+	writing []*keys.LogEntry
+	// flushing indicates that pending entries are being written.
+	flushing bool
+}
+
+// New returns a Buffer that retains up to capacity entries in the supplied
+// storage area (e.g., chrome.storage.session).
+func New(area js.Value, capacity int) *Buffer {
+	return &Buffer{
+		area:     area,
+		capacity: capacity,
+	}
+}
+
+// Add appends an entry to the buffer. The entry is written asynchronously.
+func (b *Buffer) Add(level string, t time.Time, msg string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, &keys.LogEntry{
+		Time:    t.UnixMilli(),
+		Level:   level,
+		Message: msg,
+	})
+	if len(b.pending) > b.capacity {
+		b.pending = b.pending[len(b.pending)-b.capacity:]
+	}
+	if !b.flushing {
+		b.flushing = true
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			b.flush(ctx)
+			return js.Undefined(), nil
+		})
+	}
+}
+
+// flush writes pending entries until none remain.
+func (b *Buffer) flush(ctx jsutil.AsyncContext) {
+	for {
+		b.mu.Lock()
+		if len(b.pending) == 0 {
+			b.flushing = false
+			b.mu.Unlock()
+			return
+		}
+		b.writing, b.pending = b.pending, nil
+		b.mu.Unlock()
+
+		if err := b.write(ctx, b.writing); err != nil {
+			console.Call("error", fmt.Sprintf("failed to write log buffer: %v", err))
+		}
+
+		b.mu.Lock()
+		b.writing = nil
+		b.mu.Unlock()
+	}
+}
+
+// read returns the entries that have been written.
+func (b *Buffer) read(ctx jsutil.AsyncContext) ([]*keys.LogEntry, error) {
+	val, err := jsutil.AsPromise(b.area.Call("get", bufferKey)).Await(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log buffer: %w", err)
+	}
+	val = val.Get(bufferKey)
+	if val.Type() != js.TypeObject {
+		return nil, nil
+	}
+
+	var stored storedBuffer
+	if err := vert.ValueOf(val).AssignTo(&stored); err != nil {
+		return nil, fmt.Errorf("failed to parse log buffer: %w", err)
+	}
+	return stored.Entries, nil
+}
+
+// write appends entries to those that have been written, discarding the
+// oldest entries if the buffer is full.
+func (b *Buffer) write(ctx jsutil.AsyncContext, entries []*keys.LogEntry) error {
+	stored, err := b.read(ctx)
+	if err != nil {
+		return err
+	}
+	stored = append(stored, entries...)
+	if len(stored) > b.capacity {
+		stored = stored[len(stored)-b.capacity:]
+	}
+
+	data := jsutil.NewObject()
+	data.Set(bufferKey, vert.ValueOf(&storedBuffer{Entries: stored}).JSValue())
+	if _, err := jsutil.AsPromise(b.area.Call("set", data)).Await(ctx); err != nil {
+		return fmt.Errorf("failed to write log buffer: %w", err)
+	}
+	return nil
+}
+
+// Entries implements keys.LogBuffer.Entries.
+func (b *Buffer) Entries(ctx jsutil.AsyncContext) ([]*keys.LogEntry, error) {
+	stored, err := b.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := append(stored, b.writing...)
+	entries = append(entries, b.pending...)
+	if len(entries) > b.capacity {
+		entries = entries[len(entries)-b.capacity:]
+	}
+	return entries, nil
+}
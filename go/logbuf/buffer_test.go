@@ -0,0 +1,109 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logbuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// waitFlushed blocks until all entries added to the buffer have been written.
+func waitFlushed(b *Buffer) {
+	for {
+		b.mu.Lock()
+		flushing := b.flushing
+		b.mu.Unlock()
+		if !flushing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	t.Parallel()
+
+	entry := func(i int64) *keys.LogEntry {
+		return &keys.LogEntry{Time: i, Level: "INFO", Message: "message"}
+	}
+
+	testcases := []struct {
+		description string
+		capacity    int
+		add         []*keys.LogEntry
+		want        []*keys.LogEntry
+	}{
+		{
+			description: "empty",
+			capacity:    3,
+		},
+		{
+			description: "entries retained in order",
+			capacity:    3,
+			add:         []*keys.LogEntry{entry(1), entry(2)},
+			want:        []*keys.LogEntry{entry(1), entry(2)},
+		},
+		{
+			description: "oldest entries discarded when full",
+			capacity:    3,
+			add:         []*keys.LogEntry{entry(1), entry(2), entry(3), entry(4), entry(5)},
+			want:        []*keys.LogEntry{entry(3), entry(4), entry(5)},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				area := st.NewMemArea()
+				b := New(area, tc.capacity)
+				for _, e := range tc.add {
+					b.Add(e.Level, time.UnixMilli(e.Time), e.Message)
+				}
+
+				// Entries not yet written are also returned.
+				got, err := b.Entries(ctx)
+				if err != nil {
+					t.Errorf("Entries failed: %v", err)
+					return
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect entries; -got +want: %s", diff)
+				}
+
+				// Read using a separate instance to verify the
+				// entries are persisted.
+				waitFlushed(b)
+				got, err = New(area, tc.capacity).Entries(ctx)
+				if err != nil {
+					t.Errorf("Entries failed: %v", err)
+					return
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect persisted entries; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -17,10 +17,11 @@
 package fakes
 
 import (
-	"errors"
+	"fmt"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/message"
 )
 
 // Receiver defines methods sufficient to receive messages and send
@@ -32,11 +33,15 @@ type Receiver interface {
 // Hub is a fake implementation of Chrome's messaging APIs.
 type Hub struct {
 	receivers []Receiver
+	listeners map[int]func(ctx jsutil.AsyncContext, msg js.Value)
+	nextID    int
 }
 
 // NewHub returns a fake implementation of Chrome's messaging APIs.
 func NewHub() *Hub {
-	return &Hub{}
+	return &Hub{
+		listeners: map[int]func(ctx jsutil.AsyncContext, msg js.Value){},
+	}
 }
 
 // AddReceiver adds a receiver to which messages should be delivered.
@@ -44,13 +49,33 @@ func (m *Hub) AddReceiver(r Receiver) {
 	m.receivers = append(m.receivers, r)
 }
 
+// Listen implements Listener.Listen().
+func (m *Hub) Listen(callback func(ctx jsutil.AsyncContext, msg js.Value)) jsutil.CleanupFunc {
+	id := m.nextID
+	m.nextID++
+	m.listeners[id] = callback
+	return func() {
+		delete(m.listeners, id)
+	}
+}
+
 // Send implements Sender.Send().
 func (m *Hub) Send(ctx jsutil.AsyncContext, msg js.Value) (js.Value, error) {
+	// Mirror Chrome, which notifies listeners asynchronously (e.g., in
+	// other pages).
+	for _, l := range m.listeners {
+		l := l
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			l(ctx, msg)
+			return js.Undefined(), nil
+		})
+	}
 	for _, r := range m.receivers {
 		rsp := r.OnMessage(ctx, msg, js.Null())
 		if !rsp.IsUndefined() {
 			return rsp, nil
 		}
 	}
-	return js.Undefined(), errors.New("no receivers")
+	// Mirror Chrome, which fails if there is no receiving end.
+	return js.Undefined(), fmt.Errorf("%w: no receivers", message.ErrUnavailable)
 }
@@ -90,3 +90,35 @@ func TestMessagePassing(t *testing.T) {
 		t.Errorf("incorrect response for map; -got +want: %s", diff)
 	}
 }
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	hub := NewHub()
+	hub.AddReceiver(&intReceiver{})
+
+	received := make(chan int, 1)
+	cleanup := hub.Listen(func(_ jsutil.AsyncContext, msg js.Value) {
+		received <- msg.Int()
+	})
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		// Listeners are notified, but the receiver responds.
+		rsp, err := hub.Send(ctx, js.ValueOf(42))
+		if err != nil {
+			t.Errorf("SendMessage failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(rsp.String(), "int"); diff != "" {
+			t.Errorf("incorrect response; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(<-received, 42); diff != "" {
+			t.Errorf("incorrect message received by listener; -got +want: %s", diff)
+		}
+	})
+
+	cleanup()
+	if diff := cmp.Diff(len(hub.listeners), 0); diff != "" {
+		t.Errorf("incorrect listeners after cleanup; -got +want: %s", diff)
+	}
+}
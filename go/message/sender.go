@@ -17,6 +17,9 @@
 package message
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -32,6 +35,18 @@ var (
 	}()
 )
 
+// ErrUnavailable indicates that messages cannot be delivered, and retrying
+// will not help. For example, the page may have been opened in a context in
+// which the extension's background worker cannot be reached.
+var ErrUnavailable = errors.New("messaging unavailable")
+
+// unavailableMessages are substrings of errors returned by Chrome that
+// indicate messages cannot be delivered.
+var unavailableMessages = []string{
+	"Could not establish connection",
+	"Extension context invalidated",
+}
+
 // Sender specifies the interface for a type that sends messages.
 type Sender interface {
 	// Send sends a message. Response (or an error) are returned. See:
@@ -39,9 +54,18 @@ type Sender interface {
 	Send(ctx jsutil.AsyncContext, msg js.Value) (js.Value, error)
 }
 
-// ExtSender sends messages within our own extension.
+// Listener specifies the interface for a type that receives messages.
+type Listener interface {
+	// Listen invokes callback for each message received, until the
+	// returned cleanup function is invoked. Listeners do not respond to
+	// messages; responses are left to the intended receiver. See:
+	//   https://developer.chrome.com/docs/extensions/reference/runtime/#event-onMessage
+	Listen(callback func(ctx jsutil.AsyncContext, msg js.Value)) jsutil.CleanupFunc
+}
+
+// ExtSender sends and receives messages within our own extension.
 //
-// ExtSender implements the Sender interface.
+// ExtSender implements the Sender and Listener interfaces.
 type ExtSender struct{}
 
 // NewLocalSender returns a ExtSender for sending messages within our own
@@ -52,5 +76,41 @@ func NewLocalSender() *ExtSender {
 
 // Send implements Sender.Send().
 func (e *ExtSender) Send(ctx jsutil.AsyncContext, msg js.Value) (js.Value, error) {
-	return jsutil.AsPromise(runtime.Call("sendMessage", msg)).Await(ctx)
+	if runtime.IsUndefined() || runtime.Get("id").IsUndefined() {
+		return js.Undefined(), fmt.Errorf("%w: extension runtime not available", ErrUnavailable)
+	}
+
+	rsp, err := jsutil.AsPromise(runtime.Call("sendMessage", msg)).Await(ctx)
+	if err != nil {
+		for _, m := range unavailableMessages {
+			if strings.Contains(err.Error(), m) {
+				return js.Undefined(), fmt.Errorf("%w: %w", ErrUnavailable, err)
+			}
+		}
+	}
+	return rsp, err
+}
+
+// Listen implements Listener.Listen().
+func (e *ExtSender) Listen(callback func(ctx jsutil.AsyncContext, msg js.Value)) jsutil.CleanupFunc {
+	if runtime.IsUndefined() || runtime.Get("onMessage").IsUndefined() {
+		jsutil.LogDebug("ExtSender.Listen: extension runtime not available")
+		return func() {}
+	}
+
+	onMessage := runtime.Get("onMessage")
+	fo := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		msg := jsutil.SingleArg(args)
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			callback(ctx, msg)
+			return js.Undefined(), nil
+		})
+		// Returning undefined indicates that no response will be sent.
+		return nil
+	})
+	onMessage.Call("addListener", fo)
+	return func() {
+		onMessage.Call("removeListener", fo)
+		fo.Release()
+	}
 }
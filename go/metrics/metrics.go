@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics aggregates simple statistics about the extension's
+// performance in real-world use.
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stat accumulates observations of a single metric.
+type Stat struct {
+	// Count is the number of observations.
+	Count int64 `js:"count"`
+	// Bytes is the total number of bytes across all observations.
+	Bytes int64 `js:"bytes"`
+	// Duration is the total time across all observations.
+	Duration time.Duration `js:"duration"`
+}
+
+// Registry aggregates named metrics. It is safe for concurrent use.
+type Registry struct {
+	lock  sync.Mutex
+	stats map[string]*Stat // Protected by lock.
+}
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		stats: map[string]*Stat{},
+	}
+}
+
+// Observe records a single observation of the named metric.
+func (r *Registry) Observe(name string, bytes int, d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s, ok := r.stats[name]
+	if !ok {
+		s = &Stat{}
+		r.stats[name] = s
+	}
+	s.Count++
+	s.Bytes += int64(bytes)
+	s.Duration += d
+}
+
+// Snapshot returns the current value of all metrics.
+func (r *Registry) Snapshot() map[string]Stat {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result := map[string]Stat{}
+	for n, s := range r.stats {
+		result[n] = *s
+	}
+	return result
+}
+
+// Format returns a human-readable summary of the supplied metrics, suitable
+// for logging. Metrics are listed in order of name.
+func Format(stats map[string]Stat) string {
+	var names []string
+	for n := range stats {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, n := range names {
+		s := stats[n]
+		lines = append(lines, fmt.Sprintf("%s: count=%d bytes=%d duration=%s", n, s.Count, s.Bytes, s.Duration))
+	}
+	return strings.Join(lines, "\n")
+}
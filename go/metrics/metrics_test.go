@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestObserve(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.Observe("a", 10, time.Second)
+	r.Observe("a", 5, 2*time.Second)
+	r.Observe("b", 1, 0)
+
+	want := map[string]Stat{
+		"a": {Count: 2, Bytes: 15, Duration: 3 * time.Second},
+		"b": {Count: 1, Bytes: 1},
+	}
+	if diff := cmp.Diff(r.Snapshot(), want); diff != "" {
+		t.Errorf("incorrect snapshot; -got +want: %s", diff)
+	}
+}
+
+func TestObserveConcurrent(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Observe("a", 1, time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := map[string]Stat{
+		"a": {Count: 1000, Bytes: 1000, Duration: time.Second},
+	}
+	if diff := cmp.Diff(r.Snapshot(), want); diff != "" {
+		t.Errorf("incorrect snapshot; -got +want: %s", diff)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	stats := map[string]Stat{
+		"b": {Count: 1, Bytes: 1},
+		"a": {Count: 2, Bytes: 15, Duration: 3 * time.Second},
+	}
+	want := "a: count=2 bytes=15 duration=3s\nb: count=1 bytes=1 duration=0s"
+	if diff := cmp.Diff(Format(stats), want); diff != "" {
+		t.Errorf("incorrect format; -got +want: %s", diff)
+	}
+}
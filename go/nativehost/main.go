@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary nativehost forwards the SSH Agent served by the extension to desktop
+// applications, such as ssh and git.
+//
+// It is a native messaging host, started by Chrome when the extension
+// connects to it. It listens on a unix socket, and forwards each connection
+// accepted on it to the extension until Chrome disconnects. Desktop
+// applications use the agent by setting SSH_AUTH_SOCK to the socket's path.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// socketName is the name of the socket on which connections are
+	// accepted.
+	socketName = "chrome-ssh-agent.sock"
+	// socketEnv is the environment variable that, if set, overrides the
+	// path of the socket.
+	socketEnv = "CHROME_SSH_AGENT_SOCK"
+)
+
+// socketPath returns the path of the socket on which connections are
+// accepted. Unless overridden, it is in the user's runtime directory, or in
+// ~/.ssh if there is none.
+func socketPath() (string, error) {
+	if p := os.Getenv(socketEnv); p != "" {
+		return p, nil
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, socketName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".ssh", socketName), nil
+}
+
+// listen listens on the socket at path, which only the user may access. A
+// stale socket left by a previous instance is replaced, but one on which
+// another instance (e.g., for another Chrome profile) is listening is not.
+func listen(path string) (net.Listener, error) {
+	if c, err := net.Dial("unix", path); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("socket %s is already in use", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	// Create the socket with restricted permissions, rather than
+	// restricting them afterwards; otherwise, other users could connect in
+	// the interim.
+	restore := restrictUmask()
+	l, err := net.Listen("unix", path)
+	restore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return l, nil
+}
+
+func main() {
+	// Chrome records messages written to stderr in its log; stdout is
+	// reserved for messages to the extension.
+	log.SetFlags(0)
+	log.SetPrefix("chrome-ssh-agent nativehost: ")
+
+	path, err := socketPath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	l, err := listen(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer l.Close()
+
+	r := newRelay(os.Stdout)
+	go func() {
+		if err := r.accept(l); err != nil {
+			log.Print(err)
+		}
+	}()
+	if err := r.receive(os.Stdin); err != nil {
+		log.Print(err)
+	}
+}
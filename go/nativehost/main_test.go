@@ -0,0 +1,54 @@
+//go:build unix
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenRestrictsAccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), socketName)
+	l, err := listen(path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer l.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := fi.Mode().Perm(); perm&0o077 != 0 {
+		t.Errorf("socket accessible to other users; got permissions %o", perm)
+	}
+}
+
+func TestListenRefusesSocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), socketName)
+	l, err := listen(path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer l.Close()
+
+	if l2, err := listen(path); err == nil {
+		l2.Close()
+		t.Errorf("listen succeeded on socket in use")
+	}
+}
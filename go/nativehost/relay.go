@@ -0,0 +1,234 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// Types of message exchanged with the extension. These must be kept in sync
+// with go/nativeport.
+const (
+	// msgOpen announces a connection accepted on the socket.
+	msgOpen = "open"
+	// msgData carries data sent over a connection.
+	msgData = "data"
+	// msgClose announces that a connection was closed.
+	msgClose = "close"
+)
+
+// message is a message exchanged with the extension.
+type message struct {
+	// Type is the type of message; one of the msg* constants.
+	Type string `json:"type"`
+	// ID identifies the connection to which the message refers.
+	ID int `json:"id"`
+	// Data is the data sent over the connection, for msgData. It is
+	// base64-encoded in JSON.
+	Data []byte `json:"data,omitempty"`
+}
+
+const (
+	// maxMessageSize is the maximum length of a message read from the
+	// extension. Messages carry at most one agent message, whose length
+	// OpenSSH limits to 256 KiB, and the overhead of encoding it.
+	maxMessageSize = 1024 * 1024
+	// readSize is the maximum amount of data read from a connection and
+	// forwarded to the extension in a single message. Chrome limits
+	// messages from the native host to 1 MiB.
+	readSize = 32 * 1024
+)
+
+// readMessage reads a message in the native messaging format: a 32-bit
+// length in native byte order, followed by the message encoded as JSON.
+func readMessage(r io.Reader) (*message, error) {
+	var length uint32
+	if err := binary.Read(r, binary.NativeEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message length %d exceeds maximum of %d", length, maxMessageSize)
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	var m message
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	return &m, nil
+}
+
+// writeMessage writes a message in the native messaging format.
+func writeMessage(w io.Writer, m *message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	framed := binary.NativeEndian.AppendUint32(nil, uint32(len(b)))
+	if _, err := w.Write(append(framed, b...)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// relay forwards connections accepted on a socket to the extension, with
+// which it exchanges messages in the native messaging format.
+type relay struct {
+	outMu sync.Mutex // serializes messages written to out
+	out   io.Writer
+
+	mu     sync.Mutex
+	conns  map[int]net.Conn
+	nextID int
+}
+
+func newRelay(out io.Writer) *relay {
+	return &relay{
+		out:   out,
+		conns: map[int]net.Conn{},
+	}
+}
+
+// send sends a message to the extension.
+func (r *relay) send(m *message) error {
+	r.outMu.Lock()
+	defer r.outMu.Unlock()
+	return writeMessage(r.out, m)
+}
+
+// remove forgets the connection with the specified ID, returning it, or nil
+// if it is not open.
+func (r *relay) remove(id int) net.Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.conns[id]
+	delete(r.conns, id)
+	return c
+}
+
+// lookup returns the connection with the specified ID, or nil if it is not
+// open.
+func (r *relay) lookup(id int) net.Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.conns[id]
+}
+
+// accept accepts connections on l, forwarding each to the extension, until l
+// is closed.
+func (r *relay) accept(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		r.mu.Lock()
+		r.nextID++
+		id := r.nextID
+		r.conns[id] = c
+		r.mu.Unlock()
+
+		if err := r.send(&message{Type: msgOpen, ID: id}); err != nil {
+			r.remove(id)
+			c.Close()
+			return err
+		}
+		go r.forward(id, c)
+	}
+}
+
+// forward forwards data read from a connection to the extension, until the
+// connection is closed.
+func (r *relay) forward(id int, c net.Conn) {
+	buf := make([]byte, readSize)
+	for {
+		n, err := c.Read(buf)
+		if n > 0 {
+			if err := r.send(&message{Type: msgData, ID: id, Data: buf[:n]}); err != nil {
+				log.Printf("connection %d: %v", id, err)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// The connection is only announced as closed if the extension did
+	// not close it.
+	if r.remove(id) == nil {
+		return
+	}
+	c.Close()
+	if err := r.send(&message{Type: msgClose, ID: id}); err != nil {
+		log.Printf("connection %d: %v", id, err)
+	}
+}
+
+// receive handles messages from the extension until it disconnects, at which
+// point all connections are closed.
+func (r *relay) receive(in io.Reader) error {
+	defer r.closeAll()
+	for {
+		m, err := readMessage(in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch m.Type {
+		case msgData:
+			c := r.lookup(m.ID)
+			if c == nil {
+				continue
+			}
+			if _, err := c.Write(m.Data); err != nil {
+				log.Printf("connection %d: failed to write: %v", m.ID, err)
+				c.Close()
+			}
+		case msgClose:
+			if c := r.remove(m.ID); c != nil {
+				c.Close()
+			}
+		default:
+			log.Printf("unrecognized message type %q", m.Type)
+		}
+	}
+}
+
+// closeAll closes all connections.
+func (r *relay) closeAll() {
+	r.mu.Lock()
+	conns := r.conns
+	r.conns = map[int]net.Conn{}
+	r.mu.Unlock()
+	for _, c := range conns {
+		c.Close()
+	}
+}
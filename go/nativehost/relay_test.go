@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// pipeListener is a net.Listener whose connections are in-memory pipes.
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// Dial returns the client end of a new connection.
+func (l *pipeListener) Dial() net.Conn {
+	client, server := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "unix"}
+}
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	want := &message{Type: msgData, ID: 3, Data: []byte{0, 0, 0, 1, 11}}
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, want); err != nil {
+		t.Fatalf("writeMessage() failed: %v", err)
+	}
+	got, err := readMessage(&buf)
+	if err != nil {
+		t.Fatalf("readMessage() failed: %v", err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect message; -got +want: %s", diff)
+	}
+
+	// Excessively long messages are rejected without being read.
+	if _, err := readMessage(bytes.NewReader([]byte{255, 255, 255, 255})); err == nil {
+		t.Errorf("readMessage() succeeded for oversized message; want error")
+	}
+}
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+
+	toExt, fromHost := io.Pipe()
+	toHost, fromExt := io.Pipe()
+	r := newRelay(fromHost)
+	l := newPipeListener()
+	go r.accept(l)
+	received := make(chan error, 1)
+	go func() { received <- r.receive(toHost) }()
+
+	// nextMessage reads the next message sent to the extension.
+	nextMessage := func() *message {
+		t.Helper()
+		m, err := readMessage(toExt)
+		if err != nil {
+			t.Fatalf("failed to read message to extension: %v", err)
+		}
+		return m
+	}
+
+	// Connections are announced, and their data forwarded in both
+	// directions.
+	c := l.Dial()
+	if diff := cmp.Diff(nextMessage(), &message{Type: msgOpen, ID: 1}); diff != "" {
+		t.Errorf("incorrect open message; -got +want: %s", diff)
+	}
+	if _, err := c.Write([]byte{0, 0, 0, 1, 11}); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+	if diff := cmp.Diff(nextMessage(), &message{Type: msgData, ID: 1, Data: []byte{0, 0, 0, 1, 11}}); diff != "" {
+		t.Errorf("incorrect data message; -got +want: %s", diff)
+	}
+	go writeMessage(fromExt, &message{Type: msgData, ID: 1, Data: []byte{0, 0, 0, 1, 6}})
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(c, got); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if diff := cmp.Diff(got, []byte{0, 0, 0, 1, 6}); diff != "" {
+		t.Errorf("incorrect response; -got +want: %s", diff)
+	}
+
+	// Connections closed by the extension are closed.
+	go writeMessage(fromExt, &message{Type: msgClose, ID: 1})
+	if _, err := c.Read(got); err != io.EOF {
+		t.Errorf("read from connection closed by extension returned %v; want %v", err, io.EOF)
+	}
+
+	// Connections closed by the client are announced.
+	c = l.Dial()
+	if diff := cmp.Diff(nextMessage(), &message{Type: msgOpen, ID: 2}); diff != "" {
+		t.Errorf("incorrect open message; -got +want: %s", diff)
+	}
+	c.Close()
+	if diff := cmp.Diff(nextMessage(), &message{Type: msgClose, ID: 2}); diff != "" {
+		t.Errorf("incorrect close message; -got +want: %s", diff)
+	}
+
+	// Remaining connections are closed once the extension disconnects.
+	c = l.Dial()
+	nextMessage()
+	fromExt.Close()
+	if err := <-received; err != nil {
+		t.Errorf("receive() failed: %v", err)
+	}
+	if _, err := c.Read(got); err != io.EOF {
+		t.Errorf("read after extension disconnected returned %v; want %v", err, io.EOF)
+	}
+	l.Close()
+}
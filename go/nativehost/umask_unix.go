@@ -0,0 +1,28 @@
+//go:build unix
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "syscall"
+
+// restrictUmask sets the process's umask such that files (including sockets)
+// are only accessible to the user, and returns a function that restores the
+// previous umask. The umask is process-wide, so this must not be invoked
+// concurrently with the creation of other files.
+func restrictUmask() (restore func()) {
+	prev := syscall.Umask(0o177)
+	return func() { syscall.Umask(prev) }
+}
@@ -0,0 +1,261 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nativeport supports serving the SSH Agent protocol to desktop
+// applications (e.g., ssh and git) through a native messaging host.
+//
+// The native host listens on the socket used by desktop applications, and
+// forwards each connection accepted on it over a single chrome.runtime.Port.
+// Messages exchanged over the Port identify the connection to which they
+// refer; see message.
+package nativeport
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/norunners/vert"
+)
+
+const (
+	// HostName is the name of the native messaging host, as registered
+	// in its manifest on the user's machine.
+	HostName = "com.google.chrome_ssh_agent"
+)
+
+// Types of message exchanged with the native host.
+const (
+	// msgOpen announces a connection accepted by the native host. It is
+	// only sent by the native host.
+	msgOpen = "open"
+	// msgData carries data sent over a connection.
+	msgData = "data"
+	// msgClose announces that a connection was closed.
+	msgClose = "close"
+)
+
+// message is a message exchanged with the native host.
+type message struct {
+	// Type is the type of message; one of the msg* constants.
+	Type string `js:"type"`
+	// ID identifies the connection to which the message refers. It is
+	// assigned by the native host when the connection is accepted.
+	ID int `js:"id"`
+	// Data is the base64-encoded data sent over the connection, for
+	// msgData. Native messaging exchanges JSON, which cannot hold binary
+	// data directly.
+	Data string `js:"data"`
+}
+
+// Bridge multiplexes the connections accepted by the native host over a
+// single chrome.runtime.Port.
+type Bridge struct {
+	p     js.Value
+	serve func(c *Conn)
+	clock clock.Clock
+
+	mu      sync.Mutex
+	conns   map[int]*Conn
+	release func() // detaches the event listeners added by Connect, if any
+}
+
+// New returns a Bridge that exchanges messages with the native host over p,
+// a chrome.runtime.Port returned by chrome.runtime.connectNative. serve is
+// invoked for each connection accepted by the native host; it must not
+// block. clk supplies the time each connection was accepted.
+//
+// The caller is responsible for forwarding the Port's messages to OnMessage,
+// and its disconnection to OnDisconnect.
+func New(p js.Value, serve func(c *Conn), clk clock.Clock) *Bridge {
+	return &Bridge{
+		p:     p,
+		serve: serve,
+		clock: clk,
+		conns: map[int]*Conn{},
+	}
+}
+
+// Connect starts the native host using runtime (chrome.runtime if undefined),
+// and returns a Bridge over the Port connected to it. serve and clk are as
+// for New. disconnected is invoked once the native host disconnects (e.g.,
+// because it exited or is not installed), but not if the Bridge is closed
+// using Close.
+func Connect(runtime js.Value, serve func(c *Conn), disconnected func(err error), clk clock.Clock) (*Bridge, error) {
+	if runtime.IsUndefined() || runtime.IsNull() {
+		runtime = js.Global().Get("chrome").Get("runtime")
+	}
+	if runtime.Get("connectNative").Type() != js.TypeFunction {
+		return nil, errors.New("native messaging is unavailable")
+	}
+
+	p := runtime.Call("connectNative", HostName)
+	b := New(p, serve, clk)
+	onMessage := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		b.OnMessage(jsutil.SingleArg(args))
+		return nil
+	})
+	onDisconnect := js.FuncOf(func(_ js.Value, _ []js.Value) any {
+		// lastError is only set while the event is dispatched.
+		var err error
+		if lastErr := runtime.Get("lastError"); lastErr.Truthy() {
+			err = errors.New(lastErr.Get("message").String())
+		}
+		b.OnDisconnect()
+		b.detach()
+		disconnected(err)
+		return nil
+	})
+	p.Get("onMessage").Call("addListener", onMessage)
+	p.Get("onDisconnect").Call("addListener", onDisconnect)
+	b.release = func() {
+		p.Get("onMessage").Call("removeListener", onMessage)
+		p.Get("onDisconnect").Call("removeListener", onDisconnect)
+		onMessage.Release()
+		onDisconnect.Release()
+	}
+	return b, nil
+}
+
+// OnMessage handles a message received from the native host. It does not
+// block, so it may be invoked directly from the Port's onMessage event.
+func (b *Bridge) OnMessage(val js.Value) {
+	var msg message
+	if err := vert.ValueOf(val).AssignTo(&msg); err != nil {
+		jsutil.LogError("Bridge.OnMessage: failed to parse message: %v", err)
+		return
+	}
+
+	switch msg.Type {
+	case msgOpen:
+		c := newConn(b, msg.ID, b.clock.Now())
+		b.mu.Lock()
+		old := b.conns[msg.ID]
+		b.conns[msg.ID] = c
+		b.mu.Unlock()
+		if old != nil {
+			jsutil.LogError("Bridge.OnMessage: connection %d reopened; closing previous", msg.ID)
+			old.onClose()
+		}
+		jsutil.LogDebug("Bridge.OnMessage: connection %d opened", msg.ID)
+		b.serve(c)
+	case msgData:
+		c := b.lookup(msg.ID)
+		if c == nil {
+			jsutil.LogDebug("Bridge.OnMessage: data for unknown connection %d", msg.ID)
+			return
+		}
+		data, err := base64.StdEncoding.DecodeString(msg.Data)
+		if err != nil {
+			jsutil.LogError("Bridge.OnMessage: failed to decode data for connection %d: %v", msg.ID, err)
+			c.Close()
+			return
+		}
+		c.onData(data)
+	case msgClose:
+		c := b.remove(msg.ID)
+		if c == nil {
+			return
+		}
+		jsutil.LogDebug("Bridge.OnMessage: connection %d closed by native host", msg.ID)
+		c.onClose()
+	default:
+		jsutil.LogError("Bridge.OnMessage: unrecognized message type %q", msg.Type)
+	}
+}
+
+// OnDisconnect closes all connections once the Port is disconnected (e.g.,
+// because the native host exited).
+func (b *Bridge) OnDisconnect() {
+	b.mu.Lock()
+	conns := b.conns
+	b.conns = map[int]*Conn{}
+	b.mu.Unlock()
+
+	for _, c := range conns {
+		c.onClose()
+	}
+}
+
+// Close disconnects from the native host, closing all connections.
+func (b *Bridge) Close() {
+	b.detach()
+	b.p.Call("disconnect")
+	b.OnDisconnect()
+}
+
+// detach detaches the event listeners added by Connect, if any.
+func (b *Bridge) detach() {
+	b.mu.Lock()
+	release := b.release
+	b.release = nil
+	b.mu.Unlock()
+	if release != nil {
+		release()
+	}
+}
+
+// Conns returns the open connections, in the order they were accepted.
+func (b *Bridge) Conns() []*Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []*Conn
+	for _, c := range b.conns {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].id < result[j].id
+	})
+	return result
+}
+
+// lookup returns the connection with the specified ID, or nil if it is not
+// open.
+func (b *Bridge) lookup(id int) *Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conns[id]
+}
+
+// remove forgets the connection with the specified ID, returning it, or nil
+// if it is not open.
+func (b *Bridge) remove(id int) *Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.conns[id]
+	delete(b.conns, id)
+	return c
+}
+
+// post sends a message to the native host.
+func (b *Bridge) post(msg *message) error {
+	var err error
+	func() {
+		// postMessage throws if the Port is already disconnected.
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("failed to post message: %v", r)
+			}
+		}()
+		b.p.Call("postMessage", vert.ValueOf(msg).JSValue())
+	}()
+	return err
+}
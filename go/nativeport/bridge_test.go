@@ -0,0 +1,238 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nativeport
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/go-cmp/cmp"
+	"github.com/norunners/vert"
+)
+
+// fakePort is a fake chrome.runtime.Port connected to the native host.
+type fakePort struct {
+	// port is the Javascript object.
+	port js.Value
+	// posted receives the messages posted to the native host.
+	posted chan message
+	// listeners are the listeners added to the onMessage and onDisconnect
+	// events.
+	listeners map[string][]js.Value
+	// funcs are released when the test completes.
+	funcs []js.Func
+}
+
+func newFakePort(t *testing.T) *fakePort {
+	f := &fakePort{
+		port:      jsutil.NewObject(),
+		posted:    make(chan message, 10),
+		listeners: map[string][]js.Value{},
+	}
+	t.Cleanup(func() {
+		for _, fo := range f.funcs {
+			fo.Release()
+		}
+	})
+
+	f.set(f.port, "postMessage", func(args []js.Value) {
+		var msg message
+		if err := vert.ValueOf(args[0]).AssignTo(&msg); err != nil {
+			t.Errorf("failed to parse posted message: %v", err)
+		}
+		f.posted <- msg
+	})
+	f.set(f.port, "disconnect", func([]js.Value) {})
+	for _, name := range []string{"onMessage", "onDisconnect"} {
+		name := name
+		event := jsutil.NewObject()
+		f.set(event, "addListener", func(args []js.Value) {
+			f.listeners[name] = append(f.listeners[name], args[0])
+		})
+		f.set(event, "removeListener", func(args []js.Value) {
+			var kept []js.Value
+			for _, l := range f.listeners[name] {
+				if !l.Equal(args[0]) {
+					kept = append(kept, l)
+				}
+			}
+			f.listeners[name] = kept
+		})
+		f.port.Set(name, event)
+	}
+	return f
+}
+
+// set defines a method on o that invokes f.
+func (f *fakePort) set(o js.Value, name string, fn func(args []js.Value)) {
+	fo := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		fn(args)
+		return nil
+	})
+	f.funcs = append(f.funcs, fo)
+	o.Set(name, fo)
+}
+
+// dispatch invokes the listeners added to the specified event.
+func (f *fakePort) dispatch(event string, args ...any) {
+	for _, l := range f.listeners[event] {
+		l.Invoke(args...)
+	}
+}
+
+// hostMessage returns a message from the native host.
+func hostMessage(typ string, id int, data []byte) js.Value {
+	return vert.ValueOf(&message{
+		Type: typ,
+		ID:   id,
+		Data: base64.StdEncoding.EncodeToString(data),
+	}).JSValue()
+}
+
+// readAll reads from c until it is closed.
+func readAll(t *testing.T, c *Conn) []byte {
+	t.Helper()
+	got, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("failed to read from connection: %v", err)
+	}
+	return got
+}
+
+func TestBridge(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	f := newFakePort(t)
+	served := make(chan *Conn, 10)
+	b := New(f.port, func(c *Conn) { served <- c }, fakes.NewClock(start))
+
+	b.OnMessage(hostMessage(msgOpen, 1, nil))
+	c := <-served
+	if diff := cmp.Diff(c.Opened(), start); diff != "" {
+		t.Errorf("incorrect open time; -got +want: %s", diff)
+	}
+
+	// Data from the native host is read as a stream.
+	b.OnMessage(hostMessage(msgData, 1, []byte{0, 0, 0, 1}))
+	b.OnMessage(hostMessage(msgData, 1, []byte{11}))
+	b.OnMessage(hostMessage(msgData, 2, []byte{99}))
+	b.OnMessage(hostMessage(msgClose, 1, nil))
+	if diff := cmp.Diff(readAll(t, c), []byte{0, 0, 0, 1, 11}); diff != "" {
+		t.Errorf("incorrect data read; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(len(b.Conns()), 0); diff != "" {
+		t.Errorf("incorrect number of connections; -got +want: %s", diff)
+	}
+
+	// Writing to a closed connection fails.
+	if _, err := c.Write([]byte{12}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Write() returned error %v; want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestConnWrite(t *testing.T) {
+	t.Parallel()
+
+	f := newFakePort(t)
+	served := make(chan *Conn, 10)
+	b := New(f.port, func(c *Conn) { served <- c }, fakes.NewClock(time.Unix(1700000000, 0)))
+
+	b.OnMessage(hostMessage(msgOpen, 7, nil))
+	c := <-served
+	if _, err := c.Write([]byte{0, 0, 0, 1, 12}); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	want := message{Type: msgData, ID: 7, Data: base64.StdEncoding.EncodeToString([]byte{0, 0, 0, 1, 12})}
+	if diff := cmp.Diff(<-f.posted, want); diff != "" {
+		t.Errorf("incorrect message posted; -got +want: %s", diff)
+	}
+
+	// Closing the connection asks the native host to close its socket,
+	// but only once.
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("second Close() failed: %v", err)
+	}
+	if diff := cmp.Diff(<-f.posted, message{Type: msgClose, ID: 7}); diff != "" {
+		t.Errorf("incorrect message posted; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(len(f.posted), 0); diff != "" {
+		t.Errorf("incorrect number of additional messages posted; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(readAll(t, c), []byte{}); diff != "" {
+		t.Errorf("incorrect data read; -got +want: %s", diff)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	t.Parallel()
+
+	f := newFakePort(t)
+	runtime := jsutil.NewObject()
+	connectNative := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if diff := cmp.Diff(args[0].String(), HostName); diff != "" {
+			t.Errorf("incorrect host name; -got +want: %s", diff)
+		}
+		return f.port
+	})
+	defer connectNative.Release()
+	runtime.Set("connectNative", connectNative)
+
+	served := make(chan *Conn, 10)
+	disconnected := make(chan error, 1)
+	b, err := Connect(runtime, func(c *Conn) { served <- c }, func(err error) { disconnected <- err }, fakes.NewClock(time.Unix(1700000000, 0)))
+	if err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+
+	f.dispatch("onMessage", hostMessage(msgOpen, 1, nil))
+	f.dispatch("onMessage", hostMessage(msgData, 1, []byte{11}))
+	c := <-served
+	if diff := cmp.Diff(len(b.Conns()), 1); diff != "" {
+		t.Errorf("incorrect number of connections; -got +want: %s", diff)
+	}
+
+	// Disconnection closes all connections, and reports the error.
+	lastError := jsutil.NewObject()
+	lastError.Set("message", "Specified native messaging host not found.")
+	runtime.Set("lastError", lastError)
+	f.dispatch("onDisconnect", f.port)
+	if got := <-disconnected; got == nil || got.Error() != "Specified native messaging host not found." {
+		t.Errorf("disconnected with error %v; want host not found", got)
+	}
+	if diff := cmp.Diff(readAll(t, c), []byte{11}); diff != "" {
+		t.Errorf("incorrect data read; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(len(f.listeners["onMessage"])+len(f.listeners["onDisconnect"]), 0); diff != "" {
+		t.Errorf("incorrect number of listeners remaining; -got +want: %s", diff)
+	}
+}
+
+func TestConnectUnavailable(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Connect(jsutil.NewObject(), func(*Conn) {}, func(error) {}, fakes.NewClock(time.Unix(1700000000, 0))); err == nil {
+		t.Errorf("Connect() succeeded without native messaging; want error")
+	}
+}
@@ -0,0 +1,127 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nativeport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"sync"
+	"time"
+)
+
+// Conn is a connection accepted by the native host. It is an
+// io.ReadWriteCloser over which the standard SSH Agent protocol is served.
+//
+// Unlike the connections served by agentport, data is exchanged as a stream
+// rather than as whole agent messages, exactly as it is read from and
+// written to the native host's socket.
+type Conn struct {
+	b      *Bridge
+	id     int
+	opened time.Time
+
+	mu     sync.Mutex
+	cond   *sync.Cond   // signalled when data is received or the connection is closed
+	in     bytes.Buffer // data received from the native host and not yet read
+	closed bool
+}
+
+func newConn(b *Bridge, id int, opened time.Time) *Conn {
+	c := &Conn{
+		b:      b,
+		id:     id,
+		opened: opened,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Opened returns the time at which the connection was accepted by the native
+// host.
+func (c *Conn) Opened() time.Time {
+	return c.opened
+}
+
+// onData buffers data received from the native host. It does not block, so
+// it may be invoked from a Javascript event handler.
+func (c *Conn) onData(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.in.Write(data)
+	c.cond.Broadcast()
+}
+
+// onClose marks the connection as closed, such that data already received
+// may still be read, but subsequent reads return io.EOF.
+func (c *Conn) onClose() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.cond.Broadcast()
+}
+
+// Read reads data received from the native host, blocking until some is
+// available.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.in.Len() == 0 && !c.closed {
+		c.cond.Wait()
+	}
+	if c.in.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.in.Read(p)
+}
+
+// Write sends data to the native host.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if err := c.b.post(&message{
+		Type: msgData,
+		ID:   c.id,
+		Data: base64.StdEncoding.EncodeToString(p),
+	}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection, asking the native host to close the
+// corresponding socket. It is a no-op if the connection is already closed.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil
+	}
+
+	c.b.remove(c.id)
+	c.onClose()
+	return c.b.post(&message{Type: msgClose, ID: c.id})
+}
@@ -20,26 +20,47 @@ import (
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/app"
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
 	"github.com/google/chrome-ssh-agent/go/dom"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
 	"github.com/google/chrome-ssh-agent/go/keys"
 	"github.com/google/chrome-ssh-agent/go/message"
 	"github.com/google/chrome-ssh-agent/go/optionsui"
+	"github.com/google/chrome-ssh-agent/go/securitykey"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
 	"github.com/google/chrome-ssh-agent/go/testing"
+	"github.com/google/chrome-ssh-agent/go/vault"
+	"github.com/google/chrome-ssh-agent/go/wait"
 )
 
 type options struct {
-	manager keys.Manager
-	doc     *dom.Doc
+	manager  keys.Manager
+	listener message.Listener
+	settings *settings.Client
+	clients  *clients.Store
+	vault    *vault.Vault
+	cache    storage.Area
+	doc      *dom.Doc
 }
 
 func newOptions() *options {
-	mgr := keys.NewClient(message.NewLocalSender())
+	msg := message.NewLocalSender()
+	mgr := keys.NewClient(msg)
+	prefStorage := storage.NewOptional(storage.DefaultSync())
+	sts := settings.NewClient(msg)
+	cache := storage.DefaultLocal()
 	doc := dom.New(js.Null())
 
 	return &options{
-		manager: mgr,
-		doc:     doc,
+		manager:  mgr,
+		listener: msg,
+		settings: sts,
+		clients:  clients.NewStore(prefStorage),
+		vault:    vault.New(prefStorage, storage.DefaultSession()),
+		cache:    cache,
+		doc:      doc,
 	}
 }
 
@@ -48,17 +69,46 @@ func (a *options) Name() string {
 }
 
 func (a *options) Init(ctx jsutil.AsyncContext, cleanup *jsutil.CleanupFuncs) error {
-	ui := optionsui.New(a.manager, a.doc)
+	qs := dom.NewURLSearchParams(dom.DefaultQueryString())
+	if qs.Has(securitykey.RequestParam) {
+		// The page was opened to ask a security key to sign on behalf
+		// of the background worker.
+		a.authenticate(ctx, qs.Get(securitykey.RequestParam))
+		return nil
+	}
+
+	ui := optionsui.New(a.manager, a.listener, a.settings, a.clients, a.vault, a.cache, clock.Real, a.doc)
 	cleanup.Add(ui.Release)
 
-	qs := dom.NewURLSearchParams(dom.DefaultQueryString())
 	if qs.Has("test") {
-		testing.WriteResults(a.doc, ui.EndToEndTest(ctx))
+		w, err := wait.Default.WithParams(qs.Get)
+		if err != nil {
+			jsutil.LogError("failed to configure waits: %v; using defaults", err)
+			w = wait.Default
+		}
+		testing.WriteResults(a.doc, ui.EndToEndTest(ctx, w))
+	} else if qs.Has("verify") {
+		testing.WriteResults(a.doc, ui.VerifySetup(ctx))
 	}
 
 	return nil
 }
 
+// authenticate asks the user to touch their security key, and returns the
+// result to the background worker. The window is closed once finished.
+func (a *options) authenticate(ctx jsutil.AsyncContext, request string) {
+	body := a.doc.GetElementsByTag("body")[0]
+	dom.RemoveChildren(body)
+	dom.AppendChild(body, a.doc.NewElement("p"), func(p js.Value) {
+		dom.AppendChild(p, a.doc.NewText("Touch your security key to sign with your SSH key."), nil)
+	})
+
+	if err := securitykey.Respond(ctx, request, message.NewLocalSender()); err != nil {
+		jsutil.LogError("failed to respond to security key request: %v", err)
+	}
+	js.Global().Call("close")
+}
+
 func main() {
 	a := app.New(newOptions())
 	defer a.Release()
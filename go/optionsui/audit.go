@@ -0,0 +1,91 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// auditKeyName returns the name under which the key used in an audit entry is
+// displayed: the name of the configured key, if any, or its fingerprint.
+func auditKeyName(e *keys.AuditEntry, configured []*keys.ConfiguredKey) string {
+	for _, k := range configured {
+		if k.Fingerprint != "" && k.Fingerprint == e.Fingerprint {
+			return k.Name
+		}
+	}
+	return e.Fingerprint
+}
+
+// auditResult describes the outcome of the signature request.
+func auditResult(e *keys.AuditEntry) string {
+	if e.Err == "" {
+		return i18n.Message("auditSigned")
+	}
+	return i18n.Message("auditRefused", e.Err)
+}
+
+// updateAudit reads the log of signatures requested from the agent, and
+// displays it.
+func (u *UI) updateAudit(ctx jsutil.AsyncContext) {
+	entries, err := u.mgr.AuditEntries(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read signature log: %v", err)
+		return
+	}
+	u.setAudit(entries)
+}
+
+// clearAudit removes all entries from the log of signatures, and refreshes
+// the displayed log.
+func (u *UI) clearAudit(ctx jsutil.AsyncContext, _ dom.Event) {
+	if err := u.mgr.ClearAuditLog(ctx); err != nil {
+		u.setError(i18n.Wrap(err, "failedClearAudit"))
+	} else {
+		u.setError(nil)
+	}
+	u.updateAudit(ctx)
+}
+
+// setAudit refreshes the UI to reflect the log of signatures that should be
+// displayed. The most recent entries are displayed first.
+func (u *UI) setAudit(entries []*keys.AuditEntry) {
+	dom.RemoveChildren(u.auditData)
+	u.noAudit.Set("hidden", len(entries) > 0)
+	u.clearAuditButton.Set("disabled", len(entries) == 0)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		dom.AppendChild(u.auditData, u.dom.NewElement("tr"), func(row js.Value) {
+			for _, text := range []string{
+				formatSeen(e.Time),
+				auditKeyName(e, u.configured),
+				e.Client,
+				auditResult(e),
+			} {
+				dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+					dom.AppendChild(cell, u.dom.NewText(text), nil)
+				})
+			}
+		})
+	}
+}
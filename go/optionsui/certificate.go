@@ -0,0 +1,80 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// setCertificate prompts the user for a new certificate for the key with the
+// specified ID, and replaces the key's certificate with it.
+func (u *UI) setCertificate(ctx jsutil.AsyncContext, id keys.ID) {
+	k := u.keyByID(id)
+	if k == nil {
+		u.setError(i18n.Wrap(keys.ErrKeyNotFound, "failedSetCertificate"))
+		return
+	}
+
+	ok, certificate := u.promptCertificate(ctx, k.Name)
+	if !ok {
+		return
+	}
+
+	if err := u.mgr.SetCertificate(ctx, id, certificate); err != nil {
+		u.setError(i18n.Wrap(err, "failedSetCertificate"))
+		return
+	}
+
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// promptCertificate displays a dialog prompting the user for the new
+// certificate for the key with the specified name.
+func (u *UI) promptCertificate(ctx jsutil.AsyncContext, name string) (ok bool, certificate string) {
+	dialog := u.dialog("certificateDialog")
+	form := u.dom.GetElement("certificateForm")
+	nameText := u.dom.GetElement("certificateName")
+	certField := u.dom.GetElement("certificate")
+	cancel := u.dom.GetElement("certificateCancel")
+
+	dom.RemoveChildren(nameText)
+	dom.AppendChild(nameText, u.dom.NewText(name), nil)
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		certificate = dom.Value(certField)
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.SetValue(certField, "")
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
@@ -0,0 +1,230 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"slices"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// clientElementID returns the value of the 'id' attribute to be assigned to
+// an element of the specified kind (e.g., 'revoke') for a client.
+func clientElementID(kind, id string) string {
+	return fmt.Sprintf("client-%s-%s", kind, id)
+}
+
+// clientKeyElementID returns the value of the 'id' attribute to be assigned
+// to the checkbox that grants a key to a client.
+func clientKeyElementID(id string, key keys.ID) string {
+	return fmt.Sprintf("client-key-%s-%s", id, key)
+}
+
+// clientStatus describes the user's decision for a client.
+func clientStatus(c *clients.Client) string {
+	switch c.Decision {
+	case clients.Allowed:
+		return i18n.Message("clientAllowed")
+	case clients.Denied:
+		return i18n.Message("clientRevoked")
+	default:
+		return i18n.Message("clientUndecided")
+	}
+}
+
+// formatSeen formats the time at which a client connected for display.
+func formatSeen(t int64) string {
+	if t == 0 {
+		return i18n.Message("seenUnknown")
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// grantableKeys returns the configured keys that may be granted to clients.
+// Keys are granted by fingerprint, so keys whose fingerprint is not known
+// are excluded.
+func grantableKeys(configured []*keys.ConfiguredKey) []*keys.ConfiguredKey {
+	var result []*keys.ConfiguredKey
+	for _, k := range configured {
+		if k.Fingerprint != "" {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// updateClients reads the records of clients that have connected to the
+// agent, and displays them.
+func (u *UI) updateClients(ctx jsutil.AsyncContext) {
+	cs, err := u.clients.List(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read clients: %v", err)
+		return
+	}
+	u.setClients(cs)
+	u.updateConnections(ctx, cs)
+}
+
+// changeClient applies the change to the client's record, and refreshes the
+// displayed clients.
+func (u *UI) changeClient(ctx jsutil.AsyncContext, id string, change func(c *clients.Client)) {
+	if err := u.clients.Update(ctx, id, change); err != nil {
+		u.setError(i18n.Wrap(err, "failedUpdateClient"))
+	} else {
+		u.setError(nil)
+	}
+	u.updateClients(ctx)
+}
+
+// forgetClient removes the client's record, and refreshes the displayed
+// clients.
+func (u *UI) forgetClient(ctx jsutil.AsyncContext, id string) {
+	if err := u.clients.Delete(ctx, id); err != nil {
+		u.setError(i18n.Wrap(err, "failedRemoveClient"))
+	} else {
+		u.setError(nil)
+	}
+	u.updateClients(ctx)
+}
+
+// grantedKeys returns the fingerprints of the keys granted to the client,
+// as selected in the UI. Fingerprints of granted keys that are not
+// displayed (e.g., because they are no longer configured) are retained.
+func (u *UI) grantedKeys(c *clients.Client) []string {
+	grantable := grantableKeys(u.configured)
+	var result []string
+	for _, fp := range c.Keys {
+		if !slices.ContainsFunc(grantable, func(k *keys.ConfiguredKey) bool { return k.Fingerprint == fp }) {
+			result = append(result, fp)
+		}
+	}
+	for _, k := range grantable {
+		if dom.Checked(u.dom.GetElement(clientKeyElementID(c.ID, keys.ID(k.ID)))) {
+			result = append(result, k.Fingerprint)
+		}
+	}
+	return result
+}
+
+// setClients refreshes the UI to reflect the clients that should be
+// displayed.
+func (u *UI) setClients(cs []*clients.Client) {
+	dom.RemoveChildren(u.clientsData)
+	u.clientsCleanup.Do()
+	u.clientsCleanup = &jsutil.CleanupFuncs{}
+	u.noClients.Set("hidden", len(cs) > 0)
+
+	grantable := grantableKeys(u.configured)
+	for _, c := range cs {
+		c := c
+		dom.AppendChild(u.clientsData, u.dom.NewElement("tr"), func(row js.Value) {
+			// Name and ID
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewElement("input"), func(input js.Value) {
+					input.Set("type", "text")
+					input.Set("id", clientElementID("name", c.ID))
+					input.Set("placeholder", i18n.Message("clientNamePlaceholder"))
+					dom.SetValue(input, c.Name)
+					u.clientsCleanup.Add(dom.OnChange(input, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						name := dom.Value(input)
+						u.changeClient(ctx, c.ID, func(c *clients.Client) { c.Name = name })
+					}))
+				})
+				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+					dom.AddClass(div, "clientID", "note")
+					dom.AppendChild(div, u.dom.NewText(c.ID), nil)
+				})
+			})
+
+			// Status
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				cell.Set("id", clientElementID("status", c.ID))
+				dom.AppendChild(cell, u.dom.NewText(clientStatus(c)), nil)
+			})
+
+			// Granted keys
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				for _, k := range grantable {
+					k := k
+					dom.AppendChild(cell, u.dom.NewElement("label"), func(label js.Value) {
+						dom.AddClass(label, "clientKey")
+						dom.AppendChild(label, u.dom.NewElement("input"), func(cb js.Value) {
+							cb.Set("type", "checkbox")
+							cb.Set("id", clientKeyElementID(c.ID, keys.ID(k.ID)))
+							dom.SetChecked(cb, len(c.Keys) > 0 && c.Permits(k.Fingerprint))
+							u.clientsCleanup.Add(dom.OnChange(cb, func(ctx jsutil.AsyncContext, evt dom.Event) {
+								granted := u.grantedKeys(c)
+								u.changeClient(ctx, c.ID, func(c *clients.Client) { c.Keys = granted })
+							}))
+						})
+						dom.AppendChild(label, u.dom.NewText(k.Name), nil)
+					})
+				}
+				if len(c.Keys) == 0 {
+					dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+						dom.AddClass(div, "clientAllKeys")
+						dom.AppendChild(div, u.dom.NewText(i18n.Message("clientAllKeys")), nil)
+					})
+				}
+			})
+
+			// First and last seen
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+					dom.AppendChild(div, u.dom.NewText(i18n.Message("clientFirstSeen", formatSeen(c.FirstSeen))), nil)
+				})
+				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+					dom.AppendChild(div, u.dom.NewText(i18n.Message("clientLastSeen", formatSeen(c.LastSeen))), nil)
+				})
+			})
+
+			// Controls
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				button := func(kind, text string, onClick func(ctx jsutil.AsyncContext)) {
+					dom.AppendChild(cell, u.dom.NewElement("button"), func(btn js.Value) {
+						btn.Set("type", "button")
+						btn.Set("id", clientElementID(kind, c.ID))
+						dom.AppendChild(btn, u.dom.NewText(text), nil)
+						u.clientsCleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+							onClick(ctx)
+						}))
+					})
+				}
+				if c.Decision != clients.Allowed {
+					button("allow", i18n.Message("clientAllow"), func(ctx jsutil.AsyncContext) {
+						u.changeClient(ctx, c.ID, func(c *clients.Client) { c.Decision = clients.Allowed })
+					})
+				}
+				if c.Decision != clients.Denied {
+					button("revoke", i18n.Message("clientRevoke"), func(ctx jsutil.AsyncContext) {
+						u.changeClient(ctx, c.ID, func(c *clients.Client) { c.Decision = clients.Denied })
+					})
+				}
+				button("forget", i18n.Message("clientForget"), func(ctx jsutil.AsyncContext) {
+					u.forgetClient(ctx, c.ID)
+				})
+			})
+		})
+	}
+}
@@ -0,0 +1,120 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// conflictingKeys returns the displayed keys that share the name of the key
+// with the specified ID, including the key itself.
+func (u *UI) conflictingKeys(id keys.ID) []*displayedKey {
+	k := u.keyByID(id)
+	if k == nil {
+		return nil
+	}
+	var result []*displayedKey
+	for _, c := range u.keys {
+		if c.ID != keys.InvalidID && c.Name == k.Name {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// conflictLabel describes a conflicting key, such that the user can tell it
+// apart from the others with the same name.
+func conflictLabel(k *displayedKey) string {
+	location := i18n.Message("locationSynced")
+	if k.Local {
+		location = i18n.Message("locationLocal")
+	}
+	fingerprint := k.Fingerprint
+	if fingerprint == "" {
+		fingerprint = i18n.Message("fingerprintUnknown")
+	}
+	return i18n.Message("conflictLabel", location, fingerprint)
+}
+
+// resolveConflict prompts the user to choose which of the keys sharing the
+// name of the key with the specified ID to keep, and removes the others.
+func (u *UI) resolveConflict(ctx jsutil.AsyncContext, id keys.ID) {
+	conflicting := u.conflictingKeys(id)
+	if len(conflicting) == 0 {
+		u.setError(i18n.Wrap(keys.ErrKeyNotFound, "failedResolveConflict"))
+		return
+	}
+
+	ok, keep := u.promptConflict(ctx, conflicting)
+	if !ok {
+		return
+	}
+
+	for _, k := range conflicting {
+		if k.ID == keep {
+			continue
+		}
+		if err := u.mgr.Remove(ctx, k.ID); err != nil {
+			u.setError(i18n.Wrap(err, "failedRemoveKey", string(k.ID)))
+			u.updateKeys(ctx)
+			return
+		}
+		u.forgetPassphrase(ctx, k.ID)
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// promptConflict displays a dialog prompting the user to choose which of the
+// conflicting keys to keep.
+func (u *UI) promptConflict(ctx jsutil.AsyncContext, conflicting []*displayedKey) (ok bool, keep keys.ID) {
+	dialog := u.dialog("conflictDialog")
+	form := u.dom.GetElement("conflictForm")
+	nameText := u.dom.GetElement("conflictName")
+	sel := u.dom.GetElement("conflictKeep")
+	cancel := u.dom.GetElement("conflictCancel")
+
+	dom.RemoveChildren(nameText)
+	dom.AppendChild(nameText, u.dom.NewText(conflicting[0].Name), nil)
+	dom.RemoveChildren(sel)
+	for _, k := range conflicting {
+		u.appendOption(sel, string(k.ID), conflictLabel(k))
+	}
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		keep = keys.ID(dom.Value(sel))
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.RemoveChildren(sel)
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
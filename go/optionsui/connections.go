@@ -0,0 +1,71 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// connectionName returns the name under which a connected client is
+// displayed: the name the user gave the client, if any, or its ID.
+func connectionName(c *keys.Connection, cs []*clients.Client) string {
+	for _, cl := range cs {
+		if cl.ID == c.Client && cl.Name != "" {
+			return fmt.Sprintf("%s (%s)", cl.Name, c.Client)
+		}
+	}
+	return c.Client
+}
+
+// updateConnections reads the clients currently connected to the agent, and
+// displays them. cs are the records of clients, used to display the names
+// the user gave them.
+func (u *UI) updateConnections(ctx jsutil.AsyncContext, cs []*clients.Client) {
+	conns, err := u.mgr.Connected(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read connected clients: %v", err)
+		return
+	}
+	u.setConnections(conns, cs)
+}
+
+// setConnections refreshes the UI to reflect the connected clients that
+// should be displayed.
+func (u *UI) setConnections(conns []*keys.Connection, cs []*clients.Client) {
+	dom.RemoveChildren(u.connectionsData)
+	u.noConnections.Set("hidden", len(conns) > 0)
+
+	for _, c := range conns {
+		dom.AppendChild(u.connectionsData, u.dom.NewElement("tr"), func(row js.Value) {
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewText(connectionName(c, cs)), nil)
+			})
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewText(c.Origin), nil)
+			})
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewText(formatSeen(c.Connected)), nil)
+			})
+		})
+	}
+}
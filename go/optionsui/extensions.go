@@ -0,0 +1,66 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+var errInvalidExtensionID = i18n.NewError("errInvalidExtensionID")
+
+// parseExtensionIDs parses extension IDs separated by whitespace or commas.
+// Duplicate IDs are removed.
+func parseExtensionIDs(text string) ([]string, error) {
+	var result []string
+	for _, id := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if !settings.ValidExtensionID(id) {
+			return nil, fmt.Errorf("%w: %s", errInvalidExtensionID, id)
+		}
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// updateAllowedExtensions displays the extensions allowed to connect from the
+// settings.
+func (u *UI) updateAllowedExtensions(s *settings.Settings, managed bool) {
+	dom.SetValue(u.allowedExtensions, strings.Join(s.AllowedExtensions, "\n"))
+	u.allowedExtensions.Set("disabled", managed)
+}
+
+// changeAllowedExtensions stores the extensions allowed to connect when the
+// user changes them.
+func (u *UI) changeAllowedExtensions(ctx jsutil.AsyncContext, _ dom.Event) {
+	ids, err := parseExtensionIDs(dom.Value(u.allowedExtensions))
+	if err != nil {
+		u.setError(err)
+		return
+	}
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.AllowedExtensions = ids
+	})
+}
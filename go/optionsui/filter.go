@@ -0,0 +1,160 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// sortColumn is a column of the keys table by which keys may be sorted.
+type sortColumn int
+
+const (
+	// sortByName sorts keys by name. This is the default.
+	sortByName sortColumn = iota
+	// sortByType sorts keys by type (e.g., 'ssh-rsa').
+	sortByType
+	// sortByFingerprint sorts keys by fingerprint.
+	sortByFingerprint
+	// sortByLastUsed sorts keys by the time they were last used, such
+	// that keys that have not been used are first.
+	sortByLastUsed
+)
+
+// sortHeader is a header of the keys table that sorts by its column when
+// clicked.
+type sortHeader struct {
+	column sortColumn
+	cell   js.Value
+}
+
+// sortKey returns the value of the column used to order the key.
+func (c sortColumn) sortKey(k *displayedKey) string {
+	switch c {
+	case sortByType:
+		return k.Type
+	case sortByFingerprint:
+		return k.Fingerprint
+	case sortByLastUsed:
+		// Padded so that times order correctly as strings.
+		return fmt.Sprintf("%020d", k.LastUsed)
+	}
+	return strings.ToLower(k.Name)
+}
+
+// sortKeys returns the keys ordered by the specified column. Keys with equal
+// values retain their existing order.
+func sortKeys(disp []*displayedKey, column sortColumn, descending bool) []*displayedKey {
+	result := append([]*displayedKey(nil), disp...)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := column.sortKey(result[i]), column.sortKey(result[j])
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+	return result
+}
+
+// matchesFilter indicates if the key matches the filter entered by the user.
+// The filter matches keys whose name, type, comment, fingerprint, or one of
+// whose tags or hosts contains it, ignoring case. An empty filter matches all keys.
+func (d *displayedKey) matchesFilter(filter string) bool {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return true
+	}
+	fields := []string{d.Name, d.Type, d.Comment, d.Fingerprint}
+	fields = append(fields, d.Tags...)
+	fields = append(fields, d.Hosts...)
+	for _, s := range fields {
+		if strings.Contains(strings.ToLower(s), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterKeys hides the rows of keys that do not match the filter.
+func (u *UI) filterKeys(_ jsutil.AsyncContext, _ dom.Event) {
+	u.arrangeKeys(u.keys)
+}
+
+// sortBy sorts the keys table by the specified column. Sorting by the current
+// column again reverses the order.
+func (u *UI) sortBy(column sortColumn) {
+	if u.sortColumn == column {
+		u.sortDescending = !u.sortDescending
+	} else {
+		u.sortColumn = column
+		u.sortDescending = false
+	}
+	u.arrangeKeys(u.keys)
+}
+
+// arrangeKeys orders the rows of the keys table according to the selected
+// column, and hides those that do not match the filter, are not in the
+// active keyring, or lack the selected tag. Rows are moved rather than
+// rebuilt, and rows already in place are not touched, so that updates remain
+// fast with many keys.
+func (u *UI) arrangeKeys(disp []*displayedKey) {
+	// Moving a row removes focus from it; restore focus afterwards.
+	focus := u.saveKeysFocus()
+	defer u.restoreKeysFocus(focus)
+
+	filter := dom.Value(u.keysFilter)
+	matched := 0
+	rows := u.keysData.Get("children") // Live; reflects moves below.
+	for i, k := range sortKeys(disp, u.sortColumn, u.sortDescending) {
+		match := k.matchesFilter(filter) && k.matchesKeyring(u.keyring) && k.matchesTag(u.tag)
+		if match {
+			matched++
+		}
+		if k.row.Get("hidden").Bool() == match {
+			k.row.Set("hidden", !match)
+		}
+		if i < rows.Length() && rows.Index(i).Equal(k.row) {
+			continue
+		}
+		next := js.Null()
+		if i < rows.Length() {
+			next = rows.Index(i)
+		}
+		u.keysData.Call("insertBefore", k.row, next)
+	}
+	u.noMatchingKeys.Set("hidden", matched > 0 || len(disp) == 0)
+
+	for _, h := range u.sortHeaders {
+		sorted := h.column == u.sortColumn
+		dom.SetClass(h.cell, "sortAscending", sorted && !u.sortDescending)
+		dom.SetClass(h.cell, "sortDescending", sorted && u.sortDescending)
+		sort := "none"
+		if sorted && u.sortDescending {
+			sort = "descending"
+		} else if sorted {
+			sort = "ascending"
+		}
+		dom.SetAttribute(h.cell, "aria-sort", sort)
+	}
+	u.updateTabOrder(disp)
+}
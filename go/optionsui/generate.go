@@ -0,0 +1,169 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/passgen"
+)
+
+var (
+	errPassphraseMismatch = i18n.NewError("errPassphraseMismatch")
+)
+
+// generate prompts the user for the parameters of a new key, generates it,
+// and displays its public key.
+func (u *UI) generate(ctx jsutil.AsyncContext, _ dom.Event) {
+	ok, name, keyType, passphrase, confirm := u.promptGenerate(ctx)
+	if !ok {
+		return
+	}
+
+	u.generatedPane.Set("hidden", true)
+	dom.RemoveChildren(u.generatedKey)
+	if passphrase != confirm {
+		u.setError(i18n.Wrap(errPassphraseMismatch, "failedGenerateKey"))
+		return
+	}
+
+	// Options are of the form 'type' or 'type-bits'.
+	keyType, sbits, _ := strings.Cut(keyType, "-")
+	var bits int
+	if sbits != "" {
+		var err error
+		if bits, err = strconv.Atoi(sbits); err != nil {
+			u.setError(i18n.NewError("failedGenerateKeyInvalidSize", sbits))
+			return
+		}
+	}
+
+	pub, err := u.mgr.Generate(ctx, name, keyType, bits, passphrase)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedGenerateKey"))
+		return
+	}
+
+	u.setError(nil)
+	dom.AppendChild(u.generatedKey, u.dom.NewText(pub), nil)
+	u.generatedPane.Set("hidden", false)
+	u.updateKeys(ctx)
+}
+
+// promptGenerate displays a dialog prompting the user for a name, key type
+// and passphrase for a new key.
+func (u *UI) promptGenerate(ctx jsutil.AsyncContext) (ok bool, name, keyType, passphrase, confirm string) {
+	dialog := u.dialog("generateDialog")
+	form := u.dom.GetElement("generateForm")
+	nameField := u.dom.GetElement("generateName")
+	typeField := u.dom.GetElement("generateType")
+	passphraseField := u.dom.GetElement("generatePassphrase")
+	confirmField := u.dom.GetElement("generateConfirm")
+	style := u.dom.GetElement("generateSuggestStyle")
+	suggest := u.dom.GetElement("generateSuggest")
+	copyButton := u.dom.GetElement("generateCopy")
+	cancel := u.dom.GetElement("generateCancel")
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		name = dom.Value(nameField)
+		keyType = dom.Value(typeField)
+		passphrase = dom.Value(passphraseField)
+		confirm = dom.Value(confirmField)
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(suggest, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		u.suggestPassphrase(style, copyButton, passphraseField, confirmField)
+	}))
+	cleanup.Add(dom.OnClick(copyButton, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		u.copyPassphrase(ctx, passphraseField)
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.SetValue(nameField, "")
+		hidePassphrase(copyButton, passphraseField, confirmField)
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
+
+// defaultPassphraseStyle is the style of passphrase suggested if none is
+// selected.
+const defaultPassphraseStyle = "words8"
+
+// passphraseStyles generate the passphrases that may be suggested, keyed by
+// the value of the option selecting them (see options.html).
+var passphraseStyles = map[string]func() (string, error){
+	"words6":  func() (string, error) { return passgen.Words(6, passgen.DefaultSeparator) },
+	"words8":  func() (string, error) { return passgen.Words(passgen.DefaultWords, passgen.DefaultSeparator) },
+	"words12": func() (string, error) { return passgen.Words(12, passgen.DefaultSeparator) },
+	"chars20": func() (string, error) { return passgen.Random(passgen.DefaultLength, passgen.DefaultAlphabet) },
+	"chars32": func() (string, error) { return passgen.Random(32, passgen.DefaultAlphabet) },
+}
+
+// suggestPassphrase fills the passphrase fields (i.e., the passphrase and its
+// confirmation) with a randomly generated passphrase of the style selected
+// in style, and reveals it so the user can record it. The copy button is
+// enabled, so that the user can copy it to a password manager.
+func (u *UI) suggestPassphrase(style, copyButton js.Value, fields ...js.Value) {
+	generate, ok := passphraseStyles[dom.Value(style)]
+	if !ok {
+		generate = passphraseStyles[defaultPassphraseStyle]
+	}
+	passphrase, err := generate()
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedSuggestPassphrase"))
+		return
+	}
+	for _, f := range fields {
+		dom.SetValue(f, passphrase)
+		f.Set("type", "text")
+	}
+	copyButton.Set("disabled", false)
+}
+
+// copyPassphrase copies the passphrase in field to the clipboard.
+func (u *UI) copyPassphrase(ctx jsutil.AsyncContext, field js.Value) {
+	if err := writeClipboard(ctx, dom.Value(field)); err != nil {
+		u.setError(i18n.Wrap(err, "failedCopyPassphrase"))
+		return
+	}
+	u.setError(nil)
+}
+
+// hidePassphrase clears the passphrase fields, hides any passphrase revealed
+// by suggestPassphrase, and disables the copy button.
+func hidePassphrase(copyButton js.Value, fields ...js.Value) {
+	for _, f := range fields {
+		dom.SetValue(f, "")
+		f.Set("type", "password")
+	}
+	copyButton.Set("disabled", true)
+}
@@ -0,0 +1,73 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// splitHosts splits the host patterns entered by the user, which may be
+// separated by commas or whitespace. The manager trims and deduplicates them.
+func splitHosts(s string) []string {
+	return strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' })
+}
+
+// appendHosts appends a note describing the hosts for which the key is
+// intended.
+func (u *UI) appendHosts(parent js.Value, k *displayedKey) {
+	if len(k.Hosts) == 0 {
+		return
+	}
+	dom.AppendChild(parent, u.dom.NewElement("div"), func(div js.Value) {
+		dom.AddClass(div, "keyHosts", "note")
+		dom.AppendChild(div, u.dom.NewText(i18n.Message("keyHosts", strings.Join(k.Hosts, ", "))), nil)
+	})
+}
+
+// appendHostsControl appends an input element to edit the hosts for which
+// the key is intended.
+func (u *UI) appendHostsControl(parent js.Value, k *displayedKey) {
+	dom.AppendChild(parent, u.dom.NewElement("input"), func(input js.Value) {
+		input.Set("type", "text")
+		input.Set("id", buttonID(HostsInput, k.ID))
+		dom.AddClass(input, "hosts")
+		input.Set("title", i18n.Message("keyHostsTitle"))
+		input.Set("placeholder", i18n.Message("keyHostsPlaceholder"))
+		dom.SetValue(input, strings.Join(k.Hosts, ", "))
+		k.cleanup.Add(dom.OnChange(input, func(ctx jsutil.AsyncContext, evt dom.Event) {
+			u.setHosts(ctx, k.ID, splitHosts(dom.Value(input)))
+		}))
+	})
+}
+
+// setHosts replaces the hosts for which the specified key is intended.
+func (u *UI) setHosts(ctx jsutil.AsyncContext, id keys.ID, hosts []string) {
+	if err := u.mgr.SetHosts(ctx, id, hosts); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.invalidateRow(id)
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
@@ -0,0 +1,179 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strconv"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// idleTimeoutChoices are the idle timeouts, in minutes, offered for
+// individual keys in addition to the default and never unloading them.
+var idleTimeoutChoices = []int{15, 60, 4 * 60, 8 * 60}
+
+// idleTimeoutLabel returns a human-readable description of an idle timeout.
+func idleTimeoutLabel(minutes int) string {
+	switch {
+	case minutes == 60:
+		return i18n.Message("durationOneHour")
+	case minutes > 60 && minutes%60 == 0:
+		return i18n.Message("durationHours", strconv.Itoa(minutes/60))
+	case minutes == 1:
+		return i18n.Message("durationOneMinute")
+	default:
+		return i18n.Message("durationMinutes", strconv.Itoa(minutes))
+	}
+}
+
+// appendOption appends an option to a select element.
+func (u *UI) appendOption(sel js.Value, value, label string) {
+	dom.AppendChild(sel, u.dom.NewElement("option"), func(opt js.Value) {
+		opt.Set("value", value)
+		dom.AppendChild(opt, u.dom.NewText(label), nil)
+	})
+}
+
+// selectOption selects the option with the specified value, first appending
+// one with the specified label if none exists (e.g., the value was set by
+// policy).
+func (u *UI) selectOption(sel js.Value, value, label string) {
+	options := sel.Get("options")
+	found := false
+	for i := 0; i < options.Length(); i++ {
+		if options.Index(i).Get("value").String() == value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		u.appendOption(sel, value, label)
+	}
+	dom.SetValue(sel, value)
+}
+
+// updateIdleTimeout displays the default idle timeout from the settings.
+func (u *UI) updateIdleTimeout(s *settings.Settings, managed bool) {
+	minutes := s.IdleTimeoutMinutes
+	if minutes < 0 {
+		minutes = 0
+	}
+	u.selectOption(u.idleTimeout, strconv.Itoa(minutes), idleTimeoutLabel(minutes))
+	u.idleTimeout.Set("disabled", managed)
+}
+
+// changeIdleTimeout stores the default idle timeout when the user changes it.
+func (u *UI) changeIdleTimeout(ctx jsutil.AsyncContext, _ dom.Event) {
+	minutes, err := strconv.Atoi(dom.Value(u.idleTimeout))
+	if err != nil {
+		u.setError(i18n.Wrap(err, "errInvalidIdleTimeout"))
+		return
+	}
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.IdleTimeoutMinutes = minutes
+	})
+}
+
+// updateConnectionIdleTimeout displays the connection idle timeout from the
+// settings.
+func (u *UI) updateConnectionIdleTimeout(s *settings.Settings, managed bool) {
+	minutes := s.ConnectionIdleTimeoutMinutes
+	if minutes != 0 {
+		minutes = int(s.ConnectionIdleTimeout() / time.Minute)
+	}
+	u.selectOption(u.connIdleTimeout, strconv.Itoa(minutes), idleTimeoutLabel(minutes))
+	u.connIdleTimeout.Set("disabled", managed)
+}
+
+// changeConnectionIdleTimeout stores the connection idle timeout when the
+// user changes it.
+func (u *UI) changeConnectionIdleTimeout(ctx jsutil.AsyncContext, _ dom.Event) {
+	minutes, err := strconv.Atoi(dom.Value(u.connIdleTimeout))
+	if err != nil {
+		u.setError(i18n.Wrap(err, "errInvalidIdleTimeout"))
+		return
+	}
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.ConnectionIdleTimeoutMinutes = minutes
+	})
+}
+
+// updateExportReauth displays the time during which private keys may be
+// exported again without the master password.
+func (u *UI) updateExportReauth(s *settings.Settings, managed bool) {
+	minutes := int(s.ExportReauthWindow() / time.Minute)
+	label := i18n.Message("exportReauthAfter", idleTimeoutLabel(minutes))
+	if minutes == 0 {
+		label = i18n.Message("exportReauthAlways")
+	}
+	u.selectOption(u.exportReauth, strconv.Itoa(minutes), label)
+	u.exportReauth.Set("disabled", managed)
+}
+
+// changeExportReauth stores the time during which private keys may be
+// exported again without the master password when the user changes it.
+func (u *UI) changeExportReauth(ctx jsutil.AsyncContext, _ dom.Event) {
+	minutes, err := strconv.Atoi(dom.Value(u.exportReauth))
+	if err != nil {
+		u.setError(i18n.Wrap(err, "errInvalidExportReauth"))
+		return
+	}
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.ExportReauthMinutes = minutes
+	})
+}
+
+// appendIdleTimeoutControl appends a select element to configure the idle
+// timeout for the key.
+func (u *UI) appendIdleTimeoutControl(parent js.Value, k *displayedKey) {
+	dom.AppendChild(parent, u.dom.NewElement("select"), func(sel js.Value) {
+		sel.Set("id", buttonID(IdleTimeoutSelect, k.ID))
+		sel.Set("title", i18n.Message("keyIdleTimeoutTitle"))
+		u.appendOption(sel, strconv.Itoa(keys.IdleTimeoutDefault), i18n.Message("keyIdleTimeoutDefault"))
+		u.appendOption(sel, strconv.Itoa(keys.IdleTimeoutNever), i18n.Message("keyIdleTimeoutNever"))
+		for _, minutes := range idleTimeoutChoices {
+			u.appendOption(sel, strconv.Itoa(minutes), i18n.Message("keyIdleTimeoutAfter", idleTimeoutLabel(minutes)))
+		}
+		u.selectOption(sel, strconv.Itoa(k.IdleTimeout), i18n.Message("keyIdleTimeoutAfter", idleTimeoutLabel(k.IdleTimeout)))
+		k.cleanup.Add(dom.OnChange(sel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+			minutes, err := strconv.Atoi(dom.Value(sel))
+			if err != nil {
+				u.setError(i18n.Wrap(err, "errInvalidIdleTimeout"))
+				return
+			}
+			u.setIdleTimeout(ctx, k.ID, minutes)
+		}))
+	})
+}
+
+// setIdleTimeout configures the idle timeout for the specified key.
+func (u *UI) setIdleTimeout(ctx jsutil.AsyncContext, id keys.ID, minutes int) {
+	if err := u.mgr.SetIdleTimeout(ctx, id, minutes); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.invalidateRow(id)
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
@@ -0,0 +1,112 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+var (
+	errNoFile = i18n.NewError("errNoFile")
+)
+
+// importKeys prompts the user for a file of exported keys, and configures
+// the keys in it.
+func (u *UI) importKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	ok, file, onConflict := u.promptImport(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := readFile(ctx, file)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedImportKeys"))
+		return
+	}
+	res, err := u.mgr.Import(ctx, data, onConflict)
+	// Some keys may have been imported even if an error occurred.
+	u.updateKeys(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedImportKeys"))
+		return
+	}
+	u.setError(nil)
+
+	msg := i18n.Message("nothingImported")
+	if len(res.Imported) > 0 {
+		msg = i18n.Message("imported", strings.Join(res.Imported, ", "))
+	}
+	if len(res.Skipped) > 0 {
+		msg += " " + i18n.Message("notImported", strings.Join(res.Skipped, ", "))
+	}
+	dom.RemoveChildren(u.importResult)
+	dom.AppendChild(u.importResult, u.dom.NewText(msg), nil)
+}
+
+// promptImport displays a dialog prompting the user for a file of exported
+// keys, and how to handle keys whose name is already in use.
+func (u *UI) promptImport(ctx jsutil.AsyncContext) (ok bool, file js.Value, onConflict string) {
+	dialog := u.dialog("importDialog")
+	form := u.dom.GetElement("importForm")
+	fileField := u.dom.GetElement("importFile")
+	conflictField := u.dom.GetElement("importConflict")
+	cancel := u.dom.GetElement("importCancel")
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		file = js.Null()
+		if files := fileField.Get("files"); files.Truthy() && files.Length() > 0 {
+			file = files.Index(0)
+		}
+		onConflict = dom.Value(conflictField)
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.SetValue(fileField, "")
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
+
+// readFile returns the content of a File selected by the user.
+func readFile(ctx jsutil.AsyncContext, file js.Value) ([]byte, error) {
+	if !file.Truthy() {
+		return nil, errNoFile
+	}
+	buf, err := jsutil.AsPromise(file.Call("arrayBuffer")).Await(ctx)
+	if err != nil {
+		return nil, i18n.Wrap(err, "failedReadFile", file.Get("name").String())
+	}
+	arr := js.Global().Get("Uint8Array").New(buf)
+	data := make([]byte, arr.Length())
+	js.CopyBytesToGo(data, arr)
+	return data, nil
+}
@@ -0,0 +1,195 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// The keys table follows the ARIA grid pattern: a single row is in the tab
+// order at a time (the active row), the arrow keys move between rows, and
+// Enter moves to the controls of the focused row. Only the active row's
+// controls are in the tab order, such that Tab leaves the table after them,
+// rather than visiting the controls of every key.
+
+// keyForElement returns the displayed key whose row is, or contains, the
+// element. nil is returned if there is none.
+func (u *UI) keyForElement(elt js.Value) *displayedKey {
+	if !dom.Contains(u.keysData, elt) {
+		return nil
+	}
+	row := elt.Call("closest", "tr")
+	for _, k := range u.keys {
+		if k.row.Equal(row) {
+			return k
+		}
+	}
+	return nil
+}
+
+// visibleRows returns the rows of the keys table that are displayed, in the
+// order they are displayed.
+func (u *UI) visibleRows() []js.Value {
+	var result []js.Value
+	rows := u.keysData.Get("children")
+	for i := 0; i < rows.Length(); i++ {
+		if row := rows.Index(i); !row.Get("hidden").Bool() {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// updateTabOrder places the active row and its controls in the tab order,
+// and removes the other displayed keys' rows and controls from it. If the
+// active row is not displayed, the first displayed row becomes active.
+func (u *UI) updateTabOrder(disp []*displayedKey) {
+	var active js.Value
+	for _, k := range disp {
+		if k.rowKey() == u.activeRow && !k.row.Get("hidden").Bool() {
+			active = k.row
+		}
+	}
+	if !active.Truthy() {
+		if rows := u.visibleRows(); len(rows) > 0 {
+			active = rows[0]
+		}
+	}
+
+	for _, k := range disp {
+		tabIndex := "-1"
+		if k.row.Equal(active) {
+			tabIndex = "0"
+		}
+		dom.SetAttribute(k.row, "tabindex", tabIndex)
+		for _, c := range dom.Focusable(k.row) {
+			dom.SetAttribute(c, "tabindex", tabIndex)
+		}
+	}
+}
+
+// keysFocused makes the row that received keyboard focus (or contains the
+// control that did) the active row.
+func (u *UI) keysFocused(_ jsutil.AsyncContext, evt dom.Event) {
+	k := u.keyForElement(evt.Get("target"))
+	if k == nil || k.rowKey() == u.activeRow {
+		return
+	}
+	u.activeRow = k.rowKey()
+	u.updateTabOrder(u.keys)
+}
+
+// keysKeyDown handles keyboard navigation of the keys table. On a row, the up
+// and down arrow keys (and Home and End) move to another row, and Enter moves
+// to the row's first control. On a control, Escape returns to its row.
+func (u *UI) keysKeyDown(evt dom.Event) {
+	target := evt.Get("target")
+	k := u.keyForElement(target)
+	if k == nil {
+		return
+	}
+	key := evt.Get("key").String()
+	if !target.Equal(k.row) {
+		if key == "Escape" {
+			evt.Call("preventDefault")
+			dom.Focus(k.row)
+		}
+		return
+	}
+
+	rows := u.visibleRows()
+	i := 0
+	for i < len(rows) && !rows[i].Equal(k.row) {
+		i++
+	}
+	var next js.Value
+	switch key {
+	case "ArrowDown":
+		if i+1 < len(rows) {
+			next = rows[i+1]
+		}
+	case "ArrowUp":
+		if i > 0 {
+			next = rows[i-1]
+		}
+	case "Home":
+		next = rows[0]
+	case "End":
+		next = rows[len(rows)-1]
+	case "Enter":
+		if controls := dom.Focusable(k.row); len(controls) > 0 {
+			next = controls[0]
+		}
+	default:
+		return
+	}
+	evt.Call("preventDefault")
+	if next.Truthy() {
+		dom.Focus(next)
+	}
+}
+
+// keysFocus records which part of the keys table has keyboard focus, so that
+// it can be restored after the table is updated.
+type keysFocus struct {
+	// rowKey identifies the key whose row has focus; see
+	// displayedKey.rowKey.
+	rowKey string
+	// id is the ID of the focused control, or empty if the row itself
+	// has focus.
+	id string
+}
+
+// saveKeysFocus returns the part of the keys table that has keyboard focus,
+// or nil if focus is elsewhere.
+func (u *UI) saveKeysFocus() *keysFocus {
+	active := u.dom.ActiveElement()
+	k := u.keyForElement(active)
+	if k == nil {
+		return nil
+	}
+	f := &keysFocus{rowKey: k.rowKey()}
+	if !active.Equal(k.row) {
+		f.id = dom.ID(active)
+	}
+	return f
+}
+
+// restoreKeysFocus returns keyboard focus to the keys table if it was lost
+// while the table was updated (e.g., because the focused row was replaced or
+// moved). Focus returns to the same control if it is still displayed, and
+// otherwise to the active row.
+func (u *UI) restoreKeysFocus(f *keysFocus) {
+	if f == nil || dom.Contains(u.keysData, u.dom.ActiveElement()) {
+		return
+	}
+	if f.id != "" {
+		if elt := u.dom.GetElement(f.id); dom.Contains(u.keysData, elt) && !elt.Get("disabled").Truthy() {
+			dom.Focus(elt)
+			return
+		}
+	}
+	for _, row := range u.visibleRows() {
+		if row.Get("tabIndex").Int() == 0 {
+			dom.Focus(row)
+			return
+		}
+	}
+}
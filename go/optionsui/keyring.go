@@ -0,0 +1,109 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// keyringLabel returns a human-readable description of a keyring selected as
+// the active keyring.
+func keyringLabel(keyring string) string {
+	if keyring == keys.AllKeyrings {
+		return i18n.Message("allKeyrings")
+	}
+	return i18n.Message("keyringLabel", keyring)
+}
+
+// matchesKeyring indicates if the key is displayed when the specified keyring
+// is active. Keys that are not configured (e.g., they were added by a client)
+// belong to no keyring and are always displayed, just as they are always
+// offered to clients.
+func (d *displayedKey) matchesKeyring(keyring string) bool {
+	return keyring == keys.AllKeyrings || d.ID == keys.InvalidID || d.Keyring == keyring
+}
+
+// updateKeyrings refreshes the keyrings that may be selected as the active
+// keyring, and suggested when assigning a key to a keyring.
+func (u *UI) updateKeyrings(configured []*keys.ConfiguredKey) {
+	names := keys.Keyrings(configured)
+
+	dom.RemoveChildren(u.activeKeyring)
+	u.appendOption(u.activeKeyring, keys.AllKeyrings, keyringLabel(keys.AllKeyrings))
+	for _, name := range names {
+		u.appendOption(u.activeKeyring, name, keyringLabel(name))
+	}
+	u.selectOption(u.activeKeyring, u.keyring, keyringLabel(u.keyring))
+
+	dom.RemoveChildren(u.keyringNames)
+	for _, name := range names {
+		dom.AppendChild(u.keyringNames, u.dom.NewElement("option"), func(opt js.Value) {
+			opt.Set("value", name)
+		})
+	}
+}
+
+// updateActiveKeyring displays the active keyring from the settings, and
+// displays only the keys in it.
+func (u *UI) updateActiveKeyring(s *settings.Settings, managed bool) {
+	u.keyring = s.ActiveKeyring
+	u.selectOption(u.activeKeyring, u.keyring, keyringLabel(u.keyring))
+	u.activeKeyring.Set("disabled", managed)
+	u.arrangeKeys(u.keys)
+}
+
+// changeActiveKeyring stores the active keyring when the user changes it.
+func (u *UI) changeActiveKeyring(ctx jsutil.AsyncContext, _ dom.Event) {
+	keyring := dom.Value(u.activeKeyring)
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.ActiveKeyring = keyring
+	})
+}
+
+// appendKeyringControl appends an input element to assign the key to a
+// keyring.
+func (u *UI) appendKeyringControl(parent js.Value, k *displayedKey) {
+	dom.AppendChild(parent, u.dom.NewElement("input"), func(input js.Value) {
+		input.Set("type", "text")
+		input.Set("id", buttonID(KeyringInput, k.ID))
+		dom.AddClass(input, "keyring")
+		input.Set("title", i18n.Message("keyKeyringTitle"))
+		input.Call("setAttribute", "list", "keyringNames")
+		dom.SetValue(input, k.Keyring)
+		k.cleanup.Add(dom.OnChange(input, func(ctx jsutil.AsyncContext, evt dom.Event) {
+			u.setKeyring(ctx, k.ID, dom.Value(input))
+		}))
+	})
+}
+
+// setKeyring assigns the specified key to a keyring.
+func (u *UI) setKeyring(ctx jsutil.AsyncContext, id keys.ID, keyring string) {
+	if err := u.mgr.SetKeyring(ctx, id, keyring); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.invalidateRow(id)
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
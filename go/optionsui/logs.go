@@ -0,0 +1,83 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// formatLogTime returns a human-readable representation of the time at which
+// an entry was logged, in milliseconds since the Unix epoch.
+func formatLogTime(t int64) string {
+	return time.UnixMilli(t).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// logRows returns the time, level and message of each log entry, most recent
+// first.
+func logRows(entries []*keys.LogEntry) [][3]string {
+	var rows [][3]string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		rows = append(rows, [3]string{formatLogTime(e.Time), e.Level, e.Message})
+	}
+	return rows
+}
+
+// updateLogs reads the entries logged by the background worker, and displays
+// them.
+func (u *UI) updateLogs(ctx jsutil.AsyncContext) {
+	entries, err := u.mgr.LogEntries(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read agent log: %v", err)
+		return
+	}
+	u.setLogs(entries)
+}
+
+// refreshLogs refreshes the displayed entries logged by the background worker.
+func (u *UI) refreshLogs(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.updateLogs(ctx)
+}
+
+// changeVerboseLogging stores the setting when the user changes it.
+func (u *UI) changeVerboseLogging(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.VerboseLogging = dom.Checked(u.verboseLogging)
+	})
+}
+
+// setLogs refreshes the UI to reflect the entries logged by the background
+// worker that should be displayed.
+func (u *UI) setLogs(entries []*keys.LogEntry) {
+	dom.RemoveChildren(u.logData)
+	u.noLogs.Set("hidden", len(entries) > 0)
+	for _, r := range logRows(entries) {
+		dom.AppendChild(u.logData, u.dom.NewElement("tr"), func(row js.Value) {
+			for _, text := range r {
+				dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+					dom.AppendChild(cell, u.dom.NewText(text), nil)
+				})
+			}
+		})
+	}
+}
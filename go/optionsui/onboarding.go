@@ -0,0 +1,92 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// startOnboarding displays the onboarding panel on first run; that is, if no
+// keys are configured and the user has never changed a setting. The panel
+// guides the user through adding a key, copying its public key, and testing
+// the agent.
+func (u *UI) startOnboarding(ctx jsutil.AsyncContext) {
+	if u.viewer {
+		return
+	}
+	configured, err := u.mgr.Configured(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read keys; skipping onboarding: %v", err)
+		return
+	}
+	stored, err := u.settings.Stored(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read settings; skipping onboarding: %v", err)
+		return
+	}
+	if len(configured) > 0 || stored {
+		return
+	}
+
+	u.onboardingPane.Set("hidden", false)
+	u.updateOnboarding()
+}
+
+// onboardingPublicKey returns the public key of the first displayed key whose
+// public key is known, or the empty string if there is none.
+func (u *UI) onboardingPublicKey() string {
+	for _, k := range u.keys {
+		if k.AuthorizedKey != "" {
+			return k.AuthorizedKey
+		}
+	}
+	return ""
+}
+
+// updateOnboarding updates the onboarding panel to reflect the displayed
+// keys. The public key can be copied once it is known.
+func (u *UI) updateOnboarding() {
+	u.onboardingAdd.Set("hidden", !u.capabilities.Add)
+	u.onboardingGen.Set("hidden", !u.capabilities.Add)
+	u.onboardingCopy.Set("disabled", u.onboardingPublicKey() == "")
+}
+
+// copyOnboardingKey copies the public key of the first displayed key to the
+// clipboard.
+func (u *UI) copyOnboardingKey(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.copyPublicKey(ctx, u.onboardingPublicKey())
+}
+
+// testAgent checks that the agent works, and displays the outcome.
+func (u *UI) testAgent(ctx jsutil.AsyncContext, _ dom.Event) {
+	dom.RemoveChildren(u.onboardingResult)
+	text := i18n.Message("selfTestPassed")
+	if err := u.mgr.SelfTest(ctx); err != nil {
+		text = i18n.Message("selfTestFailed", err.Error())
+	}
+	dom.AppendChild(u.onboardingResult, u.dom.NewText(text), nil)
+}
+
+// finishOnboarding hides the onboarding panel. The settings are stored, even
+// if unchanged, so that the panel is not displayed again.
+func (u *UI) finishOnboarding(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.onboardingPane.Set("hidden", true)
+	u.changeSettings(ctx, func(*settings.Settings) {})
+}
@@ -0,0 +1,235 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/approval"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingPrompter allows every client, and counts the number of times it was
+// prompted.
+type countingPrompter struct {
+	prompts int
+}
+
+func (c *countingPrompter) Prompt(ctx jsutil.AsyncContext, client string) (bool, bool, error) {
+	c.prompts++
+	return true, true, nil
+}
+
+// TestPolicyOverridesUser verifies that policy configured in managed storage
+// takes precedence over the user's own settings everywhere it applies: the
+// effective settings, the connection gate, the controls displayed in the UI,
+// and the operations the server permits when the UI is bypassed.
+func TestPolicyOverridesUser(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description     string
+		user            *settings.Settings
+		policy          map[string]js.Value
+		unavailable     bool
+		wantApproval    bool
+		wantLocked      bool
+		wantButtons     []buttonKind
+		wantAddHidden   bool
+		wantOpsRejected bool
+	}{
+		{
+			description:  "no policy",
+			user:         &settings.Settings{ApproveNewClients: true},
+			wantApproval: true,
+			wantButtons:  []buttonKind{LoadButton, RemoveButton, LocationButton},
+		},
+		{
+			description: "policy disables approval enabled by user",
+			user:        &settings.Settings{ApproveNewClients: true},
+			policy: map[string]js.Value{
+				"approveNewClients": js.ValueOf(false),
+			},
+			wantApproval: false,
+			wantLocked:   true,
+			wantButtons:  []buttonKind{LoadButton, RemoveButton, LocationButton},
+		},
+		{
+			description: "policy enables approval disabled by user",
+			user:        &settings.Settings{ApproveNewClients: false},
+			policy: map[string]js.Value{
+				"approveNewClients": js.ValueOf(true),
+			},
+			wantApproval: true,
+			wantLocked:   true,
+			wantButtons:  []buttonKind{LoadButton, RemoveButton, LocationButton},
+		},
+		{
+			description: "policy restricts key management",
+			user:        &settings.Settings{},
+			policy: map[string]js.Value{
+				"disableKeyAdd":            js.ValueOf(true),
+				"disableKeyRemove":         js.ValueOf(true),
+				"disableKeyLocationChange": js.ValueOf(true),
+			},
+			wantButtons:     []buttonKind{LoadButton},
+			wantAddHidden:   true,
+			wantOpsRejected: true,
+		},
+		{
+			description: "managed storage unavailable",
+			user:        &settings.Settings{ApproveNewClients: true},
+			policy: map[string]js.Value{
+				"disableKeyRemove": js.ValueOf(true),
+			},
+			unavailable:  true,
+			wantApproval: true,
+			wantButtons:  []buttonKind{LoadButton, RemoveButton, LocationButton},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				// The user configures their preferences and a key
+				// before the administrator applies policy.
+				if err := h.settings.Set(ctx, tc.user); err != nil {
+					t.Fatalf("failed to write settings: %v", err)
+				}
+				if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				h.managed.SetPolicy(tc.policy)
+				if tc.unavailable {
+					h.managed.SetUnavailable(errors.New("managed storage not supported"))
+				}
+
+				// Effective settings.
+				got, err := h.settings.Get(ctx)
+				if err != nil {
+					t.Fatalf("failed to read settings: %v", err)
+				}
+				if diff := cmp.Diff(got.ApproveNewClients, tc.wantApproval); diff != "" {
+					t.Errorf("incorrect effective approval setting; -got +want: %s", diff)
+				}
+
+				// Connection gate.
+				prompter := &countingPrompter{}
+				gate := approval.NewGate(h.settings, storage.NewRaw(st.NewMemArea()), prompter, clock.Real)
+				if _, err := gate.Allow(ctx, "client-1"); err != nil {
+					t.Fatalf("Allow failed: %v", err)
+				}
+				if diff := cmp.Diff(prompter.prompts > 0, tc.wantApproval); diff != "" {
+					t.Errorf("incorrect prompting; -got +want: %s", diff)
+				}
+
+				// Controls displayed in the UI.
+				h.UI.updateSettings(ctx)
+				h.UI.updateKeys(ctx)
+				h.waitKeyConfigured(ctx, "good-key")
+				if diff := cmp.Diff(dom.Checked(h.approveNewClients), tc.wantApproval); diff != "" {
+					t.Errorf("incorrect checkbox state; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(h.approveNewClients.Get("disabled").Bool(), tc.wantLocked); diff != "" {
+					t.Errorf("incorrect checkbox disabled state; -got +want: %s", diff)
+				}
+				id := h.UI.keyByName("good-key").ID
+				var buttons []buttonKind
+				for _, kind := range []buttonKind{LoadButton, UnloadButton, RemoveButton, LocationButton} {
+					if !h.dom.GetElement(buttonID(kind, id)).IsNull() {
+						buttons = append(buttons, kind)
+					}
+				}
+				if diff := cmp.Diff(buttons, tc.wantButtons); diff != "" {
+					t.Errorf("incorrect buttons; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(h.addButton.Get("hidden").Bool(), tc.wantAddHidden); diff != "" {
+					t.Errorf("incorrect add button visibility; -got +want: %s", diff)
+				}
+
+				// Operations requested directly of the server, bypassing
+				// the UI.
+				ops := map[string]error{
+					"add": func() error {
+						_, err := h.Client.Add(ctx, "other-key", testdata.WithPassphrase.Private)
+						return err
+					}(),
+					"set local": h.Client.SetLocal(ctx, id, true),
+					"remove":    h.Client.Remove(ctx, id),
+				}
+				for op, err := range ops {
+					if diff := cmp.Diff(err != nil, tc.wantOpsRejected); diff != "" {
+						t.Errorf("incorrect %s result %v; -got +want: %s", op, err, diff)
+					}
+				}
+				if tc.wantOpsRejected {
+					configured, err := h.manager.Configured(ctx)
+					if err != nil {
+						t.Fatalf("failed to read configured keys: %v", err)
+					}
+					if len(configured) != 1 || configured[0].Name != "good-key" || configured[0].Local {
+						t.Errorf("rejected operations modified configured keys: %+v", configured)
+					}
+				}
+			})
+		})
+	}
+}
+
+// TestPolicyChange verifies that a change in policy takes effect without
+// reloading the UI.
+func TestPolicyChange(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "good-key")
+		id := h.UI.keyByName("good-key").ID
+		if h.dom.GetElement(buttonID(RemoveButton, id)).IsNull() {
+			t.Fatalf("remove button not displayed without policy")
+		}
+
+		h.managed.SetPolicy(map[string]js.Value{
+			"disableKeyRemove": js.ValueOf(true),
+		})
+		h.UI.updateKeys(ctx)
+		mustPoll(ctx, func() bool { return h.dom.GetElement(buttonID(RemoveButton, id)).IsNull() })
+		if err := h.Client.Remove(ctx, id); err == nil {
+			t.Errorf("remove permitted after policy applied")
+		}
+	})
+}
@@ -0,0 +1,117 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// operation identifies an operation started by the user, whose completion can
+// be awaited using nextCompletion.
+type operation string
+
+const (
+	opAdd    operation = "add"
+	opLoad   operation = "load"
+	opUnload operation = "unload"
+	opRemove operation = "remove"
+)
+
+// condition is a condition awaited by await.
+type condition struct {
+	done func() bool
+	sig  *signal
+}
+
+// await waits until done returns true, or the timeout elapses. Rather than
+// being polled, done is evaluated whenever the state of the UI may have
+// changed; see changed. It returns true if done returned true.
+//
+// The AsyncContext ensures this is invoked within an async context where
+// blocking is acceptable.
+func (u *UI) await(ctx jsutil.AsyncContext, timeout time.Duration, done func() bool) bool {
+	if done() {
+		return true
+	}
+	c := &condition{done: done, sig: newSignal()}
+	u.conditions = append(u.conditions, c)
+	defer func() {
+		for i, o := range u.conditions {
+			if o == c {
+				u.conditions = append(u.conditions[:i], u.conditions[i+1:]...)
+				break
+			}
+		}
+	}()
+	return c.sig.WaitTimeout(ctx, timeout)
+}
+
+// changed evaluates the conditions awaited by await. It is invoked whenever
+// the state of the UI changes in a way that callers may await: the displayed
+// keys or error are updated, a dialog is shown or closed, or an operation
+// completes.
+func (u *UI) changed() {
+	// Conditions may be removed while evaluated; iterate over a copy.
+	for _, c := range append([]*condition(nil), u.conditions...) {
+		if c.done() {
+			c.sig.Notify()
+		}
+	}
+}
+
+// finished records that an operation started by the user has completed,
+// whether or not it succeeded.
+func (u *UI) finished(op operation) {
+	u.completed[op]++
+	u.changed()
+}
+
+// nextCompletion returns a function that waits until op next completes after
+// nextCompletion is invoked, or the timeout elapses. This allows the caller to
+// start an operation (e.g., by clicking a button), and then await its
+// completion. The function returns true if op completed.
+func (u *UI) nextCompletion(op operation) func(ctx jsutil.AsyncContext, timeout time.Duration) bool {
+	n := u.completed[op]
+	return func(ctx jsutil.AsyncContext, timeout time.Duration) bool {
+		return u.await(ctx, timeout, func() bool { return u.completed[op] > n })
+	}
+}
+
+// dialog returns the dialog with the specified element ID. The same Dialog is
+// returned for each call, so that callers can await it being shown or closed
+// (see awaitDialog) regardless of which operation shows it.
+func (u *UI) dialog(id string) *dom.Dialog {
+	if d, ok := u.dialogs[id]; ok {
+		return d
+	}
+	d := dom.NewDialog(u.dom.GetElement(id))
+	u.cleanup.Add(d.OnShow(func(ctx jsutil.AsyncContext, evt dom.Event) { u.changed() }))
+	u.cleanup.Add(d.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) { u.changed() }))
+	u.dialogs[id] = d
+	return d
+}
+
+// awaitDialog waits until the dialog with the specified element ID is shown
+// (if open is true) or closed, or the timeout elapses. It returns true if the
+// dialog reached the requested state.
+func (u *UI) awaitDialog(ctx jsutil.AsyncContext, timeout time.Duration, id string, open bool) bool {
+	d := u.dialog(id)
+	return u.await(ctx, timeout, func() bool { return d.Open() == open })
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/wait"
+)
+
+func TestAwait(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		h.waitLoaded(ctx)
+
+		// await gives up if the condition does not become true.
+		done := false
+		if h.UI.await(ctx, 100*time.Millisecond, func() bool { return done }) {
+			t.Errorf("await succeeded before condition was true")
+		}
+
+		jsutil.SetTimeout(10*time.Millisecond, func() {
+			done = true
+			h.UI.changed()
+		})
+		if !h.UI.await(ctx, wait.Default.Timeout, func() bool { return done }) {
+			t.Errorf("await failed after condition became true")
+		}
+		if len(h.UI.conditions) != 0 {
+			t.Errorf("conditions not released: %d remain", len(h.UI.conditions))
+		}
+	})
+}
+
+func TestNextCompletion(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		h.waitLoaded(ctx)
+
+		// Completions before the call are not reported.
+		h.UI.finished(opAdd)
+		added := h.UI.nextCompletion(opAdd)
+		if added(ctx, 100*time.Millisecond) {
+			t.Errorf("earlier completion reported")
+		}
+
+		// Completions of other operations are not reported.
+		h.UI.finished(opLoad)
+		if added(ctx, 100*time.Millisecond) {
+			t.Errorf("completion of another operation reported")
+		}
+
+		h.UI.finished(opAdd)
+		if !added(ctx, wait.Default.Timeout) {
+			t.Errorf("completion not reported")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+const (
+	// refreshInterval is the minimum time between refreshes of the
+	// displayed keys that are requested via scheduleUpdate.
+	refreshInterval = 500 * time.Millisecond
+)
+
+// refresher coalesces requests to refresh the UI, such that at most one
+// refresh starts per interval.
+//
+// The first request refreshes immediately. Requests that arrive while that
+// refresh is running, or within the interval after it, are coalesced into a
+// single refresh that runs once the interval has elapsed. The last request is
+// therefore always followed by a refresh, so the final state is displayed.
+type refresher struct {
+	refresh  func(ctx jsutil.AsyncContext)
+	interval time.Duration
+	clock    clock.Clock
+
+	mu sync.Mutex
+	// active indicates that a refresh, or the interval following it, is
+	// in progress.
+	active bool
+	// pending indicates that a refresh was requested while active.
+	pending bool
+}
+
+// newRefresher returns a refresher that invokes refresh at most once per
+// interval, as measured by clk.
+func newRefresher(refresh func(ctx jsutil.AsyncContext), interval time.Duration, clk clock.Clock) *refresher {
+	return &refresher{
+		refresh:  refresh,
+		interval: interval,
+		clock:    clk,
+	}
+}
+
+// Request requests a refresh. If no refresh is in progress, Request performs
+// it, along with any coalesced refreshes requested in the meantime, before
+// returning. Otherwise, it returns immediately.
+func (r *refresher) Request(ctx jsutil.AsyncContext) {
+	r.mu.Lock()
+	if r.active {
+		r.pending = true
+		r.mu.Unlock()
+		return
+	}
+	r.active = true
+	r.mu.Unlock()
+
+	for {
+		r.refresh(ctx)
+		<-r.clock.After(r.interval)
+
+		r.mu.Lock()
+		if !r.pending {
+			r.active = false
+			r.mu.Unlock()
+			return
+		}
+		r.pending = false
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,80 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strings"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/relayport"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// updateRelay displays the relay through which the agent is served to a
+// remote development environment from the specified settings. The token is
+// never displayed (nor sent to the page); the placeholder only indicates
+// whether one is configured.
+func (u *UI) updateRelay(ctx jsutil.AsyncContext, s *settings.Settings, managedURL, managedToken bool) {
+	dom.SetValue(u.relayURL, s.RelayURL)
+	u.relayURL.Set("disabled", managedURL)
+
+	set, err := u.settings.RelayTokenSet(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read relay token: %v", err)
+	}
+	placeholder := ""
+	if set {
+		placeholder = i18n.Message("relayTokenPlaceholder")
+	}
+	u.relayToken.Set("placeholder", placeholder)
+	u.relayToken.Set("disabled", managedToken)
+}
+
+// changeRelayURL stores the relay's URL when the user changes it. An empty
+// URL disables the relay, and clears its token.
+func (u *UI) changeRelayURL(ctx jsutil.AsyncContext, _ dom.Event) {
+	var relayURL string
+	if text := strings.TrimSpace(dom.Value(u.relayURL)); text != "" {
+		var err error
+		if relayURL, err = relayport.ParseURL(text); err != nil {
+			u.setError(err)
+			return
+		}
+	}
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.RelayURL = relayURL
+	})
+}
+
+// changeRelayToken stores the token that authenticates the extension to the
+// relay when the user enters one. The input is then cleared, such that the
+// token does not remain on the page.
+func (u *UI) changeRelayToken(ctx jsutil.AsyncContext, _ dom.Event) {
+	token := strings.TrimSpace(dom.Value(u.relayToken))
+	if token == "" {
+		return
+	}
+	if err := u.settings.SetRelayToken(ctx, token); err != nil {
+		u.setError(i18n.Wrap(err, "failedUpdateSettings"))
+		return
+	}
+	dom.SetValue(u.relayToken, "")
+	u.setError(nil)
+	u.updateSettings(ctx)
+}
@@ -0,0 +1,135 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+const (
+	// snapshotKey is the key under which the snapshot is stored.
+	snapshotKey = "optionsSnapshot"
+)
+
+// snapshot is the most recent set of keys displayed in the UI. It is cached
+// such that the keys can be displayed even when the background worker cannot
+// be reached.
+//
+// Only key metadata is cached; private keys are never included.
+type snapshot struct {
+	// Updated is the time at which the snapshot was taken, in seconds
+	// since the Unix epoch.
+	Updated int64        `js:"updated"`
+	Keys    []*cachedKey `js:"keys"`
+}
+
+// cachedKey is the cached metadata for a single displayed key.
+type cachedKey struct {
+	ID            string                `js:"id"`
+	Name          string                `js:"name"`
+	Loaded        bool                  `js:"loaded"`
+	Local         bool                  `js:"local"`
+	Type          string                `js:"type"`
+	Blob          string                `js:"blob"`
+	AuthorizedKey string                `js:"authorizedKey"`
+	Fingerprint   string                `js:"fingerprint"`
+	Comment       string                `js:"comment"`
+	Certificate   *keys.CertificateInfo `js:"certificate"`
+	AutoLoad      bool                  `js:"autoLoad"`
+}
+
+// newSnapshot returns a snapshot of the supplied keys.
+func newSnapshot(disp []*displayedKey, now time.Time) *snapshot {
+	s := &snapshot{
+		Updated: now.Unix(),
+		Keys:    []*cachedKey{},
+	}
+	for _, k := range disp {
+		s.Keys = append(s.Keys, &cachedKey{
+			ID:            string(k.ID),
+			Name:          k.Name,
+			Loaded:        k.Loaded,
+			Local:         k.Local,
+			Type:          k.Type,
+			Blob:          k.Blob,
+			AuthorizedKey: k.AuthorizedKey,
+			Fingerprint:   k.Fingerprint,
+			Comment:       k.Comment,
+			Certificate:   k.Certificate,
+			AutoLoad:      k.AutoLoad,
+		})
+	}
+	return s
+}
+
+// DisplayedKeys returns the keys in the snapshot.
+func (s *snapshot) DisplayedKeys() []*displayedKey {
+	var result []*displayedKey
+	for _, k := range s.Keys {
+		result = append(result, &displayedKey{
+			ID:            keys.ID(k.ID),
+			Name:          k.Name,
+			Loaded:        k.Loaded,
+			Local:         k.Local,
+			Type:          k.Type,
+			Blob:          k.Blob,
+			AuthorizedKey: k.AuthorizedKey,
+			Fingerprint:   k.Fingerprint,
+			Comment:       k.Comment,
+			Certificate:   k.Certificate,
+			AutoLoad:      k.AutoLoad,
+		})
+	}
+	return result
+}
+
+// writeSnapshot stores the snapshot in the supplied storage area.
+func writeSnapshot(ctx jsutil.AsyncContext, area storage.Area, s *snapshot) error {
+	data := map[string]js.Value{
+		snapshotKey: vert.ValueOf(s).JSValue(),
+	}
+	if err := area.Set(ctx, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// readSnapshot reads the snapshot from the supplied storage area. nil is
+// returned if no snapshot has been stored.
+func readSnapshot(ctx jsutil.AsyncContext, area storage.Area) (*snapshot, error) {
+	data, err := area.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	val, ok := data[snapshotKey]
+	if !ok || val.Type() != js.TypeObject {
+		return nil, nil
+	}
+
+	var s snapshot
+	if err := vert.ValueOf(val).AssignTo(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,81 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strconv"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// statusRows returns the label and value of each row describing the status of
+// the background worker.
+func statusRows(s *keys.Status) [][2]string {
+	lastError := s.LastError
+	if lastError == "" {
+		lastError = i18n.Message("statusNoError")
+	}
+	rows := [][2]string{
+		{i18n.Message("statusUptime"), (time.Duration(s.Uptime) * time.Second).String()},
+		{i18n.Message("statusConnections"), strconv.Itoa(s.Connections)},
+		{i18n.Message("statusLoadedKeys"), strconv.Itoa(s.LoadedKeys)},
+		{i18n.Message("statusLastError"), lastError},
+	}
+	for _, ss := range s.Storage {
+		health := i18n.Message("statusOK")
+		if ss.Err != "" {
+			health = ss.Err
+		}
+		rows = append(rows, [2]string{i18n.Message("statusStorage", ss.Area), health})
+	}
+	return rows
+}
+
+// updateStatus reads the status of the background worker, and displays it.
+func (u *UI) updateStatus(ctx jsutil.AsyncContext) {
+	status, err := u.mgr.Status(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read agent status: %v", err)
+		return
+	}
+	u.setStatus(status)
+}
+
+// refreshStatus refreshes the displayed status of the background worker.
+func (u *UI) refreshStatus(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.updateStatus(ctx)
+}
+
+// setStatus refreshes the UI to reflect the status of the background worker
+// that should be displayed.
+func (u *UI) setStatus(status *keys.Status) {
+	dom.RemoveChildren(u.statusData)
+	for _, r := range statusRows(status) {
+		dom.AppendChild(u.statusData, u.dom.NewElement("tr"), func(row js.Value) {
+			for _, text := range r {
+				dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+					dom.AppendChild(cell, u.dom.NewText(text), nil)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strconv"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// tab is a tab of the options page, and the panel displayed when it is
+// selected.
+type tab struct {
+	button js.Value
+	panel  js.Value
+}
+
+// selectTab displays the panel of the tab with the specified index, and hides
+// the others. Only the selected tab is in the tab order; the arrow keys move
+// between tabs.
+func (u *UI) selectTab(selected int) {
+	for i, t := range u.tabs {
+		active := i == selected
+		dom.SetAttribute(t.button, "aria-selected", strconv.FormatBool(active))
+		tabIndex := "-1"
+		if active {
+			tabIndex = "0"
+		}
+		dom.SetAttribute(t.button, "tabindex", tabIndex)
+		t.panel.Set("hidden", !active)
+	}
+	u.selectedTab = selected
+}
+
+// onTab returns a callback that selects the tab with the specified index when
+// it is clicked.
+func (u *UI) onTab(i int) func(ctx jsutil.AsyncContext, evt dom.Event) {
+	return func(ctx jsutil.AsyncContext, evt dom.Event) {
+		u.selectTab(i)
+	}
+}
+
+// tabsKeyDown moves between tabs using the arrow, Home and End keys.
+func (u *UI) tabsKeyDown(evt dom.Event) {
+	next := u.selectedTab
+	switch evt.Get("key").String() {
+	case "ArrowRight":
+		next = (next + 1) % len(u.tabs)
+	case "ArrowLeft":
+		next = (next + len(u.tabs) - 1) % len(u.tabs)
+	case "Home":
+		next = 0
+	case "End":
+		next = len(u.tabs) - 1
+	default:
+		return
+	}
+	evt.Call("preventDefault")
+	u.selectTab(next)
+	dom.Focus(u.tabs[next].button)
+}
+
+// updateStoreKeysLocally displays whether new keys are stored only on the
+// local device by default.
+func (u *UI) updateStoreKeysLocally(s *settings.Settings, managed bool) {
+	u.storeLocally = s.StoreKeysLocally
+	dom.SetChecked(u.storeKeysLocally, s.StoreKeysLocally)
+	u.storeKeysLocally.Set("disabled", managed)
+}
+
+// changeStoreKeysLocally stores the setting when the user changes it.
+func (u *UI) changeStoreKeysLocally(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.StoreKeysLocally = dom.Checked(u.storeKeysLocally)
+	})
+}
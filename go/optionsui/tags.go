@@ -0,0 +1,164 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// splitTags splits comma-separated tags entered by the user. The manager
+// trims and deduplicates them.
+func splitTags(s string) []string {
+	return strings.Split(s, ",")
+}
+
+// matchesTag indicates if the key is displayed when the specified tag is
+// selected. An empty tag matches all keys.
+func (d *displayedKey) matchesTag(tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for _, t := range d.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendTags appends the tags attached to the key.
+func (u *UI) appendTags(parent js.Value, k *displayedKey) {
+	if len(k.Tags) == 0 {
+		return
+	}
+	dom.AppendChild(parent, u.dom.NewElement("div"), func(div js.Value) {
+		dom.AddClass(div, "keyTags")
+		for _, t := range k.Tags {
+			dom.AppendChild(div, u.dom.NewElement("span"), func(span js.Value) {
+				dom.AddClass(span, "tag")
+				dom.AppendChild(span, u.dom.NewText(t), nil)
+			})
+		}
+	})
+}
+
+// appendTagsControl appends an input element to edit the tags attached to the
+// key.
+func (u *UI) appendTagsControl(parent js.Value, k *displayedKey) {
+	dom.AppendChild(parent, u.dom.NewElement("input"), func(input js.Value) {
+		input.Set("type", "text")
+		input.Set("id", buttonID(TagsInput, k.ID))
+		dom.AddClass(input, "tags")
+		input.Set("title", i18n.Message("keyTagsTitle"))
+		input.Set("placeholder", i18n.Message("keyTagsPlaceholder"))
+		dom.SetValue(input, strings.Join(k.Tags, ", "))
+		k.cleanup.Add(dom.OnChange(input, func(ctx jsutil.AsyncContext, evt dom.Event) {
+			u.setTags(ctx, k.ID, splitTags(dom.Value(input)))
+		}))
+	})
+}
+
+// setTags replaces the tags attached to the specified key.
+func (u *UI) setTags(ctx jsutil.AsyncContext, id keys.ID, tags []string) {
+	if err := u.mgr.SetTags(ctx, id, tags); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.invalidateRow(id)
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// tagLabel returns a human-readable description of a tag selected to filter
+// keys.
+func tagLabel(tag string) string {
+	if tag == "" {
+		return i18n.Message("allTags")
+	}
+	return i18n.Message("tagLabel", tag)
+}
+
+// updateTags refreshes the tags by which keys may be filtered. If the
+// selected tag is no longer attached to any key, all keys are displayed.
+func (u *UI) updateTags(configured []*keys.ConfiguredKey) {
+	tags := keys.Tags(configured)
+
+	found := false
+	dom.RemoveChildren(u.tagFilter)
+	u.appendOption(u.tagFilter, "", tagLabel(""))
+	for _, t := range tags {
+		u.appendOption(u.tagFilter, t, tagLabel(t))
+		if strings.EqualFold(t, u.tag) {
+			u.tag = t
+			found = true
+		}
+	}
+	if !found {
+		u.tag = ""
+	}
+	dom.SetValue(u.tagFilter, u.tag)
+	u.tagFilter.Set("hidden", len(tags) == 0)
+	u.updateTagButtons()
+}
+
+// updateTagButtons displays the buttons to load and unload keys with the
+// selected tag, if a tag is selected.
+func (u *UI) updateTagButtons() {
+	u.loadTagged.Set("hidden", u.viewer || u.tag == "")
+	u.unloadTagged.Set("hidden", u.viewer || u.tag == "")
+}
+
+// changeTagFilter displays only the keys with the tag selected by the user.
+func (u *UI) changeTagFilter(_ jsutil.AsyncContext, _ dom.Event) {
+	u.tag = dom.Value(u.tagFilter)
+	u.updateTagButtons()
+	u.arrangeKeys(u.keys)
+}
+
+// loadTaggedKeys loads the keys with the selected tag that are not already loaded.
+// Keys are loaded one at a time, prompting for passphrases as required.
+func (u *UI) loadTaggedKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	tag := u.tag
+	var ids []keys.ID
+	for _, k := range u.keys {
+		if k.ID != keys.InvalidID && !k.Loaded && k.matchesTag(tag) {
+			ids = append(ids, k.ID)
+		}
+	}
+	for _, id := range ids {
+		u.load(ctx, id)
+	}
+}
+
+// unloadTaggedKeys unloads the keys with the selected tag.
+func (u *UI) unloadTaggedKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	tag := u.tag
+	if _, err := u.mgr.UnloadTagged(ctx, tag); err != nil {
+		u.setError(i18n.Wrap(err, "failedUnloadTagged", tag))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
@@ -0,0 +1,42 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/settings"
+)
+
+// updateTheme displays the color theme from the specified settings, and
+// applies it to the page.
+func (u *UI) updateTheme(s *settings.Settings, managed bool) {
+	theme := s.Theme
+	if theme == "" {
+		theme = settings.ThemeSystem
+	}
+	dom.SetValue(u.theme, theme)
+	u.theme.Set("disabled", managed)
+	u.dom.SetTheme(dom.Theme(theme))
+}
+
+// changeTheme stores the color theme when the user changes it.
+func (u *UI) changeTheme(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.Theme = dom.Value(u.theme)
+	})
+}
@@ -21,213 +21,761 @@ package optionsui
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall/js"
 	"time"
 
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/debugreport"
 	"github.com/google/chrome-ssh-agent/go/dom"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
 	"github.com/google/chrome-ssh-agent/go/keys"
 	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/google/chrome-ssh-agent/go/vault"
+	"github.com/google/chrome-ssh-agent/go/wait"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // UI implements the behavior underlying the user interface for the extension's
 // options.
 type UI struct {
-	mgr         keys.Manager
-	dom         *dom.Doc
-	addButton   js.Value
-	loadingText js.Value
-	errorText   js.Value
-	keysData    js.Value
-	keys        []*displayedKey
-	cleanup     *jsutil.CleanupFuncs
+	mgr               keys.Manager
+	settings          *settings.Client
+	clients           *clients.Store
+	vault             *vault.Vault
+	cache             storage.Area
+	clock             clock.Clock
+	dom               *dom.Doc
+	addButton         js.Value
+	generateButton    js.Value
+	generatedPane     js.Value
+	generatedKey      js.Value
+	addResult         js.Value
+	exportButton      js.Value
+	exportPrivate     js.Value
+	exportReauth      js.Value
+	importButton      js.Value
+	importResult      js.Value
+	approveNewClients js.Value
+	repeatedSign      js.Value
+	idleTimeout       js.Value
+	connIdleTimeout   js.Value
+	allowedExtensions js.Value
+	vaultStatus       js.Value
+	encryptKeys       js.Value
+	loadingText       js.Value
+	errorText         js.Value
+	viewerText        js.Value
+	syncPane          js.Value
+	lockedPane        js.Value
+	copyDebugButton   js.Value
+	debugInfo         js.Value
+	externalKeys      js.Value
+	compareResult     js.Value
+	fingerprints      js.Value
+	verifyResult      js.Value
+	diagnosticsResult js.Value
+	keysData          js.Value
+	keysFilter        js.Value
+	activeKeyring     js.Value
+	tagFilter         js.Value
+	loadTagged        js.Value
+	unloadTagged      js.Value
+	keyringNames      js.Value
+	noMatchingKeys    js.Value
+	attentionPane     js.Value
+	attentionList     js.Value
+	clientsData       js.Value
+	noClients         js.Value
+	connectionsData   js.Value
+	noConnections     js.Value
+	auditData         js.Value
+	noAudit           js.Value
+	clearAuditButton  js.Value
+	storageSummary    js.Value
+	storageData       js.Value
+	statusData        js.Value
+	statusRefresh     js.Value
+	logData           js.Value
+	noLogs            js.Value
+	verboseLogging    js.Value
+	nativeHost        js.Value
+	captureAddedKeys  js.Value
+	relayURL          js.Value
+	relayToken        js.Value
+	theme             js.Value
+	storeKeysLocally  js.Value
+	onboardingPane    js.Value
+	onboardingAdd     js.Value
+	onboardingGen     js.Value
+	onboardingCopy    js.Value
+	onboardingResult  js.Value
+	keys              []*displayedKey
+	// sortHeaders are the headers of the keys table that sort by their
+	// column when clicked.
+	sortHeaders []sortHeader
+	// sortColumn is the column by which the keys table is sorted.
+	sortColumn sortColumn
+	// sortDescending indicates that the keys table is sorted in
+	// descending order.
+	sortDescending bool
+	// activeRow identifies the key whose row is in the tab order of the
+	// keys table; see displayedKey.rowKey.
+	activeRow string
+	// keyring is the active keyring; only keys in it are displayed.
+	keyring string
+	// tag is the tag selected to filter keys, or empty if keys are not
+	// filtered by tag.
+	tag string
+	// configured are the most recently read configured keys, which may
+	// be granted to clients.
+	configured []*keys.ConfiguredKey
+	// tabs are the tabs of the options page.
+	tabs []tab
+	// selectedTab is the index of the displayed tab.
+	selectedTab int
+	// storeLocally indicates whether new keys are stored only on the local
+	// device by default.
+	storeLocally bool
+	// usage is the most recently read storage usage, or nil if it has
+	// not been read.
+	usage *keys.StorageUsage
+	// refresher coalesces refreshes requested via scheduleUpdate.
+	refresher *refresher
+	// toaster displays notifications, such as those offering to undo
+	// removing or unloading a key.
+	toaster *dom.Toaster
+	// malformedCleanup releases resources for the displayed malformed
+	// keys.
+	malformedCleanup *jsutil.CleanupFuncs
+	// clientsCleanup releases resources for the displayed clients.
+	clientsCleanup *jsutil.CleanupFuncs
+	// capabilities indicates the operations the user may perform. The
+	// server enforces these; the UI merely hides unavailable controls.
+	capabilities *keys.Capabilities
+	// syncErr indicates why synced storage is unavailable, or nil if it
+	// is available. While unavailable, keys are stored only locally.
+	syncErr error
+	// viewer indicates that the background worker cannot be reached, and
+	// keys are displayed read-only from the cached snapshot.
+	viewer bool
+	// fresh indicates that keys have been read from the manager at least
+	// once. Until then, keys may be displayed from the cached snapshot.
+	fresh bool
+	// warm indicates that the displayed keys are from the cached snapshot,
+	// pending a refresh from the manager.
+	warm bool
+	// dialogs are the dialogs shown by the UI, keyed by element ID; see
+	// dialog.
+	dialogs map[string]*dom.Dialog
+	// conditions are the conditions awaited by callers of await.
+	conditions []*condition
+	// completed counts the completions of each operation started by the
+	// user; see nextCompletion.
+	completed map[operation]int
+	cleanup   *jsutil.CleanupFuncs
 }
 
 // signal is a primitive that allows one routine to block until notified.
 //
-// It is a simple wrapper around WaitGroup that ensures blocking is invoked
+// It is a simple wrapper around a channel that ensures blocking is invoked
 // within an AsyncContext.
 type signal struct {
-	wg *sync.WaitGroup
+	once sync.Once
+	c    chan struct{}
 }
 
 // newSignal returns a new signal in the unnotified state.
 func newSignal() *signal {
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	return &signal{wg: wg}
+	return &signal{c: make(chan struct{})}
 }
 
-// Notify triggers any waiters to complete. Subsequent waits do not block.
+// Notify triggers any waiters to complete. Subsequent waits do not block, and
+// subsequent notifications have no effect.
 func (s *signal) Notify() {
-	s.wg.Done()
+	s.once.Do(func() { close(s.c) })
 }
 
 // Wait waits for the signal to be notified before returning. The AsyncContext
 // ensures this is invoked within an async context where blocking is acceptable.
 func (s *signal) Wait(_ jsutil.AsyncContext) {
-	s.wg.Wait()
+	<-s.c
 }
 
-// New returns a new UI instance that manages keys using the supplied manager.
-// domObj is the DOM instance corresponding to the document in which the Options
-// UI is displayed.
-func New(mgr keys.Manager, domObj *dom.Doc) *UI {
+// WaitTimeout is similar to Wait, but gives up once the timeout elapses. It
+// returns true if the signal was notified.
+func (s *signal) WaitTimeout(_ jsutil.AsyncContext, timeout time.Duration) bool {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-s.c:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// New returns a new UI instance that manages keys using the supplied manager,
+// settings using the supplied settings client, and the records of clients that
+// have connected using the supplied clients store. Changes to keys made
+// elsewhere (e.g., in another window) are announced via the supplied listener,
+// and the displayed keys refreshed. Passphrases the user chooses
+// to save are kept in the supplied vault. A snapshot of the displayed keys is
+// cached in the supplied storage area, and displayed read-only if the manager
+// is unavailable. The same area holds the diagnostics recorded by the
+// background worker, which are included in debug reports. clk supplies the
+// current time. domObj is the DOM instance corresponding to the document in
+// which the Options UI is displayed; its text is localized first.
+func New(mgr keys.Manager, lst message.Listener, sts *settings.Client, cls *clients.Store, vlt *vault.Vault, cache storage.Area, clk clock.Clock, domObj *dom.Doc) *UI {
+	domObj.Localize(i18n.Message)
+
 	result := &UI{
-		mgr:         mgr,
-		dom:         domObj,
-		addButton:   domObj.GetElement("add"),
-		loadingText: domObj.GetElement("loadingMessage"),
-		errorText:   domObj.GetElement("errorMessage"),
-		keysData:    domObj.GetElement("keysData"),
-		cleanup:     &jsutil.CleanupFuncs{},
+		mgr:               mgr,
+		settings:          sts,
+		clients:           cls,
+		vault:             vlt,
+		cache:             cache,
+		clock:             clk,
+		dom:               domObj,
+		addButton:         domObj.GetElement("add"),
+		generateButton:    domObj.GetElement("generate"),
+		generatedPane:     domObj.GetElement("generatedPane"),
+		generatedKey:      domObj.GetElement("generatedPublicKey"),
+		addResult:         domObj.GetElement("addResult"),
+		exportButton:      domObj.GetElement("exportKeys"),
+		exportPrivate:     domObj.GetElement("exportPrivate"),
+		exportReauth:      domObj.GetElement("exportReauth"),
+		importButton:      domObj.GetElement("importBundle"),
+		importResult:      domObj.GetElement("importResult"),
+		approveNewClients: domObj.GetElement("approveNewClients"),
+		repeatedSign:      domObj.GetElement("repeatedSignProtection"),
+		idleTimeout:       domObj.GetElement("idleTimeout"),
+		connIdleTimeout:   domObj.GetElement("connectionIdleTimeout"),
+		allowedExtensions: domObj.GetElement("allowedExtensions"),
+		vaultStatus:       domObj.GetElement("vaultStatus"),
+		encryptKeys:       domObj.GetElement("encryptKeys"),
+		loadingText:       domObj.GetElement("loadingMessage"),
+		errorText:         domObj.GetElement("errorMessage"),
+		viewerText:        domObj.GetElement("viewerMessage"),
+		syncPane:          domObj.GetElement("syncUnavailable"),
+		lockedPane:        domObj.GetElement("agentLocked"),
+		copyDebugButton:   domObj.GetElement("copyDebugInfo"),
+		debugInfo:         domObj.GetElement("debugInfo"),
+		externalKeys:      domObj.GetElement("externalKeys"),
+		compareResult:     domObj.GetElement("compareResult"),
+		fingerprints:      domObj.GetElement("fingerprints"),
+		verifyResult:      domObj.GetElement("verifyResult"),
+		diagnosticsResult: domObj.GetElement("diagnosticsResult"),
+		keysData:          domObj.GetElement("keysData"),
+		keysFilter:        domObj.GetElement("keysFilter"),
+		activeKeyring:     domObj.GetElement("activeKeyring"),
+		tagFilter:         domObj.GetElement("tagFilter"),
+		loadTagged:        domObj.GetElement("loadTagged"),
+		unloadTagged:      domObj.GetElement("unloadTagged"),
+		keyringNames:      domObj.GetElement("keyringNames"),
+		noMatchingKeys:    domObj.GetElement("noMatchingKeys"),
+		attentionPane:     domObj.GetElement("attentionPane"),
+		attentionList:     domObj.GetElement("attentionList"),
+		clientsData:       domObj.GetElement("clientsData"),
+		noClients:         domObj.GetElement("noClients"),
+		connectionsData:   domObj.GetElement("connectionsData"),
+		noConnections:     domObj.GetElement("noConnections"),
+		auditData:         domObj.GetElement("auditData"),
+		noAudit:           domObj.GetElement("noAudit"),
+		clearAuditButton:  domObj.GetElement("clearAudit"),
+		storageSummary:    domObj.GetElement("storageSummary"),
+		storageData:       domObj.GetElement("storageData"),
+		statusData:        domObj.GetElement("statusData"),
+		statusRefresh:     domObj.GetElement("refreshStatus"),
+		logData:           domObj.GetElement("logData"),
+		noLogs:            domObj.GetElement("noLogs"),
+		verboseLogging:    domObj.GetElement("verboseLogging"),
+		nativeHost:        domObj.GetElement("nativeHost"),
+		captureAddedKeys:  domObj.GetElement("captureAddedKeys"),
+		relayURL:          domObj.GetElement("relayURL"),
+		relayToken:        domObj.GetElement("relayToken"),
+		theme:             domObj.GetElement("theme"),
+		storeKeysLocally:  domObj.GetElement("storeKeysLocally"),
+		onboardingPane:    domObj.GetElement("onboardingPane"),
+		onboardingAdd:     domObj.GetElement("onboardingAdd"),
+		onboardingGen:     domObj.GetElement("onboardingGenerate"),
+		onboardingCopy:    domObj.GetElement("onboardingCopy"),
+		onboardingResult:  domObj.GetElement("onboardingResult"),
+		malformedCleanup:  &jsutil.CleanupFuncs{},
+		clientsCleanup:    &jsutil.CleanupFuncs{},
+		capabilities:      keys.AllCapabilities(),
+		dialogs:           map[string]*dom.Dialog{},
+		completed:         map[operation]int{},
+		cleanup:           &jsutil.CleanupFuncs{},
 	}
+	result.sortHeaders = []sortHeader{
+		{column: sortByName, cell: domObj.GetElement("sortName")},
+		{column: sortByType, cell: domObj.GetElement("sortType")},
+		{column: sortByLastUsed, cell: domObj.GetElement("sortLastUsed")},
+		{column: sortByFingerprint, cell: domObj.GetElement("sortFingerprint")},
+	}
+
+	result.tabs = []tab{
+		{button: domObj.GetElement("keysTab"), panel: domObj.GetElement("keysPanel")},
+		{button: domObj.GetElement("advancedTab"), panel: domObj.GetElement("advancedPanel")},
+	}
+
+	result.refresher = newRefresher(result.updateKeys, refreshInterval, clk)
+	result.toaster = dom.NewToaster(domObj, domObj.GetElement("toasts"), clk)
 
 	// Add event handlers.
 	cf := result.cleanup
-	// Populate keys on initial display
+	// Populate keys and settings on initial display. Cached keys are
+	// displayed immediately, and replaced once the manager responds.
+	cf.Add(result.dom.OnDOMContentLoaded(result.showCached))
 	cf.Add(result.dom.OnDOMContentLoaded(result.updateKeys))
+	cf.Add(result.dom.OnDOMContentLoaded(result.updateSettings))
+	cf.Add(result.dom.OnDOMContentLoaded(result.updateVault))
+	cf.Add(result.dom.OnDOMContentLoaded(result.startOnboarding))
+	// Refresh keys when they are changed elsewhere.
+	cf.Add(keys.WatchChanges(lst, result.scheduleUpdate))
+	// Refresh settings when they are changed elsewhere.
+	cf.Add(settings.WatchChanges(lst, result.updateSettings))
+	// Filter and sort keys
+	cf.Add(dom.OnInput(result.keysFilter, result.filterKeys))
+	cf.Add(dom.OnChange(result.activeKeyring, result.changeActiveKeyring))
+	cf.Add(dom.OnChange(result.tagFilter, result.changeTagFilter))
+	cf.Add(dom.OnClick(result.loadTagged, result.loadTaggedKeys))
+	cf.Add(dom.OnClick(result.unloadTagged, result.unloadTaggedKeys))
+	for _, h := range result.sortHeaders {
+		h := h
+		cf.Add(dom.OnClick(h.cell, func(ctx jsutil.AsyncContext, _ dom.Event) {
+			result.sortBy(h.column)
+		}))
+		cf.Add(dom.OnKeyDown(h.cell, func(evt dom.Event) {
+			if key := evt.Get("key").String(); key == "Enter" || key == " " {
+				evt.Call("preventDefault")
+				result.sortBy(h.column)
+			}
+		}))
+	}
+	// Switch tabs on click, or with the keyboard
+	for i, t := range result.tabs {
+		cf.Add(dom.OnClick(t.button, result.onTab(i)))
+	}
+	cf.Add(dom.OnKeyDown(domObj.GetElement("tabs"), result.tabsKeyDown))
+	// Navigate keys with the keyboard
+	cf.Add(dom.OnKeyDown(result.keysData, result.keysKeyDown))
+	cf.Add(dom.OnFocusIn(result.keysData, result.keysFocused))
 	// Configure new key on click
 	cf.Add(dom.OnClick(result.addButton, result.add))
+	// Generate new key on click
+	cf.Add(dom.OnClick(result.generateButton, result.generate))
+	// Guide the user through setup on first run
+	cf.Add(dom.OnClick(result.onboardingAdd, result.add))
+	cf.Add(dom.OnClick(result.onboardingGen, result.generate))
+	cf.Add(dom.OnClick(result.onboardingCopy, result.copyOnboardingKey))
+	cf.Add(dom.OnClick(domObj.GetElement("onboardingTest"), result.testAgent))
+	cf.Add(dom.OnClick(domObj.GetElement("onboardingDone"), result.finishOnboarding))
+	// Update settings on change
+	cf.Add(dom.OnChange(result.approveNewClients, result.changeApproveNewClients))
+	cf.Add(dom.OnChange(result.repeatedSign, result.changeRepeatedSign))
+	cf.Add(dom.OnChange(result.idleTimeout, result.changeIdleTimeout))
+	cf.Add(dom.OnChange(result.connIdleTimeout, result.changeConnectionIdleTimeout))
+	cf.Add(dom.OnChange(result.exportReauth, result.changeExportReauth))
+	cf.Add(dom.OnChange(result.allowedExtensions, result.changeAllowedExtensions))
+	cf.Add(dom.OnClick(result.clearAuditButton, result.clearAudit))
+	cf.Add(dom.OnClick(result.statusRefresh, result.refreshStatus))
+	cf.Add(dom.OnClick(domObj.GetElement("refreshLogs"), result.refreshLogs))
+	cf.Add(dom.OnClick(domObj.GetElement("runDiagnostics"), result.runDiagnostics))
+	cf.Add(dom.OnChange(result.verboseLogging, result.changeVerboseLogging))
+	cf.Add(dom.OnChange(result.nativeHost, result.changeNativeHost))
+	cf.Add(dom.OnChange(result.captureAddedKeys, result.changeCaptureAddedKeys))
+	cf.Add(dom.OnChange(result.relayURL, result.changeRelayURL))
+	cf.Add(dom.OnChange(result.relayToken, result.changeRelayToken))
+	cf.Add(dom.OnChange(result.theme, result.changeTheme))
+	cf.Add(dom.OnChange(result.storeKeysLocally, result.changeStoreKeysLocally))
+	// Manage the passphrase cache on click
+	cf.Add(dom.OnClick(domObj.GetElement("vaultSetup"), result.setupVault))
+	cf.Add(dom.OnClick(domObj.GetElement("vaultUnlock"), func(ctx jsutil.AsyncContext, _ dom.Event) {
+		if result.unlockVault(ctx) {
+			// Encrypted keys can be read once unlocked.
+			result.updateKeys(ctx)
+		}
+	}))
+	cf.Add(dom.OnClick(domObj.GetElement("vaultLock"), result.lockVault))
+	cf.Add(dom.OnClick(domObj.GetElement("vaultChange"), result.changeVaultPassword))
+	cf.Add(dom.OnClick(domObj.GetElement("vaultRemove"), result.removeVault))
+	cf.Add(dom.OnChange(result.encryptKeys, result.changeEncryptKeys))
+	// Gather debug information on click
+	cf.Add(dom.OnClick(result.copyDebugButton, result.copyDebugInfo))
+	// Compare with another agent on click
+	cf.Add(dom.OnClick(domObj.GetElement("compareKeys"), result.compareKeys))
+	cf.Add(dom.OnClick(domObj.GetElement("listFingerprints"), result.listFingerprints))
+	// Verify setup on click
+	cf.Add(dom.OnClick(domObj.GetElement("verifySetup"), result.verifySetup))
+	// Export public keys on click
+	cf.Add(dom.OnClick(result.exportButton, func(ctx jsutil.AsyncContext, _ dom.Event) {
+		result.exportPublicKeys(ctx, keys.InvalidID)
+	}))
+	// Export configured keys on click
+	cf.Add(dom.OnClick(domObj.GetElement("exportBundle"), result.exportKeys))
+	// Import keys on click
+	cf.Add(dom.OnClick(result.importButton, result.importKeys))
+	// Check again whether synced storage is available on click
+	cf.Add(dom.OnClick(domObj.GetElement("syncRetry"), func(ctx jsutil.AsyncContext, _ dom.Event) {
+		result.updateKeys(ctx)
+	}))
 	return result
 }
 
 // Release cleans up any resources when UI is no longer used.
 func (u *UI) Release() {
 	u.setKeys(nil)
+	u.setMalformed(nil)
+	u.setClients(nil)
 	u.cleanup.Do()
 }
 
 // setError updates the UI to display the supplied error. If the supplied error
 // is nil, then any displayed error is cleared.
 func (u *UI) setError(err error) {
+	defer u.changed()
+
 	// Clear any existing error
 	dom.RemoveChildren(u.errorText)
 
 	if err != nil {
 		jsutil.LogError("UI.setError(): %v", err)
 		dom.AppendChild(u.errorText, u.dom.NewText(err.Error()), nil)
+		if advice := errorAdvice(err); advice != "" {
+			dom.AppendChild(u.errorText, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "errorAdvice", "note")
+				dom.AppendChild(div, u.dom.NewText(advice), nil)
+			})
+		}
+	}
+}
+
+// errorAdvice returns a suggested remedy for a key or storage error, based on
+// its category. An empty string is returned if there is no suggestion.
+func errorAdvice(err error) string {
+	switch keys.Category(err) {
+	case keys.ErrIncorrectPassphrase:
+		return i18n.Message("adviceIncorrectPassphrase")
+	case keys.ErrKeyNotFound:
+		return i18n.Message("adviceKeyNotFound")
+	case keys.ErrNotPermitted:
+		return i18n.Message("adviceNotPermitted")
+	case keys.ErrUnsupportedAlgorithm:
+		return i18n.Message("adviceUnsupportedAlgorithm")
+	case keys.ErrAgentLocked:
+		return i18n.Message("adviceAgentLocked")
+	case keys.ErrIncompatibleVersion:
+		return i18n.Message("adviceIncompatibleVersion")
+	}
+
+	switch storage.Category(err) {
+	case storage.ErrQuota:
+		return i18n.Message("adviceStorageQuota")
+	case storage.ErrUnavailable:
+		return i18n.Message("adviceStorageUnavailable")
+	case storage.ErrCorrupted:
+		return i18n.Message("adviceStorageCorrupted")
+	case storage.ErrTransient:
+		return i18n.Message("adviceStorageTransient")
 	}
+	return ""
 }
 
 // add configures a new key.  It displays a dialog prompting the user for a name
-// and the corresponding private key.  If the user continues, the key is
-// added to the manager.
+// and the corresponding private key, or a PKCS#12 archive containing it.  If
+// the user continues, the key is added to the manager, and stored only on the
+// local device if the user requested it.  On success, a summary of the added
+// key is displayed.
 func (u *UI) add(ctx jsutil.AsyncContext, _ dom.Event) {
-	ok, name, privateKey := u.promptAdd(ctx)
+	defer u.finished(opAdd)
+
+	ok, name, privateKey, file, password, local := u.promptAdd(ctx)
 	if !ok {
 		return
 	}
 
-	if err := u.mgr.Add(ctx, name, privateKey); err != nil {
-		u.setError(fmt.Errorf("failed to add key: %w", err))
+	dom.RemoveChildren(u.addResult)
+	if file.Truthy() {
+		// Archives are converted here, so that the key is added like
+		// any other.
+		data, err := readFile(ctx, file)
+		if err != nil {
+			u.setError(i18n.Wrap(err, "failedAddKey"))
+			return
+		}
+		if privateKey, err = keys.ConvertPKCS12(data, password, name); err != nil {
+			u.setError(i18n.Wrap(err, "failedAddKey"))
+			return
+		}
+	}
+	add := u.mgr.Add
+	if local {
+		add = u.mgr.AddLocal
+	}
+	info, err := add(ctx, name, privateKey)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedAddKey"))
 		return
 	}
 
 	u.setError(nil)
+	dom.AppendChild(u.addResult, u.dom.NewText(describeAdded(name, info)), nil)
 	u.updateKeys(ctx)
 }
 
-// promptAdd displays a dialog prompting the user for a name and private key.
-func (u *UI) promptAdd(ctx jsutil.AsyncContext) (ok bool, name, privateKey string) {
-	dialog := dom.NewDialog(u.dom.GetElement("addDialog"))
+// describeAdded returns a human-readable summary of a newly-added key.
+func describeAdded(name string, info *keys.KeyInfo) string {
+	if info == nil {
+		return i18n.Message("addedKey", name)
+	}
+	var details []string
+	if info.Bits > 0 {
+		details = append(details, i18n.Message("keyBits", strconv.Itoa(info.Bits)))
+	}
+	if info.Encrypted {
+		details = append(details, i18n.Message("keyEncrypted"))
+	}
+	desc := i18n.Message("keyTypeUnknown")
+	if info.Type != "" {
+		desc = i18n.Message("keyType", info.Type)
+	}
+	msg := i18n.Message("addedKeyDetails", desc, name)
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg + "."
+}
+
+// promptAdd displays a dialog prompting the user for a name and private key
+// (or a PKCS#12 archive and its password), and whether the key should be
+// stored only on the local device. file is null if no archive was selected.
+func (u *UI) promptAdd(ctx jsutil.AsyncContext) (ok bool, name, privateKey string, file js.Value, password string, local bool) {
+	dialog := u.dialog("addDialog")
 	form := u.dom.GetElement("addForm")
 	nameField := u.dom.GetElement("addName")
 	keyField := u.dom.GetElement("addKey")
+	fileField := u.dom.GetElement("addFile")
+	passwordField := u.dom.GetElement("addFilePassword")
+	localField := u.dom.GetElement("addLocal")
+	warning := u.dom.GetElement("addQuotaWarning")
 	cancel := u.dom.GetElement("addCancel")
 
+	// Warn if the key would not fit in storage, before the user attempts
+	// to add it.
+	checkQuota := func(ctx jsutil.AsyncContext, evt dom.Event) {
+		text := addQuotaWarning(u.usage, dom.Value(nameField), dom.Value(keyField), dom.Checked(localField))
+		dom.RemoveChildren(warning)
+		dom.AppendChild(warning, u.dom.NewText(text), nil)
+		warning.Set("hidden", text == "")
+	}
+
 	sig := newSignal()
 	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnInput(nameField, checkQuota))
+	cleanup.Add(dom.OnInput(keyField, checkQuota))
+	cleanup.Add(dom.OnChange(localField, checkQuota))
 	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
 		ok = true
 		name = dom.Value(nameField)
 		privateKey = dom.Value(keyField)
+		file = js.Null()
+		if files := fileField.Get("files"); files.Truthy() && files.Length() > 0 {
+			file = files.Index(0)
+		}
+		password = dom.Value(passwordField)
+		local = dom.Checked(localField)
 		dialog.Close()
-		sig.Notify()
 	}))
 	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
-		dialog.Close()
-		sig.Notify()
+		dialog.Cancel()
 	}))
 	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
 		dom.SetValue(nameField, "")
 		dom.SetValue(keyField, "")
+		dom.SetValue(fileField, "")
+		dom.SetValue(passwordField, "")
+		dom.SetChecked(localField, false)
+		dom.RemoveChildren(warning)
+		warning.Set("hidden", true)
 		cleanup.Do()
+		sig.Notify()
 	}))
 
+	dom.SetChecked(localField, u.storeLocally)
 	dialog.ShowModal()
 	sig.Wait(ctx)
 	return
 }
 
-// load loads the key with the specified ID.  A dialog prompts the user for a
-// passphrase if the private key is encrypted.
+// load loads the key with the specified ID.  If the private key is encrypted,
+// its saved passphrase is used if available; otherwise, a dialog prompts the
+// user for a passphrase.
 func (u *UI) load(ctx jsutil.AsyncContext, id keys.ID) {
+	defer u.finished(opLoad)
+
 	k := u.keyByID(id)
 	if k == nil {
-		u.setError(fmt.Errorf("failed to unload key ID %s: not found", id))
+		u.setError(i18n.NewError("errUnloadKeyNotFound", string(id)))
 		return
 	}
 
-	var ok bool
-	var passphrase string
-	if k.Encrypted {
-		ok, passphrase = u.promptPassphrase(ctx)
-		if !ok {
+	if !k.Encrypted {
+		if err := u.mgr.Load(ctx, id, ""); err != nil {
+			u.setError(i18n.Wrap(err, "failedLoadKey"))
 			return
 		}
+		u.setError(nil)
+		u.updateKeys(ctx)
+		return
+	}
+
+	if passphrase, ok := u.cachedPassphrase(ctx, id); ok {
+		err := u.mgr.Load(ctx, id, passphrase)
+		if err == nil {
+			u.setError(nil)
+			u.updateKeys(ctx)
+			return
+		}
+		if !errors.Is(err, keys.ErrIncorrectPassphrase) {
+			u.setError(i18n.Wrap(err, "failedLoadKey"))
+			return
+		}
+		// The saved passphrase is stale (e.g., the key's passphrase
+		// was changed); fall back to asking the user.
 	}
 
-	if err := u.mgr.Load(ctx, id, passphrase); err != nil {
-		u.setError(fmt.Errorf("failed to load key: %w", err))
+	unlocked, err := u.vault.Unlocked(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read passphrase cache: %v", err)
+	}
+
+	// Ask again if the passphrase is mistyped, rather than requiring the
+	// user to click Load again.
+	var remember bool
+	retry := ""
+	for attempt := 1; ; attempt++ {
+		var ok bool
+		var passphrase string
+		ok, passphrase, remember = u.promptPassphrase(ctx, unlocked, remember, retry)
+		if !ok {
+			return
+		}
+
+		err := u.mgr.Load(ctx, id, passphrase)
+		if errors.Is(err, keys.ErrIncorrectPassphrase) && attempt < maxPassphraseAttempts {
+			retry = i18n.Message("passphraseRetry", strconv.Itoa(attempt+1), strconv.Itoa(maxPassphraseAttempts))
+			continue
+		}
+		if err != nil {
+			u.setError(i18n.Wrap(err, "failedLoadKey"))
+			return
+		}
+		u.setError(nil)
+		if remember {
+			u.rememberPassphrase(ctx, id, passphrase)
+		}
+		u.updateKeys(ctx)
 		return
 	}
-	u.setError(nil)
-	u.updateKeys(ctx)
 }
 
-// promptPassphrase displays a dialog prompting the user for a passphrase.
-func (u *UI) promptPassphrase(ctx jsutil.AsyncContext) (ok bool, passphrase string) {
-	dialog := dom.NewDialog(u.dom.GetElement("passphraseDialog"))
+// maxPassphraseAttempts is the number of times the user is asked for a key's
+// passphrase before loading it fails.
+const maxPassphraseAttempts = 3
+
+// promptPassphrase displays a dialog prompting the user for a passphrase. If
+// canRemember is true, the user may also choose to save the passphrase in the
+// passphrase cache; remember is the initial choice. If retry is not empty, it
+// is displayed to explain why the passphrase is requested again.
+func (u *UI) promptPassphrase(ctx jsutil.AsyncContext, canRemember, remember bool, retry string) (ok bool, passphrase string, rememberChosen bool) {
+	dialog := u.dialog("passphraseDialog")
 	form := u.dom.GetElement("passphraseForm")
 	passphraseField := u.dom.GetElement("passphrase")
+	rememberField := u.dom.GetElement("passphraseRemember")
+	showField := u.dom.GetElement("passphraseShow")
+	capsLock := u.dom.GetElement("passphraseCapsLock")
+	retryText := u.dom.GetElement("passphraseRetry")
 	cancel := u.dom.GetElement("passphraseCancel")
 
+	u.dom.GetElement("passphraseRememberPane").Set("hidden", !canRemember)
+	dom.SetChecked(rememberField, canRemember && remember)
+	dom.RemoveChildren(retryText)
+	dom.AppendChild(retryText, u.dom.NewText(retry), nil)
+	retryText.Set("hidden", retry == "")
+
 	sig := newSignal()
 	var cleanup jsutil.CleanupFuncs
 	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
 		ok = true
 		passphrase = dom.Value(passphraseField)
+		rememberChosen = canRemember && dom.Checked(rememberField)
 		dialog.Close()
-		sig.Notify()
 	}))
 	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
-		dialog.Close()
-		sig.Notify()
+		dialog.Cancel()
+	}))
+	cleanup.Add(dom.OnChange(showField, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		showPassphrase(passphraseField, dom.Checked(showField))
+	}))
+	cleanup.Add(dom.OnKeyUp(passphraseField, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		capsLock.Set("hidden", !capsLockOn(evt))
 	}))
 	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
 		dom.SetValue(passphraseField, "")
+		dom.SetChecked(rememberField, false)
+		dom.SetChecked(showField, false)
+		showPassphrase(passphraseField, false)
+		capsLock.Set("hidden", true)
 		cleanup.Do()
+		sig.Notify()
 	}))
 
 	dialog.ShowModal()
+	passphraseField.Call("focus")
 	sig.Wait(ctx)
 	return
 }
 
+// showPassphrase configures whether the passphrase entered in the field is
+// displayed, so that the user can check it for typos.
+func showPassphrase(field js.Value, show bool) {
+	typ := "password"
+	if show {
+		typ = "text"
+	}
+	field.Set("type", typ)
+}
+
+// capsLockOn indicates if Caps Lock was on when the keyboard event occurred.
+func capsLockOn(evt dom.Event) bool {
+	return evt.Call("getModifierState", "CapsLock").Bool()
+}
+
 // unload unloads the specified key.
 func (u *UI) unload(ctx jsutil.AsyncContext, id keys.ID) {
+	defer u.finished(opUnload)
+
 	if err := u.mgr.Unload(ctx, id); err != nil {
-		u.setError(fmt.Errorf("failed to unload key ID %s: %w", id, err))
+		u.setError(i18n.Wrap(err, "failedUnloadKey", string(id)))
 		return
 	}
 	u.setError(nil)
+	u.offerUndoUnload(id)
 	u.updateKeys(ctx)
 }
 
@@ -236,11 +784,11 @@ func (u *UI) unload(ctx jsutil.AsyncContext, id keys.ID) {
 func (u *UI) promptRemove(ctx jsutil.AsyncContext, id keys.ID) (yes bool) {
 	k := u.keyByID(id)
 	if k == nil {
-		u.setError(fmt.Errorf("failed to remove key ID %s: not found", id))
+		u.setError(i18n.NewError("errRemoveKeyNotFound", string(id)))
 		return
 	}
 
-	dialog := dom.NewDialog(u.dom.GetElement("removeDialog"))
+	dialog := u.dialog("removeDialog")
 	form := u.dom.GetElement("removeForm")
 	name := u.dom.GetElement("removeName")
 	no := u.dom.GetElement("removeNo")
@@ -251,15 +799,14 @@ func (u *UI) promptRemove(ctx jsutil.AsyncContext, id keys.ID) (yes bool) {
 	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
 		yes = true
 		dialog.Close()
-		sig.Notify()
 	}))
 	cleanup.Add(dom.OnClick(no, func(ctx jsutil.AsyncContext, evt dom.Event) {
-		dialog.Close()
-		sig.Notify()
+		dialog.Cancel()
 	}))
 	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
 		dom.RemoveChildren(name)
 		cleanup.Do()
+		sig.Notify()
 	}))
 
 	dialog.ShowModal()
@@ -270,18 +817,475 @@ func (u *UI) promptRemove(ctx jsutil.AsyncContext, id keys.ID) (yes bool) {
 // remove removes the key with the specified ID.  A dialog prompts the user to
 // confirm that the key should be removed.
 func (u *UI) remove(ctx jsutil.AsyncContext, id keys.ID) {
+	defer u.finished(opRemove)
+
 	if yes := u.promptRemove(ctx, id); !yes {
 		return
 	}
 
 	if err := u.mgr.Remove(ctx, id); err != nil {
-		u.setError(fmt.Errorf("failed to remove key ID %s: %w", id, err))
+		u.setError(i18n.Wrap(err, "failedRemoveKey", string(id)))
+		return
+	}
+	u.forgetPassphrase(ctx, id)
+	u.setError(nil)
+	u.offerUndoRemove(id)
+	u.updateKeys(ctx)
+}
+
+// setLocal moves the specified key between synced and local-only storage.
+// Progress is reflected on the supplied button while the move is in progress.
+func (u *UI) setLocal(ctx jsutil.AsyncContext, id keys.ID, local bool, btn js.Value) {
+	btn.Set("disabled", true)
+	dom.RemoveChildren(btn)
+	dom.AppendChild(btn, u.dom.NewText(i18n.Message("moving")), nil)
+
+	if err := u.mgr.SetLocal(ctx, id, local); err != nil {
+		u.setError(i18n.Wrap(err, "failedMoveKey", string(id)))
+		u.invalidateRow(id)
+		u.updateKeys(ctx)
 		return
 	}
 	u.setError(nil)
 	u.updateKeys(ctx)
 }
 
+// setAutoLoad configures whether the specified key is loaded whenever the
+// agent starts.
+func (u *UI) setAutoLoad(ctx jsutil.AsyncContext, id keys.ID, autoLoad bool) {
+	if err := u.mgr.SetAutoLoad(ctx, id, autoLoad); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// setConfirm configures whether each signature with the specified key must be
+// confirmed.
+func (u *UI) setConfirm(ctx jsutil.AsyncContext, id keys.ID, confirm bool) {
+	if err := u.mgr.SetConfirm(ctx, id, confirm); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// setNotify configures whether the user is notified of each signature with the
+// specified key.
+func (u *UI) setNotify(ctx jsutil.AsyncContext, id keys.ID, notify bool) {
+	if err := u.mgr.SetNotify(ctx, id, notify); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// setPersist configures whether the specified key remains loaded after the
+// browser restarts.
+func (u *UI) setPersist(ctx jsutil.AsyncContext, id keys.ID, persist bool) {
+	if err := u.mgr.SetPersist(ctx, id, persist); err != nil {
+		u.setError(i18n.Wrap(err, "failedConfigureKey", string(id)))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// adoptedName returns the name for a key added by a client with the specified
+// comment, once saved as a configured key.
+func adoptedName(comment string) string {
+	if comment == "" {
+		return i18n.Message("adoptedKeyName")
+	}
+	return comment
+}
+
+// adopt saves the key added by a client, identified by its public key blob,
+// as a configured key with the specified name.
+func (u *UI) adopt(ctx jsutil.AsyncContext, blob, name string) {
+	if err := u.mgr.AdoptLoaded(ctx, blob, name); err != nil {
+		u.setError(i18n.Wrap(err, "failedSaveKey"))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// repin trusts the current material for the specified key, after it changed
+// since the key was added.
+func (u *UI) repin(ctx jsutil.AsyncContext, id keys.ID) {
+	if err := u.mgr.Repin(ctx, id); err != nil {
+		u.setError(i18n.Wrap(err, "failedTrustKey", string(id)))
+		u.updateKeys(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// compareKeys displays which configured keys are loaded in another agent,
+// based on the output of 'ssh-add -L' pasted by the user.
+func (u *UI) compareKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	dom.RemoveChildren(u.compareResult)
+
+	external, err := keys.ParseAgentList(dom.Value(u.externalKeys))
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedParseKeys"))
+		return
+	}
+	configured, err := u.mgr.Configured(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadKeys"))
+		return
+	}
+
+	for _, m := range keys.MatchExternal(configured, external) {
+		status := i18n.Message("compareNotLoaded")
+		switch {
+		case m.Fingerprint == "":
+			status = i18n.Message("compareUnknown")
+		case m.Loaded:
+			status = i18n.Message("compareLoaded")
+		}
+		dom.AppendChild(u.compareResult, u.dom.NewElement("li"), func(li js.Value) {
+			dom.AppendChild(li, u.dom.NewText(fmt.Sprintf("%s: %s", m.Name, status)), nil)
+		})
+	}
+	u.setError(nil)
+}
+
+// listFingerprints displays the keys loaded in the agent in the format output
+// by 'ssh-add -l', for comparison with another agent.
+func (u *UI) listFingerprints(ctx jsutil.AsyncContext, _ dom.Event) {
+	dom.RemoveChildren(u.fingerprints)
+
+	configured, err := u.mgr.Configured(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadKeys"))
+		return
+	}
+	loaded, err := u.mgr.Loaded(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedEnumerateKeys"))
+		return
+	}
+
+	text := keys.FormatLoaded(configured, loaded)
+	if text == "" {
+		text = i18n.Message("noIdentities")
+	}
+	dom.AppendChild(u.fingerprints, u.dom.NewText(text), nil)
+	u.setError(nil)
+}
+
+// runVerifyChecks runs the checks that verify the user's setup, returning the
+// outcome of each.
+func (u *UI) runVerifyChecks(ctx jsutil.AsyncContext) []*keys.DiagnosticResult {
+	var results []*keys.DiagnosticResult
+	for _, c := range keys.VerifyChecks(u.mgr) {
+		res := &keys.DiagnosticResult{Name: c.Name}
+		if err := c.Run(ctx); err != nil {
+			res.Err = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+const (
+	// exportFilename is the name of the file to which public keys are
+	// exported.
+	exportFilename = "ssh-agent-public-keys.json"
+	// bundleFilename is the name of the file to which configured keys
+	// are exported.
+	bundleFilename = "ssh-agent-keys.json"
+)
+
+// exportPublicKeys downloads a JSON description of the public keys of the
+// configured keys, for use in provisioning authorized_keys files. If id is
+// valid, only the specified key is exported.
+func (u *UI) exportPublicKeys(ctx jsutil.AsyncContext, id keys.ID) {
+	configured, err := u.mgr.Configured(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadKeys"))
+		return
+	}
+	loaded, err := u.mgr.Loaded(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedEnumerateKeys"))
+		return
+	}
+	if id != keys.InvalidID {
+		var selected []*keys.ConfiguredKey
+		for _, c := range configured {
+			if keys.ID(c.ID) == id {
+				selected = append(selected, c)
+			}
+		}
+		configured = selected
+	}
+
+	data, skipped, err := keys.ExportPublicKeys(configured, loaded)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedExportKeys"))
+		return
+	}
+	if len(skipped) > 0 {
+		u.setError(fmt.Errorf("%w: %s", errPublicKeyUnknown, strings.Join(skipped, ", ")))
+	} else {
+		u.setError(nil)
+	}
+	if len(skipped) == len(configured) && len(configured) > 0 {
+		return // Nothing to export.
+	}
+	u.dom.Download(exportFilename, "application/json", string(data))
+}
+
+// promptExportPrivate displays a dialog prompting the user to confirm that
+// private keys should be exported although no master password is set.
+func (u *UI) promptExportPrivate(ctx jsutil.AsyncContext) (yes bool) {
+	dialog := u.dialog("exportPrivateDialog")
+	form := u.dom.GetElement("exportPrivateForm")
+	no := u.dom.GetElement("exportPrivateNo")
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		yes = true
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(no, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
+
+// exportKeys downloads the configured keys, so they can be configured in
+// another browser. Private keys are included only if the user asked for them,
+// and either entered the master password again (unless they did so recently;
+// see Settings.ExportReauthMinutes) or, if none is set, confirmed the export.
+func (u *UI) exportKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	includePrivate := dom.Checked(u.exportPrivate)
+	if includePrivate {
+		configured, err := u.vault.Configured(ctx)
+		if err != nil {
+			u.setError(i18n.Wrap(err, "failedExportKeys"))
+			return
+		}
+		if !configured {
+			if yes := u.promptExportPrivate(ctx); !yes {
+				return
+			}
+		}
+	}
+
+	data, err := u.mgr.Export(ctx, includePrivate, "")
+	if errors.Is(err, keys.ErrReauthRequired) {
+		ok, password, _, _ := u.promptVault(ctx, i18n.Message("exportPrivateTitle"), true, false)
+		if !ok {
+			return
+		}
+		data, err = u.mgr.Export(ctx, includePrivate, password)
+	}
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedExportKeys"))
+		return
+	}
+	u.setError(nil)
+	u.dom.Download(bundleFilename, "application/json", string(data))
+}
+
+var (
+	errPublicKeyUnknown     = i18n.NewError("errPublicKeyUnknown")
+	errClipboardUnavailable = i18n.NewError("errClipboardUnavailable")
+)
+
+// verifySetup checks the user's configured keys and the state of the agent,
+// and summarizes the results. No keys are modified.
+func (u *UI) verifySetup(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.showResults(u.verifyResult, u.runVerifyChecks(ctx))
+}
+
+// showResults displays the outcome of each check in list, replacing any
+// previously displayed.
+func (u *UI) showResults(list js.Value, results []*keys.DiagnosticResult) {
+	dom.RemoveChildren(list)
+	for _, res := range results {
+		status := i18n.Message("verifyOK")
+		if res.Err != "" {
+			status = i18n.Message("verifyFailed", res.Err)
+		}
+		dom.AppendChild(list, u.dom.NewElement("li"), func(li js.Value) {
+			dom.AppendChild(li, u.dom.NewText(fmt.Sprintf("%s: %s", res.Name, status)), nil)
+		})
+	}
+}
+
+// runDiagnostics checks that the agent works, both directly and through an
+// AgentPort, and displays the outcome of each check.
+func (u *UI) runDiagnostics(ctx jsutil.AsyncContext, _ dom.Event) {
+	dom.RemoveChildren(u.diagnosticsResult)
+	results, err := u.mgr.RunDiagnostics(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedRunDiagnostics"))
+		return
+	}
+	u.setError(nil)
+	u.showResults(u.diagnosticsResult, results)
+}
+
+// copyDebugInfo gathers a debug report, displays it, and copies it to the
+// clipboard. The report is displayed so that it can be copied manually if the
+// clipboard is unavailable.
+func (u *UI) copyDebugInfo(ctx jsutil.AsyncContext, _ dom.Event) {
+	r := debugreport.Collect(ctx, debugreport.Sources{
+		Manager:     u.mgr,
+		Settings:    u.settings,
+		Diagnostics: u.cache,
+		Usage:       debugreport.ChromeStorageUsage,
+	}, u.clock.Now())
+	text := r.JSON()
+
+	dom.RemoveChildren(u.debugInfo)
+	dom.AppendChild(u.debugInfo, u.dom.NewText(text), nil)
+	u.debugInfo.Set("hidden", false)
+
+	if err := writeClipboard(ctx, text); err != nil {
+		u.setError(i18n.Wrap(err, "failedCopyDebug"))
+		return
+	}
+	u.setError(nil)
+}
+
+// copyPublicKey copies the public key to the clipboard.
+func (u *UI) copyPublicKey(ctx jsutil.AsyncContext, pub string) {
+	if err := writeClipboard(ctx, pub); err != nil {
+		u.setError(i18n.Wrap(err, "failedCopyPublicKey"))
+		return
+	}
+	u.setError(nil)
+}
+
+// writeClipboard writes the text to the system clipboard.
+func writeClipboard(ctx jsutil.AsyncContext, text string) error {
+	clipboard := js.Global().Get("navigator")
+	if !clipboard.IsUndefined() {
+		clipboard = clipboard.Get("clipboard")
+	}
+	if clipboard.IsUndefined() {
+		return errClipboardUnavailable
+	}
+	if _, err := jsutil.AsPromise(clipboard.Call("writeText", text)).Await(ctx); err != nil {
+		return i18n.Wrap(err, "failedWriteClipboard")
+	}
+	return nil
+}
+
+// updateSettings displays the current settings. Settings overridden by policy
+// are displayed, but cannot be changed.
+func (u *UI) updateSettings(ctx jsutil.AsyncContext) {
+	s, err := u.settings.Get(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadSettings"))
+		return
+	}
+	managed, err := u.settings.Managed(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read managed settings: %v", err)
+	}
+
+	dom.SetChecked(u.approveNewClients, s.ApproveNewClients)
+	u.approveNewClients.Set("disabled", managed["approveNewClients"])
+
+	repeatedSign := s.RepeatedSignProtection
+	if repeatedSign == "" {
+		repeatedSign = settings.RepeatedSignOff
+	}
+	dom.SetValue(u.repeatedSign, repeatedSign)
+	u.repeatedSign.Set("disabled", managed["repeatedSignProtection"])
+
+	u.updateIdleTimeout(s, managed["idleTimeoutMinutes"])
+	u.updateConnectionIdleTimeout(s, managed["connectionIdleTimeoutMinutes"])
+	u.updateExportReauth(s, managed["exportReauthMinutes"])
+	u.updateActiveKeyring(s, managed["activeKeyring"])
+	u.updateAllowedExtensions(s, managed["allowedExtensions"])
+
+	dom.SetChecked(u.verboseLogging, s.VerboseLogging)
+	u.verboseLogging.Set("disabled", managed["verboseLogging"])
+
+	dom.SetChecked(u.nativeHost, s.NativeHost)
+	u.nativeHost.Set("disabled", managed["nativeHost"])
+
+	dom.SetChecked(u.captureAddedKeys, s.CaptureAddedKeys)
+	u.captureAddedKeys.Set("disabled", managed["captureAddedKeys"])
+
+	u.updateRelay(ctx, s, managed["relayURL"], managed["relayToken"])
+	u.updateTheme(s, managed["theme"])
+	u.updateStoreKeysLocally(s, managed["storeKeysLocally"])
+}
+
+// changeApproveNewClients stores the setting when the user changes it.
+func (u *UI) changeApproveNewClients(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.ApproveNewClients = dom.Checked(u.approveNewClients)
+	})
+}
+
+// changeNativeHost stores the setting when the user changes it.
+func (u *UI) changeNativeHost(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.NativeHost = dom.Checked(u.nativeHost)
+	})
+}
+
+// changeCaptureAddedKeys stores the setting when the user changes it.
+func (u *UI) changeCaptureAddedKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.CaptureAddedKeys = dom.Checked(u.captureAddedKeys)
+	})
+}
+
+// changeRepeatedSign stores the setting when the user changes it.
+func (u *UI) changeRepeatedSign(ctx jsutil.AsyncContext, _ dom.Event) {
+	u.changeSettings(ctx, func(s *settings.Settings) {
+		s.RepeatedSignProtection = dom.Value(u.repeatedSign)
+	})
+}
+
+// changeSettings applies the change to the current settings, and stores
+// them.
+func (u *UI) changeSettings(ctx jsutil.AsyncContext, change func(s *settings.Settings)) {
+	s, err := u.settings.Get(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadSettings"))
+		return
+	}
+
+	change(s)
+	if err := u.settings.Set(ctx, s); err != nil {
+		u.setError(i18n.Wrap(err, "failedUpdateSettings"))
+		u.updateSettings(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateSettings(ctx)
+}
+
 // displayedKey represents a key displayed in the UI.
 type displayedKey struct {
 	// ID is the unique ID corresponding to the key.
@@ -292,19 +1296,115 @@ type displayedKey struct {
 	// passphrase to load. This field is only valid if the key is not
 	// loaded.
 	Encrypted bool
+	// Local indicates if the key is stored only on the local device.
+	Local bool
 	// Name is the human-readable name assigned to the key.
 	Name string
 	// Type is the type of key (e.g., 'ssh-rsa').
 	Type string
 	// Blob is the public key material for the key.
 	Blob string
+	// AuthorizedKey is the public key as a line in authorized_keys
+	// format, or an empty string if it cannot be determined without the
+	// passphrase.
+	AuthorizedKey string
+	// Fingerprint is the SHA256 fingerprint of the key, or an empty string
+	// if it cannot be determined without the passphrase.
+	Fingerprint string
 	// Comment is the comment attached to the key in the agent
 	Comment string
+	// Certificate describes the certificate associated with the key, if
+	// any.
+	Certificate *keys.CertificateInfo
+	// AutoLoad indicates that the key is loaded whenever the agent
+	// starts.
+	AutoLoad bool
+	// IdleTimeout is the key's idle timeout in minutes; see
+	// keys.ConfiguredKey.IdleTimeout.
+	IdleTimeout int
+	// Keyring is the keyring containing the key.
+	Keyring string
+	// Tags are the tags attached to the key.
+	Tags []string
+	// Hosts are the patterns matching the hosts for which the key is
+	// intended.
+	Hosts []string
+	// Confirm indicates that each signature with the key must be
+	// confirmed.
+	Confirm bool
+	// Notify indicates that the user is notified of each signature with
+	// the key.
+	Notify bool
+	// Persist indicates that the key remains loaded after the browser
+	// restarts.
+	Persist bool
+	// Captured indicates that a client added the key, and that it can be
+	// saved as a configured key.
+	Captured bool
+	// ChecksumMismatch indicates that the key material no longer matches
+	// its pinned checksum.
+	ChecksumMismatch bool
+	// Conflict indicates that another configured key has the same name.
+	Conflict bool
+	// LastUsed is when the key was last used to sign on this device, in
+	// seconds since the Unix epoch, or zero if it has not been used.
+	LastUsed int64
+	// row is the row of the keys table displaying the key.
+	row js.Value
+	// state is the state of the UI when row was constructed.
+	state rowState
+	// stale indicates that row must be rebuilt, even if the key is
+	// unchanged.
+	stale bool
 	// cleanup keeps track of any cleanup required before removing this key
 	// from the UI.
 	cleanup jsutil.CleanupFuncs
 }
 
+// rowKey identifies the key across refreshes, so that its row may be reused.
+func (d *displayedKey) rowKey() string {
+	if d.ID != keys.InvalidID {
+		return "id:" + string(d.ID)
+	}
+	return "blob:" + d.Blob
+}
+
+// sameKey indicates if two displayed keys have identical fields, and hence
+// would be displayed identically.
+func sameKey(a, b *displayedKey) bool {
+	return cmp.Equal(a, b, cmpopts.IgnoreUnexported(displayedKey{}))
+}
+
+// invalidateRow ensures that the row for the key with the specified ID is
+// rebuilt when keys are next updated. This restores controls that were
+// modified in anticipation of a change that did not happen (e.g., because an
+// operation failed).
+func (u *UI) invalidateRow(id keys.ID) {
+	if k := u.keyByID(id); k != nil {
+		k.stale = true
+	}
+}
+
+// rowState is the state of the UI, beyond the key itself, that determines
+// how a key's row is displayed.
+type rowState struct {
+	viewer        bool
+	warm          bool
+	capabilities  keys.Capabilities
+	syncAvailable bool
+}
+
+// rowState returns the current state of the UI that determines how rows are
+// displayed.
+func (u *UI) rowState() rowState {
+	return rowState{
+		viewer:        u.viewer,
+		warm:          u.warm,
+		capabilities:  *u.capabilities,
+		syncAvailable: u.syncErr == nil,
+	}
+}
+
 // LoadedKey returns the corresponding LoadedKey.
 func (d *displayedKey) LoadedKey() (*keys.LoadedKey, error) {
 	blob, err := base64.StdEncoding.DecodeString(d.Blob)
@@ -313,8 +1413,9 @@ func (d *displayedKey) LoadedKey() (*keys.LoadedKey, error) {
 	}
 
 	l := &keys.LoadedKey{
-		Type:    d.Type,
-		Comment: d.Comment,
+		Type:        d.Type,
+		Comment:     d.Comment,
+		Fingerprint: d.Fingerprint,
 	}
 	l.SetBlob(blob)
 	return l, nil
@@ -358,6 +1459,54 @@ const (
 	UnloadButton
 	// RemoveButton indicates that the button removes the key.
 	RemoveButton
+	// LocationButton indicates that the button moves the key between
+	// synced and local-only storage.
+	LocationButton
+	// AutoLoadButton indicates that the button configures whether the key
+	// is loaded whenever the agent starts.
+	AutoLoadButton
+	// RepinButton indicates that the button trusts changed key material.
+	RepinButton
+	// ExportButton indicates that the button exports the key's public
+	// key.
+	ExportButton
+	// IdleTimeoutSelect indicates that the select element configures the
+	// key's idle timeout.
+	IdleTimeoutSelect
+	// ConfirmButton indicates that the button configures whether each
+	// signature with the key must be confirmed.
+	ConfirmButton
+	// CopyButton indicates that the button copies the key's public key
+	// to the clipboard.
+	CopyButton
+	// UpdateButton indicates that the button replaces the key's private
+	// key.
+	UpdateButton
+	// CertificateButton indicates that the button replaces the key's
+	// certificate.
+	CertificateButton
+	// NotifyButton indicates that the button configures whether the user
+	// is notified of each signature with the key.
+	NotifyButton
+	// ResolveButton indicates that the button chooses between keys with
+	// the same name.
+	ResolveButton
+	// KeyringInput indicates that the input element assigns the key to
+	// a keyring.
+	KeyringInput
+	// PersistButton indicates that the button configures whether the key
+	// remains loaded after the browser restarts.
+	PersistButton
+	// AdoptButton indicates that the button saves a key added by a
+	// client as a configured key. Such keys have no ID, so the button is
+	// identified by the key's fingerprint.
+	AdoptButton
+	// TagsInput indicates that the input element edits the tags attached
+	// to the key.
+	TagsInput
+	// HostsInput indicates that the input element edits the hosts for
+	// which the key is intended.
+	HostsInput
 )
 
 // buttonID returns the value of the 'id' attribute to be assigned to the HTML
@@ -371,6 +1520,38 @@ func buttonID(kind buttonKind, id keys.ID) string {
 		s = "unload"
 	case RemoveButton:
 		s = "remove"
+	case LocationButton:
+		s = "location"
+	case AutoLoadButton:
+		s = "autoload"
+	case RepinButton:
+		s = "repin"
+	case ExportButton:
+		s = "export"
+	case IdleTimeoutSelect:
+		s = "idletimeout"
+	case ConfirmButton:
+		s = "confirm"
+	case CopyButton:
+		s = "copy"
+	case UpdateButton:
+		s = "update"
+	case CertificateButton:
+		s = "certificate"
+	case NotifyButton:
+		s = "notify"
+	case ResolveButton:
+		s = "resolve"
+	case KeyringInput:
+		s = "keyring"
+	case PersistButton:
+		s = "persist"
+	case AdoptButton:
+		s = "adopt"
+	case TagsInput:
+		s = "tags"
+	case HostsInput:
+		s = "hosts"
 	}
 	return fmt.Sprintf("%s-%s", s, id)
 }
@@ -378,88 +1559,469 @@ func buttonID(kind buttonKind, id keys.ID) string {
 // setKeys refreshes the UI to reflect the keys that should be
 // displayed.
 func (u *UI) setKeys(newKeys []*displayedKey) {
-	// Cleanup elements and resources for all previous keys.
-	dom.RemoveChildren(u.keysData)
+	// Replacing or removing the focused row removes focus from it;
+	// restore focus once the table is updated.
+	focus := u.saveKeysFocus()
+	// Reuse the rows of keys that are unchanged, so that refreshes do not
+	// rebuild the entire table.
+	state := u.rowState()
+	previous := make(map[string]*displayedKey)
 	for _, k := range u.keys {
-		k.cleanup.Do()
+		previous[k.rowKey()] = k
 	}
-
-	// Construct elements for new keys.
+	retained := make(map[*displayedKey]bool)
+	var result []*displayedKey
 	for _, k := range newKeys {
-		k := k
-		dom.AppendChild(u.keysData, u.dom.NewElement("tr"), func(row js.Value) {
-			// Key name
-			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
-				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
-					div.Set("className", "keyName")
-					dom.AppendChild(div, u.dom.NewText(k.Name), nil)
-				})
-			})
+		prev := previous[k.rowKey()]
+		if prev != nil && retained[prev] {
+			prev = nil
+		}
+		if prev != nil && !prev.stale && prev.state == state && sameKey(prev, k) {
+			retained[prev] = true
+			result = append(result, prev)
+			continue
+		}
+		k.state = state
+		k.row = u.newKeyRow(k)
+		if prev != nil {
+			// Replace the changed key's row in place, so that
+			// other rows need not move.
+			retained[prev] = true
+			prev.row.Call("replaceWith", k.row)
+			prev.cleanup.Do()
+		}
+		result = append(result, k)
+	}
 
-			// Controls
-			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
-				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
-					div.Set("className", "keyControls")
-					if k.ID == keys.InvalidID {
-						// We only control keys with a valid ID.
-						return
-					}
+	// Cleanup elements and resources for keys that are no longer
+	// displayed.
+	for _, k := range u.keys {
+		if retained[k] {
+			continue
+		}
+		k.row.Call("remove")
+		k.cleanup.Do()
+	}
 
-					if k.Loaded {
-						// Unload button
-						dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
-							btn.Set("type", "button")
-							btn.Set("id", buttonID(UnloadButton, k.ID))
-							dom.AppendChild(btn, u.dom.NewText("Unload"), nil)
-							k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
-								u.unload(ctx, k.ID)
-							}))
-						})
-					} else {
-						// Load button
-						dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
-							btn.Set("type", "button")
-							btn.Set("id", buttonID(LoadButton, k.ID))
-							dom.AppendChild(btn, u.dom.NewText("Load"), nil)
-							k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
-								u.load(ctx, k.ID)
-							}))
-						})
-					}
+	u.arrangeKeys(result)
+	// Update internal state after DOM is updated. Otherwise, callers (e.g.,
+	// our end-to-end test) may look for the new DOM elements before they
+	// are available.
+	u.keys = result
+	u.restoreKeysFocus(focus)
+	u.changed()
+}
 
-					// Remove button
+// newKeyRow returns a new row of the keys table that displays the key.
+func (u *UI) newKeyRow(k *displayedKey) js.Value {
+	row := u.dom.NewElement("tr")
+	dom.SetRole(row, "row")
+	dom.SetLabel(row, k.Name)
+	// Key name
+	dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+		dom.SetRole(cell, "gridcell")
+		dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "keyName")
+			dom.AppendChild(div, u.dom.NewText(k.Name), nil)
+		})
+		if k.Local {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "keyLocation", "note")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message("keyNotSynced")), nil)
+			})
+		}
+		if k.Confirm {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "keyConfirm", "note")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message("keyConfirmEachUse")), nil)
+			})
+		}
+		if k.Notify {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "keyNotify", "note")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message("keyNotifyEachUse")), nil)
+			})
+		}
+		if k.AutoLoad {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "autoLoadWarning", "warning")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message(autoLoadWarning)), nil)
+			})
+		}
+		if k.Persist {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "persistWarning", "warning")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message(persistWarning)), nil)
+			})
+		}
+		if k.ChecksumMismatch {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "checksumWarning", "warning")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message(checksumWarning)), nil)
+			})
+		}
+		if k.Conflict {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "conflictWarning", "warning")
+				dom.AppendChild(div, u.dom.NewText(i18n.Message(conflictWarning)), nil)
+			})
+		}
+		u.appendTags(cell, k)
+		u.appendHosts(cell, k)
+		if k.Certificate != nil {
+			u.appendCertificate(cell, k.Certificate)
+		}
+	})
+
+	// Controls
+	dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+		dom.SetRole(cell, "gridcell")
+		dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "keyControls")
+			if u.viewer || u.warm {
+				// We only control keys if the manager is
+				// available and the key's state is current.
+				return
+			}
+			if k.ID == keys.InvalidID {
+				// Keys without a valid ID were added by
+				// clients, and can only be saved, and only if
+				// they were captured.
+				if k.Captured && k.Fingerprint != "" && u.capabilities.Add {
 					dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
 						btn.Set("type", "button")
-						btn.Set("id", buttonID(RemoveButton, k.ID))
-						dom.AppendChild(btn, u.dom.NewText("Remove"), nil)
+						btn.Set("id", buttonID(AdoptButton, keys.ID(k.Fingerprint)))
+						dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonSaveKey")), nil)
 						k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
-							u.remove(ctx, k.ID)
+							u.adopt(ctx, k.Blob, adoptedName(k.Comment))
 						}))
 					})
+				}
+				return
+			}
+
+			if k.Loaded {
+				// Unload button
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(UnloadButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonUnload")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.unload(ctx, k.ID)
+					}))
 				})
-			})
+			} else {
+				// Load button
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(LoadButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonLoad")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.load(ctx, k.ID)
+					}))
+				})
+			}
+
+			// Remove button
+			if u.capabilities.Remove {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(RemoveButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonRemove")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.remove(ctx, k.ID)
+					}))
+				})
+			}
+
+			// Replace and certificate buttons. Replacing
+			// key material is equivalent to adding a key.
+			if u.capabilities.Add {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(UpdateButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonReplaceKey")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.update(ctx, k.ID)
+					}))
+				})
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(CertificateButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonSetCertificate")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.setCertificate(ctx, k.ID)
+					}))
+				})
+			}
+
+			// Storage location button. Keys cannot be
+			// moved while synced storage is unavailable.
+			if u.capabilities.SetLocal && u.syncErr == nil {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(LocationButton, k.ID))
+					text := i18n.Message("buttonStopSyncing")
+					if k.Local {
+						text = i18n.Message("buttonSync")
+					}
+					dom.AppendChild(btn, u.dom.NewText(text), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.setLocal(ctx, k.ID, !k.Local, btn)
+					}))
+				})
+			}
 
-			// Type
-			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
-				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
-					div.Set("className", "keyType")
-					dom.AppendChild(div, u.dom.NewText(k.Type), nil)
+			// Auto-load button. Encryption status is only
+			// known for keys that are not loaded, so the
+			// option is only offered for those (or to turn it
+			// off again).
+			if k.AutoLoad || (!k.Loaded && !k.Encrypted) {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(AutoLoadButton, k.ID))
+					text := i18n.Message("buttonLoadAtStartup")
+					if k.AutoLoad {
+						text = i18n.Message("buttonNoLoadAtStartup")
+					}
+					dom.AppendChild(btn, u.dom.NewText(text), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.setAutoLoad(ctx, k.ID, !k.AutoLoad)
+					}))
 				})
+			}
+
+			// Confirmation button
+			dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+				btn.Set("type", "button")
+				btn.Set("id", buttonID(ConfirmButton, k.ID))
+				text := i18n.Message("buttonConfirmEachUse")
+				if k.Confirm {
+					text = i18n.Message("buttonNoConfirmEachUse")
+				}
+				dom.AppendChild(btn, u.dom.NewText(text), nil)
+				k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+					u.setConfirm(ctx, k.ID, !k.Confirm)
+				}))
+			})
+
+			// Notification button
+			dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+				btn.Set("type", "button")
+				btn.Set("id", buttonID(NotifyButton, k.ID))
+				text := i18n.Message("buttonNotifyEachUse")
+				if k.Notify {
+					text = i18n.Message("buttonNoNotifyEachUse")
+				}
+				dom.AppendChild(btn, u.dom.NewText(text), nil)
+				k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+					u.setNotify(ctx, k.ID, !k.Notify)
+				}))
 			})
 
-			// Blob
-			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
-				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
-					div.Set("className", "keyBlob")
-					dom.AppendChild(div, u.dom.NewText(k.Blob), nil)
+			// Button to keep the key loaded after the browser restarts.
+			dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+				btn.Set("type", "button")
+				btn.Set("id", buttonID(PersistButton, k.ID))
+				text := i18n.Message("buttonPersist")
+				if k.Persist {
+					text = i18n.Message("buttonNoPersist")
+				}
+				dom.AppendChild(btn, u.dom.NewText(text), nil)
+				k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+					u.setPersist(ctx, k.ID, !k.Persist)
+				}))
+			})
+
+			// Button to trust changed key material.
+			if k.ChecksumMismatch && u.capabilities.Add {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(RepinButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonTrustChanges")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.repin(ctx, k.ID)
+					}))
+				})
+			}
+
+			// Button to choose between keys with the same name.
+			if k.Conflict && u.capabilities.Remove {
+				dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", buttonID(ResolveButton, k.ID))
+					dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonResolveConflict")), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						u.resolveConflict(ctx, k.ID)
+					}))
 				})
+			}
+
+			// Idle timeout
+			u.appendIdleTimeoutControl(div, k)
+
+			// Keyring
+			u.appendKeyringControl(div, k)
+
+			// Tags
+			u.appendTagsControl(div, k)
+
+			// Hosts
+			u.appendHostsControl(div, k)
+
+			// Export button
+			dom.AppendChild(div, u.dom.NewElement("button"), func(btn js.Value) {
+				btn.Set("type", "button")
+				btn.Set("id", buttonID(ExportButton, k.ID))
+				dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonExport")), nil)
+				k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+					u.exportPublicKeys(ctx, k.ID)
+				}))
 			})
 		})
+	})
+
+	// Type
+	dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+		dom.SetRole(cell, "gridcell")
+		dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "keyType")
+			dom.AppendChild(div, u.dom.NewText(k.Type), nil)
+		})
+	})
+
+	// Last used
+	dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+		dom.SetRole(cell, "gridcell")
+		dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "keyLastUsed")
+			dom.AppendChild(div, u.dom.NewText(formatLastUsed(k.LastUsed)), nil)
+		})
+	})
+
+	// Public key. Copying is permitted even when the key
+	// cannot otherwise be controlled.
+	dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+		dom.SetRole(cell, "gridcell")
+		if k.Fingerprint != "" {
+			dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AddClass(div, "keyFingerprint")
+				dom.AppendChild(div, u.dom.NewText(k.Fingerprint), nil)
+			})
+		}
+		dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "keyBlob")
+			dom.AppendChild(div, u.dom.NewText(k.AuthorizedKey), nil)
+		})
+		if k.AuthorizedKey == "" {
+			return
+		}
+		dom.AppendChild(cell, u.dom.NewElement("button"), func(btn js.Value) {
+			btn.Set("type", "button")
+			if k.ID != keys.InvalidID {
+				btn.Set("id", buttonID(CopyButton, k.ID))
+			}
+			dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonCopy")), nil)
+			k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+				u.copyPublicKey(ctx, k.AuthorizedKey)
+			}))
+		})
+	})
+	return row
+}
+
+const (
+	// autoLoadWarning names the message displayed for keys that are loaded
+	// whenever the agent starts.
+	autoLoadWarning = "autoLoadWarning"
+	// persistWarning names the message displayed for keys that remain
+	// loaded after the browser restarts.
+	persistWarning = "persistWarning"
+	// checksumWarning names the message displayed for keys whose material
+	// no longer matches its pinned checksum.
+	checksumWarning = "checksumWarning"
+	// conflictWarning names the message displayed for keys that share
+	// their name with another key.
+	conflictWarning = "conflictWarning"
+	// certExpiryWarning is how long before a certificate expires that
+	// the user is warned.
+	certExpiryWarning = 7 * 24 * time.Hour
+)
+
+// certificateWarning returns a warning to display for the certificate at the
+// specified time. An empty string is returned if there is nothing to warn
+// about.
+func certificateWarning(c *keys.CertificateInfo, now time.Time) string {
+	switch {
+	case c.Expired(now):
+		return i18n.Message("certificateExpired")
+	case c.ExpiresWithin(now, certExpiryWarning):
+		return i18n.Message("certificateExpiresIn", time.Unix(c.ValidBefore, 0).Sub(now).Round(time.Minute).String())
 	}
-	// Update internal state after DOM is updated. Otherwise, callers (e.g.,
-	// our end-to-end test) may look for the new DOM elements before they
-	// are available.
-	u.keys = newKeys
+	return ""
+}
+
+// formatCertTime formats a certificate validity time for display. zero is
+// displayed if the time is unset.
+func formatCertTime(t int64, zero string) string {
+	if t == 0 {
+		return zero
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// appendCertificate appends the details of a key's certificate to the
+// supplied element, along with any warning about its expiry.
+func (u *UI) appendCertificate(parent js.Value, c *keys.CertificateInfo) {
+	if w := certificateWarning(c, u.clock.Now()); w != "" {
+		dom.AppendChild(parent, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AddClass(div, "certWarning", "warning")
+			dom.AppendChild(div, u.dom.NewText(w), nil)
+		})
+	}
+
+	principals := strings.Join(c.Principals, ", ")
+	if principals == "" {
+		principals = i18n.Message("certificateAnyPrincipal")
+	}
+	lines := []string{
+		i18n.Message("certificateType", c.Type),
+		i18n.Message("certificateKeyID", c.KeyID),
+		i18n.Message("certificatePrincipals", principals),
+		i18n.Message("certificateValidAfter", formatCertTime(c.ValidAfter, i18n.Message("certificateAlways"))),
+		i18n.Message("certificateValidBefore", formatCertTime(c.ValidBefore, i18n.Message("certificateForever"))),
+		i18n.Message("certificateCA", c.CAFingerprint),
+	}
+	dom.AppendChild(parent, u.dom.NewElement("details"), func(details js.Value) {
+		dom.AddClass(details, "keyDetails")
+		dom.AppendChild(details, u.dom.NewElement("summary"), func(summary js.Value) {
+			dom.AppendChild(summary, u.dom.NewText(i18n.Message("certificateSummary")), nil)
+		})
+		for _, l := range lines {
+			dom.AppendChild(details, u.dom.NewElement("div"), func(div js.Value) {
+				dom.AppendChild(div, u.dom.NewText(l), nil)
+			})
+		}
+	})
+}
+
+// authorizedKey returns a line in authorized_keys format for the public key
+// (itself in authorized_keys format, but without a comment), using the
+// supplied comment. An empty string is returned if the public key is unknown.
+func authorizedKey(pub, comment string) string {
+	if pub == "" {
+		return ""
+	}
+	return strings.TrimSpace(pub + " " + comment)
+}
+
+// formatLastUsed formats the time at which a key was last used, in seconds
+// since the Unix epoch, for display.
+func formatLastUsed(t int64) string {
+	if t == 0 {
+		return i18n.Message("never")
+	}
+	return time.Unix(t, 0).UTC().Format("2006-01-02")
 }
 
 // mergeKeys merges configured and loaded keys to create a consolidated list
@@ -477,12 +2039,22 @@ func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*di
 	// being loaded.
 	loadedIds := make(map[keys.ID]bool)
 	for _, l := range loaded {
+		// A key with a certificate is loaded both with and without the
+		// certificate. The certificate is displayed as part of the
+		// configured key.
+		if l.IsCertificate() && configuredMap[l.ID()] != nil {
+			continue
+		}
+
 		// Gather basic fields we get for any loaded key.
 		dk := &displayedKey{
-			Loaded:  true,
-			Type:    l.Type,
-			Blob:    base64.StdEncoding.EncodeToString(l.Blob()),
-			Comment: l.Comment,
+			Loaded:        true,
+			Type:          l.Type,
+			Blob:          l.EncodedBlob(),
+			Comment:       l.Comment,
+			AuthorizedKey: authorizedKey(l.Type+" "+l.EncodedBlob(), l.Comment),
+			Fingerprint:   l.Fingerprint,
+			Captured:      l.Captured,
 		}
 		// Attempt to figure out if this is a key we loaded. If so, fill
 		// in some additional information.  It is possible that a key with
@@ -493,6 +2065,20 @@ func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*di
 				loadedIds[id] = true
 				dk.ID = id
 				dk.Name = ak.Name
+				dk.AuthorizedKey = authorizedKey(l.Type+" "+l.EncodedBlob(), ak.Name)
+				dk.Local = ak.Local
+				dk.Certificate = ak.Certificate
+				dk.AutoLoad = ak.AutoLoad
+				dk.IdleTimeout = ak.IdleTimeout
+				dk.Keyring = ak.Keyring
+				dk.Tags = ak.Tags
+				dk.Hosts = ak.Hosts
+				dk.Confirm = ak.Confirm
+				dk.Notify = ak.Notify
+				dk.Persist = ak.Persist
+				dk.ChecksumMismatch = ak.ChecksumMismatch
+				dk.Conflict = ak.Conflict
+				dk.LastUsed = ak.LastUsed
 			}
 		}
 		result = append(result, dk)
@@ -506,10 +2092,25 @@ func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*di
 		}
 
 		result = append(result, &displayedKey{
-			ID:        keys.ID(a.ID),
-			Loaded:    false,
-			Encrypted: a.Encrypted,
-			Name:      a.Name,
+			ID:               keys.ID(a.ID),
+			Loaded:           false,
+			Encrypted:        a.Encrypted,
+			Local:            a.Local,
+			Name:             a.Name,
+			AuthorizedKey:    authorizedKey(a.PublicKey, a.Name),
+			Fingerprint:      a.Fingerprint,
+			Certificate:      a.Certificate,
+			AutoLoad:         a.AutoLoad,
+			IdleTimeout:      a.IdleTimeout,
+			Keyring:          a.Keyring,
+			Tags:             a.Tags,
+			Hosts:            a.Hosts,
+			Confirm:          a.Confirm,
+			Notify:           a.Notify,
+			Persist:          a.Persist,
+			ChecksumMismatch: a.ChecksumMismatch,
+			Conflict:         a.Conflict,
+			LastUsed:         a.LastUsed,
 		})
 	}
 
@@ -537,42 +2138,203 @@ func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*di
 // updateKeys queries the manager for configured and loaded keys, then triggers
 // UI updates to reflect the current state.
 func (u *UI) updateKeys(ctx jsutil.AsyncContext) {
-	configured, err := u.mgr.Configured(ctx)
-	if err != nil {
-		u.setError(fmt.Errorf("failed to get configured keys: %w", err))
+	if u.viewer {
 		return
 	}
 
-	loaded, err := u.mgr.Loaded(ctx)
+	// Read the configured and loaded keys together, such that they are
+	// consistent with each other.
+	snapshot, err := u.mgr.Snapshot(ctx)
+	if errors.Is(err, message.ErrUnavailable) {
+		u.showSnapshot(ctx, err)
+		return
+	}
 	if err != nil {
-		u.setError(fmt.Errorf("failed to get loaded keys: %w", err))
+		u.setError(i18n.Wrap(err, "failedGetKeys"))
 		return
 	}
+	configured, loaded := snapshot.Configured, snapshot.Loaded
+	caps, err := u.settings.Capabilities(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read capabilities; showing all controls: %v", err)
+		caps = keys.AllCapabilities()
+	}
+	u.capabilities = caps
+	u.addButton.Set("hidden", !caps.Add)
+	u.generateButton.Set("hidden", !caps.Add)
+	u.importButton.Set("hidden", !caps.Add)
+	u.updateSync(ctx)
+	u.lockedPane.Set("hidden", !snapshot.Locked)
+
 	u.setError(nil)
+	u.fresh = true
+	u.warm = false
+	u.updateKeyrings(configured)
+	u.updateTags(configured)
 	u.setKeys(mergeKeys(configured, loaded))
+	u.updateOnboarding()
+	u.updateMalformed(ctx)
+	u.configured = configured
+	u.updateClients(ctx)
+	u.updateAudit(ctx)
+	u.updateUsage(ctx)
+	u.updateStatus(ctx)
+	u.updateLogs(ctx)
 
 	// We have successfully loaded keys. No need for initial status.
 	dom.RemoveChildren(u.loadingText)
+	u.changed()
+
+	if err := writeSnapshot(ctx, u.cache, newSnapshot(u.keys, u.clock.Now())); err != nil {
+		jsutil.LogError("failed to cache keys: %v", err)
+	}
 }
 
-const (
-	pollInterval = 100 * time.Millisecond
-	pollTimeout  = 10 * time.Second
-)
+// scheduleUpdate requests that the displayed keys be refreshed. Unlike
+// updateKeys, bursts of requests (e.g., when many keys are changed at once)
+// are coalesced so that the keys are refreshed at most once per
+// refreshInterval.
+func (u *UI) scheduleUpdate(ctx jsutil.AsyncContext) {
+	u.refresher.Request(ctx)
+}
 
-// poll checks a condition for up to a timeout.
-//
-// The AsyncContext ensures this is invoked within an async context where
-// blocking is acceptable.
-func poll(_ jsutil.AsyncContext, done func() bool) bool {
-	timeout := time.Now().Add(pollTimeout)
-	for time.Now().Before(timeout) {
-		if done() {
-			return true
+// updateMalformed queries the manager for stored keys that cannot be used, and
+// displays them so the user can decide whether to discard them.
+func (u *UI) updateMalformed(ctx jsutil.AsyncContext) {
+	malformed, err := u.mgr.Malformed(ctx)
+	if err != nil {
+		// Usable keys are already displayed; don't obscure them with
+		// an error.
+		jsutil.LogError("failed to get malformed keys: %v", err)
+		return
+	}
+	u.setMalformed(malformed)
+}
+
+// updateSync checks whether synced storage is available, and displays a
+// banner if it is not.
+func (u *UI) updateSync(ctx jsutil.AsyncContext) {
+	u.syncErr = u.mgr.CheckSync(ctx)
+	if u.syncErr != nil {
+		jsutil.Log("Synced storage unavailable: %v", u.syncErr)
+	}
+	u.syncPane.Set("hidden", u.syncErr == nil)
+}
+
+// discardMalformed removes the specified malformed key from storage.
+func (u *UI) discardMalformed(ctx jsutil.AsyncContext, mk *keys.MalformedKey) {
+	if err := u.mgr.RemoveMalformed(ctx, mk.StorageKey, mk.Local); err != nil {
+		u.setError(i18n.Wrap(err, "failedDiscardKey"))
+		return
+	}
+	u.setError(nil)
+	u.updateMalformed(ctx)
+}
+
+// setMalformed refreshes the UI to reflect the malformed keys that should be
+// displayed. The list is hidden if there are none.
+func (u *UI) setMalformed(malformed []*keys.MalformedKey) {
+	dom.RemoveChildren(u.attentionList)
+	u.malformedCleanup.Do()
+	u.malformedCleanup = &jsutil.CleanupFuncs{}
+	u.attentionPane.Set("hidden", len(malformed) == 0)
+
+	for _, mk := range malformed {
+		mk := mk
+		dom.AppendChild(u.attentionList, u.dom.NewElement("li"), func(li js.Value) {
+			name := mk.Name
+			if name == "" {
+				name = i18n.Message("unnamedKey")
+			}
+			if mk.Local {
+				name += " (not synced)"
+			}
+			dom.AppendChild(li, u.dom.NewText(fmt.Sprintf("%s: %s", name, mk.Reason)), nil)
+
+			if !u.capabilities.Remove {
+				return
+			}
+			dom.AppendChild(li, u.dom.NewElement("button"), func(btn js.Value) {
+				btn.Set("type", "button")
+				btn.Set("id", discardButtonID(mk))
+				dom.AppendChild(btn, u.dom.NewText(i18n.Message("buttonDiscard")), nil)
+				u.malformedCleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+					u.discardMalformed(ctx, mk)
+				}))
+			})
+		})
+	}
+}
+
+// discardButtonID returns the value of the 'id' attribute to be assigned to
+// the button that discards a malformed key.
+func discardButtonID(mk *keys.MalformedKey) string {
+	location := "sync"
+	if mk.Local {
+		location = "local"
+	}
+	return fmt.Sprintf("discard-%s-%s", location, mk.StorageKey)
+}
+
+// showCached displays the keys from the cached snapshot while the manager is
+// queried, so the page need not be blank while the keys load. The keys are
+// displayed without controls until they are refreshed by updateKeys.
+func (u *UI) showCached(ctx jsutil.AsyncContext) {
+	s, err := readSnapshot(ctx, u.cache)
+	if err != nil {
+		jsutil.LogError("failed to read cached keys: %v", err)
+		return
+	}
+	// Don't replace keys already read from the manager, or the snapshot
+	// displayed because the manager is unavailable.
+	if s == nil || u.fresh || u.viewer {
+		return
+	}
+
+	u.warm = true
+	u.setKeys(s.DisplayedKeys())
+	dom.RemoveChildren(u.loadingText)
+	dom.AppendChild(u.loadingText, u.dom.NewText(i18n.Message("refreshingKeys")), nil)
+	u.changed()
+}
+
+// showSnapshot switches the UI to a read-only view of the cached snapshot of
+// keys. It is used when the manager cannot be reached (reported by cause), in
+// which case waiting for keys to load would never complete.
+func (u *UI) showSnapshot(ctx jsutil.AsyncContext, cause error) {
+	jsutil.LogError("UI.showSnapshot(): manager unavailable: %v", cause)
+	u.viewer = true
+	u.addButton.Set("disabled", true)
+	u.generateButton.Set("disabled", true)
+	u.exportButton.Set("disabled", true)
+
+	s, err := readSnapshot(ctx, u.cache)
+	if err != nil {
+		jsutil.LogError("failed to read cached keys: %v", err)
+	}
+
+	msg := i18n.Message("viewerNoSnapshot")
+	if s != nil {
+		u.setKeys(s.DisplayedKeys())
+		msg = i18n.Message("viewerSnapshot", time.Unix(s.Updated, 0).UTC().Format(time.RFC3339))
+	}
+	dom.RemoveChildren(u.viewerText)
+	dom.AppendChild(u.viewerText, u.dom.NewText(msg), nil)
+	dom.RemoveChildren(u.loadingText)
+	u.changed()
+}
+
+// VerifySetup is a read-only variant of EndToEndTest. It verifies the user's
+// configured keys and the state of the agent, without adding, loading or
+// removing any keys. Failures are returned as a list of errors.
+func (u *UI) VerifySetup(ctx jsutil.AsyncContext) []error {
+	var errs []error
+	for _, res := range u.runVerifyChecks(ctx) {
+		if res.Err != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", res.Name, res.Err))
 		}
-		time.Sleep(pollInterval)
 	}
-	return false
+	return errs
 }
 
 // EndToEndTest runs a set of tests via the UI.  Failures are returned as a list
@@ -580,7 +2342,10 @@ func poll(_ jsutil.AsyncContext, done func() bool) bool {
 //
 // No attempt is made to clean up from any intermediate state should the test
 // fail.
-func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
+//
+// w determines how long to wait for the UI to reflect each step. Each step is
+// awaited as it completes, rather than polled.
+func (u *UI) EndToEndTest(ctx jsutil.AsyncContext, w wait.Waiter) []error {
 	var errs []error
 
 	jsutil.Log("Starting test")
@@ -591,15 +2356,12 @@ func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
 		}
 	}()
 
-	addDialog := u.dom.GetElement("addDialog")
 	addButton := u.dom.GetElement("add")
 	addName := u.dom.GetElement("addName")
 	addKey := u.dom.GetElement("addKey")
 	addOk := u.dom.GetElement("addOk")
-	passphraseDialog := u.dom.GetElement("passphraseDialog")
 	passphraseInput := u.dom.GetElement("passphrase")
 	passphraseOk := u.dom.GetElement("passphraseOk")
-	removeDialog := u.dom.GetElement("removeDialog")
 	removeYes := u.dom.GetElement("removeYes")
 
 	jsutil.Log("Generate random name to use for key")
@@ -611,8 +2373,9 @@ func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
 	keyName := fmt.Sprintf("e2e-test-key-%s", i.String())
 
 	jsutil.Log("Configure a new key")
+	added := u.nextCompletion(opAdd)
 	dom.DoClick(addButton)
-	if !poll(ctx, func() bool { return addDialog.Get("open").Bool() }) {
+	if !u.awaitDialog(ctx, w.Timeout, "addDialog", true) {
 		errs = append(errs, fmt.Errorf("add dialog failed to open"))
 		return errs
 	}
@@ -620,31 +2383,35 @@ func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
 	// Use the long key to exercise storage of large values in Chrome storage.
 	dom.SetValue(addKey, testdata.LongKeyWithPassphrase.Private)
 	dom.DoClick(addOk)
+	if !added(ctx, w.Timeout) {
+		errs = append(errs, fmt.Errorf("add failed to complete"))
+		return errs
+	}
 
 	jsutil.Log("Validate configured keys; ensure new key is present")
-	var key *displayedKey
-	if !poll(ctx, func() bool {
-		key = u.keyByName(keyName)
-		return key != nil
-	}) {
+	key := u.keyByName(keyName)
+	if key == nil {
 		errs = append(errs, fmt.Errorf("after added: failed to find key"))
 		return errs
 	}
 
 	jsutil.Log("Load the new key")
+	loaded := u.nextCompletion(opLoad)
 	dom.DoClick(u.dom.GetElement(buttonID(LoadButton, key.ID)))
-	if !poll(ctx, func() bool { return passphraseDialog.Get("open").Bool() }) {
+	if !u.awaitDialog(ctx, w.Timeout, "passphraseDialog", true) {
 		errs = append(errs, fmt.Errorf("passphrase dialog failed to open"))
 		return errs
 	}
 	dom.SetValue(passphraseInput, testdata.LongKeyWithPassphrase.Passphrase)
 	dom.DoClick(passphraseOk)
+	if !loaded(ctx, w.Timeout) {
+		errs = append(errs, fmt.Errorf("load failed to complete"))
+		return errs
+	}
 
 	jsutil.Log("Validate loaded keys; ensure new key is loaded")
-	if !poll(ctx, func() bool {
-		key = u.keyByName(keyName)
-		return key != nil && key.Loaded
-	}) {
+	key = u.keyByName(keyName)
+	if key == nil || !key.Loaded {
 		errs = append(errs, fmt.Errorf("after loaded: failed to find loaded key"))
 		return errs
 	}
@@ -659,13 +2426,16 @@ func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
 	}
 
 	jsutil.Log("Unload key")
+	unloaded := u.nextCompletion(opUnload)
 	dom.DoClick(u.dom.GetElement(buttonID(UnloadButton, key.ID)))
+	if !unloaded(ctx, w.Timeout) {
+		errs = append(errs, fmt.Errorf("unload failed to complete"))
+		return errs
+	}
 
 	jsutil.Log("Validate loaded keys; ensure key is unloaded")
-	if !poll(ctx, func() bool {
-		key = u.keyByName(keyName)
-		return key != nil && !key.Loaded
-	}) {
+	key = u.keyByName(keyName)
+	if key == nil || key.Loaded {
 		errs = append(errs, fmt.Errorf("after unload: failed to find unloaded key"))
 		return errs // Remaining tests have hard dependency on unloaded key.
 	}
@@ -680,18 +2450,20 @@ func (u *UI) EndToEndTest(ctx jsutil.AsyncContext) []error {
 	}
 
 	jsutil.Log("Remove key")
+	removed := u.nextCompletion(opRemove)
 	dom.DoClick(u.dom.GetElement(buttonID(RemoveButton, key.ID)))
-	if !poll(ctx, func() bool { return removeDialog.Get("open").Bool() }) {
+	if !u.awaitDialog(ctx, w.Timeout, "removeDialog", true) {
 		errs = append(errs, fmt.Errorf("remove dialog failed to open"))
 		return errs
 	}
 	dom.DoClick(removeYes)
+	if !removed(ctx, w.Timeout) {
+		errs = append(errs, fmt.Errorf("remove failed to complete"))
+		return errs
+	}
 
 	jsutil.Log("Validate configured keys; ensure key is removed")
-	if !poll(ctx, func() bool {
-		key = u.keyByName(keyName)
-		return key == nil
-	}) {
+	if u.keyByName(keyName) != nil {
 		errs = append(errs, fmt.Errorf("after removed: failed to observe key as removed"))
 		return errs
 	}
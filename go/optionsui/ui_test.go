@@ -15,7 +15,12 @@
 package optionsui
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall/js"
 	"testing"
 	"time"
@@ -23,6 +28,12 @@ import (
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 
+	"github.com/google/chrome-ssh-agent/go/audit"
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/clients"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/debugreport"
 	"github.com/google/chrome-ssh-agent/go/dom"
 	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -30,19 +41,26 @@ import (
 	"github.com/google/chrome-ssh-agent/go/keys"
 	"github.com/google/chrome-ssh-agent/go/keys/testdata"
 	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/passgen"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	sfakes "github.com/google/chrome-ssh-agent/go/settings/fakes"
 	"github.com/google/chrome-ssh-agent/go/storage"
 	st "github.com/google/chrome-ssh-agent/go/storage/testing"
 	"github.com/google/chrome-ssh-agent/go/testutil"
+	"github.com/google/chrome-ssh-agent/go/vault"
+	"github.com/google/chrome-ssh-agent/go/wait"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/norunners/vert"
 )
 
 var (
 	validID = keys.ID("1")
 
-	// Don't bother with Comment field, since it may contain a
-	// randomly-generated ID.
-	displayedKeyCmp = cmpopts.IgnoreFields(displayedKey{}, "Comment", "cleanup")
+	// Don't bother with Comment or AuthorizedKey fields, since they may
+	// contain a randomly-generated ID. AuthorizedKey and Fingerprint are
+	// covered by TestPublicKeys.
+	displayedKeyCmp = cmpopts.IgnoreFields(displayedKey{}, "Comment", "AuthorizedKey", "Fingerprint", "row", "state", "stale", "cleanup")
 
 	optionsHTMLData = string(testutil.MustReadRunfile("_main/html/options.html"))
 )
@@ -53,104 +71,187 @@ type testHarness struct {
 	manager   keys.Manager
 	server    *keys.Server
 	Client    keys.Manager
+	settings  *settings.Store
+	clients   *clients.Store
+	vault     *vault.Vault
+	storage   storage.Area
+	managed   *sfakes.Managed
+	cache     storage.Area
 	dom       *dom.Doc
 	UI        *UI
 
-	loadingText      js.Value
-	addDialog        js.Value
-	addButton        js.Value
-	addName          js.Value
-	addKey           js.Value
-	addOk            js.Value
-	addCancel        js.Value
-	passphraseDialog js.Value
-	passphraseInput  js.Value
-	passphraseOk     js.Value
-	passphraseCancel js.Value
-	removeDialog     js.Value
-	removeYes        js.Value
-	removeNo         js.Value
+	loadingText       js.Value
+	addDialog         js.Value
+	addButton         js.Value
+	addName           js.Value
+	addKey            js.Value
+	addLocal          js.Value
+	addOk             js.Value
+	addCancel         js.Value
+	passphraseDialog  js.Value
+	passphraseInput   js.Value
+	passphraseOk      js.Value
+	passphraseCancel  js.Value
+	removeDialog      js.Value
+	removeYes         js.Value
+	removeNo          js.Value
+	approveNewClients js.Value
+	repeatedSign      js.Value
+	idleTimeout       js.Value
 }
 
 func (h *testHarness) Release() {
 	h.UI.Release()
 }
 
-func mustPoll(ctx jsutil.AsyncContext, done func() bool) {
-	if !poll(ctx, done) {
+// mustPoll polls for a condition that the UI does not announce (e.g., the
+// effect of a change to settings). Prefer mustAwait where possible.
+func mustPoll(_ jsutil.AsyncContext, done func() bool) {
+	if !wait.Default.Until(done) {
+		panic("timed out waiting for condition")
+	}
+}
+
+// mustAwait waits for a condition on the state of the UI, which is evaluated
+// whenever the state changes; see UI.await.
+func (h *testHarness) mustAwait(ctx jsutil.AsyncContext, done func() bool) {
+	if !h.UI.await(ctx, wait.Default.Timeout, done) {
 		panic("timed out waiting for condition")
 	}
 }
 
 func (h *testHarness) waitLoaded(ctx jsutil.AsyncContext) {
-	mustPoll(ctx, func() bool { return dom.TextContent(h.loadingText) == "" })
+	h.mustAwait(ctx, func() bool { return dom.TextContent(h.loadingText) == "" })
 }
 
 func (h *testHarness) waitDialogOpen(ctx jsutil.AsyncContext, dialog js.Value) {
-	mustPoll(ctx, func() bool { return dialog.Get("open").Bool() })
+	if !h.UI.awaitDialog(ctx, wait.Default.Timeout, dom.ID(dialog), true) {
+		panic("timed out waiting for dialog to open")
+	}
 }
 
 func (h *testHarness) waitDialogClosed(ctx jsutil.AsyncContext, dialog js.Value) {
-	mustPoll(ctx, func() bool { return !dialog.Get("open").Bool() })
+	if !h.UI.awaitDialog(ctx, wait.Default.Timeout, dom.ID(dialog), false) {
+		panic("timed out waiting for dialog to close")
+	}
+}
+
+// waitPassphraseAttempt waits for the passphrase dialog to be opened for the
+// specified attempt at entering a passphrase.
+func (h *testHarness) waitPassphraseAttempt(ctx jsutil.AsyncContext, attempt int) {
+	retry := h.dom.GetElement("passphraseRetry")
+	want := fmt.Sprintf("Attempt %d of", attempt)
+	dialog := h.UI.dialog("passphraseDialog")
+	h.mustAwait(ctx, func() bool {
+		if !dialog.Open() {
+			return false
+		}
+		if attempt == 1 {
+			return retry.Get("hidden").Bool()
+		}
+		return strings.Contains(dom.TextContent(retry), want)
+	})
 }
 
 func (h *testHarness) waitKeyConfigured(ctx jsutil.AsyncContext, name string) {
-	mustPoll(ctx, func() bool { return h.UI.keyByName(name) != nil })
+	h.mustAwait(ctx, func() bool { return h.UI.keyByName(name) != nil })
 }
 
 func (h *testHarness) waitKeyRemoved(ctx jsutil.AsyncContext, name string) {
-	mustPoll(ctx, func() bool { return h.UI.keyByName(name) == nil })
+	h.mustAwait(ctx, func() bool { return h.UI.keyByName(name) == nil })
+}
+
+// undo clicks the Undo button of the displayed notification, once it has the
+// specified text.
+func (h *testHarness) undo(ctx jsutil.AsyncContext, text string) {
+	toasts := h.dom.GetElement("toasts")
+	mustPoll(ctx, func() bool { return strings.HasPrefix(dom.TextContent(toasts), text) })
+	dom.DoClick(toasts.Call("querySelector", "button"))
+	mustPoll(ctx, func() bool { return dom.TextContent(toasts) == "" })
 }
 
 func (h *testHarness) waitKeyLoaded(ctx jsutil.AsyncContext, name string) {
-	mustPoll(ctx, func() bool {
+	h.mustAwait(ctx, func() bool {
 		k := h.UI.keyByName(name)
 		return k != nil && k.Loaded
 	})
 }
 
 func (h *testHarness) waitKeyUnloaded(ctx jsutil.AsyncContext, name string) {
-	mustPoll(ctx, func() bool {
+	h.mustAwait(ctx, func() bool {
 		k := h.UI.keyByName(name)
 		return k != nil && !k.Loaded
 	})
 }
 
 func newHarness() *testHarness {
-	syncStorage := storage.NewRaw(st.NewMemArea())
+	return newHarnessWithSync(storage.NewRaw(st.NewMemArea()))
+}
+
+// newHarnessWithSync returns a testHarness that uses the supplied area as
+// synced storage.
+func newHarnessWithSync(syncStorage storage.Area) *testHarness {
 	sessionStorage := storage.NewRaw(st.NewMemArea())
 	msg := mfakes.NewHub()
 
 	agt := agent.NewKeyring()
-	mgr := keys.NewManager(agt, syncStorage, sessionStorage)
-	srv := keys.NewServer(mgr)
+	localStorage := storage.NewRaw(st.NewMemArea())
+	managed := sfakes.NewManaged()
+	prefStorage := storage.NewRaw(st.NewMemArea())
+	sts := settings.NewStore(prefStorage, localStorage, managed)
+	cls := clients.NewStore(prefStorage)
+	vlt := vault.New(prefStorage, sessionStorage)
+	mgr := keys.NewManager(agt, syncStorage, localStorage, sessionStorage)
+	mgr.SetKeySource(vlt)
+	mgr.SetPasswordVerifier(vlt)
+	mgr.SetReauthWindow(func(ctx jsutil.AsyncContext) (time.Duration, error) {
+		s, err := sts.Get(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return s.ExportReauthWindow(), nil
+	})
+	// Settings requests are answered first, as by the background worker.
+	msg.AddReceiver(settings.NewServer(sts))
+	srv := keys.NewServer(mgr, sts.Capabilities)
 	msg.AddReceiver(srv)
 	cli := keys.NewClient(msg)
+	cache := storage.NewRaw(st.NewMemArea())
 	domObj := dom.New(dt.NewDocForTesting(optionsHTMLData))
-	ui := New(cli, domObj)
+	ui := New(cli, msg, settings.NewClient(msg), cls, vlt, cache, clock.Real, domObj)
 
 	return &testHarness{
-		messaging:        msg,
-		agent:            agt,
-		manager:          mgr,
-		server:           srv,
-		Client:           cli,
-		dom:              domObj,
-		UI:               ui,
-		loadingText:      domObj.GetElement("loadingMessage"),
-		addDialog:        domObj.GetElement("addDialog"),
-		addButton:        domObj.GetElement("add"),
-		addName:          domObj.GetElement("addName"),
-		addKey:           domObj.GetElement("addKey"),
-		addOk:            domObj.GetElement("addOk"),
-		addCancel:        domObj.GetElement("addCancel"),
-		passphraseDialog: domObj.GetElement("passphraseDialog"),
-		passphraseInput:  domObj.GetElement("passphrase"),
-		passphraseOk:     domObj.GetElement("passphraseOk"),
-		passphraseCancel: domObj.GetElement("passphraseCancel"),
-		removeDialog:     domObj.GetElement("removeDialog"),
-		removeYes:        domObj.GetElement("removeYes"),
-		removeNo:         domObj.GetElement("removeNo"),
+		messaging:         msg,
+		agent:             agt,
+		manager:           mgr,
+		server:            srv,
+		Client:            cli,
+		settings:          sts,
+		clients:           cls,
+		vault:             vlt,
+		storage:           syncStorage,
+		managed:           managed,
+		cache:             cache,
+		dom:               domObj,
+		UI:                ui,
+		loadingText:       domObj.GetElement("loadingMessage"),
+		addDialog:         domObj.GetElement("addDialog"),
+		addButton:         domObj.GetElement("add"),
+		addName:           domObj.GetElement("addName"),
+		addKey:            domObj.GetElement("addKey"),
+		addLocal:          domObj.GetElement("addLocal"),
+		addOk:             domObj.GetElement("addOk"),
+		addCancel:         domObj.GetElement("addCancel"),
+		passphraseDialog:  domObj.GetElement("passphraseDialog"),
+		passphraseInput:   domObj.GetElement("passphrase"),
+		passphraseOk:      domObj.GetElement("passphraseOk"),
+		passphraseCancel:  domObj.GetElement("passphraseCancel"),
+		removeDialog:      domObj.GetElement("removeDialog"),
+		removeYes:         domObj.GetElement("removeYes"),
+		removeNo:          domObj.GetElement("removeNo"),
+		approveNewClients: domObj.GetElement("approveNewClients"),
+		repeatedSign:      domObj.GetElement("repeatedSignProtection"),
+		idleTimeout:       domObj.GetElement("idleTimeout"),
 	}
 }
 
@@ -165,6 +266,19 @@ func directLoadKey(agt agent.Agent, privateKey string) {
 	}
 }
 
+// fingerprint returns the SHA256 fingerprint of the base64-encoded public key.
+func fingerprint(blob string) string {
+	b, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode blob: %v", err))
+	}
+	pub, err := ssh.ParsePublicKey(b)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse public key: %v", err))
+	}
+	return ssh.FingerprintSHA256(pub)
+}
+
 func findKey(disp []*displayedKey, name string) keys.ID {
 	for _, k := range disp {
 		if k.Name == name {
@@ -201,7 +315,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key")
-				dom.SetValue(h.addKey, "private-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key")
@@ -219,7 +333,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-1")
-				dom.SetValue(h.addKey, "private-key-1")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-1")
@@ -227,7 +341,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-2")
-				dom.SetValue(h.addKey, "private-key-2")
+				dom.SetValue(h.addKey, testdata.ED25519WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-2")
@@ -249,30 +363,79 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key")
-				dom.SetValue(h.addKey, "private-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addCancel)
 				h.waitDialogClosed(ctx, h.addDialog)
 			},
 		},
+		{
+			description: "add key cancelled by escape",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+				dt.PressEscape(h.addDialog)
+				h.waitDialogClosed(ctx, h.addDialog)
+
+				// The dialog is usable again after cancellation.
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "other-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "other-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:   validID,
+					Name: "other-key",
+				},
+			},
+		},
+		{
+			description: "add key cancelled by clicking backdrop",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+				dt.ClickBackdrop(h.addDialog)
+				h.waitDialogClosed(ctx, h.addDialog)
+			},
+		},
 		{
 			description: "add key fails",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "")
-				dom.SetValue(h.addKey, "private-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 			},
 			wantErr: "failed to add key: invalid name: name must not be empty",
 		},
+		{
+			description: "add key fails on public key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Type+" "+testdata.WithoutPassphrase.Blob)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+			},
+			wantErr: "failed to add key: malformed key: private key is not PEM-encoded",
+		},
 		{
 			description: "remove key",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-1")
-				dom.SetValue(h.addKey, "private-key-1")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-1")
@@ -280,7 +443,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-2")
-				dom.SetValue(h.addKey, "private-key-2")
+				dom.SetValue(h.addKey, testdata.ED25519WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-2")
@@ -299,13 +462,40 @@ func TestUserActions(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "undo remove key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key-1")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key-1")
+
+				id := findKey(h.UI.displayedKeys(), "new-key-1")
+				dom.DoClick(h.dom.GetElement(buttonID(RemoveButton, id)))
+				h.waitDialogOpen(ctx, h.removeDialog)
+				dom.DoClick(h.removeYes)
+				h.waitDialogClosed(ctx, h.removeDialog)
+				h.waitKeyRemoved(ctx, "new-key-1")
+				h.undo(ctx, "Removed key new-key-1")
+				h.waitKeyConfigured(ctx, "new-key-1")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:   validID,
+					Name: "new-key-1",
+				},
+			},
+		},
 		{
 			description: "remove key cancelled by user",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-1")
-				dom.SetValue(h.addKey, "private-key-1")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-1")
@@ -313,7 +503,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-2")
-				dom.SetValue(h.addKey, "private-key-2")
+				dom.SetValue(h.addKey, testdata.ED25519WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-2")
@@ -341,7 +531,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-1")
-				dom.SetValue(h.addKey, "private-key-1")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-1")
@@ -349,7 +539,7 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key-2")
-				dom.SetValue(h.addKey, "private-key-2")
+				dom.SetValue(h.addKey, testdata.ED25519WithoutPassphrase.Private)
 				dom.DoClick(h.addOk)
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key-2")
@@ -437,9 +627,12 @@ func TestUserActions(t *testing.T) {
 
 				id := findKey(h.UI.displayedKeys(), "new-key")
 				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
-				h.waitDialogOpen(ctx, h.passphraseDialog)
-				dom.SetValue(h.passphraseInput, "incorrect-passphrase")
-				dom.DoClick(h.passphraseOk)
+				for attempt := 1; attempt <= maxPassphraseAttempts; attempt++ {
+					h.waitPassphraseAttempt(ctx, attempt)
+					dom.SetValue(h.passphraseInput, "incorrect-passphrase")
+					dom.DoClick(h.passphraseOk)
+				}
+				mustPoll(ctx, func() bool { return dom.TextContent(h.dom.GetElement("errorMessage")) != "" })
 				h.waitDialogClosed(ctx, h.passphraseDialog)
 			},
 			wantDisplayed: []*displayedKey{
@@ -451,6 +644,38 @@ func TestUserActions(t *testing.T) {
 			},
 			wantErr: "failed to load key: failed to decrypt key: failed to parse private key: x509: decryption password incorrect",
 		},
+		{
+			description: "load key after mistyped passphrase",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+				h.waitPassphraseAttempt(ctx, 1)
+				dom.SetValue(h.passphraseInput, "incorrect-passphrase")
+				dom.DoClick(h.passphraseOk)
+				h.waitPassphraseAttempt(ctx, 2)
+				dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
+				dom.DoClick(h.passphraseOk)
+				h.waitDialogClosed(ctx, h.passphraseDialog)
+				h.waitKeyLoaded(ctx, "new-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:     validID,
+					Name:   "new-key",
+					Loaded: true,
+					Type:   testdata.WithPassphrase.Type,
+					Blob:   testdata.WithPassphrase.Blob,
+				},
+			},
+		},
 		{
 			description: "load unencrypted key",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
@@ -507,7 +732,7 @@ func TestUserActions(t *testing.T) {
 			},
 		},
 		{
-			description: "unload key fails",
+			description: "undo unload key",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
@@ -525,26 +750,23 @@ func TestUserActions(t *testing.T) {
 				h.waitDialogClosed(ctx, h.passphraseDialog)
 				h.waitKeyLoaded(ctx, "new-key")
 
-				h.UI.unload(ctx, keys.ID("bogus-id"))
+				dom.DoClick(h.dom.GetElement(buttonID(UnloadButton, id)))
+				h.waitKeyUnloaded(ctx, "new-key")
+				h.undo(ctx, "Unloaded key new-key")
+				h.waitKeyLoaded(ctx, "new-key")
 			},
 			wantDisplayed: []*displayedKey{
 				{
-					ID:     validID,
-					Name:   "new-key",
-					Loaded: true,
-					Type:   testdata.WithPassphrase.Type,
-					Blob:   testdata.WithPassphrase.Blob,
+					ID:        validID,
+					Name:      "new-key",
+					Loaded:    true,
+					Encrypted: true,
 				},
 			},
-			wantErr: "failed to unload key ID bogus-id: key unload from agent failed: invalid id: bogus-id",
 		},
 		{
-			description: "display non-configured keys",
+			description: "unload key fails",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
-				// Load an additional key directly into the agent.
-				directLoadKey(h.agent, testdata.WithoutPassphrase.Private)
-
-				// Configure a key of our own.
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
 				dom.SetValue(h.addName, "new-key")
@@ -553,7 +775,6 @@ func TestUserActions(t *testing.T) {
 				h.waitDialogClosed(ctx, h.addDialog)
 				h.waitKeyConfigured(ctx, "new-key")
 
-				// Load the key we configured.
 				id := findKey(h.UI.displayedKeys(), "new-key")
 				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
 				h.waitDialogOpen(ctx, h.passphraseDialog)
@@ -561,14 +782,10 @@ func TestUserActions(t *testing.T) {
 				dom.DoClick(h.passphraseOk)
 				h.waitDialogClosed(ctx, h.passphraseDialog)
 				h.waitKeyLoaded(ctx, "new-key")
+
+				h.UI.unload(ctx, keys.ID("bogus-id"))
 			},
 			wantDisplayed: []*displayedKey{
-				{
-					ID:     keys.InvalidID,
-					Loaded: true,
-					Type:   testdata.WithoutPassphrase.Type,
-					Blob:   testdata.WithoutPassphrase.Blob,
-				},
 				{
 					ID:     validID,
 					Name:   "new-key",
@@ -577,9 +794,31 @@ func TestUserActions(t *testing.T) {
 					Blob:   testdata.WithPassphrase.Blob,
 				},
 			},
+			wantErr: "failed to unload key ID bogus-id: key unload from agent failed: invalid id: bogus-id",
 		},
 		{
-			description: "display loaded key that was previously-configured, then removed",
+			description: "add key stored locally",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.SetChecked(h.addLocal, true)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:        validID,
+					Name:      "new-key",
+					Encrypted: true,
+					Local:     true,
+				},
+			},
+		},
+		{
+			description: "move key to local storage",
 			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
 				dom.DoClick(h.addButton)
 				h.waitDialogOpen(ctx, h.addDialog)
@@ -590,50 +829,287 @@ func TestUserActions(t *testing.T) {
 				h.waitKeyConfigured(ctx, "new-key")
 
 				id := findKey(h.UI.displayedKeys(), "new-key")
-				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
-				h.waitDialogOpen(ctx, h.passphraseDialog)
-				dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
-				dom.DoClick(h.passphraseOk)
-				h.waitDialogClosed(ctx, h.passphraseDialog)
-				h.waitKeyLoaded(ctx, "new-key")
-
-				dom.DoClick(h.dom.GetElement(buttonID(RemoveButton, id)))
-				h.waitDialogOpen(ctx, h.removeDialog)
-				dom.DoClick(h.removeYes)
-				h.waitDialogClosed(ctx, h.removeDialog)
-				h.waitKeyRemoved(ctx, "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(LocationButton, id)))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.Local
+				})
 			},
 			wantDisplayed: []*displayedKey{
 				{
-					ID:     keys.InvalidID,
-					Loaded: true,
-					Type:   testdata.WithPassphrase.Type,
-					Blob:   testdata.WithPassphrase.Blob,
+					ID:        validID,
+					Name:      "new-key",
+					Encrypted: true,
+					Local:     true,
 				},
 			},
 		},
-	}
-
-	for _, tc := range testcases {
-		tc := tc
-		t.Run(tc.description, func(t *testing.T) {
-			t.Parallel()
+		{
+			description: "configure key to load at startup",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
 
-			h := newHarness()
-			defer h.Release()
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(AutoLoadButton, id)))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.AutoLoad
+				})
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:       validID,
+					Name:     "new-key",
+					AutoLoad: true,
+				},
+			},
+		},
+		{
+			description: "require confirmation for each use",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
 
-			jut.DoSync(func(ctx jsutil.AsyncContext) {
-				h.waitLoaded(ctx)
-				tc.sequence(ctx, h)
-				// Give some buffer for any pending async
-				// operations to settle.
-				time.Sleep(50 * time.Millisecond)
-			})
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(ConfirmButton, id)))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.Confirm
+				})
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:        validID,
+					Name:      "new-key",
+					Encrypted: true,
+					Confirm:   true,
+				},
+			},
+		},
+		{
+			description: "notify on each use",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
 
-			displayed := equalizeIds(h.UI.displayedKeys())
-			if diff := cmp.Diff(displayed, tc.wantDisplayed, displayedKeyCmp); diff != "" {
-				t.Errorf("%s: incorrect displayed keys; -got +want: %s", tc.description, diff)
-			}
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(NotifyButton, id)))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.Notify
+				})
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:        validID,
+					Name:      "new-key",
+					Encrypted: true,
+					Notify:    true,
+				},
+			},
+		},
+		{
+			description: "keep loaded after restart",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(PersistButton, id)))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.Persist
+				})
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:        validID,
+					Name:      "new-key",
+					Encrypted: true,
+					Persist:   true,
+				},
+			},
+		},
+		{
+			description: "configure key idle timeout",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				sel := h.dom.GetElement(buttonID(IdleTimeoutSelect, id))
+				dom.SetValue(sel, strconv.Itoa(keys.IdleTimeoutNever))
+				event := sel.Get("ownerDocument").Get("defaultView").Get("Event")
+				sel.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					k := h.UI.keyByName("new-key")
+					return k != nil && k.IdleTimeout == keys.IdleTimeoutNever
+				})
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:          validID,
+					Name:        "new-key",
+					Encrypted:   true,
+					IdleTimeout: keys.IdleTimeoutNever,
+				},
+			},
+		},
+		{
+			description: "display non-configured keys",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				// Load an additional key directly into the agent.
+				directLoadKey(h.agent, testdata.WithoutPassphrase.Private)
+
+				// Configure a key of our own.
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				// Load the key we configured.
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+				h.waitDialogOpen(ctx, h.passphraseDialog)
+				dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
+				dom.DoClick(h.passphraseOk)
+				h.waitDialogClosed(ctx, h.passphraseDialog)
+				h.waitKeyLoaded(ctx, "new-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:     keys.InvalidID,
+					Loaded: true,
+					Type:   testdata.WithoutPassphrase.Type,
+					Blob:   testdata.WithoutPassphrase.Blob,
+				},
+				{
+					ID:     validID,
+					Name:   "new-key",
+					Loaded: true,
+					Type:   testdata.WithPassphrase.Type,
+					Blob:   testdata.WithPassphrase.Blob,
+				},
+			},
+		},
+		{
+			description: "display loaded key that was previously-configured, then removed",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, testdata.WithPassphrase.Private)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				id := findKey(h.UI.displayedKeys(), "new-key")
+				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+				h.waitDialogOpen(ctx, h.passphraseDialog)
+				dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
+				dom.DoClick(h.passphraseOk)
+				h.waitDialogClosed(ctx, h.passphraseDialog)
+				h.waitKeyLoaded(ctx, "new-key")
+
+				dom.DoClick(h.dom.GetElement(buttonID(RemoveButton, id)))
+				h.waitDialogOpen(ctx, h.removeDialog)
+				dom.DoClick(h.removeYes)
+				h.waitDialogClosed(ctx, h.removeDialog)
+				h.waitKeyRemoved(ctx, "new-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:     keys.InvalidID,
+					Loaded: true,
+					Type:   testdata.WithPassphrase.Type,
+					Blob:   testdata.WithPassphrase.Blob,
+				},
+			},
+		},
+		{
+			description: "load key with certificate",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "cert-key")
+				dom.SetValue(h.addKey, testdata.ED25519WithCertificate.Private+"\n"+testdata.ED25519WithCertificate.Certificate)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+				h.waitKeyConfigured(ctx, "cert-key")
+
+				id := findKey(h.UI.displayedKeys(), "cert-key")
+				dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+				h.waitKeyLoaded(ctx, "cert-key")
+			},
+			wantDisplayed: []*displayedKey{
+				{
+					ID:     validID,
+					Name:   "cert-key",
+					Loaded: true,
+					Type:   testdata.ED25519WithCertificate.Type,
+					Blob:   testdata.ED25519WithCertificate.Blob,
+					Certificate: &keys.CertificateInfo{
+						Type:          "user",
+						KeyID:         "test-cert",
+						Principals:    []string{"alice", "bob"},
+						ValidAfter:    1577836800,
+						ValidBefore:   4102358400,
+						CAFingerprint: "SHA256:fQjt437JzDyDzK2iGXRN2zdV2PRbZrzkMeIS+1RJBSE",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				h.waitLoaded(ctx)
+				tc.sequence(ctx, h)
+				// Give some buffer for any pending async
+				// operations to settle.
+				time.Sleep(50 * time.Millisecond)
+			})
+
+			displayed := equalizeIds(h.UI.displayedKeys())
+			if diff := cmp.Diff(displayed, tc.wantDisplayed, displayedKeyCmp); diff != "" {
+				t.Errorf("%s: incorrect displayed keys; -got +want: %s", tc.description, diff)
+			}
 			err := dom.TextContent(h.UI.errorText)
 			if diff := cmp.Diff(err, tc.wantErr); diff != "" {
 				t.Errorf("%s: incorrect error; -got +want: %s", tc.description, diff)
@@ -641,3 +1117,3028 @@ func TestUserActions(t *testing.T) {
 		})
 	}
 }
+
+func TestPassphraseDialog(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "new-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+
+		dom.DoClick(h.dom.GetElement(buttonID(LoadButton, h.UI.keyByName("new-key").ID)))
+		h.waitPassphraseAttempt(ctx, 1)
+
+		// The passphrase field has focus, so the user can type
+		// immediately.
+		if !h.passphraseInput.Equal(h.passphraseInput.Get("ownerDocument").Get("activeElement")) {
+			t.Errorf("passphrase field does not have focus")
+		}
+
+		// The passphrase may be displayed to check for typos.
+		show := h.dom.GetElement("passphraseShow")
+		dom.DoClick(show)
+		mustPoll(ctx, func() bool { return h.passphraseInput.Get("type").String() == "text" })
+		dom.DoClick(show)
+		mustPoll(ctx, func() bool { return h.passphraseInput.Get("type").String() == "password" })
+
+		// The user is warned while Caps Lock is on.
+		capsLock := h.dom.GetElement("passphraseCapsLock")
+		keyboardEvent := h.passphraseInput.Get("ownerDocument").Get("defaultView").Get("KeyboardEvent")
+		init := js.Global().Get("Object").New()
+		init.Set("key", "A")
+		init.Set("modifierCapsLock", true)
+		h.passphraseInput.Call("dispatchEvent", keyboardEvent.New("keyup", init))
+		mustPoll(ctx, func() bool { return !capsLock.Get("hidden").Bool() })
+		init.Set("modifierCapsLock", false)
+		h.passphraseInput.Call("dispatchEvent", keyboardEvent.New("keyup", init))
+		mustPoll(ctx, func() bool { return capsLock.Get("hidden").Bool() })
+
+		// The passphrase is hidden again the next time it is requested.
+		dom.DoClick(show)
+		mustPoll(ctx, func() bool { return h.passphraseInput.Get("type").String() == "text" })
+		dom.DoClick(h.passphraseCancel)
+		h.waitDialogClosed(ctx, h.passphraseDialog)
+		if typ := h.passphraseInput.Get("type").String(); typ != "password" {
+			t.Errorf("passphrase still displayed after dialog closed: type %q", typ)
+		}
+	})
+}
+
+func TestAddResult(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		want        string
+	}{
+		{
+			description: "unencrypted key",
+			key:         testdata.ED25519WithoutPassphrase.Private,
+			want:        "Added ssh-ed25519 key new-key (256 bits).",
+		},
+		{
+			description: "encrypted OpenSSH key",
+			key:         testdata.ED25519WithPassphrase.Private,
+			want:        "Added ssh-ed25519 key new-key (256 bits, passphrase required to load).",
+		},
+		{
+			description: "encrypted legacy key",
+			key:         testdata.WithPassphrase.Private,
+			want:        "Added key new-key (passphrase required to load).",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				h.waitLoaded(ctx)
+				added := h.UI.nextCompletion(opAdd)
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+				dom.SetValue(h.addKey, tc.key)
+				dom.DoClick(h.addOk)
+				if !added(ctx, wait.Default.Timeout) {
+					t.Errorf("add failed to complete")
+					return
+				}
+
+				// The result is displayed by the time the
+				// operation completes.
+				addResult := h.dom.GetElement("addResult")
+				if diff := cmp.Diff(dom.TextContent(addResult), tc.want); diff != "" {
+					t.Errorf("incorrect add result; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestAddPKCS12(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		password    string
+		wantResult  string
+		wantErr     string
+	}{
+		{
+			description: "add key from archive",
+			password:    testdata.PKCS12RSA.Password,
+			wantResult:  "Added ssh-rsa key new-key (2048 bits, passphrase required to load).",
+		},
+		{
+			description: "fail on incorrect password",
+			password:    "incorrect",
+			wantErr:     "failed to add key: failed to read PKCS#12 archive: x509: decryption password incorrect",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			data, err := base64.StdEncoding.DecodeString(testdata.PKCS12RSA.Data)
+			if err != nil {
+				t.Fatalf("failed to decode archive: %v", err)
+			}
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				h.waitLoaded(ctx)
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				dom.SetValue(h.addName, "new-key")
+
+				// jsdom does not permit files to be selected;
+				// substitute a File-like object.
+				file := js.Global().Get("Object").New()
+				file.Set("name", "key.p12")
+				readFile := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+					arr := js.Global().Get("Uint8Array").New(len(data))
+					js.CopyBytesToJS(arr, data)
+					return js.Global().Get("Promise").Call("resolve", arr.Get("buffer"))
+				})
+				defer readFile.Release()
+				file.Set("arrayBuffer", readFile)
+				js.Global().Get("Object").Call("defineProperty", h.dom.GetElement("addFile"), "files", map[string]interface{}{
+					"value": []interface{}{file},
+				})
+				dom.SetValue(h.dom.GetElement("addFilePassword"), tc.password)
+				dom.DoClick(h.addOk)
+				h.waitDialogClosed(ctx, h.addDialog)
+
+				if tc.wantErr != "" {
+					errorText := h.dom.GetElement("errorMessage")
+					mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), tc.wantErr) })
+					return
+				}
+
+				h.waitKeyConfigured(ctx, "new-key")
+				addResult := h.dom.GetElement("addResult")
+				mustPoll(ctx, func() bool { return dom.TextContent(addResult) != "" })
+				if diff := cmp.Diff(dom.TextContent(addResult), tc.wantResult); diff != "" {
+					t.Errorf("incorrect add result; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestParseExtensionIDs(t *testing.T) {
+	t.Parallel()
+
+	const (
+		id1 = "abcdefghijklmnopabcdefghijklmnop"
+		id2 = "ponmlkjihgfedcbaponmlkjihgfedcba"
+	)
+
+	testcases := []struct {
+		description string
+		text        string
+		want        []string
+		wantErr     error
+	}{
+		{
+			description: "empty",
+			text:        " \n ",
+		},
+		{
+			description: "one per line",
+			text:        id1 + "\n" + id2 + "\n",
+			want:        []string{id1, id2},
+		},
+		{
+			description: "comma separated",
+			text:        id1 + ", " + id2,
+			want:        []string{id1, id2},
+		},
+		{
+			description: "duplicates removed",
+			text:        id1 + "\n" + id1,
+			want:        []string{id1},
+		},
+		{
+			description: "invalid ID",
+			text:        id1 + "\nnot-an-extension-id",
+			wantErr:     errInvalidExtensionID,
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := parseExtensionIDs(tc.text)
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("%s: incorrect IDs; -got +want: %s", tc.description, diff)
+		}
+		if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("%s: incorrect error; -got +want: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description  string
+		managed      map[string]js.Value
+		sequence     func(ctx jsutil.AsyncContext, h *testHarness)
+		wantSettings *settings.Settings
+		wantDisabled bool
+		// wantSignDisabled indicates if the repeated signature
+		// protection setting is disabled.
+		wantSignDisabled bool
+		// wantIdleDisabled indicates if the idle timeout setting is
+		// disabled.
+		wantIdleDisabled bool
+	}{
+		{
+			description:  "default settings",
+			sequence:     func(ctx jsutil.AsyncContext, h *testHarness) {},
+			wantSettings: &settings.Settings{},
+		},
+		{
+			description: "enable client approval",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.approveNewClients)
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.ApproveNewClients
+				})
+			},
+			wantSettings: &settings.Settings{ApproveNewClients: true},
+		},
+		{
+			description: "client approval enforced by policy",
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(true),
+			},
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				mustPoll(ctx, func() bool { return dom.Checked(h.approveNewClients) })
+			},
+			wantSettings: &settings.Settings{ApproveNewClients: true},
+			wantDisabled: true,
+		},
+		{
+			description: "enable repeated signature throttling",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.SetValue(h.repeatedSign, settings.RepeatedSignThrottle)
+				event := h.repeatedSign.Get("ownerDocument").Get("defaultView").Get("Event")
+				h.repeatedSign.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.RepeatedSignProtection == settings.RepeatedSignThrottle
+				})
+			},
+			wantSettings: &settings.Settings{RepeatedSignProtection: settings.RepeatedSignThrottle},
+		},
+		{
+			description: "repeated signature protection enforced by policy",
+			managed: map[string]js.Value{
+				"repeatedSignProtection": js.ValueOf(settings.RepeatedSignPrompt),
+			},
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				mustPoll(ctx, func() bool { return dom.Value(h.repeatedSign) == settings.RepeatedSignPrompt })
+			},
+			wantSettings:     &settings.Settings{RepeatedSignProtection: settings.RepeatedSignPrompt},
+			wantSignDisabled: true,
+		},
+		{
+			description: "set idle timeout",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.SetValue(h.idleTimeout, "60")
+				event := h.idleTimeout.Get("ownerDocument").Get("defaultView").Get("Event")
+				h.idleTimeout.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.IdleTimeoutMinutes == 60
+				})
+			},
+			wantSettings: &settings.Settings{IdleTimeoutMinutes: 60},
+		},
+		{
+			description: "idle timeout enforced by policy",
+			managed: map[string]js.Value{
+				"idleTimeoutMinutes": js.ValueOf(90),
+			},
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				// The value is not one of the predefined options.
+				mustPoll(ctx, func() bool { return dom.Value(h.idleTimeout) == "90" })
+			},
+			wantSettings:     &settings.Settings{IdleTimeoutMinutes: 90},
+			wantIdleDisabled: true,
+		},
+		{
+			description: "set connection idle timeout",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				sel := h.dom.GetElement("connectionIdleTimeout")
+				dom.SetValue(sel, "240")
+				event := sel.Get("ownerDocument").Get("defaultView").Get("Event")
+				sel.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.ConnectionIdleTimeoutMinutes == 240
+				})
+			},
+			wantSettings: &settings.Settings{ConnectionIdleTimeoutMinutes: 240},
+		},
+		{
+			description: "set export re-authentication window",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				sel := h.dom.GetElement("exportReauth")
+				dom.SetValue(sel, "15")
+				event := sel.Get("ownerDocument").Get("defaultView").Get("Event")
+				sel.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.ExportReauthMinutes == 15
+				})
+			},
+			wantSettings: &settings.Settings{ExportReauthMinutes: 15},
+		},
+		{
+			description: "set allowed extensions",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				input := h.dom.GetElement("allowedExtensions")
+				dom.SetValue(input, "abcdefghijklmnopabcdefghijklmnop\n\nponmlkjihgfedcbaponmlkjihgfedcba")
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && len(s.AllowedExtensions) == 2
+				})
+			},
+			wantSettings: &settings.Settings{AllowedExtensions: []string{
+				"abcdefghijklmnopabcdefghijklmnop",
+				"ponmlkjihgfedcbaponmlkjihgfedcba",
+			}},
+		},
+		{
+			description: "set relay",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				input := h.dom.GetElement("relayURL")
+				dom.SetValue(input, "WSS://Relay.Example.com/agent")
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.RelayURL == "wss://relay.example.com/agent"
+				})
+
+				input = h.dom.GetElement("relayToken")
+				dom.SetValue(input, "s3cret")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.RelayToken == "s3cret"
+				})
+
+				// The token is not displayed once stored.
+				mustPoll(ctx, func() bool {
+					return dom.Value(input) == "" && input.Get("placeholder").String() == i18n.Message("relayTokenPlaceholder")
+				})
+			},
+			wantSettings: &settings.Settings{
+				RelayURL:   "wss://relay.example.com/agent",
+				RelayToken: "s3cret",
+			},
+		},
+		{
+			description: "clear relay",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				if err := h.settings.Set(ctx, &settings.Settings{RelayURL: "wss://relay.example.com/agent"}); err != nil {
+					t.Errorf("failed to set relay URL: %v", err)
+					return
+				}
+				if err := h.settings.SetRelayToken(ctx, "s3cret"); err != nil {
+					t.Errorf("failed to set relay token: %v", err)
+					return
+				}
+				h.UI.updateSettings(ctx)
+
+				input := h.dom.GetElement("relayURL")
+				dom.SetValue(input, "")
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.RelayURL == "" && s.RelayToken == ""
+				})
+				mustPoll(ctx, func() bool {
+					return h.dom.GetElement("relayToken").Get("placeholder").String() == ""
+				})
+			},
+			wantSettings: &settings.Settings{},
+		},
+		{
+			description: "set dark theme",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				input := h.dom.GetElement("theme")
+				dom.SetValue(input, settings.ThemeDark)
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool { return h.dom.Theme() == dom.ThemeDark })
+			},
+			wantSettings: &settings.Settings{Theme: settings.ThemeDark},
+		},
+		{
+			description: "theme enforced by policy",
+			managed: map[string]js.Value{
+				"theme": js.ValueOf(settings.ThemeDark),
+			},
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				mustPoll(ctx, func() bool {
+					return h.dom.Theme() == dom.ThemeDark && h.dom.GetElement("theme").Get("disabled").Bool()
+				})
+			},
+			wantSettings: &settings.Settings{Theme: settings.ThemeDark},
+		},
+		{
+			description: "store new keys locally by default",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				dom.DoClick(h.dom.GetElement("storeKeysLocally"))
+				mustPoll(ctx, func() bool {
+					s, err := h.settings.Get(ctx)
+					return err == nil && s.StoreKeysLocally
+				})
+				mustPoll(ctx, func() bool { return h.UI.storeLocally })
+				dom.DoClick(h.addButton)
+				h.waitDialogOpen(ctx, h.addDialog)
+				if !dom.Checked(h.addLocal) {
+					t.Errorf("new key not stored locally by default")
+				}
+				dom.DoClick(h.addCancel)
+				h.waitDialogClosed(ctx, h.addDialog)
+			},
+			wantSettings: &settings.Settings{StoreKeysLocally: true},
+		},
+		{
+			description: "local key storage enforced by policy",
+			managed: map[string]js.Value{
+				"storeKeysLocally": js.ValueOf(true),
+			},
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				mustPoll(ctx, func() bool {
+					input := h.dom.GetElement("storeKeysLocally")
+					return dom.Checked(input) && input.Get("disabled").Bool()
+				})
+			},
+			wantSettings: &settings.Settings{StoreKeysLocally: true},
+		},
+		{
+			description: "insecure relay not stored",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				input := h.dom.GetElement("relayURL")
+				dom.SetValue(input, "ws://relay.example.com/agent")
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					return strings.Contains(dom.TextContent(h.dom.GetElement("errorMessage")), "invalid relay URL")
+				})
+			},
+			wantSettings: &settings.Settings{},
+		},
+		{
+			description: "invalid allowed extensions not stored",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				input := h.dom.GetElement("allowedExtensions")
+				dom.SetValue(input, "not-an-extension-id")
+				event := input.Get("ownerDocument").Get("defaultView").Get("Event")
+				input.Call("dispatchEvent", event.New("change"))
+				mustPoll(ctx, func() bool {
+					return strings.Contains(dom.TextContent(h.dom.GetElement("errorMessage")), "invalid extension ID")
+				})
+			},
+			wantSettings: &settings.Settings{},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				h.managed.SetPolicy(tc.managed)
+				h.UI.updateSettings(ctx)
+				h.waitLoaded(ctx)
+				tc.sequence(ctx, h)
+				// Give some buffer for any pending async
+				// operations to settle.
+				time.Sleep(50 * time.Millisecond)
+
+				got, err := h.settings.Get(ctx)
+				if err != nil {
+					t.Fatalf("failed to read settings: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.wantSettings); diff != "" {
+					t.Errorf("incorrect settings; -got +want: %s", diff)
+				}
+			})
+
+			if diff := cmp.Diff(dom.Checked(h.approveNewClients), tc.wantSettings.ApproveNewClients); diff != "" {
+				t.Errorf("incorrect checkbox state; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(h.approveNewClients.Get("disabled").Bool(), tc.wantDisabled); diff != "" {
+				t.Errorf("incorrect checkbox disabled state; -got +want: %s", diff)
+			}
+			wantSign := tc.wantSettings.RepeatedSignProtection
+			if wantSign == "" {
+				wantSign = settings.RepeatedSignOff
+			}
+			if diff := cmp.Diff(dom.Value(h.repeatedSign), wantSign); diff != "" {
+				t.Errorf("incorrect repeated signature protection; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(h.repeatedSign.Get("disabled").Bool(), tc.wantSignDisabled); diff != "" {
+				t.Errorf("incorrect repeated signature protection disabled state; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(dom.Value(h.idleTimeout), strconv.Itoa(tc.wantSettings.IdleTimeoutMinutes)); diff != "" {
+				t.Errorf("incorrect idle timeout; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(h.idleTimeout.Get("disabled").Bool(), tc.wantIdleDisabled); diff != "" {
+				t.Errorf("incorrect idle timeout disabled state; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCertificateWarning(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		description string
+		cert        *keys.CertificateInfo
+		want        string
+	}{
+		{
+			description: "no expiry",
+			cert:        &keys.CertificateInfo{},
+		},
+		{
+			description: "valid",
+			cert:        &keys.CertificateInfo{ValidBefore: now.Add(30 * 24 * time.Hour).Unix()},
+		},
+		{
+			description: "expires soon",
+			cert:        &keys.CertificateInfo{ValidBefore: now.Add(36 * time.Hour).Unix()},
+			want:        "Certificate expires in 36h0m0s",
+		},
+		{
+			description: "expired",
+			cert:        &keys.CertificateInfo{ValidBefore: now.Add(-time.Hour).Unix()},
+			want:        "Certificate expired",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(certificateWarning(tc.cert, now), tc.want); diff != "" {
+				t.Errorf("incorrect warning; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestViewerMode(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		cached      bool
+		wantKeys    []string
+		wantMessage string
+	}{
+		{
+			description: "display cached keys",
+			cached:      true,
+			wantKeys:    []string{"cached-key"},
+			wantMessage: "read-only view of keys",
+		},
+		{
+			description: "nothing cached",
+			wantMessage: "no keys have been cached",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if tc.cached {
+					if _, err := h.manager.Add(ctx, "cached-key", testdata.WithPassphrase.Private); err != nil {
+						t.Fatalf("failed to add key: %v", err)
+					}
+					h.UI.updateKeys(ctx)
+					h.waitKeyConfigured(ctx, "cached-key")
+				}
+
+				// Open another UI that cannot reach the manager,
+				// sharing the same cache.
+				viewerDom := dom.New(dt.NewDocForTesting(optionsHTMLData))
+				unreachable := mfakes.NewHub()
+				viewer := New(keys.NewClient(unreachable), unreachable, settings.NewClient(unreachable), h.clients, h.vault, h.cache, clock.Real, viewerDom)
+				defer viewer.Release()
+				viewer.updateKeys(ctx)
+				loadingText := viewerDom.GetElement("loadingMessage")
+				mustPoll(ctx, func() bool { return dom.TextContent(loadingText) == "" })
+
+				var names []string
+				for _, k := range viewer.displayedKeys() {
+					names = append(names, k.Name)
+					// Keys cannot be modified.
+					for _, kind := range []buttonKind{LoadButton, UnloadButton, RemoveButton, LocationButton, AutoLoadButton, RepinButton, IdleTimeoutSelect, ConfirmButton, NotifyButton, KeyringInput, PersistButton} {
+						if btn := viewerDom.GetElement(buttonID(kind, k.ID)); !btn.IsNull() {
+							t.Errorf("unexpected button %s for key %s", buttonID(kind, k.ID), k.Name)
+						}
+					}
+				}
+				if diff := cmp.Diff(names, tc.wantKeys); diff != "" {
+					t.Errorf("incorrect displayed keys; -got +want: %s", diff)
+				}
+				if msg := dom.TextContent(viewerDom.GetElement("viewerMessage")); !strings.Contains(msg, tc.wantMessage) {
+					t.Errorf("incorrect message: got %q, want substring %q", msg, tc.wantMessage)
+				}
+				if !viewerDom.GetElement("add").Get("disabled").Bool() {
+					t.Errorf("add button unexpectedly enabled")
+				}
+			})
+		})
+	}
+}
+
+func TestKeysChangedElsewhere(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mustPoll(ctx, func() bool { return h.UI.fresh })
+
+		// Change keys without using the UI (e.g., as if from another
+		// window), and announce the change.
+		if _, err := h.manager.Add(ctx, "other-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		keys.BroadcastChange(ctx, h.messaging)
+		h.waitKeyConfigured(ctx, "other-key")
+
+		if err := h.manager.Load(ctx, h.UI.keyByName("other-key").ID, ""); err != nil {
+			t.Fatalf("failed to load key: %v", err)
+		}
+		keys.BroadcastChange(ctx, h.messaging)
+		h.waitKeyLoaded(ctx, "other-key")
+	})
+}
+
+func TestWarmStart(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "cached-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "cached-key")
+		// Change the keys after the snapshot was taken.
+		if _, err := h.manager.Add(ctx, "new-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		// Open another UI sharing the same cache, and display the
+		// cached keys.
+		otherDom := dom.New(dt.NewDocForTesting(optionsHTMLData))
+		other := New(h.Client, h.messaging, settings.NewClient(h.messaging), h.clients, h.vault, h.cache, clock.Real, otherDom)
+		defer other.Release()
+		names := func() []string {
+			var result []string
+			for _, k := range other.displayedKeys() {
+				result = append(result, k.Name)
+			}
+			return result
+		}
+		loadButton := func() js.Value {
+			return otherDom.GetElement(buttonID(LoadButton, other.keyByName("cached-key").ID))
+		}
+		loadingText := otherDom.GetElement("loadingMessage")
+
+		// Wait for the initial display to complete, then simulate
+		// the manager not yet having responded.
+		mustPoll(ctx, func() bool { return other.fresh && dom.TextContent(loadingText) == "" })
+		other.fresh = false
+		other.showCached(ctx)
+		if diff := cmp.Diff(names(), []string{"cached-key"}); diff != "" {
+			t.Errorf("incorrect cached keys; -got +want: %s", diff)
+		}
+		if !loadButton().IsNull() {
+			t.Errorf("unexpected load button for cached key")
+		}
+		if diff := cmp.Diff(dom.TextContent(loadingText), "Refreshing keys..."); diff != "" {
+			t.Errorf("incorrect loading text; -got +want: %s", diff)
+		}
+
+		// Once refreshed, the current keys are displayed with controls.
+		other.updateKeys(ctx)
+		if diff := cmp.Diff(names(), []string{"cached-key", "new-key"}); diff != "" {
+			t.Errorf("incorrect refreshed keys; -got +want: %s", diff)
+		}
+		if loadButton().IsNull() {
+			t.Errorf("missing load button for refreshed key")
+		}
+		if diff := cmp.Diff(dom.TextContent(loadingText), ""); diff != "" {
+			t.Errorf("incorrect loading text; -got +want: %s", diff)
+		}
+
+		// The cached keys never replace refreshed keys.
+		other.showCached(ctx)
+		if diff := cmp.Diff(names(), []string{"cached-key", "new-key"}); diff != "" {
+			t.Errorf("incorrect keys after refresh; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestRestrictions(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description    string
+		managed        map[string]js.Value
+		wantButtons    []buttonKind
+		wantAddHidden  bool
+		wantRemoveFail bool
+	}{
+		{
+			description: "no restrictions",
+			wantButtons: []buttonKind{LoadButton, RemoveButton, LocationButton, UpdateButton, CertificateButton},
+		},
+		{
+			description: "removal disabled",
+			managed: map[string]js.Value{
+				"disableKeyRemove": js.ValueOf(true),
+			},
+			wantButtons:    []buttonKind{LoadButton, LocationButton, UpdateButton, CertificateButton},
+			wantRemoveFail: true,
+		},
+		{
+			description: "all restricted",
+			managed: map[string]js.Value{
+				"disableKeyAdd":            js.ValueOf(true),
+				"disableKeyRemove":         js.ValueOf(true),
+				"disableKeyLocationChange": js.ValueOf(true),
+			},
+			wantButtons:    []buttonKind{LoadButton},
+			wantAddHidden:  true,
+			wantRemoveFail: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				h.managed.SetPolicy(tc.managed)
+				if _, err := h.manager.Add(ctx, "good-key", testdata.WithPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				h.UI.updateKeys(ctx)
+				h.waitKeyConfigured(ctx, "good-key")
+				id := h.UI.keyByName("good-key").ID
+
+				// Only permitted operations are displayed.
+				var buttons []buttonKind
+				for _, kind := range []buttonKind{LoadButton, UnloadButton, RemoveButton, LocationButton, UpdateButton, CertificateButton} {
+					if !h.dom.GetElement(buttonID(kind, id)).IsNull() {
+						buttons = append(buttons, kind)
+					}
+				}
+				if diff := cmp.Diff(buttons, tc.wantButtons); diff != "" {
+					t.Errorf("incorrect buttons; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(h.addButton.Get("hidden").Bool(), tc.wantAddHidden); diff != "" {
+					t.Errorf("incorrect add button visibility; -got +want: %s", diff)
+				}
+
+				// The server rejects operations that are not
+				// permitted, even if the UI is bypassed.
+				err := h.Client.Remove(ctx, id)
+				if diff := cmp.Diff(err != nil, tc.wantRemoveFail); diff != "" {
+					t.Errorf("incorrect remove result %v; -got +want: %s", err, diff)
+				}
+			})
+		})
+	}
+}
+
+func TestAddQuotaWarning(t *testing.T) {
+	t.Parallel()
+
+	needed := keys.EstimateBytes("new-key", testdata.WithoutPassphrase.Private)
+	testcases := []struct {
+		description string
+		usage       *keys.StorageUsage
+		local       bool
+		want        string
+	}{
+		{
+			description: "usage unknown",
+		},
+		{
+			description: "fits in synced storage",
+			usage: &keys.StorageUsage{
+				Synced: &keys.AreaUsage{BytesInUse: 0, Quota: needed},
+				Local:  &keys.AreaUsage{},
+			},
+		},
+		{
+			description: "exceeds synced storage",
+			usage: &keys.StorageUsage{
+				Synced: &keys.AreaUsage{BytesInUse: 1, Quota: needed},
+				Local:  &keys.AreaUsage{},
+			},
+			want: "Store it only on this device instead.",
+		},
+		{
+			description: "stored locally",
+			usage: &keys.StorageUsage{
+				Synced: &keys.AreaUsage{BytesInUse: 1, Quota: needed},
+				Local:  &keys.AreaUsage{BytesInUse: 1, Quota: 10 * needed},
+			},
+			local: true,
+		},
+		{
+			description: "exceeds local storage",
+			usage: &keys.StorageUsage{
+				Synced: &keys.AreaUsage{},
+				Local:  &keys.AreaUsage{BytesInUse: needed, Quota: needed},
+			},
+			local: true,
+			want:  "of storage on this device remains.",
+		},
+		{
+			description: "quota unknown",
+			usage: &keys.StorageUsage{
+				Synced: &keys.AreaUsage{BytesInUse: 1000000},
+				Local:  &keys.AreaUsage{},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		got := addQuotaWarning(tc.usage, "new-key", testdata.WithoutPassphrase.Private, tc.local)
+		if tc.want == "" && got != "" {
+			t.Errorf("%s: unexpected warning: %q", tc.description, got)
+		}
+		if !strings.Contains(got, tc.want) {
+			t.Errorf("%s: incorrect warning: got %q, want substring %q", tc.description, got, tc.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	for n, want := range map[int]string{
+		100:             "100 bytes",
+		1536:            "1.5 KB",
+		102400:          "100.0 KB",
+		5 * 1024 * 1024: "5.0 MB",
+	} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("incorrect formatBytes(%d): got %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestStatusRows(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		status      *keys.Status
+		want        [][2]string
+	}{
+		{
+			description: "healthy",
+			status: &keys.Status{
+				Uptime:      3725,
+				Connections: 1,
+				LoadedKeys:  2,
+				Storage:     []*keys.StorageStatus{{Area: "sync"}},
+			},
+			want: [][2]string{
+				{"Running for", "1h2m5s"},
+				{"Connected clients", "1"},
+				{"Keys in agent", "2"},
+				{"Last error", "None"},
+				{"Storage (sync)", "OK"},
+			},
+		},
+		{
+			description: "errors",
+			status: &keys.Status{
+				LastError: "failed to load key",
+				Storage:   []*keys.StorageStatus{{Area: "sync", Err: "sync unavailable"}},
+			},
+			want: [][2]string{
+				{"Running for", "0s"},
+				{"Connected clients", "0"},
+				{"Keys in agent", "0"},
+				{"Last error", "failed to load key"},
+				{"Storage (sync)", "sync unavailable"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(statusRows(tc.status), tc.want); diff != "" {
+				t.Errorf("incorrect rows; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatLastUsed(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		lastUsed    int64
+		want        string
+	}{
+		{
+			description: "never used",
+			want:        "Never",
+		},
+		{
+			description: "used",
+			lastUsed:    1700000000,
+			want:        "2023-11-14",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(formatLastUsed(tc.lastUsed), tc.want); diff != "" {
+				t.Errorf("incorrect display; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLogRows(t *testing.T) {
+	t.Parallel()
+
+	entries := []*keys.LogEntry{
+		{Time: 1700000000123, Level: "INFO", Message: "started"},
+		{Time: 1700000001456, Level: "ERROR", Message: "failed to load key"},
+	}
+	want := [][3]string{
+		{"2023-11-14T22:13:21.456Z", "ERROR", "failed to load key"},
+		{"2023-11-14T22:13:20.123Z", "INFO", "started"},
+	}
+	if diff := cmp.Diff(logRows(entries), want); diff != "" {
+		t.Errorf("incorrect rows; -got +want: %s", diff)
+	}
+	if diff := cmp.Diff(logRows(nil), [][3]string(nil)); diff != "" {
+		t.Errorf("incorrect rows for no entries; -got +want: %s", diff)
+	}
+}
+
+func TestErrorAdvice(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		err         error
+		want        string
+	}{
+		{
+			description: "quota",
+			err:         fmt.Errorf("failed to add key: %w", fmt.Errorf("%w: QUOTA_BYTES quota exceeded", storage.ErrQuota)),
+			want:        "Browser storage is full",
+		},
+		{
+			description: "unavailable",
+			err:         fmt.Errorf("failed to read keys: %w", storage.ErrUnavailable),
+			want:        "Browser storage is unavailable",
+		},
+		{
+			description: "corrupted",
+			err:         fmt.Errorf("failed to read keys: %w", storage.ErrCorrupted),
+			want:        "Stored data could not be read",
+		},
+		{
+			description: "transient",
+			err:         fmt.Errorf("failed to store loaded key to session: %w", storage.ErrTransient),
+			want:        "temporary problem",
+		},
+		{
+			description: "incorrect passphrase",
+			err:         fmt.Errorf("failed to load key: %w", fmt.Errorf("failed to decrypt key: %w", keys.ErrIncorrectPassphrase)),
+			want:        "Check that the passphrase is correct",
+		},
+		{
+			description: "key not found",
+			err:         fmt.Errorf("failed to load key: %w", fmt.Errorf("%w: failed to find key with ID id-0", keys.ErrKeyNotFound)),
+			want:        "removed in another window",
+		},
+		{
+			description: "not permitted",
+			err:         fmt.Errorf("failed to add key: %w", fmt.Errorf("%w: Add", keys.ErrNotPermitted)),
+			want:        "disabled by your administrator",
+		},
+		{
+			description: "unsupported key algorithm",
+			err:         fmt.Errorf("failed to load key: %w", fmt.Errorf("%w: DSA keys are insecure", keys.ErrUnsupportedAlgorithm)),
+			want:        "Generate a new key",
+		},
+		{
+			description: "agent locked",
+			err:         fmt.Errorf("failed to load key: %w", fmt.Errorf("%w: unlock it to load keys", keys.ErrAgentLocked)),
+			want:        "ssh-add -X",
+		},
+		{
+			description: "incompatible version",
+			err:         fmt.Errorf("failed to send message: %w", fmt.Errorf("%w: client uses version 0", keys.ErrIncompatibleVersion)),
+			want:        "Reload the page",
+		},
+		{
+			description: "uncategorized",
+			err:         fmt.Errorf("invalid passphrase"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got := errorAdvice(tc.err)
+			if tc.want == "" {
+				if got != "" {
+					t.Errorf("unexpected advice: %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tc.want) {
+				t.Errorf("incorrect advice: got %q, want substring %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCopyDebugInfo(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		debugInfo := h.dom.GetElement("debugInfo")
+		dom.DoClick(h.dom.GetElement("copyDebugInfo"))
+		mustPoll(ctx, func() bool { return dom.TextContent(debugInfo) != "" })
+
+		var r debugreport.Report
+		if err := vert.ValueOf(jsutil.FromJSON(dom.TextContent(debugInfo))).AssignTo(&r); err != nil {
+			t.Fatalf("failed to parse debug info: %v", err)
+		}
+		var names []string
+		for _, k := range r.Keys {
+			names = append(names, k.Name)
+		}
+		if diff := cmp.Diff(names, []string{"good-key"}); diff != "" {
+			t.Errorf("incorrect keys; -got +want: %s", diff)
+		}
+		if strings.Contains(dom.TextContent(debugInfo), "PRIVATE KEY") {
+			t.Errorf("debug info unexpectedly contains private key")
+		}
+		if debugInfo.Get("hidden").Bool() {
+			t.Errorf("debug info unexpectedly hidden")
+		}
+	})
+}
+
+func TestCompareKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		if _, err := h.manager.Add(ctx, "locked-key", testdata.WithPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		// Compare with another agent in which one key is loaded.
+		result := h.dom.GetElement("compareResult")
+		dom.SetValue(h.dom.GetElement("externalKeys"), fmt.Sprintf("ssh-rsa %s user@host\n", testdata.WithoutPassphrase.Blob))
+		dom.DoClick(h.dom.GetElement("compareKeys"))
+		mustPoll(ctx, func() bool { return dom.TextContent(result) != "" })
+		for _, want := range []string{"good-key: loaded", "locked-key: unknown"} {
+			if !strings.Contains(dom.TextContent(result), want) {
+				t.Errorf("incorrect comparison: got %q, want substring %q", dom.TextContent(result), want)
+			}
+		}
+
+		// No keys are loaded in our agent.
+		fingerprints := h.dom.GetElement("fingerprints")
+		dom.DoClick(h.dom.GetElement("listFingerprints"))
+		mustPoll(ctx, func() bool { return dom.TextContent(fingerprints) != "" })
+		if diff := cmp.Diff(dom.TextContent(fingerprints), "The agent has no identities."); diff != "" {
+			t.Errorf("incorrect fingerprints; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestMalformedKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		pane := h.dom.GetElement("attentionPane")
+		list := h.dom.GetElement("attentionList")
+
+		// No malformed keys; the list is hidden.
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		if !pane.Get("hidden").Bool() {
+			t.Errorf("attention pane displayed without malformed keys")
+		}
+
+		// A key written by an incompatible version is displayed.
+		if err := h.storage.Set(ctx, map[string]js.Value{
+			"key.1": js.ValueOf(map[string]any{"name": "old-key"}),
+		}); err != nil {
+			t.Fatalf("failed to write key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		mustPoll(ctx, func() bool { return !pane.Get("hidden").Bool() })
+		if want := "old-key: invalid value: malformed key: missing ID"; !strings.Contains(dom.TextContent(list), want) {
+			t.Errorf("incorrect malformed keys: got %q, want substring %q", dom.TextContent(list), want)
+		}
+		if h.UI.keyByName("old-key") != nil {
+			t.Errorf("malformed key displayed as configured")
+		}
+
+		// Discard the key.
+		dom.DoClick(h.dom.GetElement("discard-sync-1"))
+		mustPoll(ctx, func() bool { return pane.Get("hidden").Bool() })
+		data, err := h.storage.Get(ctx)
+		if err != nil {
+			t.Fatalf("failed to read storage: %v", err)
+		}
+		if len(data) != 0 {
+			t.Errorf("malformed key not discarded: %v", data)
+		}
+	})
+}
+
+func TestVerifySetup(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		if errs := h.UI.VerifySetup(ctx); len(errs) != 0 {
+			t.Errorf("unexpected failures: %v", errs)
+		}
+
+		// A key written by an incompatible version is reported.
+		if err := h.storage.Set(ctx, map[string]js.Value{
+			"key.1": js.ValueOf(map[string]any{"name": "old-key"}),
+		}); err != nil {
+			t.Fatalf("failed to write key: %v", err)
+		}
+		result := h.dom.GetElement("verifyResult")
+		dom.DoClick(h.dom.GetElement("verifySetup"))
+		mustPoll(ctx, func() bool { return dom.TextContent(result) != "" })
+		for _, want := range []string{"messaging round trip: OK", "stored keys readable: FAILED"} {
+			if !strings.Contains(dom.TextContent(result), want) {
+				t.Errorf("incorrect results: got %q, want substring %q", dom.TextContent(result), want)
+			}
+		}
+
+		// Nothing was modified.
+		configured, err := h.manager.Configured(ctx)
+		if err != nil {
+			t.Fatalf("failed to get configured keys: %v", err)
+		}
+		if len(configured) != 1 {
+			t.Errorf("incorrect number of configured keys: got %d, want 1", len(configured))
+		}
+	})
+}
+
+func TestRunDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		result := h.dom.GetElement("diagnosticsResult")
+		dom.DoClick(h.dom.GetElement("runDiagnostics"))
+		mustPoll(ctx, func() bool { return dom.TextContent(result) != "" })
+		for _, want := range []string{"agent round trip: OK", "agent port round trip: OK"} {
+			if !strings.Contains(dom.TextContent(result), want) {
+				t.Errorf("incorrect results: got %q, want substring %q", dom.TextContent(result), want)
+			}
+		}
+	})
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "changed-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		// Replace the key material behind the manager's back.
+		data, err := h.storage.Get(ctx)
+		if err != nil {
+			t.Fatalf("failed to read storage: %v", err)
+		}
+		for k, v := range data {
+			if v.Get("name").String() == "changed-key" {
+				v.Set("pemPrivateKey", testdata.WithPassphrase.Private)
+				if err := h.storage.Set(ctx, map[string]js.Value{k: v}); err != nil {
+					t.Fatalf("failed to write key: %v", err)
+				}
+			}
+		}
+
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		k := h.UI.keyByName("changed-key")
+		if k == nil || !k.ChecksumMismatch {
+			t.Fatalf("changed key not reported: %+v", k)
+		}
+		if got := dom.TextContent(h.dom.GetElement("keysData")); !strings.Contains(got, i18n.Message(checksumWarning)) {
+			t.Errorf("warning not displayed: got %q, want substring %q", got, i18n.Message(checksumWarning))
+		}
+
+		// Trust the changed key.
+		dom.DoClick(h.dom.GetElement(buttonID(RepinButton, k.ID)))
+		mustPoll(ctx, func() bool {
+			k := h.UI.keyByName("changed-key")
+			return k != nil && !k.ChecksumMismatch
+		})
+		if btn := h.dom.GetElement(buttonID(RepinButton, k.ID)); !btn.IsNull() {
+			t.Errorf("unexpected button %s after trusting key", buttonID(RepinButton, k.ID))
+		}
+	})
+}
+
+func TestResolveConflict(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		// As if the same name were used on two devices.
+		for _, pem := range []string{testdata.WithoutPassphrase.Private, testdata.ED25519WithoutPassphrase.Private} {
+			if _, err := h.manager.Add(ctx, "shared-name", pem); err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+		}
+
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		conflicting := h.UI.conflictingKeys(findKey(h.UI.displayedKeys(), "shared-name"))
+		if len(conflicting) != 2 || !conflicting[0].Conflict || !conflicting[1].Conflict {
+			t.Fatalf("conflict not reported: %+v", conflicting)
+		}
+		if got := dom.TextContent(h.dom.GetElement("keysData")); !strings.Contains(got, i18n.Message(conflictWarning)) {
+			t.Errorf("warning not displayed: got %q, want substring %q", got, i18n.Message(conflictWarning))
+		}
+
+		// Keep the second key.
+		keep := conflicting[1].ID
+		dialog := h.dom.GetElement("conflictDialog")
+		dom.DoClick(h.dom.GetElement(buttonID(ResolveButton, conflicting[0].ID)))
+		h.waitDialogOpen(ctx, dialog)
+		dom.SetValue(h.dom.GetElement("conflictKeep"), string(keep))
+		dom.DoClick(h.dom.GetElement("conflictOk"))
+		h.waitDialogClosed(ctx, dialog)
+		mustPoll(ctx, func() bool { return len(h.UI.conflictingKeys(keep)) == 1 })
+		if k := h.UI.keyByID(keep); k == nil || k.Conflict {
+			t.Errorf("incorrect key kept: %+v", k)
+		}
+	})
+}
+
+func TestExportPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	// jsdom does not support object URLs; capture the exported data
+	// instead.
+	url := h.addButton.Get("ownerDocument").Get("defaultView").Get("URL")
+	var exported []js.Value
+	createObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		exported = append(exported, args[0])
+		return "blob:export"
+	})
+	defer createObjectURL.Release()
+	revokeObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil })
+	defer revokeObjectURL.Release()
+	url.Set("createObjectURL", createObjectURL)
+	url.Set("revokeObjectURL", revokeObjectURL)
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		readExport := func() []*keys.ExportedKey {
+			t.Helper()
+			if len(exported) == 0 {
+				t.Fatalf("nothing exported")
+			}
+			text, err := jsutil.AsPromise(exported[len(exported)-1].Call("text")).Await(ctx)
+			if err != nil {
+				t.Fatalf("failed to read exported data: %v", err)
+			}
+			var result []*keys.ExportedKey
+			if err := json.Unmarshal([]byte(text.String()), &result); err != nil {
+				t.Fatalf("failed to parse exported data: %v", err)
+			}
+			return result
+		}
+		names := func(exported []*keys.ExportedKey) []string {
+			var result []string
+			for _, e := range exported {
+				result = append(result, e.Name)
+			}
+			return result
+		}
+
+		for name, key := range map[string]string{
+			"good-key":   testdata.WithoutPassphrase.Private,
+			"other-key":  testdata.ED25519WithoutPassphrase.Private,
+			"locked-key": testdata.WithPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, key); err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+		}
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "locked-key")
+
+		// Export all keys. The public key of the encrypted key is not
+		// known.
+		dom.DoClick(h.dom.GetElement("exportKeys"))
+		mustPoll(ctx, func() bool { return len(exported) == 1 })
+		if diff := cmp.Diff(names(readExport()), []string{"good-key", "other-key"}, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+			t.Errorf("incorrect exported keys; -got +want: %s", diff)
+		}
+		if got := dom.TextContent(h.dom.GetElement("errorMessage")); !strings.Contains(got, "locked-key") {
+			t.Errorf("skipped key not reported: got %q", got)
+		}
+
+		// Export a single key.
+		id := h.UI.keyByName("other-key").ID
+		dom.DoClick(h.dom.GetElement(buttonID(ExportButton, id)))
+		mustPoll(ctx, func() bool { return len(exported) == 2 })
+		got := readExport()
+		if diff := cmp.Diff(names(got), []string{"other-key"}); diff != "" {
+			t.Errorf("incorrect exported keys; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(got[0].Type, ssh.KeyAlgoED25519); diff != "" {
+			t.Errorf("incorrect key type; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, key := range map[string]string{
+			"good-key":    testdata.WithoutPassphrase.Private,
+			"openssh-key": testdata.OpenSSHFormat.Private,
+			"locked-key":  testdata.WithPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, key); err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+		}
+		directLoadKey(h.agent, testdata.ED25519WithoutPassphrase.Private)
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "locked-key")
+
+		// The public key of an encrypted OpenSSH key is known without
+		// the passphrase, but not that of an encrypted PEM key.
+		for _, tc := range []struct {
+			name            string
+			want            string
+			wantFingerprint string
+		}{
+			{
+				name:            "good-key",
+				want:            fmt.Sprintf("%s %s good-key", testdata.WithoutPassphrase.Type, testdata.WithoutPassphrase.Blob),
+				wantFingerprint: fingerprint(testdata.WithoutPassphrase.Blob),
+			},
+			{
+				name:            "openssh-key",
+				want:            fmt.Sprintf("%s %s openssh-key", testdata.OpenSSHFormat.Type, testdata.OpenSSHFormat.Blob),
+				wantFingerprint: fingerprint(testdata.OpenSSHFormat.Blob),
+			},
+			{
+				name: "locked-key",
+			},
+		} {
+			k := h.UI.keyByName(tc.name)
+			if diff := cmp.Diff(k.AuthorizedKey, tc.want); diff != "" {
+				t.Errorf("%s: incorrect authorized key; -got +want: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(k.Fingerprint, tc.wantFingerprint); diff != "" {
+				t.Errorf("%s: incorrect fingerprint; -got +want: %s", tc.name, diff)
+			}
+			if got, want := h.dom.GetElement(buttonID(CopyButton, k.ID)).IsNull(), tc.want == ""; got != want {
+				t.Errorf("%s: copy button missing=%t, want %t", tc.name, got, want)
+			}
+		}
+
+		// Keys loaded by other means retain their comment.
+		k := h.UI.keyByName("")
+		want := fmt.Sprintf("%s %s", testdata.ED25519WithoutPassphrase.Type, testdata.ED25519WithoutPassphrase.Blob)
+		if diff := cmp.Diff(k.AuthorizedKey, want); diff != "" {
+			t.Errorf("incorrect authorized key for non-configured key; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(k.Fingerprint, fingerprint(testdata.ED25519WithoutPassphrase.Blob)); diff != "" {
+			t.Errorf("incorrect fingerprint for non-configured key; -got +want: %s", diff)
+		}
+
+		// Loading a key keeps its name as the comment.
+		dom.DoClick(h.dom.GetElement(buttonID(LoadButton, h.UI.keyByName("good-key").ID)))
+		mustPoll(ctx, func() bool { return h.UI.keyByName("good-key").Loaded })
+		want = fmt.Sprintf("%s %s good-key", testdata.WithoutPassphrase.Type, testdata.WithoutPassphrase.Blob)
+		if diff := cmp.Diff(h.UI.keyByName("good-key").AuthorizedKey, want); diff != "" {
+			t.Errorf("incorrect authorized key after load; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestExportKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	// jsdom does not support object URLs; capture the exported data
+	// instead.
+	url := h.addButton.Get("ownerDocument").Get("defaultView").Get("URL")
+	var exported []js.Value
+	createObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		exported = append(exported, args[0])
+		return "blob:export"
+	})
+	defer createObjectURL.Release()
+	revokeObjectURL := js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil })
+	defer revokeObjectURL.Release()
+	url.Set("createObjectURL", createObjectURL)
+	url.Set("revokeObjectURL", revokeObjectURL)
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		readExport := func() *keys.BackupBundle {
+			t.Helper()
+			text, err := jsutil.AsPromise(exported[len(exported)-1].Call("text")).Await(ctx)
+			if err != nil {
+				t.Fatalf("failed to read exported data: %v", err)
+			}
+			var result keys.BackupBundle
+			if err := json.Unmarshal([]byte(text.String()), &result); err != nil {
+				t.Fatalf("failed to parse exported data: %v", err)
+			}
+			return &result
+		}
+
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		// By default, private keys are excluded.
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		mustPoll(ctx, func() bool { return len(exported) == 1 })
+		b := readExport()
+		if diff := cmp.Diff(len(b.Keys), 1); diff != "" {
+			t.Fatalf("incorrect number of exported keys; -got +want: %s", diff)
+		}
+		if b.Keys[0].Name != "good-key" || b.Keys[0].PrivateKey != "" {
+			t.Errorf("incorrect exported key: got name %q, private key %q", b.Keys[0].Name, b.Keys[0].PrivateKey)
+		}
+
+		// Without a master password, private keys are exported only once
+		// the user confirms.
+		dom.SetChecked(h.dom.GetElement("exportPrivate"), true)
+		confirmDialog := h.dom.GetElement("exportPrivateDialog")
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		h.waitDialogOpen(ctx, confirmDialog)
+		dom.DoClick(h.dom.GetElement("exportPrivateNo"))
+		h.waitDialogClosed(ctx, confirmDialog)
+		if diff := cmp.Diff(len(exported), 1); diff != "" {
+			t.Errorf("keys exported without confirmation; -got +want: %s", diff)
+		}
+
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		h.waitDialogOpen(ctx, confirmDialog)
+		dom.DoClick(h.dom.GetElement("exportPrivateYes"))
+		h.waitDialogClosed(ctx, confirmDialog)
+		mustPoll(ctx, func() bool { return len(exported) == 2 })
+		if diff := cmp.Diff(readExport().Keys[0].PrivateKey, testdata.WithoutPassphrase.Private); diff != "" {
+			t.Errorf("incorrect private key; -got +want: %s", diff)
+		}
+
+		// Once a master password is set, it is required.
+		if err := h.vault.Setup(ctx, "master"); err != nil {
+			t.Errorf("failed to set up passphrase cache: %v", err)
+			return
+		}
+
+		vaultDialog := h.dom.GetElement("vaultDialog")
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		h.waitDialogOpen(ctx, vaultDialog)
+		dom.SetValue(h.dom.GetElement("vaultCurrent"), "wrong")
+		dom.DoClick(h.dom.GetElement("vaultOk"))
+		h.waitDialogClosed(ctx, vaultDialog)
+		errorText := h.dom.GetElement("errorMessage")
+		mustPoll(ctx, func() bool {
+			return strings.Contains(dom.TextContent(errorText), vault.ErrWrongPassword.Error())
+		})
+		if diff := cmp.Diff(len(exported), 2); diff != "" {
+			t.Errorf("keys exported with wrong password; -got +want: %s", diff)
+		}
+
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		h.waitDialogOpen(ctx, vaultDialog)
+		dom.SetValue(h.dom.GetElement("vaultCurrent"), "master")
+		dom.DoClick(h.dom.GetElement("vaultOk"))
+		h.waitDialogClosed(ctx, vaultDialog)
+		mustPoll(ctx, func() bool { return len(exported) == 3 })
+		if diff := cmp.Diff(readExport().Keys[0].PrivateKey, testdata.WithoutPassphrase.Private); diff != "" {
+			t.Errorf("incorrect private key; -got +want: %s", diff)
+		}
+
+		// Within the re-authentication window, the master password is
+		// not required again.
+		if err := h.settings.Set(ctx, &settings.Settings{ExportReauthMinutes: 15}); err != nil {
+			t.Errorf("failed to set export re-authentication window: %v", err)
+			return
+		}
+		dom.DoClick(h.dom.GetElement("exportBundle"))
+		mustPoll(ctx, func() bool { return len(exported) == 4 })
+		if vaultDialog.Get("open").Bool() {
+			t.Errorf("master password requested within re-authentication window")
+		}
+	})
+}
+
+func TestImportKeys(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		onConflict  string
+		wantKeys    []string
+		wantResult  string
+	}{
+		{
+			description: "rename conflicting key",
+			onConflict:  keys.ImportRename,
+			wantKeys:    []string{"imported-key", "new-key", "new-key (2)"},
+			wantResult:  "Imported: new-key (2), imported-key.",
+		},
+		{
+			description: "skip conflicting key",
+			onConflict:  keys.ImportSkip,
+			wantKeys:    []string{"imported-key", "new-key"},
+			wantResult:  "Imported: imported-key. Not imported (already configured, or private key not included): new-key.",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if _, err := h.manager.Add(ctx, "new-key", testdata.WithoutPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				h.UI.updateKeys(ctx)
+				h.waitKeyConfigured(ctx, "new-key")
+
+				bundle, err := json.Marshal(&keys.BackupBundle{
+					Version: 1,
+					Keys: []*keys.BackupKey{
+						{Name: "new-key", PrivateKey: testdata.WithPassphrase.Private},
+						{Name: "imported-key", PrivateKey: testdata.ED25519WithPassphrase.Private},
+					},
+				})
+				if err != nil {
+					t.Fatalf("failed to encode bundle: %v", err)
+				}
+
+				importDialog := h.dom.GetElement("importDialog")
+				dom.DoClick(h.dom.GetElement("importBundle"))
+				h.waitDialogOpen(ctx, importDialog)
+
+				// jsdom does not permit files to be selected;
+				// substitute a File-like object.
+				file := js.Global().Get("Object").New()
+				file.Set("name", "keys.json")
+				readFile := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+					arr := js.Global().Get("Uint8Array").New(len(bundle))
+					js.CopyBytesToJS(arr, bundle)
+					return js.Global().Get("Promise").Call("resolve", arr.Get("buffer"))
+				})
+				defer readFile.Release()
+				file.Set("arrayBuffer", readFile)
+				js.Global().Get("Object").Call("defineProperty", h.dom.GetElement("importFile"), "files", map[string]interface{}{
+					"value": []interface{}{file},
+				})
+				dom.SetValue(h.dom.GetElement("importConflict"), tc.onConflict)
+				dom.DoClick(h.dom.GetElement("importOk"))
+				h.waitDialogClosed(ctx, importDialog)
+
+				importResult := h.dom.GetElement("importResult")
+				mustPoll(ctx, func() bool { return dom.TextContent(importResult) != "" })
+				if diff := cmp.Diff(dom.TextContent(importResult), tc.wantResult); diff != "" {
+					t.Errorf("incorrect result; -got +want: %s", diff)
+				}
+				var names []string
+				for _, k := range h.UI.displayedKeys() {
+					names = append(names, k.Name)
+				}
+				if diff := cmp.Diff(names, tc.wantKeys); diff != "" {
+					t.Errorf("incorrect keys; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestUpdateKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description     string
+		privateKey      string
+		cancel          bool
+		wantFingerprint string
+		wantErr         string
+	}{
+		{
+			description:     "replace key",
+			privateKey:      testdata.ED25519WithoutPassphrase.Private,
+			wantFingerprint: fingerprint(testdata.ED25519WithoutPassphrase.Blob),
+		},
+		{
+			description:     "cancelled by user",
+			privateKey:      testdata.ED25519WithoutPassphrase.Private,
+			cancel:          true,
+			wantFingerprint: fingerprint(testdata.WithoutPassphrase.Blob),
+		},
+		{
+			description:     "invalid key",
+			privateKey:      "bogus-key",
+			wantFingerprint: fingerprint(testdata.WithoutPassphrase.Blob),
+			wantErr:         "failed to replace key",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				h.UI.updateKeys(ctx)
+				h.waitKeyConfigured(ctx, "good-key")
+				id := h.UI.keyByName("good-key").ID
+
+				updateDialog := h.dom.GetElement("updateDialog")
+				dom.DoClick(h.dom.GetElement(buttonID(UpdateButton, id)))
+				h.waitDialogOpen(ctx, updateDialog)
+				if diff := cmp.Diff(dom.TextContent(h.dom.GetElement("updateName")), "good-key"); diff != "" {
+					t.Errorf("incorrect key name; -got +want: %s", diff)
+				}
+				dom.SetValue(h.dom.GetElement("updateKey"), tc.privateKey)
+				if tc.cancel {
+					dom.DoClick(h.dom.GetElement("updateCancel"))
+				} else {
+					dom.DoClick(h.dom.GetElement("updateOk"))
+				}
+				h.waitDialogClosed(ctx, updateDialog)
+
+				errorText := h.dom.GetElement("errorMessage")
+				if tc.wantErr != "" {
+					mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), tc.wantErr) })
+				} else {
+					mustPoll(ctx, func() bool {
+						k := h.UI.keyByName("good-key")
+						return k != nil && k.Fingerprint == tc.wantFingerprint
+					})
+				}
+				k := h.UI.keyByName("good-key")
+				if diff := cmp.Diff(k.ID, id); diff != "" {
+					t.Errorf("incorrect ID; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(k.Fingerprint, tc.wantFingerprint); diff != "" {
+					t.Errorf("incorrect fingerprint; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestSetCertificate(t *testing.T) {
+	t.Parallel()
+
+	certKey := testdata.ED25519WithCertificate
+
+	testcases := []struct {
+		description string
+		certificate string
+		cancel      bool
+		wantCert    bool
+		wantErr     string
+	}{
+		{
+			description: "attach certificate",
+			certificate: certKey.Certificate,
+			wantCert:    true,
+		},
+		{
+			description: "cancelled by user",
+			certificate: certKey.Certificate,
+			cancel:      true,
+		},
+		{
+			description: "invalid certificate",
+			certificate: "bogus-certificate",
+			wantErr:     "failed to set certificate",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if _, err := h.manager.Add(ctx, "cert-key", certKey.Private); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				h.UI.updateKeys(ctx)
+				h.waitKeyConfigured(ctx, "cert-key")
+				id := h.UI.keyByName("cert-key").ID
+
+				certificateDialog := h.dom.GetElement("certificateDialog")
+				dom.DoClick(h.dom.GetElement(buttonID(CertificateButton, id)))
+				h.waitDialogOpen(ctx, certificateDialog)
+				if diff := cmp.Diff(dom.TextContent(h.dom.GetElement("certificateName")), "cert-key"); diff != "" {
+					t.Errorf("incorrect key name; -got +want: %s", diff)
+				}
+				dom.SetValue(h.dom.GetElement("certificate"), tc.certificate)
+				if tc.cancel {
+					dom.DoClick(h.dom.GetElement("certificateCancel"))
+				} else {
+					dom.DoClick(h.dom.GetElement("certificateOk"))
+				}
+				h.waitDialogClosed(ctx, certificateDialog)
+
+				errorText := h.dom.GetElement("errorMessage")
+				if tc.wantErr != "" {
+					mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), tc.wantErr) })
+				} else if tc.wantCert {
+					mustPoll(ctx, func() bool {
+						k := h.UI.keyByName("cert-key")
+						return k != nil && k.Certificate != nil
+					})
+				}
+				if diff := cmp.Diff(h.UI.keyByName("cert-key").Certificate != nil, tc.wantCert); diff != "" {
+					t.Errorf("incorrect certificate presence; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestPassphraseCache(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "new-key", testdata.WithPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "new-key")
+		id := h.UI.keyByName("new-key").ID
+
+		// Set up the passphrase cache.
+		vaultDialog := h.dom.GetElement("vaultDialog")
+		dom.DoClick(h.dom.GetElement("vaultSetup"))
+		h.waitDialogOpen(ctx, vaultDialog)
+		dom.SetValue(h.dom.GetElement("vaultNew"), "master")
+		dom.SetValue(h.dom.GetElement("vaultConfirm"), "master")
+		dom.DoClick(h.dom.GetElement("vaultOk"))
+		h.waitDialogClosed(ctx, vaultDialog)
+		mustPoll(ctx, func() bool {
+			unlocked, err := h.vault.Unlocked(ctx)
+			return err == nil && unlocked
+		})
+
+		// Load the key, saving its passphrase.
+		dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+		h.waitDialogOpen(ctx, h.passphraseDialog)
+		if h.dom.GetElement("passphraseRememberPane").Get("hidden").Bool() {
+			t.Errorf("option to save passphrase not displayed")
+		}
+		dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
+		dom.SetChecked(h.dom.GetElement("passphraseRemember"), true)
+		dom.DoClick(h.passphraseOk)
+		h.waitDialogClosed(ctx, h.passphraseDialog)
+		h.waitKeyLoaded(ctx, "new-key")
+		mustPoll(ctx, func() bool {
+			cached, err := h.vault.Cached(ctx, id)
+			return err == nil && cached
+		})
+
+		// Lock the passphrase cache and unload the key. Loading the
+		// key again requires only the master password.
+		dom.DoClick(h.dom.GetElement("vaultLock"))
+		mustPoll(ctx, func() bool {
+			unlocked, err := h.vault.Unlocked(ctx)
+			return err == nil && !unlocked
+		})
+		dom.DoClick(h.dom.GetElement(buttonID(UnloadButton, id)))
+		h.waitKeyUnloaded(ctx, "new-key")
+
+		dom.DoClick(h.dom.GetElement(buttonID(LoadButton, id)))
+		h.waitDialogOpen(ctx, vaultDialog)
+		dom.SetValue(h.dom.GetElement("vaultCurrent"), "master")
+		dom.DoClick(h.dom.GetElement("vaultOk"))
+		h.waitDialogClosed(ctx, vaultDialog)
+		h.waitKeyLoaded(ctx, "new-key")
+		if h.passphraseDialog.Get("open").Bool() {
+			t.Errorf("passphrase unexpectedly requested")
+		}
+
+		// Removing the key forgets its passphrase.
+		dom.DoClick(h.dom.GetElement(buttonID(RemoveButton, id)))
+		h.waitDialogOpen(ctx, h.removeDialog)
+		dom.DoClick(h.removeYes)
+		h.waitDialogClosed(ctx, h.removeDialog)
+		h.waitKeyRemoved(ctx, "new-key")
+		mustPoll(ctx, func() bool {
+			cached, err := h.vault.Cached(ctx, id)
+			return err == nil && !cached
+		})
+	})
+}
+
+func TestEncryptKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := h.manager.Add(ctx, "new-key", testdata.WithPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+
+		// Keys cannot be encrypted until the passphrase cache is set up.
+		encryptKeys := h.dom.GetElement("encryptKeys")
+		h.UI.updateVault(ctx)
+		if !encryptKeys.Get("disabled").Bool() {
+			t.Errorf("key encryption unexpectedly enabled before setup")
+		}
+		vaultDialog := h.dom.GetElement("vaultDialog")
+		dom.DoClick(h.dom.GetElement("vaultSetup"))
+		h.waitDialogOpen(ctx, vaultDialog)
+		dom.SetValue(h.dom.GetElement("vaultNew"), "master")
+		dom.SetValue(h.dom.GetElement("vaultConfirm"), "master")
+		dom.DoClick(h.dom.GetElement("vaultOk"))
+		h.waitDialogClosed(ctx, vaultDialog)
+		mustPoll(ctx, func() bool { return !encryptKeys.Get("disabled").Bool() })
+
+		// Encrypt the keys.
+		dom.SetChecked(encryptKeys, true)
+		encryptKeys.Call("dispatchEvent", encryptKeys.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool {
+			encrypted, err := h.manager.KeysEncrypted(ctx)
+			return err == nil && encrypted
+		})
+
+		// The passphrase cache cannot be removed while keys are
+		// encrypted with it.
+		dom.DoClick(h.dom.GetElement("vaultRemove"))
+		errorText := h.dom.GetElement("errorMessage")
+		mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), "stop encrypting them first") })
+		if configured, err := h.vault.Configured(ctx); err != nil || !configured {
+			t.Errorf("passphrase cache unexpectedly removed: %v", err)
+		}
+
+		// Locking the passphrase cache makes the keys unreadable.
+		dom.DoClick(h.dom.GetElement("vaultLock"))
+		mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), "locked") })
+	})
+}
+
+// visibleKeyNames returns the names of the keys in the visible rows of the
+// keys table, in the order they are displayed.
+func (h *testHarness) visibleKeyNames() []string {
+	var names []string
+	rows := h.dom.GetElement("keysData").Get("children")
+	for i := 0; i < rows.Length(); i++ {
+		row := rows.Index(i)
+		if row.Get("hidden").Bool() {
+			continue
+		}
+		names = append(names, dom.TextContent(row.Call("querySelector", ".keyName")))
+	}
+	return names
+}
+
+func TestFilterAndSortKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"beta":  testdata.WithoutPassphrase.Private,
+			"alpha": testdata.ED25519WithoutPassphrase.Private,
+			"gamma": testdata.ECDSAWithoutPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"alpha", "beta", "gamma"}); diff != "" {
+			t.Errorf("incorrect initial keys; -got +want: %s", diff)
+		}
+
+		// Clicking the name header again reverses the order.
+		sortName := h.dom.GetElement("sortName")
+		dom.DoClick(sortName)
+		mustPoll(ctx, func() bool { return h.UI.sortDescending })
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"gamma", "beta", "alpha"}); diff != "" {
+			t.Errorf("incorrect keys sorted descending; -got +want: %s", diff)
+		}
+		dom.DoClick(sortName)
+		mustPoll(ctx, func() bool { return !h.UI.sortDescending })
+
+		// Filter by name, ignoring case.
+		filter := h.dom.GetElement("keysFilter")
+		noMatch := h.dom.GetElement("noMatchingKeys")
+		dom.SetValue(filter, "AL")
+		filter.Call("dispatchEvent", filter.Get("ownerDocument").Get("defaultView").Get("Event").New("input"))
+		mustPoll(ctx, func() bool { return len(h.visibleKeyNames()) == 1 })
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"alpha"}); diff != "" {
+			t.Errorf("incorrect keys filtered by name; -got +want: %s", diff)
+		}
+
+		// Filter by fingerprint.
+		dom.SetValue(filter, h.UI.keyByName("gamma").Fingerprint)
+		h.UI.filterKeys(ctx, dom.Event{})
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"gamma"}); diff != "" {
+			t.Errorf("incorrect keys filtered by fingerprint; -got +want: %s", diff)
+		}
+
+		// The filter applies to rows as keys are refreshed, and rows
+		// of unchanged keys are reused.
+		row := h.UI.keyByName("gamma").row
+		h.UI.updateKeys(ctx)
+		if !h.UI.keyByName("gamma").row.Equal(row) {
+			t.Errorf("row for unchanged key unexpectedly rebuilt")
+		}
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"gamma"}); diff != "" {
+			t.Errorf("incorrect keys filtered after refresh; -got +want: %s", diff)
+		}
+
+		// A filter matching no keys says so.
+		if !noMatch.Get("hidden").Bool() {
+			t.Errorf("no matching keys message unexpectedly displayed")
+		}
+		dom.SetValue(filter, "bogus")
+		h.UI.filterKeys(ctx, dom.Event{})
+		if noMatch.Get("hidden").Bool() {
+			t.Errorf("no matching keys message unexpectedly hidden")
+		}
+	})
+}
+
+func TestKeyboardNavigation(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"beta":  testdata.WithoutPassphrase.Private,
+			"alpha": testdata.ED25519WithoutPassphrase.Private,
+			"gamma": testdata.ECDSAWithoutPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+		alpha, beta, gamma := h.UI.keyByName("alpha").row, h.UI.keyByName("beta").row, h.UI.keyByName("gamma").row
+		tabbable := func() []string {
+			var names []string
+			for _, k := range h.UI.keys {
+				if k.row.Get("tabIndex").Int() == 0 {
+					names = append(names, k.Name)
+				}
+			}
+			return names
+		}
+
+		// Only the first row is initially in the tab order.
+		if diff := cmp.Diff(tabbable(), []string{"alpha"}); diff != "" {
+			t.Errorf("incorrect initial tab order; -got +want: %s", diff)
+		}
+		if got := alpha.Call("getAttribute", "aria-label").String(); got != "alpha" {
+			t.Errorf("incorrect row label: got %q, want %q", got, "alpha")
+		}
+
+		// Arrow keys move between rows, and the focused row becomes
+		// the only one in the tab order.
+		dom.Focus(alpha)
+		if dt.PressKey(alpha, "ArrowDown", false) {
+			t.Errorf("ArrowDown not handled")
+		}
+		if !h.dom.ActiveElement().Equal(beta) {
+			t.Errorf("ArrowDown did not focus next row")
+		}
+		mustPoll(ctx, func() bool { return h.UI.activeRow == h.UI.keyByName("beta").rowKey() })
+		if diff := cmp.Diff(tabbable(), []string{"beta"}); diff != "" {
+			t.Errorf("incorrect tab order after ArrowDown; -got +want: %s", diff)
+		}
+		dt.PressKey(beta, "End", false)
+		if !h.dom.ActiveElement().Equal(gamma) {
+			t.Errorf("End did not focus last row")
+		}
+		dt.PressKey(gamma, "ArrowUp", false)
+		if !h.dom.ActiveElement().Equal(beta) {
+			t.Errorf("ArrowUp did not focus previous row")
+		}
+
+		// Enter moves to the row's controls, and Escape returns to the
+		// row.
+		dt.PressKey(beta, "Enter", false)
+		control := h.dom.ActiveElement()
+		if !dom.Contains(beta, control) || control.Equal(beta) {
+			t.Errorf("Enter did not focus row's controls")
+		}
+		dt.PressKey(control, "Escape", false)
+		if !h.dom.ActiveElement().Equal(beta) {
+			t.Errorf("Escape did not focus row")
+		}
+
+		// Focus follows the row when the keys are re-sorted.
+		sortName := h.dom.GetElement("sortName")
+		if sortName.Call("getAttribute", "aria-sort").String() != "ascending" {
+			t.Errorf("name column not reported as sorted ascending")
+		}
+		if dt.PressKey(sortName, "Enter", false) {
+			t.Errorf("Enter on sort header not handled")
+		}
+		if !h.UI.sortDescending {
+			t.Errorf("Enter on sort header did not reverse sort order")
+		}
+		if sortName.Call("getAttribute", "aria-sort").String() != "descending" {
+			t.Errorf("name column not reported as sorted descending")
+		}
+		dom.Focus(beta)
+		dt.PressKey(sortName, " ", false)
+		if !h.dom.ActiveElement().Equal(beta) {
+			t.Errorf("focus lost from row when keys were re-sorted")
+		}
+	})
+}
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		keysTab, advancedTab := h.dom.GetElement("keysTab"), h.dom.GetElement("advancedTab")
+		keysPanel, advancedPanel := h.dom.GetElement("keysPanel"), h.dom.GetElement("advancedPanel")
+		displayed := func() []bool {
+			return []bool{!keysPanel.Get("hidden").Bool(), !advancedPanel.Get("hidden").Bool()}
+		}
+
+		// The keys are initially displayed.
+		if diff := cmp.Diff(displayed(), []bool{true, false}); diff != "" {
+			t.Errorf("incorrect initial panels; -got +want: %s", diff)
+		}
+
+		// Clicking a tab displays its panel.
+		dom.DoClick(advancedTab)
+		if diff := cmp.Diff(displayed(), []bool{false, true}); diff != "" {
+			t.Errorf("incorrect panels after click; -got +want: %s", diff)
+		}
+		if got := advancedTab.Call("getAttribute", "aria-selected").String(); got != "true" {
+			t.Errorf("clicked tab not selected: aria-selected=%q", got)
+		}
+		if got := keysTab.Get("tabIndex").Int(); got != -1 {
+			t.Errorf("unselected tab in tab order: tabIndex=%d", got)
+		}
+
+		// Arrow keys move between tabs.
+		if dt.PressKey(advancedTab, "ArrowRight", false) {
+			t.Errorf("ArrowRight not handled")
+		}
+		if !h.dom.ActiveElement().Equal(keysTab) {
+			t.Errorf("ArrowRight did not wrap to first tab")
+		}
+		if diff := cmp.Diff(displayed(), []bool{true, false}); diff != "" {
+			t.Errorf("incorrect panels after ArrowRight; -got +want: %s", diff)
+		}
+		dt.PressKey(keysTab, "End", false)
+		if !h.dom.ActiveElement().Equal(advancedTab) {
+			t.Errorf("End did not focus last tab")
+		}
+		if diff := cmp.Diff(displayed(), []bool{false, true}); diff != "" {
+			t.Errorf("incorrect panels after End; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestOnboarding(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		pane := h.dom.GetElement("onboardingPane")
+		copyButton := h.dom.GetElement("onboardingCopy")
+		result := h.dom.GetElement("onboardingResult")
+
+		// Onboarding is offered on first run. The public key cannot be
+		// copied until a key is added.
+		h.UI.startOnboarding(ctx)
+		if pane.Get("hidden").Bool() {
+			t.Errorf("onboarding not offered on first run")
+		}
+		if !copyButton.Get("disabled").Bool() {
+			t.Errorf("copy enabled with no keys")
+		}
+
+		if _, err := h.manager.Add(ctx, "new-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		h.UI.updateKeys(ctx)
+		if copyButton.Get("disabled").Bool() {
+			t.Errorf("copy disabled once key was added")
+		}
+
+		dom.DoClick(h.dom.GetElement("onboardingTest"))
+		mustPoll(ctx, func() bool { return dom.TextContent(result) != "" })
+		if got, want := dom.TextContent(result), i18n.Message("selfTestPassed"); got != want {
+			t.Errorf("incorrect test result: got %q, want %q", got, want)
+		}
+
+		// Once finished, onboarding is not offered again, even if
+		// there are no keys.
+		dom.DoClick(h.dom.GetElement("onboardingDone"))
+		mustPoll(ctx, func() bool { return pane.Get("hidden").Bool() })
+		mustPoll(ctx, func() bool {
+			stored, err := h.settings.Stored(ctx)
+			return err == nil && stored
+		})
+		if err := h.manager.Remove(ctx, h.UI.keyByName("new-key").ID); err != nil {
+			t.Errorf("failed to remove key: %v", err)
+		}
+		h.UI.startOnboarding(ctx)
+		if !pane.Get("hidden").Bool() {
+			t.Errorf("onboarding offered again after it was finished")
+		}
+	})
+}
+
+func TestKeyrings(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"home": testdata.WithoutPassphrase.Private,
+			"work": testdata.ED25519WithoutPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+
+		// Assign a key to a new keyring.
+		input := h.dom.GetElement(buttonID(KeyringInput, h.UI.keyByName("work").ID))
+		dom.SetValue(input, "work")
+		input.Call("dispatchEvent", input.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool { return h.UI.keyByName("work").Keyring == "work" })
+
+		// The new keyring may be selected as the active keyring.
+		var options []string
+		opts := h.UI.activeKeyring.Get("options")
+		for i := 0; i < opts.Length(); i++ {
+			options = append(options, opts.Index(i).Get("value").String())
+		}
+		if diff := cmp.Diff(options, []string{keys.AllKeyrings, keys.DefaultKeyring, "work"}); diff != "" {
+			t.Errorf("incorrect keyring options; -got +want: %s", diff)
+		}
+
+		// Only keys in the active keyring are displayed.
+		for _, tc := range []struct {
+			keyring string
+			want    []string
+		}{
+			{keyring: "work", want: []string{"work"}},
+			{keyring: keys.DefaultKeyring, want: []string{"home"}},
+			{keyring: keys.AllKeyrings, want: []string{"home", "work"}},
+		} {
+			dom.SetValue(h.UI.activeKeyring, tc.keyring)
+			h.UI.changeActiveKeyring(ctx, dom.Event{})
+			if diff := cmp.Diff(h.visibleKeyNames(), tc.want); diff != "" {
+				t.Errorf("incorrect keys displayed for keyring %q; -got +want: %s", tc.keyring, diff)
+			}
+			s, err := h.settings.Get(ctx)
+			if err != nil {
+				t.Fatalf("failed to read settings: %v", err)
+			}
+			if s.ActiveKeyring != tc.keyring {
+				t.Errorf("incorrect active keyring stored: got %q, want %q", s.ActiveKeyring, tc.keyring)
+			}
+		}
+	})
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"home": testdata.WithoutPassphrase.Private,
+			"work": testdata.ED25519WithoutPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+		if !h.UI.tagFilter.Get("hidden").Bool() {
+			t.Errorf("tag filter displayed with no tags")
+		}
+
+		// Attach tags to a key.
+		input := h.dom.GetElement(buttonID(TagsInput, h.UI.keyByName("work").ID))
+		dom.SetValue(input, "prod, Work")
+		input.Call("dispatchEvent", input.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool { return len(h.UI.keyByName("work").Tags) == 2 })
+		if diff := cmp.Diff(h.UI.keyByName("work").Tags, []string{"prod", "Work"}); diff != "" {
+			t.Errorf("incorrect tags; -got +want: %s", diff)
+		}
+
+		// The tags may be selected to filter keys.
+		var options []string
+		opts := h.UI.tagFilter.Get("options")
+		for i := 0; i < opts.Length(); i++ {
+			options = append(options, opts.Index(i).Get("value").String())
+		}
+		if diff := cmp.Diff(options, []string{"", "prod", "Work"}); diff != "" {
+			t.Errorf("incorrect tag options; -got +want: %s", diff)
+		}
+		dom.SetValue(h.UI.tagFilter, "Work")
+		h.UI.changeTagFilter(ctx, dom.Event{})
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"work"}); diff != "" {
+			t.Errorf("incorrect keys displayed for tag; -got +want: %s", diff)
+		}
+
+		// Keys with the selected tag may be loaded and unloaded together.
+		h.UI.loadTaggedKeys(ctx, dom.Event{})
+		if !h.UI.keyByName("work").Loaded || h.UI.keyByName("home").Loaded {
+			t.Errorf("incorrect keys loaded by tag")
+		}
+		h.UI.unloadTaggedKeys(ctx, dom.Event{})
+		if h.UI.keyByName("work").Loaded {
+			t.Errorf("tagged key not unloaded")
+		}
+
+		// Tags also match the text filter.
+		dom.SetValue(h.UI.tagFilter, "")
+		h.UI.changeTagFilter(ctx, dom.Event{})
+		dom.SetValue(h.UI.keysFilter, "prod")
+		h.UI.filterKeys(ctx, dom.Event{})
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"work"}); diff != "" {
+			t.Errorf("incorrect keys displayed for filter; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestHosts(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"github": testdata.WithoutPassphrase.Private,
+			"work":   testdata.ED25519WithoutPassphrase.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+
+		// Annotate a key with the hosts for which it is intended.
+		id := h.UI.keyByName("work").ID
+		input := h.dom.GetElement(buttonID(HostsInput, id))
+		dom.SetValue(input, "*.Example.com, db.internal")
+		input.Call("dispatchEvent", input.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool { return len(h.UI.keyByName("work").Hosts) == 2 })
+		if diff := cmp.Diff(h.UI.keyByName("work").Hosts, []string{"*.example.com", "db.internal"}); diff != "" {
+			t.Errorf("incorrect hosts; -got +want: %s", diff)
+		}
+		if !strings.Contains(dom.TextContent(h.UI.keyByName("work").row), "*.example.com, db.internal") {
+			t.Errorf("hosts not displayed")
+		}
+
+		// Invalid hosts are reported, and the input restored.
+		input = h.dom.GetElement(buttonID(HostsInput, id))
+		dom.SetValue(input, "user@host")
+		input.Call("dispatchEvent", input.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool {
+			return strings.Contains(dom.TextContent(h.dom.GetElement("errorMessage")), "invalid host")
+		})
+		if got := dom.Value(h.dom.GetElement(buttonID(HostsInput, id))); got != "*.example.com, db.internal" {
+			t.Errorf("hosts input not restored; got %q", got)
+		}
+
+		// Hosts match the filter.
+		dom.SetValue(h.UI.keysFilter, "db.internal")
+		h.UI.filterKeys(ctx, dom.Event{})
+		if diff := cmp.Diff(h.visibleKeyNames(), []string{"work"}); diff != "" {
+			t.Errorf("incorrect keys displayed for filter; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestIncrementalUpdate(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for _, name := range []string{"alpha", "beta", "gamma"} {
+			if _, err := h.manager.Add(ctx, name, testdata.WithoutPassphrase.Private); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+		alpha, beta, gamma := h.UI.keyByName("alpha"), h.UI.keyByName("beta"), h.UI.keyByName("gamma")
+
+		// Change only one of the keys.
+		if err := h.manager.SetConfirm(ctx, beta.ID, true); err != nil {
+			t.Errorf("failed to configure key: %v", err)
+			return
+		}
+		h.UI.updateKeys(ctx)
+
+		if h.UI.keyByName("alpha") != alpha || h.UI.keyByName("gamma") != gamma {
+			t.Errorf("unchanged keys unexpectedly rebuilt")
+		}
+		newBeta := h.UI.keyByName("beta")
+		if newBeta == beta || !newBeta.Confirm {
+			t.Errorf("changed key not rebuilt")
+			return
+		}
+		rows := h.dom.GetElement("keysData").Get("children")
+		if got := rows.Length(); got != 3 {
+			t.Errorf("incorrect number of rows: got %d, want 3", got)
+			return
+		}
+		for i, k := range []*displayedKey{alpha, newBeta, gamma} {
+			if !rows.Index(i).Equal(k.row) {
+				t.Errorf("incorrect row at position %d; want key %s", i, k.Name)
+			}
+		}
+	})
+}
+
+func TestGenerateKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		name        string
+		keyType     string
+		passphrase  string
+		confirm     string
+		wantKeys    []string
+		wantPrefix  string
+		wantErr     string
+	}{
+		{
+			description: "generate ed25519 key",
+			name:        "new-key",
+			keyType:     "ed25519",
+			passphrase:  "secret",
+			confirm:     "secret",
+			wantKeys:    []string{"new-key"},
+			wantPrefix:  "ssh-ed25519 ",
+		},
+		{
+			description: "generate ecdsa key",
+			name:        "new-key",
+			keyType:     "ecdsa-384",
+			passphrase:  "secret",
+			confirm:     "secret",
+			wantKeys:    []string{"new-key"},
+			wantPrefix:  "ecdsa-sha2-nistp384 ",
+		},
+		{
+			description: "mismatched passphrases",
+			name:        "new-key",
+			keyType:     "ed25519",
+			passphrase:  "secret",
+			confirm:     "other",
+			wantErr:     errPassphraseMismatch.Error(),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				generateDialog := h.dom.GetElement("generateDialog")
+				dom.DoClick(h.dom.GetElement("generate"))
+				h.waitDialogOpen(ctx, generateDialog)
+				dom.SetValue(h.dom.GetElement("generateName"), tc.name)
+				dom.SetValue(h.dom.GetElement("generateType"), tc.keyType)
+				dom.SetValue(h.dom.GetElement("generatePassphrase"), tc.passphrase)
+				dom.SetValue(h.dom.GetElement("generateConfirm"), tc.confirm)
+				dom.DoClick(h.dom.GetElement("generateOk"))
+				h.waitDialogClosed(ctx, generateDialog)
+
+				if tc.wantErr != "" {
+					errorText := h.dom.GetElement("errorMessage")
+					mustPoll(ctx, func() bool { return strings.Contains(dom.TextContent(errorText), tc.wantErr) })
+					if !h.dom.GetElement("generatedPane").Get("hidden").Bool() {
+						t.Errorf("generated key unexpectedly displayed")
+					}
+					return
+				}
+
+				h.waitKeyConfigured(ctx, tc.name)
+				var names []string
+				for _, k := range h.UI.displayedKeys() {
+					names = append(names, k.Name)
+				}
+				if diff := cmp.Diff(names, tc.wantKeys); diff != "" {
+					t.Errorf("incorrect keys; -got +want: %s", diff)
+				}
+				if h.dom.GetElement("generatedPane").Get("hidden").Bool() {
+					t.Errorf("generated key not displayed")
+				}
+				pub := dom.TextContent(h.dom.GetElement("generatedPublicKey"))
+				if !strings.HasPrefix(pub, tc.wantPrefix) || !strings.HasSuffix(pub, " "+tc.name) {
+					t.Errorf("incorrect public key: got %q, want prefix %q and comment %q", pub, tc.wantPrefix, tc.name)
+				}
+			})
+		})
+	}
+}
+
+func TestSuggestPassphrase(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		generateDialog := h.dom.GetElement("generateDialog")
+		passphrase := h.dom.GetElement("generatePassphrase")
+		confirm := h.dom.GetElement("generateConfirm")
+		copyButton := h.dom.GetElement("generateCopy")
+
+		dom.DoClick(h.dom.GetElement("generate"))
+		h.waitDialogOpen(ctx, generateDialog)
+		if !copyButton.Get("disabled").Bool() {
+			t.Errorf("copy button enabled before a passphrase is suggested")
+		}
+		dom.DoClick(h.dom.GetElement("generateSuggest"))
+
+		// The suggestion fills both fields, and is revealed.
+		got := dom.Value(passphrase)
+		if diff := cmp.Diff(len(strings.Split(got, passgen.DefaultSeparator)), passgen.DefaultWords); diff != "" {
+			t.Errorf("incorrect number of words in %q; -got +want: %s", got, diff)
+		}
+		if diff := cmp.Diff(dom.Value(confirm), got); diff != "" {
+			t.Errorf("incorrect confirmation; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(passphrase.Get("type").String(), "text"); diff != "" {
+			t.Errorf("passphrase not revealed; -got +want: %s", diff)
+		}
+		if copyButton.Get("disabled").Bool() {
+			t.Errorf("copy button disabled after a passphrase is suggested")
+		}
+
+		// Other styles and lengths may be selected.
+		dom.SetValue(h.dom.GetElement("generateSuggestStyle"), "chars32")
+		dom.DoClick(h.dom.GetElement("generateSuggest"))
+		got = dom.Value(passphrase)
+		if diff := cmp.Diff(len(got), 32); diff != "" {
+			t.Errorf("incorrect length of %q; -got +want: %s", got, diff)
+		}
+		for _, c := range got {
+			if !strings.ContainsRune(passgen.DefaultAlphabet, c) {
+				t.Errorf("character %q not in alphabet", c)
+			}
+		}
+
+		// Closing the dialog hides the fields again.
+		dom.DoClick(h.dom.GetElement("generateCancel"))
+		h.waitDialogClosed(ctx, generateDialog)
+		for _, f := range []js.Value{passphrase, confirm} {
+			if dom.Value(f) != "" || f.Get("type").String() != "password" {
+				t.Errorf("field %s not reset: value %q, type %s", f.Get("id"), dom.Value(f), f.Get("type"))
+			}
+		}
+		if !copyButton.Get("disabled").Bool() {
+			t.Errorf("copy button not disabled once the dialog is closed")
+		}
+	})
+}
+
+func TestClients(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		const id = "client-extension-id"
+		if _, err := h.clients.Seen(ctx, id, time.Unix(1000, 0)); err != nil {
+			t.Fatalf("failed to record client: %v", err)
+		}
+		if _, err := h.manager.Add(ctx, "good-key", testdata.WithoutPassphrase.Private); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		h.waitKeyConfigured(ctx, "good-key")
+
+		if h.dom.GetElement(clientElementID("status", id)).IsNull() {
+			t.Fatalf("client not displayed")
+		}
+		client := func() *clients.Client {
+			t.Helper()
+			c, err := h.clients.Get(ctx, id)
+			if err != nil {
+				t.Fatalf("failed to read client: %v", err)
+			}
+			return c
+		}
+
+		// Name the client.
+		name := h.dom.GetElement(clientElementID("name", id))
+		dom.SetValue(name, "My Terminal")
+		name.Call("dispatchEvent", name.Get("ownerDocument").Get("defaultView").Get("Event").New("change"))
+		mustPoll(ctx, func() bool { return client().Name == "My Terminal" })
+
+		// Grant a single key.
+		k := h.UI.keyByName("good-key")
+		dom.DoClick(h.dom.GetElement(clientKeyElementID(id, k.ID)))
+		mustPoll(ctx, func() bool { return len(client().Keys) == 1 })
+		var fingerprint string
+		for _, c := range h.UI.configured {
+			if c.Name == "good-key" {
+				fingerprint = c.Fingerprint
+			}
+		}
+		if diff := cmp.Diff(client().Keys, []string{fingerprint}); diff != "" {
+			t.Errorf("incorrect granted keys; -got +want: %s", diff)
+		}
+
+		// Revoke access.
+		dom.DoClick(h.dom.GetElement(clientElementID("revoke", id)))
+		mustPoll(ctx, func() bool { return client().Decision == clients.Denied })
+		mustPoll(ctx, func() bool {
+			return dom.TextContent(h.dom.GetElement(clientElementID("status", id))) == "Revoked"
+		})
+
+		// Forget the client.
+		dom.DoClick(h.dom.GetElement(clientElementID("forget", id)))
+		mustPoll(ctx, func() bool { return client() == nil })
+		mustPoll(ctx, func() bool { return h.dom.GetElement(clientElementID("status", id)).IsNull() })
+	})
+}
+
+func TestConnections(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		noConnections := h.dom.GetElement("noConnections")
+		h.UI.updateKeys(ctx)
+		if noConnections.Get("hidden").Bool() {
+			t.Errorf("absence of connected clients not displayed")
+		}
+
+		const id = "client-extension-id"
+		if _, err := h.clients.Seen(ctx, id, time.Unix(1000, 0)); err != nil {
+			t.Errorf("failed to record client: %v", err)
+			return
+		}
+		if err := h.clients.Update(ctx, id, func(c *clients.Client) { c.Name = "My Terminal" }); err != nil {
+			t.Errorf("failed to name client: %v", err)
+			return
+		}
+		h.manager.(*keys.DefaultManager).SetConnectionSource(func() []*keys.Connection {
+			return []*keys.Connection{
+				{Client: id, Origin: "chrome-extension://" + id, Connected: 1000},
+				{Client: "https://example.com", Origin: "https://example.com", Connected: 2000},
+			}
+		})
+		h.UI.updateKeys(ctx)
+
+		if !noConnections.Get("hidden").Bool() {
+			t.Errorf("connected clients not displayed")
+		}
+		got := dom.TextContent(h.dom.GetElement("connectionsData"))
+		for _, want := range []string{
+			"My Terminal (client-extension-id)",
+			"chrome-extension://client-extension-id",
+			"https://example.com",
+			formatSeen(2000),
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("connected clients %q do not include %q", got, want)
+			}
+		}
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		l := audit.NewLog(storage.NewRaw(st.NewMemArea()), audit.DefaultCapacity)
+		h.manager.(*keys.DefaultManager).SetAuditLog(l)
+		for _, e := range []*keys.AuditEntry{
+			{Time: 1000, Fingerprint: "SHA256:first", Client: "client-1"},
+			{Time: 2000, Fingerprint: "SHA256:second", Client: "client-2", Err: "key not granted to client"},
+		} {
+			if err := l.Record(ctx, e); err != nil {
+				t.Errorf("failed to record entry: %v", err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+
+		noAudit := h.dom.GetElement("noAudit")
+		if !noAudit.Get("hidden").Bool() {
+			t.Errorf("signature log not displayed")
+		}
+		got := dom.TextContent(h.dom.GetElement("auditData"))
+		for _, want := range []string{
+			"SHA256:first",
+			"client-1",
+			"Signed",
+			"SHA256:second",
+			"Refused: key not granted to client",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("signature log %q does not include %q", got, want)
+			}
+		}
+		if strings.Index(got, "SHA256:second") > strings.Index(got, "SHA256:first") {
+			t.Errorf("most recent entry not displayed first: %q", got)
+		}
+
+		dom.DoClick(h.dom.GetElement("clearAudit"))
+		mustPoll(ctx, func() bool { return !noAudit.Get("hidden").Bool() })
+		entries, err := l.Entries(ctx)
+		if err != nil {
+			t.Errorf("failed to read entries: %v", err)
+			return
+		}
+		if len(entries) > 0 {
+			t.Errorf("signature log not cleared: %v", entries)
+		}
+	})
+}
+
+// toggledArea is a storage area that can be made unavailable.
+type toggledArea struct {
+	storage.Area
+	unavailable bool
+}
+
+func (a *toggledArea) err() error {
+	if a.unavailable {
+		return fmt.Errorf("%w: sync disabled for test", storage.ErrUnavailable)
+	}
+	return nil
+}
+
+func (a *toggledArea) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	if err := a.err(); err != nil {
+		return err
+	}
+	return a.Area.Set(ctx, data)
+}
+
+func (a *toggledArea) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	if err := a.err(); err != nil {
+		return nil, err
+	}
+	return a.Area.Get(ctx)
+}
+
+func (a *toggledArea) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	if err := a.err(); err != nil {
+		return err
+	}
+	return a.Area.Delete(ctx, keys)
+}
+
+func TestSyncUnavailable(t *testing.T) {
+	t.Parallel()
+
+	syncStorage := &toggledArea{Area: storage.NewRaw(st.NewMemArea()), unavailable: true}
+	h := newHarnessWithSync(syncStorage)
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		pane := h.dom.GetElement("syncUnavailable")
+
+		// Keys can still be added, but are stored locally.
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		if pane.Get("hidden").Bool() {
+			t.Errorf("banner not displayed while synced storage unavailable")
+		}
+		dom.DoClick(h.addButton)
+		h.waitDialogOpen(ctx, h.addDialog)
+		dom.SetValue(h.addName, "new-key")
+		dom.SetValue(h.addKey, testdata.WithoutPassphrase.Private)
+		dom.DoClick(h.addOk)
+		h.waitDialogClosed(ctx, h.addDialog)
+		h.waitKeyConfigured(ctx, "new-key")
+		k := h.UI.keyByName("new-key")
+		if !k.Local {
+			t.Errorf("key not stored locally while synced storage unavailable")
+		}
+		if btn := h.dom.GetElement(buttonID(LocationButton, k.ID)); !btn.IsNull() {
+			t.Errorf("unexpected button %s while synced storage unavailable", buttonID(LocationButton, k.ID))
+		}
+
+		// Once synced storage is available again, the key can be
+		// synced.
+		syncStorage.unavailable = false
+		dom.DoClick(h.dom.GetElement("syncRetry"))
+		mustPoll(ctx, func() bool { return pane.Get("hidden").Bool() })
+		mustPoll(ctx, func() bool { return !h.dom.GetElement(buttonID(LocationButton, k.ID)).IsNull() })
+	})
+}
+
+func TestAgentLocked(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		pane := h.dom.GetElement("agentLocked")
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		if !pane.Get("hidden").Bool() {
+			t.Errorf("banner displayed while agent unlocked")
+		}
+
+		// Clients lock the agent using the agent protocol.
+		agt := keys.NewLockAgent(h.agent, h.manager.(*keys.DefaultManager), nil)
+		if err := agt.Lock([]byte("secret")); err != nil {
+			t.Fatalf("failed to lock agent: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		mustPoll(ctx, func() bool { return !pane.Get("hidden").Bool() })
+
+		if err := agt.Unlock([]byte("secret")); err != nil {
+			t.Fatalf("failed to unlock agent: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		mustPoll(ctx, func() bool { return pane.Get("hidden").Bool() })
+	})
+}
+
+func TestAdoptLoaded(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		// A client adds a key while keys are captured.
+		mgr := h.manager.(*keys.DefaultManager)
+		agt := keys.NewCaptureAgent(h.agent, mgr, func(ctx jsutil.AsyncContext) (bool, error) { return true, nil })
+		priv, err := ssh.ParseRawPrivateKey([]byte(testdata.WithoutPassphrase.Private))
+		if err != nil {
+			t.Fatalf("failed to parse key: %v", err)
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "client-key"}); err != nil {
+			t.Fatalf("failed to add key: %v", err)
+		}
+
+		h.UI.updateKeys(ctx)
+		h.waitLoaded(ctx)
+		var fingerprint string
+		for _, k := range h.UI.displayedKeys() {
+			if k.Captured {
+				fingerprint = k.Fingerprint
+			}
+		}
+		if fingerprint == "" {
+			t.Fatalf("captured key not displayed")
+		}
+
+		dom.DoClick(h.dom.GetElement(buttonID(AdoptButton, keys.ID(fingerprint))))
+		h.waitKeyConfigured(ctx, "client-key")
+		h.waitKeyLoaded(ctx, "client-key")
+		if n := len(h.UI.displayedKeys()); n != 1 {
+			t.Errorf("incorrect number of displayed keys: got %d, want 1", n)
+		}
+	})
+}
+
+func TestRefresher(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		clk := fakes.NewClock(time.Unix(0, 0))
+		var mu sync.Mutex
+		refreshes := 0
+		count := func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return refreshes
+		}
+		r := newRefresher(func(_ jsutil.AsyncContext) {
+			mu.Lock()
+			defer mu.Unlock()
+			refreshes++
+		}, time.Second, clk)
+
+		done := make(chan struct{})
+		go func() {
+			r.Request(ctx)
+			close(done)
+		}()
+
+		// The first request refreshes immediately.
+		clk.BlockUntil(1)
+		if diff := cmp.Diff(count(), 1); diff != "" {
+			t.Errorf("incorrect refreshes after first request; -got +want: %s", diff)
+		}
+
+		// A burst of requests within the interval is coalesced into a
+		// single refresh once the interval elapses.
+		for i := 0; i < 10; i++ {
+			r.Request(ctx)
+		}
+		if diff := cmp.Diff(count(), 1); diff != "" {
+			t.Errorf("incorrect refreshes during interval; -got +want: %s", diff)
+		}
+		clk.Advance(time.Second)
+		clk.BlockUntil(1)
+		if diff := cmp.Diff(count(), 2); diff != "" {
+			t.Errorf("incorrect refreshes after burst; -got +want: %s", diff)
+		}
+
+		// No further requests; no further refreshes.
+		clk.Advance(time.Second)
+		<-done
+		if diff := cmp.Diff(count(), 2); diff != "" {
+			t.Errorf("incorrect refreshes after idle; -got +want: %s", diff)
+		}
+	})
+}
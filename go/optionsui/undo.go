@@ -0,0 +1,67 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// keyName returns the name of the displayed key with the specified ID, or the
+// ID itself if it is not displayed.
+func (u *UI) keyName(id keys.ID) string {
+	if k := u.keyByID(id); k != nil {
+		return k.Name
+	}
+	return string(id)
+}
+
+// offerUndoRemove displays a notification that the key with the specified ID
+// was removed, offering to restore it while the manager holds it. It must be
+// invoked before the displayed keys are updated.
+func (u *UI) offerUndoRemove(id keys.ID) {
+	name := u.keyName(id)
+	action := ""
+	if u.capabilities.Add {
+		action = i18n.Message("buttonUndo")
+	}
+	u.toaster.Show(i18n.Message("keyRemoved", name), action, keys.UndoPeriod, func(ctx jsutil.AsyncContext) {
+		if err := u.mgr.UndoRemove(ctx, id); err != nil {
+			u.setError(i18n.Wrap(err, "failedUndoRemove", name))
+			return
+		}
+		u.setError(nil)
+		u.updateKeys(ctx)
+	})
+}
+
+// offerUndoUnload displays a notification that the key with the specified ID
+// was unloaded, offering to load it again (without its passphrase) while the
+// manager holds it. It must be invoked before the displayed keys are
+// updated.
+func (u *UI) offerUndoUnload(id keys.ID) {
+	name := u.keyName(id)
+	u.toaster.Show(i18n.Message("keyUnloaded", name), i18n.Message("buttonUndo"), keys.UndoPeriod, func(ctx jsutil.AsyncContext) {
+		if err := u.mgr.UndoUnload(ctx, id); err != nil {
+			u.setError(i18n.Wrap(err, "failedUndoUnload", name))
+			return
+		}
+		u.setError(nil)
+		u.updateKeys(ctx)
+	})
+}
@@ -0,0 +1,82 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// update prompts the user for new private key material for the key with the
+// specified ID, and replaces the key's material with it.
+func (u *UI) update(ctx jsutil.AsyncContext, id keys.ID) {
+	k := u.keyByID(id)
+	if k == nil {
+		u.setError(i18n.Wrap(keys.ErrKeyNotFound, "failedReplaceKey"))
+		return
+	}
+
+	ok, privateKey := u.promptUpdate(ctx, k.Name)
+	if !ok {
+		return
+	}
+
+	if err := u.mgr.Update(ctx, id, privateKey); err != nil {
+		u.setError(i18n.Wrap(err, "failedReplaceKey"))
+		return
+	}
+	// The new key likely has a different passphrase.
+	u.forgetPassphrase(ctx, id)
+
+	u.setError(nil)
+	u.updateKeys(ctx)
+}
+
+// promptUpdate displays a dialog prompting the user for the new private key
+// for the key with the specified name.
+func (u *UI) promptUpdate(ctx jsutil.AsyncContext, name string) (ok bool, privateKey string) {
+	dialog := u.dialog("updateDialog")
+	form := u.dom.GetElement("updateForm")
+	nameText := u.dom.GetElement("updateName")
+	keyField := u.dom.GetElement("updateKey")
+	cancel := u.dom.GetElement("updateCancel")
+
+	dom.RemoveChildren(nameText)
+	dom.AppendChild(nameText, u.dom.NewText(name), nil)
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		privateKey = dom.Value(keyField)
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.SetValue(keyField, "")
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
@@ -0,0 +1,127 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+const (
+	// nearlyFullPercent is the percentage of an area's quota beyond which
+	// the user is warned that it is nearly full.
+	nearlyFullPercent = 90
+)
+
+// formatBytes returns a human-readable description of a number of bytes.
+func formatBytes(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return i18n.Message("byteCount", strconv.Itoa(n))
+}
+
+// describeAreaUsage returns a human-readable summary of the usage of the
+// named storage area.
+func describeAreaUsage(name string, a *keys.AreaUsage) string {
+	if a.Quota <= 0 {
+		return i18n.Message("storageUsed", name, formatBytes(a.BytesInUse))
+	}
+	msg := i18n.Message("storageUsedOfQuota", name, formatBytes(a.BytesInUse), formatBytes(a.Quota))
+	if a.BytesInUse*100 >= a.Quota*nearlyFullPercent {
+		msg += " " + i18n.Message("storageNearlyFull")
+	}
+	return msg
+}
+
+// addQuotaWarning returns a warning to display if a key with the specified
+// name and private key would not fit in the storage to which it would be
+// added, or an empty string if it would fit (or the quota is not known).
+func addQuotaWarning(usage *keys.StorageUsage, name, privateKey string, local bool) string {
+	if usage == nil || privateKey == "" {
+		return ""
+	}
+	area, where := usage.Synced, i18n.Message("storageWhereSynced")
+	if local {
+		area, where = usage.Local, i18n.Message("storageWhereLocal")
+	}
+	remaining := area.Remaining()
+	needed := keys.EstimateBytes(name, privateKey)
+	if remaining < 0 || needed <= remaining {
+		return ""
+	}
+	msg := i18n.Message("storageQuotaExceeded", formatBytes(needed), formatBytes(remaining), where)
+	if !local {
+		msg += " " + i18n.Message("storageSuggestLocal")
+	}
+	return msg
+}
+
+// updateUsage reads the storage used by the configured keys, and displays it.
+func (u *UI) updateUsage(ctx jsutil.AsyncContext) {
+	usage, err := u.mgr.StorageUsage(ctx)
+	if err != nil {
+		jsutil.LogError("failed to read storage usage: %v", err)
+		return
+	}
+	u.usage = usage
+	u.setUsage(usage)
+}
+
+// setUsage refreshes the UI to reflect the storage usage that should be
+// displayed.
+func (u *UI) setUsage(usage *keys.StorageUsage) {
+	dom.RemoveChildren(u.storageSummary)
+	for _, line := range []string{
+		describeAreaUsage(i18n.Message("storageAreaSynced"), usage.Synced),
+		describeAreaUsage(i18n.Message("storageAreaLocal"), usage.Local),
+	} {
+		dom.AppendChild(u.storageSummary, u.dom.NewElement("div"), func(div js.Value) {
+			dom.AppendChild(div, u.dom.NewText(line), nil)
+		})
+	}
+
+	dom.RemoveChildren(u.storageData)
+	for _, k := range usage.Keys {
+		name := k.ID
+		for _, c := range u.configured {
+			if c.ID == k.ID {
+				name = c.Name
+			}
+		}
+		location := i18n.Message("storageLocationSynced")
+		if k.Local {
+			location = i18n.Message("storageLocationLocal")
+		}
+		dom.AppendChild(u.storageData, u.dom.NewElement("tr"), func(row js.Value) {
+			for _, text := range []string{name, location, formatBytes(k.Bytes)} {
+				dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+					dom.AppendChild(cell, u.dom.NewText(text), nil)
+				})
+			}
+		})
+	}
+}
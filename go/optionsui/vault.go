@@ -0,0 +1,259 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optionsui
+
+import (
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+var (
+	errPasswordMismatch = i18n.NewError("errPasswordMismatch")
+	errKeysEncrypted    = i18n.NewError("errKeysEncrypted")
+)
+
+// updateVault displays the state of the passphrase cache, and the controls
+// applicable to that state.
+func (u *UI) updateVault(ctx jsutil.AsyncContext) {
+	configured, err := u.vault.Configured(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadVault"))
+		return
+	}
+	unlocked, err := u.vault.Unlocked(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadVault"))
+		return
+	}
+
+	var status string
+	switch {
+	case !configured:
+		status = i18n.Message("vaultNotConfigured")
+	case unlocked:
+		status = i18n.Message("vaultUnlocked")
+	default:
+		status = i18n.Message("vaultLocked")
+	}
+	dom.RemoveChildren(u.vaultStatus)
+	dom.AppendChild(u.vaultStatus, u.dom.NewText(status), nil)
+
+	u.dom.GetElement("vaultSetup").Set("hidden", configured)
+	u.dom.GetElement("vaultUnlock").Set("hidden", !configured || unlocked)
+	u.dom.GetElement("vaultLock").Set("hidden", !unlocked)
+	u.dom.GetElement("vaultChange").Set("hidden", !configured)
+	u.dom.GetElement("vaultRemove").Set("hidden", !configured)
+
+	encrypted, err := u.mgr.KeysEncrypted(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedReadEncryption"))
+		return
+	}
+	dom.SetChecked(u.encryptKeys, encrypted)
+	// Keys can only be encrypted or decrypted using the data key.
+	u.encryptKeys.Set("disabled", !unlocked)
+}
+
+// changeEncryptKeys encrypts or decrypts the keys in synced storage, as
+// selected by the user.
+func (u *UI) changeEncryptKeys(ctx jsutil.AsyncContext, _ dom.Event) {
+	if err := u.mgr.SetKeysEncrypted(ctx, dom.Checked(u.encryptKeys)); err != nil {
+		u.setError(i18n.Wrap(err, "failedChangeEncryption"))
+		u.updateVault(ctx)
+		return
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+	u.updateKeys(ctx)
+}
+
+// setupVault prompts the user for a master password, and sets up the
+// passphrase cache with it.
+func (u *UI) setupVault(ctx jsutil.AsyncContext, _ dom.Event) {
+	ok, _, password, confirm := u.promptVault(ctx, i18n.Message("vaultSetupTitle"), false, true)
+	if !ok {
+		return
+	}
+	if password != confirm {
+		u.setError(i18n.Wrap(errPasswordMismatch, "failedSetupVault"))
+		return
+	}
+	if err := u.vault.Setup(ctx, password); err != nil {
+		u.setError(i18n.Wrap(err, "failedSetupVault"))
+		return
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+}
+
+// unlockVault prompts the user for the master password, and unlocks the
+// passphrase cache. Returns true if the cache was unlocked.
+func (u *UI) unlockVault(ctx jsutil.AsyncContext) bool {
+	ok, password, _, _ := u.promptVault(ctx, i18n.Message("vaultUnlockTitle"), true, false)
+	if !ok {
+		return false
+	}
+	if err := u.vault.Unlock(ctx, password); err != nil {
+		u.setError(i18n.Wrap(err, "failedUnlockVault"))
+		return false
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+	return true
+}
+
+// lockVault locks the passphrase cache.
+func (u *UI) lockVault(ctx jsutil.AsyncContext, _ dom.Event) {
+	if err := u.vault.Lock(ctx); err != nil {
+		u.setError(i18n.Wrap(err, "failedLockVault"))
+		return
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+	u.updateKeys(ctx)
+}
+
+// changeVaultPassword prompts the user for the current and new master
+// passwords, and changes the master password.
+func (u *UI) changeVaultPassword(ctx jsutil.AsyncContext, _ dom.Event) {
+	ok, current, password, confirm := u.promptVault(ctx, i18n.Message("vaultChangeTitle"), true, true)
+	if !ok {
+		return
+	}
+	if password != confirm {
+		u.setError(i18n.Wrap(errPasswordMismatch, "failedChangeMasterPassword"))
+		return
+	}
+	if err := u.vault.ChangePassword(ctx, current, password); err != nil {
+		u.setError(i18n.Wrap(err, "failedChangeMasterPassword"))
+		return
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+}
+
+// removeVault removes the passphrase cache, including all saved passphrases.
+func (u *UI) removeVault(ctx jsutil.AsyncContext, _ dom.Event) {
+	// Encrypted keys could no longer be read.
+	encrypted, err := u.mgr.KeysEncrypted(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedRemoveVault"))
+		return
+	}
+	if encrypted {
+		u.setError(i18n.Wrap(errKeysEncrypted, "failedRemoveVault"))
+		return
+	}
+	if err := u.vault.Remove(ctx); err != nil {
+		u.setError(i18n.Wrap(err, "failedRemoveVault"))
+		return
+	}
+	u.setError(nil)
+	u.updateVault(ctx)
+}
+
+// promptVault displays a dialog prompting the user for the current master
+// password (if current is true) and a new master password along with its
+// confirmation (if replace is true).
+func (u *UI) promptVault(ctx jsutil.AsyncContext, title string, current, replace bool) (ok bool, currentPassword, password, confirm string) {
+	dialog := u.dialog("vaultDialog")
+	form := u.dom.GetElement("vaultForm")
+	titleText := u.dom.GetElement("vaultTitle")
+	currentField := u.dom.GetElement("vaultCurrent")
+	passwordField := u.dom.GetElement("vaultNew")
+	confirmField := u.dom.GetElement("vaultConfirm")
+	style := u.dom.GetElement("vaultSuggestStyle")
+	suggest := u.dom.GetElement("vaultSuggest")
+	copyButton := u.dom.GetElement("vaultCopy")
+	cancel := u.dom.GetElement("vaultCancel")
+
+	dom.RemoveChildren(titleText)
+	dom.AppendChild(titleText, u.dom.NewText(title), nil)
+	u.dom.GetElement("vaultCurrentPane").Set("hidden", !current)
+	u.dom.GetElement("vaultNewPane").Set("hidden", !replace)
+
+	sig := newSignal()
+	var cleanup jsutil.CleanupFuncs
+	cleanup.Add(dom.OnSubmit(form, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		ok = true
+		currentPassword = dom.Value(currentField)
+		password = dom.Value(passwordField)
+		confirm = dom.Value(confirmField)
+		dialog.Close()
+	}))
+	cleanup.Add(dom.OnClick(suggest, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		u.suggestPassphrase(style, copyButton, passwordField, confirmField)
+	}))
+	cleanup.Add(dom.OnClick(copyButton, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		u.copyPassphrase(ctx, passwordField)
+	}))
+	cleanup.Add(dom.OnClick(cancel, func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dialog.Cancel()
+	}))
+	cleanup.Add(dialog.OnClose(func(ctx jsutil.AsyncContext, evt dom.Event) {
+		dom.SetValue(currentField, "")
+		hidePassphrase(copyButton, passwordField, confirmField)
+		cleanup.Do()
+		sig.Notify()
+	}))
+
+	dialog.ShowModal()
+	sig.Wait(ctx)
+	return
+}
+
+// cachedPassphrase returns the saved passphrase for the key with the
+// specified ID. If a passphrase is saved but the passphrase cache is locked,
+// the user is first prompted for the master password. ok is false if no
+// passphrase is available.
+func (u *UI) cachedPassphrase(ctx jsutil.AsyncContext, id keys.ID) (passphrase string, ok bool) {
+	cached, err := u.vault.Cached(ctx, id)
+	if err != nil || !cached {
+		return "", false
+	}
+	unlocked, err := u.vault.Unlocked(ctx)
+	if err != nil {
+		return "", false
+	}
+	if !unlocked && !u.unlockVault(ctx) {
+		return "", false
+	}
+	passphrase, ok, err = u.vault.Passphrase(ctx, id)
+	if err != nil {
+		jsutil.LogError("failed to read saved passphrase for key ID %s: %v", id, err)
+		return "", false
+	}
+	return passphrase, ok
+}
+
+// rememberPassphrase saves the passphrase for the key with the specified ID
+// in the passphrase cache.
+func (u *UI) rememberPassphrase(ctx jsutil.AsyncContext, id keys.ID, passphrase string) {
+	if err := u.vault.Store(ctx, id, passphrase); err != nil {
+		u.setError(i18n.Wrap(err, "failedSavePassphrase"))
+	}
+}
+
+// forgetPassphrase removes any saved passphrase for the key with the
+// specified ID, such as when the key is removed or replaced.
+func (u *UI) forgetPassphrase(ctx jsutil.AsyncContext, id keys.ID) {
+	if err := u.vault.Forget(ctx, id); err != nil {
+		jsutil.LogError("failed to forget saved passphrase for key ID %s: %v", id, err)
+	}
+}
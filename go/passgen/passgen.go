@@ -0,0 +1,137 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package passgen generates random passphrases suitable for protecting
+// private keys.
+package passgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	// DefaultAlphabet is the set of characters used by Random when no
+	// other alphabet is specified. Characters that are easily confused
+	// (e.g., 'l', '1', 'O' and '0') are omitted.
+	DefaultAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789-_.!@#%+="
+	// DefaultLength is the default number of characters generated by
+	// Random.
+	DefaultLength = 20
+	// DefaultWords is the default number of words generated by Words.
+	DefaultWords = 8
+	// DefaultSeparator separates words generated by Words.
+	DefaultSeparator = "-"
+)
+
+var (
+	errInvalidLength = errors.New("invalid length")
+	errEmptyAlphabet = errors.New("empty alphabet")
+)
+
+// pick returns a uniformly random index in [0, n).
+func pick(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(i.Int64()), nil
+}
+
+// Random returns a passphrase consisting of length characters chosen
+// uniformly at random from alphabet.
+func Random(length int, alphabet string) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("%w: %d", errInvalidLength, length)
+	}
+	chars := []rune(alphabet)
+	if len(chars) == 0 {
+		return "", errEmptyAlphabet
+	}
+
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := pick(len(chars))
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(chars[n])
+	}
+	return b.String(), nil
+}
+
+// Words returns a diceware-style passphrase consisting of count words chosen
+// uniformly at random from WordList and joined by sep.
+func Words(count int, sep string) (string, error) {
+	if count <= 0 {
+		return "", fmt.Errorf("%w: %d", errInvalidLength, count)
+	}
+
+	words := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		n, err := pick(len(WordList))
+		if err != nil {
+			return "", err
+		}
+		words = append(words, WordList[n])
+	}
+	return strings.Join(words, sep), nil
+}
+
+// Entropy returns the number of bits of entropy in a passphrase made of count
+// symbols, each chosen uniformly at random from a set of the specified size.
+func Entropy(count, size int) float64 {
+	if count <= 0 || size <= 1 {
+		return 0
+	}
+	return float64(count) * math.Log2(float64(size))
+}
+
+// WordList is the list of words from which Words chooses. It contains 256
+// distinct words, so each word contributes 8 bits of entropy.
+var WordList = []string{
+	"able", "acid", "aged", "also", "area", "army", "away", "baby", "back",
+	"ball", "band", "bank", "base", "bath", "bear", "beat", "been", "beer",
+	"bell", "belt", "best", "bird", "blow", "blue", "boat", "body", "bone",
+	"book", "boot", "born", "boss", "both", "bowl", "bulk", "burn", "bush",
+	"busy", "cake", "call", "calm", "came", "camp", "card", "care", "cart",
+	"case", "cash", "cast", "cell", "chat", "chip", "city", "clay", "club",
+	"coal", "coat", "code", "cold", "come", "cook", "cool", "cope", "copy",
+	"core", "corn", "cost", "crew", "crop", "dark", "data", "date", "dawn",
+	"days", "dead", "deal", "dear", "debt", "deep", "deny", "desk", "dial",
+	"diet", "disk", "dock", "does", "done", "door", "dose", "down", "draw",
+	"drew", "drop", "drum", "dual", "duck", "dust", "duty", "each", "earn",
+	"ease", "east", "easy", "edge", "else", "even", "ever", "exit", "face",
+	"fact", "fair", "fall", "farm", "fast", "fear", "feed", "feel", "feet",
+	"fell", "felt", "file", "fill", "film", "find", "fine", "fire", "firm",
+	"fish", "five", "flag", "flat", "flow", "fold", "folk", "food", "foot",
+	"form", "fort", "four", "free", "from", "fuel", "full", "fund", "gain",
+	"game", "gate", "gave", "gear", "gift", "girl", "give", "glad", "goal",
+	"goes", "gold", "golf", "gone", "good", "gray", "grew", "grid", "grow",
+	"gulf", "hair", "half", "hall", "hand", "hang", "hard", "harm", "hate",
+	"have", "head", "hear", "heat", "held", "hell", "help", "here", "hero",
+	"high", "hill", "hint", "hold", "hole", "holy", "home", "hope", "host",
+	"hour", "huge", "hung", "hunt", "idea", "inch", "into", "iron", "item",
+	"jazz", "join", "jump", "jury", "just", "keen", "keep", "kept", "kick",
+	"kind", "king", "knee", "knew", "know", "lack", "lady", "laid", "lake",
+	"lamp", "land", "lane", "last", "late", "lawn", "lead", "leaf", "lean",
+	"left", "lend", "less", "life", "lift", "like", "line", "link", "list",
+	"live", "load", "loan", "lock", "logo", "long", "look", "lord", "lose",
+	"loss", "lost", "loud", "love", "luck", "made", "mail", "main", "make",
+	"male", "mall", "many", "mark",
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package passgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		length      int
+		alphabet    string
+		wantErr     error
+	}{
+		{
+			description: "default alphabet",
+			length:      DefaultLength,
+			alphabet:    DefaultAlphabet,
+		},
+		{
+			description: "single character",
+			length:      1,
+			alphabet:    "ab",
+		},
+		{
+			description: "multi-byte characters",
+			length:      10,
+			alphabet:    "äöü",
+		},
+		{
+			description: "zero length",
+			length:      0,
+			alphabet:    DefaultAlphabet,
+			wantErr:     errInvalidLength,
+		},
+		{
+			description: "empty alphabet",
+			length:      DefaultLength,
+			alphabet:    "",
+			wantErr:     errEmptyAlphabet,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Random(tc.length, tc.alphabet)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(len([]rune(got)), tc.length); diff != "" {
+				t.Errorf("incorrect length; -got +want: %s", diff)
+			}
+			for _, c := range got {
+				if !strings.ContainsRune(tc.alphabet, c) {
+					t.Errorf("character %q not in alphabet %q", c, tc.alphabet)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomDiffers(t *testing.T) {
+	t.Parallel()
+
+	a, err := Random(DefaultLength, DefaultAlphabet)
+	if err != nil {
+		t.Fatalf("failed to generate passphrase: %v", err)
+	}
+	b, err := Random(DefaultLength, DefaultAlphabet)
+	if err != nil {
+		t.Fatalf("failed to generate passphrase: %v", err)
+	}
+	if a == b {
+		t.Errorf("generated identical passphrases: %q", a)
+	}
+}
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		count       int
+		sep         string
+		wantErr     error
+	}{
+		{
+			description: "default words",
+			count:       DefaultWords,
+			sep:         DefaultSeparator,
+		},
+		{
+			description: "single word",
+			count:       1,
+			sep:         DefaultSeparator,
+		},
+		{
+			description: "space separator",
+			count:       4,
+			sep:         " ",
+		},
+		{
+			description: "negative count",
+			count:       -1,
+			sep:         DefaultSeparator,
+			wantErr:     errInvalidLength,
+		},
+	}
+
+	known := map[string]bool{}
+	for _, w := range WordList {
+		known[w] = true
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Words(tc.count, tc.sep)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			words := strings.Split(got, tc.sep)
+			if diff := cmp.Diff(len(words), tc.count); diff != "" {
+				t.Errorf("incorrect word count; -got +want: %s", diff)
+			}
+			for _, w := range words {
+				if !known[w] {
+					t.Errorf("unknown word %q", w)
+				}
+			}
+		})
+	}
+}
+
+func TestWordList(t *testing.T) {
+	t.Parallel()
+
+	if diff := cmp.Diff(len(WordList), 256); diff != "" {
+		t.Errorf("incorrect word list size; -got +want: %s", diff)
+	}
+	seen := map[string]bool{}
+	for _, w := range WordList {
+		if seen[w] {
+			t.Errorf("duplicate word %q", w)
+		}
+		if strings.Contains(w, DefaultSeparator) || strings.Contains(w, " ") {
+			t.Errorf("word %q contains a separator", w)
+		}
+		seen[w] = true
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		count       int
+		size        int
+		want        float64
+	}{
+		{
+			description: "words",
+			count:       DefaultWords,
+			size:        len(WordList),
+			want:        64,
+		},
+		{
+			description: "binary",
+			count:       10,
+			size:        2,
+			want:        10,
+		},
+		{
+			description: "single symbol",
+			count:       10,
+			size:        1,
+			want:        0,
+		},
+		{
+			description: "no symbols",
+			count:       0,
+			size:        10,
+			want:        0,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(Entropy(tc.count, tc.size), tc.want); diff != "" {
+				t.Errorf("incorrect entropy; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkcs12 extracts private keys from PKCS#12 archives (i.e., .p12 and
+// .pfx files). Only the private key is extracted; certificates are ignored.
+// See RFC 7292.
+package pkcs12
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"unicode/utf16"
+
+	"github.com/youmark/pkcs8"
+)
+
+var (
+	errInvalidArchive     = errors.New("invalid PKCS#12 archive")
+	errUnsupportedArchive = errors.New("unsupported PKCS#12 archive")
+	errNoPrivateKey       = errors.New("PKCS#12 archive does not contain a private key")
+)
+
+var (
+	oidData               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidKeyBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidShroudedKeyBag     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidPBEWithSHA1And3DES = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBES2              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidSHA1               = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA512             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// Key derivation purposes; see RFC 7292 Appendix B.3.
+const (
+	idKey = 1
+	idIV  = 2
+	idMAC = 3
+)
+
+type pfx struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue   `asn1:"tag:0,explicit"`
+	Attributes []asn1.RawValue `asn1:"set,optional"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// PrivateKey returns the private key held in a DER-encoded PKCS#12 archive,
+// decrypting it with the supplied password. The returned key is of a type
+// returned by x509.ParsePKCS8PrivateKey. x509.IncorrectPasswordError is
+// returned if the password is incorrect.
+func PrivateKey(data []byte, password string) (interface{}, error) {
+	var p pfx
+	rest, err := asn1.Unmarshal(data, &p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data", errInvalidArchive)
+	}
+	if p.Version != 3 {
+		return nil, fmt.Errorf("%w: version %d", errUnsupportedArchive, p.Version)
+	}
+	if !p.AuthSafe.ContentType.Equal(oidData) {
+		return nil, fmt.Errorf("%w: only password integrity mode is supported", errUnsupportedArchive)
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(p.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+
+	if len(p.MacData.Mac.Digest) > 0 {
+		if err := verifyMAC(&p.MacData, authSafe, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var contents []contentInfo
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+	for _, ci := range contents {
+		var bags []byte
+		switch {
+		case ci.ContentType.Equal(oidData):
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &bags); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+			}
+		case ci.ContentType.Equal(oidEncryptedData):
+			// Certificates are typically encrypted separately from
+			// the private key, sometimes with algorithms that are
+			// not supported. Skip anything that can't be
+			// decrypted.
+			var ed encryptedData
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+			}
+			eci := ed.EncryptedContentInfo
+			if bags, err = decrypt(eci.ContentEncryptionAlgorithm, eci.EncryptedContent, password); err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+
+		key, err := findPrivateKey(bags, password)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+	return nil, errNoPrivateKey
+}
+
+// findPrivateKey returns the first private key in a SafeContents structure,
+// or nil if there is none.
+func findPrivateKey(data []byte, password string) (interface{}, error) {
+	var bags []safeBag
+	if _, err := asn1.Unmarshal(data, &bags); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+	for _, bag := range bags {
+		switch {
+		case bag.ID.Equal(oidKeyBag):
+			key, err := x509.ParsePKCS8PrivateKey(bag.Value.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+			}
+			return key, nil
+		case bag.ID.Equal(oidShroudedKeyBag):
+			return decryptPrivateKey(bag.Value.Bytes, password)
+		}
+	}
+	return nil, nil
+}
+
+// decryptPrivateKey decrypts a DER-encoded EncryptedPrivateKeyInfo.
+func decryptPrivateKey(der []byte, password string) (interface{}, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+
+	// PBES2 is also used for encrypted PKCS#8 keys, and handled by the
+	// same library. The password is used as-is, rather than encoded as
+	// for the schemes defined by PKCS#12.
+	if info.Algorithm.Algorithm.Equal(oidPBES2) {
+		key, _, err := pkcs8.ParsePrivateKey(der, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+		}
+		return key, nil
+	}
+
+	plain, err := decrypt(info.Algorithm, info.EncryptedData, password)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, x509.IncorrectPasswordError
+	}
+	return key, nil
+}
+
+// decrypt decrypts data encrypted using one of the password-based encryption
+// schemes defined by PKCS#12. Only the 3DES-based scheme is supported.
+func decrypt(alg pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	if !alg.Algorithm.Equal(oidPBEWithSHA1And3DES) {
+		return nil, fmt.Errorf("%w: encryption algorithm %s", errUnsupportedArchive, alg.Algorithm)
+	}
+	var params pbeParams
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+
+	pass := bmpString(password)
+	key := deriveKey(sha1.New, params.Salt, pass, params.Iterations, idKey, 24)
+	iv := deriveKey(sha1.New, params.Salt, pass, params.Iterations, idIV, des.BlockSize)
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidArchive, err)
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: encrypted data is not a multiple of the block size", errInvalidArchive)
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// Remove the PKCS#7 padding; if it is invalid, the password was
+	// almost certainly incorrect.
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() || !bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, x509.IncorrectPasswordError
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// verifyMAC checks the MAC over the archive's contents, which indicates
+// whether the password is correct.
+func verifyMAC(md *macData, content []byte, password string) error {
+	var h func() hash.Hash
+	switch alg := md.Mac.Algorithm.Algorithm; {
+	case alg.Equal(oidSHA1):
+		h = sha1.New
+	case alg.Equal(oidSHA256):
+		h = sha256.New
+	case alg.Equal(oidSHA512):
+		h = sha512.New
+	default:
+		return fmt.Errorf("%w: MAC algorithm %s", errUnsupportedArchive, alg)
+	}
+
+	key := deriveKey(h, md.MacSalt, bmpString(password), md.Iterations, idMAC, h().Size())
+	mac := hmac.New(h, key)
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return x509.IncorrectPasswordError
+	}
+	return nil
+}
+
+// bmpString encodes a password as a null-terminated big-endian UTF-16 string,
+// as required by the PKCS#12 key derivation function.
+func bmpString(s string) []byte {
+	var b []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(r>>8), byte(r))
+	}
+	return append(b, 0, 0)
+}
+
+// deriveKey implements the PKCS#12 key derivation function. See RFC 7292
+// Appendix B.2.
+func deriveKey(h func() hash.Hash, salt, password []byte, iterations int, id byte, size int) []byte {
+	u := h().Size()
+	v := h().BlockSize()
+
+	// fill concatenates copies of b to a multiple of v bytes.
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		n := v * ((len(b) + v - 1) / v)
+		out := make([]byte, n)
+		for i := 0; i < n; i += len(b) {
+			copy(out[i:], b)
+		}
+		return out
+	}
+
+	d := bytes.Repeat([]byte{id}, v)
+	in := append(fill(salt), fill(password)...)
+	var out []byte
+	for len(out) < size {
+		a := h()
+		a.Write(d)
+		a.Write(in)
+		sum := a.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			a.Reset()
+			a.Write(sum)
+			sum = a.Sum(sum[:0])
+		}
+		out = append(out, sum...)
+
+		// Add B + 1 to each v-byte block of the input, where B is
+		// the hash repeated to v bytes.
+		b := make([]byte, v)
+		for i := range b {
+			b[i] = sum[i%u]
+		}
+		for j := 0; j < len(in); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				s := int(in[j+k]) + int(b[k]) + carry
+				in[j+k] = byte(s)
+				carry = s >> 8
+			}
+		}
+	}
+	return out[:size]
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs12
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode archive: %v", err)
+	}
+	return b
+}
+
+func TestPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		archive     testdata.TestArchive
+		password    string
+		wantErr     error
+	}{
+		{
+			description: "PBES2 archive",
+			archive:     testdata.PKCS12RSA,
+			password:    testdata.PKCS12RSA.Password,
+		},
+		{
+			description: "PBES2 archive with incorrect password",
+			archive:     testdata.PKCS12RSA,
+			password:    "incorrect",
+			wantErr:     x509.IncorrectPasswordError,
+		},
+		{
+			description: "legacy archive",
+			archive:     testdata.PKCS12ECDSALegacy,
+			password:    testdata.PKCS12ECDSALegacy.Password,
+		},
+		{
+			description: "legacy archive with incorrect password",
+			archive:     testdata.PKCS12ECDSALegacy,
+			password:    "incorrect",
+			wantErr:     x509.IncorrectPasswordError,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := PrivateKey(mustDecode(t, tc.archive.Data), tc.password)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			signer, err := ssh.NewSignerFromKey(key)
+			if err != nil {
+				t.Fatalf("NewSignerFromKey() failed: %v", err)
+			}
+			if diff := cmp.Diff(base64.StdEncoding.EncodeToString(signer.PublicKey().Marshal()), tc.archive.Blob); diff != "" {
+				t.Errorf("incorrect key; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPrivateKeyInvalid(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		data        []byte
+		wantErr     error
+	}{
+		{
+			description: "not an archive",
+			data:        []byte(testdata.WithoutPassphrase.Private),
+			wantErr:     errInvalidArchive,
+		},
+		{
+			description: "truncated archive",
+			data:        mustDecode(t, testdata.PKCS12RSA.Data)[:100],
+			wantErr:     errInvalidArchive,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := PrivateKey(tc.data, "secret")
+		if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("%s: incorrect error; -got +want: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestBMPString(t *testing.T) {
+	t.Parallel()
+
+	// Passwords are null-terminated big-endian UTF-16.
+	if diff := cmp.Diff(bmpString("ab\u00e9"), []byte{0, 'a', 0, 'b', 0, 0xe9, 0, 0}); diff != "" {
+		t.Errorf("incorrect encoding; -got +want: %s", diff)
+	}
+}
@@ -0,0 +1,56 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/app"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/google/chrome-ssh-agent/go/popupui"
+)
+
+type popup struct {
+	manager keys.Manager
+	doc     *dom.Doc
+}
+
+func newPopup() *popup {
+	return &popup{
+		manager: keys.NewClient(message.NewLocalSender()),
+		doc:     dom.New(js.Null()),
+	}
+}
+
+func (a *popup) Name() string {
+	return "PopupUI"
+}
+
+func (a *popup) Init(ctx jsutil.AsyncContext, cleanup *jsutil.CleanupFuncs) error {
+	ui := popupui.New(a.manager, a.doc)
+	cleanup.Add(ui.Release)
+	return nil
+}
+
+func main() {
+	a := app.New(newPopup())
+	defer a.Release()
+	a.Run()
+}
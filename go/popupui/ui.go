@@ -0,0 +1,282 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package popupui defines the behavior underlying the extension's popup, which
+// offers quick access to load and unload configured keys.
+package popupui
+
+import (
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+)
+
+// UI implements the behavior underlying the extension's popup.
+type UI struct {
+	mgr              keys.Manager
+	dom              *dom.Doc
+	errorText        js.Value
+	keysData         js.Value
+	noKeys           js.Value
+	passphraseDialog *dom.Dialog
+	passphraseForm   js.Value
+	passphraseName   js.Value
+	passphraseField  js.Value
+	passphraseCancel js.Value
+	keys             []*displayedKey
+	// pending is the ID of the key awaiting a passphrase, or
+	// keys.InvalidID if no passphrase has been requested.
+	pending keys.ID
+	cleanup *jsutil.CleanupFuncs
+}
+
+// New returns a new UI instance that loads and unloads keys using the supplied
+// manager. domObj is the DOM instance corresponding to the document in which
+// the popup is displayed; its text is localized first.
+func New(mgr keys.Manager, domObj *dom.Doc) *UI {
+	domObj.Localize(i18n.Message)
+
+	result := &UI{
+		mgr:              mgr,
+		dom:              domObj,
+		errorText:        domObj.GetElement("errorMessage"),
+		keysData:         domObj.GetElement("keysData"),
+		noKeys:           domObj.GetElement("noKeys"),
+		passphraseDialog: dom.NewDialog(domObj.GetElement("passphraseDialog")),
+		passphraseForm:   domObj.GetElement("passphraseForm"),
+		passphraseName:   domObj.GetElement("passphraseKeyName"),
+		passphraseField:  domObj.GetElement("passphrase"),
+		passphraseCancel: domObj.GetElement("passphraseCancel"),
+		pending:          keys.InvalidID,
+		cleanup:          &jsutil.CleanupFuncs{},
+	}
+
+	// Add event handlers.
+	cf := result.cleanup
+	// Populate keys on initial display.
+	cf.Add(result.dom.OnDOMContentLoaded(result.updateKeys))
+	// Load the pending key once the user supplies its passphrase.
+	cf.Add(dom.OnSubmit(result.passphraseForm, result.submitPassphrase))
+	cf.Add(dom.OnClick(result.passphraseCancel, func(ctx jsutil.AsyncContext, _ dom.Event) {
+		result.passphraseDialog.Cancel()
+	}))
+	cf.Add(result.passphraseDialog.OnClose(func(ctx jsutil.AsyncContext, _ dom.Event) {
+		result.pending = keys.InvalidID
+		dom.SetValue(result.passphraseField, "")
+	}))
+	return result
+}
+
+// Release cleans up any resources when UI is no longer used.
+func (u *UI) Release() {
+	u.setKeys(nil)
+	u.cleanup.Do()
+}
+
+// setError updates the UI to display the supplied error. If the supplied error
+// is nil, then any displayed error is cleared.
+func (u *UI) setError(err error) {
+	// Clear any existing error
+	dom.RemoveChildren(u.errorText)
+
+	if err != nil {
+		jsutil.LogError("UI.setError(): %v", err)
+		dom.AppendChild(u.errorText, u.dom.NewText(err.Error()), nil)
+	}
+}
+
+// displayedKey is a key displayed in the popup.
+type displayedKey struct {
+	// ID is the unique ID of the configured key.
+	ID keys.ID
+	// Name is the name allocated to the key.
+	Name string
+	// Loaded indicates that the key is loaded into the agent.
+	Loaded bool
+	// Encrypted indicates that a passphrase is required to load the key.
+	Encrypted bool
+	cleanup   jsutil.CleanupFuncs
+}
+
+// mergeKeys returns the configured keys, annotated with whether each is
+// loaded. Unlike the Options UI, keys loaded from elsewhere are not displayed;
+// the popup only offers control of configured keys.
+func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*displayedKey {
+	loadedIDs := make(map[keys.ID]bool)
+	for _, l := range loaded {
+		if id := l.ID(); id != keys.InvalidID {
+			loadedIDs[id] = true
+		}
+	}
+
+	var result []*displayedKey
+	for _, c := range configured {
+		result = append(result, &displayedKey{
+			ID:        keys.ID(c.ID),
+			Name:      c.Name,
+			Loaded:    loadedIDs[keys.ID(c.ID)],
+			Encrypted: c.Encrypted,
+		})
+	}
+
+	// Sort to ensure consistent ordering.
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return result
+}
+
+// keyByID returns the displayed key with the specified ID, or nil if it is
+// not displayed.
+func (u *UI) keyByID(id keys.ID) *displayedKey {
+	for _, k := range u.keys {
+		if k.ID == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// keyByName returns the displayed key with the specified name, or nil if it
+// is not displayed.
+func (u *UI) keyByName(name string) *displayedKey {
+	for _, k := range u.keys {
+		if k.Name == name {
+			return k
+		}
+	}
+	return nil
+}
+
+// loadButtonID returns the value of the 'id' attribute assigned to the button
+// that loads or unloads the key.
+func loadButtonID(id keys.ID) string {
+	return fmt.Sprintf("toggle-%s", id)
+}
+
+// setKeys refreshes the UI to reflect the supplied keys.
+func (u *UI) setKeys(newKeys []*displayedKey) {
+	// Cleanup elements and resources for all previous keys.
+	dom.RemoveChildren(u.keysData)
+	for _, k := range u.keys {
+		k.cleanup.Do()
+	}
+
+	// Construct elements for new keys.
+	for _, k := range newKeys {
+		k := k
+		dom.AppendChild(u.keysData, u.dom.NewElement("tr"), func(row js.Value) {
+			// Key name
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				cell.Set("className", "keyName")
+				dom.AppendChild(cell, u.dom.NewText(k.Name), nil)
+			})
+
+			// Load/unload toggle
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				dom.AppendChild(cell, u.dom.NewElement("button"), func(btn js.Value) {
+					btn.Set("type", "button")
+					btn.Set("id", loadButtonID(k.ID))
+					label := i18n.Message("buttonLoad")
+					if k.Loaded {
+						label = i18n.Message("buttonUnload")
+					}
+					dom.AppendChild(btn, u.dom.NewText(label), nil)
+					k.cleanup.Add(dom.OnClick(btn, func(ctx jsutil.AsyncContext, evt dom.Event) {
+						if k.Loaded {
+							u.unload(ctx, k.ID)
+						} else {
+							u.load(ctx, k.ID)
+						}
+					}))
+				})
+			})
+		})
+	}
+
+	u.noKeys.Set("hidden", len(newKeys) > 0)
+	u.keys = newKeys
+}
+
+// updateKeys queries the manager for configured and loaded keys, then
+// refreshes the displayed keys.
+func (u *UI) updateKeys(ctx jsutil.AsyncContext) {
+	snapshot, err := u.mgr.Snapshot(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedGetKeys"))
+		return
+	}
+	u.setError(nil)
+	u.setKeys(mergeKeys(snapshot.Configured, snapshot.Loaded))
+}
+
+// load loads the key with the specified ID. If the private key is encrypted,
+// a dialog prompts the user for its passphrase first.
+func (u *UI) load(ctx jsutil.AsyncContext, id keys.ID) {
+	k := u.keyByID(id)
+	if k == nil {
+		u.setError(i18n.NewError("errLoadKeyNotFound", string(id)))
+		return
+	}
+
+	if k.Encrypted {
+		u.pending = id
+		dom.RemoveChildren(u.passphraseName)
+		dom.AppendChild(u.passphraseName, u.dom.NewText(k.Name), nil)
+		u.passphraseDialog.ShowModal()
+		return
+	}
+
+	u.doLoad(ctx, id, "")
+}
+
+// submitPassphrase loads the key awaiting a passphrase using the passphrase
+// entered by the user.
+func (u *UI) submitPassphrase(ctx jsutil.AsyncContext, _ dom.Event) {
+	id, passphrase := u.pending, dom.Value(u.passphraseField)
+	u.passphraseDialog.Close()
+	if id == keys.InvalidID {
+		return
+	}
+	u.doLoad(ctx, id, passphrase)
+}
+
+// doLoad loads the key with the specified ID using the supplied passphrase.
+func (u *UI) doLoad(ctx jsutil.AsyncContext, id keys.ID, passphrase string) {
+	if err := u.mgr.Load(ctx, id, passphrase); err != nil {
+		u.setError(i18n.Wrap(err, "failedLoadKey"))
+		return
+	}
+	u.updateKeys(ctx)
+}
+
+// unload unloads the key with the specified ID.
+func (u *UI) unload(ctx jsutil.AsyncContext, id keys.ID) {
+	if err := u.mgr.Unload(ctx, id); err != nil {
+		u.setError(i18n.Wrap(err, "failedUnloadKey", string(id)))
+		return
+	}
+	u.updateKeys(ctx)
+}
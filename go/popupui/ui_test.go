@@ -0,0 +1,249 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package popupui
+
+import (
+	"syscall/js"
+	"testing"
+
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/chrome-ssh-agent/go/testutil"
+	"github.com/google/chrome-ssh-agent/go/wait"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var (
+	// IDs are randomly generated; identify keys by name instead.
+	displayedKeyCmp = cmpopts.IgnoreFields(displayedKey{}, "ID", "cleanup")
+
+	popupHTMLData = string(testutil.MustReadRunfile("_main/html/popup.html"))
+)
+
+type testHarness struct {
+	manager keys.Manager
+	dom     *dom.Doc
+	UI      *UI
+
+	errorText        js.Value
+	passphraseDialog js.Value
+	passphraseInput  js.Value
+	passphraseOk     js.Value
+	passphraseCancel js.Value
+}
+
+func (h *testHarness) Release() {
+	h.UI.Release()
+}
+
+func newHarness() *testHarness {
+	msg := mfakes.NewHub()
+	mgr := keys.NewManager(agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+	msg.AddReceiver(keys.NewServer(mgr, nil))
+	domObj := dom.New(dt.NewDocForTesting(popupHTMLData))
+
+	return &testHarness{
+		manager:          mgr,
+		dom:              domObj,
+		UI:               New(keys.NewClient(msg), domObj),
+		errorText:        domObj.GetElement("errorMessage"),
+		passphraseDialog: domObj.GetElement("passphraseDialog"),
+		passphraseInput:  domObj.GetElement("passphrase"),
+		passphraseOk:     domObj.GetElement("passphraseOk"),
+		passphraseCancel: domObj.GetElement("passphraseCancel"),
+	}
+}
+
+func mustPoll(ctx jsutil.AsyncContext, done func() bool) {
+	if !wait.Default.Until(done) {
+		panic("timed out waiting for condition")
+	}
+}
+
+func (h *testHarness) waitDialogOpen(ctx jsutil.AsyncContext) {
+	mustPoll(ctx, func() bool { return h.passphraseDialog.Get("open").Bool() })
+}
+
+func (h *testHarness) waitDialogClosed(ctx jsutil.AsyncContext) {
+	mustPoll(ctx, func() bool { return !h.passphraseDialog.Get("open").Bool() })
+}
+
+func (h *testHarness) toggle(ctx jsutil.AsyncContext, name string) {
+	k := h.UI.keyByName(name)
+	if k == nil {
+		panic("key not displayed: " + name)
+	}
+	dom.DoClick(h.dom.GetElement(loadButtonID(k.ID)))
+}
+
+func (h *testHarness) waitLoaded(ctx jsutil.AsyncContext, name string, loaded bool) {
+	mustPoll(ctx, func() bool {
+		k := h.UI.keyByName(name)
+		return k != nil && k.Loaded == loaded
+	})
+}
+
+func TestUserActions(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description   string
+		sequence      func(ctx jsutil.AsyncContext, h *testHarness)
+		wantDisplayed []*displayedKey
+		wantErr       string
+	}{
+		{
+			description: "list keys",
+			sequence:    func(ctx jsutil.AsyncContext, h *testHarness) {},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true},
+				{Name: "plain"},
+			},
+		},
+		{
+			description: "load unencrypted key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				h.toggle(ctx, "plain")
+				h.waitLoaded(ctx, "plain", true)
+			},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true},
+				{Name: "plain", Loaded: true},
+			},
+		},
+		{
+			description: "unload key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				h.toggle(ctx, "plain")
+				h.waitLoaded(ctx, "plain", true)
+				h.toggle(ctx, "plain")
+				h.waitLoaded(ctx, "plain", false)
+			},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true},
+				{Name: "plain"},
+			},
+		},
+		{
+			description: "load encrypted key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				h.toggle(ctx, "encrypted")
+				h.waitDialogOpen(ctx)
+				dom.SetValue(h.passphraseInput, testdata.WithPassphrase.Passphrase)
+				dom.DoClick(h.passphraseOk)
+				h.waitDialogClosed(ctx)
+				h.waitLoaded(ctx, "encrypted", true)
+			},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true, Loaded: true},
+				{Name: "plain"},
+			},
+		},
+		{
+			description: "load encrypted key with incorrect passphrase",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				h.toggle(ctx, "encrypted")
+				h.waitDialogOpen(ctx)
+				dom.SetValue(h.passphraseInput, "incorrect-passphrase")
+				dom.DoClick(h.passphraseOk)
+				h.waitDialogClosed(ctx)
+				mustPoll(ctx, func() bool { return dom.TextContent(h.errorText) != "" })
+			},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true},
+				{Name: "plain"},
+			},
+			wantErr: "failed to load key: failed to decrypt key: failed to parse private key: x509: decryption password incorrect",
+		},
+		{
+			description: "cancel loading encrypted key",
+			sequence: func(ctx jsutil.AsyncContext, h *testHarness) {
+				h.toggle(ctx, "encrypted")
+				h.waitDialogOpen(ctx)
+				dom.DoClick(h.passphraseCancel)
+				h.waitDialogClosed(ctx)
+			},
+			wantDisplayed: []*displayedKey{
+				{Name: "encrypted", Encrypted: true},
+				{Name: "plain"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHarness()
+			defer h.Release()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if _, err := h.manager.Add(ctx, "plain", testdata.WithoutPassphrase.Private); err != nil {
+					t.Errorf("failed to add key: %v", err)
+					return
+				}
+				if _, err := h.manager.Add(ctx, "encrypted", testdata.WithPassphrase.Private); err != nil {
+					t.Errorf("failed to add key: %v", err)
+					return
+				}
+				h.UI.updateKeys(ctx)
+
+				tc.sequence(ctx, h)
+				if diff := cmp.Diff(h.UI.keys, tc.wantDisplayed, displayedKeyCmp); diff != "" {
+					t.Errorf("incorrect displayed keys; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(dom.TextContent(h.errorText), tc.wantErr); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestNoKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		noKeys := h.dom.GetElement("noKeys")
+		h.UI.updateKeys(ctx)
+		if noKeys.Get("hidden").Bool() {
+			t.Errorf("no keys message unexpectedly hidden")
+			return
+		}
+
+		if _, err := h.manager.Add(ctx, "plain", testdata.WithoutPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		h.UI.updateKeys(ctx)
+		if !noKeys.Get("hidden").Bool() {
+			t.Errorf("no keys message unexpectedly displayed")
+		}
+	})
+}
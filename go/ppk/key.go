@@ -0,0 +1,400 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ppk supports private keys in PuTTY's key file format (i.e., .ppk
+// files, as written by PuTTYgen). Versions 2 and 3 of the format are
+// supported. See:
+//
+//	https://the.earth.li/~sgtatham/putty/latest/htmldoc/AppendixC.html
+package ppk
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errInvalidKey         = errors.New("invalid PuTTY key")
+	errUnsupportedVersion = errors.New("unsupported PuTTY key file version; convert the key using a recent version of PuTTYgen")
+	errUnsupportedKey     = errors.New("unsupported PuTTY key")
+)
+
+const (
+	// headerPrefix begins the first line of a PuTTY key file, and is
+	// followed by the format version.
+	headerPrefix = "PuTTY-User-Key-File-"
+	// macKeyPrefix is hashed with the passphrase to derive the MAC key for
+	// version 2 key files.
+	macKeyPrefix = "putty-private-key-file-mac-key"
+)
+
+// Key is a private key read from a PuTTY key file. The private key itself
+// may be encrypted; the public key never is.
+type Key struct {
+	version    int
+	algorithm  string
+	encryption string
+	// Comment is the comment recorded in the key file.
+	Comment    string
+	pub        ssh.PublicKey
+	publicBlob []byte
+	private    []byte
+	mac        []byte
+
+	// Key derivation parameters, used by encrypted version 3 key files.
+	kdf         string
+	memory      uint32
+	passes      uint32
+	parallelism uint8
+	salt        []byte
+}
+
+// IsPPK returns true if the private key appears to be in PuTTY's key file
+// format. The key may still fail to parse with Parse.
+func IsPPK(privateKey []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(privateKey), []byte(headerPrefix))
+}
+
+// fields reads the 'Name: value' lines of a key file. The lines following a
+// '*-Lines' field are its base64-encoded value.
+type fields struct {
+	s *bufio.Scanner
+}
+
+// next returns the next field, which must have the supplied name.
+func (f *fields) next(name string) (string, error) {
+	if !f.s.Scan() {
+		return "", fmt.Errorf("%w: missing %s", errInvalidKey, name)
+	}
+	got, value, ok := strings.Cut(strings.TrimSpace(f.s.Text()), ": ")
+	if !ok || got != name {
+		return "", fmt.Errorf("%w: expected %s, got %q", errInvalidKey, name, got)
+	}
+	return value, nil
+}
+
+// blob reads a field holding the number of lines of base64-encoded data,
+// followed by the data itself.
+func (f *fields) blob(name string) ([]byte, error) {
+	value, err := f.next(name)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%w: invalid %s %q", errInvalidKey, name, value)
+	}
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if !f.s.Scan() {
+			return nil, fmt.Errorf("%w: truncated %s", errInvalidKey, name)
+		}
+		b.WriteString(strings.TrimSpace(f.s.Text()))
+	}
+	data, err := base64.StdEncoding.DecodeString(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s: %w", errInvalidKey, name, err)
+	}
+	return data, nil
+}
+
+// Parse parses a private key in PuTTY's key file format. The private key is
+// not decrypted; see Decrypt.
+func Parse(privateKey []byte) (*Key, error) {
+	if !IsPPK(privateKey) {
+		return nil, fmt.Errorf("%w: not a PuTTY key file", errInvalidKey)
+	}
+
+	f := &fields{s: bufio.NewScanner(bytes.NewReader(bytes.TrimSpace(privateKey)))}
+	if !f.s.Scan() {
+		return nil, fmt.Errorf("%w: missing header", errInvalidKey)
+	}
+	version, algorithm, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(f.s.Text()), headerPrefix), ": ")
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid header", errInvalidKey)
+	}
+
+	k := &Key{algorithm: algorithm}
+	switch version {
+	case "2":
+		k.version = 2
+	case "3":
+		k.version = 3
+	default:
+		return nil, fmt.Errorf("%w: version %s", errUnsupportedVersion, version)
+	}
+
+	var err error
+	if k.encryption, err = f.next("Encryption"); err != nil {
+		return nil, err
+	}
+	switch k.encryption {
+	case "none", "aes256-cbc":
+	default:
+		return nil, fmt.Errorf("%w: encryption %q", errUnsupportedKey, k.encryption)
+	}
+	if k.Comment, err = f.next("Comment"); err != nil {
+		return nil, err
+	}
+	if k.publicBlob, err = f.blob("Public-Lines"); err != nil {
+		return nil, err
+	}
+	if k.pub, err = ssh.ParsePublicKey(k.publicBlob); err != nil {
+		return nil, fmt.Errorf("%w: invalid public key: %w", errInvalidKey, err)
+	}
+	if k.pub.Type() != k.algorithm {
+		return nil, fmt.Errorf("%w: public key type %s does not match key type %s", errInvalidKey, k.pub.Type(), k.algorithm)
+	}
+
+	if k.version == 3 && k.Encrypted() {
+		if err := k.parseKDF(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if k.private, err = f.blob("Private-Lines"); err != nil {
+		return nil, err
+	}
+	mac, err := f.next("Private-MAC")
+	if err != nil {
+		return nil, err
+	}
+	if k.mac, err = hex.DecodeString(mac); err != nil {
+		return nil, fmt.Errorf("%w: invalid Private-MAC: %w", errInvalidKey, err)
+	}
+	return k, nil
+}
+
+// parseKDF reads the parameters used to derive keys from the passphrase in a
+// version 3 key file.
+func (k *Key) parseKDF(f *fields) error {
+	var err error
+	if k.kdf, err = f.next("Key-Derivation"); err != nil {
+		return err
+	}
+	if k.kdf != "Argon2id" && k.kdf != "Argon2i" {
+		return fmt.Errorf("%w: key derivation %q", errUnsupportedKey, k.kdf)
+	}
+	num := func(name string, bits int) (uint64, error) {
+		value, err := f.next(name)
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseUint(value, 10, bits)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("%w: invalid %s %q", errInvalidKey, name, value)
+		}
+		return n, nil
+	}
+	memory, err := num("Argon2-Memory", 32)
+	if err != nil {
+		return err
+	}
+	passes, err := num("Argon2-Passes", 32)
+	if err != nil {
+		return err
+	}
+	parallelism, err := num("Argon2-Parallelism", 8)
+	if err != nil {
+		return err
+	}
+	salt, err := f.next("Argon2-Salt")
+	if err != nil {
+		return err
+	}
+	if k.salt, err = hex.DecodeString(salt); err != nil {
+		return fmt.Errorf("%w: invalid Argon2-Salt: %w", errInvalidKey, err)
+	}
+	k.memory, k.passes, k.parallelism = uint32(memory), uint32(passes), uint8(parallelism)
+	return nil
+}
+
+// Encrypted returns true if a passphrase is required to decrypt the key.
+func (k *Key) Encrypted() bool {
+	return k.encryption != "none"
+}
+
+// PublicKey returns the key's public key.
+func (k *Key) PublicKey() ssh.PublicKey {
+	return k.pub
+}
+
+// deriveKeys returns the cipher key, initialization vector and MAC key
+// derived from the passphrase, along with the hash used for the MAC. The
+// cipher key and IV are nil if the key is not encrypted.
+func (k *Key) deriveKeys(passphrase string) (cipherKey, iv, macKey []byte, mac func() hash.Hash) {
+	if !k.Encrypted() {
+		passphrase = ""
+	}
+
+	if k.version == 2 {
+		h := sha1.New()
+		h.Write([]byte(macKeyPrefix))
+		h.Write([]byte(passphrase))
+		macKey = h.Sum(nil)
+		if k.Encrypted() {
+			// The cipher key is the concatenation of two SHA-1
+			// hashes, truncated to 256 bits. The IV is zero.
+			var buf []byte
+			for i := byte(0); i < 2; i++ {
+				h := sha1.New()
+				h.Write([]byte{0, 0, 0, i})
+				h.Write([]byte(passphrase))
+				buf = h.Sum(buf)
+			}
+			cipherKey, iv = buf[:32], make([]byte, aes.BlockSize)
+		}
+		return cipherKey, iv, macKey, sha1.New
+	}
+
+	if !k.Encrypted() {
+		// Unencrypted version 3 key files use an empty MAC key.
+		return nil, nil, nil, sha256.New
+	}
+	argon := argon2.IDKey
+	if k.kdf == "Argon2i" {
+		argon = argon2.Key
+	}
+	buf := argon([]byte(passphrase), k.salt, k.passes, k.memory, k.parallelism, 80)
+	return buf[:32], buf[32:48], buf[48:], sha256.New
+}
+
+// Decrypt decrypts the private key using the supplied passphrase, which is
+// ignored if the key is not encrypted. The returned key is a *rsa.PrivateKey,
+// *ecdsa.PrivateKey or ed25519.PrivateKey. x509.IncorrectPasswordError is
+// returned if the passphrase is incorrect.
+func (k *Key) Decrypt(passphrase string) (interface{}, error) {
+	cipherKey, iv, macKey, mac := k.deriveKeys(passphrase)
+
+	private := bytes.Clone(k.private)
+	if cipherKey != nil {
+		if len(private)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w: private key is not a multiple of the cipher block size", errInvalidKey)
+		}
+		block, err := aes.NewCipher(cipherKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(private, private)
+	}
+
+	h := hmac.New(mac, macKey)
+	h.Write(ssh.Marshal(struct {
+		Algorithm  string
+		Encryption string
+		Comment    string
+		Public     []byte
+		Private    []byte
+	}{k.algorithm, k.encryption, k.Comment, k.publicBlob, private}))
+	if subtle.ConstantTimeCompare(h.Sum(nil), k.mac) != 1 {
+		if k.Encrypted() {
+			return nil, x509.IncorrectPasswordError
+		}
+		return nil, fmt.Errorf("%w: MAC does not match", errInvalidKey)
+	}
+
+	return k.privateKey(private)
+}
+
+// privateKey parses the decrypted private section of the key file. Its
+// contents depend on the key type. Any padding added before encryption
+// follows it.
+func (k *Key) privateKey(private []byte) (interface{}, error) {
+	cpk, ok := k.pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: key type %s", errUnsupportedKey, k.algorithm)
+	}
+
+	switch pub := cpk.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		var priv struct {
+			D    *big.Int
+			P    *big.Int
+			Q    *big.Int
+			Iqmp *big.Int
+			Pad  []byte `ssh:"rest"`
+		}
+		if err := ssh.Unmarshal(private, &priv); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: *pub,
+			D:         priv.D,
+			Primes:    []*big.Int{priv.P, priv.Q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		key.Precompute()
+		return key, nil
+	case *ecdsa.PublicKey:
+		var priv struct {
+			D   *big.Int
+			Pad []byte `ssh:"rest"`
+		}
+		if err := ssh.Unmarshal(private, &priv); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		key := &ecdsa.PrivateKey{PublicKey: *pub, D: priv.D}
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		if ecdhPub, err := pub.ECDH(); err != nil || !ecdhKey.PublicKey().Equal(ecdhPub) {
+			return nil, fmt.Errorf("%w: private key does not match public key", errInvalidKey)
+		}
+		return key, nil
+	case ed25519.PublicKey:
+		var priv struct {
+			Seed []byte
+			Pad  []byte `ssh:"rest"`
+		}
+		if err := ssh.Unmarshal(private, &priv); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+		}
+		if len(priv.Seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("%w: invalid Ed25519 private key size %d", errInvalidKey, len(priv.Seed))
+		}
+		key := ed25519.NewKeyFromSeed(priv.Seed)
+		if !bytes.Equal(key.Public().(ed25519.PublicKey), pub) {
+			return nil, fmt.Errorf("%w: private key does not match public key", errInvalidKey)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: key type %s", errUnsupportedKey, k.algorithm)
+	}
+}
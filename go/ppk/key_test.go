@@ -0,0 +1,209 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ppk
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsPPK(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		want        bool
+	}{
+		{
+			description: "PuTTY key",
+			key:         testdata.PPKv2ED25519WithoutPassphrase.Private,
+			want:        true,
+		},
+		{
+			description: "OpenSSH key",
+			key:         testdata.ED25519WithoutPassphrase.Private,
+			want:        false,
+		},
+		{
+			description: "empty",
+			key:         "",
+			want:        false,
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := IsPPK([]byte(tc.key)); got != tc.want {
+			t.Errorf("%s: incorrect result; got %v, want %v", tc.description, got, tc.want)
+		}
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description   string
+		key           testdata.TestKey
+		passphrase    string
+		wantEncrypted bool
+		wantErr       error
+	}{
+		{
+			description:   "version 2 RSA key",
+			key:           testdata.PPKv2RSAWithPassphrase,
+			passphrase:    testdata.PPKv2RSAWithPassphrase.Passphrase,
+			wantEncrypted: true,
+		},
+		{
+			description:   "version 2 RSA key with incorrect passphrase",
+			key:           testdata.PPKv2RSAWithPassphrase,
+			passphrase:    "incorrect",
+			wantEncrypted: true,
+			wantErr:       x509.IncorrectPasswordError,
+		},
+		{
+			description: "version 2 unencrypted Ed25519 key",
+			key:         testdata.PPKv2ED25519WithoutPassphrase,
+		},
+		{
+			description: "version 2 unencrypted key ignores passphrase",
+			key:         testdata.PPKv2ED25519WithoutPassphrase,
+			passphrase:  "ignored",
+		},
+		{
+			description:   "version 3 Ed25519 key",
+			key:           testdata.PPKv3ED25519WithPassphrase,
+			passphrase:    testdata.PPKv3ED25519WithPassphrase.Passphrase,
+			wantEncrypted: true,
+		},
+		{
+			description:   "version 3 Ed25519 key with incorrect passphrase",
+			key:           testdata.PPKv3ED25519WithPassphrase,
+			passphrase:    "incorrect",
+			wantEncrypted: true,
+			wantErr:       x509.IncorrectPasswordError,
+		},
+		{
+			description: "version 3 unencrypted ECDSA key",
+			key:         testdata.PPKv3ECDSAWithoutPassphrase,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			k, err := Parse([]byte(tc.key.Private))
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if diff := cmp.Diff(k.Encrypted(), tc.wantEncrypted); diff != "" {
+				t.Errorf("incorrect encrypted; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(k.Comment, "ppk-test"); diff != "" {
+				t.Errorf("incorrect comment; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(base64.StdEncoding.EncodeToString(k.PublicKey().Marshal()), tc.key.Blob); diff != "" {
+				t.Errorf("incorrect public key; -got +want: %s", diff)
+			}
+
+			priv, err := k.Decrypt(tc.passphrase)
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			signer, err := ssh.NewSignerFromKey(priv)
+			if err != nil {
+				t.Fatalf("NewSignerFromKey() failed: %v", err)
+			}
+			if diff := cmp.Diff(base64.StdEncoding.EncodeToString(signer.PublicKey().Marshal()), tc.key.Blob); diff != "" {
+				t.Errorf("incorrect decrypted key; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         string
+		wantErr     error
+	}{
+		{
+			description: "not a PuTTY key",
+			key:         testdata.ED25519WithoutPassphrase.Private,
+			wantErr:     errInvalidKey,
+		},
+		{
+			description: "version 1 key file",
+			key:         strings.Replace(testdata.PPKv2ED25519WithoutPassphrase.Private, "File-2", "File-1", 1),
+			wantErr:     errUnsupportedVersion,
+		},
+		{
+			description: "unsupported encryption",
+			key:         strings.Replace(testdata.PPKv2RSAWithPassphrase.Private, "aes256-cbc", "aes128-cbc", 1),
+			wantErr:     errUnsupportedKey,
+		},
+		{
+			description: "unsupported key derivation",
+			key:         strings.Replace(testdata.PPKv3ED25519WithPassphrase.Private, "Argon2id", "Argon2d", 1),
+			wantErr:     errUnsupportedKey,
+		},
+		{
+			description: "mismatched key type",
+			key:         strings.Replace(testdata.PPKv2ED25519WithoutPassphrase.Private, ": ssh-ed25519", ": ssh-rsa", 1),
+			wantErr:     errInvalidKey,
+		},
+		{
+			description: "missing MAC",
+			key:         testdata.PPKv2ED25519WithoutPassphrase.Private[:strings.Index(testdata.PPKv2ED25519WithoutPassphrase.Private, "Private-MAC")],
+			wantErr:     errInvalidKey,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := Parse([]byte(tc.key))
+		if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("%s: incorrect error; -got +want: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestDecryptModified(t *testing.T) {
+	t.Parallel()
+
+	// Modifying the comment invalidates the MAC.
+	k, err := Parse([]byte(strings.Replace(testdata.PPKv2ED25519WithoutPassphrase.Private, "Comment: ppk-test", "Comment: modified", 1)))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	_, err = k.Decrypt("")
+	if diff := cmp.Diff(err, errInvalidKey, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("incorrect error; -got +want: %s", diff)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/app"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/google/chrome-ssh-agent/go/pubkeysui"
+)
+
+type pubkeys struct {
+	manager  keys.Manager
+	listener message.Listener
+	doc      *dom.Doc
+}
+
+func newPubkeys() *pubkeys {
+	msg := message.NewLocalSender()
+	return &pubkeys{
+		manager:  keys.NewClient(msg),
+		listener: msg,
+		doc:      dom.New(js.Null()),
+	}
+}
+
+func (a *pubkeys) Name() string {
+	return "PubkeysUI"
+}
+
+func (a *pubkeys) Init(ctx jsutil.AsyncContext, cleanup *jsutil.CleanupFuncs) error {
+	ui := pubkeysui.New(a.manager, a.listener, a.doc)
+	cleanup.Add(ui.Release)
+	return nil
+}
+
+func main() {
+	a := app.New(newPubkeys())
+	defer a.Release()
+	a.Run()
+}
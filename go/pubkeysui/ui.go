@@ -0,0 +1,232 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pubkeysui defines the behavior underlying the extension's public
+// keys page, which lists the public keys of configured keys for sharing (e.g.,
+// while screen sharing) without offering any control over the keys.
+package pubkeysui
+
+import (
+	"sort"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/chrome/i18n"
+	"github.com/google/chrome-ssh-agent/go/dom"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+)
+
+// UI implements the behavior underlying the extension's public keys page.
+type UI struct {
+	mgr        keys.Manager
+	dom        *dom.Doc
+	errorText  js.Value
+	keysData   js.Value
+	noKeys     js.Value
+	copyButton js.Value
+	copyResult js.Value
+	keys       []*displayedKey
+	cleanup    *jsutil.CleanupFuncs
+}
+
+// New returns a new UI instance that lists the public keys of keys configured
+// in the supplied manager, refreshing them when lst announces that keys have
+// changed. domObj is the DOM instance corresponding to the document in which
+// the page is displayed; its text is localized first.
+func New(mgr keys.Manager, lst message.Listener, domObj *dom.Doc) *UI {
+	domObj.Localize(i18n.Message)
+
+	result := &UI{
+		mgr:        mgr,
+		dom:        domObj,
+		errorText:  domObj.GetElement("errorMessage"),
+		keysData:   domObj.GetElement("keysData"),
+		noKeys:     domObj.GetElement("noKeys"),
+		copyButton: domObj.GetElement("copyAll"),
+		copyResult: domObj.GetElement("copyResult"),
+		cleanup:    &jsutil.CleanupFuncs{},
+	}
+
+	// Add event handlers.
+	cf := result.cleanup
+	// Populate keys on initial display.
+	cf.Add(result.dom.OnDOMContentLoaded(result.updateKeys))
+	// Refresh keys when they are changed elsewhere.
+	cf.Add(keys.WatchChanges(lst, result.updateKeys))
+	cf.Add(dom.OnClick(result.copyButton, result.copyAll))
+	return result
+}
+
+// Release cleans up any resources when UI is no longer used.
+func (u *UI) Release() {
+	u.cleanup.Do()
+}
+
+// setError updates the UI to display the supplied error. If the supplied error
+// is nil, then any displayed error is cleared.
+func (u *UI) setError(err error) {
+	// Clear any existing error
+	dom.RemoveChildren(u.errorText)
+
+	if err != nil {
+		jsutil.LogError("UI.setError(): %v", err)
+		dom.AppendChild(u.errorText, u.dom.NewText(err.Error()), nil)
+	}
+}
+
+// displayedKey is a key whose public key is displayed on the page.
+type displayedKey struct {
+	// Name is the name allocated to the key.
+	Name string
+	// Fingerprint is the SHA256 fingerprint of the key, or an empty
+	// string if it cannot be determined without the passphrase.
+	Fingerprint string
+	// AuthorizedKey is the public key as a line in authorized_keys
+	// format, with the key's name as the comment, or an empty string if
+	// it cannot be determined without the passphrase.
+	AuthorizedKey string
+}
+
+// mergeKeys returns the public keys of the configured keys. The public key of
+// an encrypted key is not stored in a form that can be read without its
+// passphrase, so it is taken from the agent if the key is loaded. As with the
+// popup, keys loaded from elsewhere are not displayed.
+func mergeKeys(configured []*keys.ConfiguredKey, loaded []*keys.LoadedKey) []*displayedKey {
+	loadedByID := make(map[keys.ID]*keys.LoadedKey)
+	for _, l := range loaded {
+		// A key with a certificate is loaded both with and without
+		// the certificate; display the plain public key.
+		if id := l.ID(); id != keys.InvalidID && !l.IsCertificate() {
+			loadedByID[id] = l
+		}
+	}
+
+	var result []*displayedKey
+	for _, c := range configured {
+		dk := &displayedKey{
+			Name:        c.Name,
+			Fingerprint: c.Fingerprint,
+		}
+		pub := c.PublicKey
+		if l := loadedByID[keys.ID(c.ID)]; pub == "" && l != nil {
+			pub = l.Type + " " + l.EncodedBlob()
+			dk.Fingerprint = l.Fingerprint
+		}
+		if pub != "" {
+			dk.AuthorizedKey = strings.TrimSpace(pub + " " + c.Name)
+		}
+		result = append(result, dk)
+	}
+
+	// Sort to ensure consistent ordering.
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// authorizedKeys returns the public keys that are known, in authorized_keys
+// format.
+func authorizedKeys(disp []*displayedKey) string {
+	var lines []string
+	for _, k := range disp {
+		if k.AuthorizedKey != "" {
+			lines = append(lines, k.AuthorizedKey+"\n")
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// setKeys refreshes the UI to reflect the supplied keys.
+func (u *UI) setKeys(newKeys []*displayedKey) {
+	dom.RemoveChildren(u.keysData)
+	for _, k := range newKeys {
+		k := k
+		dom.AppendChild(u.keysData, u.dom.NewElement("tr"), func(row js.Value) {
+			// Key name
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				cell.Set("className", "keyName")
+				dom.AppendChild(cell, u.dom.NewText(k.Name), nil)
+			})
+
+			// Public key
+			dom.AppendChild(row, u.dom.NewElement("td"), func(cell js.Value) {
+				if k.AuthorizedKey == "" {
+					cell.Set("className", "keyUnavailable")
+					dom.AppendChild(cell, u.dom.NewText(i18n.Message("publicKeyUnavailable")), nil)
+					return
+				}
+				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+					div.Set("className", "keyFingerprint")
+					dom.AppendChild(div, u.dom.NewText(k.Fingerprint), nil)
+				})
+				dom.AppendChild(cell, u.dom.NewElement("div"), func(div js.Value) {
+					div.Set("className", "keyBlob")
+					dom.AppendChild(div, u.dom.NewText(k.AuthorizedKey), nil)
+				})
+			})
+		})
+	}
+
+	u.noKeys.Set("hidden", len(newKeys) > 0)
+	u.copyButton.Set("disabled", authorizedKeys(newKeys) == "")
+	u.keys = newKeys
+}
+
+// updateKeys queries the manager for configured and loaded keys, then
+// refreshes the displayed keys.
+func (u *UI) updateKeys(ctx jsutil.AsyncContext) {
+	snapshot, err := u.mgr.Snapshot(ctx)
+	if err != nil {
+		u.setError(i18n.Wrap(err, "failedGetKeys"))
+		return
+	}
+	u.setError(nil)
+	u.setKeys(mergeKeys(snapshot.Configured, snapshot.Loaded))
+}
+
+// copyAll copies the public keys that are known to the clipboard, in
+// authorized_keys format.
+func (u *UI) copyAll(ctx jsutil.AsyncContext, _ dom.Event) {
+	dom.RemoveChildren(u.copyResult)
+	if err := writeClipboard(ctx, authorizedKeys(u.keys)); err != nil {
+		u.setError(i18n.Wrap(err, "failedCopyPublicKeys"))
+		return
+	}
+	u.setError(nil)
+	dom.AppendChild(u.copyResult, u.dom.NewText(i18n.Message("copiedPublicKeys")), nil)
+}
+
+var (
+	errClipboardUnavailable = i18n.NewError("errClipboardUnavailable")
+)
+
+// writeClipboard writes the text to the system clipboard.
+func writeClipboard(ctx jsutil.AsyncContext, text string) error {
+	clipboard := js.Global().Get("navigator")
+	if !clipboard.IsUndefined() {
+		clipboard = clipboard.Get("clipboard")
+	}
+	if clipboard.IsUndefined() {
+		return errClipboardUnavailable
+	}
+	if _, err := jsutil.AsPromise(clipboard.Call("writeText", text)).Await(ctx); err != nil {
+		return i18n.Wrap(err, "failedWriteClipboard")
+	}
+	return nil
+}
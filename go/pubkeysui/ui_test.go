@@ -0,0 +1,190 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubkeysui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"syscall/js"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/google/chrome-ssh-agent/go/dom"
+	dt "github.com/google/chrome-ssh-agent/go/dom/testing"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/chrome-ssh-agent/go/testutil"
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	pubkeysHTMLData = string(testutil.MustReadRunfile("_main/html/pubkeys.html"))
+)
+
+type testHarness struct {
+	manager keys.Manager
+	dom     *dom.Doc
+	UI      *UI
+
+	errorText js.Value
+	keysData  js.Value
+	noKeys    js.Value
+	copyAll   js.Value
+}
+
+func (h *testHarness) Release() {
+	h.UI.Release()
+}
+
+func newHarness() *testHarness {
+	msg := mfakes.NewHub()
+	mgr := keys.NewManager(agent.NewKeyring(), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+	msg.AddReceiver(keys.NewServer(mgr, nil))
+	domObj := dom.New(dt.NewDocForTesting(pubkeysHTMLData))
+
+	return &testHarness{
+		manager:   mgr,
+		dom:       domObj,
+		UI:        New(keys.NewClient(msg), msg, domObj),
+		errorText: domObj.GetElement("errorMessage"),
+		keysData:  domObj.GetElement("keysData"),
+		noKeys:    domObj.GetElement("noKeys"),
+		copyAll:   domObj.GetElement("copyAll"),
+	}
+}
+
+func fingerprint(blob string) string {
+	b, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode blob: %v", err))
+	}
+	pub, err := ssh.ParsePublicKey(b)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse public key: %v", err))
+	}
+	return ssh.FingerprintSHA256(pub)
+}
+
+func TestPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		for name, priv := range map[string]string{
+			"plain":     testdata.WithoutPassphrase.Private,
+			"encrypted": testdata.WithPassphrase.Private,
+			"openssh":   testdata.OpenSSHFormat.Private,
+		} {
+			if _, err := h.manager.Add(ctx, name, priv); err != nil {
+				t.Errorf("failed to add key %s: %v", name, err)
+				return
+			}
+		}
+		h.UI.updateKeys(ctx)
+
+		// The public key of an encrypted PEM key is unknown until it is
+		// loaded.
+		want := []*displayedKey{
+			{Name: "encrypted"},
+			{
+				Name:          "openssh",
+				Fingerprint:   fingerprint(testdata.OpenSSHFormat.Blob),
+				AuthorizedKey: fmt.Sprintf("%s %s openssh", testdata.OpenSSHFormat.Type, testdata.OpenSSHFormat.Blob),
+			},
+			{
+				Name:          "plain",
+				Fingerprint:   fingerprint(testdata.WithoutPassphrase.Blob),
+				AuthorizedKey: fmt.Sprintf("%s %s plain", testdata.WithoutPassphrase.Type, testdata.WithoutPassphrase.Blob),
+			},
+		}
+		if diff := cmp.Diff(h.UI.keys, want); diff != "" {
+			t.Errorf("incorrect displayed keys; -got +want: %s", diff)
+		}
+		wantAll := want[1].AuthorizedKey + "\n" + want[2].AuthorizedKey + "\n"
+		if diff := cmp.Diff(authorizedKeys(h.UI.keys), wantAll); diff != "" {
+			t.Errorf("incorrect authorized keys; -got +want: %s", diff)
+		}
+
+		// Once loaded, the public key of the encrypted key is known.
+		id := keys.InvalidID
+		configured, err := h.manager.Configured(ctx)
+		if err != nil {
+			t.Fatalf("failed to get configured keys: %v", err)
+		}
+		for _, c := range configured {
+			if c.Name == "encrypted" {
+				id = keys.ID(c.ID)
+			}
+		}
+		if err := h.manager.Load(ctx, id, testdata.WithPassphrase.Passphrase); err != nil {
+			t.Fatalf("failed to load key: %v", err)
+		}
+		h.UI.updateKeys(ctx)
+		want[0] = &displayedKey{
+			Name:          "encrypted",
+			Fingerprint:   fingerprint(testdata.WithPassphrase.Blob),
+			AuthorizedKey: fmt.Sprintf("%s %s encrypted", testdata.WithPassphrase.Type, testdata.WithPassphrase.Blob),
+		}
+		if diff := cmp.Diff(h.UI.keys, want); diff != "" {
+			t.Errorf("incorrect displayed keys after load; -got +want: %s", diff)
+		}
+
+		// The page offers no control over the keys.
+		if n := h.keysData.Call("querySelectorAll", "button, input, select").Length(); n != 0 {
+			t.Errorf("unexpected controls for keys: got %d, want 0", n)
+		}
+		if dom.TextContent(h.errorText) != "" {
+			t.Errorf("unexpected error: %s", dom.TextContent(h.errorText))
+		}
+	})
+}
+
+func TestNoKeys(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness()
+	defer h.Release()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		h.UI.updateKeys(ctx)
+		if h.noKeys.Get("hidden").Bool() {
+			t.Errorf("no keys message unexpectedly hidden")
+		}
+		if !h.copyAll.Get("disabled").Bool() {
+			t.Errorf("copy button unexpectedly enabled")
+		}
+
+		if _, err := h.manager.Add(ctx, "plain", testdata.WithoutPassphrase.Private); err != nil {
+			t.Errorf("failed to add key: %v", err)
+			return
+		}
+		h.UI.updateKeys(ctx)
+		if !h.noKeys.Get("hidden").Bool() {
+			t.Errorf("no keys message unexpectedly displayed")
+		}
+		if h.copyAll.Get("disabled").Bool() {
+			t.Errorf("copy button unexpectedly disabled")
+		}
+	})
+}
@@ -0,0 +1,159 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package relayport supports serving the SSH Agent protocol to remote
+// development environments (e.g., dev containers and cloud IDEs) through a
+// relay reachable over a WebSocket.
+//
+// The relay runs alongside the remote environment, listens on the socket
+// used by applications there, and forwards each connection accepted on it
+// over a single WebSocket opened by the extension. The extension first sends
+// a hello message carrying the token configured by the user; the relay must
+// close the WebSocket unless the token is the one it expects. Each subsequent
+// message is a JSON text message of the form exchanged with the native host;
+// see nativeport.
+package relayport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/nativeport"
+	"github.com/norunners/vert"
+)
+
+var (
+	// ErrInvalidURL indicates that the URL of a relay is invalid.
+	ErrInvalidURL = errors.New("invalid relay URL")
+	// ErrNoToken indicates that no token was supplied to authenticate to
+	// a relay.
+	ErrNoToken = errors.New("relay token is required")
+)
+
+const (
+	// msgHello authenticates the extension to the relay. It is only sent
+	// by the extension, and only once the WebSocket is open.
+	msgHello = "hello"
+)
+
+// hello is the first message sent to the relay.
+type hello struct {
+	// Type is always msgHello.
+	Type string `js:"type"`
+	// Token is the token configured by the user.
+	Token string `js:"token"`
+}
+
+// ParseURL parses the URL of a relay, returning it in canonical form. The
+// URL must use the wss scheme, such that the token is not sent in the clear,
+// unless the relay runs on the local machine (e.g., behind a forwarded port).
+func ParseURL(text string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(text))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURL, text)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch {
+	case u.Hostname() == "" || u.User != nil || u.Fragment != "":
+		return "", fmt.Errorf("%w: %s", ErrInvalidURL, text)
+	case u.Scheme == "wss":
+	case u.Scheme == "ws" && isLoopback(u.Hostname()):
+	default:
+		return "", fmt.Errorf("%w: must use wss://: %s", ErrInvalidURL, text)
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), nil
+}
+
+// isLoopback returns true if host refers to the local machine. Only the hosts
+// permitted for ws:// by the extension's content security policy (see
+// manifest.json) are recognized.
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.Equal(net.IPv4(127, 0, 0, 1)) || ip.Equal(net.IPv6loopback))
+}
+
+// Connect opens a WebSocket to the relay at relayURL using ctor (the global
+// WebSocket class if undefined), authenticates using token, and returns a
+// Bridge that forwards the connections accepted by the relay. serve and clk
+// are as for nativeport.New. disconnected is invoked once the relay closes
+// the WebSocket (e.g., because the token was rejected) or it fails to open,
+// but not if the Bridge is closed using Close.
+func Connect(ctor js.Value, relayURL, token string, serve func(c *nativeport.Conn), disconnected func(err error), clk clock.Clock) (*nativeport.Bridge, error) {
+	if _, err := ParseURL(relayURL); err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	// The Bridge exchanges messages over an object with the methods of a
+	// chrome.runtime.Port, which forwards them over the WebSocket.
+	port := jsutil.NewObject()
+	b := nativeport.New(port, serve, clk)
+
+	var ws *jsutil.WebSocket
+	postMessage := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if err := ws.Send(jsutil.ToJSON(jsutil.SingleArg(args))); err != nil {
+			jsutil.LogError("relay: failed to send message: %v", err)
+		}
+		return nil
+	})
+	var disconnect js.Func
+	release := func() {
+		postMessage.Release()
+		disconnect.Release()
+	}
+	disconnect = js.FuncOf(func(_ js.Value, _ []js.Value) any {
+		ws.Close()
+		release()
+		return nil
+	})
+	port.Set("postMessage", postMessage)
+	port.Set("disconnect", disconnect)
+
+	ws, err := jsutil.NewWebSocket(ctor, relayURL,
+		func() {
+			msg := &hello{Type: msgHello, Token: token}
+			if err := ws.Send(jsutil.ToJSON(vert.ValueOf(msg).JSValue())); err != nil {
+				jsutil.LogError("relay: failed to authenticate: %v", err)
+			}
+		},
+		func(data string) {
+			if val := jsutil.FromJSON(data); !val.IsUndefined() {
+				b.OnMessage(val)
+			}
+		},
+		func(err error) {
+			b.OnDisconnect()
+			release()
+			disconnected(err)
+		})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,247 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitykey
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	errLocked = errors.New("agent is locked")
+)
+
+// added is a key backed by a security key that was added to the agent.
+type added struct {
+	signer  ssh.Signer
+	comment string
+}
+
+// Agent wraps an agent (typically a keyring), and additionally holds keys
+// backed by security keys, which the keyring does not support. Such keys are
+// added by supplying a *Key as the agent.AddedKey's PrivateKey; all other
+// keys are added to the wrapped agent.
+//
+// Agent implements the agent.ExtendedAgent interface.
+type Agent struct {
+	agent.Agent
+	auth Authenticator
+
+	mu sync.Mutex
+	// keys are the keys backed by security keys. Protected by mu.
+	keys []*added
+	// locked indicates that the wrapped agent is locked, in which case
+	// keys backed by security keys are also unavailable. Protected by mu.
+	locked bool
+}
+
+// NewAgent returns an Agent that wraps agt, and signs with keys backed by
+// security keys using auth.
+func NewAgent(agt agent.Agent, auth Authenticator) *Agent {
+	return &Agent{
+		Agent: agt,
+		auth:  auth,
+	}
+}
+
+// findLocked returns the index of the added key with the specified public
+// key, or -1 if there is none. a.mu must be held.
+func (a *Agent) findLocked(key ssh.PublicKey) int {
+	blob := key.Marshal()
+	for i, k := range a.keys {
+		if bytes.Equal(k.signer.PublicKey().Marshal(), blob) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add implements agent.Agent.Add.
+func (a *Agent) Add(key agent.AddedKey) error {
+	k, ok := key.PrivateKey.(*Key)
+	if !ok {
+		return a.Agent.Add(key)
+	}
+	if key.LifetimeSecs > 0 || key.ConfirmBeforeUse || len(key.ConstraintExtensions) > 0 {
+		return errors.New("constraints are not supported for security keys")
+	}
+
+	var signer ssh.Signer = NewSigner(k, a.auth)
+	if key.Certificate != nil {
+		var err error
+		if signer, err = ssh.NewCertSigner(key.Certificate, signer); err != nil {
+			return fmt.Errorf("failed to add certificate: %w", err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Adding a key again replaces it, just as for a keyring.
+	if i := a.findLocked(signer.PublicKey()); i >= 0 {
+		a.keys = append(a.keys[:i], a.keys[i+1:]...)
+	}
+	a.keys = append(a.keys, &added{signer: signer, comment: key.Comment})
+	return nil
+}
+
+// Remove implements agent.Agent.Remove.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	a.mu.Lock()
+	i := a.findLocked(key)
+	if i >= 0 {
+		a.keys = append(a.keys[:i], a.keys[i+1:]...)
+	}
+	a.mu.Unlock()
+	if i >= 0 {
+		return nil
+	}
+	return a.Agent.Remove(key)
+}
+
+// RemoveAll implements agent.Agent.RemoveAll.
+func (a *Agent) RemoveAll() error {
+	a.mu.Lock()
+	a.keys = nil
+	a.mu.Unlock()
+	return a.Agent.RemoveAll()
+}
+
+// Lock implements agent.Agent.Lock.
+func (a *Agent) Lock(passphrase []byte) error {
+	if err := a.Agent.Lock(passphrase); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.locked = true
+	a.mu.Unlock()
+	return nil
+}
+
+// Unlock implements agent.Agent.Unlock.
+func (a *Agent) Unlock(passphrase []byte) error {
+	if err := a.Agent.Unlock(passphrase); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.locked = false
+	a.mu.Unlock()
+	return nil
+}
+
+// List implements agent.Agent.List.
+func (a *Agent) List() ([]*agent.Key, error) {
+	result, err := a.Agent.List()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return result, nil
+	}
+	for _, k := range a.keys {
+		pub := k.signer.PublicKey()
+		result = append(result, &agent.Key{
+			Format:  pub.Type(),
+			Blob:    pub.Marshal(),
+			Comment: k.comment,
+		})
+	}
+	return result, nil
+}
+
+// Signers implements agent.Agent.Signers.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	result, err := a.Agent.Signers()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return result, nil
+	}
+	for _, k := range a.keys {
+		result = append(result, k.signer)
+	}
+	return result, nil
+}
+
+// signer returns the signer for the added key with the specified public key,
+// or nil if it is not backed by a security key.
+func (a *Agent) signer(key ssh.PublicKey) (ssh.Signer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	i := a.findLocked(key)
+	if i < 0 {
+		return nil, nil
+	}
+	if a.locked {
+		return nil, errLocked
+	}
+	return a.keys[i].signer, nil
+}
+
+// Sign implements agent.Agent.Sign.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	signer, err := a.signer(key)
+	if err != nil {
+		return nil, err
+	}
+	if signer == nil {
+		return a.Agent.Sign(key, data)
+	}
+	// The lock is not held while waiting for the user to touch the
+	// security key.
+	return signer.Sign(rand.Reader, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags. The flags only
+// select RSA signature algorithms, and so do not apply to security keys.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	signer, err := a.signer(key)
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		return signer.Sign(rand.Reader, data)
+	}
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Agent.Sign(key, data)
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,339 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitykey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	testOrigin = "chrome-extension://test"
+)
+
+var (
+	errFakeAuthenticator = errors.New("security key not present")
+)
+
+// fakeAuthenticator signs as the security key for testdata.SecurityKeyECDSA
+// would.
+type fakeAuthenticator struct {
+	priv *ecdsa.PrivateKey
+	// rpID, if non-empty, overrides the relying party ID that is signed.
+	rpID string
+	// flags are the flags returned by the authenticator.
+	flags byte
+	// err, if non-nil, is returned instead of an assertion.
+	err error
+	// requests are the requests received.
+	requests []*Request
+}
+
+func newFakeAuthenticator(t *testing.T) *fakeAuthenticator {
+	t.Helper()
+	block, _ := pem.Decode([]byte(testdata.SecurityKeyECDSA.Authenticator))
+	if block == nil {
+		t.Fatalf("failed to decode authenticator key")
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse authenticator key: %v", err)
+	}
+	return &fakeAuthenticator{
+		priv:  priv.(*ecdsa.PrivateKey),
+		flags: FlagUserPresence,
+	}
+}
+
+func (f *fakeAuthenticator) Authenticate(ctx jsutil.AsyncContext, req *Request) (*Assertion, error) {
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if req.CredentialID != testdata.SecurityKeyECDSA.KeyHandle {
+		return nil, fmt.Errorf("unknown credential %s", req.CredentialID)
+	}
+
+	challenge, err := base64.StdEncoding.DecodeString(req.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	clientData, err := json.Marshal(clientData{
+		Type:      webauthnGet,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    testOrigin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rpID := req.RPID
+	if f.rpID != "" {
+		rpID = f.rpID
+	}
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], f.flags, 0, 0, 0, 42)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, f.priv, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		Origin:            testOrigin,
+		AuthenticatorData: base64.StdEncoding.EncodeToString(authData),
+		ClientDataJSON:    base64.StdEncoding.EncodeToString(clientData),
+		Signature:         base64.StdEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// verifyWebAuthn verifies a signature made using WebAuthn, as an SSH server
+// would.
+func verifyWebAuthn(pub ssh.PublicKey, application string, data []byte, sig *ssh.Signature) error {
+	if sig.Format != webauthnSigFormat {
+		return fmt.Errorf("unexpected signature format %s", sig.Format)
+	}
+	var ecSig struct {
+		R *big.Int
+		S *big.Int
+	}
+	if err := ssh.Unmarshal(sig.Blob, &ecSig); err != nil {
+		return fmt.Errorf("failed to parse signature: %w", err)
+	}
+	var rest struct {
+		Flags      byte
+		Counter    uint32
+		Origin     string
+		ClientData []byte
+		Extensions []byte
+	}
+	if err := ssh.Unmarshal(sig.Rest, &rest); err != nil {
+		return fmt.Errorf("failed to parse signature: %w", err)
+	}
+
+	var cd clientData
+	if err := json.Unmarshal(rest.ClientData, &cd); err != nil {
+		return fmt.Errorf("failed to parse client data: %w", err)
+	}
+	if cd.Challenge != base64.RawURLEncoding.EncodeToString(data) {
+		return errors.New("client data does not match signed data")
+	}
+
+	appHash := sha256.Sum256([]byte(application))
+	clientDataHash := sha256.Sum256(rest.ClientData)
+	var signed bytes.Buffer
+	signed.Write(appHash[:])
+	signed.WriteByte(rest.Flags)
+	binary.Write(&signed, binary.BigEndian, rest.Counter)
+	signed.Write(rest.Extensions)
+	signed.Write(clientDataHash[:])
+	digest := sha256.Sum256(signed.Bytes())
+
+	ecPub := pub.(ssh.CryptoPublicKey).CryptoPublicKey().(*ecdsa.PublicKey)
+	if !ecdsa.Verify(ecPub, digest[:], ecSig.R, ecSig.S) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		configure   func(f *fakeAuthenticator)
+		wantErr     error
+	}{
+		{
+			description: "signs",
+			configure:   func(f *fakeAuthenticator) {},
+		},
+		{
+			description: "signed for different application",
+			configure: func(f *fakeAuthenticator) {
+				f.rpID = "example.com"
+			},
+			wantErr: errInvalidAssertion,
+		},
+		{
+			description: "user not present",
+			configure: func(f *fakeAuthenticator) {
+				f.flags = 0
+			},
+			wantErr: errInvalidAssertion,
+		},
+		{
+			description: "authenticator fails",
+			configure: func(f *fakeAuthenticator) {
+				f.err = errFakeAuthenticator
+			},
+			wantErr: errFakeAuthenticator,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			key, err := ParsePrivateKey([]byte(testdata.SecurityKeyECDSA.Private))
+			if err != nil {
+				t.Fatalf("failed to parse key: %v", err)
+			}
+			auth := newFakeAuthenticator(t)
+			tc.configure(auth)
+			agt := NewAgent(agent.NewKeyring(), auth)
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				if err := agt.Add(agent.AddedKey{PrivateKey: key, Comment: "sk"}); err != nil {
+					t.Errorf("Add() failed: %v", err)
+					return
+				}
+
+				data := []byte("data")
+				sig, err := agt.Sign(key.PublicKey(), data)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("Sign() returned incorrect error; -got +want:\n%s", diff)
+				}
+				if err != nil {
+					return
+				}
+				if err := verifyWebAuthn(key.PublicKey(), key.Application, data, sig); err != nil {
+					t.Errorf("Sign() returned signature that failed to verify: %v", err)
+				}
+
+				wantRequests := []*Request{
+					{
+						RPID:             "ssh:",
+						CredentialID:     testdata.SecurityKeyECDSA.KeyHandle,
+						Challenge:        base64.StdEncoding.EncodeToString(data),
+						UserVerification: false,
+					},
+				}
+				if diff := cmp.Diff(auth.requests, wantRequests); diff != "" {
+					t.Errorf("incorrect requests; -got +want:\n%s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	skKey, err := ParsePrivateKey([]byte(testdata.SecurityKeyECDSA.Private))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	agt := NewAgent(agent.NewKeyring(), newFakeAuthenticator(t))
+
+	list := func() []string {
+		t.Helper()
+		keys, err := agt.List()
+		if err != nil {
+			t.Errorf("List() failed: %v", err)
+			return nil
+		}
+		var result []string
+		for _, k := range keys {
+			result = append(result, k.Comment)
+		}
+		return result
+	}
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "plain"}); err != nil {
+			t.Errorf("Add(plain) failed: %v", err)
+			return
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: skKey, Comment: "sk"}); err != nil {
+			t.Errorf("Add(sk) failed: %v", err)
+			return
+		}
+		if err := agt.Add(agent.AddedKey{PrivateKey: skKey, Comment: "sk", ConfirmBeforeUse: true}); err == nil {
+			t.Errorf("Add(sk) with constraint succeeded; want error")
+		}
+		if diff := cmp.Diff(list(), []string{"plain", "sk"}); diff != "" {
+			t.Errorf("List() returned incorrect keys; -got +want:\n%s", diff)
+		}
+
+		// Keys not backed by a security key are signed by the
+		// wrapped agent.
+		sig, err := agt.Sign(signer.PublicKey(), []byte("data"))
+		if err != nil {
+			t.Errorf("Sign(plain) failed: %v", err)
+		} else if err := signer.PublicKey().Verify([]byte("data"), sig); err != nil {
+			t.Errorf("Sign(plain) returned invalid signature: %v", err)
+		}
+
+		// Locking the agent applies to all keys.
+		if err := agt.Lock([]byte("secret")); err != nil {
+			t.Errorf("Lock() failed: %v", err)
+		}
+		if diff := cmp.Diff(list(), []string(nil)); diff != "" {
+			t.Errorf("List() while locked returned incorrect keys; -got +want:\n%s", diff)
+		}
+		if _, err := agt.Sign(skKey.PublicKey(), []byte("data")); !errors.Is(err, errLocked) {
+			t.Errorf("Sign(sk) while locked returned error %v, want %v", err, errLocked)
+		}
+		if err := agt.Unlock([]byte("secret")); err != nil {
+			t.Errorf("Unlock() failed: %v", err)
+		}
+
+		if err := agt.Remove(skKey.PublicKey()); err != nil {
+			t.Errorf("Remove(sk) failed: %v", err)
+		}
+		if diff := cmp.Diff(list(), []string{"plain"}); diff != "" {
+			t.Errorf("List() after Remove() returned incorrect keys; -got +want:\n%s", diff)
+		}
+
+		if err := agt.RemoveAll(); err != nil {
+			t.Errorf("RemoveAll() failed: %v", err)
+		}
+		if diff := cmp.Diff(list(), []string(nil)); diff != "" {
+			t.Errorf("List() after RemoveAll() returned incorrect keys; -got +want:\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package securitykey supports SSH keys backed by a FIDO2 security key (i.e.,
+// keys generated with 'ssh-keygen -t ecdsa-sk'). The private key never leaves
+// the security key; signatures are requested from it using WebAuthn.
+package securitykey
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	// ErrNotSecurityKey indicates that a private key is not backed by a
+	// security key.
+	ErrNotSecurityKey = errors.New("not a security key")
+
+	errInvalidKey     = errors.New("invalid security key")
+	errEncrypted      = errors.New("security keys protected by a passphrase are not supported; remove the passphrase using 'ssh-keygen -p'")
+	errUnsupportedKey = errors.New("ed25519-sk keys cannot be used in the browser, since SSH servers do not accept WebAuthn signatures for them; use an ecdsa-sk key instead")
+)
+
+const (
+	// opensshBlockType is the PEM block type of OpenSSH private keys.
+	opensshBlockType = "OPENSSH PRIVATE KEY"
+	// opensshMagic prefixes the contents of OpenSSH private keys.
+	opensshMagic = "openssh-key-v1\x00"
+)
+
+// Flags requested by the key when it is used to sign. See:
+//
+//	https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.u2f
+const (
+	// FlagUserPresence indicates that the user must touch the security
+	// key for each signature.
+	FlagUserPresence = 0x01
+	// FlagUserVerification indicates that the user must also verify
+	// their identity (e.g., using a PIN) for each signature.
+	FlagUserVerification = 0x04
+)
+
+// Key is an SSH key backed by a security key. It holds no secret material;
+// it only identifies the credential held by the security key.
+type Key struct {
+	pub ssh.PublicKey
+	// Application is the FIDO application (i.e., the WebAuthn relying
+	// party ID) for which the credential was created, typically 'ssh:'.
+	Application string
+	// Flags are the Flag* values requested when signing.
+	Flags byte
+	// KeyHandle identifies the credential to the security key (i.e., the
+	// WebAuthn credential ID).
+	KeyHandle []byte
+	// Comment is the comment recorded in the private key file.
+	Comment string
+}
+
+// PublicKey returns the key's public key.
+func (k *Key) PublicKey() ssh.PublicKey {
+	return k.pub
+}
+
+// opensshKey is the outer structure of an OpenSSH private key. See:
+//
+//	https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+type opensshKey struct {
+	CipherName   string
+	KdfName      string
+	KdfOpts      string
+	NumKeys      uint32
+	PubKey       []byte
+	PrivKeyBlock []byte
+}
+
+// opensshPrivate is the (unencrypted) private section of an OpenSSH private
+// key. Its remaining contents depend on the key type.
+type opensshPrivate struct {
+	Check1  uint32
+	Check2  uint32
+	Keytype string
+	Rest    []byte `ssh:"rest"`
+}
+
+// skECDSAPrivate is the private section of an sk-ecdsa key following the key
+// type.
+type skECDSAPrivate struct {
+	Curve       string
+	Pub         []byte
+	Application string
+	Flags       byte
+	KeyHandle   []byte
+	Reserved    []byte
+	Comment     string
+	Pad         []byte `ssh:"rest"`
+}
+
+// decode returns the outer structure of an OpenSSH private key, or
+// ErrNotSecurityKey if the PEM-encoded key cannot be identified as an OpenSSH
+// key for a security key. Keys that are not, or are malformed, are left for
+// the SSH library to report.
+func decode(pemPrivateKey []byte) (*opensshKey, ssh.PublicKey, error) {
+	block, _ := pem.Decode(pemPrivateKey)
+	if block == nil || block.Type != opensshBlockType || !bytes.HasPrefix(block.Bytes, []byte(opensshMagic)) {
+		return nil, nil, ErrNotSecurityKey
+	}
+
+	var w opensshKey
+	if err := ssh.Unmarshal(block.Bytes[len(opensshMagic):], &w); err != nil || w.NumKeys != 1 {
+		return nil, nil, ErrNotSecurityKey
+	}
+	// Key types unknown to the SSH library are not security keys either.
+	pub, err := ssh.ParsePublicKey(w.PubKey)
+	if err != nil {
+		return nil, nil, ErrNotSecurityKey
+	}
+	switch pub.Type() {
+	case ssh.KeyAlgoSKECDSA256, ssh.KeyAlgoSKED25519:
+		return &w, pub, nil
+	default:
+		return nil, nil, ErrNotSecurityKey
+	}
+}
+
+// IsSecurityKey returns true if the PEM-encoded private key is backed by a
+// security key. The key may still fail to parse with ParsePrivateKey (e.g.,
+// if it is of an unsupported type).
+func IsSecurityKey(pemPrivateKey []byte) bool {
+	_, _, err := decode(pemPrivateKey)
+	return !errors.Is(err, ErrNotSecurityKey)
+}
+
+// ParsePrivateKey parses a PEM-encoded OpenSSH private key for a key backed by
+// a security key. ErrNotSecurityKey is returned if the key is not backed by a
+// security key.
+func ParsePrivateKey(pemPrivateKey []byte) (*Key, error) {
+	w, pub, err := decode(pemPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if w.CipherName != "none" || w.KdfName != "none" {
+		return nil, errEncrypted
+	}
+	if pub.Type() != ssh.KeyAlgoSKECDSA256 {
+		return nil, errUnsupportedKey
+	}
+
+	var priv opensshPrivate
+	if err := ssh.Unmarshal(w.PrivKeyBlock, &priv); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+	}
+	if priv.Check1 != priv.Check2 {
+		return nil, fmt.Errorf("%w: check bytes do not match", errInvalidKey)
+	}
+	if priv.Keytype != pub.Type() {
+		return nil, fmt.Errorf("%w: private key type %s does not match public key type %s", errInvalidKey, priv.Keytype, pub.Type())
+	}
+
+	var sk skECDSAPrivate
+	if err := ssh.Unmarshal(priv.Rest, &sk); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidKey, err)
+	}
+	if len(sk.KeyHandle) == 0 {
+		return nil, fmt.Errorf("%w: missing key handle", errInvalidKey)
+	}
+	return &Key{
+		pub:         pub,
+		Application: sk.Application,
+		Flags:       sk.Flags,
+		KeyHandle:   sk.KeyHandle,
+		Comment:     sk.Comment,
+	}, nil
+}
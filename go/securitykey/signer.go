@@ -0,0 +1,206 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitykey
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	errInvalidAssertion = errors.New("invalid response from security key")
+)
+
+const (
+	// webauthnSigFormat is the format of signatures made using WebAuthn.
+	// Unlike the format used by OpenSSH itself, the signed data includes
+	// the WebAuthn client data. OpenSSH accepts either. See:
+	//
+	//	https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.u2f
+	webauthnSigFormat = "webauthn-sk-ecdsa-sha2-nistp256@openssh.com"
+	// webauthnGet is the type recorded in the client data for WebAuthn
+	// assertions.
+	webauthnGet = "webauthn.get"
+	// authDataLen is the length of WebAuthn authenticator data without
+	// extensions: the relying party ID hash, flags, and signature counter.
+	authDataLen = sha256.Size + 1 + 4
+)
+
+// Request asks a security key to sign. Binary values are base64-encoded, such
+// that a Request can be passed between extension pages.
+type Request struct {
+	// ID identifies the request to the Authenticator.
+	ID string `js:"id"`
+	// RPID is the WebAuthn relying party ID; that is, the key's
+	// application.
+	RPID string `js:"rpId"`
+	// CredentialID is the WebAuthn credential ID; that is, the key's key
+	// handle.
+	CredentialID string `js:"credentialId"`
+	// Challenge is the data to be signed.
+	Challenge string `js:"challenge"`
+	// UserVerification indicates that the user must verify their identity
+	// (e.g., using a PIN).
+	UserVerification bool `js:"userVerification"`
+}
+
+// Assertion is the response of a security key to a Request, as returned by
+// WebAuthn. Binary values are base64-encoded.
+type Assertion struct {
+	// Origin is the origin of the page that made the WebAuthn request.
+	Origin string `js:"origin"`
+	// AuthenticatorData is the authenticator data signed by the security
+	// key.
+	AuthenticatorData string `js:"authenticatorData"`
+	// ClientDataJSON is the client data whose hash was signed by the
+	// security key.
+	ClientDataJSON string `js:"clientDataJSON"`
+	// Signature is the DER-encoded ECDSA signature.
+	Signature string `js:"signature"`
+}
+
+// Authenticator obtains signatures from security keys.
+type Authenticator interface {
+	// Authenticate asks the security key to sign as described by the
+	// request.
+	Authenticate(ctx jsutil.AsyncContext, req *Request) (*Assertion, error)
+}
+
+// Signer signs using a key backed by a security key.
+//
+// Signer implements the ssh.Signer interface.
+type Signer struct {
+	key  *Key
+	auth Authenticator
+}
+
+// NewSigner returns a Signer that signs with the key using auth.
+func NewSigner(key *Key, auth Authenticator) *Signer {
+	return &Signer{
+		key:  key,
+		auth: auth,
+	}
+}
+
+// PublicKey implements ssh.Signer.PublicKey.
+func (s *Signer) PublicKey() ssh.PublicKey {
+	return s.key.PublicKey()
+}
+
+// Sign implements ssh.Signer.Sign. Signatures are requested outside of an
+// AsyncContext, so the security key is asked asynchronously.
+func (s *Signer) Sign(_ io.Reader, data []byte) (*ssh.Signature, error) {
+	req := &Request{
+		RPID:             s.key.Application,
+		CredentialID:     base64.StdEncoding.EncodeToString(s.key.KeyHandle),
+		Challenge:        base64.StdEncoding.EncodeToString(data),
+		UserVerification: s.key.Flags&FlagUserVerification != 0,
+	}
+
+	var assertion *Assertion
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		assertion, err = s.auth.Authenticate(ctx, req)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign using security key: %w", err)
+	}
+	return s.key.signature(data, assertion)
+}
+
+// clientData is the subset of the WebAuthn client data that is checked.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// signature returns the SSH signature of data, given the security key's
+// response to the request to sign it.
+//
+// The response is checked as an SSH server would, such that an unusable
+// signature is reported here rather than as an authentication failure.
+func (k *Key) signature(data []byte, a *Assertion) (*ssh.Signature, error) {
+	authData, err := base64.StdEncoding.DecodeString(a.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid authenticator data: %w", errInvalidAssertion, err)
+	}
+	rawClientData, err := base64.StdEncoding.DecodeString(a.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid client data: %w", errInvalidAssertion, err)
+	}
+	der, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %w", errInvalidAssertion, err)
+	}
+
+	if len(authData) < authDataLen {
+		return nil, fmt.Errorf("%w: authenticator data too short", errInvalidAssertion)
+	}
+	rpIDHash := sha256.Sum256([]byte(k.Application))
+	if !bytes.Equal(authData[:sha256.Size], rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: signed for a different application than %q", errInvalidAssertion, k.Application)
+	}
+	flags := authData[sha256.Size]
+	if k.Flags&FlagUserPresence != 0 && flags&FlagUserPresence == 0 {
+		return nil, fmt.Errorf("%w: user presence not confirmed", errInvalidAssertion)
+	}
+	if k.Flags&FlagUserVerification != 0 && flags&FlagUserVerification == 0 {
+		return nil, fmt.Errorf("%w: user not verified", errInvalidAssertion)
+	}
+	counter := binary.BigEndian.Uint32(authData[sha256.Size+1 : authDataLen])
+	extensions := authData[authDataLen:]
+
+	var cd clientData
+	if err := json.Unmarshal(rawClientData, &cd); err != nil {
+		return nil, fmt.Errorf("%w: invalid client data: %w", errInvalidAssertion, err)
+	}
+	if cd.Type != webauthnGet || cd.Challenge != base64.RawURLEncoding.EncodeToString(data) || cd.Origin != a.Origin {
+		return nil, fmt.Errorf("%w: client data does not match request", errInvalidAssertion)
+	}
+
+	var ecSig struct {
+		R *big.Int
+		S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(der, &ecSig); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: invalid signature encoding", errInvalidAssertion)
+	}
+
+	return &ssh.Signature{
+		Format: webauthnSigFormat,
+		Blob:   ssh.Marshal(ecSig),
+		Rest: ssh.Marshal(struct {
+			Flags      byte
+			Counter    uint32
+			Origin     string
+			ClientData []byte
+			Extensions []byte
+		}{flags, counter, a.Origin, rawClientData, extensions}),
+	}, nil
+}
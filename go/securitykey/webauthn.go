@@ -0,0 +1,90 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitykey
+
+import (
+	"encoding/base64"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+const (
+	// webauthnTimeout is how long the browser waits for the user to
+	// touch the security key.
+	webauthnTimeout = time.Minute
+)
+
+// GetAssertion asks a security key to sign using WebAuthn. See:
+//
+//	https://developer.mozilla.org/en-US/docs/Web/API/CredentialsContainer/get
+//
+// WebAuthn is only available to pages, not the extension's background worker.
+func GetAssertion(ctx jsutil.AsyncContext, req *Request) (*Assertion, error) {
+	challenge, err := base64.StdEncoding.DecodeString(req.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid challenge: %w", err)
+	}
+	credentialID, err := base64.StdEncoding.DecodeString(req.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential ID: %w", err)
+	}
+
+	userVerification := "discouraged"
+	if req.UserVerification {
+		userVerification = "required"
+	}
+	opts := js.ValueOf(map[string]interface{}{
+		"publicKey": map[string]interface{}{
+			"challenge": jsutil.BytesToJS(challenge),
+			"rpId":      req.RPID,
+			"allowCredentials": []interface{}{
+				map[string]interface{}{
+					"type": "public-key",
+					"id":   jsutil.BytesToJS(credentialID),
+				},
+			},
+			"userVerification": userVerification,
+			"timeout":          webauthnTimeout.Milliseconds(),
+		},
+	})
+
+	credentials := js.Global().Get("navigator").Get("credentials")
+	if !credentials.Truthy() {
+		return nil, fmt.Errorf("WebAuthn is not available")
+	}
+	cred, err := jsutil.AsPromise(credentials.Call("get", opts)).Await(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("security key request failed: %w", err)
+	}
+	if !cred.Truthy() {
+		return nil, fmt.Errorf("security key request returned no credential")
+	}
+
+	rsp := cred.Get("response")
+	encode := func(name string) string {
+		return base64.StdEncoding.EncodeToString(jsutil.BytesFromJS(rsp.Get(name)))
+	}
+	return &Assertion{
+		Origin:            js.Global().Get("location").Get("origin").String(),
+		AuthenticatorData: encode("authenticatorData"),
+		ClientDataJSON:    encode("clientDataJSON"),
+		Signature:         encode("signature"),
+	}, nil
+}
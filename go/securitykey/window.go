@@ -0,0 +1,199 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securitykey
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/norunners/vert"
+)
+
+var (
+	errTimeout = errors.New("timed out waiting for security key")
+)
+
+const (
+	// RequestParam is the query string parameter holding the request for
+	// the page opened by WindowAuthenticator.
+	RequestParam = "securityKey"
+
+	// windowTimeout is how long to wait for a response from the window;
+	// it is longer than the time the browser waits for the user to touch
+	// the security key, such that the user's response normally arrives
+	// first.
+	windowTimeout = webauthnTimeout + 30*time.Second
+	// windowWidth and windowHeight are the size of the window.
+	windowWidth  = 480
+	windowHeight = 360
+)
+
+// Response is sent by the window opened by WindowAuthenticator once the
+// security key responds.
+type Response struct {
+	// ID identifies the request. The field's name distinguishes
+	// responses from other messages sent to the background worker.
+	ID string `js:"securityKeyResponse"`
+	// Assertion is the security key's response, if successful.
+	Assertion Assertion `js:"assertion"`
+	// Err describes why the request failed, or is empty if it succeeded.
+	Err string `js:"err"`
+}
+
+// WindowAuthenticator obtains signatures from security keys by opening a
+// window in which WebAuthn is used, since WebAuthn is not available to the
+// extension's background worker. The window must invoke Respond with the
+// request from its query string.
+//
+// Responses are sent to the background worker as messages; the worker must
+// forward them to OnMessage().
+//
+// WindowAuthenticator implements the Authenticator interface.
+type WindowAuthenticator struct {
+	windows js.Value
+	pageURL string
+	clock   clock.Clock
+
+	lock    sync.Mutex
+	pending map[string]chan *Response // Protected by lock.
+}
+
+// NewWindowAuthenticator returns a WindowAuthenticator that opens pageURL
+// using the supplied object implementing the chrome.windows API.  If the
+// object is null or undefined, chrome.windows is used.
+func NewWindowAuthenticator(windows js.Value, pageURL string, clk clock.Clock) *WindowAuthenticator {
+	if windows.IsUndefined() || windows.IsNull() {
+		windows = js.Global().Get("chrome").Get("windows")
+	}
+	return &WindowAuthenticator{
+		windows: windows,
+		pageURL: pageURL,
+		clock:   clk,
+		pending: map[string]chan *Response{},
+	}
+}
+
+func newRequestID() (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate request ID: %w", err)
+	}
+	return i.String(), nil
+}
+
+// Authenticate implements Authenticator.Authenticate.
+func (w *WindowAuthenticator) Authenticate(ctx jsutil.AsyncContext, req *Request) (*Assertion, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	r.ID = id
+
+	c := make(chan *Response, 1)
+	w.lock.Lock()
+	w.pending[id] = c
+	w.lock.Unlock()
+	defer func() {
+		w.lock.Lock()
+		delete(w.pending, id)
+		w.lock.Unlock()
+	}()
+
+	q := url.Values{RequestParam: {jsutil.ToJSON(vert.ValueOf(&r).JSValue())}}
+	opts := js.ValueOf(map[string]interface{}{
+		"url":    w.pageURL + "?" + q.Encode(),
+		"type":   "popup",
+		"width":  windowWidth,
+		"height": windowHeight,
+	})
+	jsutil.LogDebug("WindowAuthenticator.Authenticate: opening window for request %s", id)
+	win, err := jsutil.AsPromise(w.windows.Call("create", opts)).Await(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open window: %w", err)
+	}
+
+	select {
+	case rsp := <-c:
+		jsutil.LogDebug("WindowAuthenticator.Authenticate: request %s finished", id)
+		if rsp.Err != "" {
+			return nil, errors.New(rsp.Err)
+		}
+		return &rsp.Assertion, nil
+	case <-w.clock.After(windowTimeout):
+		// The user may have closed the window.
+		jsutil.LogDebug("WindowAuthenticator.Authenticate: request %s timed out", id)
+		if _, err := jsutil.AsPromise(w.windows.Call("remove", win.Get("id"))).Await(ctx); err != nil {
+			jsutil.LogDebug("WindowAuthenticator.Authenticate: failed to close window: %v", err)
+		}
+		return nil, errTimeout
+	}
+}
+
+// OnMessage must be invoked when the background worker receives a message.
+// It returns true if the message was a Response, in which case it should not
+// be processed further.
+func (w *WindowAuthenticator) OnMessage(msg js.Value) bool {
+	if msg.Type() != js.TypeObject || msg.Get("securityKeyResponse").Type() != js.TypeString {
+		return false
+	}
+
+	var rsp Response
+	if err := vert.ValueOf(msg).AssignTo(&rsp); err != nil {
+		jsutil.LogError("WindowAuthenticator.OnMessage: failed to parse response: %v", err)
+		return true
+	}
+
+	w.lock.Lock()
+	c, ok := w.pending[rsp.ID]
+	w.lock.Unlock()
+	if ok {
+		c <- &rsp
+	}
+	return true
+}
+
+// Respond performs the request passed to the window opened by
+// WindowAuthenticator in the RequestParam query string parameter, and sends
+// the response using sender.
+func Respond(ctx jsutil.AsyncContext, encoded string, sender message.Sender) error {
+	var req Request
+	if err := vert.ValueOf(jsutil.FromJSON(encoded)).AssignTo(&req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	rsp := Response{ID: req.ID}
+	if a, err := GetAssertion(ctx, &req); err != nil {
+		rsp.Err = err.Error()
+	} else {
+		rsp.Assertion = *a
+	}
+	if _, err := sender.Send(ctx, vert.ValueOf(&rsp).JSValue()); err != nil {
+		return fmt.Errorf("failed to send response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selftest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/agentport"
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	errMismatch = errors.New("unexpected result")
+)
+
+const (
+	// agentKeyComment is the comment attached to the temporary key loaded
+	// into the agent.
+	agentKeyComment = "chrome-ssh-agent-selftest"
+	// storageTestKey is the key under which a temporary value is stored.
+	storageTestKey = "selfTestValue"
+)
+
+// AgentRoundTrip returns a Check that adds a temporary key to the agent, signs
+// data with it, verifies the signature, and then removes the key.
+func AgentRoundTrip(agt agent.Agent) Check {
+	return Check{
+		Name: "agent round trip",
+		Run: func(_ jsutil.AsyncContext) error {
+			pub, priv, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return fmt.Errorf("failed to generate key: %w", err)
+			}
+			sshPub, err := ssh.NewPublicKey(pub)
+			if err != nil {
+				return fmt.Errorf("failed to convert public key: %w", err)
+			}
+
+			if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: agentKeyComment}); err != nil {
+				return fmt.Errorf("failed to add key: %w", err)
+			}
+			defer func() {
+				if err := agt.Remove(sshPub); err != nil {
+					jsutil.LogError("selftest: failed to remove temporary key: %v", err)
+				}
+			}()
+
+			keys, err := agt.List()
+			if err != nil {
+				return fmt.Errorf("failed to list keys: %w", err)
+			}
+			found := false
+			for _, k := range keys {
+				if bytes.Equal(k.Blob, sshPub.Marshal()) {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("%w: added key not listed", errMismatch)
+			}
+
+			data := []byte("chrome-ssh-agent self-test")
+			sig, err := agt.Sign(sshPub, data)
+			if err != nil {
+				return fmt.Errorf("failed to sign: %w", err)
+			}
+			if err := sshPub.Verify(data, sig); err != nil {
+				return fmt.Errorf("failed to verify signature: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// PortRoundTrip returns a Check that performs the same steps as
+// AgentRoundTrip, but through an AgentPort connected to the agent; that is,
+// along the path taken by requests from other extensions.
+func PortRoundTrip(agt agent.Agent) Check {
+	return Check{
+		Name: "agent port round trip",
+		Run: func(ctx jsutil.AsyncContext) error {
+			l := agentport.NewLoopback(clock.Real)
+			defer l.Close()
+			go func() {
+				if err := agent.ServeAgent(agt, l.Port); err != nil {
+					jsutil.LogDebug("selftest: agent stopped: %v", err)
+				}
+			}()
+			return AgentRoundTrip(agent.NewClient(l)).Run(ctx)
+		},
+	}
+}
+
+// StorageReadWrite returns a Check that writes a temporary value to the
+// storage area, reads it back, and then removes it. name identifies the
+// storage area.
+func StorageReadWrite(name string, area storage.Area) Check {
+	return Check{
+		Name: fmt.Sprintf("%s storage read/write", name),
+		Run: func(ctx jsutil.AsyncContext) error {
+			var b [16]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				return fmt.Errorf("failed to generate value: %w", err)
+			}
+			want := fmt.Sprintf("%x", b)
+
+			if err := area.Set(ctx, map[string]js.Value{storageTestKey: js.ValueOf(want)}); err != nil {
+				return fmt.Errorf("failed to write: %w", err)
+			}
+			defer func() {
+				if err := area.Delete(ctx, []string{storageTestKey}); err != nil {
+					jsutil.LogError("selftest: failed to remove temporary value: %v", err)
+				}
+			}()
+
+			data, err := area.Get(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read: %w", err)
+			}
+			got, ok := data[storageTestKey]
+			if !ok || got.Type() != js.TypeString || got.String() != want {
+				return fmt.Errorf("%w: value read does not match value written", errMismatch)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,124 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selftest verifies that the extension's core functionality works in
+// the environment in which it is installed. It is run after the extension is
+// updated, such that regressions are noticed quickly.
+package selftest
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+// Check is a single test of some functionality.
+type Check struct {
+	// Name is the human-readable name of the check.
+	Name string
+	// Run performs the check, returning an error if it failed.
+	Run func(ctx jsutil.AsyncContext) error
+}
+
+// Result is the outcome of a single Check.
+type Result struct {
+	// Name is the name of the check.
+	Name string `js:"name"`
+	// Err is the error reported by the check, or empty if it succeeded.
+	Err string `js:"err"`
+}
+
+// Report is the outcome of running a set of checks.
+type Report struct {
+	// Version is the version of the extension that was tested.
+	Version string `js:"version"`
+	// Time is the time at which the checks were run, in seconds since the
+	// Unix epoch.
+	Time int64 `js:"time"`
+	// Results are the outcomes of the individual checks.
+	Results []*Result `js:"results"`
+}
+
+// Failed returns the results for checks that failed.
+func (r *Report) Failed() []*Result {
+	var failed []*Result
+	for _, res := range r.Results {
+		if res.Err != "" {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Run runs the supplied checks in order. All checks are run, even if an
+// earlier one fails.
+func Run(ctx jsutil.AsyncContext, version string, checks []Check, now time.Time) *Report {
+	r := &Report{
+		Version: version,
+		Time:    now.Unix(),
+		Results: []*Result{},
+	}
+	for _, c := range checks {
+		jsutil.Log("selftest: running %s", c.Name)
+		res := &Result{Name: c.Name}
+		if err := c.Run(ctx); err != nil {
+			jsutil.LogError("selftest: %s failed: %v", c.Name, err)
+			res.Err = err.Error()
+		}
+		r.Results = append(r.Results, res)
+	}
+	return r
+}
+
+const (
+	// reportKey is the key under which the most recent report is stored.
+	reportKey = "selfTestReport"
+)
+
+// WriteReport stores the report in the supplied storage area, replacing any
+// previous report.
+func WriteReport(ctx jsutil.AsyncContext, area storage.Area, r *Report) error {
+	data := map[string]js.Value{
+		reportKey: vert.ValueOf(r).JSValue(),
+	}
+	if err := area.Set(ctx, data); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// ReadReport reads the most recent report from the supplied storage area. nil
+// is returned if no report has been stored.
+func ReadReport(ctx jsutil.AsyncContext, area storage.Area) (*Report, error) {
+	data, err := area.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	val, ok := data[reportKey]
+	if !ok || val.Type() != js.TypeObject {
+		return nil, nil
+	}
+
+	var r Report
+	if err := vert.ValueOf(val).AssignTo(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &r, nil
+}
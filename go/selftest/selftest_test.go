@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selftest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		var ran []string
+		checks := []Check{
+			{
+				Name: "fails",
+				Run: func(ctx jsutil.AsyncContext) error {
+					ran = append(ran, "fails")
+					return errors.New("failed")
+				},
+			},
+			{
+				Name: "succeeds",
+				Run: func(ctx jsutil.AsyncContext) error {
+					ran = append(ran, "succeeds")
+					return nil
+				},
+			},
+		}
+
+		got := Run(ctx, "1.2.3", checks, time.Unix(1000, 0))
+		want := &Report{
+			Version: "1.2.3",
+			Time:    1000,
+			Results: []*Result{
+				{Name: "fails", Err: "failed"},
+				{Name: "succeeds"},
+			},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect report; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(ran, []string{"fails", "succeeds"}); diff != "" {
+			t.Errorf("incorrect checks run; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(got.Failed(), []*Result{{Name: "fails", Err: "failed"}}); diff != "" {
+			t.Errorf("incorrect failed checks; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestReadWriteReport(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		area := storage.NewRaw(st.NewMemArea())
+
+		got, err := ReadReport(ctx, area)
+		if err != nil {
+			t.Fatalf("failed to read report: %v", err)
+		}
+		if got != nil {
+			t.Errorf("unexpected report: %+v", got)
+		}
+
+		want := &Report{
+			Version: "1.2.3",
+			Time:    1000,
+			Results: []*Result{
+				{Name: "fails", Err: "failed"},
+				{Name: "succeeds"},
+			},
+		}
+		if err := WriteReport(ctx, area, want); err != nil {
+			t.Fatalf("failed to write report: %v", err)
+		}
+		got, err = ReadReport(ctx, area)
+		if err != nil {
+			t.Fatalf("failed to read report: %v", err)
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect report; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestChecks(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		agt := agent.NewKeyring()
+		area := storage.NewRaw(st.NewMemArea())
+
+		r := Run(ctx, "1.2.3", []Check{
+			AgentRoundTrip(agt),
+			PortRoundTrip(agt),
+			StorageReadWrite("memory", area),
+		}, time.Now())
+		if diff := cmp.Diff(r.Failed(), []*Result(nil)); diff != "" {
+			t.Errorf("incorrect failed checks; -got +want: %s", diff)
+		}
+
+		// Ensure temporary data is cleaned up.
+		keys, err := agt.List()
+		if err != nil {
+			t.Fatalf("failed to list keys: %v", err)
+		}
+		if diff := cmp.Diff(len(keys), 0); diff != "" {
+			t.Errorf("incorrect number of keys in agent; -got +want: %s", diff)
+		}
+		data, err := area.Get(ctx)
+		if err != nil {
+			t.Fatalf("failed to read storage: %v", err)
+		}
+		if diff := cmp.Diff(len(data), 0); diff != "" {
+			t.Errorf("incorrect number of values in storage; -got +want: %s", diff)
+		}
+	})
+}
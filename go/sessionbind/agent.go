@@ -0,0 +1,191 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessionbind implements the session-bind@openssh.com agent protocol
+// extension, with which OpenSSH clients inform the agent of the SSH sessions
+// that use a connection to it. See:
+//
+//	https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.agent
+package sessionbind
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// ExtensionType is the name of the extension.
+	ExtensionType = "session-bind@openssh.com"
+	// maxBindings is the maximum number of sessions that may be bound to
+	// a connection, matching OpenSSH's agent.
+	maxBindings = 16
+)
+
+var (
+	errInvalidRequest = errors.New("invalid session-bind request")
+	errBadSignature   = errors.New("session-bind signature verification failed")
+	errTooManyBinds   = errors.New("too many sessions bound to connection")
+	errAlreadyBound   = errors.New("connection already bound for authentication")
+	errKeyMismatch    = errors.New("session ID already bound to a different host key")
+)
+
+// Binding records that a connection to the agent is used by an SSH session.
+type Binding struct {
+	// HostKey is the host key of the server with which the session was
+	// established.
+	HostKey ssh.PublicKey
+	// SessionID is the session's identifier (i.e., the exchange hash).
+	SessionID []byte
+	// Forwarding indicates that the connection is forwarded to the
+	// server. Otherwise, it is used to authenticate to the server.
+	Forwarding bool
+}
+
+// sessionBindMsg is the contents of a session-bind@openssh.com request.
+type sessionBindMsg struct {
+	HostKey    []byte
+	SessionID  []byte
+	Signature  []byte
+	Forwarding bool
+}
+
+// signature is an SSH signature in wire format.
+type signature struct {
+	Format string
+	Blob   []byte
+	Rest   []byte `ssh:"rest"`
+}
+
+// Agent wraps an agent, and handles session-bind@openssh.com requests. Other
+// extension requests are passed to the wrapped agent.
+//
+// An Agent must be created for each connection, since requests bind the
+// connection on which they are received.
+//
+// Agent implements the agent.ExtendedAgent interface.
+type Agent struct {
+	agent.Agent
+
+	mu sync.Mutex
+	// bindings are the sessions bound to the connection. Protected by mu.
+	bindings []*Binding
+}
+
+// New returns an Agent that wraps agt for a single connection.
+func New(agt agent.Agent) *Agent {
+	return &Agent{
+		Agent: agt,
+	}
+}
+
+// Bindings returns the sessions bound to the connection, in the order they
+// were bound.
+func (a *Agent) Bindings() []*Binding {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*Binding(nil), a.bindings...)
+}
+
+// parse parses and verifies a session-bind@openssh.com request. The host key
+// must have signed the session ID, proving that the server with the host key
+// participated in the session.
+func parse(contents []byte) (*Binding, error) {
+	var msg sessionBindMsg
+	if err := ssh.Unmarshal(contents, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+	hostKey, err := ssh.ParsePublicKey(msg.HostKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid host key: %w", errInvalidRequest, err)
+	}
+	var sig signature
+	if err := ssh.Unmarshal(msg.Signature, &sig); err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %w", errInvalidRequest, err)
+	}
+	if err := hostKey.Verify(msg.SessionID, &ssh.Signature{Format: sig.Format, Blob: sig.Blob, Rest: sig.Rest}); err != nil {
+		return nil, fmt.Errorf("%w: %w", errBadSignature, err)
+	}
+	return &Binding{
+		HostKey:    hostKey,
+		SessionID:  msg.SessionID,
+		Forwarding: msg.Forwarding,
+	}, nil
+}
+
+// bind records the binding, applying the same rules as OpenSSH's agent.
+func (a *Agent) bind(b *Binding) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.bindings) >= maxBindings {
+		return errTooManyBinds
+	}
+	for _, e := range a.bindings {
+		// A connection used for authentication is not forwarded, and
+		// so must not be bound to further sessions.
+		if !e.Forwarding {
+			return errAlreadyBound
+		}
+		if bytes.Equal(e.SessionID, b.SessionID) {
+			if bytes.Equal(e.HostKey.Marshal(), b.HostKey.Marshal()) {
+				return nil // Already recorded.
+			}
+			return errKeyMismatch
+		}
+	}
+	a.bindings = append(a.bindings, b)
+	return nil
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := a.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return a.Agent.Sign(key, data)
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if extensionType == ExtensionType {
+		b, err := parse(contents)
+		if err != nil {
+			jsutil.LogDebug("sessionbind.Agent: rejecting request: %v", err)
+			return nil, err
+		}
+		if err := a.bind(b); err != nil {
+			jsutil.LogDebug("sessionbind.Agent: rejecting binding to host key %s: %v", ssh.FingerprintSHA256(b.HostKey), err)
+			return nil, err
+		}
+		jsutil.LogDebug("sessionbind.Agent: bound to host key %s (forwarding=%t)", ssh.FingerprintSHA256(b.HostKey), b.Forwarding)
+		return nil, nil
+	}
+
+	if ext, ok := a.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
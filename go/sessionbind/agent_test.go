@@ -0,0 +1,183 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionbind
+
+import (
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func mustSigner(t *testing.T, key testdata.TestKey) ssh.Signer {
+	t.Helper()
+	signer, err := ssh.ParsePrivateKey([]byte(key.Private))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	return signer
+}
+
+// bindRequest returns the contents of a session-bind@openssh.com request, as
+// sent by an OpenSSH client. The session ID is signed by signer, which is
+// normally the host key.
+func bindRequest(t *testing.T, hostKey ssh.PublicKey, signer ssh.Signer, sessionID string, forwarding bool) []byte {
+	t.Helper()
+	sig, err := signer.Sign(rand.Reader, []byte(sessionID))
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return ssh.Marshal(sessionBindMsg{
+		HostKey:    hostKey.Marshal(),
+		SessionID:  []byte(sessionID),
+		Signature:  ssh.Marshal(sig),
+		Forwarding: forwarding,
+	})
+}
+
+func TestSessionBind(t *testing.T) {
+	t.Parallel()
+
+	host := mustSigner(t, testdata.ED25519WithoutPassphrase)
+	other := mustSigner(t, testdata.ECDSAWithoutPassphrase)
+
+	type request struct {
+		hostKey    ssh.PublicKey
+		signer     ssh.Signer
+		sessionID  string
+		forwarding bool
+	}
+	testcases := []struct {
+		description  string
+		requests     []request
+		wantErr      error
+		wantBindings []string
+	}{
+		{
+			description: "bind for authentication",
+			requests: []request{
+				{host.PublicKey(), host, "session-0", false},
+			},
+			wantBindings: []string{"session-0"},
+		},
+		{
+			description: "bind forwarded connection to multiple sessions",
+			requests: []request{
+				{host.PublicKey(), host, "session-0", true},
+				{other.PublicKey(), other, "session-1", false},
+			},
+			wantBindings: []string{"session-0", "session-1"},
+		},
+		{
+			description: "same session bound again",
+			requests: []request{
+				{host.PublicKey(), host, "session-0", true},
+				{host.PublicKey(), host, "session-0", true},
+			},
+			wantBindings: []string{"session-0"},
+		},
+		{
+			description: "reject binding after authentication",
+			requests: []request{
+				{host.PublicKey(), host, "session-0", false},
+				{host.PublicKey(), host, "session-1", false},
+			},
+			wantErr:      errAlreadyBound,
+			wantBindings: []string{"session-0"},
+		},
+		{
+			description: "reject session bound to different host key",
+			requests: []request{
+				{host.PublicKey(), host, "session-0", true},
+				{other.PublicKey(), other, "session-0", true},
+			},
+			wantErr:      errKeyMismatch,
+			wantBindings: []string{"session-0"},
+		},
+		{
+			description: "reject signature by different key",
+			requests: []request{
+				{host.PublicKey(), other, "session-0", false},
+			},
+			wantErr: errBadSignature,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			agt := New(agent.NewKeyring())
+			var err error
+			for _, r := range tc.requests {
+				_, err = agt.Extension(ExtensionType, bindRequest(t, r.hostKey, r.signer, r.sessionID, r.forwarding))
+			}
+			if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect error; -got +want: %s", diff)
+			}
+
+			var bound []string
+			for _, b := range agt.Bindings() {
+				bound = append(bound, string(b.SessionID))
+			}
+			if diff := cmp.Diff(bound, tc.wantBindings); diff != "" {
+				t.Errorf("incorrect bindings; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestMalformedRequest(t *testing.T) {
+	t.Parallel()
+
+	agt := New(agent.NewKeyring())
+	_, err := agt.Extension(ExtensionType, []byte("bogus"))
+	if diff := cmp.Diff(err, errInvalidRequest, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("incorrect error; -got +want: %s", diff)
+	}
+}
+
+func TestServeAgent(t *testing.T) {
+	t.Parallel()
+
+	host := mustSigner(t, testdata.ED25519WithoutPassphrase)
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	go agent.ServeAgent(New(agent.NewKeyring()), c2)
+	client := agent.NewClient(c1)
+
+	// Clients receive SSH_AGENT_SUCCESS rather than SSH_AGENT_FAILURE.
+	rsp, err := client.Extension(ExtensionType, bindRequest(t, host.PublicKey(), host, "session-0", false))
+	if err != nil {
+		t.Errorf("session-bind failed: %v", err)
+	}
+	if diff := cmp.Diff(rsp, []byte{6}); diff != "" {
+		t.Errorf("incorrect response; -got +want: %s", diff)
+	}
+
+	// Other extensions are passed to the wrapped agent.
+	_, err = client.Extension("unknown@example.com", nil)
+	if diff := cmp.Diff(err, agent.ErrExtensionUnsupported, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("incorrect error for unknown extension; -got +want: %s", diff)
+	}
+}
@@ -0,0 +1,158 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/norunners/vert"
+)
+
+// Client reads and writes settings by forwarding calls to a Server, such that
+// the extension's pages share the settings used by the background worker. Its
+// methods behave as those of Store.
+type Client struct {
+	msg message.Sender
+}
+
+// NewClient returns a Client that forwards calls to a Server using msg. Calls
+// fail with keys.ErrIncompatibleVersion if the Server uses a different
+// protocol version.
+func NewClient(msg message.Sender) *Client {
+	return &Client{msg: msg}
+}
+
+// call sends req to the Server, and parses the response into rsp. Responses
+// from a Server using a different protocol version are rejected with
+// keys.ErrIncompatibleVersion.
+func (c *Client) call(ctx jsutil.AsyncContext, req any, rsp any) error {
+	reqObj := vert.ValueOf(req).JSValue()
+	reqObj.Set("version", protocolVersion)
+	rspObj, err := c.msg.Send(ctx, reqObj)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	var header msgHeader
+	if err := vert.ValueOf(rspObj).AssignTo(&header); err != nil {
+		return fmt.Errorf("failed to parse response header: %w", err)
+	}
+	if header.Version != protocolVersion {
+		return fmt.Errorf("%w: background worker uses version %d, client uses version %d", keys.ErrIncompatibleVersion, header.Version, protocolVersion)
+	}
+	if err := vert.ValueOf(rspObj).AssignTo(rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.Get, except that the relay token is never returned;
+// see RelayTokenSet.
+func (c *Client) Get(ctx jsutil.AsyncContext) (*Settings, error) {
+	var rsp rspGet
+	if err := c.call(ctx, msgGet{Type: msgTypeGet}, &rsp); err != nil {
+		return nil, err
+	}
+	if err := makeErr(rsp.Err, rsp.Code); err != nil {
+		return nil, err
+	}
+	if rsp.Settings == nil {
+		return &Settings{}, nil
+	}
+	return rsp.Settings, nil
+}
+
+// Set implements Store.Set. Settings are validated before they are sent, as
+// well as by the Server.
+func (c *Client) Set(ctx jsutil.AsyncContext, settings *Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	var rsp rspSet
+	if err := c.call(ctx, msgSet{Type: msgTypeSet, Settings: settings}, &rsp); err != nil {
+		return err
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
+
+// Stored implements Store.Stored.
+func (c *Client) Stored(ctx jsutil.AsyncContext) (bool, error) {
+	var rsp rspStored
+	if err := c.call(ctx, msgStored{Type: msgTypeStored}, &rsp); err != nil {
+		return false, err
+	}
+	return rsp.Stored, makeErr(rsp.Err, rsp.Code)
+}
+
+// Managed implements Store.Managed.
+func (c *Client) Managed(ctx jsutil.AsyncContext) (map[string]bool, error) {
+	var rsp rspManaged
+	if err := c.call(ctx, msgManaged{Type: msgTypeManaged}, &rsp); err != nil {
+		return nil, err
+	}
+	if err := makeErr(rsp.Err, rsp.Code); err != nil {
+		return nil, err
+	}
+	if rsp.Managed == nil {
+		return map[string]bool{}, nil
+	}
+	return rsp.Managed, nil
+}
+
+// Restrictions implements Store.Restrictions.
+func (c *Client) Restrictions(ctx jsutil.AsyncContext) (*Restrictions, error) {
+	var rsp rspRestrictions
+	if err := c.call(ctx, msgRestrictions{Type: msgTypeRestrictions}, &rsp); err != nil {
+		return nil, err
+	}
+	if err := makeErr(rsp.Err, rsp.Code); err != nil {
+		return nil, err
+	}
+	if rsp.Restrictions == nil {
+		return &Restrictions{}, nil
+	}
+	return rsp.Restrictions, nil
+}
+
+// Capabilities implements Store.Capabilities.
+func (c *Client) Capabilities(ctx jsutil.AsyncContext) (*keys.Capabilities, error) {
+	r, err := c.Restrictions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.capabilities(), nil
+}
+
+// RelayTokenSet implements Store.RelayTokenSet.
+func (c *Client) RelayTokenSet(ctx jsutil.AsyncContext) (bool, error) {
+	var rsp rspRelayTokenSet
+	if err := c.call(ctx, msgRelayTokenSet{Type: msgTypeRelayTokenSet}, &rsp); err != nil {
+		return false, err
+	}
+	return rsp.Set, makeErr(rsp.Err, rsp.Code)
+}
+
+// SetRelayToken implements Store.SetRelayToken.
+func (c *Client) SetRelayToken(ctx jsutil.AsyncContext, token string) error {
+	var rsp rspSetRelayToken
+	if err := c.call(ctx, msgSetRelayToken{Type: msgTypeSetRelayToken, Token: token}, &rsp); err != nil {
+		return err
+	}
+	return makeErr(rsp.Err, rsp.Code)
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/norunners/vert"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		managed := fakes.NewManaged()
+		managed.SetPolicy(map[string]js.Value{
+			"verboseLogging":   js.ValueOf(true),
+			"disableKeyRemove": js.ValueOf(true),
+		})
+		store := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(store))
+		cli := NewClient(hub)
+
+		got, err := cli.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(got, &Settings{VerboseLogging: true}); diff != "" {
+			t.Errorf("incorrect initial settings; -got +want: %s", diff)
+		}
+
+		if stored, err := cli.Stored(ctx); err != nil || stored {
+			t.Errorf("Stored before Set: got (%v, %v), want (false, nil)", stored, err)
+		}
+
+		want := &Settings{
+			ApproveNewClients: true,
+			AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+			Theme:             ThemeDark,
+		}
+		if err := cli.Set(ctx, want); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+
+		// The settings are those stored by the Server.
+		got, err = store.Get(ctx)
+		if err != nil {
+			t.Errorf("Store.Get failed: %v", err)
+			return
+		}
+		want.VerboseLogging = true
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect stored settings; -got +want: %s", diff)
+		}
+		got, err = cli.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+
+		if stored, err := cli.Stored(ctx); err != nil || !stored {
+			t.Errorf("Stored after Set: got (%v, %v), want (true, nil)", stored, err)
+		}
+
+		gotManaged, err := cli.Managed(ctx)
+		if err != nil {
+			t.Errorf("Managed failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(gotManaged, map[string]bool{"verboseLogging": true, "disableKeyRemove": true}); diff != "" {
+			t.Errorf("incorrect managed settings; -got +want: %s", diff)
+		}
+
+		caps, err := cli.Capabilities(ctx)
+		if err != nil {
+			t.Errorf("Capabilities failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(caps, &keys.Capabilities{Add: true, SetLocal: true}); diff != "" {
+			t.Errorf("incorrect capabilities; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())))
+		cli := NewClient(hub)
+
+		if err := cli.Set(ctx, &Settings{ConnectionIdleTimeoutMinutes: 1}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Set with invalid settings: got %v, want %v", err, ErrInvalid)
+		}
+
+		// Messages fail if the background worker cannot be reached.
+		if _, err := NewClient(mfakes.NewHub()).Get(ctx); !errors.Is(err, message.ErrUnavailable) {
+			t.Errorf("Get without Server: got %v, want %v", err, message.ErrUnavailable)
+		}
+	})
+}
+
+// outdatedServer answers requests as a Server that predates protocol
+// versioning would.
+type outdatedServer struct{}
+
+func (outdatedServer) OnMessage(_ jsutil.AsyncContext, _ js.Value, _ js.Value) js.Value {
+	return vert.ValueOf(rspGet{Type: msgTypeGetRsp, Settings: &Settings{}}).JSValue()
+}
+
+func TestClientIncompatibleVersion(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		hub.AddReceiver(outdatedServer{})
+
+		if _, err := NewClient(hub).Get(ctx); !errors.Is(err, keys.ErrIncompatibleVersion) {
+			t.Errorf("incorrect error: got %v, want %v", err, keys.ErrIncompatibleVersion)
+		}
+	})
+}
+
+func TestClientRelayToken(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		store := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(store))
+		cli := NewClient(hub)
+
+		if set, err := cli.RelayTokenSet(ctx); err != nil || set {
+			t.Errorf("RelayTokenSet before SetRelayToken: got (%v, %v), want (false, nil)", set, err)
+		}
+		if err := cli.SetRelayToken(ctx, "s3cret"); err != nil {
+			t.Errorf("SetRelayToken failed: %v", err)
+			return
+		}
+		if set, err := cli.RelayTokenSet(ctx); err != nil || !set {
+			t.Errorf("RelayTokenSet after SetRelayToken: got (%v, %v), want (true, nil)", set, err)
+		}
+		if err := cli.SetRelayToken(ctx, " s3cret"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("SetRelayToken with invalid token: got %v, want %v", err, ErrInvalid)
+		}
+
+		// The token is stored, but never sent to the client.
+		if s, err := store.Get(ctx); err != nil || s.RelayToken != "s3cret" {
+			t.Errorf("token not stored: got (%+v, %v)", s, err)
+		}
+		got, err := cli.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if got.RelayToken != "" {
+			t.Errorf("relay token sent to client: got %q", got.RelayToken)
+		}
+	})
+}
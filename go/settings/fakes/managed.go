@@ -0,0 +1,158 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakes implements fakes of the settings storage for testing.
+package fakes
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/storage"
+)
+
+var (
+	// ErrReadOnly is returned when attempting to modify managed storage.
+	ErrReadOnly = errors.New("managed storage is read-only")
+)
+
+// Managed is a fake of managed storage (i.e., enterprise policy). As in
+// Chrome, the extension cannot modify managed storage; policy is instead
+// configured by the test, acting as the administrator.
+//
+// Managed implements storage.Area.
+type Managed struct {
+	mu       sync.Mutex
+	policy   map[string]js.Value
+	err      error
+	watchers map[int]storage.ChangeFunc
+	nextID   int
+}
+
+// NewManaged returns a new Managed with no policy configured.
+func NewManaged() *Managed {
+	return &Managed{
+		policy:   map[string]js.Value{},
+		watchers: map[int]storage.ChangeFunc{},
+	}
+}
+
+// SetPolicy replaces the configured policy. Watchers are notified of all
+// keys in the previous and new policy.
+func (m *Managed) SetPolicy(policy map[string]js.Value) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := map[string]bool{}
+	for k := range m.policy {
+		changed[k] = true
+	}
+	m.policy = map[string]js.Value{}
+	for k, v := range policy {
+		m.policy[k] = v
+		changed[k] = true
+	}
+
+	var keys []string
+	for k := range changed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, w := range m.watchers {
+		w := w
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			w(ctx, keys)
+			return js.Undefined(), nil
+		})
+	}
+}
+
+// SetUnavailable causes reads to fail with the specified error, as they do
+// on platforms where managed storage is not supported. A nil error makes
+// managed storage available again.
+func (m *Managed) SetUnavailable(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Set implements storage.Area.Set.
+func (m *Managed) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	return ErrReadOnly
+}
+
+// Get implements storage.Area.Get.
+func (m *Managed) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	result := map[string]js.Value{}
+	for k, v := range m.policy {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// Delete implements storage.Area.Delete.
+func (m *Managed) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	return ErrReadOnly
+}
+
+// Watch implements storage.Area.Watch.
+func (m *Managed) Watch(ctx jsutil.AsyncContext, callback storage.ChangeFunc) (jsutil.CleanupFunc, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextID
+	m.nextID++
+	m.watchers[id] = callback
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers, id)
+	}, nil
+}
+
+// BytesInUse implements storage.Area.BytesInUse. As in Chrome, each item uses
+// the length of its key and of its value in JSON format.
+func (m *Managed) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return 0, m.err
+	}
+	if keys == nil {
+		for k := range m.policy {
+			keys = append(keys, k)
+		}
+	}
+	n := 0
+	for _, k := range keys {
+		if v, ok := m.policy[k]; ok {
+			n += len(k) + len(jsutil.ToJSON(v))
+		}
+	}
+	return n, nil
+}
+
+// Quota implements storage.Area.Quota. Managed storage is not subject to a
+// quota.
+func (m *Managed) Quota() int {
+	return 0
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakes
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+var _ storage.Area = (*Managed)(nil)
+
+func TestManaged(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		m := NewManaged()
+
+		got, err := m.Get(ctx)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("unexpected policy: %v", got)
+		}
+
+		m.SetPolicy(map[string]js.Value{"disableKeyAdd": js.ValueOf(true)})
+		got, err = m.Get(ctx)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if diff := cmp.Diff(got["disableKeyAdd"].Bool(), true); diff != "" {
+			t.Errorf("incorrect policy; -got +want: %s", diff)
+		}
+
+		// The extension cannot modify policy.
+		if err := m.Set(ctx, map[string]js.Value{"disableKeyAdd": js.ValueOf(false)}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("incorrect Set error: got %v, want %v", err, ErrReadOnly)
+		}
+		if err := m.Delete(ctx, []string{"disableKeyAdd"}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("incorrect Delete error: got %v, want %v", err, ErrReadOnly)
+		}
+
+		// Policy is replaced, not merged.
+		m.SetPolicy(map[string]js.Value{"disableKeyRemove": js.ValueOf(true)})
+		got, err = m.Get(ctx)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if _, ok := got["disableKeyAdd"]; ok || len(got) != 1 {
+			t.Errorf("incorrect policy after replacement: %v", got)
+		}
+
+		unavailable := errors.New("unavailable")
+		m.SetUnavailable(unavailable)
+		if _, err := m.Get(ctx); !errors.Is(err, unavailable) {
+			t.Errorf("incorrect Get error: got %v, want %v", err, unavailable)
+		}
+		m.SetUnavailable(nil)
+		if _, err := m.Get(ctx); err != nil {
+			t.Errorf("Get failed after managed storage restored: %v", err)
+		}
+	})
+}
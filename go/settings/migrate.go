@@ -0,0 +1,142 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+const (
+	// versionKey is the name of the property of the stored settings
+	// holding the version of the schema in which they are stored.
+	versionKey = "version"
+	// unversioned is the version of settings stored before the schema was
+	// versioned.
+	unversioned = 1
+)
+
+// migration converts settings stored in one version of the schema to the
+// next, modifying them in place.
+//
+// Settings are synced between the user's devices, which may run different
+// versions of the extension; a device with an older version may store
+// settings in an older schema after they were migrated. Migrations must
+// therefore tolerate settings that are already (partially) migrated.
+type migration func(settings js.Value)
+
+// migrations convert stored settings to the current schema: migrations[i]
+// converts settings from version i+1 to version i+2. Append a migration
+// whenever a stored setting is renamed, or its meaning changes.
+var migrations = []migration{}
+
+// currentVersion returns the version of the schema produced by the
+// migrations.
+func currentVersion(migrations []migration) int {
+	return unversioned + len(migrations)
+}
+
+// versionOf returns the version of the schema in which the settings are
+// stored.
+func versionOf(settings js.Value) int {
+	v := settings.Get(versionKey)
+	if v.Type() != js.TypeNumber || v.Int() < unversioned {
+		return unversioned
+	}
+	return v.Int()
+}
+
+// migrate converts the settings to the current schema, modifying them in
+// place. It returns true if they were modified. Settings stored by a newer
+// version of the extension (e.g., on another of the user's devices) are
+// left unchanged; settings this version does not know about are ignored.
+func (s *Store) migrate(settings js.Value) bool {
+	version := versionOf(settings)
+	current := currentVersion(s.migrations)
+	if version >= current {
+		if version > current {
+			jsutil.LogDebug("settings: stored in newer version %d of schema; using as-is", version)
+		}
+		return false
+	}
+	for v := version; v < current; v++ {
+		s.migrations[v-unversioned](settings)
+	}
+	settings.Set(versionKey, current)
+	jsutil.Log("settings: migrated from version %d to %d of schema", version, current)
+	return true
+}
+
+// Migrate converts the settings configured by the user to the current
+// schema, and stores them. Settings are also migrated whenever they are read,
+// so this is only needed to store them in the current schema.
+//
+// Device settings synced by an earlier version of the extension are moved to
+// this device (unless already configured on it), and are no longer synced.
+func (s *Store) Migrate(ctx jsutil.AsyncContext) error {
+	user, err := s.user.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read settings: %w", err)
+	}
+	val, ok := user[settingsKey]
+	if !ok || val.Type() != js.TypeObject {
+		return nil
+	}
+	moved, err := s.moveDeviceSettings(ctx, val)
+	if err != nil {
+		return err
+	}
+	if !s.migrate(val) && !moved {
+		return nil
+	}
+	if err := s.user.Set(ctx, map[string]js.Value{settingsKey: val}); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// moveDeviceSettings moves device settings from the synced settings to this
+// device, modifying the synced settings in place. It returns true if they
+// were modified.
+func (s *Store) moveDeviceSettings(ctx jsutil.AsyncContext, synced js.Value) (bool, error) {
+	device, err := s.readDevice(ctx)
+	if err != nil {
+		return false, err
+	}
+	moved := false
+	for _, n := range deviceSettings {
+		v := synced.Get(n)
+		if v.IsUndefined() {
+			continue
+		}
+		if device.Get(n).IsUndefined() && v.Truthy() {
+			device.Set(n, v)
+		}
+		synced.Delete(n)
+		moved = true
+	}
+	if !moved {
+		return false, nil
+	}
+	if err := s.device.Set(ctx, map[string]js.Value{deviceSettingsKey: device}); err != nil {
+		return false, fmt.Errorf("failed to write device settings: %w", err)
+	}
+	jsutil.Log("settings: moved device settings from synced storage to this device")
+	return true, nil
+}
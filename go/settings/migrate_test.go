@@ -0,0 +1,258 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+// renameVerbose is a migration that renames a setting, as it was named in
+// a hypothetical earlier version.
+func renameVerbose(settings js.Value) {
+	if v := settings.Get("verbose"); !v.IsUndefined() {
+		settings.Set("verboseLogging", v)
+		settings.Delete("verbose")
+	}
+}
+
+// storedSettings returns the settings object stored under key in the area,
+// or nil if none is stored.
+func storedSettings(t *testing.T, ctx jsutil.AsyncContext, area storage.Area, key string) map[string]any {
+	t.Helper()
+
+	data, err := area.Get(ctx)
+	if err != nil {
+		t.Fatalf("failed to read storage: %v", err)
+	}
+	val, ok := data[key]
+	if !ok {
+		return nil
+	}
+	names, err := jsutil.ObjectKeys(val)
+	if err != nil {
+		t.Fatalf("failed to parse settings: %v", err)
+	}
+	result := map[string]any{}
+	for _, n := range names {
+		switch v := val.Get(n); v.Type() {
+		case js.TypeBoolean:
+			result[n] = v.Bool()
+		case js.TypeNumber:
+			result[n] = v.Int()
+		case js.TypeString:
+			result[n] = v.String()
+		}
+	}
+	return result
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		stored      map[string]any
+		want        *Settings
+		wantStored  map[string]any
+	}{
+		{
+			description: "nothing stored",
+			want:        &Settings{},
+		},
+		{
+			description: "migrate unversioned settings",
+			stored:      map[string]any{"verbose": true},
+			want:        &Settings{VerboseLogging: true},
+			wantStored:  map[string]any{"verboseLogging": true, versionKey: 2},
+		},
+		{
+			description: "current settings unchanged",
+			stored:      map[string]any{"verboseLogging": true, versionKey: 2},
+			want:        &Settings{VerboseLogging: true},
+			wantStored:  map[string]any{"verboseLogging": true, versionKey: 2},
+		},
+		{
+			description: "newer settings unchanged",
+			stored:      map[string]any{"verboseLogging": true, "verbose": false, versionKey: 3},
+			want:        &Settings{VerboseLogging: true},
+			wantStored:  map[string]any{"verboseLogging": true, "verbose": false, versionKey: 3},
+		},
+		{
+			description: "older version rewrote migrated settings",
+			stored:      map[string]any{"verboseLogging": false, "verbose": true},
+			want:        &Settings{VerboseLogging: true},
+			wantStored:  map[string]any{"verboseLogging": true, versionKey: 2},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				user := storage.NewRaw(st.NewMemArea())
+				s := NewStore(user, storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+				s.migrations = []migration{renameVerbose}
+
+				if tc.stored != nil {
+					if err := user.Set(ctx, map[string]js.Value{settingsKey: js.ValueOf(tc.stored)}); err != nil {
+						t.Fatalf("failed to store settings: %v", err)
+					}
+				}
+
+				// Settings are migrated when read, without being
+				// stored.
+				got, err := s.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect settings before migration; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(storedSettings(t, ctx, user, settingsKey), tc.stored); diff != "" {
+					t.Errorf("settings stored when read; -got +want: %s", diff)
+				}
+
+				if err := s.Migrate(ctx); err != nil {
+					t.Fatalf("Migrate failed: %v", err)
+				}
+				if diff := cmp.Diff(storedSettings(t, ctx, user, settingsKey), tc.wantStored); diff != "" {
+					t.Errorf("incorrect stored settings; -got +want: %s", diff)
+				}
+				got, err = s.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect settings after migration; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestMigrateDeviceSettings(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description  string
+		stored       map[string]any
+		storedDevice map[string]any
+		wantBefore   *Settings
+		want         *Settings
+		wantStored   map[string]any
+		wantDevice   map[string]any
+	}{
+		{
+			description: "synced relay moved to device",
+			stored:      map[string]any{"verboseLogging": true, "relayURL": "wss://relay.example.com/agent", "relayToken": "s3cret", versionKey: 1},
+			wantBefore:  &Settings{VerboseLogging: true},
+			want:        &Settings{VerboseLogging: true, RelayURL: "wss://relay.example.com/agent", RelayToken: "s3cret"},
+			wantStored:  map[string]any{"verboseLogging": true, versionKey: 1},
+			wantDevice:  map[string]any{"relayURL": "wss://relay.example.com/agent", "relayToken": "s3cret"},
+		},
+		{
+			description:  "relay configured on device retained",
+			stored:       map[string]any{"relayURL": "wss://synced.example.com/agent", "relayToken": "synced", versionKey: 1},
+			storedDevice: map[string]any{"relayURL": "wss://device.example.com/agent", "relayToken": "device"},
+			wantBefore:   &Settings{RelayURL: "wss://device.example.com/agent", RelayToken: "device"},
+			want:         &Settings{RelayURL: "wss://device.example.com/agent", RelayToken: "device"},
+			wantStored:   map[string]any{versionKey: 1},
+			wantDevice:   map[string]any{"relayURL": "wss://device.example.com/agent", "relayToken": "device"},
+		},
+		{
+			description: "no synced relay",
+			stored:      map[string]any{"verboseLogging": true, versionKey: 1},
+			wantBefore:  &Settings{VerboseLogging: true},
+			want:        &Settings{VerboseLogging: true},
+			wantStored:  map[string]any{"verboseLogging": true, versionKey: 1},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				user := storage.NewRaw(st.NewMemArea())
+				device := storage.NewRaw(st.NewMemArea())
+				s := NewStore(user, device, fakes.NewManaged())
+				s.migrations = nil
+
+				if err := user.Set(ctx, map[string]js.Value{settingsKey: js.ValueOf(tc.stored)}); err != nil {
+					t.Fatalf("failed to store settings: %v", err)
+				}
+				if tc.storedDevice != nil {
+					if err := device.Set(ctx, map[string]js.Value{deviceSettingsKey: js.ValueOf(tc.storedDevice)}); err != nil {
+						t.Fatalf("failed to store device settings: %v", err)
+					}
+				}
+
+				// Synced device settings are ignored.
+				got, err := s.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.wantBefore); diff != "" {
+					t.Errorf("incorrect settings before migration; -got +want: %s", diff)
+				}
+
+				if err := s.Migrate(ctx); err != nil {
+					t.Fatalf("Migrate failed: %v", err)
+				}
+				if diff := cmp.Diff(storedSettings(t, ctx, user, settingsKey), tc.wantStored); diff != "" {
+					t.Errorf("incorrect stored settings; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(storedSettings(t, ctx, device, deviceSettingsKey), tc.wantDevice); diff != "" {
+					t.Errorf("incorrect device settings; -got +want: %s", diff)
+				}
+				got, err = s.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect settings after migration; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestSetStoresVersion(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		user := storage.NewRaw(st.NewMemArea())
+		s := NewStore(user, storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+		s.migrations = []migration{renameVerbose}
+
+		if err := s.Set(ctx, &Settings{}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if got := storedSettings(t, ctx, user, settingsKey)[versionKey]; got != 2 {
+			t.Errorf("incorrect version stored: got %v, want 2", got)
+		}
+	})
+}
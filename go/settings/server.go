@@ -0,0 +1,387 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/message"
+	"github.com/norunners/vert"
+)
+
+// Server exposes a Store via a messaging API, such that the background worker
+// and the extension's pages read and change the same settings through the
+// background worker.
+type Server struct {
+	store *Store
+}
+
+// NewServer returns a new Server that reads and writes settings using the
+// supplied Store.
+func NewServer(store *Store) *Server {
+	return &Server{store: store}
+}
+
+// The messages below define the API exposed by Server. They are described in
+// the machine-readable description generated alongside that of keys.Server
+// (see go/keys/generate.go); regenerate it whenever they change.
+
+// protocolVersion is the version of the messaging API. It is included in every
+// request and response, as for keys.Server, and is kept equal to the version
+// used by keys.Server such that the description carries a single version; the
+// generator fails if they differ.
+const protocolVersion = 1
+
+// Define a distinct type for each message. These are embedded in each
+// message, and are distinct from those used by keys.Server, such that both
+// servers can receive the same messages.
+const (
+	msgTypeGet int = 2000 + iota
+	msgTypeGetRsp
+	msgTypeSet
+	msgTypeSetRsp
+	msgTypeStored
+	msgTypeStoredRsp
+	msgTypeManaged
+	msgTypeManagedRsp
+	msgTypeRestrictions
+	msgTypeRestrictionsRsp
+	msgTypeChanged
+	_ // msgTypeChanged has no response.
+	msgTypeRelayTokenSet
+	msgTypeRelayTokenSetRsp
+	msgTypeSetRelayToken
+	msgTypeSetRelayTokenRsp
+)
+
+// Error codes included in responses, such that callers can use errors.Is on
+// errors received via messaging.
+const (
+	errCodeNone int = iota
+	errCodeInvalid
+	errCodeIncompatibleVersion
+)
+
+// msgHeader are the common fields included in every message.
+type msgHeader struct {
+	Type    int `js:"type"`
+	Version int `js:"version"`
+}
+
+type msgGet struct {
+	Type int `js:"type"`
+}
+
+type rspGet struct {
+	Type     int       `js:"type"`
+	Settings *Settings `js:"settings"`
+	Err      string    `js:"err"`
+	Code     int       `js:"code"`
+}
+
+type msgSet struct {
+	Type     int       `js:"type"`
+	Settings *Settings `js:"settings"`
+}
+
+type rspSet struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgStored struct {
+	Type int `js:"type"`
+}
+
+type rspStored struct {
+	Type   int    `js:"type"`
+	Stored bool   `js:"stored"`
+	Err    string `js:"err"`
+	Code   int    `js:"code"`
+}
+
+type msgManaged struct {
+	Type int `js:"type"`
+}
+
+type rspManaged struct {
+	Type    int             `js:"type"`
+	Managed map[string]bool `js:"managed"`
+	Err     string          `js:"err"`
+	Code    int             `js:"code"`
+}
+
+type msgRestrictions struct {
+	Type int `js:"type"`
+}
+
+type rspRestrictions struct {
+	Type         int           `js:"type"`
+	Restrictions *Restrictions `js:"restrictions"`
+	Err          string        `js:"err"`
+	Code         int           `js:"code"`
+}
+
+type msgRelayTokenSet struct {
+	Type int `js:"type"`
+}
+
+type rspRelayTokenSet struct {
+	Type int    `js:"type"`
+	Set  bool   `js:"set"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+type msgSetRelayToken struct {
+	Type  int    `js:"type"`
+	Token string `js:"token"`
+}
+
+type rspSetRelayToken struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+// rspError is the response to a request that cannot be handled (e.g., one
+// from a client using a different protocol version). Its type is that of the
+// response to the request, such that the client parses it as usual.
+type rspError struct {
+	Type int    `js:"type"`
+	Err  string `js:"err"`
+	Code int    `js:"code"`
+}
+
+// msgChanged is broadcast to the extension's pages when the settings may have
+// changed. It is not a request; no response is expected.
+type msgChanged struct {
+	Type int `js:"type"`
+}
+
+// makeErrStr converts an error to a string. A nil error is converted to the
+// empty string.
+func makeErrStr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// errorCode returns the code identifying the category of err.
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		return errCodeInvalid
+	case errors.Is(err, keys.ErrIncompatibleVersion):
+		return errCodeIncompatibleVersion
+	default:
+		return errCodeNone
+	}
+}
+
+// remoteError is an error received via messaging, whose category was
+// recovered from its code.
+type remoteError struct {
+	msg      string
+	category error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+func (e *remoteError) Unwrap() error { return e.category }
+
+// makeErr converts an error string and code in a response to an error. The
+// empty string is converted to a nil error.
+func makeErr(s string, code int) error {
+	switch {
+	case s == "":
+		return nil
+	case code == errCodeInvalid:
+		return &remoteError{msg: s, category: ErrInvalid}
+	case code == errCodeIncompatibleVersion:
+		return &remoteError{msg: s, category: keys.ErrIncompatibleVersion}
+	default:
+		return errors.New(s)
+	}
+}
+
+// messageHeader returns the header of msg, and whether it is a message
+// defined above.
+func messageHeader(msg js.Value) (msgHeader, bool) {
+	var header msgHeader
+	if msg.Type() != js.TypeObject {
+		return header, false
+	}
+	if err := vert.ValueOf(msg).AssignTo(&header); err != nil {
+		return header, false
+	}
+	return header, header.Type >= msgTypeGet && header.Type <= msgTypeSetRelayTokenRsp
+}
+
+// OnMessage handles a message received from a client, and returns the
+// response. Messages other than requests for settings (e.g., those for
+// keys.Server) are not answered; undefined is returned, such that another
+// receiver may handle them. Requests from a client using a different protocol
+// version are rejected with keys.ErrIncompatibleVersion.
+func (s *Server) OnMessage(ctx jsutil.AsyncContext, headerObj js.Value, _ js.Value) js.Value {
+	header, ok := messageHeader(headerObj)
+	if !ok {
+		return js.Undefined()
+	}
+	rsp := s.handle(ctx, header, headerObj)
+	if rsp.Type() == js.TypeObject {
+		rsp.Set("version", protocolVersion)
+	}
+	return rsp
+}
+
+// handle implements OnMessage, returning the response without its protocol
+// version.
+func (s *Server) handle(ctx jsutil.AsyncContext, header msgHeader, headerObj js.Value) js.Value {
+	typ := header.Type
+	if typ == msgTypeChanged || (typ-msgTypeGet)%2 == 1 {
+		// Responses follow their requests, such that their offsets
+		// are odd.
+		// Responses and announcements are not answered.
+		return js.Undefined()
+	}
+	if header.Version != protocolVersion {
+		err := fmt.Errorf("%w: client uses version %d, background worker uses version %d", keys.ErrIncompatibleVersion, header.Version, protocolVersion)
+		rsp := rspError{
+			Type: typ + 1,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	}
+
+	jsutil.LogDebug("settings.Server.OnMessage(type = %d)", typ)
+	switch typ {
+	case msgTypeGet:
+		settings, err := s.store.Get(ctx)
+		if settings != nil {
+			// Pages are never sent the relay token; see RelayTokenSet.
+			settings.RelayToken = ""
+		}
+		rsp := rspGet{
+			Type:     msgTypeGetRsp,
+			Settings: settings,
+			Err:      makeErrStr(err),
+			Code:     errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSet:
+		var msg msgSet
+		err := vert.ValueOf(headerObj).AssignTo(&msg)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("failed to parse message: %w", err)
+		case msg.Settings == nil:
+			err = errors.New("failed to parse message: missing settings")
+		default:
+			err = s.store.Set(ctx, msg.Settings)
+		}
+		rsp := rspSet{
+			Type: msgTypeSetRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeStored:
+		stored, err := s.store.Stored(ctx)
+		rsp := rspStored{
+			Type:   msgTypeStoredRsp,
+			Stored: stored,
+			Err:    makeErrStr(err),
+			Code:   errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeManaged:
+		managed, err := s.store.Managed(ctx)
+		rsp := rspManaged{
+			Type:    msgTypeManagedRsp,
+			Managed: managed,
+			Err:     makeErrStr(err),
+			Code:    errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRestrictions:
+		restrictions, err := s.store.Restrictions(ctx)
+		rsp := rspRestrictions{
+			Type:         msgTypeRestrictionsRsp,
+			Restrictions: restrictions,
+			Err:          makeErrStr(err),
+			Code:         errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeRelayTokenSet:
+		set, err := s.store.RelayTokenSet(ctx)
+		rsp := rspRelayTokenSet{
+			Type: msgTypeRelayTokenSetRsp,
+			Set:  set,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	case msgTypeSetRelayToken:
+		var msg msgSetRelayToken
+		err := vert.ValueOf(headerObj).AssignTo(&msg)
+		if err != nil {
+			err = fmt.Errorf("failed to parse message: %w", err)
+		} else {
+			err = s.store.SetRelayToken(ctx, msg.Token)
+		}
+		rsp := rspSetRelayToken{
+			Type: msgTypeSetRelayTokenRsp,
+			Err:  makeErrStr(err),
+			Code: errorCode(err),
+		}
+		return vert.ValueOf(rsp).JSValue()
+	default:
+		// Responses and announcements are not answered.
+		return js.Undefined()
+	}
+}
+
+// BroadcastChange announces to the extension's pages, using sender, that the
+// settings may have changed. Pages can use WatchChanges to refresh the
+// settings they display.
+func BroadcastChange(ctx jsutil.AsyncContext, sender message.Sender) {
+	msg := msgChanged{Type: msgTypeChanged}
+	// Chrome fails if no page is listening (e.g., none is open), so the
+	// announcement is best-effort.
+	if _, err := sender.Send(ctx, vert.ValueOf(msg).JSValue()); err != nil {
+		jsutil.LogDebug("settings.BroadcastChange: announcement not delivered: %v", err)
+	}
+}
+
+// WatchChanges invokes callback whenever a change to the settings is
+// announced by BroadcastChange, until the returned cleanup function is
+// invoked.
+func WatchChanges(listener message.Listener, callback func(ctx jsutil.AsyncContext)) jsutil.CleanupFunc {
+	return listener.Listen(func(ctx jsutil.AsyncContext, msg js.Value) {
+		if header, ok := messageHeader(msg); !ok || header.Type != msgTypeChanged {
+			return
+		}
+		callback(ctx)
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	mfakes "github.com/google/chrome-ssh-agent/go/message/fakes"
+	"github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/norunners/vert"
+)
+
+// call sends req to the server using the current protocol version, and
+// parses the response into rsp.
+func call(ctx jsutil.AsyncContext, hub *mfakes.Hub, req any, rsp any) error {
+	reqObj := vert.ValueOf(req).JSValue()
+	reqObj.Set("version", protocolVersion)
+	rspObj, err := hub.Send(ctx, reqObj)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := vert.ValueOf(rspObj).AssignTo(rsp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		managed := fakes.NewManaged()
+		managed.SetPolicy(map[string]js.Value{
+			"idleTimeoutMinutes": js.ValueOf(60),
+			"disableKeyAdd":      js.ValueOf(true),
+		})
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)))
+
+		var stored rspStored
+		if err := call(ctx, hub, msgStored{Type: msgTypeStored}, &stored); err != nil {
+			t.Errorf("Stored failed: %v", err)
+			return
+		}
+		if stored.Stored || stored.Err != "" {
+			t.Errorf("incorrect stored response before Set: got %+v, want not stored", stored)
+		}
+
+		var set rspSet
+		if err := call(ctx, hub, msgSet{Type: msgTypeSet, Settings: &Settings{ApproveNewClients: true, IdleTimeoutMinutes: 15}}, &set); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if set.Err != "" {
+			t.Errorf("Set returned error: %s", set.Err)
+		}
+
+		var get rspGet
+		if err := call(ctx, hub, msgGet{Type: msgTypeGet}, &get); err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(get.Settings, &Settings{ApproveNewClients: true, IdleTimeoutMinutes: 60}); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+
+		if err := call(ctx, hub, msgStored{Type: msgTypeStored}, &stored); err != nil {
+			t.Errorf("Stored failed: %v", err)
+			return
+		}
+		if !stored.Stored {
+			t.Errorf("settings not stored after Set")
+		}
+
+		var gotManaged rspManaged
+		if err := call(ctx, hub, msgManaged{Type: msgTypeManaged}, &gotManaged); err != nil {
+			t.Errorf("Managed failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(gotManaged.Managed, map[string]bool{"idleTimeoutMinutes": true, "disableKeyAdd": true}); diff != "" {
+			t.Errorf("incorrect managed settings; -got +want: %s", diff)
+		}
+
+		var restrictions rspRestrictions
+		if err := call(ctx, hub, msgRestrictions{Type: msgTypeRestrictions}, &restrictions); err != nil {
+			t.Errorf("Restrictions failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(restrictions.Restrictions, &Restrictions{DisableKeyAdd: true}); diff != "" {
+			t.Errorf("incorrect restrictions; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestServerSetInvalid(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())))
+
+		var rsp rspSet
+		if err := call(ctx, hub, msgSet{Type: msgTypeSet, Settings: &Settings{ConnectionIdleTimeoutMinutes: 1}}, &rsp); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if err := makeErr(rsp.Err, rsp.Code); !errors.Is(err, ErrInvalid) {
+			t.Errorf("incorrect error: got %v, want %v", err, ErrInvalid)
+		}
+	})
+}
+
+func TestServerIncompatibleVersion(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		version     any
+		wantErr     error
+	}{
+		{
+			description: "current version",
+			version:     protocolVersion,
+		},
+		{
+			description: "missing version",
+			wantErr:     keys.ErrIncompatibleVersion,
+		},
+		{
+			description: "newer version",
+			version:     protocolVersion + 1,
+			wantErr:     keys.ErrIncompatibleVersion,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				srv := NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged()))
+
+				msg := vert.ValueOf(msgGet{Type: msgTypeGet}).JSValue()
+				if tc.version != nil {
+					msg.Set("version", tc.version)
+				}
+				rspObj := srv.OnMessage(ctx, msg, js.Null())
+				var header msgHeader
+				var rsp rspGet
+				if err := vert.ValueOf(rspObj).AssignTo(&header); err != nil {
+					t.Errorf("failed to parse response header: %v", err)
+					return
+				}
+				if err := vert.ValueOf(rspObj).AssignTo(&rsp); err != nil {
+					t.Errorf("failed to parse response: %v", err)
+					return
+				}
+				if header.Type != msgTypeGetRsp || header.Version != protocolVersion {
+					t.Errorf("incorrect response header: got %+v, want type %d and version %d", header, msgTypeGetRsp, protocolVersion)
+				}
+				if diff := cmp.Diff(makeErr(rsp.Err, rsp.Code), tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestServerIgnoresOtherMessages(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		srv := NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged()))
+		for _, msg := range []js.Value{
+			js.Undefined(),
+			js.ValueOf("abc"),
+			js.ValueOf(map[string]any{"type": 1000}),
+			js.ValueOf(map[string]any{"securityKeyResponse": "id"}),
+			vert.ValueOf(msgChanged{Type: msgTypeChanged}).JSValue(),
+		} {
+			if rsp := srv.OnMessage(ctx, msg, js.Null()); !rsp.IsUndefined() {
+				t.Errorf("OnMessage(%v) answered %v; want no response", msg, rsp)
+			}
+		}
+	})
+}
+
+func TestWatchChanges(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		hub := mfakes.NewHub()
+		hub.AddReceiver(NewServer(NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), fakes.NewManaged())))
+
+		changed := make(chan bool, 1)
+		cleanup := WatchChanges(hub, func(_ jsutil.AsyncContext) {
+			changed <- true
+		})
+		defer cleanup()
+
+		BroadcastChange(ctx, hub)
+		if !<-changed {
+			t.Errorf("change not announced")
+		}
+	})
+}
@@ -0,0 +1,586 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings manages user-configurable settings for the extension.
+//
+// Settings are configured by the user, and synced between the user's devices
+// except for those that only apply to one device (see deviceSettings). They
+// may be overridden by an administrator using managed storage (i.e.,
+// enterprise policy). See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/storage/#property-managed
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/relayport"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"github.com/norunners/vert"
+)
+
+// Settings are the user-configurable settings.
+//
+// The 'js' tag for each field is also the name of the corresponding policy
+// in managed storage; see managed_schema.json.
+type Settings struct {
+	// ApproveNewClients requires the user to approve each client (e.g.,
+	// another extension) the first time it connects to the agent.
+	ApproveNewClients bool `js:"approveNewClients"`
+	// RepeatedSignProtection is the action taken when a client requests
+	// many signatures with the same key in quick succession; one of the
+	// RepeatedSign* values. Empty is equivalent to RepeatedSignOff.
+	RepeatedSignProtection string `js:"repeatedSignProtection"`
+	// IdleTimeoutMinutes is the number of minutes a loaded key may go
+	// unused before it is unloaded, unless the key overrides it. Zero
+	// disables the timeout.
+	IdleTimeoutMinutes int `js:"idleTimeoutMinutes"`
+	// AllowedExtensions are the IDs of the extensions that may connect
+	// to the agent, subject to approval by the user. If empty, any
+	// extension may connect.
+	AllowedExtensions []string `js:"allowedExtensions"`
+	// VerboseLogging records debug messages from the background worker
+	// in addition to general information and errors, so they can be
+	// reviewed when diagnosing a problem.
+	VerboseLogging bool `js:"verboseLogging"`
+	// NativeHost serves the agent to desktop applications (e.g., ssh and
+	// git) through the native messaging host, which must be installed
+	// separately.
+	NativeHost bool `js:"nativeHost"`
+	// ActiveKeyring is the keyring whose keys are offered to clients.
+	// Keys in other keyrings remain loaded but are hidden. If empty, keys
+	// in all keyrings are offered.
+	ActiveKeyring string `js:"activeKeyring"`
+	// CaptureAddedKeys retains, for the browser session, the private
+	// keys that clients add to the agent (e.g., using ssh-add), such that
+	// the user can save them as configured keys.
+	CaptureAddedKeys bool `js:"captureAddedKeys"`
+	// RelayURL is the URL (e.g., wss://relay.example.com/agent) of a
+	// relay through which the agent is served to a remote development
+	// environment, such as a dev container or cloud IDE. If empty, no
+	// relay is used. It is stored only on the device on which it is
+	// configured.
+	RelayURL string `js:"relayURL"`
+	// RelayToken authenticates the extension to the relay at RelayURL.
+	// It is stored only on the device on which it is configured, is
+	// changed only using SetRelayToken, and is never sent to the
+	// extension's pages.
+	RelayToken string `js:"relayToken"`
+	// Theme is the color theme of the options page; one of the
+	// Theme* values. Empty is equivalent to ThemeSystem.
+	Theme string `js:"theme"`
+	// StoreKeysLocally stores new keys only on the local device by
+	// default, rather than syncing them. The user may still choose
+	// otherwise for each key.
+	StoreKeysLocally bool `js:"storeKeysLocally"`
+	// ConnectionIdleTimeoutMinutes is the number of minutes a connection
+	// from a client may go without exchanging a message before it is
+	// closed. Zero uses DefaultConnectionIdleTimeout.
+	ConnectionIdleTimeoutMinutes int `js:"connectionIdleTimeoutMinutes"`
+	// ExportReauthMinutes is the number of minutes after the master
+	// password is entered to export private keys during which they may
+	// be exported again without it. Zero requires the master password
+	// for every export.
+	ExportReauthMinutes int `js:"exportReauthMinutes"`
+}
+
+// ErrInvalid indicates that a setting has an invalid value.
+var ErrInvalid = errors.New("invalid setting")
+
+const (
+	// DefaultConnectionIdleTimeout is the connection idle timeout used
+	// unless configured otherwise. It is generous, since an open SSH
+	// session may legitimately not use the agent for some time.
+	DefaultConnectionIdleTimeout = 8 * time.Hour
+
+	// MinConnectionIdleTimeoutMinutes is the shortest connection idle
+	// timeout that may be configured. Shorter timeouts would close
+	// connections that clients are still using.
+	MinConnectionIdleTimeoutMinutes = 5
+
+	// MaxExportReauthMinutes is the longest time after the master password
+	// is entered during which private keys may be exported again without
+	// it.
+	MaxExportReauthMinutes = 60
+
+	// maxRelayTokenLength is the maximum length of RelayToken, in bytes.
+	maxRelayTokenLength = 1024
+)
+
+// extensionIDPattern matches the ID of a Chrome extension.
+var extensionIDPattern = regexp.MustCompile(`^[a-p]{32}$`)
+
+// ValidExtensionID returns true if id is the ID of a Chrome extension.
+func ValidExtensionID(id string) bool {
+	return extensionIDPattern.MatchString(id)
+}
+
+// Validate returns an error wrapping ErrInvalid if a setting has an invalid
+// value.
+func (s *Settings) Validate() error {
+	switch s.RepeatedSignProtection {
+	case "", RepeatedSignOff, RepeatedSignPrompt, RepeatedSignThrottle:
+	default:
+		return fmt.Errorf("%w: unknown repeated signature protection %q", ErrInvalid, s.RepeatedSignProtection)
+	}
+	if m := s.IdleTimeoutMinutes; m < 0 {
+		return fmt.Errorf("%w: idle timeout must not be negative; got %d", ErrInvalid, m)
+	}
+	for _, id := range s.AllowedExtensions {
+		if !ValidExtensionID(id) {
+			return fmt.Errorf("%w: invalid extension ID %q", ErrInvalid, id)
+		}
+	}
+	if s.ActiveKeyring != "" {
+		if err := keys.ValidateKeyring(s.ActiveKeyring); err != nil {
+			return fmt.Errorf("%w: active keyring: %w", ErrInvalid, err)
+		}
+	}
+	if s.RelayURL != "" {
+		// The URL is stored in canonical form, as the user sees it.
+		if u, err := relayport.ParseURL(s.RelayURL); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		} else if u != s.RelayURL {
+			return fmt.Errorf("%w: relay URL is not in canonical form: %s", ErrInvalid, s.RelayURL)
+		}
+	}
+	if t := s.RelayToken; t != strings.TrimSpace(t) || len(t) > maxRelayTokenLength {
+		return fmt.Errorf("%w: relay token must be at most %d bytes, without surrounding whitespace", ErrInvalid, maxRelayTokenLength)
+	}
+	switch s.Theme {
+	case "", ThemeSystem, ThemeLight, ThemeDark:
+	default:
+		return fmt.Errorf("%w: unknown theme %q", ErrInvalid, s.Theme)
+	}
+	if m := s.ConnectionIdleTimeoutMinutes; m != 0 && m < MinConnectionIdleTimeoutMinutes {
+		return fmt.Errorf("%w: connection idle timeout must be at least %d minutes; got %d", ErrInvalid, MinConnectionIdleTimeoutMinutes, m)
+	}
+	if m := s.ExportReauthMinutes; m < 0 || m > MaxExportReauthMinutes {
+		return fmt.Errorf("%w: export re-authentication window must be between 0 and %d minutes; got %d", ErrInvalid, MaxExportReauthMinutes, m)
+	}
+	return nil
+}
+
+// ExtensionAllowed returns true if the extension with the specified ID may
+// connect to the agent according to AllowedExtensions.
+func (s *Settings) ExtensionAllowed(id string) bool {
+	return len(s.AllowedExtensions) == 0 || slices.Contains(s.AllowedExtensions, id)
+}
+
+// IdleTimeout returns the default idle timeout for loaded keys, or zero if
+// it is disabled.
+func (s *Settings) IdleTimeout() time.Duration {
+	if s.IdleTimeoutMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(s.IdleTimeoutMinutes) * time.Minute
+}
+
+// ConnectionIdleTimeout returns the time after which an idle connection from a
+// client is closed. Invalid values (e.g., set by policy) are raised to the
+// minimum.
+func (s *Settings) ConnectionIdleTimeout() time.Duration {
+	switch m := s.ConnectionIdleTimeoutMinutes; {
+	case m == 0:
+		return DefaultConnectionIdleTimeout
+	case m < MinConnectionIdleTimeoutMinutes:
+		return MinConnectionIdleTimeoutMinutes * time.Minute
+	default:
+		return time.Duration(m) * time.Minute
+	}
+}
+
+// ExportReauthWindow returns the time after the master password is entered
+// during which private keys may be exported again without it. Invalid values
+// (e.g., set by policy) are limited to the range permitted by Validate.
+func (s *Settings) ExportReauthWindow() time.Duration {
+	m := min(max(s.ExportReauthMinutes, 0), MaxExportReauthMinutes)
+	return time.Duration(m) * time.Minute
+}
+
+// Values for Settings.RepeatedSignProtection.
+const (
+	// RepeatedSignOff takes no action.
+	RepeatedSignOff = "off"
+	// RepeatedSignPrompt asks the user whether to permit the signatures.
+	RepeatedSignPrompt = "prompt"
+	// RepeatedSignThrottle temporarily refuses further signatures with
+	// the key.
+	RepeatedSignThrottle = "throttle"
+)
+
+// Values for Settings.Theme.
+const (
+	// ThemeSystem follows the browser's preferred color scheme.
+	ThemeSystem = "system"
+	// ThemeLight always uses a light color scheme.
+	ThemeLight = "light"
+	// ThemeDark always uses a dark color scheme.
+	ThemeDark = "dark"
+)
+
+// Restrictions limit the operations the user may perform on configured keys.
+// Unlike Settings, they can only be configured by an administrator.
+//
+// The 'js' tag for each field is the name of the corresponding policy in
+// managed storage; see managed_schema.json.
+type Restrictions struct {
+	// DisableKeyAdd prevents the user from configuring new keys.
+	DisableKeyAdd bool `js:"disableKeyAdd"`
+	// DisableKeyRemove prevents the user from removing configured keys.
+	DisableKeyRemove bool `js:"disableKeyRemove"`
+	// DisableKeyLocationChange prevents the user from moving keys between
+	// synced and local-only storage.
+	DisableKeyLocationChange bool `js:"disableKeyLocationChange"`
+}
+
+const (
+	// settingsKey is the key under which settings are stored.
+	settingsKey = "settings"
+	// deviceSettingsKey is the key under which deviceSettings are stored.
+	deviceSettingsKey = "deviceSettings"
+
+	// Names of settings handled specially by Store.
+	relayURLName   = "relayURL"
+	relayTokenName = "relayToken"
+)
+
+// deviceSettings are the names of the settings stored only on the device on
+// which they are configured, rather than synced between the user's devices.
+// A relay serves the agent to environments reached from one device, and its
+// token is a credential; neither should be used by the user's other devices.
+var deviceSettings = []string{relayURLName, relayTokenName}
+
+// Store reads and writes settings.
+//
+// Stored settings are versioned, and converted to the current schema when
+// read; see Migrate.
+type Store struct {
+	user       storage.Area
+	device     storage.Area
+	managed    storage.Area
+	migrations []migration
+}
+
+// NewStore returns a Store that persists settings configured by the user
+// in the user storage area (typically synced), except for those that apply
+// only to this device, which are persisted in the device storage area. It
+// applies overrides from the managed storage area.
+func NewStore(user, device, managed storage.Area) *Store {
+	return &Store{
+		user:       user,
+		device:     device,
+		managed:    managed,
+		migrations: migrations,
+	}
+}
+
+// readDevice returns a copy of the settings configured by the user on this
+// device. An empty object is returned if none are stored.
+func (s *Store) readDevice(ctx jsutil.AsyncContext) (js.Value, error) {
+	result := jsutil.NewObject()
+
+	device, err := s.device.Get(ctx)
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("failed to read device settings: %w", err)
+	}
+	if val, ok := device[deviceSettingsKey]; ok && val.Type() == js.TypeObject {
+		for _, n := range deviceSettings {
+			if v := val.Get(n); !v.IsUndefined() {
+				result.Set(n, v)
+			}
+		}
+	}
+	return result, nil
+}
+
+// readUser returns a copy of the settings configured by the user, converted
+// to the current schema. An empty object is returned if none are stored.
+// Device settings are those configured on this device; any synced from
+// another device (e.g., by an earlier version of the extension) are ignored.
+func (s *Store) readUser(ctx jsutil.AsyncContext) (js.Value, error) {
+	result := jsutil.NewObject()
+
+	user, err := s.user.Get(ctx)
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("failed to read settings: %w", err)
+	}
+	if val, ok := user[settingsKey]; ok && val.Type() == js.TypeObject {
+		names, err := jsutil.ObjectKeys(val)
+		if err != nil {
+			return js.Undefined(), fmt.Errorf("failed to parse settings: %w", err)
+		}
+		for _, n := range names {
+			result.Set(n, val.Get(n))
+		}
+		s.migrate(result)
+	}
+
+	device, err := s.readDevice(ctx)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	for _, n := range deviceSettings {
+		if v := device.Get(n); !v.IsUndefined() {
+			result.Set(n, v)
+		} else {
+			result.Delete(n)
+		}
+	}
+	return result, nil
+}
+
+// readManaged returns the settings overridden by managed storage.
+func (s *Store) readManaged(ctx jsutil.AsyncContext) map[string]js.Value {
+	managed, err := s.managed.Get(ctx)
+	if err != nil {
+		// Managed storage is unavailable on some platforms; treat this
+		// as the absence of policy.
+		jsutil.LogError("failed to read managed settings; ignoring: %v", err)
+		return nil
+	}
+	return managed
+}
+
+// Get returns the current settings, including any overrides from managed
+// storage.
+func (s *Store) Get(ctx jsutil.AsyncContext) (*Settings, error) {
+	merged, err := s.readUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for n, val := range s.readManaged(ctx) {
+		merged.Set(n, val)
+	}
+
+	var result Settings
+	if err := vert.ValueOf(merged).AssignTo(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	return &result, nil
+}
+
+// Set stores the settings configured by the user, returning an error if they
+// are invalid; see Settings.Validate. Settings overridden by managed storage
+// continue to take precedence: the user cannot change them, so the values
+// previously stored by the user are retained rather than those in settings
+// (typically the values of the policies, as returned by Get). The relay token
+// is likewise retained, since it is changed only using SetRelayToken, unless
+// the relay URL is cleared.
+func (s *Store) Set(ctx jsutil.AsyncContext, settings *Settings) error {
+	val := vert.ValueOf(settings).JSValue()
+	user, err := s.readUser(ctx)
+	if err != nil {
+		return err
+	}
+	managed := s.readManaged(ctx)
+	retain := []string{relayTokenName}
+	for n := range managed {
+		retain = append(retain, n)
+	}
+	for _, n := range retain {
+		if stored := user.Get(n); !stored.IsUndefined() {
+			val.Set(n, stored)
+		} else {
+			val.Delete(n)
+		}
+	}
+	if _, ok := managed[relayTokenName]; !ok && settings.RelayURL == "" && user.Get(relayURLName).Truthy() {
+		// The token is of no use once the relay is no longer used.
+		val.Delete(relayTokenName)
+	}
+
+	var retained Settings
+	if err := vert.ValueOf(val).AssignTo(&retained); err != nil {
+		return fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if err := retained.Validate(); err != nil {
+		return err
+	}
+	return s.write(ctx, val)
+}
+
+// write stores the settings configured by the user, separating those that
+// apply only to this device from those that are synced. Device settings are
+// written only if changed, such that watchers are not notified twice.
+func (s *Store) write(ctx jsutil.AsyncContext, val js.Value) error {
+	device := jsutil.NewObject()
+	for _, n := range deviceSettings {
+		// Empty values are omitted, as if never configured.
+		if v := val.Get(n); v.Truthy() {
+			device.Set(n, v)
+		}
+		val.Delete(n)
+	}
+	stored, err := s.readDevice(ctx)
+	if err != nil {
+		return err
+	}
+	if jsutil.ToJSON(device) != jsutil.ToJSON(stored) {
+		if err := s.device.Set(ctx, map[string]js.Value{deviceSettingsKey: device}); err != nil {
+			return fmt.Errorf("failed to write device settings: %w", err)
+		}
+	}
+
+	val.Set(versionKey, currentVersion(s.migrations))
+	if err := s.user.Set(ctx, map[string]js.Value{settingsKey: val}); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// RelayTokenSet reports whether a relay token is configured, either by the
+// user or by managed storage. Unlike Get, it may be used by the extension's
+// pages, which are never sent the token itself.
+func (s *Store) RelayTokenSet(ctx jsutil.AsyncContext) (bool, error) {
+	settings, err := s.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return settings.RelayToken != "", nil
+}
+
+// SetRelayToken stores the token that authenticates the extension to the
+// relay on this device. An empty token clears it. An error wrapping
+// ErrInvalid is returned if the token is invalid, or is set by managed
+// storage.
+func (s *Store) SetRelayToken(ctx jsutil.AsyncContext, token string) error {
+	if _, ok := s.readManaged(ctx)[relayTokenName]; ok {
+		return fmt.Errorf("%w: relay token is set by policy", ErrInvalid)
+	}
+	if err := (&Settings{RelayToken: token}).Validate(); err != nil {
+		return err
+	}
+	device, err := s.readDevice(ctx)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		device.Set(relayTokenName, token)
+	} else {
+		device.Delete(relayTokenName)
+	}
+	if err := s.device.Set(ctx, map[string]js.Value{deviceSettingsKey: device}); err != nil {
+		return fmt.Errorf("failed to write device settings: %w", err)
+	}
+	return nil
+}
+
+// Stored reports whether the user has stored any settings. Nothing is stored
+// until the user first changes a setting, so this is false on first run.
+func (s *Store) Stored(ctx jsutil.AsyncContext) (bool, error) {
+	user, err := s.user.Get(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read settings: %w", err)
+	}
+	_, ok := user[settingsKey]
+	return ok, nil
+}
+
+// Watch invokes callback whenever the settings configured by the user are
+// changed, including on another of the user's devices. Watching continues
+// until the returned cleanup function is invoked.
+func (s *Store) Watch(ctx jsutil.AsyncContext, callback func(ctx jsutil.AsyncContext)) (jsutil.CleanupFunc, error) {
+	watched := []struct {
+		area storage.Area
+		key  string
+	}{
+		{s.user, settingsKey},
+		{s.device, deviceSettingsKey},
+	}
+	var cleanup jsutil.CleanupFuncs
+	for _, w := range watched {
+		key := w.key
+		c, err := w.area.Watch(ctx, func(ctx jsutil.AsyncContext, keys []string) {
+			if slices.Contains(keys, key) {
+				callback(ctx)
+			}
+		})
+		if err != nil {
+			cleanup.Do()
+			return nil, err
+		}
+		cleanup.Add(c)
+	}
+	return cleanup.Do, nil
+}
+
+// Managed returns the names of the settings that are overridden by managed
+// storage, and therefore cannot be changed by the user.
+func (s *Store) Managed(ctx jsutil.AsyncContext) (map[string]bool, error) {
+	managed, err := s.managed.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read managed settings: %w", err)
+	}
+
+	result := map[string]bool{}
+	for n := range managed {
+		result[n] = true
+	}
+	return result, nil
+}
+
+// Restrictions returns the restrictions configured in managed storage.
+func (s *Store) Restrictions(ctx jsutil.AsyncContext) (*Restrictions, error) {
+	managed, err := s.managed.Get(ctx)
+	if err != nil {
+		// Managed storage is unavailable on some platforms; treat this
+		// as the absence of policy.
+		jsutil.LogError("failed to read managed settings; ignoring: %v", err)
+		managed = nil
+	}
+
+	obj := jsutil.NewObject()
+	for n, val := range managed {
+		obj.Set(n, val)
+	}
+
+	var result Restrictions
+	if err := vert.ValueOf(obj).AssignTo(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse restrictions: %w", err)
+	}
+	return &result, nil
+}
+
+// Capabilities returns the operations on configured keys permitted by the
+// restrictions configured in managed storage.
+//
+// Capabilities implements keys.CapabilitiesFunc.
+func (s *Store) Capabilities(ctx jsutil.AsyncContext) (*keys.Capabilities, error) {
+	r, err := s.Restrictions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.capabilities(), nil
+}
+
+// capabilities returns the operations on configured keys permitted by the
+// restrictions.
+func (r *Restrictions) capabilities() *keys.Capabilities {
+	return &keys.Capabilities{
+		Add:      !r.DisableKeyAdd,
+		Remove:   !r.DisableKeyRemove,
+		SetLocal: !r.DisableKeyLocationChange,
+	}
+}
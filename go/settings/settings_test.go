@@ -0,0 +1,671 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"errors"
+	"strings"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGetSet(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		set         *Settings
+		managed     map[string]js.Value
+		want        *Settings
+		wantManaged map[string]bool
+	}{
+		{
+			description: "defaults",
+			want:        &Settings{},
+			wantManaged: map[string]bool{},
+		},
+		{
+			description: "user settings",
+			set:         &Settings{ApproveNewClients: true},
+			want:        &Settings{ApproveNewClients: true},
+			wantManaged: map[string]bool{},
+		},
+		{
+			description: "managed settings override defaults",
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(true),
+			},
+			want:        &Settings{ApproveNewClients: true},
+			wantManaged: map[string]bool{"approveNewClients": true},
+		},
+		{
+			description: "managed settings override user settings",
+			set:         &Settings{ApproveNewClients: true},
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(false),
+			},
+			want:        &Settings{ApproveNewClients: false},
+			wantManaged: map[string]bool{"approveNewClients": true},
+		},
+		{
+			description: "managed idle timeout overrides user setting",
+			set:         &Settings{IdleTimeoutMinutes: 15},
+			managed: map[string]js.Value{
+				"idleTimeoutMinutes": js.ValueOf(60),
+			},
+			want:        &Settings{IdleTimeoutMinutes: 60},
+			wantManaged: map[string]bool{"idleTimeoutMinutes": true},
+		},
+		{
+			description: "user allowed extensions",
+			set:         &Settings{AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"}},
+			want:        &Settings{AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"}},
+			wantManaged: map[string]bool{},
+		},
+		{
+			description: "managed allowed extensions override user setting",
+			set:         &Settings{AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop"}},
+			managed: map[string]js.Value{
+				"allowedExtensions": js.ValueOf([]any{"ponmlkjihgfedcbaponmlkjihgfedcba"}),
+			},
+			want:        &Settings{AllowedExtensions: []string{"ponmlkjihgfedcbaponmlkjihgfedcba"}},
+			wantManaged: map[string]bool{"allowedExtensions": true},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				managed := fakes.NewManaged()
+				managed.SetPolicy(tc.managed)
+				s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+
+				if tc.set != nil {
+					if err := s.Set(ctx, tc.set); err != nil {
+						t.Fatalf("Set failed: %v", err)
+					}
+				}
+
+				got, err := s.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect settings; -got +want: %s", diff)
+				}
+
+				gotManaged, err := s.Managed(ctx)
+				if err != nil {
+					t.Fatalf("Managed failed: %v", err)
+				}
+				if diff := cmp.Diff(gotManaged, tc.wantManaged); diff != "" {
+					t.Errorf("incorrect managed settings; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{minutes: 0, want: 0},
+		{minutes: -5, want: 0},
+		{minutes: 30, want: 30 * time.Minute},
+	}
+
+	for _, tc := range testcases {
+		s := &Settings{IdleTimeoutMinutes: tc.minutes}
+		if diff := cmp.Diff(s.IdleTimeout(), tc.want); diff != "" {
+			t.Errorf("incorrect idle timeout for %d minutes; -got +want: %s", tc.minutes, diff)
+		}
+	}
+}
+
+func TestConnectionIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{minutes: 0, want: DefaultConnectionIdleTimeout},
+		{minutes: -5, want: MinConnectionIdleTimeoutMinutes * time.Minute},
+		{minutes: 1, want: MinConnectionIdleTimeoutMinutes * time.Minute},
+		{minutes: 30, want: 30 * time.Minute},
+	}
+
+	for _, tc := range testcases {
+		s := &Settings{ConnectionIdleTimeoutMinutes: tc.minutes}
+		if diff := cmp.Diff(s.ConnectionIdleTimeout(), tc.want); diff != "" {
+			t.Errorf("incorrect connection idle timeout for %d minutes; -got +want: %s", tc.minutes, diff)
+		}
+	}
+}
+
+func TestExportReauthWindow(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{minutes: 0, want: 0},
+		{minutes: -5, want: 0},
+		{minutes: 15, want: 15 * time.Minute},
+		{minutes: 24 * 60, want: MaxExportReauthMinutes * time.Minute},
+	}
+
+	for _, tc := range testcases {
+		s := &Settings{ExportReauthMinutes: tc.minutes}
+		if diff := cmp.Diff(s.ExportReauthWindow(), tc.want); diff != "" {
+			t.Errorf("incorrect export re-authentication window for %d minutes; -got +want: %s", tc.minutes, diff)
+		}
+	}
+}
+
+func TestSetValidates(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		set         *Settings
+		wantErr     error
+	}{
+		{
+			description: "default connection idle timeout",
+			set:         &Settings{},
+		},
+		{
+			description: "valid connection idle timeout",
+			set:         &Settings{ConnectionIdleTimeoutMinutes: 60},
+		},
+		{
+			description: "connection idle timeout too short",
+			set:         &Settings{ConnectionIdleTimeoutMinutes: 1},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "negative connection idle timeout",
+			set:         &Settings{ConnectionIdleTimeoutMinutes: -1},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "valid export re-authentication window",
+			set:         &Settings{ExportReauthMinutes: 15},
+		},
+		{
+			description: "export re-authentication window too long",
+			set:         &Settings{ExportReauthMinutes: MaxExportReauthMinutes + 1},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "negative export re-authentication window",
+			set:         &Settings{ExportReauthMinutes: -1},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "valid repeated signature protection",
+			set:         &Settings{RepeatedSignProtection: RepeatedSignThrottle},
+		},
+		{
+			description: "unknown repeated signature protection",
+			set:         &Settings{RepeatedSignProtection: "sometimes"},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "negative idle timeout",
+			set:         &Settings{IdleTimeoutMinutes: -1},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "invalid allowed extension",
+			set:         &Settings{AllowedExtensions: []string{"abcdefghijklmnopabcdefghijklmnop", "extension-1"}},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "valid active keyring",
+			set:         &Settings{ActiveKeyring: "work"},
+		},
+		{
+			description: "blank active keyring",
+			set:         &Settings{ActiveKeyring: "  "},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "valid relay",
+			set:         &Settings{RelayURL: "wss://relay.example.com/agent", RelayToken: "token"},
+		},
+		{
+			description: "relay URL without wss",
+			set:         &Settings{RelayURL: "ws://relay.example.com/agent"},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "relay URL not in canonical form",
+			set:         &Settings{RelayURL: "wss://Relay.Example.com/agent"},
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "valid theme",
+			set:         &Settings{Theme: ThemeDark},
+		},
+		{
+			description: "unknown theme",
+			set:         &Settings{Theme: "neon"},
+			wantErr:     ErrInvalid,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+				err := s.Set(ctx, tc.set)
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("incorrect error; got %v, want %v", err, tc.wantErr)
+				}
+				if tc.wantErr == nil {
+					return
+				}
+
+				// Invalid settings are not stored.
+				stored, err := s.Stored(ctx)
+				if err != nil {
+					t.Errorf("Stored failed: %v", err)
+					return
+				}
+				if stored {
+					t.Errorf("invalid settings were stored")
+				}
+			})
+		})
+	}
+}
+
+func TestSetRetainsUserValuesForManagedSettings(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		managed := fakes.NewManaged()
+		s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+		if err := s.Set(ctx, &Settings{IdleTimeoutMinutes: 15}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+
+		// The settings returned by Get include the policies, one of
+		// which is invalid as a user setting. Storing them with a change
+		// must neither fail nor store the policies.
+		managed.SetPolicy(map[string]js.Value{
+			"idleTimeoutMinutes": js.ValueOf(60),
+			"theme":              js.ValueOf("neon"),
+		})
+		settings, err := s.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		settings.ApproveNewClients = true
+		if err := s.Set(ctx, settings); err != nil {
+			t.Errorf("Set with managed settings failed: %v", err)
+			return
+		}
+
+		managed.SetPolicy(nil)
+		got, err := s.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(got, &Settings{ApproveNewClients: true, IdleTimeoutMinutes: 15}); diff != "" {
+			t.Errorf("incorrect settings after policy removed; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestDeviceSettings(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		user := storage.NewRaw(st.NewMemArea())
+		device := storage.NewRaw(st.NewMemArea())
+		s := NewStore(user, device, fakes.NewManaged())
+
+		const relayURL = "wss://relay.example.com/agent"
+		if err := s.Set(ctx, &Settings{VerboseLogging: true, RelayURL: relayURL, RelayToken: "ignored"}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if err := s.SetRelayToken(ctx, "s3cret"); err != nil {
+			t.Errorf("SetRelayToken failed: %v", err)
+			return
+		}
+		for _, n := range deviceSettings {
+			if _, ok := storedSettings(t, ctx, user, settingsKey)[n]; ok {
+				t.Errorf("device setting %s is synced", n)
+			}
+		}
+		if diff := cmp.Diff(storedSettings(t, ctx, device, deviceSettingsKey), map[string]any{"relayURL": relayURL, "relayToken": "s3cret"}); diff != "" {
+			t.Errorf("incorrect device settings; -got +want: %s", diff)
+		}
+
+		// The token is retained when other settings change.
+		if err := s.Set(ctx, &Settings{RelayURL: relayURL}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		got, err := s.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(got, &Settings{RelayURL: relayURL, RelayToken: "s3cret"}); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+		if set, err := s.RelayTokenSet(ctx); err != nil || !set {
+			t.Errorf("RelayTokenSet: got (%v, %v), want (true, nil)", set, err)
+		}
+
+		// Clearing the relay URL clears the token.
+		if err := s.Set(ctx, &Settings{}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if set, err := s.RelayTokenSet(ctx); err != nil || set {
+			t.Errorf("RelayTokenSet after clearing relay: got (%v, %v), want (false, nil)", set, err)
+		}
+	})
+}
+
+func TestSetRelayToken(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		managed     map[string]js.Value
+		token       string
+		wantErr     error
+	}{
+		{
+			description: "valid token",
+			token:       "s3cret",
+		},
+		{
+			description: "clear token",
+			token:       "",
+		},
+		{
+			description: "token with surrounding whitespace",
+			token:       " s3cret",
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "token too long",
+			token:       strings.Repeat("x", maxRelayTokenLength+1),
+			wantErr:     ErrInvalid,
+		},
+		{
+			description: "token set by policy",
+			managed:     map[string]js.Value{"relayToken": js.ValueOf("policy")},
+			token:       "s3cret",
+			wantErr:     ErrInvalid,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				managed := fakes.NewManaged()
+				managed.SetPolicy(tc.managed)
+				s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+
+				err := s.SetRelayToken(ctx, tc.token)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if tc.wantErr != nil {
+					return
+				}
+				got, err := s.Get(ctx)
+				if err != nil {
+					t.Errorf("Get failed: %v", err)
+					return
+				}
+				if got.RelayToken != tc.token {
+					t.Errorf("incorrect token: got %q, want %q", got.RelayToken, tc.token)
+				}
+			})
+		})
+	}
+}
+
+func TestExtensionAllowed(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		allowed     []string
+		id          string
+		want        bool
+	}{
+		{
+			description: "empty allowlist permits any extension",
+			id:          "abcdefghijklmnopabcdefghijklmnop",
+			want:        true,
+		},
+		{
+			description: "allowlisted extension",
+			allowed:     []string{"abcdefghijklmnopabcdefghijklmnop", "ponmlkjihgfedcbaponmlkjihgfedcba"},
+			id:          "ponmlkjihgfedcbaponmlkjihgfedcba",
+			want:        true,
+		},
+		{
+			description: "extension not allowlisted",
+			allowed:     []string{"abcdefghijklmnopabcdefghijklmnop"},
+			id:          "ponmlkjihgfedcbaponmlkjihgfedcba",
+			want:        false,
+		},
+	}
+
+	for _, tc := range testcases {
+		s := &Settings{AllowedExtensions: tc.allowed}
+		if diff := cmp.Diff(s.ExtensionAllowed(tc.id), tc.want); diff != "" {
+			t.Errorf("%s: incorrect result; -got +want: %s", tc.description, diff)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		managed     map[string]js.Value
+		want        *keys.Capabilities
+	}{
+		{
+			description: "no restrictions",
+			want:        keys.AllCapabilities(),
+		},
+		{
+			description: "unrelated policy",
+			managed: map[string]js.Value{
+				"approveNewClients": js.ValueOf(true),
+			},
+			want: keys.AllCapabilities(),
+		},
+		{
+			description: "disable removal",
+			managed: map[string]js.Value{
+				"disableKeyRemove": js.ValueOf(true),
+			},
+			want: &keys.Capabilities{
+				Add:      true,
+				SetLocal: true,
+			},
+		},
+		{
+			description: "disable all",
+			managed: map[string]js.Value{
+				"disableKeyAdd":            js.ValueOf(true),
+				"disableKeyRemove":         js.ValueOf(true),
+				"disableKeyLocationChange": js.ValueOf(true),
+			},
+			want: &keys.Capabilities{},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				managed := fakes.NewManaged()
+				managed.SetPolicy(tc.managed)
+				s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+
+				got, err := s.Capabilities(ctx)
+				if err != nil {
+					t.Fatalf("Capabilities failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect capabilities; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestManagedUnavailable(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		managed := fakes.NewManaged()
+		managed.SetPolicy(map[string]js.Value{
+			"approveNewClients": js.ValueOf(false),
+			"disableKeyRemove":  js.ValueOf(true),
+		})
+		managed.SetUnavailable(errors.New("managed storage not supported"))
+		s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+		if err := s.Set(ctx, &Settings{ApproveNewClients: true}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// The absence of managed storage is treated as the absence of
+		// policy.
+		got, err := s.Get(ctx)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if diff := cmp.Diff(got, &Settings{ApproveNewClients: true}); diff != "" {
+			t.Errorf("incorrect settings; -got +want: %s", diff)
+		}
+		caps, err := s.Capabilities(ctx)
+		if err != nil {
+			t.Fatalf("Capabilities failed: %v", err)
+		}
+		if diff := cmp.Diff(caps, keys.AllCapabilities()); diff != "" {
+			t.Errorf("incorrect capabilities; -got +want: %s", diff)
+		}
+		if _, err := s.Managed(ctx); err == nil {
+			t.Errorf("Managed succeeded with managed storage unavailable")
+		}
+	})
+}
+
+func TestStored(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		managed := fakes.NewManaged()
+		managed.SetPolicy(map[string]js.Value{
+			"approveNewClients": js.ValueOf(true),
+		})
+		s := NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), managed)
+
+		// Policy does not count as settings stored by the user.
+		if stored, err := s.Stored(ctx); err != nil || stored {
+			t.Errorf("incorrect result before Set: got (%t, %v), want (false, nil)", stored, err)
+		}
+		// Storing the default settings counts.
+		if err := s.Set(ctx, &Settings{}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if stored, err := s.Stored(ctx); err != nil || !stored {
+			t.Errorf("incorrect result after Set: got (%t, %v), want (true, nil)", stored, err)
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		user := storage.NewRaw(st.NewMemArea())
+		s := NewStore(user, storage.NewRaw(st.NewMemArea()), fakes.NewManaged())
+		changed := make(chan *Settings, 10)
+		cleanup, err := s.Watch(ctx, func(ctx jsutil.AsyncContext) {
+			got, err := s.Get(ctx)
+			if err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+			changed <- got
+		})
+		if err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+		defer cleanup()
+
+		// Changes to other items in storage are not reported.
+		if err := user.Set(ctx, map[string]js.Value{"other": js.ValueOf(1)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if err := s.Set(ctx, &Settings{VerboseLogging: true}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(<-changed, &Settings{VerboseLogging: true}); diff != "" {
+			t.Errorf("incorrect settings after change; -got +want: %s", diff)
+		}
+		if n := len(changed); n != 0 {
+			t.Errorf("incorrect number of further changes: got %d, want 0", n)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// ErrNotConfirmed indicates that the user did not confirm a signature
+	// with a key that requires confirmation.
+	ErrNotConfirmed = errors.New("signature not confirmed by user")
+)
+
+// ConfirmPrompter asks the user to confirm a signature with a key that
+// requires confirmation.
+type ConfirmPrompter interface {
+	// Confirm asks the user whether the client may sign using the key
+	// with the specified name and fingerprint. allowed is false if the
+	// user declined, or did not make a decision (e.g., they dismissed
+	// the prompt).
+	Confirm(ctx jsutil.AsyncContext, client, name, fingerprint string) (allowed bool, err error)
+}
+
+// KeyLookup returns the configured key that is loaded into the agent with
+// the specified public key, or nil if there is none.
+type KeyLookup func(ctx jsutil.AsyncContext, pub ssh.PublicKey) (*keys.ConfiguredKey, error)
+
+// Confirmer wraps the agent for a single connection, and asks the user to
+// confirm each signature with a key configured to require it (similar to
+// 'ssh-add -c'). The key's configuration is read on every signature, so
+// changes take effect without the client reconnecting.
+//
+// Confirmer implements the agent.ExtendedAgent interface.
+type Confirmer struct {
+	agent.Agent
+	client   string
+	lookup   KeyLookup
+	prompter ConfirmPrompter
+}
+
+// NewConfirmer returns a Confirmer for a connection from the specified
+// client.
+func NewConfirmer(agt agent.Agent, client string, lookup KeyLookup, prompter ConfirmPrompter) *Confirmer {
+	return &Confirmer{
+		Agent:    agt,
+		client:   client,
+		lookup:   lookup,
+		prompter: prompter,
+	}
+}
+
+// Sign implements agent.Agent.Sign.
+func (c *Confirmer) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if err := c.check(key); err != nil {
+		return nil, err
+	}
+	return c.Agent.Sign(key, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (c *Confirmer) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := c.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return c.Sign(key, data)
+	}
+	if err := c.check(key); err != nil {
+		return nil, err
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (c *Confirmer) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := c.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// check returns an error if a signature with the key should not proceed.
+// Signatures are requested outside of an AsyncContext, so the decision is
+// made asynchronously.
+func (c *Confirmer) check(key ssh.PublicKey) error {
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		err = c.decide(ctx, key)
+	})
+	return err
+}
+
+func (c *Confirmer) decide(ctx jsutil.AsyncContext, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	k, err := c.lookup(ctx, key)
+	if err != nil {
+		// The key may require confirmation; refuse rather than risk
+		// signing without it.
+		return fmt.Errorf("%w: key %s: failed to read key configuration: %w", ErrNotConfirmed, fingerprint, err)
+	}
+	if k == nil || !k.Confirm {
+		return nil
+	}
+
+	jsutil.Log("Client %s requested a signature with key %s; asking for confirmation", c.client, fingerprint)
+	allowed, err := c.prompter.Confirm(ctx, c.client, k.Name, fingerprint)
+	if err != nil {
+		return fmt.Errorf("%w: key %s: failed to prompt: %w", ErrNotConfirmed, fingerprint, err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: key %s", ErrNotConfirmed, fingerprint)
+	}
+	return nil
+}
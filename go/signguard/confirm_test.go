@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// fakeConfirmPrompter answers confirmation prompts with a fixed response, and
+// records the names of the keys it was asked about.
+type fakeConfirmPrompter struct {
+	allowed bool
+	err     error
+	names   []string
+}
+
+func (f *fakeConfirmPrompter) Confirm(ctx jsutil.AsyncContext, client, name, fingerprint string) (bool, error) {
+	f.names = append(f.names, name)
+	return f.allowed, f.err
+}
+
+func TestConfirmerSign(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         *keys.ConfiguredKey
+		lookupErr   error
+		prompter    *fakeConfirmPrompter
+		wantPrompts []string
+		wantErr     error
+	}{
+		{
+			description: "key not configured",
+			prompter:    &fakeConfirmPrompter{},
+		},
+		{
+			description: "confirmation not required",
+			key:         &keys.ConfiguredKey{Name: "key"},
+			prompter:    &fakeConfirmPrompter{},
+		},
+		{
+			description: "user confirms",
+			key:         &keys.ConfiguredKey{Name: "key", Confirm: true},
+			prompter:    &fakeConfirmPrompter{allowed: true},
+			wantPrompts: []string{"key"},
+		},
+		{
+			description: "user declines",
+			key:         &keys.ConfiguredKey{Name: "key", Confirm: true},
+			prompter:    &fakeConfirmPrompter{allowed: false},
+			wantPrompts: []string{"key"},
+			wantErr:     ErrNotConfirmed,
+		},
+		{
+			description: "prompt fails",
+			key:         &keys.ConfiguredKey{Name: "key", Confirm: true},
+			prompter:    &fakeConfirmPrompter{allowed: true, err: errors.New("failed")},
+			wantPrompts: []string{"key"},
+			wantErr:     ErrNotConfirmed,
+		},
+		{
+			description: "lookup fails",
+			lookupErr:   errors.New("failed"),
+			prompter:    &fakeConfirmPrompter{},
+			wantErr:     ErrNotConfirmed,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+				if err != nil {
+					t.Fatalf("failed to parse key: %v", err)
+				}
+				agt := agent.NewKeyring()
+				if err := agt.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				loaded, err := agt.List()
+				if err != nil {
+					t.Fatalf("failed to list keys: %v", err)
+				}
+
+				lookup := func(ctx jsutil.AsyncContext, pub ssh.PublicKey) (*keys.ConfiguredKey, error) {
+					return tc.key, tc.lookupErr
+				}
+				c := NewConfirmer(agt, "client-1", lookup, tc.prompter)
+				_, err = c.Sign(loaded[0], []byte("data"))
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(tc.prompter.names, tc.wantPrompts); diff != "" {
+					t.Errorf("incorrect prompts; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
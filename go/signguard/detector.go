@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signguard detects unusual signing patterns, such as a client
+// requesting many signatures with the same key in quick succession. This
+// provides defense in depth against a compromised client (e.g., another
+// extension) using the agent's keys far more often than expected.
+package signguard
+
+import (
+	"time"
+)
+
+const (
+	// DefaultLimit is the number of signatures with the same key, within
+	// DefaultWindow, that is considered unusual.
+	DefaultLimit = 5
+	// DefaultWindow is the period over which signatures are counted.
+	DefaultWindow = time.Second
+)
+
+// Detector counts the signatures requested with each key, and detects when
+// the number within a window reaches a limit.
+type Detector struct {
+	limit  int
+	window time.Duration
+	recent map[string][]time.Time
+}
+
+// NewDetector returns a Detector that considers limit signatures with the
+// same key within the window to be unusual.
+func NewDetector(limit int, window time.Duration) *Detector {
+	return &Detector{
+		limit:  limit,
+		window: window,
+		recent: map[string][]time.Time{},
+	}
+}
+
+// Observe records a signature with the specified key at the specified time.
+// It returns the number of signatures with the key within the window, and
+// true if that number is unusual.
+func (d *Detector) Observe(key string, now time.Time) (int, bool) {
+	var recent []time.Time
+	for _, t := range d.recent[key] {
+		if now.Sub(t) < d.window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	d.recent[key] = recent
+	return len(recent), len(recent) >= d.limit
+}
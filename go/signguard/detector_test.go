@@ -0,0 +1,100 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectorObserve(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	type observation struct {
+		key string
+		at  time.Time
+	}
+	type result struct {
+		Count   int
+		Unusual bool
+	}
+
+	testcases := []struct {
+		description  string
+		observations []observation
+		want         []result
+	}{
+		{
+			description: "below limit",
+			observations: []observation{
+				{key: "a", at: at(0)},
+				{key: "a", at: at(100)},
+			},
+			want: []result{{1, false}, {2, false}},
+		},
+		{
+			description: "limit reached within window",
+			observations: []observation{
+				{key: "a", at: at(0)},
+				{key: "a", at: at(100)},
+				{key: "a", at: at(200)},
+				{key: "a", at: at(300)},
+			},
+			want: []result{{1, false}, {2, false}, {3, true}, {4, true}},
+		},
+		{
+			description: "old signatures expire",
+			observations: []observation{
+				{key: "a", at: at(0)},
+				{key: "a", at: at(500)},
+				{key: "a", at: at(1100)},
+				{key: "a", at: at(1200)},
+			},
+			want: []result{{1, false}, {2, false}, {2, false}, {3, true}},
+		},
+		{
+			description: "keys counted separately",
+			observations: []observation{
+				{key: "a", at: at(0)},
+				{key: "b", at: at(100)},
+				{key: "a", at: at(200)},
+				{key: "b", at: at(300)},
+			},
+			want: []result{{1, false}, {1, false}, {2, false}, {2, false}},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			d := NewDetector(3, time.Second)
+			var got []result
+			for _, o := range tc.observations {
+				count, unusual := d.Observe(o.key, o.at)
+				got = append(got, result{count, unusual})
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect results; -got +want: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// ErrThrottled indicates that a signature was refused because too
+	// many were recently requested with the same key.
+	ErrThrottled = errors.New("too many signatures requested")
+	// ErrDenied indicates that the user did not permit a signature.
+	ErrDenied = errors.New("signature denied by user")
+)
+
+const (
+	// ThrottlePeriod is the period for which signatures with a key are
+	// refused once unusual signing is detected.
+	ThrottlePeriod = 10 * time.Second
+)
+
+// Prompter asks the user whether a client may continue signing with a key
+// after unusual signing was detected.
+type Prompter interface {
+	// Prompt asks the user whether the client may continue signing with
+	// the key with the specified fingerprint, count being the number of
+	// signatures recently requested. decided is false if the user did
+	// not make a decision (e.g., they dismissed the prompt).
+	Prompt(ctx jsutil.AsyncContext, client, fingerprint string, count int) (allowed, decided bool, err error)
+}
+
+// Guard wraps the agent for a single connection, and applies the
+// RepeatedSignProtection setting to signatures requested over the
+// connection.
+//
+// Requests over a connection are served sequentially, so a Guard must not
+// be shared between connections.
+//
+// Guard implements the agent.ExtendedAgent interface.
+type Guard struct {
+	agent.Agent
+	client   string
+	settings *settings.Store
+	prompter Prompter
+	clock    clock.Clock
+	detector *Detector
+	// approved are the keys the user permitted to continue signing.
+	approved map[string]bool
+	// denied are the keys the user did not permit to continue signing.
+	denied map[string]bool
+	// refused maps keys to the time until which signatures are refused.
+	refused map[string]time.Time
+}
+
+// NewGuard returns a Guard for a connection from the specified client.
+func NewGuard(agt agent.Agent, client string, settings *settings.Store, prompter Prompter, clk clock.Clock) *Guard {
+	return &Guard{
+		Agent:    agt,
+		client:   client,
+		settings: settings,
+		prompter: prompter,
+		clock:    clk,
+		detector: NewDetector(DefaultLimit, DefaultWindow),
+		approved: map[string]bool{},
+		denied:   map[string]bool{},
+		refused:  map[string]time.Time{},
+	}
+}
+
+// Sign implements agent.Agent.Sign.
+func (g *Guard) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if err := g.check(key); err != nil {
+		return nil, err
+	}
+	return g.Agent.Sign(key, data)
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (g *Guard) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := g.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			return nil, fmt.Errorf("signature flags %d not supported", flags)
+		}
+		return g.Sign(key, data)
+	}
+	if err := g.check(key); err != nil {
+		return nil, err
+	}
+	return ext.SignWithFlags(key, data, flags)
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (g *Guard) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := g.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// check returns an error if a signature with the key should not proceed.
+// Signatures are requested outside of an AsyncContext, so the decision is
+// made asynchronously.
+func (g *Guard) check(key ssh.PublicKey) error {
+	var err error
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		err = g.decide(ctx, key)
+	})
+	return err
+}
+
+func (g *Guard) decide(ctx jsutil.AsyncContext, key ssh.PublicKey) error {
+	id := string(key.Marshal())
+	fingerprint := ssh.FingerprintSHA256(key)
+	now := g.clock.Now()
+
+	if g.denied[id] {
+		return fmt.Errorf("%w: key %s", ErrDenied, fingerprint)
+	}
+	if until, ok := g.refused[id]; ok && now.Before(until) {
+		return fmt.Errorf("%w: key %s", ErrThrottled, fingerprint)
+	}
+
+	count, unusual := g.detector.Observe(id, now)
+	if !unusual || g.approved[id] {
+		return nil
+	}
+
+	s, err := g.settings.Get(ctx)
+	if err != nil {
+		jsutil.LogError("Guard.decide: failed to read settings; allowing signature: %v", err)
+		return nil
+	}
+
+	switch s.RepeatedSignProtection {
+	case settings.RepeatedSignThrottle:
+		jsutil.Log("Client %s requested %d signatures with key %s; refusing signatures for %s", g.client, count, fingerprint, ThrottlePeriod)
+		g.refused[id] = now.Add(ThrottlePeriod)
+		return fmt.Errorf("%w: key %s", ErrThrottled, fingerprint)
+	case settings.RepeatedSignPrompt:
+		jsutil.Log("Client %s requested %d signatures with key %s; prompting", g.client, count, fingerprint)
+		allowed, decided, err := g.prompter.Prompt(ctx, g.client, fingerprint, count)
+		if err != nil {
+			return fmt.Errorf("failed to prompt for signature: %w", err)
+		}
+		if !decided {
+			// Refuse this signature, but ask again next time.
+			return fmt.Errorf("%w: key %s", ErrDenied, fingerprint)
+		}
+		if !allowed {
+			// Refuse signatures for the rest of the connection.
+			g.denied[id] = true
+			return fmt.Errorf("%w: key %s", ErrDenied, fingerprint)
+		}
+		g.approved[id] = true
+		return nil
+	default:
+		return nil
+	}
+}
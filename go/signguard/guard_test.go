@@ -0,0 +1,198 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/chrome-ssh-agent/go/settings"
+	sfakes "github.com/google/chrome-ssh-agent/go/settings/fakes"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// fakePrompter answers prompts with a fixed response, and records the
+// number of times it was prompted.
+type fakePrompter struct {
+	allowed bool
+	decided bool
+	prompts int
+}
+
+func (f *fakePrompter) Prompt(ctx jsutil.AsyncContext, client, fingerprint string, count int) (bool, bool, error) {
+	f.prompts++
+	return f.allowed, f.decided, nil
+}
+
+// signResult summarizes the result of a signature.
+type signResult int
+
+const (
+	signed signResult = iota
+	throttled
+	denied
+)
+
+func TestGuardSign(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		protection  string
+		prompter    *fakePrompter
+		// signs is the number of signatures requested in quick
+		// succession.
+		signs int
+		// wait is the time to wait before a final signature.
+		wait        time.Duration
+		want        []signResult
+		wantFinal   signResult
+		wantPrompts int
+	}{
+		{
+			description: "protection disabled",
+			protection:  "",
+			prompter:    &fakePrompter{},
+			signs:       6,
+			want:        []signResult{signed, signed, signed, signed, signed, signed},
+			wantFinal:   signed,
+		},
+		{
+			description: "protection off",
+			protection:  settings.RepeatedSignOff,
+			prompter:    &fakePrompter{},
+			signs:       6,
+			want:        []signResult{signed, signed, signed, signed, signed, signed},
+			wantFinal:   signed,
+		},
+		{
+			description: "throttle",
+			protection:  settings.RepeatedSignThrottle,
+			prompter:    &fakePrompter{},
+			signs:       6,
+			wait:        time.Second,
+			want:        []signResult{signed, signed, signed, signed, throttled, throttled},
+			wantFinal:   throttled,
+		},
+		{
+			description: "throttle expires",
+			protection:  settings.RepeatedSignThrottle,
+			prompter:    &fakePrompter{},
+			signs:       5,
+			wait:        ThrottlePeriod,
+			want:        []signResult{signed, signed, signed, signed, throttled},
+			wantFinal:   signed,
+		},
+		{
+			description: "prompt allowed",
+			protection:  settings.RepeatedSignPrompt,
+			prompter:    &fakePrompter{allowed: true, decided: true},
+			signs:       7,
+			want:        []signResult{signed, signed, signed, signed, signed, signed, signed},
+			wantFinal:   signed,
+			wantPrompts: 1,
+		},
+		{
+			description: "prompt denied",
+			protection:  settings.RepeatedSignPrompt,
+			prompter:    &fakePrompter{allowed: false, decided: true},
+			signs:       6,
+			wait:        ThrottlePeriod,
+			want:        []signResult{signed, signed, signed, signed, denied, denied},
+			wantFinal:   denied,
+			wantPrompts: 1,
+		},
+		{
+			description: "prompt dismissed",
+			protection:  settings.RepeatedSignPrompt,
+			prompter:    &fakePrompter{decided: false},
+			signs:       6,
+			wait:        ThrottlePeriod,
+			want:        []signResult{signed, signed, signed, signed, denied, denied},
+			wantFinal:   signed,
+			wantPrompts: 2,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				sts := settings.NewStore(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()), sfakes.NewManaged())
+				if err := sts.Set(ctx, &settings.Settings{RepeatedSignProtection: tc.protection}); err != nil {
+					t.Fatalf("failed to write settings: %v", err)
+				}
+
+				priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+				if err != nil {
+					t.Fatalf("failed to parse key: %v", err)
+				}
+				agt := agent.NewKeyring()
+				if err := agt.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+					t.Fatalf("failed to add key: %v", err)
+				}
+				loaded, err := agt.List()
+				if err != nil {
+					t.Fatalf("failed to list keys: %v", err)
+				}
+
+				clk := fakes.NewClock(time.Unix(1700000000, 0))
+				g := NewGuard(agt, "client-1", sts, tc.prompter, clk)
+				sign := func() signResult {
+					_, err := g.Sign(loaded[0], []byte("data"))
+					switch {
+					case err == nil:
+						return signed
+					case errors.Is(err, ErrThrottled):
+						return throttled
+					case errors.Is(err, ErrDenied):
+						return denied
+					default:
+						t.Fatalf("unexpected error: %v", err)
+						return signed
+					}
+				}
+
+				var got []signResult
+				for i := 0; i < tc.signs; i++ {
+					got = append(got, sign())
+					clk.Advance(10 * time.Millisecond)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect results; -got +want: %s", diff)
+				}
+
+				clk.Advance(tc.wait)
+				if diff := cmp.Diff(sign(), tc.wantFinal); diff != "" {
+					t.Errorf("incorrect final result; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(tc.prompter.prompts, tc.wantPrompts); diff != "" {
+					t.Errorf("incorrect prompts; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,110 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// UsageNotifier notifies the user of signatures with a key configured to
+// require it.
+type UsageNotifier interface {
+	// NotifyUsage notifies the user that the client requested a
+	// signature using the key with the specified name and fingerprint.
+	// signErr is the reason the signature was refused, or nil if it was
+	// made.
+	NotifyUsage(ctx jsutil.AsyncContext, client, name, fingerprint string, signErr error) error
+}
+
+// Notifier wraps the agent for a single connection, and notifies the user of
+// each signature made, or refused, with a key configured to require it. The
+// key's configuration is read on every signature, so changes take effect
+// without the client reconnecting. Failure to notify the user does not
+// affect the signature.
+//
+// Notifier implements the agent.ExtendedAgent interface.
+type Notifier struct {
+	agent.Agent
+	client   string
+	lookup   KeyLookup
+	notifier UsageNotifier
+}
+
+// NewNotifier returns a Notifier for a connection from the specified client.
+func NewNotifier(agt agent.Agent, client string, lookup KeyLookup, notifier UsageNotifier) *Notifier {
+	return &Notifier{
+		Agent:    agt,
+		client:   client,
+		lookup:   lookup,
+		notifier: notifier,
+	}
+}
+
+// Sign implements agent.Agent.Sign.
+func (n *Notifier) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	sig, err := n.Agent.Sign(key, data)
+	n.notify(key, err)
+	return sig, err
+}
+
+// SignWithFlags implements agent.ExtendedAgent.SignWithFlags.
+func (n *Notifier) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	ext, ok := n.Agent.(agent.ExtendedAgent)
+	if !ok {
+		if flags != 0 {
+			err := fmt.Errorf("signature flags %d not supported", flags)
+			n.notify(key, err)
+			return nil, err
+		}
+		return n.Sign(key, data)
+	}
+	sig, err := ext.SignWithFlags(key, data, flags)
+	n.notify(key, err)
+	return sig, err
+}
+
+// Extension implements agent.ExtendedAgent.Extension.
+func (n *Notifier) Extension(extensionType string, contents []byte) ([]byte, error) {
+	if ext, ok := n.Agent.(agent.ExtendedAgent); ok {
+		return ext.Extension(extensionType, contents)
+	}
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// notify notifies the user of the signature with the key, if the key is
+// configured to require it. Signatures are requested outside of an
+// AsyncContext, so the user is notified asynchronously.
+func (n *Notifier) notify(key ssh.PublicKey, signErr error) {
+	jsutil.RunAsync(func(ctx jsutil.AsyncContext) {
+		fingerprint := ssh.FingerprintSHA256(key)
+		k, err := n.lookup(ctx, key)
+		if err != nil {
+			jsutil.LogError("failed to read configuration for key %s: %v", fingerprint, err)
+			return
+		}
+		if k == nil || !k.Notify {
+			return
+		}
+		if err := n.notifier.NotifyUsage(ctx, n.client, k.Name, fingerprint, signErr); err != nil {
+			jsutil.LogError("failed to notify of signature with key %s: %v", fingerprint, err)
+		}
+	})
+}
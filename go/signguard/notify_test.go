@@ -0,0 +1,134 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/keys/testdata"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// usage is a single notification sent to fakeUsageNotifier.
+type usage struct {
+	Client string
+	Name   string
+	Failed bool
+}
+
+// fakeUsageNotifier sends each notification it receives to a channel.
+type fakeUsageNotifier struct {
+	notified chan usage
+}
+
+func (f *fakeUsageNotifier) NotifyUsage(ctx jsutil.AsyncContext, client, name, fingerprint string, signErr error) error {
+	f.notified <- usage{Client: client, Name: name, Failed: signErr != nil}
+	return nil
+}
+
+func TestNotifierSign(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		key         *keys.ConfiguredKey
+		lookupErr   error
+		unloaded    bool
+		want        *usage
+	}{
+		{
+			description: "key not configured",
+		},
+		{
+			description: "notification not required",
+			key:         &keys.ConfiguredKey{Name: "key"},
+		},
+		{
+			description: "key used",
+			key:         &keys.ConfiguredKey{Name: "key", Notify: true},
+			want:        &usage{Client: "client-1", Name: "key"},
+		},
+		{
+			description: "signature refused",
+			key:         &keys.ConfiguredKey{Name: "key", Notify: true},
+			unloaded:    true,
+			want:        &usage{Client: "client-1", Name: "key", Failed: true},
+		},
+		{
+			description: "lookup fails",
+			lookupErr:   errors.New("failed"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				priv, err := ssh.ParseRawPrivateKey([]byte(testdata.ED25519WithoutPassphrase.Private))
+				if err != nil {
+					t.Errorf("failed to parse key: %v", err)
+					return
+				}
+				signer, err := ssh.NewSignerFromKey(priv)
+				if err != nil {
+					t.Errorf("failed to create signer: %v", err)
+					return
+				}
+				agt := agent.NewKeyring()
+				if !tc.unloaded {
+					if err := agt.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+						t.Errorf("failed to add key: %v", err)
+						return
+					}
+				}
+
+				lookup := func(ctx jsutil.AsyncContext, pub ssh.PublicKey) (*keys.ConfiguredKey, error) {
+					return tc.key, tc.lookupErr
+				}
+				notifier := &fakeUsageNotifier{notified: make(chan usage, 1)}
+				n := NewNotifier(agt, "client-1", lookup, notifier)
+				_, err = n.Sign(signer.PublicKey(), []byte("data"))
+				if gotErr := err != nil; gotErr != tc.unloaded {
+					t.Errorf("incorrect error: got %v, want error %t", err, tc.unloaded)
+				}
+
+				// Notifications are sent asynchronously; wait a short
+				// time if none is expected.
+				var got *usage
+				timeout := 5 * time.Second
+				if tc.want == nil {
+					timeout = 100 * time.Millisecond
+				}
+				select {
+				case u := <-notifier.notified:
+					got = &u
+				case <-time.After(timeout):
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect notification; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,93 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signguard
+
+import (
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/chrome"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// NotificationPrompter prompts the user using a desktop notification.
+//
+// NotificationPrompter implements the Prompter, ConfirmPrompter and
+// UsageNotifier interfaces.
+type NotificationPrompter struct {
+	notifications *chrome.Notifications
+}
+
+// NewNotificationPrompter returns a NotificationPrompter that displays
+// prompts using the supplied notifications.
+func NewNotificationPrompter(notifications *chrome.Notifications) *NotificationPrompter {
+	return &NotificationPrompter{
+		notifications: notifications,
+	}
+}
+
+const (
+	allowButton = iota
+	denyButton
+)
+
+// Prompt implements Prompter.Prompt().
+func (p *NotificationPrompter) Prompt(ctx jsutil.AsyncContext, client, fingerprint string, count int) (allowed, decided bool, err error) {
+	button, err := p.notifications.Ask(
+		ctx,
+		"Allow repeated signatures?",
+		fmt.Sprintf("%s requested %d signatures with key %s in quick succession.", client, count, fingerprint),
+		[]string{"Allow", "Deny"})
+	if err != nil {
+		return false, false, err
+	}
+
+	switch button {
+	case allowButton:
+		return true, true, nil
+	case denyButton:
+		return false, true, nil
+	default:
+		return false, false, nil
+	}
+}
+
+// Confirm implements ConfirmPrompter.Confirm().
+func (p *NotificationPrompter) Confirm(ctx jsutil.AsyncContext, client, name, fingerprint string) (bool, error) {
+	button, err := p.notifications.Ask(
+		ctx,
+		"Allow signature?",
+		fmt.Sprintf("%s requested a signature with key '%s' (%s).", client, name, fingerprint),
+		[]string{"Allow", "Deny"})
+	if err != nil {
+		return false, err
+	}
+	return button == allowButton, nil
+}
+
+// NotifyUsage implements UsageNotifier.NotifyUsage().
+func (p *NotificationPrompter) NotifyUsage(ctx jsutil.AsyncContext, client, name, fingerprint string, signErr error) error {
+	if signErr != nil {
+		return p.notifications.Notify(
+			ctx,
+			"Signature refused",
+			fmt.Sprintf("A signature requested by %s with key '%s' (%s) was refused: %v", client, name, fingerprint, signErr))
+	}
+	return p.notifications.Notify(
+		ctx,
+		"Key used",
+		fmt.Sprintf("%s signed using key '%s' (%s).", client, name, fingerprint))
+}
@@ -17,6 +17,7 @@
 package storage
 
 import (
+	"sort"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -40,4 +41,47 @@ type Area interface {
 	// key is not found in storage, it will be silently ignored (i.e., no
 	// error will be returned).
 	Delete(ctx jsutil.AsyncContext, keys []string) error
+
+	// Watch invokes callback whenever items in storage are changed,
+	// including by other parts of the extension or, for synced storage,
+	// on another of the user's devices. Watching continues until the
+	// returned cleanup function is invoked.
+	Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error)
+
+	// BytesInUse returns the number of bytes used in storage by the items
+	// with the specified keys, or by all items if keys is nil.
+	BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error)
+
+	// Quota returns the total number of bytes that may be stored, or 0 if
+	// it is not known.
+	Quota() int
+}
+
+// ChangeFunc is invoked when items in storage are changed. keys are the keys
+// of the items that were set or removed, in sorted order. Values are not
+// supplied, since they may need to be read through other Areas to be
+// understood (e.g., if stored encrypted); callers should read any they need.
+type ChangeFunc func(ctx jsutil.AsyncContext, keys []string)
+
+// filterChanges returns a ChangeFunc that invokes callback with the changed
+// keys translated by f. Keys for which f returns false are omitted, and
+// callback is not invoked if no keys remain.
+func filterChanges(callback ChangeFunc, f func(key string) (string, bool)) ChangeFunc {
+	return func(ctx jsutil.AsyncContext, keys []string) {
+		seen := map[string]bool{}
+		var result []string
+		for _, k := range keys {
+			nk, ok := f(k)
+			if !ok || seen[nk] {
+				continue
+			}
+			seen[nk] = true
+			result = append(result, nk)
+		}
+		if len(result) == 0 {
+			return
+		}
+		sort.Strings(result)
+		callback(ctx, result)
+	}
 }
@@ -169,26 +169,19 @@ func (b *Big) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
 		chunked[k] = vert.ValueOf(manifest).JSValue()
 	}
 
-	var err error
-	_, aerr := lock.Async(lockResourceID, func(ctx jsutil.AsyncContext) {
-		err = b.s.Set(ctx, chunked)
-	}).Await(ctx)
-	if aerr != nil {
-		return aerr
-	}
-	return err
+	return lock.WithLock(ctx, lockResourceID, func(ctx jsutil.AsyncContext) error {
+		return b.s.Set(ctx, chunked)
+	})
 }
 
 // See PersistentStore.Get().
 func (b *Big) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
 	var data map[string]js.Value
-	var err error
-	_, aerr := lock.Async(lockResourceID, func(ctx jsutil.AsyncContext) {
+	err := lock.WithLock(ctx, lockResourceID, func(ctx jsutil.AsyncContext) error {
+		var err error
 		data, err = b.s.Get(ctx)
-	}).Await(ctx)
-	if aerr != nil {
-		return nil, aerr
-	}
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -208,11 +201,11 @@ func (b *Big) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
 			for _, chunkKey := range manifest.ChunkKeys {
 				chunkVal, present := data[chunkKey]
 				if !present {
-					return nil, fmt.Errorf("failed to read data; chunk key %s missing", chunkKey)
+					return nil, fmt.Errorf("%w: failed to read data; chunk key %s missing", ErrCorrupted, chunkKey)
 				}
 				dec, err := base64.StdEncoding.DecodeString(chunkVal.String())
 				if err != nil {
-					return nil, fmt.Errorf("failed to read data; base64 decode failed: %w", err)
+					return nil, fmt.Errorf("%w: failed to read data; base64 decode failed: %w", ErrCorrupted, err)
 				}
 
 				json.WriteString(string(dec))
@@ -231,55 +224,83 @@ func (b *Big) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
 
 // See PersistentStore.Delete().
 func (b *Big) Delete(ctx jsutil.AsyncContext, keys []string) error {
-	var derr error
-	_, aerr := lock.Async(lockResourceID, func(ctx jsutil.AsyncContext) {
-		derr = func() error {
-			// Delete the requested keys.
-			if err := b.s.Delete(ctx, keys); err != nil {
-				return err
-			}
-
-			// Once successful, delete all chunks that are no longer
-			// referenced by any manifest. This takes care of those that
-			// were just deleted, as well as any dangling ones that may
-			// have been left over from before.
-			data, err := b.s.Get(ctx)
-			if err != nil {
-				return fmt.Errorf("failed to query for dangling chunks: %w", err)
-			}
+	return lock.WithLock(ctx, lockResourceID, func(ctx jsutil.AsyncContext) error {
+		// Delete the requested keys.
+		if err := b.s.Delete(ctx, keys); err != nil {
+			return err
+		}
 
-			// Initially, consider all chunk keys as dangling.
-			danglingChunkKeys := map[string]bool{}
-			for k := range data {
-				if isChunkKey(k) {
-					danglingChunkKeys[k] = true
-				}
-			}
+		// Once successful, delete all chunks that are no longer
+		// referenced by any manifest. This takes care of those that
+		// were just deleted, as well as any dangling ones that may
+		// have been left over from before.
+		data, err := b.s.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to query for dangling chunks: %w", err)
+		}
 
-			// Remove those that are referenced by a manifest.
-			for _, v := range data {
-				var manifest bigValueManifest
-				if err := vert.ValueOf(v).AssignTo(&manifest); err != nil || !manifest.Valid() {
-					continue // This is not a manifest.
-				}
-				for _, chunkKey := range manifest.ChunkKeys {
-					delete(danglingChunkKeys, chunkKey)
-				}
+		// Initially, consider all chunk keys as dangling.
+		danglingChunkKeys := map[string]bool{}
+		for k := range data {
+			if isChunkKey(k) {
+				danglingChunkKeys[k] = true
 			}
+		}
 
-			// Delete dangling chunk keys.
-			var dangling []string
-			for k := range danglingChunkKeys {
-				dangling = append(dangling, k)
+		// Remove those that are referenced by a manifest.
+		for _, v := range data {
+			var manifest bigValueManifest
+			if err := vert.ValueOf(v).AssignTo(&manifest); err != nil || !manifest.Valid() {
+				continue // This is not a manifest.
 			}
-			if err := b.s.Delete(ctx, dangling); err != nil {
-				return fmt.Errorf("failed to delete dangling chunks: %w", err)
+			for _, chunkKey := range manifest.ChunkKeys {
+				delete(danglingChunkKeys, chunkKey)
 			}
-			return nil
-		}()
-	}).Await(ctx)
-	if aerr != nil {
-		return aerr
+		}
+
+		// Delete dangling chunk keys.
+		var dangling []string
+		for k := range danglingChunkKeys {
+			dangling = append(dangling, k)
+		}
+		if err := b.s.Delete(ctx, dangling); err != nil {
+			return fmt.Errorf("failed to delete dangling chunks: %w", err)
+		}
+		return nil
+	})
+}
+
+// Watch implements Area.Watch(). Changes to chunks are not reported; a change
+// to a big value is reported as a change to its key.
+func (b *Big) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return b.s.Watch(ctx, filterChanges(callback, func(key string) (string, bool) {
+		return key, !isChunkKey(key)
+	}))
+}
+
+// BytesInUse implements Area.BytesInUse(). The usage of a big value includes
+// its chunks.
+func (b *Big) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	if keys == nil {
+		return b.s.BytesInUse(ctx, nil)
+	}
+
+	data, err := b.s.Get(ctx)
+	if err != nil {
+		return 0, err
 	}
-	return derr
+	nkeys := []string{}
+	for _, k := range keys {
+		nkeys = append(nkeys, k)
+		var manifest bigValueManifest
+		if v, present := data[k]; present && vert.ValueOf(v).AssignTo(&manifest) == nil && manifest.Valid() {
+			nkeys = append(nkeys, manifest.ChunkKeys...)
+		}
+	}
+	return b.s.BytesInUse(ctx, nkeys)
+}
+
+// Quota implements Area.Quota().
+func (b *Big) Quota() int {
+	return b.s.Quota()
 }
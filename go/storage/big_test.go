@@ -273,3 +273,50 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestBigWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		b := NewBig(200, NewRaw(st.NewMemArea()))
+		next, cleanup := watchChanges(ctx, t, b)
+		defer cleanup()
+
+		// Chunks are not reported; only the key of the big value.
+		if err := b.Set(ctx, map[string]js.Value{"key": js.ValueOf(strings.Repeat("x", 1000))}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(next(), []string{"key"}); diff != "" {
+			t.Errorf("incorrect changes; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestBigBytesInUse(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		b := NewBig(200, raw)
+		if err := b.Set(ctx, map[string]js.Value{
+			"big":   js.ValueOf(strings.Repeat("x", 1000)),
+			"small": js.ValueOf(1),
+		}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// A big value's usage includes its chunks, which are the
+		// remainder of the storage used.
+		all, err := raw.BytesInUse(ctx, nil)
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		got, err := b.BytesInUse(ctx, []string{"big"})
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		if want := all - len("small1"); got != want {
+			t.Errorf("incorrect BytesInUse for big value: got %d, want %d", got, want)
+		}
+	})
+}
@@ -18,16 +18,33 @@ package storage
 
 import (
 	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
 )
 
 // DefaultSync returns an Area that can store and retrieve data that is synced
 // between the user's devices.  See:
 //
 //	https://developer.chrome.com/docs/extensions/reference/storage/#property-sync
+//
+// In some profiles (e.g., guest profiles), synced storage is not supported; an
+// Unavailable is returned instead.
 func DefaultSync() Area {
 	area := js.Global().Get("chrome").Get("storage").Get("sync")
+	if area.Type() != js.TypeObject {
+		return NewUnavailable("sync")
+	}
 	maxItemBytes := area.Get("QUOTA_BYTES_PER_ITEM").Int()
-	return NewBig(maxItemBytes, NewRaw(area))
+	return NewRetrying(NewBig(maxItemBytes, NewRaw(area)), DefaultRetryAttempts, DefaultRetryDelay, clock.Real)
+}
+
+// DefaultLocal returns an Area that can store and retrieve data that is stored
+// only on the local device.  See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/storage/#property-local
+func DefaultLocal() Area {
+	area := js.Global().Get("chrome").Get("storage").Get("local")
+	return NewRetrying(NewRaw(area), DefaultRetryAttempts, DefaultRetryDelay, clock.Real)
 }
 
 // DefaultSession returns an Area that can store and retrieve in-memory data.
@@ -36,5 +53,14 @@ func DefaultSync() Area {
 //	https://developer.chrome.com/docs/extensions/reference/storage/#property-session
 func DefaultSession() Area {
 	area := js.Global().Get("chrome").Get("storage").Get("session")
-	return NewRaw(area)
+	return NewRetrying(NewRaw(area), DefaultRetryAttempts, DefaultRetryDelay, clock.Real)
+}
+
+// DefaultManaged returns an Area that reads data configured by an
+// administrator. The data is read-only.  See:
+//
+//	https://developer.chrome.com/docs/extensions/reference/storage/#property-managed
+func DefaultManaged() Area {
+	area := js.Global().Get("chrome").Get("storage").Get("managed")
+	return NewRetrying(NewRaw(area), DefaultRetryAttempts, DefaultRetryDelay, clock.Real)
 }
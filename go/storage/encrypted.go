@@ -0,0 +1,362 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/norunners/vert"
+)
+
+// ErrUndecryptable indicates that a value read from an Encrypted area could
+// not be decrypted, either because the key is unavailable, or because the
+// value is corrupted.
+var ErrUndecryptable = errors.New("value could not be decrypted; unlock the vault to read it")
+
+// KeySource supplies the key with which an Encrypted area encrypts values.
+type KeySource interface {
+	// EncryptionKey returns the AES-256 key used to encrypt and decrypt
+	// values. An error is returned if the key is not currently available
+	// (e.g., because the password from which it is derived has not been
+	// entered).
+	EncryptionKey(ctx jsutil.AsyncContext) ([]byte, error)
+}
+
+// Encrypted encrypts the values stored in an underlying area using AES-GCM,
+// such that they cannot be read by anything else with access to the
+// underlying area (e.g., the user's synced data). Only values stored under
+// the supplied key prefixes (as used with NewView) are encrypted; others are
+// stored as-is, such that the area can be shared with other data.
+//
+// Encryption is enabled using SetEnabled, and the setting is recorded in the
+// underlying area such that it applies wherever the area is used. While
+// encryption is disabled, values are stored as-is. Encrypted values are
+// decrypted when read regardless of the setting. Values that cannot be
+// decrypted (e.g., because the key is not available) are returned as stored;
+// readers such as Typed report them as malformed (see ErrUndecryptable), such
+// that the remaining values can still be read.
+//
+// Encrypted implements the Area interface.
+type Encrypted struct {
+	keys     KeySource
+	prefixes []string
+	s        Area
+}
+
+// NewEncrypted returns an Encrypted wrapping the supplied area, which encrypts
+// values under the specified key prefixes using the key obtained from keys.
+func NewEncrypted(keys KeySource, prefixes []string, store Area) *Encrypted {
+	var prefixesAdj []string
+	for _, p := range prefixes {
+		prefixesAdj = append(prefixesAdj, p+".")
+	}
+
+	return &Encrypted{
+		keys:     keys,
+		prefixes: prefixesAdj,
+		s:        store,
+	}
+}
+
+// WithStore returns an Encrypted that uses the same key and key prefixes, but
+// wraps a different underlying area. This is typically a Transaction staging
+// changes to the original area, such that values are encrypted before they
+// are staged (and hence recorded in the commit record).
+func (e *Encrypted) WithStore(store Area) *Encrypted {
+	return &Encrypted{
+		keys:     e.keys,
+		prefixes: e.prefixes,
+		s:        store,
+	}
+}
+
+// encrypted returns true if the value stored at key is to be encrypted.
+func (e *Encrypted) encrypted(key string) bool {
+	for _, p := range e.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptedValue is the value stored in place of an encrypted value.
+type encryptedValue struct {
+	// Magic is a string that must equal encryptedValueMagic. This
+	// distinguishes encrypted values from any other value that may have
+	// been stored.
+	Magic string `js:"magic"`
+	// Data is the base64-encoded nonce and ciphertext of the value's JSON
+	// encoding.
+	Data string `js:"data"`
+}
+
+func (e *encryptedValue) Valid() bool {
+	return e.Magic == encryptedValueMagic
+}
+
+// isEncryptedValue returns true if v is an encryptedValue.
+func isEncryptedValue(v js.Value) bool {
+	if v.Type() != js.TypeObject {
+		return false
+	}
+	var ev encryptedValue
+	return vert.ValueOf(v).AssignTo(&ev) == nil && ev.Valid()
+}
+
+// encryptionConfig records whether encryption is enabled.
+type encryptionConfig struct {
+	Enabled bool `js:"enabled"`
+}
+
+const (
+	// encryptedValueMagic is the magic string that we encode in encrypted
+	// values.
+	encryptedValueMagic = "b3fd27f7-44af-44e4-8254-c24fbb873e71"
+
+	// encryptionConfigKey is the key at which encryptionConfig is
+	// stored. It is never encrypted, and is hidden from reads.
+	encryptionConfigKey = "encryption-" + encryptedValueMagic
+)
+
+// additionalData returns the additional data bound to the value stored at
+// key, such that an encrypted value cannot be substituted for another.
+func additionalData(key string) []byte {
+	return []byte("storage:" + key)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// encrypt returns the encryptedValue to be stored in place of val at key.
+func encrypt(aead cipher.AEAD, key string, val js.Value) (js.Value, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return js.Undefined(), fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(jsutil.ToJSON(val)), additionalData(key))
+	return vert.ValueOf(&encryptedValue{
+		Magic: encryptedValueMagic,
+		Data:  base64.StdEncoding.EncodeToString(sealed),
+	}).JSValue(), nil
+}
+
+// decrypt returns the value that was encrypted to produce ev at key.
+func decrypt(aead cipher.AEAD, key string, ev *encryptedValue) (js.Value, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ev.Data)
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("%w: failed to decrypt %s; base64 decode failed: %w", ErrCorrupted, key, err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return js.Undefined(), fmt.Errorf("%w: failed to decrypt %s; ciphertext too short", ErrCorrupted, key)
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	json, err := aead.Open(nil, nonce, sealed, additionalData(key))
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("%w: failed to decrypt %s: %w", ErrCorrupted, key, err)
+	}
+	return jsutil.FromJSON(string(json)), nil
+}
+
+// aead returns the cipher used to encrypt and decrypt values.
+func (e *Encrypted) aead(ctx jsutil.AsyncContext) (cipher.AEAD, error) {
+	key, err := e.keys.EncryptionKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key unavailable: %w", err)
+	}
+	return newAEAD(key)
+}
+
+// Enabled returns true if values are encrypted when written.
+func (e *Encrypted) Enabled(ctx jsutil.AsyncContext) (bool, error) {
+	data, err := e.s.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	v, ok := data[encryptionConfigKey]
+	if !ok {
+		return false, nil
+	}
+	var c encryptionConfig
+	if err := vert.ValueOf(v).AssignTo(&c); err != nil {
+		return false, fmt.Errorf("%w: failed to parse encryption setting: %w", ErrCorrupted, err)
+	}
+	return c.Enabled, nil
+}
+
+// SetEnabled configures whether values are encrypted when written, and
+// rewrites the stored values under the key prefixes accordingly. The key must
+// be available, both to read values that are currently encrypted and to
+// encrypt them. Values that cannot be decrypted are left as stored.
+func (e *Encrypted) SetEnabled(ctx jsutil.AsyncContext, enabled bool) error {
+	if _, err := e.aead(ctx); err != nil {
+		return err
+	}
+	all, undecryptable, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	data := map[string]js.Value{}
+	for k, v := range all {
+		if _, ok := undecryptable[k]; ok {
+			jsutil.LogError("Encrypted: leaving %s as stored: %v", k, undecryptable[k])
+			continue
+		}
+		if e.encrypted(k) {
+			data[k] = v
+		}
+	}
+	c := &encryptionConfig{Enabled: enabled}
+	if err := e.s.Set(ctx, map[string]js.Value{encryptionConfigKey: vert.ValueOf(c).JSValue()}); err != nil {
+		return fmt.Errorf("failed to write encryption setting: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return e.Set(ctx, data)
+}
+
+// Set implements Area.Set().
+func (e *Encrypted) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	enabled, err := e.Enabled(ctx)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return e.s.Set(ctx, data)
+	}
+
+	aead, err := e.aead(ctx)
+	if err != nil {
+		return err
+	}
+	encrypted := map[string]js.Value{}
+	for k, v := range data {
+		if !e.encrypted(k) {
+			encrypted[k] = v
+			continue
+		}
+		ev, err := encrypt(aead, k, v)
+		if err != nil {
+			return err
+		}
+		encrypted[k] = ev
+	}
+	return e.s.Set(ctx, encrypted)
+}
+
+// Get implements Area.Get(). Values that cannot be decrypted are returned as
+// stored.
+func (e *Encrypted) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	data, undecryptable, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for k, err := range undecryptable {
+		jsutil.LogDebug("Encrypted: failed to decrypt %s: %v", k, err)
+	}
+	return data, nil
+}
+
+// get returns the stored values, decrypting those that are encrypted. Values
+// that cannot be decrypted are returned as stored, and the reason is returned
+// in undecryptable.
+func (e *Encrypted) get(ctx jsutil.AsyncContext) (data map[string]js.Value, undecryptable map[string]error, err error) {
+	stored, err := e.s.Get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var aead cipher.AEAD
+	var aeadErr error
+	decrypted := map[string]js.Value{}
+	undecryptable = map[string]error{}
+	for k, v := range stored {
+		if k == encryptionConfigKey {
+			continue
+		}
+
+		if !e.encrypted(k) {
+			decrypted[k] = v
+			continue
+		}
+		var ev encryptedValue
+		if err := vert.ValueOf(v).AssignTo(&ev); err != nil || !ev.Valid() {
+			// Stored while encryption was disabled.
+			decrypted[k] = v
+			continue
+		}
+
+		if aead == nil && aeadErr == nil {
+			aead, aeadErr = e.aead(ctx)
+		}
+		if aeadErr != nil {
+			decrypted[k] = v
+			undecryptable[k] = aeadErr
+			continue
+		}
+		dv, err := decrypt(aead, k, &ev)
+		if err != nil {
+			decrypted[k] = v
+			undecryptable[k] = err
+			continue
+		}
+		decrypted[k] = dv
+	}
+	return decrypted, undecryptable, nil
+}
+
+// Delete implements Area.Delete().
+func (e *Encrypted) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	return e.s.Delete(ctx, keys)
+}
+
+// BytesInUse implements Area.BytesInUse().
+func (e *Encrypted) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	return e.s.BytesInUse(ctx, keys)
+}
+
+// Quota implements Area.Quota().
+func (e *Encrypted) Quota() int {
+	return e.s.Quota()
+}
+
+// Watch implements Area.Watch(). As with Get, changes to the encryption
+// setting are hidden; enabling or disabling encryption rewrites the values,
+// which are reported instead.
+func (e *Encrypted) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return e.s.Watch(ctx, filterChanges(callback, func(key string) (string, bool) {
+		return key, key != encryptionConfigKey
+	}))
+}
@@ -0,0 +1,273 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/norunners/vert"
+)
+
+var errKeyUnavailable = errors.New("key unavailable")
+
+// fakeKeySource returns a fixed key, or an error if the key is nil.
+type fakeKeySource struct {
+	key []byte
+}
+
+func (f *fakeKeySource) EncryptionKey(ctx jsutil.AsyncContext) ([]byte, error) {
+	if f.key == nil {
+		return nil, errKeyUnavailable
+	}
+	return f.key, nil
+}
+
+func TestEncrypted(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		keys := &fakeKeySource{key: bytes.Repeat([]byte{1}, 32)}
+		raw := NewRaw(st.NewMemArea())
+		e := NewEncrypted(keys, []string{"key"}, raw)
+
+		// Values are stored as-is until encryption is enabled, and
+		// values outside the prefixes are never encrypted.
+		if err := e.Set(ctx, map[string]js.Value{"key.plain": js.ValueOf("value-1")}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+		if err := e.SetEnabled(ctx, true); err != nil {
+			t.Errorf("SetEnabled failed: %v", err)
+			return
+		}
+		if enabled, err := e.Enabled(ctx); err != nil || !enabled {
+			t.Errorf("incorrect Enabled: got %t, %v; want true", enabled, err)
+		}
+		if err := e.Set(ctx, map[string]js.Value{"key.secret": js.ValueOf("value-2"), "other": js.ValueOf("other")}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+
+		// All values are readable, and only those under the prefix are
+		// encrypted.
+		data, err := e.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		want := map[string]string{"key.plain": `"value-1"`, "key.secret": `"value-2"`, "other": `"other"`}
+		if diff := cmp.Diff(dataToJSON(data), want); diff != "" {
+			t.Errorf("incorrect data; -got +want: %s", diff)
+		}
+		stored, err := raw.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		for k, v := range dataToJSON(stored) {
+			if strings.Contains(v, "value-") {
+				t.Errorf("value %s stored in the clear: %s", k, v)
+			}
+		}
+		if got := jsutil.ToJSON(stored["other"]); got != `"other"` {
+			t.Errorf("incorrect stored value for other: got %s, want %q", got, `"other"`)
+		}
+
+		// Without the key, encrypted values are returned as stored, and
+		// others remain readable.
+		keys.key = nil
+		data, err = e.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		for _, k := range []string{"key.plain", "key.secret"} {
+			if !isEncryptedValue(data[k]) {
+				t.Errorf("value %s not returned as stored: %s", k, jsutil.ToJSON(data[k]))
+			}
+		}
+		if got := jsutil.ToJSON(data["other"]); got != `"other"` {
+			t.Errorf("incorrect value for other: got %s, want %q", got, `"other"`)
+		}
+		if err := e.SetEnabled(ctx, false); !errors.Is(err, errKeyUnavailable) {
+			t.Errorf("incorrect SetEnabled error: got %v, want %v", err, errKeyUnavailable)
+		}
+
+		// Disabling encryption rewrites values in the clear.
+		keys.key = bytes.Repeat([]byte{1}, 32)
+		if err := e.SetEnabled(ctx, false); err != nil {
+			t.Errorf("SetEnabled failed: %v", err)
+			return
+		}
+		keys.key = nil
+		data, err = e.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(dataToJSON(data), want); diff != "" {
+			t.Errorf("incorrect data; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestEncryptedWrongKey(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		e := NewEncrypted(&fakeKeySource{key: bytes.Repeat([]byte{1}, 32)}, []string{"key"}, raw)
+		if err := e.SetEnabled(ctx, true); err != nil {
+			t.Errorf("SetEnabled failed: %v", err)
+			return
+		}
+		if err := e.Set(ctx, map[string]js.Value{"key.secret": js.ValueOf("value")}); err != nil {
+			t.Errorf("Set failed: %v", err)
+			return
+		}
+
+		other := NewEncrypted(&fakeKeySource{key: bytes.Repeat([]byte{2}, 32)}, []string{"key"}, raw)
+		_, undecryptable, err := other.get(ctx)
+		if err != nil {
+			t.Errorf("get failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(undecryptable["key.secret"], ErrCorrupted, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestEncryptedTypedQuarantine(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		keys := &fakeKeySource{key: bytes.Repeat([]byte{1}, 32)}
+		raw := NewRaw(st.NewMemArea())
+		e := NewEncrypted(keys, testKeyPrefixes, raw)
+		ts := NewTyped[myStruct](e, testKeyPrefixes)
+		if err := ts.Write(ctx, &myStruct{StringField: "plain"}); err != nil {
+			t.Errorf("Write failed: %v", err)
+			return
+		}
+		if err := e.SetEnabled(ctx, true); err != nil {
+			t.Errorf("SetEnabled failed: %v", err)
+			return
+		}
+		if err := ts.Write(ctx, &myStruct{StringField: "secret"}); err != nil {
+			t.Errorf("Write failed: %v", err)
+			return
+		}
+
+		// Corrupt one of the values, and it alone is excluded from
+		// reads and reported as malformed.
+		data, err := raw.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+			return
+		}
+		var corrupted string
+		for k, v := range data {
+			if !isEncryptedValue(v) {
+				continue
+			}
+			var ev encryptedValue
+			if err := vert.ValueOf(v).AssignTo(&ev); err != nil {
+				t.Errorf("AssignTo failed: %v", err)
+				return
+			}
+			// Alter the first byte of the nonce.
+			if ev.Data[0] == 'A' {
+				ev.Data = "B" + ev.Data[1:]
+			} else {
+				ev.Data = "A" + ev.Data[1:]
+			}
+			if err := raw.Set(ctx, map[string]js.Value{k: vert.ValueOf(&ev).JSValue()}); err != nil {
+				t.Errorf("Set failed: %v", err)
+				return
+			}
+			corrupted = k
+			break
+		}
+
+		got, err := ts.ReadAll(ctx)
+		if err != nil {
+			t.Errorf("ReadAll failed: %v", err)
+			return
+		}
+		if len(got) != 1 {
+			t.Errorf("incorrect number of readable values; got %d, want 1", len(got))
+		}
+		malformed, err := ts.Malformed(ctx)
+		if err != nil {
+			t.Errorf("Malformed failed: %v", err)
+			return
+		}
+		if len(malformed) != 1 || testKeyPrefix+"."+malformed[0].Key != corrupted || !errors.Is(malformed[0].Err, ErrUndecryptable) {
+			t.Errorf("incorrect malformed values; got %+v, want %s", malformed, corrupted)
+		}
+
+		// Without the key, all encrypted values are reported as
+		// malformed rather than failing the read.
+		keys.key = nil
+		got, err = ts.ReadAll(ctx)
+		if err != nil {
+			t.Errorf("ReadAll failed: %v", err)
+			return
+		}
+		if len(got) != 0 {
+			t.Errorf("incorrect number of readable values; got %d, want 0", len(got))
+		}
+		malformed, err = ts.Malformed(ctx)
+		if err != nil {
+			t.Errorf("Malformed failed: %v", err)
+			return
+		}
+		if len(malformed) != 2 {
+			t.Errorf("incorrect number of malformed values; got %d, want 2", len(malformed))
+		}
+	})
+}
+
+func TestEncryptedWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		e := NewEncrypted(&fakeKeySource{key: bytes.Repeat([]byte{1}, 32)}, []string{"key"}, raw)
+		next, cleanup := watchChanges(ctx, t, e)
+		defer cleanup()
+
+		// The encryption setting is not reported.
+		if err := e.SetEnabled(ctx, true); err != nil {
+			t.Fatalf("SetEnabled failed: %v", err)
+		}
+		if err := e.Set(ctx, map[string]js.Value{"key.a": js.ValueOf("value")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(next(), []string{"key.a"}); diff != "" {
+			t.Errorf("incorrect changes; -got +want: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Categories of storage errors. Errors returned by an Area wrap at most one of
+// these, so that callers can choose an appropriate remedy using errors.Is.
+var (
+	// ErrQuota indicates that a storage quota was exceeded. Retrying
+	// will not help until data is removed.
+	ErrQuota = errors.New("storage quota exceeded")
+	// ErrUnavailable indicates that storage cannot be accessed at all
+	// (e.g., the API is missing, or the extension was reloaded).
+	ErrUnavailable = errors.New("storage unavailable")
+	// ErrCorrupted indicates that stored data could not be parsed.
+	ErrCorrupted = errors.New("stored data corrupted")
+	// ErrTransient indicates a failure that is likely to succeed if
+	// retried (e.g., a rate limit was hit).
+	ErrTransient = errors.New("temporary storage failure")
+)
+
+var categories = []error{ErrQuota, ErrUnavailable, ErrCorrupted, ErrTransient}
+
+// Category returns the category of the storage error, or nil if it does not
+// have one.
+func Category(err error) error {
+	for _, c := range categories {
+		if errors.Is(err, c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// classify wraps an error returned by Chrome's StorageArea API with its
+// category. Chrome only reports errors as messages, so these are matched
+// against the messages Chrome is known to return. Unrecognized failures are
+// assumed to be transient.
+func classify(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "MAX_WRITE_OPERATIONS_PER_"):
+		// Rate limits; succeeds once the limit resets.
+		return fmt.Errorf("%w: %w", ErrTransient, err)
+	case strings.Contains(msg, "QUOTA_BYTES"), strings.Contains(msg, "MAX_ITEMS"):
+		return fmt.Errorf("%w: %w", ErrQuota, err)
+	case strings.Contains(msg, "Extension context invalidated"),
+		strings.Contains(msg, "Access to storage is not allowed"),
+		strings.Contains(msg, "Cannot read properties of undefined"),
+		strings.Contains(msg, "is not a function"):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return fmt.Errorf("%w: %w", ErrTransient, err)
+}
+
+// categorizedError is an error whose type information was lost (e.g., when
+// converted to a string), but whose category was recovered.
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string { return e.msg }
+func (e *categorizedError) Unwrap() error { return e.category }
+
+// ErrorFromString returns an error with the specified message. If the message
+// describes a storage error with a category, the returned error wraps the
+// category. This allows categories to survive conversion to and from a string
+// (e.g., when errors are sent via messaging).
+func ErrorFromString(msg string) error {
+	for _, c := range categories {
+		if strings.Contains(msg, c.Error()) {
+			return &categorizedError{msg: msg, category: c}
+		}
+	}
+	return errors.New(msg)
+}
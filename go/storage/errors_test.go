@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// rejectingArea returns an object implementing the StorageArea API, for which
+// every operation fails with the specified message.
+func rejectingArea(msg string) (js.Value, func()) {
+	reject := js.FuncOf(func(this js.Value, args []js.Value) any {
+		return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(msg))
+	})
+	area := jsutil.NewObject()
+	area.Set("set", reject)
+	area.Set("get", reject)
+	area.Set("remove", reject)
+	return area, reject.Release
+}
+
+func TestRawErrorCategories(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		msg         string
+		want        error
+	}{
+		{
+			description: "quota bytes",
+			msg:         "QUOTA_BYTES quota exceeded",
+			want:        ErrQuota,
+		},
+		{
+			description: "quota bytes per item",
+			msg:         "QUOTA_BYTES_PER_ITEM quota exceeded",
+			want:        ErrQuota,
+		},
+		{
+			description: "max items",
+			msg:         "MAX_ITEMS quota exceeded",
+			want:        ErrQuota,
+		},
+		{
+			description: "rate limit",
+			msg:         "MAX_WRITE_OPERATIONS_PER_MINUTE quota exceeded",
+			want:        ErrTransient,
+		},
+		{
+			description: "context invalidated",
+			msg:         "Extension context invalidated.",
+			want:        ErrUnavailable,
+		},
+		{
+			description: "unrecognized",
+			msg:         "IO error: something went wrong",
+			want:        ErrTransient,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			area, release := rejectingArea(tc.msg)
+			defer release()
+			r := NewRaw(area)
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				errs := map[string]error{
+					"set":    r.Set(ctx, map[string]js.Value{"key": js.ValueOf(1)}),
+					"delete": r.Delete(ctx, []string{"key"}),
+				}
+				_, errs["get"] = r.Get(ctx)
+				for op, err := range errs {
+					if diff := cmp.Diff(Category(err), tc.want, cmpopts.EquateErrors()); diff != "" {
+						t.Errorf("incorrect category for %s; -got +want: %s", op, diff)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestErrorFromString(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		err         error
+		want        error
+	}{
+		{
+			description: "categorized",
+			err:         fmt.Errorf("failed to read keys: %w", fmt.Errorf("%w: some failure", ErrQuota)),
+			want:        ErrQuota,
+		},
+		{
+			description: "uncategorized",
+			err:         errors.New("failed to read keys"),
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got := ErrorFromString(tc.err.Error())
+			if diff := cmp.Diff(got.Error(), tc.err.Error()); diff != "" {
+				t.Errorf("incorrect message; -got +want: %s", diff)
+			}
+			if diff := cmp.Diff(Category(got), tc.want, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("incorrect category; -got +want: %s", diff)
+			}
+		})
+	}
+}
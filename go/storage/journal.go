@@ -0,0 +1,131 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// RecoverFunc recovers an operation that was interrupted. data is the data
+// supplied when the operation began. The function must either complete the
+// operation or roll it back, and must be safe to invoke multiple times.
+type RecoverFunc func(ctx jsutil.AsyncContext, data js.Value) error
+
+// Journal records operations that are in progress, such that they can be
+// recovered if the worker is suspended or terminated before they complete.
+//
+// An operation is recorded (Begin) before any of its steps are performed, and
+// removed (Commit) once all steps have completed. Any operation remaining in
+// the journal on startup was interrupted; Recover invokes the function
+// registered for it to either complete it or roll it back.
+type Journal struct {
+	entries    *Typed[journalEntry]
+	recoverers map[string]RecoverFunc
+}
+
+// journalEntry is the raw object stored for an operation in progress.
+type journalEntry struct {
+	ID string `js:"id"`
+	Op string `js:"op"`
+	// Data is the JSON-encoded data supplied when the operation began.
+	Data string `js:"data"`
+}
+
+// NewJournal returns a new Journal that records operations in the supplied
+// storage area. keyPrefix is the prefix used to distinguish journal entries
+// from other data in the same storage area.
+func NewJournal(store Area, keyPrefix []string) *Journal {
+	return &Journal{
+		entries:    NewTyped[journalEntry](store, keyPrefix),
+		recoverers: map[string]RecoverFunc{},
+	}
+}
+
+// Register registers the function that recovers interrupted operations of the
+// specified type.
+func (j *Journal) Register(op string, f RecoverFunc) {
+	j.recoverers[op] = f
+}
+
+// Begin records that an operation of the specified type is starting. The
+// returned ID must be supplied to Commit once the operation completes.
+func (j *Journal) Begin(ctx jsutil.AsyncContext, op string, data js.Value) (string, error) {
+	if _, ok := j.recoverers[op]; !ok {
+		return "", fmt.Errorf("no recovery registered for operation %s", op)
+	}
+
+	i, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate journal entry ID: %w", err)
+	}
+
+	e := &journalEntry{
+		ID:   i.String(),
+		Op:   op,
+		Data: jsutil.ToJSON(data),
+	}
+	if err := j.entries.Write(ctx, e); err != nil {
+		return "", fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return e.ID, nil
+}
+
+// Commit records that the operation with the specified ID has completed.
+func (j *Journal) Commit(ctx jsutil.AsyncContext, id string) error {
+	if err := j.entries.Delete(ctx, func(e *journalEntry) bool { return e.ID == id }); err != nil {
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+	return nil
+}
+
+// Recover recovers all operations that were interrupted. Operations that
+// cannot be recovered remain in the journal, and are attempted again on the
+// next invocation.
+func (j *Journal) Recover(ctx jsutil.AsyncContext) error {
+	entries, err := j.entries.ReadAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var errs []error
+	for _, e := range entries {
+		f, ok := j.recoverers[e.Op]
+		if !ok {
+			// Nothing we can do; perhaps it was written by a newer
+			// version.
+			jsutil.LogError("Journal.Recover: no recovery registered for operation %s; dropping", e.Op)
+		} else {
+			jsutil.Log("Journal.Recover: recovering interrupted operation %s", e.Op)
+			if err := f(ctx, jsutil.FromJSON(e.Data)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to recover operation %s: %w", e.Op, err))
+				continue
+			}
+		}
+
+		if err := j.Commit(ctx, e.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
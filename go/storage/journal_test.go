@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/norunners/vert"
+)
+
+var testJournalPrefixes = []string{"journal"}
+
+func TestJournalRecover(t *testing.T) {
+	t.Parallel()
+
+	errRecover := errors.New("recovery failed")
+
+	testcases := []struct {
+		description  string
+		begin        []int
+		commit       []int
+		recoverErr   error
+		wantRecover  []int
+		wantErr      error
+		wantRemained int
+	}{
+		{
+			description: "nothing to recover",
+		},
+		{
+			description: "all operations committed",
+			begin:       []int{1, 2},
+			commit:      []int{1, 2},
+		},
+		{
+			description: "recover interrupted operations",
+			begin:       []int{1, 2, 3},
+			commit:      []int{2},
+			wantRecover: []int{1, 3},
+		},
+		{
+			description:  "failed recovery remains in journal",
+			begin:        []int{1},
+			recoverErr:   errRecover,
+			wantRecover:  []int{1},
+			wantErr:      errRecover,
+			wantRemained: 1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				store := NewRaw(st.NewMemArea())
+
+				// First instance is interrupted before all
+				// operations are committed.
+				func() {
+					j := NewJournal(store, testJournalPrefixes)
+					j.Register("op", func(ctx jsutil.AsyncContext, data js.Value) error {
+						t.Errorf("unexpected recovery")
+						return nil
+					})
+					ids := map[int]string{}
+					for _, i := range tc.begin {
+						id, err := j.Begin(ctx, "op", vert.ValueOf(&myStruct{IntField: i}).JSValue())
+						if err != nil {
+							t.Fatalf("Begin failed: %v", err)
+						}
+						ids[i] = id
+					}
+					for _, i := range tc.commit {
+						if err := j.Commit(ctx, ids[i]); err != nil {
+							t.Fatalf("Commit failed: %v", err)
+						}
+					}
+				}()
+
+				// Second instance recovers the interrupted
+				// operations.
+				var recovered []int
+				j := NewJournal(store, testJournalPrefixes)
+				j.Register("op", func(ctx jsutil.AsyncContext, data js.Value) error {
+					var s myStruct
+					if err := vert.ValueOf(data).AssignTo(&s); err != nil {
+						t.Errorf("failed to parse data: %v", err)
+					}
+					recovered = append(recovered, s.IntField)
+					return tc.recoverErr
+				})
+				err := j.Recover(ctx)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(recovered, tc.wantRecover, cmpopts.SortSlices(func(a, b int) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect recovered operations; -got +want: %s", diff)
+				}
+
+				entries, err := j.entries.ReadAll(ctx)
+				if err != nil {
+					t.Fatalf("failed to read journal: %v", err)
+				}
+				if diff := cmp.Diff(len(entries), tc.wantRemained); diff != "" {
+					t.Errorf("incorrect remaining entries; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestJournalUnknownOperation(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		store := NewRaw(st.NewMemArea())
+
+		j := NewJournal(store, testJournalPrefixes)
+		if _, err := j.Begin(ctx, "unregistered", js.Null()); err == nil {
+			t.Errorf("Begin succeeded for unregistered operation")
+		}
+
+		j.Register("op", func(ctx jsutil.AsyncContext, data js.Value) error { return nil })
+		if _, err := j.Begin(ctx, "op", js.Null()); err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+
+		// Operations no longer registered are dropped.
+		j = NewJournal(store, testJournalPrefixes)
+		if err := j.Recover(ctx); err != nil {
+			t.Errorf("Recover failed: %v", err)
+		}
+		entries, err := j.entries.ReadAll(ctx)
+		if err != nil {
+			t.Fatalf("failed to read journal: %v", err)
+		}
+		if diff := cmp.Diff(len(entries), 0); diff != "" {
+			t.Errorf("incorrect remaining entries; -got +want: %s", diff)
+		}
+	})
+}
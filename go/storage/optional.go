@@ -0,0 +1,154 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+// Unavailable is an Area for storage that does not exist (e.g.,
+// chrome.storage.sync in some guest or managed profiles). All operations
+// fail with ErrUnavailable.
+//
+// Unavailable implements the Area interface.
+type Unavailable struct {
+	name string
+}
+
+// NewUnavailable returns an Unavailable for the storage area with the
+// specified name.
+func NewUnavailable(name string) *Unavailable {
+	return &Unavailable{
+		name: name,
+	}
+}
+
+func (u *Unavailable) err() error {
+	return fmt.Errorf("%w: %s storage is not supported", ErrUnavailable, u.name)
+}
+
+// Set implements Area.Set().
+func (u *Unavailable) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	return u.err()
+}
+
+// Get implements Area.Get().
+func (u *Unavailable) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	return nil, u.err()
+}
+
+// Delete implements Area.Delete().
+func (u *Unavailable) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	return u.err()
+}
+
+// Watch implements Area.Watch().
+func (u *Unavailable) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return nil, u.err()
+}
+
+// BytesInUse implements Area.BytesInUse().
+func (u *Unavailable) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	return 0, u.err()
+}
+
+// Quota implements Area.Quota().
+func (u *Unavailable) Quota() int {
+	return 0
+}
+
+// Optional is an Area for storage that may be unavailable. While the
+// underlying area is unavailable, it appears to be empty: reads return no
+// data, and deletes succeed because there is nothing to delete. Writes
+// continue to fail, so that callers can fall back to other storage.
+//
+// Optional implements the Area interface.
+type Optional struct {
+	s Area
+}
+
+// NewOptional returns an Optional wrapping the supplied area.
+func NewOptional(store Area) *Optional {
+	return &Optional{
+		s: store,
+	}
+}
+
+// Available returns nil if the underlying area is available, or an error
+// describing why it is not.
+func (o *Optional) Available(ctx jsutil.AsyncContext) error {
+	if _, err := o.s.Get(ctx); err != nil && errors.Is(err, ErrUnavailable) {
+		return err
+	}
+	return nil
+}
+
+// Set implements Area.Set().
+func (o *Optional) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	return o.s.Set(ctx, data)
+}
+
+// Get implements Area.Get().
+func (o *Optional) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	data, err := o.s.Get(ctx)
+	if errors.Is(err, ErrUnavailable) {
+		jsutil.LogDebug("Optional.Get: storage unavailable; treating as empty: %v", err)
+		return map[string]js.Value{}, nil
+	}
+	return data, err
+}
+
+// Delete implements Area.Delete().
+func (o *Optional) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	err := o.s.Delete(ctx, keys)
+	if errors.Is(err, ErrUnavailable) {
+		jsutil.LogDebug("Optional.Delete: storage unavailable; nothing to delete: %v", err)
+		return nil
+	}
+	return err
+}
+
+// Watch implements Area.Watch(). While the underlying area is unavailable, it
+// never changes, so there is nothing to report.
+func (o *Optional) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	cleanup, err := o.s.Watch(ctx, callback)
+	if errors.Is(err, ErrUnavailable) {
+		jsutil.LogDebug("Optional.Watch: storage unavailable; nothing to watch: %v", err)
+		return func() {}, nil
+	}
+	return cleanup, err
+}
+
+// BytesInUse implements Area.BytesInUse(). While the underlying area is
+// unavailable, it is empty.
+func (o *Optional) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	n, err := o.s.BytesInUse(ctx, keys)
+	if errors.Is(err, ErrUnavailable) {
+		jsutil.LogDebug("Optional.BytesInUse: storage unavailable; treating as empty: %v", err)
+		return 0, nil
+	}
+	return n, err
+}
+
+// Quota implements Area.Quota().
+func (o *Optional) Quota() int {
+	return o.s.Quota()
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnavailable(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		u := NewUnavailable("sync")
+		if _, err := u.Get(ctx); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Get error: got %v, want %v", err, ErrUnavailable)
+		}
+		if err := u.Set(ctx, map[string]js.Value{"key": js.ValueOf("value")}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Set error: got %v, want %v", err, ErrUnavailable)
+		}
+		if err := u.Delete(ctx, []string{"key"}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Delete error: got %v, want %v", err, ErrUnavailable)
+		}
+	})
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		// Available storage is used as-is.
+		o := NewOptional(NewRaw(st.NewMemArea()))
+		if err := o.Available(ctx); err != nil {
+			t.Errorf("Available failed: %v", err)
+		}
+		if err := o.Set(ctx, map[string]js.Value{"key": js.ValueOf("value")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		data, err := o.Get(ctx)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if diff := cmp.Diff(dataToJSON(data), map[string]string{"key": `"value"`}); diff != "" {
+			t.Errorf("incorrect data; -got +want: %s", diff)
+		}
+
+		// Unavailable storage appears empty, but cannot be written.
+		o = NewOptional(NewUnavailable("sync"))
+		if err := o.Available(ctx); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Available error: got %v, want %v", err, ErrUnavailable)
+		}
+		data, err = o.Get(ctx)
+		if err != nil {
+			t.Errorf("Get failed: %v", err)
+		}
+		if len(data) != 0 {
+			t.Errorf("unexpected data: %v", data)
+		}
+		if err := o.Delete(ctx, []string{"key"}); err != nil {
+			t.Errorf("Delete failed: %v", err)
+		}
+		if err := o.Set(ctx, map[string]js.Value{"key": js.ValueOf("value")}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Set error: got %v, want %v", err, ErrUnavailable)
+		}
+	})
+}
+
+func TestOptionalBytesInUse(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := NewUnavailable("sync").BytesInUse(ctx, nil); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect BytesInUse error: got %v, want %v", err, ErrUnavailable)
+		}
+
+		// Unavailable storage appears empty.
+		n, err := NewOptional(NewUnavailable("sync")).BytesInUse(ctx, nil)
+		if err != nil {
+			t.Errorf("BytesInUse failed: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("incorrect BytesInUse: got %d, want 0", n)
+		}
+	})
+}
+
+func TestOptionalWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		if _, err := NewUnavailable("sync").Watch(ctx, func(jsutil.AsyncContext, []string) {}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Watch error: got %v, want %v", err, ErrUnavailable)
+		}
+
+		// Unavailable storage never changes, so watching succeeds.
+		cleanup, err := NewOptional(NewUnavailable("sync")).Watch(ctx, func(jsutil.AsyncContext, []string) {})
+		if err != nil {
+			t.Errorf("Watch failed: %v", err)
+		} else {
+			cleanup()
+		}
+	})
+}
@@ -18,6 +18,7 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 	"syscall/js"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
@@ -68,7 +69,7 @@ func (r *Raw) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
 	jsutil.LogDebug("RawStorage.Set: setting data in storage")
 	_, err := jsutil.AsPromise(r.o.Call("set", dataToValue(data))).Await(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to set data: %w", err)
+		return fmt.Errorf("failed to set data: %w", classify(err))
 	}
 	return nil
 }
@@ -81,13 +82,13 @@ func (r *Raw) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
 	jsutil.LogDebug("RawStorage.Get: read data from storage")
 	val, err := jsutil.AsPromise(r.o.Call("get", js.Null())).Await(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get data: %w", err)
+		return nil, fmt.Errorf("failed to get data: %w", classify(err))
 	}
 
 	jsutil.LogDebug("RawStorage.Get: parse data")
 	data, err := valueToData(val)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse data: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse data: %w", ErrCorrupted, err)
 	}
 
 	jsutil.LogDebug("RawStorage.Get: return %d values", len(data))
@@ -106,9 +107,62 @@ func (r *Raw) Delete(ctx jsutil.AsyncContext, keys []string) error {
 	jsutil.LogDebug("RawStorage.Delete: removing from storage")
 	_, err := jsutil.AsPromise(r.o.Call("remove", vert.ValueOf(keys).JSValue())).Await(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to delete data: %w", err)
+		return fmt.Errorf("failed to delete data: %w", classify(err))
 	}
 
 	jsutil.LogDebug("RawStorage.Delete: finished")
 	return nil
 }
+
+// Watch implements Area.Watch().
+func (r *Raw) Watch(_ jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	onChanged := r.o.Get("onChanged")
+	if onChanged.Type() != js.TypeObject {
+		return nil, fmt.Errorf("%w: storage does not report changes", ErrUnavailable)
+	}
+
+	fo := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		keys, err := jsutil.ObjectKeys(jsutil.SingleArg(args))
+		if err != nil {
+			jsutil.LogError("RawStorage.Watch: failed to read changes: %v", err)
+			return nil
+		}
+		sort.Strings(keys)
+		jsutil.LogDebug("RawStorage.Watch: %d values changed", len(keys))
+		jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+			callback(ctx, keys)
+			return js.Undefined(), nil
+		})
+		return nil
+	})
+	onChanged.Call("addListener", fo)
+	return func() {
+		onChanged.Call("removeListener", fo)
+		fo.Release()
+	}, nil
+}
+
+// BytesInUse implements Area.BytesInUse().
+func (r *Raw) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	if r.o.Get("getBytesInUse").Type() != js.TypeFunction {
+		return 0, fmt.Errorf("%w: storage does not report usage", ErrUnavailable)
+	}
+
+	arg := js.Null()
+	if keys != nil {
+		arg = vert.ValueOf(keys).JSValue()
+	}
+	n, err := jsutil.AsPromise(r.o.Call("getBytesInUse", arg)).Await(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get usage: %w", classify(err))
+	}
+	return n.Int(), nil
+}
+
+// Quota implements Area.Quota().
+func (r *Raw) Quota() int {
+	if q := r.o.Get("QUOTA_BYTES"); q.Type() == js.TypeNumber {
+		return q.Int()
+	}
+	return 0
+}
@@ -15,6 +15,7 @@
 package storage
 
 import (
+	"errors"
 	"syscall/js"
 	"testing"
 
@@ -183,3 +184,81 @@ func TestRawDelete(t *testing.T) {
 		})
 	}
 }
+
+// watchChanges watches area for changes. next returns the keys reported by
+// the next notification.
+func watchChanges(ctx jsutil.AsyncContext, t *testing.T, area Area) (next func() []string, cleanup jsutil.CleanupFunc) {
+	t.Helper()
+	changes := make(chan []string, 10)
+	cleanup, err := area.Watch(ctx, func(_ jsutil.AsyncContext, keys []string) {
+		changes <- keys
+	})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	return func() []string { return <-changes }, cleanup
+}
+
+func TestRawWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		next, cleanup := watchChanges(ctx, t, raw)
+		defer cleanup()
+
+		if err := raw.Set(ctx, map[string]js.Value{"b": js.ValueOf(1), "a": js.ValueOf(2)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(next(), []string{"a", "b"}); diff != "" {
+			t.Errorf("incorrect changes after Set; -got +want: %s", diff)
+		}
+
+		if err := raw.Delete(ctx, []string{"a"}); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if diff := cmp.Diff(next(), []string{"a"}); diff != "" {
+			t.Errorf("incorrect changes after Delete; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestRawWatchUnsupported(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(js.Global().Get("Object").New())
+		if _, err := raw.Watch(ctx, func(jsutil.AsyncContext, []string) {}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("incorrect Watch error: got %v, want %v", err, ErrUnavailable)
+		}
+	})
+}
+
+func TestRawBytesInUse(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		if err := raw.Set(ctx, map[string]js.Value{"a": js.ValueOf("xyz"), "bb": js.ValueOf(12)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		for _, tc := range []struct {
+			keys []string
+			want int
+		}{
+			{keys: nil, want: len(`a"xyz"`) + len(`bb12`)},
+			{keys: []string{"a"}, want: len(`a"xyz"`)},
+			{keys: []string{"missing"}, want: 0},
+			{keys: []string{}, want: 0},
+		} {
+			got, err := raw.BytesInUse(ctx, tc.keys)
+			if err != nil {
+				t.Errorf("BytesInUse(%v) failed: %v", tc.keys, err)
+			}
+			if got != tc.want {
+				t.Errorf("incorrect BytesInUse(%v): got %d, want %d", tc.keys, got, tc.want)
+			}
+		}
+	})
+}
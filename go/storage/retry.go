@@ -0,0 +1,120 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"syscall/js"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts made by the default
+	// storage areas before a transient failure is returned.
+	DefaultRetryAttempts = 3
+	// DefaultRetryDelay is the delay before the first retry made by the
+	// default storage areas. It doubles with each subsequent retry.
+	DefaultRetryDelay = 100 * time.Millisecond
+)
+
+// Retrying retries operations on an underlying store that fail with
+// ErrTransient. Other failures are returned immediately.
+//
+// Retrying implements the Area interface.
+type Retrying struct {
+	s        Area
+	attempts int
+	delay    time.Duration
+	clock    clock.Clock
+}
+
+// NewRetrying returns a Retrying that makes up to attempts attempts at each
+// operation on store, waiting delay before the first retry and doubling the
+// wait before each subsequent one. Waits are measured using clk.
+func NewRetrying(store Area, attempts int, delay time.Duration, clk clock.Clock) *Retrying {
+	return &Retrying{
+		s:        store,
+		attempts: attempts,
+		delay:    delay,
+		clock:    clk,
+	}
+}
+
+// do invokes f until it succeeds, fails with an error other than
+// ErrTransient, or the attempts are exhausted.
+func (r *Retrying) do(op string, f func() error) error {
+	delay := r.delay
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || !errors.Is(err, ErrTransient) || attempt >= r.attempts {
+			return err
+		}
+		jsutil.Log("RetryingStorage.%s: attempt %d failed, retrying in %s: %v", op, attempt, delay, err)
+		<-r.clock.After(delay)
+		delay *= 2
+	}
+}
+
+// Set implements Area.Set().
+func (r *Retrying) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	return r.do("Set", func() error {
+		return r.s.Set(ctx, data)
+	})
+}
+
+// Get implements Area.Get().
+func (r *Retrying) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	var data map[string]js.Value
+	err := r.do("Get", func() error {
+		var err error
+		data, err = r.s.Get(ctx)
+		return err
+	})
+	return data, err
+}
+
+// Delete implements Area.Delete().
+func (r *Retrying) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	return r.do("Delete", func() error {
+		return r.s.Delete(ctx, keys)
+	})
+}
+
+// Watch implements Area.Watch(). Registering for changes does not fail
+// transiently, so it is not retried.
+func (r *Retrying) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return r.s.Watch(ctx, callback)
+}
+
+// BytesInUse implements Area.BytesInUse().
+func (r *Retrying) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	var n int
+	err := r.do("BytesInUse", func() error {
+		var err error
+		n, err = r.s.BytesInUse(ctx, keys)
+		return err
+	})
+	return n, err
+}
+
+// Quota implements Area.Quota().
+func (r *Retrying) Quota() int {
+	return r.s.Quota()
+}
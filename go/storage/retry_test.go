@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/google/chrome-ssh-agent/go/clock"
+	"github.com/google/chrome-ssh-agent/go/clock/fakes"
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// flakyArea fails the first failures operations with err.
+type flakyArea struct {
+	Area
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyArea) fail() error {
+	f.calls++
+	if f.calls <= f.failures {
+		return fmt.Errorf("%w: attempt %d", f.err, f.calls)
+	}
+	return nil
+}
+
+func (f *flakyArea) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.Area.Set(ctx, data)
+}
+
+func (f *flakyArea) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.Area.Get(ctx)
+}
+
+func TestRetrying(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		failures    int
+		err         error
+		wantErr     error
+		wantCalls   int
+	}{
+		{
+			description: "success",
+			wantCalls:   1,
+		},
+		{
+			description: "transient failure retried",
+			failures:    2,
+			err:         ErrTransient,
+			wantCalls:   3,
+		},
+		{
+			description: "transient failure exhausts attempts",
+			failures:    5,
+			err:         ErrTransient,
+			wantErr:     ErrTransient,
+			wantCalls:   3,
+		},
+		{
+			description: "quota failure not retried",
+			failures:    1,
+			err:         ErrQuota,
+			wantErr:     ErrQuota,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				flaky := &flakyArea{Area: NewRaw(st.NewMemArea()), failures: tc.failures, err: tc.err}
+				r := NewRetrying(flaky, 3, 0, clock.Real)
+
+				err := r.Set(ctx, map[string]js.Value{"key": js.ValueOf(1)})
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(flaky.calls, tc.wantCalls); diff != "" {
+					t.Errorf("incorrect number of attempts; -got +want: %s", diff)
+				}
+
+				// Reads are retried in the same way.
+				flaky.calls = 0
+				_, err = r.Get(ctx)
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error from Get; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestRetryingBackoff(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		clk := fakes.NewClock(time.Unix(0, 0))
+		flaky := &flakyArea{Area: NewRaw(st.NewMemArea()), failures: 2, err: ErrTransient}
+		r := NewRetrying(flaky, 3, 100*time.Millisecond, clk)
+
+		errc := make(chan error)
+		go func() {
+			errc <- r.Set(ctx, map[string]js.Value{"key": js.ValueOf(1)})
+		}()
+
+		// First retry after the initial delay.
+		clk.BlockUntil(1)
+		clk.Advance(100 * time.Millisecond)
+
+		// Second retry after twice the delay.
+		clk.BlockUntil(1)
+		clk.Advance(199 * time.Millisecond)
+		if diff := cmp.Diff(flaky.calls, 2); diff != "" {
+			t.Errorf("retried too early; -got +want: %s", diff)
+		}
+		clk.Advance(time.Millisecond)
+
+		if err := <-errc; err != nil {
+			t.Errorf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(flaky.calls, 3); diff != "" {
+			t.Errorf("incorrect number of attempts; -got +want: %s", diff)
+		}
+	})
+}
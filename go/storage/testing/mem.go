@@ -25,6 +25,60 @@ var storageArea = js.Global().Call("eval", `{
 	require("mem-storage-area/StorageArea");
 }`)
 
+// addOnChanged emulates the onChanged event of a chrome.storage.StorageArea
+// for areas that do not provide it. Listeners are notified after every set or
+// remove, with the affected keys.
+var addOnChanged = js.Global().Call("eval", `(area) => {
+	if (area.onChanged !== undefined) {
+		return area;
+	}
+	const listeners = new Set();
+	area.onChanged = {
+		addListener: (l) => { listeners.add(l); },
+		removeListener: (l) => { listeners.delete(l); },
+	};
+	const notify = (keys) => {
+		const changes = {};
+		for (const k of keys) {
+			changes[k] = {};
+		}
+		for (const l of listeners) {
+			l(changes);
+		}
+	};
+	const set = area.set.bind(area);
+	area.set = async (items) => {
+		await set(items);
+		notify(Object.keys(items));
+	};
+	const remove = area.remove.bind(area);
+	area.remove = async (keys) => {
+		await remove(keys);
+		notify([].concat(keys));
+	};
+	return area;
+}`)
+
+// addBytesInUse emulates getBytesInUse for areas that do not provide it. As in
+// Chrome, each item uses the length of its key and of its value in JSON
+// format.
+var addBytesInUse = js.Global().Call("eval", `(area) => {
+	if (area.getBytesInUse !== undefined) {
+		return area;
+	}
+	area.getBytesInUse = async (keys) => {
+		const items = await area.get(keys);
+		let n = 0;
+		for (const [k, v] of Object.entries(items)) {
+			n += k.length + JSON.stringify(v).length;
+		}
+		return n;
+	};
+	return area;
+}`)
+
+// NewMemArea returns an in-memory implementation of
+// chrome.storage.StorageArea.
 func NewMemArea() js.Value {
-	return storageArea.New()
+	return addBytesInUse.Invoke(addOnChanged.Invoke(storageArea.New()))
 }
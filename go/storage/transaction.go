@@ -0,0 +1,251 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"syscall/js"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/lock"
+	"github.com/norunners/vert"
+)
+
+// Transaction stages changes to a storage area, such that Commit applies them
+// all or none of them. Transaction implements Area, so it may be wrapped
+// (e.g., by a View or Typed) in the same way as the underlying area. Reads
+// reflect the staged changes; other readers of the underlying area observe
+// them only once committed.
+//
+// Commit first writes all the staged changes to a single item (the commit
+// record), then applies them, then removes the record. If the worker is
+// suspended or terminated before the record is removed, RecoverTransactions
+// applies the changes again. The commit record temporarily occupies as much
+// space as the changes themselves; if it cannot be written, no changes are
+// applied.
+type Transaction struct {
+	s       Area
+	set     map[string]js.Value
+	deleted map[string]bool
+}
+
+// transactionRecord is the raw object stored for a commit in progress.
+type transactionRecord struct {
+	// Set is the JSON-encoded object containing the items to be set.
+	Set string `js:"set"`
+	// Deleted are the keys of the items to be deleted.
+	Deleted []string `js:"deleted"`
+}
+
+const (
+	// transactionKeyPrefix is the prefix for commit records.
+	transactionKeyPrefix = "transaction."
+
+	// transactionLockResourceID identifies the lock taken while
+	// committing or recovering transactions. This ensures recovery does
+	// not apply a commit that is still in progress.
+	transactionLockResourceID = "storage-transaction-lock"
+)
+
+// isTransactionKey detects if the key refers to a commit record.
+func isTransactionKey(key string) bool {
+	return strings.HasPrefix(key, transactionKeyPrefix)
+}
+
+// NewTransaction returns a new Transaction that stages changes to the supplied
+// storage area.
+func NewTransaction(store Area) *Transaction {
+	return &Transaction{
+		s:       store,
+		set:     map[string]js.Value{},
+		deleted: map[string]bool{},
+	}
+}
+
+// Set implements Area.Set(). Items are not stored until Commit is invoked.
+func (t *Transaction) Set(ctx jsutil.AsyncContext, data map[string]js.Value) error {
+	for k, v := range data {
+		t.set[k] = v
+		delete(t.deleted, k)
+	}
+	return nil
+}
+
+// Get implements Area.Get(). Staged changes are reflected in the result.
+func (t *Transaction) Get(ctx jsutil.AsyncContext) (map[string]js.Value, error) {
+	data, err := t.s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]js.Value{}
+	for k, v := range data {
+		if isTransactionKey(k) || t.deleted[k] {
+			continue
+		}
+		result[k] = v
+	}
+	for k, v := range t.set {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// Delete implements Area.Delete(). Items are not removed until Commit is
+// invoked.
+func (t *Transaction) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	for _, k := range keys {
+		t.deleted[k] = true
+		delete(t.set, k)
+	}
+	return nil
+}
+
+// Watch implements Area.Watch(). Only committed changes are reported.
+func (t *Transaction) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return t.s.Watch(ctx, filterChanges(callback, func(key string) (string, bool) {
+		return key, !isTransactionKey(key)
+	}))
+}
+
+// BytesInUse implements Area.BytesInUse(). Staged changes are not reflected.
+func (t *Transaction) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	return t.s.BytesInUse(ctx, keys)
+}
+
+// Quota implements Area.Quota().
+func (t *Transaction) Quota() int {
+	return t.s.Quota()
+}
+
+// Commit applies the staged changes to the underlying storage area. If an
+// error is returned after the commit record was written, the changes are
+// applied by the next invocation of RecoverTransactions. The Transaction may
+// be reused afterwards to stage further changes.
+func (t *Transaction) Commit(ctx jsutil.AsyncContext) error {
+	if len(t.set) == 0 && len(t.deleted) == 0 {
+		return nil
+	}
+
+	obj := jsutil.NewObject()
+	for k, v := range t.set {
+		obj.Set(k, v)
+	}
+	rec := &transactionRecord{Set: jsutil.ToJSON(obj)}
+	for k := range t.deleted {
+		rec.Deleted = append(rec.Deleted, k)
+	}
+	sort.Strings(rec.Deleted)
+
+	i, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+	key := transactionKeyPrefix + i.String()
+
+	err = lock.WithLock(ctx, transactionLockResourceID, func(ctx jsutil.AsyncContext) error {
+		if err := t.s.Set(ctx, map[string]js.Value{key: vert.ValueOf(rec).JSValue()}); err != nil {
+			return fmt.Errorf("failed to write commit record: %w", err)
+		}
+		return applyTransaction(ctx, t.s, key, t.set, rec.Deleted)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.set = map[string]js.Value{}
+	t.deleted = map[string]bool{}
+	return nil
+}
+
+// applyTransaction applies the changes from the commit record stored under
+// key, and then removes the record. It is safe to invoke multiple times.
+func applyTransaction(ctx jsutil.AsyncContext, store Area, key string, set map[string]js.Value, deleted []string) error {
+	if len(set) > 0 {
+		if err := store.Set(ctx, set); err != nil {
+			return fmt.Errorf("failed to apply transaction: %w", err)
+		}
+	}
+	if len(deleted) > 0 {
+		if err := store.Delete(ctx, deleted); err != nil {
+			return fmt.Errorf("failed to apply transaction: %w", err)
+		}
+	}
+	if err := store.Delete(ctx, []string{key}); err != nil {
+		return fmt.Errorf("failed to remove commit record: %w", err)
+	}
+	return nil
+}
+
+// RecoverTransactions applies the changes from transactions whose commit was
+// interrupted. Transactions that cannot be applied remain in storage, and are
+// attempted again on the next invocation. Commit records that cannot be
+// decoded are logged and left in storage.
+//
+// For synced storage, this may apply a transaction that was interrupted on
+// another of the user's devices; it had been committed, so this is still
+// correct.
+func RecoverTransactions(ctx jsutil.AsyncContext, store Area) error {
+	return lock.WithLock(ctx, transactionLockResourceID, func(ctx jsutil.AsyncContext) error {
+		data, err := store.Get(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read commit records: %w", err)
+		}
+
+		var keys []string
+		for k := range data {
+			if isTransactionKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var errs []error
+		for _, k := range keys {
+			var rec transactionRecord
+			if err := vert.ValueOf(data[k]).AssignTo(&rec); err != nil {
+				// Perhaps it was written by a newer version,
+				// which can still apply it; leave it in place.
+				jsutil.LogError("RecoverTransactions: skipping malformed commit record %s: %v: %s", k, err, jsutil.ToJSON(data[k]))
+				continue
+			}
+
+			set := map[string]js.Value{}
+			obj := jsutil.FromJSON(rec.Set)
+			sk, err := jsutil.ObjectKeys(obj)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse commit record %s: %w", k, err))
+				continue
+			}
+			for _, s := range sk {
+				set[s] = obj.Get(s)
+			}
+
+			jsutil.Log("RecoverTransactions: applying interrupted transaction %s", k)
+			if err := applyTransaction(ctx, store, k, set, rec.Deleted); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
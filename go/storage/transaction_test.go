@@ -0,0 +1,249 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// deleteFailingArea fails all Delete operations while fail is set.
+type deleteFailingArea struct {
+	Area
+	fail bool
+}
+
+var errDeleteFailed = errors.New("delete failed")
+
+func (d *deleteFailingArea) Delete(ctx jsutil.AsyncContext, keys []string) error {
+	if d.fail {
+		return errDeleteFailed
+	}
+	return d.Area.Delete(ctx, keys)
+}
+
+// readItems returns the string items in the store, along with the number of
+// commit records.
+func readItems(t *testing.T, ctx jsutil.AsyncContext, store Area) (map[string]string, int) {
+	data, err := store.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	items := map[string]string{}
+	records := 0
+	for k, v := range data {
+		if isTransactionKey(k) {
+			records++
+			continue
+		}
+		items[k] = v.String()
+	}
+	return items, records
+}
+
+func TestTransactionCommit(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		initial     map[string]string
+		set         map[string]string
+		del         []string
+		want        map[string]string
+	}{
+		{
+			description: "nothing staged",
+			initial:     map[string]string{"a": "1"},
+			want:        map[string]string{"a": "1"},
+		},
+		{
+			description: "set and delete",
+			initial:     map[string]string{"a": "1", "b": "2"},
+			set:         map[string]string{"a": "3", "c": "4"},
+			del:         []string{"b", "missing"},
+			want:        map[string]string{"a": "3", "c": "4"},
+		},
+		{
+			description: "delete staged item",
+			set:         map[string]string{"a": "1", "b": "2"},
+			del:         []string{"b"},
+			want:        map[string]string{"a": "1"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				store := NewRaw(st.NewMemArea())
+				initial := map[string]js.Value{}
+				for k, v := range tc.initial {
+					initial[k] = js.ValueOf(v)
+				}
+				if err := store.Set(ctx, initial); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+
+				txn := NewTransaction(store)
+				set := map[string]js.Value{}
+				for k, v := range tc.set {
+					set[k] = js.ValueOf(v)
+				}
+				if err := txn.Set(ctx, set); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+				if err := txn.Delete(ctx, tc.del); err != nil {
+					t.Fatalf("Delete failed: %v", err)
+				}
+
+				// Staged changes are visible only through the
+				// transaction.
+				got, _ := readItems(t, ctx, txn)
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect staged items; -got +want: %s", diff)
+				}
+				got, _ = readItems(t, ctx, store)
+				if diff := cmp.Diff(got, tc.initial, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect items before commit; -got +want: %s", diff)
+				}
+
+				if err := txn.Commit(ctx); err != nil {
+					t.Fatalf("Commit failed: %v", err)
+				}
+				got, records := readItems(t, ctx, store)
+				if diff := cmp.Diff(got, tc.want, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("incorrect items after commit; -got +want: %s", diff)
+				}
+				if diff := cmp.Diff(records, 0); diff != "" {
+					t.Errorf("incorrect commit records; -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestTransactionRecordFailed(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mem := NewRaw(st.NewMemArea())
+		if err := mem.Set(ctx, map[string]js.Value{"a": js.ValueOf("1")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// Failing to write the commit record leaves storage unchanged.
+		txn := NewTransaction(&flakyArea{Area: mem, failures: 1, err: ErrTransient})
+		if err := txn.Set(ctx, map[string]js.Value{"a": js.ValueOf("2"), "b": js.ValueOf("3")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		err := txn.Commit(ctx)
+		if diff := cmp.Diff(err, ErrTransient, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+
+		got, records := readItems(t, ctx, mem)
+		if diff := cmp.Diff(got, map[string]string{"a": "1"}); diff != "" {
+			t.Errorf("incorrect items; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(records, 0); diff != "" {
+			t.Errorf("incorrect commit records; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestRecoverTransactionsMalformed(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mem := NewRaw(st.NewMemArea())
+		if err := mem.Set(ctx, map[string]js.Value{
+			"a":                            js.ValueOf("1"),
+			transactionKeyPrefix + "12345": js.ValueOf("malformed"),
+		}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		if err := RecoverTransactions(ctx, mem); err != nil {
+			t.Errorf("RecoverTransactions failed: %v", err)
+		}
+
+		// The malformed record is left in place.
+		got, records := readItems(t, ctx, mem)
+		if diff := cmp.Diff(got, map[string]string{"a": "1"}); diff != "" {
+			t.Errorf("incorrect items; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(records, 1); diff != "" {
+			t.Errorf("incorrect commit records; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestRecoverTransactions(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		mem := NewRaw(st.NewMemArea())
+		if err := mem.Set(ctx, map[string]js.Value{"a": js.ValueOf("1"), "b": js.ValueOf("2")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// Commit is interrupted after the record is written, but before
+		// all changes are applied.
+		store := &deleteFailingArea{Area: mem, fail: true}
+		txn := NewTransaction(store)
+		if err := txn.Set(ctx, map[string]js.Value{"c": js.ValueOf("3")}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if err := txn.Delete(ctx, []string{"a"}); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		err := txn.Commit(ctx)
+		if diff := cmp.Diff(err, errDeleteFailed, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+
+		// Recovery fails while the underlying problem persists.
+		err = RecoverTransactions(ctx, store)
+		if diff := cmp.Diff(err, errDeleteFailed, cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("incorrect error; -got +want: %s", diff)
+		}
+		_, records := readItems(t, ctx, mem)
+		if diff := cmp.Diff(records, 1); diff != "" {
+			t.Errorf("incorrect commit records; -got +want: %s", diff)
+		}
+
+		// Recovery completes the transaction once it is resolved.
+		store.fail = false
+		if err := RecoverTransactions(ctx, store); err != nil {
+			t.Errorf("RecoverTransactions failed: %v", err)
+		}
+		got, records := readItems(t, ctx, mem)
+		if diff := cmp.Diff(got, map[string]string{"b": "2", "c": "3"}); diff != "" {
+			t.Errorf("incorrect items; -got +want: %s", diff)
+		}
+		if diff := cmp.Diff(records, 0); diff != "" {
+			t.Errorf("incorrect commit records; -got +want: %s", diff)
+		}
+	})
+}
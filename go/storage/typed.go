@@ -18,6 +18,7 @@ package storage
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -27,11 +28,40 @@ import (
 	"github.com/norunners/vert"
 )
 
+// ErrNotMalformed indicates an attempt to discard a stored value as malformed
+// when it is actually valid.
+var ErrNotMalformed = errors.New("value is not malformed")
+
+// Validator is implemented by types stored in a Typed that can verify their
+// own integrity after being deserialized.
+type Validator interface {
+	// Validate returns an error if required fields are missing or invalid.
+	Validate() error
+}
+
+// Malformed describes a stored value that could not be deserialized, or that
+// failed validation.
+type Malformed struct {
+	// Key is the key under which the value is stored.
+	Key string
+	// Value is the raw stored value.
+	Value js.Value
+	// Err describes why the value was considered malformed.
+	Err error
+}
+
 // Typed reads and writes typed values. They are serialized upon writing,
 // and deserialized upon reading.  If deserialization fails for a given value,
-// it is ignored.
+// or if the value implements Validator and fails validation, it is excluded
+// from reads. Such values are left in storage (a newer version of the
+// extension may still be able to read them) and can be enumerated with
+// Malformed.
+//
+// Operations that modify existing values (e.g., Update and Delete) hold the
+// view's lock (see View.Locked), so that they do not overwrite concurrent
+// changes.
 type Typed[V any] struct {
-	store Area
+	store *View
 }
 
 // NewTyped returns a new Typed using the underlying persistent store.
@@ -43,7 +73,26 @@ func NewTyped[V any](store Area, keyPrefix []string) *Typed[V] {
 	}
 }
 
-// readAllItems returns all the stored values, along with their keys.
+// parse deserializes and validates a single stored value.
+func (t *Typed[V]) parse(v js.Value) (*V, error) {
+	if isEncryptedValue(v) {
+		// Returned as stored by an Encrypted area that could not
+		// decrypt it.
+		return nil, ErrUndecryptable
+	}
+	var tv V
+	if err := vert.ValueOf(v).AssignTo(&tv); err != nil {
+		return nil, fmt.Errorf("failed to parse value: %w", err)
+	}
+	if val, ok := any(&tv).(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	return &tv, nil
+}
+
+// readAllItems returns all the valid stored values, along with their keys.
 func (t *Typed[V]) readAllItems(ctx jsutil.AsyncContext) (map[string]*V, error) {
 	data, err := t.store.Get(ctx)
 	if err != nil {
@@ -52,17 +101,54 @@ func (t *Typed[V]) readAllItems(ctx jsutil.AsyncContext) (map[string]*V, error)
 
 	values := map[string]*V{}
 	for k, v := range data {
-		var tv V
-		if err := vert.ValueOf(v).AssignTo(&tv); err != nil {
-			jsutil.LogError("failed to parse value %s; dropping", k)
+		tv, err := t.parse(v)
+		if err != nil {
+			jsutil.LogError("skipping malformed value %s: %v", k, err)
 			continue
 		}
 
-		values[k] = &tv
+		values[k] = tv
 	}
 	return values, nil
 }
 
+// Malformed returns the stored values that are excluded from reads because
+// they could not be deserialized or failed validation.
+func (t *Typed[V]) Malformed(ctx jsutil.AsyncContext) ([]*Malformed, error) {
+	data, err := t.store.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Malformed
+	for k, v := range data {
+		if _, err := t.parse(v); err != nil {
+			result = append(result, &Malformed{Key: k, Value: v, Err: err})
+		}
+	}
+	return result, nil
+}
+
+// DeleteMalformed removes the malformed value stored under the supplied key,
+// as returned by Malformed.  Values that are not malformed are not removed.
+func (t *Typed[V]) DeleteMalformed(ctx jsutil.AsyncContext, key string) error {
+	return t.store.Locked(ctx, func(ctx jsutil.AsyncContext) error {
+		data, err := t.store.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		v, ok := data[key]
+		if !ok {
+			return nil
+		}
+		if _, err := t.parse(v); err == nil {
+			return fmt.Errorf("%w: value %s is not malformed", ErrNotMalformed, key)
+		}
+		return t.store.Delete(ctx, []string{key})
+	})
+}
+
 // ReadAll returns all the stored values.
 func (t *Typed[V]) ReadAll(ctx jsutil.AsyncContext) ([]*V, error) {
 	data, err := t.readAllItems(ctx)
@@ -111,17 +197,60 @@ func (t *Typed[V]) Write(ctx jsutil.AsyncContext, value *V) error {
 // Delete removes the value that matches the supplied test function. If multiple
 // values match, all matching values are removed.
 func (t *Typed[V]) Delete(ctx jsutil.AsyncContext, test func(v *V) bool) error {
+	return t.store.Locked(ctx, func(ctx jsutil.AsyncContext) error {
+		data, err := t.readAllItems(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to enumerate values: %w", err)
+		}
+
+		var keys []string
+		for k, v := range data {
+			if test(v) {
+				keys = append(keys, k)
+			}
+		}
+
+		return t.store.Delete(ctx, keys)
+	})
+}
+
+// BytesInUse returns the number of bytes used in storage by the values that
+// match the supplied test function.
+func (t *Typed[V]) BytesInUse(ctx jsutil.AsyncContext, test func(v *V) bool) (int, error) {
 	data, err := t.readAllItems(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to enumerate values: %w", err)
+		return 0, fmt.Errorf("failed to enumerate values: %w", err)
 	}
 
-	var keys []string
+	keys := []string{}
 	for k, v := range data {
 		if test(v) {
 			keys = append(keys, k)
 		}
 	}
+	return t.store.BytesInUse(ctx, keys)
+}
 
-	return t.store.Delete(ctx, keys)
+// Update modifies, in place, the values that match the supplied test function.
+// update is invoked for each matching value, and the modified value is then
+// written back under its existing key.
+func (t *Typed[V]) Update(ctx jsutil.AsyncContext, test func(v *V) bool, update func(v *V)) error {
+	return t.store.Locked(ctx, func(ctx jsutil.AsyncContext) error {
+		data, err := t.readAllItems(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to enumerate values: %w", err)
+		}
+
+		updated := map[string]js.Value{}
+		for k, v := range data {
+			if test(v) {
+				update(v)
+				updated[k] = vert.ValueOf(v).JSValue()
+			}
+		}
+		if len(updated) == 0 {
+			return nil
+		}
+		return t.store.Set(ctx, updated)
+	})
 }
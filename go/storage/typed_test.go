@@ -15,6 +15,7 @@
 package storage
 
 import (
+	"errors"
 	"syscall/js"
 	"testing"
 
@@ -284,3 +285,279 @@ func TestTypedDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestTypedUpdate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		init        map[string]js.Value
+		test        func(v *myStruct) bool
+		want        []*myStruct
+		wantKeys    []string
+		wantErr     error
+	}{
+		{
+			description: "update single value",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&myStruct{IntField: 42}).JSValue(),
+				testKeyPrefix + "." + "2": vert.ValueOf(&myStruct{StringField: "foo"}).JSValue(),
+			},
+			test: func(v *myStruct) bool { return v.IntField == 42 },
+			want: []*myStruct{
+				{IntField: 42, StringField: "updated"},
+				{StringField: "foo"},
+			},
+			wantKeys: []string{testKeyPrefix + ".1", testKeyPrefix + ".2"},
+		},
+		{
+			description: "update multiple values",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&myStruct{IntField: 42}).JSValue(),
+				testKeyPrefix + "." + "2": vert.ValueOf(&myStruct{IntField: 100}).JSValue(),
+			},
+			test: func(v *myStruct) bool { return v.IntField > 0 },
+			want: []*myStruct{
+				{IntField: 42, StringField: "updated"},
+				{IntField: 100, StringField: "updated"},
+			},
+			wantKeys: []string{testKeyPrefix + ".1", testKeyPrefix + ".2"},
+		},
+		{
+			description: "no matching values",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&myStruct{StringField: "foo"}).JSValue(),
+			},
+			test: func(v *myStruct) bool { return v.IntField > 0 },
+			want: []*myStruct{
+				{StringField: "foo"},
+			},
+			wantKeys: []string{testKeyPrefix + ".1"},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				store := NewRaw(st.NewMemArea())
+				if err := store.Set(ctx, tc.init); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+
+				ts := NewTyped[myStruct](store, testKeyPrefixes)
+
+				err := ts.Update(ctx, tc.test, func(v *myStruct) { v.StringField = "updated" })
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error: -got +want: %s", diff)
+				}
+
+				got, err := ts.ReadAll(ctx)
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want, cmpopts.SortSlices(myStructLess)); diff != "" {
+					t.Errorf("incorrect result: -got +want: %s", diff)
+				}
+
+				// Values are updated in place, rather than
+				// written under new keys.
+				data, err := store.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				var keys []string
+				for k := range data {
+					keys = append(keys, k)
+				}
+				if diff := cmp.Diff(keys, tc.wantKeys, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+					t.Errorf("incorrect keys: -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestTypedUpdateConcurrent(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		store := NewRaw(st.NewMemArea())
+		ts := NewTyped[myStruct](store, []string{"concurrent-update"})
+		if err := ts.Write(ctx, &myStruct{StringField: "counter"}); err != nil {
+			t.Errorf("Write failed: %v", err)
+			return
+		}
+
+		// Concurrently increment the counter. Each update reads and then
+		// writes the value, so any interleaving would lose increments.
+		const workers = 10
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			jsutil.Async(func(ctx jsutil.AsyncContext) (js.Value, error) {
+				errs <- ts.Update(ctx, func(v *myStruct) bool { return true }, func(v *myStruct) { v.IntField++ })
+				return js.Undefined(), nil
+			})
+		}
+		for i := 0; i < workers; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}
+
+		got, err := ts.ReadAll(ctx)
+		if err != nil {
+			t.Errorf("ReadAll failed: %v", err)
+			return
+		}
+		want := []*myStruct{{IntField: workers, StringField: "counter"}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect result: -got +want: %s", diff)
+		}
+	})
+}
+
+type validatedStruct struct {
+	Name string `js:"name"`
+}
+
+var errNameMissing = errors.New("name missing")
+
+func (v *validatedStruct) Validate() error {
+	if v.Name == "" {
+		return errNameMissing
+	}
+	return nil
+}
+
+func TestTypedBytesInUse(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		store := NewRaw(st.NewMemArea())
+		if err := store.Set(ctx, map[string]js.Value{
+			testKeyPrefix + ".1": vert.ValueOf(&myStruct{IntField: 42}).JSValue(),
+			testKeyPrefix + ".2": vert.ValueOf(&myStruct{IntField: 100}).JSValue(),
+		}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		typed := NewTyped[myStruct](store, []string{testKeyPrefix})
+		got, err := typed.BytesInUse(ctx, func(v *myStruct) bool { return v.IntField == 42 })
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		want, err := store.BytesInUse(ctx, []string{testKeyPrefix + ".1"})
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("incorrect BytesInUse: got %d, want %d", got, want)
+		}
+	})
+}
+
+func TestTypedMalformed(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description   string
+		init          map[string]js.Value
+		want          []*validatedStruct
+		wantMalformed []string
+		wantErrs      []error
+	}{
+		{
+			description: "all values valid",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&validatedStruct{Name: "foo"}).JSValue(),
+			},
+			want: []*validatedStruct{
+				{Name: "foo"},
+			},
+		},
+		{
+			description: "quarantine unparseable values",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&validatedStruct{Name: "foo"}).JSValue(),
+				testKeyPrefix + "." + "2": js.ValueOf(42),
+			},
+			want: []*validatedStruct{
+				{Name: "foo"},
+			},
+			wantMalformed: []string{"2"},
+			wantErrs:      []error{nil},
+		},
+		{
+			description: "quarantine invalid values",
+			init: map[string]js.Value{
+				testKeyPrefix + "." + "1": vert.ValueOf(&validatedStruct{Name: "foo"}).JSValue(),
+				testKeyPrefix + "." + "2": vert.ValueOf(&validatedStruct{}).JSValue(),
+			},
+			want: []*validatedStruct{
+				{Name: "foo"},
+			},
+			wantMalformed: []string{"2"},
+			wantErrs:      []error{errNameMissing},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				store := NewRaw(st.NewMemArea())
+				if err := store.Set(ctx, tc.init); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+
+				ts := NewTyped[validatedStruct](store, testKeyPrefixes)
+
+				got, err := ts.ReadAll(ctx)
+				if err != nil {
+					t.Fatalf("ReadAll failed: %v", err)
+				}
+				if diff := cmp.Diff(got, tc.want); diff != "" {
+					t.Errorf("incorrect result: -got +want: %s", diff)
+				}
+
+				malformed, err := ts.Malformed(ctx)
+				if err != nil {
+					t.Fatalf("Malformed failed: %v", err)
+				}
+				var gotKeys []string
+				for i, m := range malformed {
+					gotKeys = append(gotKeys, m.Key)
+					if tc.wantErrs[i] != nil && !errors.Is(m.Err, tc.wantErrs[i]) {
+						t.Errorf("incorrect error for %s: got %v, want %v", m.Key, m.Err, tc.wantErrs[i])
+					}
+				}
+				if diff := cmp.Diff(gotKeys, tc.wantMalformed); diff != "" {
+					t.Errorf("incorrect malformed keys: -got +want: %s", diff)
+				}
+
+				// Valid values cannot be discarded as malformed.
+				if err := ts.DeleteMalformed(ctx, "1"); !errors.Is(err, ErrNotMalformed) {
+					t.Errorf("DeleteMalformed of valid value: got %v, want %v", err, ErrNotMalformed)
+				}
+
+				for _, k := range gotKeys {
+					if err := ts.DeleteMalformed(ctx, k); err != nil {
+						t.Errorf("DeleteMalformed failed: %v", err)
+					}
+				}
+				data, err := store.Get(ctx)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				if diff := cmp.Diff(len(data), len(tc.want)); diff != "" {
+					t.Errorf("incorrect number of remaining values: -got +want: %s", diff)
+				}
+			})
+		})
+	}
+}
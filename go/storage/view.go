@@ -20,8 +20,21 @@ import (
 	"fmt"
 	"strings"
 	"syscall/js"
+	"time"
 
 	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/lock"
+)
+
+const (
+	// viewLockPrefix prefixes the name of the lock held during a
+	// read-modify-write operation on a view; see View.Locked.
+	viewLockPrefix = "view/"
+
+	// viewLockTimeout is the maximum time to wait for the lock on a view.
+	// Operations holding the lock only access storage, so this is only
+	// exceeded if an operation is stuck.
+	viewLockTimeout = 30 * time.Second
 )
 
 // View supports storing and retrieving keys and values with particular key
@@ -119,6 +132,73 @@ func (v *View) Delete(ctx jsutil.AsyncContext, keys []string) error {
 	return v.s.Delete(ctx, nkeys)
 }
 
+// Watch implements Area.Watch(). Only changes to keys with the view's
+// prefixes are reported, and the prefixes are removed.
+func (v *View) Watch(ctx jsutil.AsyncContext, callback ChangeFunc) (jsutil.CleanupFunc, error) {
+	return v.s.Watch(ctx, filterChanges(callback, func(key string) (string, bool) {
+		for _, prefix := range v.prefixes {
+			if sk, ok := v.readKey(prefix, key); ok {
+				return sk, true
+			}
+		}
+		return "", false
+	}))
+}
+
+// BytesInUse implements Area.BytesInUse().
+func (v *View) BytesInUse(ctx jsutil.AsyncContext, keys []string) (int, error) {
+	var nkeys []string
+	if keys == nil {
+		// Only count the items in the view.
+		data, err := v.s.Get(ctx)
+		if err != nil {
+			return 0, err
+		}
+		nkeys = []string{}
+		for k := range data {
+			for _, prefix := range v.prefixes {
+				if _, ok := v.readKey(prefix, k); ok {
+					nkeys = append(nkeys, k)
+					break
+				}
+			}
+		}
+	} else {
+		for _, k := range keys {
+			for _, prefix := range v.prefixes {
+				nkeys = append(nkeys, v.makeKey(prefix, k))
+			}
+		}
+	}
+	return v.s.BytesInUse(ctx, nkeys)
+}
+
+// Quota implements Area.Quota().
+func (v *View) Quota() int {
+	return v.s.Quota()
+}
+
+// Locked invokes f while holding the lock for the view. This serializes
+// read-modify-write operations (e.g., read the values in the view, and then
+// write back a modified subset) across all of the extension's contexts, so
+// that concurrent operations do not overwrite each other's changes.
+//
+// The lock is identified by the view's prefixes; views with the same prefixes
+// share a lock. The lock is not reentrant; f must not invoke Locked on the
+// same view.
+func (v *View) Locked(ctx jsutil.AsyncContext, f func(ctx jsutil.AsyncContext) error) error {
+	var err error
+	name := viewLockPrefix + strings.Join(v.prefixes, ",")
+	lerr := lock.WithLockTimeout(ctx, name, viewLockTimeout, func(ctx jsutil.AsyncContext) error {
+		err = f(ctx)
+		return nil
+	})
+	if lerr != nil {
+		return fmt.Errorf("failed to lock view: %w", lerr)
+	}
+	return err
+}
+
 // DeleteViewPrefixes deletes all storage entries for views with the given prefixes.
 func DeleteViewPrefixes(ctx jsutil.AsyncContext, prefixes []string, store Area) error {
 	v := NewView(prefixes, store)
@@ -439,3 +439,61 @@ func TestDeleteViewPrefixes(t *testing.T) {
 		})
 	}
 }
+
+func TestViewWatch(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		view := NewView([]string{"foo", "bar"}, raw)
+		next, cleanup := watchChanges(ctx, t, view)
+		defer cleanup()
+
+		// Changes outside the view are not reported, and keys with
+		// multiple prefixes are reported once.
+		if err := raw.Set(ctx, map[string]js.Value{"other": js.ValueOf(1)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if err := raw.Set(ctx, map[string]js.Value{
+			"foo.a": js.ValueOf(1),
+			"bar.a": js.ValueOf(2),
+			"bar.b": js.ValueOf(3),
+		}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if diff := cmp.Diff(next(), []string{"a", "b"}); diff != "" {
+			t.Errorf("incorrect changes; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestViewBytesInUse(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		raw := NewRaw(st.NewMemArea())
+		view := NewView([]string{"foo"}, raw)
+		if err := raw.Set(ctx, map[string]js.Value{"other": js.ValueOf(1)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		if err := view.Set(ctx, map[string]js.Value{"a": js.ValueOf(1), "b": js.ValueOf(2)}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// Items outside the view are not counted.
+		got, err := view.BytesInUse(ctx, nil)
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		if want := len("foo.a1") + len("foo.b2"); got != want {
+			t.Errorf("incorrect BytesInUse for all items: got %d, want %d", got, want)
+		}
+		got, err = view.BytesInUse(ctx, []string{"a"})
+		if err != nil {
+			t.Fatalf("BytesInUse failed: %v", err)
+		}
+		if want := len("foo.a1"); got != want {
+			t.Errorf("incorrect BytesInUse for item: got %d, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,463 @@
+//go:build js
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault caches the passphrases of keys, encrypted using a master
+// password, such that the user need only enter the master password to load
+// any key whose passphrase is cached.
+//
+// Passphrases are encrypted (using AES-GCM) with a random data key. The data
+// key is itself encrypted with a key derived from the master password using
+// scrypt, such that changing the master password need only re-encrypt the
+// data key. The encrypted data key and passphrases are kept in synced
+// storage. Once the vault is unlocked, the data key is kept in session
+// storage, which is held in memory and cleared when the browser exits.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrLocked indicates that the vault must be unlocked using the
+	// master password before passphrases can be read or stored.
+	ErrLocked = errors.New("passphrase cache is locked")
+	// ErrWrongPassword indicates that the master password is incorrect.
+	ErrWrongPassword = errors.New("incorrect master password")
+
+	errNotConfigured     = errors.New("passphrase cache is not set up")
+	errAlreadyConfigured = errors.New("passphrase cache is already set up")
+	errEmptyPassword     = errors.New("master password must not be empty")
+	errDecryptFailed     = errors.New("failed to decrypt")
+)
+
+var (
+	// configPrefixes are the prefixes used for the vault's configuration
+	// in synced storage.
+	configPrefixes = []string{"vault"}
+	// entryPrefixes are the prefixes used for encrypted passphrases in
+	// synced storage.
+	entryPrefixes = []string{"vaultEntry"}
+	// unlockedPrefixes are the prefixes used for the data key in session
+	// storage.
+	unlockedPrefixes = []string{"vault"}
+)
+
+const (
+	// dataKeyBytes is the size of the data key, selecting AES-256.
+	dataKeyBytes = 32
+	// saltBytes is the size of the salt used to derive a key from the
+	// master password.
+	saltBytes = 16
+	// dataKeyContext is the additional data used when encrypting the data
+	// key. Passphrases use the key's ID, such that an encrypted
+	// passphrase cannot be substituted for another.
+	dataKeyContext = "data-key"
+)
+
+// defaultLogN is the base-2 logarithm of the scrypt CPU/memory cost used when
+// the master password is set. It is recorded alongside the encrypted data
+// key, such that it can be increased without affecting existing vaults.
+var defaultLogN = 15
+
+// maxLogN is the largest scrypt CPU/memory cost accepted in a stored
+// configuration. The configuration is synced, and may have been modified
+// elsewhere; larger costs would take too long, or too much memory (1 GiB at
+// this bound), to derive a key from the master password.
+const maxLogN = 20
+
+// config is the vault's configuration, stored in synced storage.
+type config struct {
+	// Salt is the salt used to derive a key from the master password.
+	Salt string `js:"salt"`
+	// LogN is the base-2 logarithm of the scrypt CPU/memory cost.
+	LogN int `js:"logN"`
+	// DataKey is the data key, encrypted using the key derived from the
+	// master password.
+	DataKey string `js:"dataKey"`
+}
+
+// Validate implements storage.Validator.
+func (c *config) Validate() error {
+	if c.Salt == "" || c.LogN <= 0 || c.DataKey == "" {
+		return errors.New("incomplete passphrase cache configuration")
+	}
+	if c.LogN > maxLogN {
+		return fmt.Errorf("scrypt cost in passphrase cache configuration too large: got %d, want at most %d", c.LogN, maxLogN)
+	}
+	return nil
+}
+
+// entry is a single encrypted passphrase, stored in synced storage.
+type entry struct {
+	// ID is the ID of the key to which the passphrase applies.
+	ID string `js:"id"`
+	// Passphrase is the encrypted passphrase.
+	Passphrase string `js:"passphrase"`
+}
+
+// unlocked is the data key of an unlocked vault, stored in session storage.
+type unlocked struct {
+	DataKey string `js:"dataKey"`
+}
+
+// Vault caches passphrases encrypted using a master password.
+type Vault struct {
+	config   *storage.Typed[config]
+	entries  *storage.Typed[entry]
+	unlocked *storage.Typed[unlocked]
+}
+
+// New returns a Vault that stores encrypted passphrases in syncStorage, and
+// keeps the data key for an unlocked vault in sessionStorage.
+func New(syncStorage, sessionStorage storage.Area) *Vault {
+	return &Vault{
+		config:   storage.NewTyped[config](syncStorage, configPrefixes),
+		entries:  storage.NewTyped[entry](syncStorage, entryPrefixes),
+		unlocked: storage.NewTyped[unlocked](sessionStorage, unlockedPrefixes),
+	}
+}
+
+func all[V any](*V) bool { return true }
+
+// seal encrypts plaintext using key, binding it to the additional data.
+func seal(key, plaintext []byte, additional string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(additional))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts ciphertext produced by seal.
+func open(key []byte, ciphertext, additional string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid encoding: %w", errDecryptFailed, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: ciphertext too short", errDecryptFailed)
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(additional))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// wrapDataKey encrypts the data key using a key derived from the master
+// password, returning the resulting configuration.
+func wrapDataKey(password string, dataKey []byte) (*config, error) {
+	if password == "" {
+		return nil, errEmptyPassword
+	}
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	kek, err := scrypt.Key([]byte(password), salt, 1<<defaultLogN, 8, 1, dataKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	wrapped, err := seal(kek, dataKey, dataKeyContext)
+	if err != nil {
+		return nil, err
+	}
+	return &config{
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		LogN:    defaultLogN,
+		DataKey: wrapped,
+	}, nil
+}
+
+// unwrapDataKey decrypts the data key using the master password.
+func unwrapDataKey(password string, c *config) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(c.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	kek, err := scrypt.Key([]byte(password), salt, 1<<c.LogN, 8, 1, dataKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	dataKey, err := open(kek, c.DataKey, dataKeyContext)
+	if errors.Is(err, errDecryptFailed) {
+		return nil, ErrWrongPassword
+	} else if err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// readConfig returns the vault's configuration, or nil if the vault is not
+// set up.
+func (v *Vault) readConfig(ctx jsutil.AsyncContext) (*config, error) {
+	c, err := v.config.Read(ctx, all[config])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase cache: %w", err)
+	}
+	return c, nil
+}
+
+// dataKey returns the data key of the unlocked vault. ErrLocked is returned if
+// the vault is locked.
+func (v *Vault) dataKey(ctx jsutil.AsyncContext) ([]byte, error) {
+	u, err := v.unlocked.Read(ctx, all[unlocked])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	if u == nil {
+		return nil, ErrLocked
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(u.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// setDataKey records the data key of the unlocked vault, replacing any
+// previous one.
+func (v *Vault) setDataKey(ctx jsutil.AsyncContext, dataKey []byte) error {
+	if err := v.unlocked.Delete(ctx, all[unlocked]); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if err := v.unlocked.Write(ctx, &unlocked{DataKey: base64.StdEncoding.EncodeToString(dataKey)}); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// EncryptionKey implements storage.KeySource.EncryptionKey(), such that other
+// data can be encrypted with the vault's data key. ErrLocked is returned if
+// the vault is locked.
+func (v *Vault) EncryptionKey(ctx jsutil.AsyncContext) ([]byte, error) {
+	return v.dataKey(ctx)
+}
+
+// Configured returns true if the vault is set up.
+func (v *Vault) Configured(ctx jsutil.AsyncContext) (bool, error) {
+	c, err := v.readConfig(ctx)
+	return c != nil, err
+}
+
+// Unlocked returns true if the vault is set up and unlocked.
+func (v *Vault) Unlocked(ctx jsutil.AsyncContext) (bool, error) {
+	if ok, err := v.Configured(ctx); !ok || err != nil {
+		return false, err
+	}
+	_, err := v.dataKey(ctx)
+	if errors.Is(err, ErrLocked) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Setup sets up the vault with the specified master password. The vault is
+// left unlocked.
+func (v *Vault) Setup(ctx jsutil.AsyncContext, password string) error {
+	c, err := v.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		return errAlreadyConfigured
+	}
+
+	dataKey := make([]byte, dataKeyBytes)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	c, err = wrapDataKey(password, dataKey)
+	if err != nil {
+		return err
+	}
+	// Discard passphrases left from a vault that was not completely
+	// removed; they cannot be decrypted with the new data key.
+	if err := v.entries.Delete(ctx, all[entry]); err != nil {
+		return fmt.Errorf("failed to remove old passphrases: %w", err)
+	}
+	if err := v.config.Write(ctx, c); err != nil {
+		return fmt.Errorf("failed to write passphrase cache: %w", err)
+	}
+	return v.setDataKey(ctx, dataKey)
+}
+
+// Unlock unlocks the vault using the master password.
+func (v *Vault) Unlock(ctx jsutil.AsyncContext, password string) error {
+	c, err := v.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return errNotConfigured
+	}
+	dataKey, err := unwrapDataKey(password, c)
+	if err != nil {
+		return err
+	}
+	return v.setDataKey(ctx, dataKey)
+}
+
+// VerifyPassword implements keys.PasswordVerifier.VerifyPassword.
+// ErrWrongPassword is returned if the password is incorrect, and
+// keys.ErrPasswordRequired if the vault is not set up. The vault is neither
+// unlocked nor locked.
+func (v *Vault) VerifyPassword(ctx jsutil.AsyncContext, password string) error {
+	c, err := v.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return keys.ErrPasswordRequired
+	}
+	_, err = unwrapDataKey(password, c)
+	return err
+}
+
+// Lock locks the vault. The master password must be entered again before
+// cached passphrases can be used.
+func (v *Vault) Lock(ctx jsutil.AsyncContext) error {
+	if err := v.unlocked.Delete(ctx, all[unlocked]); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword changes the master password. The vault is left unlocked.
+func (v *Vault) ChangePassword(ctx jsutil.AsyncContext, oldPassword, newPassword string) error {
+	c, err := v.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return errNotConfigured
+	}
+	dataKey, err := unwrapDataKey(oldPassword, c)
+	if err != nil {
+		return err
+	}
+	updated, err := wrapDataKey(newPassword, dataKey)
+	if err != nil {
+		return err
+	}
+	// Only the configuration changes, so the update is atomic.
+	err = v.config.Update(ctx, all[config], func(c *config) { *c = *updated })
+	if err != nil {
+		return fmt.Errorf("failed to write passphrase cache: %w", err)
+	}
+	return v.setDataKey(ctx, dataKey)
+}
+
+// Remove removes the vault, including all cached passphrases.
+func (v *Vault) Remove(ctx jsutil.AsyncContext) error {
+	if err := v.config.Delete(ctx, all[config]); err != nil {
+		return fmt.Errorf("failed to remove passphrase cache: %w", err)
+	}
+	if err := v.entries.Delete(ctx, all[entry]); err != nil {
+		return fmt.Errorf("failed to remove passphrases: %w", err)
+	}
+	return v.Lock(ctx)
+}
+
+// Store caches the passphrase for the key with the specified ID, replacing
+// any previously cached passphrase. ErrLocked is returned if the vault is
+// locked.
+func (v *Vault) Store(ctx jsutil.AsyncContext, id keys.ID, passphrase string) error {
+	dataKey, err := v.dataKey(ctx)
+	if err != nil {
+		return err
+	}
+	encrypted, err := seal(dataKey, []byte(passphrase), string(id))
+	if err != nil {
+		return err
+	}
+	if err := v.Forget(ctx, id); err != nil {
+		return err
+	}
+	if err := v.entries.Write(ctx, &entry{ID: string(id), Passphrase: encrypted}); err != nil {
+		return fmt.Errorf("failed to write passphrase: %w", err)
+	}
+	return nil
+}
+
+// Cached returns true if a passphrase is cached for the key with the
+// specified ID. The vault need not be unlocked.
+func (v *Vault) Cached(ctx jsutil.AsyncContext, id keys.ID) (bool, error) {
+	e, err := v.entries.Read(ctx, func(e *entry) bool { return e.ID == string(id) })
+	if err != nil {
+		return false, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return e != nil, nil
+}
+
+// Passphrase returns the cached passphrase for the key with the specified ID.
+// ok is false if no passphrase is cached for the key. ErrLocked is returned if
+// the vault is locked.
+func (v *Vault) Passphrase(ctx jsutil.AsyncContext, id keys.ID) (passphrase string, ok bool, err error) {
+	dataKey, err := v.dataKey(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	e, err := v.entries.Read(ctx, func(e *entry) bool { return e.ID == string(id) })
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if e == nil {
+		return "", false, nil
+	}
+	decrypted, err := open(dataKey, e.Passphrase, string(id))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt passphrase: %w", err)
+	}
+	return string(decrypted), true, nil
+}
+
+// Forget removes the cached passphrase for the key with the specified ID, if
+// any. The vault need not be unlocked.
+func (v *Vault) Forget(ctx jsutil.AsyncContext, id keys.ID) error {
+	if err := v.entries.Delete(ctx, func(e *entry) bool { return e.ID == string(id) }); err != nil {
+		return fmt.Errorf("failed to remove passphrase: %w", err)
+	}
+	return nil
+}
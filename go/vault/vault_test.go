@@ -0,0 +1,472 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/chrome-ssh-agent/go/jsutil"
+	jut "github.com/google/chrome-ssh-agent/go/jsutil/testing"
+	"github.com/google/chrome-ssh-agent/go/keys"
+	"github.com/google/chrome-ssh-agent/go/storage"
+	st "github.com/google/chrome-ssh-agent/go/storage/testing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func init() {
+	// Keep key derivation cheap in tests.
+	defaultLogN = 4
+}
+
+func setupAndStore(ctx jsutil.AsyncContext, v *Vault) error {
+	if err := v.Setup(ctx, "master"); err != nil {
+		return err
+	}
+	return v.Store(ctx, "key-1", "secret")
+}
+
+func TestVault(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description    string
+		sequence       func(ctx jsutil.AsyncContext, v *Vault) error
+		wantErr        error
+		wantConfigured bool
+		wantUnlocked   bool
+		wantCached     bool
+		wantPassphrase string
+		wantOK         bool
+		wantReadErr    error
+	}{
+		{
+			description: "not configured",
+			sequence:    func(ctx jsutil.AsyncContext, v *Vault) error { return nil },
+			wantReadErr: ErrLocked,
+		},
+		{
+			description:    "setup leaves vault unlocked",
+			sequence:       func(ctx jsutil.AsyncContext, v *Vault) error { return v.Setup(ctx, "master") },
+			wantConfigured: true,
+			wantUnlocked:   true,
+		},
+		{
+			description: "setup with empty password",
+			sequence:    func(ctx jsutil.AsyncContext, v *Vault) error { return v.Setup(ctx, "") },
+			wantErr:     errEmptyPassword,
+			wantReadErr: ErrLocked,
+		},
+		{
+			description: "setup twice",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.Setup(ctx, "other")
+			},
+			wantErr:        errAlreadyConfigured,
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "secret",
+			wantOK:         true,
+		},
+		{
+			description:    "store passphrase",
+			sequence:       setupAndStore,
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "secret",
+			wantOK:         true,
+		},
+		{
+			description: "replace passphrase",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.Store(ctx, "key-1", "new-secret")
+			},
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "new-secret",
+			wantOK:         true,
+		},
+		{
+			description: "passphrase not cached",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := v.Setup(ctx, "master"); err != nil {
+					return err
+				}
+				return v.Store(ctx, "key-2", "secret")
+			},
+			wantConfigured: true,
+			wantUnlocked:   true,
+		},
+		{
+			description: "forget passphrase",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.Forget(ctx, "key-1")
+			},
+			wantConfigured: true,
+			wantUnlocked:   true,
+		},
+		{
+			description: "lock",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.Lock(ctx)
+			},
+			wantConfigured: true,
+			wantCached:     true,
+			wantReadErr:    ErrLocked,
+		},
+		{
+			description: "store while locked",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := v.Setup(ctx, "master"); err != nil {
+					return err
+				}
+				if err := v.Lock(ctx); err != nil {
+					return err
+				}
+				return v.Store(ctx, "key-1", "secret")
+			},
+			wantErr:        ErrLocked,
+			wantConfigured: true,
+			wantReadErr:    ErrLocked,
+		},
+		{
+			description: "unlock",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				if err := v.Lock(ctx); err != nil {
+					return err
+				}
+				return v.Unlock(ctx, "master")
+			},
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "secret",
+			wantOK:         true,
+		},
+		{
+			description: "unlock with wrong password",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				if err := v.Lock(ctx); err != nil {
+					return err
+				}
+				return v.Unlock(ctx, "wrong")
+			},
+			wantErr:        ErrWrongPassword,
+			wantConfigured: true,
+			wantCached:     true,
+			wantReadErr:    ErrLocked,
+		},
+		{
+			description: "unlock when not configured",
+			sequence:    func(ctx jsutil.AsyncContext, v *Vault) error { return v.Unlock(ctx, "master") },
+			wantErr:     errNotConfigured,
+			wantReadErr: ErrLocked,
+		},
+		{
+			description: "change password",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				if err := v.ChangePassword(ctx, "master", "new-master"); err != nil {
+					return err
+				}
+				if err := v.Lock(ctx); err != nil {
+					return err
+				}
+				return v.Unlock(ctx, "new-master")
+			},
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "secret",
+			wantOK:         true,
+		},
+		{
+			description: "old password rejected after change",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				if err := v.ChangePassword(ctx, "master", "new-master"); err != nil {
+					return err
+				}
+				if err := v.Lock(ctx); err != nil {
+					return err
+				}
+				return v.Unlock(ctx, "master")
+			},
+			wantErr:        ErrWrongPassword,
+			wantConfigured: true,
+			wantCached:     true,
+			wantReadErr:    ErrLocked,
+		},
+		{
+			description: "change password with wrong password",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.ChangePassword(ctx, "wrong", "new-master")
+			},
+			wantErr:        ErrWrongPassword,
+			wantConfigured: true,
+			wantCached:     true,
+			wantUnlocked:   true,
+			wantPassphrase: "secret",
+			wantOK:         true,
+		},
+		{
+			description: "remove",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				return v.Remove(ctx)
+			},
+			wantReadErr: ErrLocked,
+		},
+		{
+			description: "setup again after remove",
+			sequence: func(ctx jsutil.AsyncContext, v *Vault) error {
+				if err := setupAndStore(ctx, v); err != nil {
+					return err
+				}
+				if err := v.Remove(ctx); err != nil {
+					return err
+				}
+				return v.Setup(ctx, "other")
+			},
+			wantConfigured: true,
+			wantUnlocked:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			jut.DoSync(func(ctx jsutil.AsyncContext) {
+				syncStorage := storage.NewRaw(st.NewMemArea())
+				sessionStorage := storage.NewRaw(st.NewMemArea())
+				err := tc.sequence(ctx, New(syncStorage, sessionStorage))
+				if diff := cmp.Diff(err, tc.wantErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect error; -got +want: %s", diff)
+				}
+
+				// Read using a separate instance, as would another
+				// page of the extension.
+				v := New(syncStorage, sessionStorage)
+				configured, err := v.Configured(ctx)
+				if err != nil {
+					t.Errorf("Configured() failed: %v", err)
+				}
+				if configured != tc.wantConfigured {
+					t.Errorf("incorrect configured state; got %t, want %t", configured, tc.wantConfigured)
+				}
+				unlocked, err := v.Unlocked(ctx)
+				if err != nil {
+					t.Errorf("Unlocked() failed: %v", err)
+				}
+				if unlocked != tc.wantUnlocked {
+					t.Errorf("incorrect unlocked state; got %t, want %t", unlocked, tc.wantUnlocked)
+				}
+				cached, err := v.Cached(ctx, "key-1")
+				if err != nil {
+					t.Errorf("Cached() failed: %v", err)
+				}
+				if cached != tc.wantCached {
+					t.Errorf("incorrect cached state; got %t, want %t", cached, tc.wantCached)
+				}
+				passphrase, ok, err := v.Passphrase(ctx, "key-1")
+				if diff := cmp.Diff(err, tc.wantReadErr, cmpopts.EquateErrors()); diff != "" {
+					t.Errorf("incorrect read error; -got +want: %s", diff)
+				}
+				if passphrase != tc.wantPassphrase || ok != tc.wantOK {
+					t.Errorf("incorrect passphrase; got (%q, %t), want (%q, %t)", passphrase, ok, tc.wantPassphrase, tc.wantOK)
+				}
+			})
+		})
+	}
+}
+
+func TestPassphraseBoundToKey(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		v := New(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		if err := setupAndStore(ctx, v); err != nil {
+			t.Errorf("setup failed: %v", err)
+			return
+		}
+
+		// Move the encrypted passphrase to a different key. It must
+		// not decrypt.
+		err := v.entries.Update(ctx, func(e *entry) bool { return e.ID == "key-1" }, func(e *entry) { e.ID = "key-2" })
+		if err != nil {
+			t.Errorf("failed to update entry: %v", err)
+			return
+		}
+		if _, _, err := v.Passphrase(ctx, "key-2"); !errors.Is(err, errDecryptFailed) {
+			t.Errorf("incorrect error; got %v, want %v", err, errDecryptFailed)
+		}
+	})
+}
+
+func TestEncryptionKey(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		v := New(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		if _, err := v.EncryptionKey(ctx); !errors.Is(err, ErrLocked) {
+			t.Errorf("incorrect error before setup; got %v, want %v", err, ErrLocked)
+		}
+		if err := v.Setup(ctx, "master"); err != nil {
+			t.Errorf("setup failed: %v", err)
+			return
+		}
+		want, err := v.EncryptionKey(ctx)
+		if err != nil {
+			t.Errorf("EncryptionKey failed: %v", err)
+			return
+		}
+
+		// The key is unavailable while locked, and unchanged by changing
+		// the master password.
+		if err := v.Lock(ctx); err != nil {
+			t.Errorf("Lock failed: %v", err)
+			return
+		}
+		if _, err := v.EncryptionKey(ctx); !errors.Is(err, ErrLocked) {
+			t.Errorf("incorrect error while locked; got %v, want %v", err, ErrLocked)
+		}
+		if err := v.ChangePassword(ctx, "master", "other"); err != nil {
+			t.Errorf("ChangePassword failed: %v", err)
+			return
+		}
+		got, err := v.EncryptionKey(ctx)
+		if err != nil {
+			t.Errorf("EncryptionKey failed: %v", err)
+			return
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("incorrect key; -got +want: %s", diff)
+		}
+	})
+}
+
+func TestVerifyPassword(t *testing.T) {
+	t.Parallel()
+
+	jut.DoSync(func(ctx jsutil.AsyncContext) {
+		v := New(storage.NewRaw(st.NewMemArea()), storage.NewRaw(st.NewMemArea()))
+		if err := v.VerifyPassword(ctx, "master"); !errors.Is(err, keys.ErrPasswordRequired) {
+			t.Errorf("incorrect error before setup; got %v, want %v", err, keys.ErrPasswordRequired)
+		}
+		if err := v.Setup(ctx, "master"); err != nil {
+			t.Errorf("setup failed: %v", err)
+			return
+		}
+		if err := v.Lock(ctx); err != nil {
+			t.Errorf("Lock failed: %v", err)
+			return
+		}
+
+		// Verification does not depend on, or change, whether the vault
+		// is unlocked.
+		if err := v.VerifyPassword(ctx, "other"); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("incorrect error for wrong password; got %v, want %v", err, ErrWrongPassword)
+		}
+		if err := v.VerifyPassword(ctx, "master"); err != nil {
+			t.Errorf("VerifyPassword failed: %v", err)
+		}
+		if unlocked, err := v.Unlocked(ctx); err != nil || unlocked {
+			t.Errorf("incorrect unlocked state; got (%t, %v), want (false, nil)", unlocked, err)
+		}
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		config      *config
+		wantErr     bool
+	}{
+		{
+			description: "valid",
+			config:      &config{Salt: "salt", LogN: 15, DataKey: "key"},
+		},
+		{
+			description: "largest cost",
+			config:      &config{Salt: "salt", LogN: maxLogN, DataKey: "key"},
+		},
+		{
+			description: "cost too large",
+			config:      &config{Salt: "salt", LogN: maxLogN + 1, DataKey: "key"},
+			wantErr:     true,
+		},
+		{
+			description: "missing cost",
+			config:      &config{Salt: "salt", DataKey: "key"},
+			wantErr:     true,
+		},
+		{
+			description: "missing salt",
+			config:      &config{LogN: 15, DataKey: "key"},
+			wantErr:     true,
+		},
+		{
+			description: "missing data key",
+			config:      &config{Salt: "salt", LogN: 15},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tc.config.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("incorrect error: got %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wait provides a utility to wait for a condition to become true,
+// with configurable timeouts and polling intervals.
+package wait
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Waiter waits for a condition to become true. The condition is checked
+// repeatedly, with the interval between checks doubling from Interval up to
+// MaxInterval, until it is true or Timeout has elapsed.
+type Waiter struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Timeout     time.Duration
+}
+
+// Default is the Waiter used if no other configuration is supplied.
+var Default = Waiter{
+	Interval:    100 * time.Millisecond,
+	MaxInterval: 1 * time.Second,
+	Timeout:     10 * time.Second,
+}
+
+// Names of query parameters that override a Waiter's configuration. Values
+// are in the format accepted by time.ParseDuration.
+const (
+	IntervalParam    = "pollInterval"
+	MaxIntervalParam = "pollMaxInterval"
+	TimeoutParam     = "pollTimeout"
+)
+
+// Until checks done until it returns true, or the timeout elapses. It returns
+// true if done returned true.
+//
+// Until blocks; in Javascript, it must be invoked in a context in which
+// blocking is acceptable.
+func (w Waiter) Until(done func() bool) bool {
+	deadline := time.Now().Add(w.Timeout)
+	interval := w.Interval
+	for {
+		if done() {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(min(interval, time.Until(deadline)))
+		interval = min(2*interval, max(w.MaxInterval, w.Interval))
+	}
+}
+
+// WithParams returns a copy of w, with any configuration overridden by the
+// query parameters returned by get. get returns an empty string for a
+// parameter that is not present.
+func (w Waiter) WithParams(get func(param string) string) (Waiter, error) {
+	for _, p := range []struct {
+		param string
+		dst   *time.Duration
+	}{
+		{IntervalParam, &w.Interval},
+		{MaxIntervalParam, &w.MaxInterval},
+		{TimeoutParam, &w.Timeout},
+	} {
+		s := get(p.param)
+		if s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Waiter{}, fmt.Errorf("invalid %s: %w", p.param, err)
+		}
+		if d <= 0 {
+			return Waiter{}, fmt.Errorf("invalid %s: must be positive", p.param)
+		}
+		*p.dst = d
+	}
+	return w, nil
+}
+
+// Params returns query parameters that configure a Waiter identical to w
+// when supplied to WithParams.
+func (w Waiter) Params() url.Values {
+	return url.Values{
+		IntervalParam:    {w.Interval.String()},
+		MaxIntervalParam: {w.MaxInterval.String()},
+		TimeoutParam:     {w.Timeout.String()},
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wait
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUntil(t *testing.T) {
+	t.Parallel()
+
+	w := Waiter{
+		Interval:    time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+		Timeout:     200 * time.Millisecond,
+	}
+
+	testcases := []struct {
+		description string
+		trueAfter   int
+		want        bool
+		wantChecks  int
+	}{
+		{
+			description: "immediately true",
+			trueAfter:   0,
+			want:        true,
+			wantChecks:  1,
+		},
+		{
+			description: "eventually true",
+			trueAfter:   5,
+			want:        true,
+			wantChecks:  6,
+		},
+		{
+			description: "never true",
+			trueAfter:   -1,
+			want:        false,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			checks := 0
+			got := w.Until(func() bool {
+				checks++
+				return tc.trueAfter >= 0 && checks > tc.trueAfter
+			})
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect result; -got +want: %s", diff)
+			}
+			if tc.wantChecks > 0 {
+				if diff := cmp.Diff(checks, tc.wantChecks); diff != "" {
+					t.Errorf("incorrect number of checks; -got +want: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestUntilBacksOff(t *testing.T) {
+	t.Parallel()
+
+	w := Waiter{
+		Interval:    time.Millisecond,
+		MaxInterval: 50 * time.Millisecond,
+		Timeout:     300 * time.Millisecond,
+	}
+
+	// With a fixed interval, we would check roughly 300 times.  Backing
+	// off limits checks to roughly 6 doublings plus 5 maximum intervals.
+	checks := 0
+	w.Until(func() bool {
+		checks++
+		return false
+	})
+	if checks > 20 {
+		t.Errorf("too many checks: got %d, want at most 20", checks)
+	}
+}
+
+func TestWithParams(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		description string
+		query       string
+		want        Waiter
+		wantErr     bool
+	}{
+		{
+			description: "no overrides",
+			query:       "test",
+			want:        Default,
+		},
+		{
+			description: "override all",
+			query:       "pollInterval=10ms&pollMaxInterval=5s&pollTimeout=1m",
+			want: Waiter{
+				Interval:    10 * time.Millisecond,
+				MaxInterval: 5 * time.Second,
+				Timeout:     time.Minute,
+			},
+		},
+		{
+			description: "override timeout",
+			query:       "pollTimeout=30s",
+			want: Waiter{
+				Interval:    Default.Interval,
+				MaxInterval: Default.MaxInterval,
+				Timeout:     30 * time.Second,
+			},
+		},
+		{
+			description: "invalid duration",
+			query:       "pollTimeout=forever",
+			wantErr:     true,
+		},
+		{
+			description: "negative duration",
+			query:       "pollInterval=-1s",
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+			got, err := Default.WithParams(q.Get)
+			if diff := cmp.Diff(err != nil, tc.wantErr); diff != "" {
+				t.Errorf("incorrect error %v; -got +want: %s", err, diff)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect waiter; -got +want: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParams(t *testing.T) {
+	t.Parallel()
+
+	w := Waiter{
+		Interval:    10 * time.Millisecond,
+		MaxInterval: 5 * time.Second,
+		Timeout:     time.Minute,
+	}
+	got, err := Default.WithParams(w.Params().Get)
+	if err != nil {
+		t.Fatalf("failed to apply params: %v", err)
+	}
+	if diff := cmp.Diff(got, w); diff != "" {
+		t.Errorf("incorrect waiter; -got +want: %s", diff)
+	}
+}
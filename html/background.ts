@@ -20,6 +20,14 @@ const app = new WASMApp("../go/background/background.wasm");
 declare function handleOnMessage(message: any, sender: chrome.runtime.MessageSender, sendResponse: (message: any) => void): Promise<void>;
 declare function handleConnectionMessage(port: chrome.runtime.Port, message: any): Promise<void>;
 declare function handleConnectionDisconnect(port: chrome.runtime.Port): Promise<void>;
+declare function handleNotificationButtonClicked(notificationId: string, buttonIndex: number): Promise<void>;
+declare function handleNotificationClosed(notificationId: string): Promise<void>;
+declare function handleOmniboxInputChanged(text: string, suggest: (suggestResults: chrome.omnibox.SuggestResult[]) => void): Promise<void>;
+declare function handleOmniboxInputEntered(text: string): Promise<void>;
+declare function handleCommand(command: string): Promise<void>;
+declare function handleInstalled(details: chrome.runtime.InstalledDetails): Promise<void>;
+declare function handleStartup(): Promise<void>;
+declare function handleAlarm(alarm: chrome.alarms.Alarm): Promise<void>;
 
 // Workaround for https://github.com/w3c/ServiceWorker/issues/1499#issuecomment-578730536.
 // The cited issue illustrates limitation for Rust, but we have the same in Go.
@@ -56,3 +64,57 @@ chrome.runtime.onConnectExternal.addListener((port: chrome.runtime.Port) => {
 	port.onMessage.addListener((msg: any) => onConnectionMessage(port, msg));
 	port.onDisconnect.addListener((port: chrome.runtime.Port) => onConnectionDisconnect(port));
 });
+
+async function onNotificationButtonClicked(notificationId: string, buttonIndex: number) {
+	await app.waitInit()
+	return handleNotificationButtonClicked(notificationId, buttonIndex);
+}
+
+async function onNotificationClosed(notificationId: string) {
+	await app.waitInit()
+	return handleNotificationClosed(notificationId);
+}
+
+chrome.notifications.onButtonClicked.addListener((notificationId: string, buttonIndex: number) => onNotificationButtonClicked(notificationId, buttonIndex));
+chrome.notifications.onClosed.addListener((notificationId: string) => onNotificationClosed(notificationId));
+
+async function onOmniboxInputChanged(text: string, suggest: (suggestResults: chrome.omnibox.SuggestResult[]) => void) {
+	await app.waitInit()
+	return handleOmniboxInputChanged(text, suggest);
+}
+
+async function onOmniboxInputEntered(text: string) {
+	await app.waitInit()
+	return handleOmniboxInputEntered(text);
+}
+
+chrome.omnibox.onInputChanged.addListener((text: string, suggest: (suggestResults: chrome.omnibox.SuggestResult[]) => void) => onOmniboxInputChanged(text, suggest));
+chrome.omnibox.onInputEntered.addListener((text: string) => onOmniboxInputEntered(text));
+
+async function onCommand(command: string) {
+	await app.waitInit()
+	return handleCommand(command);
+}
+
+chrome.commands.onCommand.addListener((command: string) => onCommand(command));
+
+async function onInstalled(details: chrome.runtime.InstalledDetails) {
+	await app.waitInit()
+	return handleInstalled(details);
+}
+
+chrome.runtime.onInstalled.addListener((details: chrome.runtime.InstalledDetails) => onInstalled(details));
+
+async function onStartup() {
+	await app.waitInit()
+	return handleStartup();
+}
+
+chrome.runtime.onStartup.addListener(() => onStartup());
+
+async function onAlarm(alarm: chrome.alarms.Alarm) {
+	await app.waitInit()
+	return handleAlarm(alarm);
+}
+
+chrome.alarms.onAlarm.addListener((alarm: chrome.alarms.Alarm) => onAlarm(alarm));
@@ -0,0 +1,188 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/google/chrome-ssh-agent/go/testutil"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	// testClientID is the ID of the test client extension; it is determined
+	// by the key in its manifest.
+	testClientID = "imjlminfmijoggnggmmipnccpkhjoohk"
+)
+
+var (
+	testClientPath = filepath.Dir(testutil.MustRunfile("_main/test/e2e/testclient/manifest.json"))
+)
+
+// portConn exchanges SSH agent protocol messages with the agent over a
+// chrome.runtime.Port opened by the test client extension.
+//
+// Messages written to portConn are sent to the agent, and the agent's
+// responses are available to be read. portConn expects that each Write
+// contains a single complete message, as is the case for agent.NewClient().
+type portConn struct {
+	ctx     context.Context
+	agentID string
+	rsp     bytes.Buffer
+}
+
+func (p *portConn) Write(b []byte) (int, error) {
+	if len(b) < 4 || int(binary.BigEndian.Uint32(b)) != len(b)-4 {
+		return 0, fmt.Errorf("write must contain a single complete message")
+	}
+
+	// Byte slices are marshalled as base64 strings; send an array of
+	// numbers instead, as expected by the agent.
+	req := make([]int, len(b)-4)
+	for i, v := range b[4:] {
+		req[i] = int(v)
+	}
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var rsp []int
+	err = chromedp.Run(p.ctx,
+		chromedp.Evaluate(
+			fmt.Sprintf("agentRequest(%q, %s)", p.agentID, reqJSON),
+			&rsp,
+			func(ep *runtime.EvaluateParams) *runtime.EvaluateParams {
+				return ep.WithAwaitPromise(true)
+			},
+		),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+
+	l := make([]byte, 4)
+	binary.BigEndian.PutUint32(l, uint32(len(rsp)))
+	p.rsp.Write(l)
+	for _, v := range rsp {
+		p.rsp.WriteByte(byte(v))
+	}
+	return len(b), nil
+}
+
+func (p *portConn) Read(b []byte) (int, error) {
+	if p.rsp.Len() == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return p.rsp.Read(b)
+}
+
+func TestAgentPort(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name          string
+		extensionPath string
+		extensionID   string
+	}{
+		{
+			name:          "Prod Release",
+			extensionPath: testutil.MustRunfile("_main/chrome-ssh-agent.zip"),
+			extensionID:   "eechpbnaifiimgajnomdipfaamobdfha",
+		},
+		{
+			name:          "Beta Release",
+			extensionPath: testutil.MustRunfile("_main/chrome-ssh-agent-beta.zip"),
+			extensionID:   "onabphcdiffmanfdhkihllckikaljmhh",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Log("Preparing extension")
+			extPath, extCleanup, err := testutil.UnzipTemp(tc.extensionPath)
+			if err != nil {
+				t.Fatalf("Failed to unzip extension: %v", err)
+			}
+			defer extCleanup()
+			if err := allowExternalExtension(extPath, testClientID); err != nil {
+				t.Fatalf("Failed to allow test client: %v", err)
+			}
+
+			cctx, ccancel := newBrowser(t, extPath, testClientPath)
+			defer ccancel()
+
+			ctx, cancel := context.WithTimeout(cctx, *testTimeout)
+			defer cancel()
+
+			t.Log("Opening test client")
+			clientURL := makeExtensionURL(testClientID, "client.html", "")
+			err = chromedp.Run(ctx,
+				chromedp.Navigate(clientURL.String()),
+				chromedp.WaitReady("#ready"),
+			)
+			if err != nil {
+				t.Fatalf("failed to open test client: %v", err)
+			}
+
+			agt := agent.NewClient(&portConn{ctx: ctx, agentID: tc.extensionID})
+
+			t.Log("Adding key")
+			pub, priv, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatalf("failed to generate key: %v", err)
+			}
+			if err := agt.Add(agent.AddedKey{PrivateKey: priv, Comment: "e2e-key"}); err != nil {
+				t.Fatalf("failed to add key: %v", err)
+			}
+			sshPub, err := ssh.NewPublicKey(pub)
+			if err != nil {
+				t.Fatalf("failed to convert public key: %v", err)
+			}
+
+			t.Log("Requesting identities")
+			keys, err := agt.List()
+			if err != nil {
+				t.Fatalf("failed to list keys: %v", err)
+			}
+			if len(keys) != 1 || !bytes.Equal(keys[0].Marshal(), sshPub.Marshal()) {
+				t.Fatalf("incorrect keys listed: got %v, want key %s", keys, ssh.FingerprintSHA256(sshPub))
+			}
+
+			t.Log("Signing")
+			data := []byte("data to sign")
+			sig, err := agt.Sign(sshPub, data)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if err := sshPub.Verify(data, sig); err != nil {
+				t.Errorf("failed to verify signature: %v", err)
+			}
+
+			t.Log("Removing key")
+			if err := agt.RemoveAll(); err != nil {
+				t.Fatalf("failed to remove keys: %v", err)
+			}
+			keys, err = agt.List()
+			if err != nil {
+				t.Fatalf("failed to list keys: %v", err)
+			}
+			if len(keys) != 0 {
+				t.Errorf("incorrect keys listed after removal: got %v, want none", keys)
+			}
+		})
+	}
+}
@@ -4,21 +4,40 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"github.com/google/chrome-ssh-agent/go/testutil"
+	"github.com/google/chrome-ssh-agent/go/wait"
 )
 
 var (
 	chromePath = testutil.MustRunfile("+chrome+chrome_chrome_linux64/chrome-linux64/chrome")
+
+	// Timeouts may be increased on slow machines, for example:
+	//   bazel test //test/e2e --test_arg=-test_timeout=1m --test_arg=-poll_timeout=30s
+	testTimeout     = flag.Duration("test_timeout", 15*time.Second, "Maximum duration of each test")
+	pollInterval    = flag.Duration("poll_interval", wait.Default.Interval, "Initial interval at which the UI is polled during tests")
+	pollMaxInterval = flag.Duration("poll_max_interval", wait.Default.MaxInterval, "Maximum interval at which the UI is polled during tests")
+	pollTimeout     = flag.Duration("poll_timeout", wait.Default.Timeout, "Maximum time to wait for the UI to reflect each step of a test")
 )
 
+// waiter returns the Waiter to be used by tests running within the browser.
+func waiter() wait.Waiter {
+	return wait.Waiter{
+		Interval:    *pollInterval,
+		MaxInterval: *pollMaxInterval,
+		Timeout:     *pollTimeout,
+	}
+}
+
 type LogLevel int
 
 const (
@@ -116,6 +135,57 @@ func (l *logWriter) Write(p []byte) (n int, err error) {
 	return l.w.Write(p)
 }
 
+// newBrowser starts Chrome with the extensions at the supplied paths loaded.
+// The returned cancel function must be invoked to stop the browser.
+func newBrowser(t *testing.T, extPaths ...string) (context.Context, context.CancelFunc) {
+	execLogger := newLogWriter(t, LogInfo, "Process")
+
+	t.Log("Initializing Chrome")
+	chromeOpts := append(
+		chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.CombinedOutput(execLogger),
+		chromedp.ExecPath(chromePath),
+		// Specific headless mode that supports extensions. See:
+		//   https://bugs.chromium.org/p/chromium/issues/detail?id=706008#c36
+		//   https://bugs.chromium.org/p/chromium/issues/detail?id=706008#c42
+		chromedp.Flag("headless", "new"),
+		chromedp.Flag("disable-extensions-except", strings.Join(extPaths, ",")),
+		chromedp.Flag("load-extension", strings.Join(extPaths, ",")),
+		// https://chromium.googlesource.com/chromium/src/+/lkgr/docs/linux/debugging.md#logging
+		chromedp.Flag("enable-logging", "stderr"),
+		chromedp.Flag("log-level", "1"),
+		chromedp.Flag("vlog", "0"),
+		// Tests fail in certain environments when sandbox is enabled.
+		chromedp.Flag("no-sandbox", true),
+	)
+
+	actx, acancel := chromedp.NewExecAllocator(
+		context.Background(),
+		chromeOpts...,
+	)
+
+	cctx, ccancel := chromedp.NewContext(
+		actx,
+		chromedp.WithLogf(makeLogFunc(t, LogInfo, "Browser")),
+		chromedp.WithErrorf(makeLogFunc(t, LogError, "Browser")),
+	)
+
+	chromedp.ListenTarget(cctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			logConsole(t, ev)
+		case *runtime.EventExceptionThrown:
+			logException(t, ev)
+		}
+	})
+
+	return cctx, func() {
+		ccancel()
+		acancel()
+		execLogger.Close()
+	}
+}
+
 func TestWebApp(t *testing.T) {
 	t.Parallel()
 
@@ -148,54 +218,16 @@ func TestWebApp(t *testing.T) {
 			}
 			defer extCleanup()
 
-			execLogger := newLogWriter(t, LogInfo, "Process")
-			defer execLogger.Close()
-
-			t.Log("Initializing Chrome")
-			chromeOpts := append(
-				chromedp.DefaultExecAllocatorOptions[:],
-				chromedp.CombinedOutput(execLogger),
-				chromedp.ExecPath(chromePath),
-				// Specific headless mode that supports extensions. See:
-				//   https://bugs.chromium.org/p/chromium/issues/detail?id=706008#c36
-				//   https://bugs.chromium.org/p/chromium/issues/detail?id=706008#c42
-				chromedp.Flag("headless", "new"),
-				chromedp.Flag("disable-extensions-except", extPath),
-				// https://chromium.googlesource.com/chromium/src/+/lkgr/docs/linux/debugging.md#logging
-				chromedp.Flag("enable-logging", "stderr"),
-				chromedp.Flag("log-level", "1"),
-				chromedp.Flag("vlog", "0"),
-				// Tests fail in certain environments when sandbox is enabled.
-				chromedp.Flag("no-sandbox", true),
-			)
-
-			actx, acancel := chromedp.NewExecAllocator(
-				context.Background(),
-				chromeOpts...,
-			)
-			defer acancel()
-
-			cctx, ccancel := chromedp.NewContext(
-				actx,
-				chromedp.WithLogf(makeLogFunc(t, LogInfo, "Browser")),
-				chromedp.WithErrorf(makeLogFunc(t, LogError, "Browser")),
-			)
+			cctx, ccancel := newBrowser(t, extPath)
 			defer ccancel()
 
-			chromedp.ListenTarget(cctx, func(ev any) {
-				switch ev := ev.(type) {
-				case *runtime.EventConsoleAPICalled:
-					logConsole(t, ev)
-				case *runtime.EventExceptionThrown:
-					logException(t, ev)
-				}
-			})
-
-			ctx, cancel := context.WithTimeout(cctx, 15*time.Second)
+			ctx, cancel := context.WithTimeout(cctx, *testTimeout)
 			defer cancel()
 
 			t.Log("Running test")
-			extURL := makeExtensionURL(tc.extensionID, "html/options.html", "test")
+			query := waiter().Params()
+			query.Set("test", "")
+			extURL := makeExtensionURL(tc.extensionID, "html/options.html", query.Encode())
 			var failureCountTxt, failures string
 			err = chromedp.Run(ctx,
 				chromedp.Navigate(extURL.String()),
@@ -1,7 +1,11 @@
 package e2e
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 )
 
 func makeExtensionURL(extensionID string, relPath string, queryString string) *url.URL {
@@ -12,3 +16,33 @@ func makeExtensionURL(extensionID string, relPath string, queryString string) *u
 		RawQuery: queryString,
 	}
 }
+
+// allowExternalExtension updates the manifest of the unpacked extension at
+// extPath such that the extension with the specified ID may connect to it.
+func allowExternalExtension(extPath string, extensionID string) error {
+	path := filepath.Join(extPath, "manifest.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest map[string]any
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	ec, ok := manifest["externally_connectable"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("manifest does not define externally_connectable")
+	}
+	ids, _ := ec["ids"].([]any)
+	ec["ids"] = append(ids, extensionID)
+
+	data, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Minimal client that talks to the SSH Agent the same way the Secure Shell
+// extension does: over a chrome.runtime.Port, with each SSH agent protocol
+// message (minus its length prefix) carried in the 'data' field.
+//
+// End-to-end tests invoke agentRequest() to exchange messages with the agent.
+
+const messageType = 'auth-agent@openssh.com';
+
+let port = null;
+let pending = [];
+
+function connect(agentId) {
+  port = chrome.runtime.connect(agentId);
+  port.onMessage.addListener((msg) => {
+    const p = pending.shift();
+    if (p) {
+      p.resolve(msg.data);
+    }
+  });
+  port.onDisconnect.addListener(() => {
+    const err = new Error('disconnected: ' + JSON.stringify(chrome.runtime.lastError));
+    for (const p of pending) {
+      p.reject(err);
+    }
+    pending = [];
+    port = null;
+  });
+}
+
+// agentRequest sends a single request to the agent with the specified
+// extension ID, and returns the response. Requests and responses are arrays
+// of bytes.
+function agentRequest(agentId, data) {
+  if (port === null) {
+    connect(agentId);
+  }
+  return new Promise((resolve, reject) => {
+    pending.push({resolve, reject});
+    port.postMessage({type: messageType, data: data});
+  });
+}
+
+globalThis.agentRequest = agentRequest;
+document.getElementById('ready').textContent = 'ready';
@@ -0,0 +1,447 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Binary apischema emits a machine-readable description of the messages
+// exchanged with the extension's background worker.
+//
+// The Go definitions in go/keys and go/settings are the source of truth for
+// the messaging API. This tool parses those definitions and writes a JSON
+// document describing each message type, its numeric identifier, and its
+// fields, so that integrators (and any plain-Javascript client) can stay in
+// sync with them.
+//
+// Usage:
+//
+//	apischema -src_dir=go/keys,go/settings -out=html/api-schema.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	srcDir = flag.String("src_dir", ".", "Comma-separated directories containing the Go source defining the messages. Their messages are combined into a single schema.")
+	out    = flag.String("out", "", "Path to which the schema is written. Written to stdout if empty.")
+)
+
+const (
+	// schemaVersion is incremented whenever the format of the schema
+	// document itself changes (not when messages change).
+	schemaVersion = 2
+
+	// Naming conventions used for message definitions.
+	protocolVersionName = "protocolVersion"
+	msgTypePrefix       = "msgType"
+	requestPrefix       = "msg"
+	responsePrefix      = "rsp"
+	responseSuffix      = "Rsp"
+)
+
+// Field describes a single field within a message or type.
+type Field struct {
+	// Name is the name of the field on the wire (i.e., the 'js' tag).
+	Name string `json:"name"`
+	// Type is the type of the field. Primitive types are one of 'number',
+	// 'string' or 'boolean'. Arrays are suffixed with '[]'. Objects keyed
+	// by string are 'map[string]' followed by the type of their values.
+	// Other values refer to an entry in Schema.Types.
+	Type string `json:"type"`
+}
+
+// Message describes a single request or response message.
+type Message struct {
+	// Name is the name of the Go type defining the message.
+	Name string `json:"name"`
+	// Kind is either 'request' or 'response'.
+	Kind string `json:"kind"`
+	// TypeName is the name of the Go constant identifying the message.
+	TypeName string `json:"typeName"`
+	// Type is the value carried in the message's 'type' field.
+	Type int64 `json:"type"`
+	// Fields are the fields carried in the message.
+	Fields []*Field `json:"fields"`
+}
+
+// Type describes a structured type referenced by a message.
+type Type struct {
+	// Name is the name of the Go type.
+	Name string `json:"name"`
+	// Fields are the fields carried in the type.
+	Fields []*Field `json:"fields"`
+}
+
+// Schema is the top-level schema document.
+type Schema struct {
+	// SchemaVersion is the version of the schema document format.
+	SchemaVersion int `json:"schemaVersion"`
+	// ProtocolVersion is the version of the messaging API. It is carried in
+	// the 'version' field of every request and response, in addition to
+	// the fields listed for each message.
+	ProtocolVersion int64 `json:"protocolVersion"`
+	// Messages are all messages, ordered by type.
+	Messages []*Message `json:"messages"`
+	// Types are all structured types referenced from messages, ordered by
+	// name.
+	Types []*Type `json:"types"`
+}
+
+// parseDir parses all non-test Go files in the directory, ignoring build
+// constraints.
+func parseDir(fset *token.FileSet, dir string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var files []*ast.File
+	for _, p := range paths {
+		if strings.HasSuffix(p, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, p, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// evalConsts evaluates the protocol version, and all constants whose name
+// starts with msgTypePrefix.
+//
+// The const declarations are type-checked in isolation so that iota and
+// simple expressions are evaluated exactly as the compiler would, without
+// requiring the package's imports to be available.
+func evalConsts(fset *token.FileSet, files []*ast.File) (map[string]int64, error) {
+	var src bytes.Buffer
+	src.WriteString("package p\n")
+	for _, f := range files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST || !declaresConst(gd) {
+				continue
+			}
+			src.WriteString("\n")
+			if err := format.Node(&src, fset, gd); err != nil {
+				return nil, fmt.Errorf("failed to format const declaration: %w", err)
+			}
+			src.WriteString("\n")
+		}
+	}
+
+	cfset := token.NewFileSet()
+	cf, err := parser.ParseFile(cfset, "consts.go", src.Bytes(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse const declarations: %w", err)
+	}
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	var conf types.Config
+	if _, err := conf.Check("p", cfset, []*ast.File{cf}, info); err != nil {
+		return nil, fmt.Errorf("failed to evaluate const declarations: %w", err)
+	}
+
+	result := map[string]int64{}
+	for id, obj := range info.Defs {
+		c, ok := obj.(*types.Const)
+		if !ok || !isConst(id.Name) {
+			continue
+		}
+		v, ok := constantInt(c)
+		if !ok {
+			return nil, fmt.Errorf("constant %s is not an integer", id.Name)
+		}
+		result[id.Name] = v
+	}
+	return result, nil
+}
+
+func constantInt(c *types.Const) (int64, bool) {
+	var v int64
+	_, err := fmt.Sscan(c.Val().ExactString(), &v)
+	return v, err == nil
+}
+
+// isConst returns true if the named constant is one evaluated by evalConsts.
+func isConst(name string) bool {
+	return name == protocolVersionName || strings.HasPrefix(name, msgTypePrefix)
+}
+
+func declaresConst(gd *ast.GenDecl) bool {
+	for _, s := range gd.Specs {
+		for _, n := range s.(*ast.ValueSpec).Names {
+			if isConst(n.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structTypes returns all struct types declared in the files, keyed by name.
+func structTypes(files []*ast.File) map[string]*ast.StructType {
+	result := map[string]*ast.StructType{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			ts, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				result[ts.Name.Name] = st
+			}
+			return false
+		})
+	}
+	return result
+}
+
+// typeName describes the type expression. Named struct types declared in the
+// package are appended to refs.
+func typeName(expr ast.Expr, structs map[string]*ast.StructType, refs *[]string) (string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+			return "number", nil
+		case "string":
+			return "string", nil
+		case "bool":
+			return "boolean", nil
+		}
+		if _, ok := structs[e.Name]; ok {
+			*refs = append(*refs, e.Name)
+			return e.Name, nil
+		}
+		return "", fmt.Errorf("unsupported type %s", e.Name)
+	case *ast.StarExpr:
+		return typeName(e.X, structs, refs)
+	case *ast.ArrayType:
+		t, err := typeName(e.Elt, structs, refs)
+		if err != nil {
+			return "", err
+		}
+		return t + "[]", nil
+	case *ast.MapType:
+		if k, ok := e.Key.(*ast.Ident); !ok || k.Name != "string" {
+			return "", fmt.Errorf("unsupported map key type %T", e.Key)
+		}
+		t, err := typeName(e.Value, structs, refs)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + t, nil
+	default:
+		return "", fmt.Errorf("unsupported type expression %T", expr)
+	}
+}
+
+// fields describes the fields of a struct type. Fields without a 'js' tag are
+// not transmitted, and are omitted.
+func fields(st *ast.StructType, structs map[string]*ast.StructType, refs *[]string) ([]*Field, error) {
+	result := []*Field{}
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tag := reflect.StructTag(strings.Trim(f.Tag.Value, "`")).Get("js")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		t, err := typeName(f.Type, structs, refs)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", tag, err)
+		}
+		result = append(result, &Field{Name: tag, Type: t})
+	}
+	return result, nil
+}
+
+// msgTypeName returns the name of the constant identifying the message with the
+// given Go type name, and the kind of message.
+func msgTypeName(name string) (typeName, kind string, ok bool) {
+	switch {
+	case strings.HasPrefix(name, requestPrefix):
+		return msgTypePrefix + strings.TrimPrefix(name, requestPrefix), "request", true
+	case strings.HasPrefix(name, responsePrefix):
+		return msgTypePrefix + strings.TrimPrefix(name, responsePrefix) + responseSuffix, "response", true
+	}
+	return "", "", false
+}
+
+// Generate produces the schema for the messages defined in the supplied files.
+func Generate(fset *token.FileSet, files []*ast.File) (*Schema, error) {
+	consts, err := evalConsts(fset, files)
+	if err != nil {
+		return nil, err
+	}
+	version, ok := consts[protocolVersionName]
+	if !ok {
+		return nil, fmt.Errorf("constant %s is not defined", protocolVersionName)
+	}
+	structs := structTypes(files)
+
+	schema := &Schema{
+		SchemaVersion:   schemaVersion,
+		ProtocolVersion: version,
+		Messages:        []*Message{},
+		Types:           []*Type{},
+	}
+	var refs []string
+	for name, st := range structs {
+		tn, kind, ok := msgTypeName(name)
+		if !ok {
+			continue
+		}
+		v, ok := consts[tn]
+		if !ok {
+			continue
+		}
+		fs, err := fields(st, structs, &refs)
+		if err != nil {
+			return nil, fmt.Errorf("message %s: %w", name, err)
+		}
+		schema.Messages = append(schema.Messages, &Message{
+			Name:     name,
+			Kind:     kind,
+			TypeName: tn,
+			Type:     v,
+			Fields:   fs,
+		})
+	}
+	sort.Slice(schema.Messages, func(i, j int) bool {
+		return schema.Messages[i].Type < schema.Messages[j].Type
+	})
+
+	// Describe referenced types, including those referenced transitively.
+	seen := map[string]bool{}
+	for len(refs) > 0 {
+		name := refs[0]
+		refs = refs[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		fs, err := fields(structs[name], structs, &refs)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", name, err)
+		}
+		schema.Types = append(schema.Types, &Type{Name: name, Fields: fs})
+	}
+	sort.Slice(schema.Types, func(i, j int) bool {
+		return schema.Types[i].Name < schema.Types[j].Name
+	})
+
+	return schema, nil
+}
+
+// Merge combines schemas generated from separate packages into one. The
+// packages must use the same protocol version and distinct message types.
+// Types declared by more than one package are described once, and must have
+// the same fields in each.
+func Merge(schemas []*Schema) (*Schema, error) {
+	if len(schemas) == 0 {
+		return nil, errors.New("no schemas to merge")
+	}
+	result := &Schema{
+		SchemaVersion:   schemaVersion,
+		ProtocolVersion: schemas[0].ProtocolVersion,
+		Messages:        []*Message{},
+		Types:           []*Type{},
+	}
+	msgs := map[int64]*Message{}
+	types := map[string]*Type{}
+	for _, s := range schemas {
+		if s.ProtocolVersion != result.ProtocolVersion {
+			return nil, fmt.Errorf("inconsistent protocol versions %d and %d", result.ProtocolVersion, s.ProtocolVersion)
+		}
+		for _, m := range s.Messages {
+			if prev, ok := msgs[m.Type]; ok {
+				return nil, fmt.Errorf("messages %s and %s have the same type %d", prev.Name, m.Name, m.Type)
+			}
+			msgs[m.Type] = m
+			result.Messages = append(result.Messages, m)
+		}
+		for _, t := range s.Types {
+			if prev, ok := types[t.Name]; ok {
+				if !reflect.DeepEqual(prev.Fields, t.Fields) {
+					return nil, fmt.Errorf("type %s is declared with different fields", t.Name)
+				}
+				continue
+			}
+			types[t.Name] = t
+			result.Types = append(result.Types, t)
+		}
+	}
+	sort.Slice(result.Messages, func(i, j int) bool {
+		return result.Messages[i].Type < result.Messages[j].Type
+	})
+	sort.Slice(result.Types, func(i, j int) bool {
+		return result.Types[i].Name < result.Types[j].Name
+	})
+	return result, nil
+}
+
+func main() {
+	flag.Parse()
+
+	var schemas []*Schema
+	for _, dir := range strings.Split(*srcDir, ",") {
+		fset := token.NewFileSet()
+		files, err := parseDir(fset, dir)
+		if err != nil {
+			log.Fatalf("Failed to read source: %v", err)
+		}
+		s, err := Generate(fset, files)
+		if err != nil {
+			log.Fatalf("Failed to generate schema for %s: %v", dir, err)
+		}
+		schemas = append(schemas, s)
+	}
+
+	schema, err := Merge(schemas)
+	if err != nil {
+		log.Fatalf("Failed to merge schemas: %v", err)
+	}
+
+	buf, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal schema: %v", err)
+	}
+	buf = append(buf, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(buf)
+		return
+	}
+	if err := os.WriteFile(*out, buf, 0644); err != nil {
+		log.Fatalf("Failed to write schema: %v", err)
+	}
+}
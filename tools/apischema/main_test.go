@@ -0,0 +1,199 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testSrc = `
+package keys
+
+type Item struct {
+	ID   string ` + "`js:\"id\"`" + `
+	Tags []string ` + "`js:\"tags\"`" + `
+	Seen map[string]bool ` + "`js:\"seen\"`" + `
+	internal int
+}
+
+const protocolVersion = 3
+
+const (
+	msgTypeList int = 1000 + iota
+	msgTypeListRsp
+	msgTypeErrorRsp
+)
+
+type msgList struct {
+	Type int ` + "`js:\"type\"`" + `
+}
+
+type rspList struct {
+	Type  int     ` + "`js:\"type\"`" + `
+	Items []*Item ` + "`js:\"items\"`" + `
+	Err   string  ` + "`js:\"err\"`" + `
+}
+
+type rspError struct {
+	Type int    ` + "`js:\"type\"`" + `
+	Err  string ` + "`js:\"err\"`" + `
+}
+
+type notAMessage struct {
+	Type int ` + "`js:\"type\"`" + `
+}
+`
+
+func TestGenerate(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "src.go", testSrc, 0)
+	if err != nil {
+		t.Fatalf("failed to parse source: %v", err)
+	}
+
+	got, err := Generate(fset, []*ast.File{f})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	want := &Schema{
+		SchemaVersion:   schemaVersion,
+		ProtocolVersion: 3,
+		Messages: []*Message{
+			{
+				Name:     "msgList",
+				Kind:     "request",
+				TypeName: "msgTypeList",
+				Type:     1000,
+				Fields:   []*Field{{Name: "type", Type: "number"}},
+			},
+			{
+				Name:     "rspList",
+				Kind:     "response",
+				TypeName: "msgTypeListRsp",
+				Type:     1001,
+				Fields: []*Field{
+					{Name: "type", Type: "number"},
+					{Name: "items", Type: "Item[]"},
+					{Name: "err", Type: "string"},
+				},
+			},
+			{
+				Name:     "rspError",
+				Kind:     "response",
+				TypeName: "msgTypeErrorRsp",
+				Type:     1002,
+				Fields: []*Field{
+					{Name: "type", Type: "number"},
+					{Name: "err", Type: "string"},
+				},
+			},
+		},
+		Types: []*Type{
+			{
+				Name: "Item",
+				Fields: []*Field{
+					{Name: "id", Type: "string"},
+					{Name: "tags", Type: "string[]"},
+					{Name: "seen", Type: "map[string]boolean"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("incorrect schema; -got +want: %s", diff)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	item := &Type{Name: "Item", Fields: []*Field{{Name: "id", Type: "string"}}}
+	msg := func(name string, typ int64) *Message {
+		return &Message{Name: name, Kind: "request", TypeName: "msgType" + name, Type: typ, Fields: []*Field{}}
+	}
+
+	testcases := []struct {
+		description string
+		schemas     []*Schema
+		want        *Schema
+		wantErr     string
+	}{
+		{
+			description: "distinct messages and shared type",
+			schemas: []*Schema{
+				{ProtocolVersion: 2, Messages: []*Message{msg("Get", 2000)}, Types: []*Type{item}},
+				{ProtocolVersion: 2, Messages: []*Message{msg("List", 1000)}, Types: []*Type{item}},
+			},
+			want: &Schema{
+				SchemaVersion:   schemaVersion,
+				ProtocolVersion: 2,
+				Messages:        []*Message{msg("List", 1000), msg("Get", 2000)},
+				Types:           []*Type{item},
+			},
+		},
+		{
+			description: "inconsistent protocol versions",
+			schemas: []*Schema{
+				{ProtocolVersion: 1},
+				{ProtocolVersion: 2},
+			},
+			wantErr: "inconsistent protocol versions",
+		},
+		{
+			description: "duplicate message type",
+			schemas: []*Schema{
+				{ProtocolVersion: 1, Messages: []*Message{msg("Get", 1000)}},
+				{ProtocolVersion: 1, Messages: []*Message{msg("List", 1000)}},
+			},
+			wantErr: "have the same type 1000",
+		},
+		{
+			description: "conflicting type",
+			schemas: []*Schema{
+				{ProtocolVersion: 1, Types: []*Type{item}},
+				{ProtocolVersion: 1, Types: []*Type{{Name: "Item", Fields: []*Field{}}}},
+			},
+			wantErr: "type Item is declared with different fields",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Merge(tc.schemas)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("incorrect error: got %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("incorrect schema; -got +want: %s", diff)
+			}
+		})
+	}
+}